@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+	"github.com/Motmedel/utils_go/pkg/http/openapi/types/document_options"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	motmedelContextLogger "github.com/Motmedel/utils_go/pkg/log/context_logger"
+	errorLogger "github.com/Motmedel/utils_go/pkg/log/error_logger"
+)
+
+// A mux only exists in the program that builds it, so the document is rendered by a small program
+// that imports the package providing the mux and is run with `go run` from within its module.
+
+var programTemplate = template.Must(template.New("program").Parse(`package main
+
+import (
+	"fmt"
+	"os"
+
+	target {{ printf "%q" .ImportPath }}
+	"github.com/Motmedel/utils_go/pkg/http/openapi"
+	"github.com/Motmedel/utils_go/pkg/http/openapi/types/document_options"
+)
+
+func main() {
+	data, err := openapi.Render(
+		target.{{ .Expression }},
+		document_options.WithTitle({{ printf "%q" .Title }}),
+		document_options.WithVersion({{ printf "%q" .Version }}),
+		document_options.WithDescription({{ printf "%q" .Description }}),
+		document_options.WithIncludeStaticContent({{ .IncludeStaticContent }}),
+	)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	os.Stdout.Write(data)
+}
+`))
+
+type programInput struct {
+	ImportPath           string
+	Expression           string
+	Title                string
+	Version              string
+	Description          string
+	IncludeStaticContent bool
+}
+
+func makeProgram(input *programInput) ([]byte, error) {
+	if input == nil {
+		return nil, nil
+	}
+
+	var buffer bytes.Buffer
+	if err := programTemplate.Execute(&buffer, input); err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("template execute: %w", err), input)
+	}
+
+	program, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("format source: %w", err), buffer.Bytes())
+	}
+
+	return program, nil
+}
+
+func run() error {
+	var importPath string
+	flag.StringVar(&importPath, "package", "", "The import path of the package providing the mux.")
+
+	var variableName string
+	flag.StringVar(&variableName, "variable", "", "The name of an exported variable holding a *mux.Mux.")
+
+	var functionName string
+	flag.StringVar(&functionName, "function", "", "The name of an exported function returning a *mux.Mux.")
+
+	var title string
+	flag.StringVar(&title, "title", document_options.DefaultTitle, "The title of the API.")
+
+	var version string
+	flag.StringVar(&version, "version", document_options.DefaultVersion, "The version of the API.")
+
+	var description string
+	flag.StringVar(&description, "description", "", "The description of the API.")
+
+	var includeStaticContent bool
+	flag.BoolVar(
+		&includeStaticContent,
+		"include-static-content",
+		false,
+		"Whether to include the endpoints serving static content.",
+	)
+
+	var outputPath string
+	flag.StringVar(&outputPath, "output", "", "The path of the file to write the document to, rather than stdout.")
+
+	flag.Parse()
+
+	if importPath == "" {
+		return empty_error.New("package")
+	}
+
+	var expression string
+	switch {
+	case variableName != "" && functionName != "":
+		return motmedelErrors.NewWithTrace(errors.New("only one of variable and function may be set"))
+	case variableName != "":
+		expression = variableName
+	case functionName != "":
+		expression = functionName + "()"
+	default:
+		return empty_error.New("variable or function")
+	}
+
+	if name := variableName + functionName; !token.IsIdentifier(name) || !token.IsExported(name) {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("not an exported identifier: %q", name))
+	}
+
+	program, err := makeProgram(
+		&programInput{
+			ImportPath:           importPath,
+			Expression:           expression,
+			Title:                title,
+			Version:              version,
+			Description:          description,
+			IncludeStaticContent: includeStaticContent,
+		},
+	)
+	if err != nil {
+		return motmedelErrors.New(fmt.Errorf("make program: %w", err))
+	}
+
+	// NOTE: The program is placed within the working directory so that it is built as part of the
+	// module the mux is defined in.
+	directory, err := os.MkdirTemp(".", ".openapi-")
+	if err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("os mkdir temp: %w", err))
+	}
+	defer os.RemoveAll(directory)
+
+	programPath := filepath.Join(directory, "main.go")
+	if err := os.WriteFile(programPath, program, 0600); err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("os write file: %w", err), programPath)
+	}
+
+	var stdout bytes.Buffer
+	command := exec.Command("go", "run", programPath)
+	command.Stdout = &stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("exec command run: %w", err), programPath)
+	}
+
+	output := stdout.Bytes()
+
+	if outputPath != "" {
+		if err := os.WriteFile(outputPath, output, 0600); err != nil {
+			return motmedelErrors.NewWithTrace(fmt.Errorf("os write file: %w", err), outputPath, output)
+		}
+	} else {
+		fmt.Println(string(output))
+	}
+
+	return nil
+}
+
+func main() {
+	logger := errorLogger.Logger{
+		Logger: motmedelContextLogger.New(
+			slog.NewJSONHandler(os.Stderr, nil),
+			&motmedelLog.ErrorContextExtractor{},
+		),
+	}
+	slog.SetDefault(logger.Logger)
+
+	if err := run(); err != nil {
+		logger.FatalWithExitingMessage("An error occurred.", err)
+	}
+}
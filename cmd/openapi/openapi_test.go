@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+)
+
+func TestMakeProgram(t *testing.T) {
+	t.Parallel()
+
+	program, err := makeProgram(
+		&programInput{
+			ImportPath: "example.com/service/api",
+			Expression: "NewMux()",
+			Title:      "Service \"API\"",
+			Version:    "1.2.3",
+		},
+	)
+	if err != nil {
+		t.Fatalf("make program: %v", err)
+	}
+
+	for _, expected := range []string{
+		`target "example.com/service/api"`,
+		"target.NewMux(),",
+		`document_options.WithTitle("Service \"API\"")`,
+		`document_options.WithVersion("1.2.3")`,
+		"document_options.WithIncludeStaticContent(false)",
+	} {
+		if !strings.Contains(string(program), expected) {
+			t.Errorf("expected the program to contain %q:\n%s", expected, program)
+		}
+	}
+}
+
+func runMain(t *testing.T, args ...string) error {
+	t.Helper()
+
+	origArgs := os.Args
+	origCommandLine := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origCommandLine
+	}()
+
+	os.Args = append([]string{"openapi"}, args...)
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+
+	return run()
+}
+
+func TestRunErrors(t *testing.T) { //nolint:paralleltest // shares process-global state through run()
+	testCases := []struct {
+		name      string
+		args      []string
+		wantEmpty bool
+	}{
+		{name: "empty package", wantEmpty: true},
+		{name: "no mux", args: []string{"-package", "example.com/api"}, wantEmpty: true},
+		{name: "both", args: []string{"-package", "example.com/api", "-variable", "Mux", "-function", "NewMux"}},
+		{name: "unexported", args: []string{"-package", "example.com/api", "-variable", "mux"}},
+		{name: "not an identifier", args: []string{"-package", "example.com/api", "-function", "New()"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := runMain(t, testCase.args...)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if _, isEmpty := errors.AsType[*empty_error.Error](err); isEmpty != testCase.wantEmpty {
+				t.Fatalf("empty error: got %t, want %t (%v)", isEmpty, testCase.wantEmpty, err)
+			}
+		})
+	}
+}
@@ -62,6 +62,20 @@ func MakeBrotliData(ctx context.Context, data []byte) ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// Writer compresses what is written to it into an underlying writer.
+type Writer interface {
+	io.WriteCloser
+	// Flush compresses and writes out what has been written so far, so that a reader can decompress
+	// it without waiting for more.
+	Flush() error
+}
+
+// NewWriter returns a writer that compresses data into the provided writer at a quality from 0
+// (fastest) to 11 (smallest). The writer must be closed to complete the data.
+func NewWriter(writer io.Writer, quality int) Writer {
+	return brotli.NewWriterLevel(writer, quality)
+}
+
 // NewReader returns a reader that decompresses Brotli data from the provided
 // reader.
 func NewReader(reader io.Reader) io.Reader {
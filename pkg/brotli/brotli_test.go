@@ -51,3 +51,34 @@ func TestMakeBrotliDataBeatsSize(t *testing.T) {
 		t.Errorf("expected compression to reduce %d bytes, got %d", len(data), len(compressed))
 	}
 }
+
+func TestNewWriter(t *testing.T) {
+	t.Parallel()
+	data := []byte(strings.Repeat("streamed ", 100))
+
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer, 4)
+	if _, err := writer.Write(data[:400]); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if buffer.Len() == 0 {
+		t.Fatal("expected a flush to write out data")
+	}
+	if _, err := writer.Write(data[400:]); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	decompressed, err := io.ReadAll(NewReader(&buffer))
+	if err != nil {
+		t.Fatalf("read all: %v", err)
+	}
+	if !bytes.Equal(decompressed, data) {
+		t.Error("expected the decompressed data to equal the input")
+	}
+}
@@ -3,6 +3,7 @@ package artifact_registry
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/url"
 
 	"github.com/Motmedel/utils_go/pkg/cloud/gcp/artifact_registry/artifact_registry_config"
@@ -112,23 +113,33 @@ func (c *Client) ListReferrers(ctx context.Context, name string, digest string,
 	return idx, nil
 }
 
-// GetBlob downloads a blob by digest and returns the raw bytes.
-func (c *Client) GetBlob(ctx context.Context, name string, digest string, options ...fetch_config.Option) ([]byte, error) {
+// blobUrl returns the URL of a blob.
+func (c *Client) blobUrl(name string, digest string) (string, error) {
 	if name == "" {
-		return nil, motmedelErrors.NewWithTrace(empty_error.New("name"))
+		return "", motmedelErrors.NewWithTrace(empty_error.New("name"))
 	}
 	if digest == "" {
-		return nil, motmedelErrors.NewWithTrace(empty_error.New("digest"))
+		return "", motmedelErrors.NewWithTrace(empty_error.New("digest"))
+	}
+
+	u := *c.baseUrl
+	u.Path += name + "/blobs/" + digest
+
+	return u.String(), nil
+}
+
+// GetBlob downloads a blob by digest and returns the raw bytes. Use fetch_config.WithMaxResponseBytes
+// to bound the memory it takes, or GetBlobStream to not hold it in memory.
+func (c *Client) GetBlob(ctx context.Context, name string, digest string, options ...fetch_config.Option) ([]byte, error) {
+	urlString, err := c.blobUrl(name, digest)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := ctx.Err(); err != nil {
 		return nil, fmt.Errorf("context err: %w", err)
 	}
 
-	u := *c.baseUrl
-	u.Path += name + "/blobs/" + digest
-	urlString := u.String()
-
 	options = append(c.config.FetchOptions, options...)
 	_, responseBody, err := motmedelHttpUtils.Fetch(ctx, urlString, options...)
 	if err != nil {
@@ -137,3 +148,23 @@ func (c *Client) GetBlob(ctx context.Context, name string, digest string, option
 
 	return responseBody, nil
 }
+
+// GetBlobStream downloads a blob by digest as a stream, which the caller must close.
+func (c *Client) GetBlobStream(ctx context.Context, name string, digest string, options ...fetch_config.Option) (io.ReadCloser, error) {
+	urlString, err := c.blobUrl(name, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context err: %w", err)
+	}
+
+	options = append(c.config.FetchOptions, options...)
+	_, responseBody, err := motmedelHttpUtils.FetchStream(ctx, urlString, options...)
+	if err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("fetch stream: %w", err), urlString)
+	}
+
+	return responseBody, nil
+}
@@ -3,6 +3,7 @@ package artifact_registry
 import (
 	"context"
 	"encoding/json/v2"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -241,6 +242,38 @@ func TestGetBlob(t *testing.T) {
 	}
 }
 
+func TestGetBlobStream(t *testing.T) {
+	t.Parallel()
+
+	client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/v2/my-project/my-repo/my-image/blobs/sha256:layer123") {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		if _, err := w.Write([]byte(`{"spdxVersion":"SPDX-2.3"}`)); err != nil {
+			t.Errorf("write: %v", err)
+		}
+	})
+
+	body, err := client.GetBlobStream(context.Background(), "my-project/my-repo/my-image", "sha256:layer123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(data) != `{"spdxVersion":"SPDX-2.3"}` {
+		t.Errorf("unexpected blob content: %q", string(data))
+	}
+
+	if _, err := client.GetBlobStream(context.Background(), "my-project/my-repo/my-image", ""); err == nil {
+		t.Error("expected error for empty digest")
+	}
+}
+
 func TestGetBlob_EmptyName(t *testing.T) {
 	t.Parallel()
 
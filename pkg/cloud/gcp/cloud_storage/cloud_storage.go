@@ -8,6 +8,7 @@ import (
 	"encoding/json/v2"
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
@@ -188,24 +189,34 @@ func (c *Client) GetObject(ctx context.Context, bucketName string, objectName st
 	return obj, nil
 }
 
-// DownloadObject downloads an object's content.
-func (c *Client) DownloadObject(ctx context.Context, bucketName string, objectName string, options ...fetch_config.Option) ([]byte, error) {
+// mediaUrl returns the URL of an object's content.
+func (c *Client) mediaUrl(bucketName string, objectName string) (string, error) {
 	if bucketName == "" {
-		return nil, motmedelErrors.NewWithTrace(empty_error.New("bucket name"))
+		return "", motmedelErrors.NewWithTrace(empty_error.New("bucket name"))
 	}
 	if objectName == "" {
-		return nil, motmedelErrors.NewWithTrace(empty_error.New("object name"))
-	}
-
-	if err := ctx.Err(); err != nil {
-		return nil, fmt.Errorf("context err: %w", err)
+		return "", motmedelErrors.NewWithTrace(empty_error.New("object name"))
 	}
 
 	u := *c.baseUrl
 	u.RawPath = u.Path + "b/" + url.PathEscape(bucketName) + "/o/" + url.PathEscape(objectName)
 	u.Path += "b/" + bucketName + "/o/" + objectName
 	u.RawQuery = url.Values{"alt": {"media"}}.Encode()
-	urlString := u.String()
+
+	return u.String(), nil
+}
+
+// DownloadObject downloads an object's content. Use fetch_config.WithMaxResponseBytes to bound the
+// memory it takes, or DownloadObjectStream to not hold it in memory.
+func (c *Client) DownloadObject(ctx context.Context, bucketName string, objectName string, options ...fetch_config.Option) ([]byte, error) {
+	urlString, err := c.mediaUrl(bucketName, objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context err: %w", err)
+	}
 
 	options = append(c.config.FetchOptions, options...)
 	_, responseBody, err := motmedelHttpUtils.Fetch(ctx, urlString, options...)
@@ -216,6 +227,26 @@ func (c *Client) DownloadObject(ctx context.Context, bucketName string, objectNa
 	return responseBody, nil
 }
 
+// DownloadObjectStream downloads an object's content as a stream, which the caller must close.
+func (c *Client) DownloadObjectStream(ctx context.Context, bucketName string, objectName string, options ...fetch_config.Option) (io.ReadCloser, error) {
+	urlString, err := c.mediaUrl(bucketName, objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context err: %w", err)
+	}
+
+	options = append(c.config.FetchOptions, options...)
+	_, responseBody, err := motmedelHttpUtils.FetchStream(ctx, urlString, options...)
+	if err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("fetch stream: %w", err), urlString)
+	}
+
+	return responseBody, nil
+}
+
 // ListObjects lists objects in a bucket. Use the query parameter to specify prefix, delimiter,
 // maxResults, pageToken, and other query parameters.
 func (c *Client) ListObjects(ctx context.Context, bucketName string, query url.Values, options ...fetch_config.Option) (*object_list.ObjectList, error) {
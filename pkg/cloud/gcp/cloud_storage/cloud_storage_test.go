@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json/v2"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -16,6 +17,8 @@ import (
 	"github.com/Motmedel/utils_go/pkg/cloud/gcp/cloud_storage/types/bucket"
 	"github.com/Motmedel/utils_go/pkg/cloud/gcp/cloud_storage/types/object"
 	"github.com/Motmedel/utils_go/pkg/cloud/gcp/cloud_storage/types/object_list"
+	motmedelHttpErrors "github.com/Motmedel/utils_go/pkg/http/errors"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
 )
 
 // fakeSigner records the payload it was asked to sign and returns a fixed signature.
@@ -249,6 +252,57 @@ func TestDownloadObject(t *testing.T) {
 	}
 }
 
+func TestDownloadObjectStream(t *testing.T) {
+	t.Parallel()
+
+	client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("alt") != "media" {
+			t.Errorf("expected alt=media, got %q", r.URL.Query().Get("alt"))
+		}
+		if _, err := w.Write([]byte("file content here")); err != nil {
+			t.Errorf("write: %v", err)
+		}
+	})
+
+	body, err := client.DownloadObjectStream(context.Background(), "my-bucket", "my-file.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(data) != "file content here" {
+		t.Errorf("expected 'file content here', got %q", string(data))
+	}
+
+	if _, err := client.DownloadObjectStream(context.Background(), "", "my-file.txt"); err == nil {
+		t.Error("expected error for empty bucket name")
+	}
+}
+
+func TestDownloadObject_MaxResponseBytes(t *testing.T) {
+	t.Parallel()
+
+	client := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte("file content here")); err != nil {
+			t.Errorf("write: %v", err)
+		}
+	})
+
+	_, err := client.DownloadObject(
+		context.Background(),
+		"my-bucket",
+		"my-file.txt",
+		fetch_config.WithMaxResponseBytes(4),
+	)
+	if !errors.Is(err, motmedelHttpErrors.ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+}
+
 func TestDownloadObject_EmptyBucketName(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,727 @@
+// Package acme is a client of the Automatic Certificate Management Environment (RFC 8555), with the
+// HTTP-01 challenge it defines and the TLS-ALPN-01 challenge of RFC 8737.
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json/v2"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	motmedelEcdsa "github.com/Motmedel/utils_go/pkg/crypto/ecdsa"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/acme/acme_config"
+	acmeTypes "github.com/Motmedel/utils_go/pkg/http/acme/types"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
+	"github.com/Motmedel/utils_go/pkg/http/types/retry_after"
+	motmedelHttpUtils "github.com/Motmedel/utils_go/pkg/http/utils"
+	jwkKey "github.com/Motmedel/utils_go/pkg/json/jose/jwk/types/key"
+)
+
+const (
+	ContentType             = "application/jose+json"
+	ReplayNonceHeaderName   = "Replay-Nonce"
+	HttpChallengePathPrefix = "/.well-known/acme-challenge/"
+	// TlsAlpnProtocol is the protocol a TLS-ALPN-01 validation asks for, and is answered with a
+	// certificate of its own.
+	TlsAlpnProtocol = "acme-tls/1"
+)
+
+var (
+	ErrInvalidObject = errors.New("invalid acme object")
+	ErrNoChallenge   = errors.New("no supported acme challenge")
+)
+
+// oidAcmeIdentifier is the id-pe-acmeIdentifier extension of RFC 8737.
+var oidAcmeIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// maxBadNonceRetries is how many times a request rejected for its nonce is sent again, with a new
+// one. A server may reject any nonce, so one retry is not always enough.
+const maxBadNonceRetries = 3
+
+// Client talks to one ACME server as one account.
+type Client struct {
+	signer     *motmedelEcdsa.Method
+	jwk        *jwkKey.Key
+	thumbprint string
+	config     *acme_config.Config
+
+	mutex      sync.Mutex
+	directory  *acmeTypes.Directory
+	accountUrl string
+	nonces     []string
+}
+
+// Thumbprint is the JWK thumbprint (RFC 7638) of the account key, which key authorizations end
+// with.
+func (client *Client) Thumbprint() string {
+	return client.thumbprint
+}
+
+// KeyAuthorization is what proves control of the account key for a challenge token.
+func (client *Client) KeyAuthorization(token string) string {
+	return token + "." + client.thumbprint
+}
+
+func (client *Client) fetchOptions(options ...fetch_config.Option) []fetch_config.Option {
+	return append(append([]fetch_config.Option{fetch_config.WithSkipErrorOnStatus(true)}, options...), client.config.FetchOptions...)
+}
+
+func parseProblem(response *http.Response, body []byte) error {
+	problem := &acmeTypes.Problem{Status: response.StatusCode}
+	if err := json.Unmarshal(body, problem); err != nil || problem.Type == "" {
+		problem.Detail = strings.TrimSpace(string(body))
+	}
+	if problem.Status == 0 {
+		problem.Status = response.StatusCode
+	}
+
+	return problem
+}
+
+// Directory returns the server's directory, fetched once.
+func (client *Client) Directory(ctx context.Context) (*acmeTypes.Directory, error) {
+	client.mutex.Lock()
+	directory := client.directory
+	client.mutex.Unlock()
+	if directory != nil {
+		return directory, nil
+	}
+
+	directoryUrl := client.config.DirectoryUrl
+	response, body, err := motmedelHttpUtils.Fetch(ctx, directoryUrl, client.fetchOptions()...)
+	if err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("fetch (directory): %w", err), directoryUrl)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, motmedelErrors.NewWithTrace(parseProblem(response, body), directoryUrl)
+	}
+
+	directory = &acmeTypes.Directory{}
+	if err := json.Unmarshal(body, directory); err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("json unmarshal (directory): %w", err), body)
+	}
+	if directory.NewNonce == "" || directory.NewAccount == "" || directory.NewOrder == "" {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: directory lacks a resource", ErrInvalidObject),
+			directory,
+		)
+	}
+
+	client.mutex.Lock()
+	client.directory = directory
+	client.mutex.Unlock()
+
+	return directory, nil
+}
+
+func (client *Client) keepNonce(response *http.Response) {
+	if response == nil {
+		return
+	}
+
+	if nonce := response.Header.Get(ReplayNonceHeaderName); nonce != "" {
+		client.mutex.Lock()
+		client.nonces = append(client.nonces, nonce)
+		client.mutex.Unlock()
+	}
+}
+
+func (client *Client) nonce(ctx context.Context) (string, error) {
+	client.mutex.Lock()
+	if count := len(client.nonces); count != 0 {
+		nonce := client.nonces[count-1]
+		client.nonces = client.nonces[:count-1]
+		client.mutex.Unlock()
+		return nonce, nil
+	}
+	client.mutex.Unlock()
+
+	directory, err := client.Directory(ctx)
+	if err != nil {
+		return "", fmt.Errorf("directory: %w", err)
+	}
+
+	response, _, err := motmedelHttpUtils.Fetch(
+		ctx,
+		directory.NewNonce,
+		client.fetchOptions(fetch_config.WithMethod(http.MethodHead))...,
+	)
+	if err != nil {
+		return "", motmedelErrors.New(fmt.Errorf("fetch (new nonce): %w", err), directory.NewNonce)
+	}
+
+	nonce := response.Header.Get(ReplayNonceHeaderName)
+	if nonce == "" {
+		return "", motmedelErrors.NewWithTrace(empty_error.New("replay nonce"), directory.NewNonce)
+	}
+
+	return nonce, nil
+}
+
+// sign makes the flattened JWS (RFC 7515) a request to url is sent as. A nil payload makes a
+// POST-as-GET request. The account's URL, keyId, identifies a request; where there is no account
+// yet, the account key's JWK does.
+func (client *Client) sign(url string, nonce string, payload any, keyId string) ([]byte, error) {
+	protected := map[string]any{"alg": client.signer.GetName(), "nonce": nonce, "url": url}
+	if keyId == "" {
+		protected["jwk"] = client.jwk
+	} else {
+		protected["kid"] = keyId
+	}
+
+	protectedData, err := json.Marshal(protected)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("json marshal (protected header): %w", err))
+	}
+
+	var payloadData []byte
+	if payload != nil {
+		payloadData, err = json.Marshal(payload)
+		if err != nil {
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("json marshal (payload): %w", err), payload)
+		}
+	}
+
+	encodedProtected := base64.RawURLEncoding.EncodeToString(protectedData)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payloadData)
+
+	signature, err := client.signer.Sign([]byte(encodedProtected + "." + encodedPayload))
+	if err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("signer sign: %w", err))
+	}
+
+	data, err := json.Marshal(
+		map[string]string{
+			"protected": encodedProtected,
+			"payload":   encodedPayload,
+			"signature": base64.RawURLEncoding.EncodeToString(signature),
+		},
+	)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("json marshal (jws): %w", err))
+	}
+
+	return data, nil
+}
+
+// post sends a signed request, and answers an error status with the problem the server gave.
+func (client *Client) post(ctx context.Context, url string, payload any, useJwk bool) (*http.Response, []byte, error) {
+	if url == "" {
+		return nil, nil, motmedelErrors.NewWithTrace(empty_error.New("url"))
+	}
+
+	var keyId string
+	if !useJwk {
+		client.mutex.Lock()
+		keyId = client.accountUrl
+		client.mutex.Unlock()
+		if keyId == "" {
+			return nil, nil, motmedelErrors.NewWithTrace(empty_error.New("account url"))
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		nonce, err := client.nonce(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("nonce: %w", err)
+		}
+
+		body, err := client.sign(url, nonce, payload, keyId)
+		if err != nil {
+			return nil, nil, fmt.Errorf("sign: %w", err)
+		}
+
+		response, responseBody, err := motmedelHttpUtils.Fetch(
+			ctx,
+			url,
+			client.fetchOptions(
+				fetch_config.WithMethod(http.MethodPost),
+				fetch_config.WithHeaders(map[string]string{"Content-Type": ContentType}),
+				fetch_config.WithBody(body),
+			)...,
+		)
+		if err != nil {
+			return nil, nil, motmedelErrors.New(fmt.Errorf("fetch: %w", err), url)
+		}
+
+		client.keepNonce(response)
+
+		if response.StatusCode < http.StatusBadRequest {
+			return response, responseBody, nil
+		}
+
+		problemErr := parseProblem(response, responseBody)
+		var problem *acmeTypes.Problem
+		if errors.As(problemErr, &problem) && problem.Type == acmeTypes.ProblemTypeBadNonce && attempt < maxBadNonceRetries {
+			continue
+		}
+
+		return response, responseBody, motmedelErrors.NewWithTrace(problemErr, url)
+	}
+}
+
+func postObject[T any](ctx context.Context, client *Client, url string, payload any, useJwk bool) (*http.Response, *T, error) {
+	response, body, err := client.post(ctx, url, payload, useJwk)
+	if err != nil {
+		return response, nil, err
+	}
+
+	object := new(T)
+	if err := json.Unmarshal(body, object); err != nil {
+		return response, nil, motmedelErrors.NewWithTrace(fmt.Errorf("json unmarshal: %w", err), body)
+	}
+
+	return response, object, nil
+}
+
+// Register registers the account, or looks it up where the key already has one.
+func (client *Client) Register(ctx context.Context) (*acmeTypes.Account, error) {
+	directory, err := client.Directory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("directory: %w", err)
+	}
+
+	response, account, err := postObject[acmeTypes.Account](
+		ctx,
+		client,
+		directory.NewAccount,
+		&acmeTypes.Account{Contact: client.config.Contacts, TermsOfServiceAgreed: client.config.TermsOfServiceAgreed},
+		true,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("post object (new account): %w", err)
+	}
+
+	account.Url = response.Header.Get("Location")
+	if account.Url == "" {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("account location"))
+	}
+
+	client.mutex.Lock()
+	client.accountUrl = account.Url
+	client.mutex.Unlock()
+
+	return account, nil
+}
+
+// NewOrder orders a certificate for the DNS names.
+func (client *Client) NewOrder(ctx context.Context, names ...string) (*acmeTypes.Order, error) {
+	if len(names) == 0 {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("names"))
+	}
+
+	directory, err := client.Directory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("directory: %w", err)
+	}
+
+	identifiers := make([]*acmeTypes.Identifier, 0, len(names))
+	for _, name := range names {
+		identifiers = append(identifiers, &acmeTypes.Identifier{Type: acmeTypes.IdentifierTypeDns, Value: name})
+	}
+
+	response, order, err := postObject[acmeTypes.Order](
+		ctx,
+		client,
+		directory.NewOrder,
+		map[string]any{"identifiers": identifiers},
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("post object (new order): %w", err)
+	}
+
+	order.Url = response.Header.Get("Location")
+	if order.Url == "" {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("order location"))
+	}
+
+	return order, nil
+}
+
+// GetOrder fetches the order at url.
+func (client *Client) GetOrder(ctx context.Context, url string) (*acmeTypes.Order, error) {
+	_, order, err := postObject[acmeTypes.Order](ctx, client, url, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("post object (order): %w", err)
+	}
+	order.Url = url
+
+	return order, nil
+}
+
+// GetAuthorization fetches the authorization at url.
+func (client *Client) GetAuthorization(ctx context.Context, url string) (*acmeTypes.Authorization, error) {
+	_, authorization, err := postObject[acmeTypes.Authorization](ctx, client, url, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("post object (authorization): %w", err)
+	}
+	authorization.Url = url
+
+	return authorization, nil
+}
+
+// AcceptChallenge tells the server that the challenge is ready to be validated.
+func (client *Client) AcceptChallenge(ctx context.Context, challenge *acmeTypes.Challenge) (*acmeTypes.Challenge, error) {
+	if challenge == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("challenge"))
+	}
+
+	_, accepted, err := postObject[acmeTypes.Challenge](ctx, client, challenge.Url, struct{}{}, false)
+	if err != nil {
+		return nil, fmt.Errorf("post object (challenge): %w", err)
+	}
+
+	return accepted, nil
+}
+
+// wait polls until done says the object polled is settled, waiting as long as the server says to
+// between polls.
+func wait[T any](
+	ctx context.Context,
+	client *Client,
+	url string,
+	done func(*T) (bool, error),
+) (*T, error) {
+	for {
+		response, object, err := postObject[T](ctx, client, url, nil, false)
+		if err != nil {
+			return nil, fmt.Errorf("post object: %w", err)
+		}
+
+		settled, err := done(object)
+		if err != nil || settled {
+			return object, err
+		}
+
+		delay := client.config.PollInterval
+		if value := response.Header.Get("Retry-After"); value != "" {
+			if retryAfter, err := retry_after.Parse([]byte(value)); err == nil && retryAfter != nil {
+				switch waitTime := retryAfter.WaitTime.(type) {
+				case time.Time:
+					delay = time.Until(waitTime)
+				case time.Duration:
+					delay = waitTime
+				}
+			}
+		}
+
+		timer := time.NewTimer(max(delay, 0))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("context done: %w", ctx.Err()), url)
+		case <-timer.C:
+		}
+	}
+}
+
+// WaitAuthorization polls the authorization at url until it is valid, or fails.
+func (client *Client) WaitAuthorization(ctx context.Context, url string) (*acmeTypes.Authorization, error) {
+	authorization, err := wait(
+		ctx,
+		client,
+		url,
+		func(authorization *acmeTypes.Authorization) (bool, error) {
+			switch authorization.Status {
+			case acmeTypes.StatusValid:
+				return true, nil
+			case acmeTypes.StatusPending:
+				return false, nil
+			default:
+				var problem error = errors.New(authorization.Status)
+				for _, challenge := range authorization.Challenges {
+					if challenge != nil && challenge.Error != nil {
+						problem = challenge.Error
+					}
+				}
+				return true, motmedelErrors.NewWithTrace(
+					fmt.Errorf("%w: authorization %s: %w", ErrInvalidObject, authorization.Status, problem),
+					url,
+				)
+			}
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("wait (authorization): %w", err)
+	}
+	authorization.Url = url
+
+	return authorization, nil
+}
+
+// WaitOrder polls the order at url until it is ready to be finalized, or has been, or fails.
+func (client *Client) WaitOrder(ctx context.Context, url string) (*acmeTypes.Order, error) {
+	order, err := wait(
+		ctx,
+		client,
+		url,
+		func(order *acmeTypes.Order) (bool, error) {
+			switch order.Status {
+			case acmeTypes.StatusReady, acmeTypes.StatusValid:
+				return true, nil
+			case acmeTypes.StatusPending, acmeTypes.StatusProcessing:
+				return false, nil
+			default:
+				var problem error = errors.New(order.Status)
+				if order.Error != nil {
+					problem = order.Error
+				}
+				return true, motmedelErrors.NewWithTrace(
+					fmt.Errorf("%w: order %s: %w", ErrInvalidObject, order.Status, problem),
+					url,
+				)
+			}
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("wait (order): %w", err)
+	}
+	order.Url = url
+
+	return order, nil
+}
+
+// FinalizeOrder asks for the certificate of the order, with the DER-encoded certificate request.
+func (client *Client) FinalizeOrder(ctx context.Context, order *acmeTypes.Order, csr []byte) (*acmeTypes.Order, error) {
+	if order == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("order"))
+	}
+
+	_, finalized, err := postObject[acmeTypes.Order](
+		ctx,
+		client,
+		order.Finalize,
+		map[string]string{"csr": base64.RawURLEncoding.EncodeToString(csr)},
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("post object (finalize): %w", err)
+	}
+	finalized.Url = order.Url
+
+	return finalized, nil
+}
+
+// GetCertificate fetches the certificate chain at url, leaf first, as DER.
+func (client *Client) GetCertificate(ctx context.Context, url string) ([][]byte, error) {
+	_, body, err := client.post(ctx, url, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("post (certificate): %w", err)
+	}
+
+	var chain [][]byte
+	for rest := body; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			chain = append(chain, block.Bytes)
+		}
+	}
+	if len(chain) == 0 {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: no certificate in the chain", ErrInvalidObject),
+			body,
+		)
+	}
+
+	return chain, nil
+}
+
+// ObtainCertificate orders a certificate for the names and sees the order through: each
+// authorization is validated with the first of challengeTypes it offers, set up with solve, and
+// the order is finalized with a request signed by key.
+//
+// solve is called to prepare a challenge before the server is told to validate it; the function it
+// returns undoes what it prepared once the server is done.
+func (client *Client) ObtainCertificate(
+	ctx context.Context,
+	key *ecdsa.PrivateKey,
+	names []string,
+	challengeTypes []string,
+	solve func(authorization *acmeTypes.Authorization, challenge *acmeTypes.Challenge) (func(), error),
+) ([][]byte, error) {
+	if key == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("key"))
+	}
+
+	if solve == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("solve"))
+	}
+
+	order, err := client.NewOrder(ctx, names...)
+	if err != nil {
+		return nil, fmt.Errorf("new order: %w", err)
+	}
+
+	for _, authorizationUrl := range order.Authorizations {
+		authorization, err := client.GetAuthorization(ctx, authorizationUrl)
+		if err != nil {
+			return nil, fmt.Errorf("get authorization: %w", err)
+		}
+
+		if authorization.Status == acmeTypes.StatusValid {
+			continue
+		}
+
+		var challenge *acmeTypes.Challenge
+		for _, challengeType := range challengeTypes {
+			index := slices.IndexFunc(authorization.Challenges, func(candidate *acmeTypes.Challenge) bool {
+				return candidate != nil && candidate.Type == challengeType
+			})
+			if index != -1 {
+				challenge = authorization.Challenges[index]
+				break
+			}
+		}
+		if challenge == nil {
+			return nil, motmedelErrors.NewWithTrace(ErrNoChallenge, authorizationUrl, challengeTypes)
+		}
+
+		cleanup, err := solve(authorization, challenge)
+		if err != nil {
+			return nil, fmt.Errorf("solve: %w", err)
+		}
+
+		_, err = client.AcceptChallenge(ctx, challenge)
+		if err == nil {
+			_, err = client.WaitAuthorization(ctx, authorizationUrl)
+		}
+		if cleanup != nil {
+			cleanup()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("accept and wait for the challenge: %w", err)
+		}
+	}
+
+	csr, err := x509.CreateCertificateRequest(
+		rand.Reader,
+		&x509.CertificateRequest{Subject: pkix.Name{CommonName: names[0]}, DNSNames: names},
+		key,
+	)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("x509 create certificate request: %w", err), names)
+	}
+
+	if order, err = client.WaitOrder(ctx, order.Url); err != nil {
+		return nil, fmt.Errorf("wait order (ready): %w", err)
+	}
+
+	if order.Status != acmeTypes.StatusValid {
+		if order, err = client.FinalizeOrder(ctx, order, csr); err != nil {
+			return nil, fmt.Errorf("finalize order: %w", err)
+		}
+
+		if order.Status != acmeTypes.StatusValid {
+			if order, err = client.WaitOrder(ctx, order.Url); err != nil {
+				return nil, fmt.Errorf("wait order (valid): %w", err)
+			}
+		}
+	}
+
+	if order.Certificate == "" {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("order certificate"), order.Url)
+	}
+
+	chain, err := client.GetCertificate(ctx, order.Certificate)
+	if err != nil {
+		return nil, fmt.Errorf("get certificate: %w", err)
+	}
+
+	return chain, nil
+}
+
+// TlsAlpnChallengeCertificate makes the self-signed certificate a TLS-ALPN-01 validation of name is
+// answered with: one for the name alone, carrying the digest of the key authorization in a
+// critical acmeIdentifier extension.
+func (client *Client) TlsAlpnChallengeCertificate(name string, token string) (*tls.Certificate, error) {
+	if name == "" {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("name"))
+	}
+
+	digest := sha256.Sum256([]byte(client.KeyAuthorization(token)))
+	extensionValue, err := asn1.Marshal(digest[:])
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("asn1 marshal (acme identifier): %w", err))
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("ecdsa generate key: %w", err))
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("rand int: %w", err))
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:    serialNumber,
+		Subject:         pkix.Name{CommonName: name},
+		DNSNames:        []string{name},
+		NotBefore:       now.Add(-time.Hour),
+		NotAfter:        now.Add(24 * time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: oidAcmeIdentifier, Critical: true, Value: extensionValue}},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("x509 create certificate: %w", err), name)
+	}
+
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// New makes a client of the account whose key is accountKey. The account is not registered until
+// Register is called.
+func New(accountKey *ecdsa.PrivateKey, options ...acme_config.Option) (*Client, error) {
+	if accountKey == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("account key"))
+	}
+
+	signer, err := motmedelEcdsa.FromPrivateKey(accountKey)
+	if err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("ecdsa from private key: %w", err))
+	}
+
+	jwk, err := jwkKey.NewFromPublicKey(&accountKey.PublicKey, "", "", "")
+	if err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("jwk new from public key: %w", err))
+	}
+
+	thumbprint, err := jwk.ThumbprintSHA256()
+	if err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("jwk thumbprint sha256: %w", err))
+	}
+
+	return &Client{
+		signer:     signer,
+		jwk:        jwk,
+		thumbprint: thumbprint,
+		config:     acme_config.New(options...),
+	}, nil
+}
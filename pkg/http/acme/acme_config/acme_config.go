@@ -0,0 +1,74 @@
+package acme_config
+
+import (
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
+)
+
+const (
+	LetsEncryptDirectoryUrl        = "https://acme-v02.api.letsencrypt.org/directory"
+	LetsEncryptStagingDirectoryUrl = "https://acme-staging-v02.api.letsencrypt.org/directory"
+
+	DefaultDirectoryUrl = LetsEncryptDirectoryUrl
+	// DefaultPollInterval is how long to wait between polls of a pending object, where the server
+	// does not say with Retry-After.
+	DefaultPollInterval = 2 * time.Second
+)
+
+type Config struct {
+	DirectoryUrl string
+	// Contacts are the URLs, commonly "mailto:" ones, the account is registered with.
+	Contacts []string
+	// TermsOfServiceAgreed says that the terms of service of the server are agreed to, which
+	// registering an account commonly requires.
+	TermsOfServiceAgreed bool
+	PollInterval         time.Duration
+	// FetchOptions are applied to every request to the server; an HTTP client that trusts a test
+	// server's root, for one.
+	FetchOptions []fetch_config.Option
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		DirectoryUrl: DefaultDirectoryUrl,
+		PollInterval: DefaultPollInterval,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	return config
+}
+
+func WithDirectoryUrl(directoryUrl string) Option {
+	return func(config *Config) {
+		config.DirectoryUrl = directoryUrl
+	}
+}
+
+func WithContacts(contacts ...string) Option {
+	return func(config *Config) {
+		config.Contacts = contacts
+	}
+}
+
+func WithTermsOfServiceAgreed(termsOfServiceAgreed bool) Option {
+	return func(config *Config) {
+		config.TermsOfServiceAgreed = termsOfServiceAgreed
+	}
+}
+
+func WithPollInterval(pollInterval time.Duration) Option {
+	return func(config *Config) {
+		config.PollInterval = pollInterval
+	}
+}
+
+func WithFetchOptions(fetchOptions ...fetch_config.Option) Option {
+	return func(config *Config) {
+		config.FetchOptions = append(config.FetchOptions, fetchOptions...)
+	}
+}
@@ -0,0 +1,57 @@
+package acme_config
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.DirectoryUrl != DefaultDirectoryUrl {
+		t.Errorf("directory url: got %q", config.DirectoryUrl)
+	}
+	if config.PollInterval != DefaultPollInterval {
+		t.Errorf("poll interval: got %v", config.PollInterval)
+	}
+	if config.TermsOfServiceAgreed {
+		t.Error("expected the terms of service not to be agreed to by default")
+	}
+	if config.Contacts != nil || config.FetchOptions != nil {
+		t.Errorf("expected no contacts or fetch options, got %v %v", config.Contacts, config.FetchOptions)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	config := New(
+		WithDirectoryUrl(LetsEncryptStagingDirectoryUrl),
+		WithContacts("mailto:admin@example.com"),
+		WithTermsOfServiceAgreed(true),
+		WithPollInterval(time.Millisecond),
+		WithFetchOptions(fetch_config.WithHttpClient(&http.Client{})),
+		WithFetchOptions(fetch_config.WithSkipErrorOnStatus(true)),
+	)
+
+	if config.DirectoryUrl != LetsEncryptStagingDirectoryUrl {
+		t.Errorf("directory url: got %q", config.DirectoryUrl)
+	}
+	if !slices.Equal(config.Contacts, []string{"mailto:admin@example.com"}) {
+		t.Errorf("contacts: got %v", config.Contacts)
+	}
+	if !config.TermsOfServiceAgreed {
+		t.Error("expected the terms of service to be agreed to")
+	}
+	if config.PollInterval != time.Millisecond {
+		t.Errorf("poll interval: got %v", config.PollInterval)
+	}
+	if len(config.FetchOptions) != 2 {
+		t.Errorf("expected the fetch options to accumulate, got %d", len(config.FetchOptions))
+	}
+}
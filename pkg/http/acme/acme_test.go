@@ -0,0 +1,264 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/acme/acme_config"
+	acmeTypes "github.com/Motmedel/utils_go/pkg/http/acme/types"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
+	"github.com/Motmedel/utils_go/pkg/testing/acme_server"
+)
+
+// solver answers the challenges of the stand-in server with what it is told to.
+type solver struct {
+	mutex            sync.Mutex
+	keyAuthorization map[string]string
+	certificates     map[string]*tls.Certificate
+}
+
+func (solver *solver) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	solver.mutex.Lock()
+	keyAuthorization, found := solver.keyAuthorization[strings.TrimPrefix(request.URL.Path, HttpChallengePathPrefix)]
+	solver.mutex.Unlock()
+
+	if !found {
+		http.NotFound(responseWriter, request)
+		return
+	}
+
+	_, _ = responseWriter.Write([]byte(keyAuthorization))
+}
+
+func newStandIn(t *testing.T) (*acme_server.Server, *solver) {
+	t.Helper()
+
+	server, err := acme_server.New()
+	if err != nil {
+		t.Fatalf("acme server new: %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	solver := &solver{keyAuthorization: map[string]string{}, certificates: map[string]*tls.Certificate{}}
+
+	httpServer := httptest.NewServer(solver)
+	t.Cleanup(httpServer.Close)
+	server.SetHttpChallengeAddress(httpServer.Listener.Addr().String())
+
+	listener, err := tls.Listen(
+		"tcp",
+		"127.0.0.1:0",
+		&tls.Config{
+			NextProtos: []string{TlsAlpnProtocol},
+			GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+				solver.mutex.Lock()
+				defer solver.mutex.Unlock()
+				return solver.certificates[hello.ServerName], nil
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("tls listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			connection, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(connection net.Conn) {
+				_ = connection.(*tls.Conn).Handshake()
+				_ = connection.Close()
+			}(connection)
+		}
+	}()
+	server.SetTlsChallengeAddress(listener.Addr().String())
+
+	return server, solver
+}
+
+func newClient(t *testing.T, server *acme_server.Server) *Client {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa generate key: %v", err)
+	}
+
+	client, err := New(
+		key,
+		acme_config.WithDirectoryUrl(server.DirectoryUrl),
+		acme_config.WithTermsOfServiceAgreed(true),
+		acme_config.WithContacts("mailto:admin@example.com"),
+		acme_config.WithPollInterval(10*time.Millisecond),
+		acme_config.WithFetchOptions(fetch_config.WithHttpClient(server.Client())),
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	return client
+}
+
+func TestClient_Register(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newStandIn(t)
+	client := newClient(t, server)
+
+	account, err := client.Register(t.Context())
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if account.Url == "" || account.Status != acmeTypes.StatusValid {
+		t.Fatalf("unexpected account: %#v", account)
+	}
+
+	// Registering again looks the account up.
+	again, err := client.Register(t.Context())
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if again.Url != account.Url {
+		t.Errorf("account url: got %q, want %q", again.Url, account.Url)
+	}
+}
+
+func TestClient_BadNonce(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newStandIn(t)
+	client := newClient(t, server)
+
+	// A nonce the server never gave is rejected, and the request is sent again with a fresh one.
+	client.nonces = []string{"made-up", "made-up"}
+
+	if _, err := client.Register(t.Context()); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+}
+
+func TestClient_ObtainCertificate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		challengeTypes []string
+		tamper         bool
+		expectedErr    error
+	}{
+		{name: "http-01", challengeTypes: []string{acmeTypes.ChallengeTypeHttp01}},
+		{name: "tls-alpn-01", challengeTypes: []string{acmeTypes.ChallengeTypeTlsAlpn01}},
+		{name: "preferred", challengeTypes: []string{acmeTypes.ChallengeTypeDns01, acmeTypes.ChallengeTypeTlsAlpn01}},
+		{name: "unsupported", challengeTypes: []string{acmeTypes.ChallengeTypeDns01}, expectedErr: ErrNoChallenge},
+		{name: "failed validation", challengeTypes: []string{acmeTypes.ChallengeTypeHttp01}, tamper: true, expectedErr: ErrInvalidObject},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, solver := newStandIn(t)
+			client := newClient(t, server)
+
+			if _, err := client.Register(t.Context()); err != nil {
+				t.Fatalf("register: %v", err)
+			}
+
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err != nil {
+				t.Fatalf("ecdsa generate key: %v", err)
+			}
+
+			var cleanedUp bool
+			chain, err := client.ObtainCertificate(
+				t.Context(),
+				key,
+				[]string{"example.com"},
+				testCase.challengeTypes,
+				func(authorization *acmeTypes.Authorization, challenge *acmeTypes.Challenge) (func(), error) {
+					keyAuthorization := client.KeyAuthorization(challenge.Token)
+					if testCase.tamper {
+						keyAuthorization += "x"
+					}
+
+					solver.mutex.Lock()
+					defer solver.mutex.Unlock()
+
+					switch challenge.Type {
+					case acmeTypes.ChallengeTypeHttp01:
+						solver.keyAuthorization[challenge.Token] = keyAuthorization
+					case acmeTypes.ChallengeTypeTlsAlpn01:
+						certificate, err := client.TlsAlpnChallengeCertificate(authorization.Identifier.Value, challenge.Token)
+						if err != nil {
+							return nil, err
+						}
+						solver.certificates[authorization.Identifier.Value] = certificate
+					}
+
+					return func() { cleanedUp = true }, nil
+				},
+			)
+			if testCase.expectedErr != nil {
+				if !errors.Is(err, testCase.expectedErr) {
+					t.Fatalf("error: got %v, want %v", err, testCase.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("obtain certificate: %v", err)
+			}
+
+			if !cleanedUp {
+				t.Error("the challenge was not cleaned up")
+			}
+			if len(chain) != 2 {
+				t.Fatalf("expected a leaf and a root, got %d certificates", len(chain))
+			}
+
+			leaf, err := x509.ParseCertificate(chain[0])
+			if err != nil {
+				t.Fatalf("x509 parse certificate: %v", err)
+			}
+			if _, err := leaf.Verify(x509.VerifyOptions{DNSName: "example.com", Roots: server.Roots()}); err != nil {
+				t.Errorf("verify: %v", err)
+			}
+			if !leaf.PublicKey.(*ecdsa.PublicKey).Equal(&key.PublicKey) {
+				t.Error("the certificate is not for the key")
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(nil); err == nil {
+		t.Error("expected an error for a nil account key")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa generate key: %v", err)
+	}
+
+	client, err := New(key)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if client.Thumbprint() == "" || client.KeyAuthorization("token") != "token."+client.Thumbprint() {
+		t.Errorf("key authorization: got %q", client.KeyAuthorization("token"))
+	}
+}
@@ -0,0 +1,20 @@
+package cache
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrCacheMiss is what Get returns for a name nothing is cached at.
+var ErrCacheMiss = errors.New("acme cache miss")
+
+// Cache holds what certificate management keeps between runs: the account key, and the certificates
+// with their keys. What is cached is secret. A cache that several replicas share makes them share
+// the account and the certificates, rather than each ordering its own.
+type Cache interface {
+	// Get returns the data cached at name, or an error wrapping ErrCacheMiss.
+	Get(ctx context.Context, name string) ([]byte, error)
+	Put(ctx context.Context, name string, data []byte) error
+	// Delete removes the data cached at name; deleting what is not there is not an error.
+	Delete(ctx context.Context, name string) error
+}
@@ -0,0 +1,118 @@
+package directory_cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+	"github.com/Motmedel/utils_go/pkg/http/acme/cache"
+)
+
+// Cache keeps each name in a file of its own in a directory. The directory and the files are
+// readable by the owner only, what is cached being secret.
+type Cache struct {
+	Directory string
+}
+
+func (directoryCache *Cache) path(name string) (string, error) {
+	if name == "" {
+		return "", motmedelErrors.NewWithTrace(empty_error.New("name"))
+	}
+
+	// A name is a host or a fixed name; anything that would reach outside the directory is not one.
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: cache name", motmedelErrors.ErrValidationError),
+			name,
+		)
+	}
+
+	return filepath.Join(directoryCache.Directory, name), nil
+}
+
+func (directoryCache *Cache) Get(ctx context.Context, name string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context err: %w", err)
+	}
+
+	path, err := directoryCache.path(name)
+	if err != nil {
+		return nil, fmt.Errorf("path: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("%w: %w", cache.ErrCacheMiss, err), path)
+		}
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("os read file: %w", err), path)
+	}
+
+	return data, nil
+}
+
+// Put writes the data to a temporary file that is then renamed into place, so that a reader never
+// sees a partly written one.
+func (directoryCache *Cache) Put(ctx context.Context, name string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context err: %w", err)
+	}
+
+	path, err := directoryCache.path(name)
+	if err != nil {
+		return fmt.Errorf("path: %w", err)
+	}
+
+	if err := os.MkdirAll(directoryCache.Directory, 0700); err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("os mkdir all: %w", err), directoryCache.Directory)
+	}
+
+	file, err := os.CreateTemp(directoryCache.Directory, "."+name+".*")
+	if err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("os create temp: %w", err), directoryCache.Directory)
+	}
+	temporaryPath := file.Name()
+	defer os.Remove(temporaryPath)
+
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return motmedelErrors.NewWithTrace(fmt.Errorf("file write: %w", err), temporaryPath)
+	}
+
+	if err := file.Close(); err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("file close: %w", err), temporaryPath)
+	}
+
+	if err := os.Rename(temporaryPath, path); err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("os rename: %w", err), temporaryPath, path)
+	}
+
+	return nil
+}
+
+func (directoryCache *Cache) Delete(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context err: %w", err)
+	}
+
+	path, err := directoryCache.path(name)
+	if err != nil {
+		return fmt.Errorf("path: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("os remove: %w", err), path)
+	}
+
+	return nil
+}
+
+func New(directory string) *Cache {
+	return &Cache{Directory: directory}
+}
@@ -0,0 +1,71 @@
+package directory_cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Motmedel/utils_go/pkg/http/acme/cache"
+)
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	directory := filepath.Join(t.TempDir(), "acme")
+	directoryCache := New(directory)
+
+	if _, err := directoryCache.Get(t.Context(), "example.com"); !errors.Is(err, cache.ErrCacheMiss) {
+		t.Fatalf("get: got %v, want a cache miss", err)
+	}
+
+	if err := directoryCache.Put(t.Context(), "example.com", []byte("first")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := directoryCache.Put(t.Context(), "example.com", []byte("second")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	data, err := directoryCache.Get(t.Context(), "example.com")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if string(data) != "second" {
+		t.Errorf("data: got %q", data)
+	}
+
+	info, err := os.Stat(directory)
+	if err != nil {
+		t.Fatalf("os stat: %v", err)
+	}
+	if permissions := info.Mode().Perm(); permissions != 0700 {
+		t.Errorf("directory permissions: got %o", permissions)
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatalf("os read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary file to be left behind, got %v", entries)
+	}
+	if info, err := entries[0].Info(); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("file permissions: got %v %v", info, err)
+	}
+
+	if err := directoryCache.Delete(t.Context(), "example.com"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := directoryCache.Delete(t.Context(), "example.com"); err != nil {
+		t.Fatalf("delete (again): %v", err)
+	}
+	if _, err := directoryCache.Get(t.Context(), "example.com"); !errors.Is(err, cache.ErrCacheMiss) {
+		t.Fatalf("get: got %v, want a cache miss", err)
+	}
+
+	for _, name := range []string{"", ".", "..", "../escape", `a\b`} {
+		if err := directoryCache.Put(t.Context(), name, nil); err == nil {
+			t.Errorf("put %q: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package memory_cache
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/http/acme/cache"
+)
+
+// Cache is an in-process cache, for tests and for a service that orders anew whenever it starts.
+type Cache struct {
+	mutex sync.Mutex
+	data  map[string][]byte
+}
+
+func (memoryCache *Cache) Get(_ context.Context, name string) ([]byte, error) {
+	memoryCache.mutex.Lock()
+	defer memoryCache.mutex.Unlock()
+
+	data, found := memoryCache.data[name]
+	if !found {
+		return nil, motmedelErrors.NewWithTrace(cache.ErrCacheMiss, name)
+	}
+
+	return slices.Clone(data), nil
+}
+
+func (memoryCache *Cache) Put(_ context.Context, name string, data []byte) error {
+	memoryCache.mutex.Lock()
+	defer memoryCache.mutex.Unlock()
+
+	memoryCache.data[name] = slices.Clone(data)
+
+	return nil
+}
+
+func (memoryCache *Cache) Delete(_ context.Context, name string) error {
+	memoryCache.mutex.Lock()
+	defer memoryCache.mutex.Unlock()
+
+	delete(memoryCache.data, name)
+
+	return nil
+}
+
+func New() *Cache {
+	return &Cache{data: make(map[string][]byte)}
+}
@@ -0,0 +1,42 @@
+package memory_cache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Motmedel/utils_go/pkg/http/acme/cache"
+)
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	memoryCache := New()
+
+	if _, err := memoryCache.Get(t.Context(), "example.com"); !errors.Is(err, cache.ErrCacheMiss) {
+		t.Fatalf("get: got %v, want a cache miss", err)
+	}
+
+	stored := []byte("data")
+	if err := memoryCache.Put(t.Context(), "example.com", stored); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	stored[0] = 'x'
+
+	data, err := memoryCache.Get(t.Context(), "example.com")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if string(data) != "data" {
+		t.Errorf("expected the cached data to be a copy, got %q", data)
+	}
+
+	if err := memoryCache.Delete(t.Context(), "example.com"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := memoryCache.Delete(t.Context(), "example.com"); err != nil {
+		t.Fatalf("delete (absent): %v", err)
+	}
+	if _, err := memoryCache.Get(t.Context(), "example.com"); !errors.Is(err, cache.ErrCacheMiss) {
+		t.Fatalf("get: got %v, want a cache miss after delete", err)
+	}
+}
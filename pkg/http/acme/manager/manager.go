@@ -0,0 +1,610 @@
+package manager
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	motmedelContext "github.com/Motmedel/utils_go/pkg/context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/acme"
+	"github.com/Motmedel/utils_go/pkg/http/acme/cache"
+	"github.com/Motmedel/utils_go/pkg/http/acme/manager/manager_config"
+	acmeTypes "github.com/Motmedel/utils_go/pkg/http/acme/types"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail"
+	"github.com/Motmedel/utils_go/pkg/tls/ocsp"
+)
+
+// AccountKeyCacheName is the name the account key is cached at. The certificates are cached at
+// their hosts, which a "+" keeps this from being.
+const AccountKeyCacheName = "acme_account+key"
+
+var (
+	ErrHostNotAllowed       = errors.New("host not allowed")
+	ErrNoChallengeResponse  = errors.New("no challenge response")
+	ErrUnsupportedChallenge = errors.New("unsupported challenge type")
+	ErrInvalidCachedData    = errors.New("invalid cached data")
+	ErrCertificateRevoked   = errors.New("certificate revoked")
+)
+
+type certificateState struct {
+	// certificate is the certificate served, with its Leaf set; nil until one is obtained.
+	certificate *tls.Certificate
+	// done is closed when the obtaining in progress finishes; nil when none is in progress.
+	done     chan struct{}
+	err      error
+	failedAt time.Time
+
+	renewing        bool
+	renewNotBefore  time.Time
+	revoked         bool
+	stapling        bool
+	stapleNotBefore time.Time
+}
+
+// Manager obtains certificates from an ACME server for the hosts it is configured with, as
+// handshakes ask for them, and keeps them renewed and their OCSP responses stapled. The challenges
+// are answered by the manager itself: TLS-ALPN-01 through GetCertificate, HTTP-01 through the
+// endpoint of HttpChallengeEndpoint.
+type Manager struct {
+	config *manager_config.Config
+	hosts  map[string]struct{}
+
+	clientMutex sync.Mutex
+	client      *acme.Client
+
+	mutex                 sync.Mutex
+	states                map[string]*certificateState
+	keyAuthorizations     map[string]string
+	challengeCertificates map[string]*tls.Certificate
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+func encodeCertificate(certificate *tls.Certificate) ([]byte, error) {
+	key, ok := certificate.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: private key", motmedelErrors.ErrConversionNotOk),
+			certificate.PrivateKey,
+		)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("x509 marshal ec private key: %w", err))
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	for _, der := range certificate.Certificate {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+
+	return data, nil
+}
+
+func makeCertificate(chain [][]byte, key *ecdsa.PrivateKey, host string) (*tls.Certificate, error) {
+	if len(chain) == 0 {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("certificate chain"))
+	}
+
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("x509 parse certificate: %w", err))
+	}
+
+	if publicKey, ok := leaf.PublicKey.(*ecdsa.PublicKey); !ok || !publicKey.Equal(&key.PublicKey) {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: the certificate is not of the key", ErrInvalidCachedData),
+			host,
+		)
+	}
+
+	if err := leaf.VerifyHostname(host); err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("x509 certificate verify hostname: %w", err), host)
+	}
+
+	return &tls.Certificate{Certificate: chain, PrivateKey: key, Leaf: leaf}, nil
+}
+
+func decodeCertificate(data []byte, host string) (*tls.Certificate, error) {
+	var (
+		key   *ecdsa.PrivateKey
+		chain [][]byte
+	)
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "EC PRIVATE KEY":
+			parsedKey, err := x509.ParseECPrivateKey(block.Bytes)
+			if err != nil {
+				return nil, motmedelErrors.NewWithTrace(fmt.Errorf("x509 parse ec private key: %w", err))
+			}
+			key = parsedKey
+		case "CERTIFICATE":
+			chain = append(chain, block.Bytes)
+		}
+	}
+
+	if key == nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("%w: no key", ErrInvalidCachedData), host)
+	}
+
+	return makeCertificate(chain, key, host)
+}
+
+func (manager *Manager) getClient(ctx context.Context) (*acme.Client, error) {
+	manager.clientMutex.Lock()
+	defer manager.clientMutex.Unlock()
+
+	if manager.client != nil {
+		return manager.client, nil
+	}
+
+	var key *ecdsa.PrivateKey
+	data, err := manager.config.Cache.Get(ctx, AccountKeyCacheName)
+	switch {
+	case err == nil:
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: no pem block", ErrInvalidCachedData),
+				AccountKeyCacheName,
+			)
+		}
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("x509 parse ec private key: %w", err))
+		}
+	case errors.Is(err, cache.ErrCacheMiss):
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("ecdsa generate key: %w", err))
+		}
+
+		keyDer, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("x509 marshal ec private key: %w", err))
+		}
+
+		data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+		if err := manager.config.Cache.Put(ctx, AccountKeyCacheName, data); err != nil {
+			return nil, motmedelErrors.New(fmt.Errorf("cache put: %w", err), AccountKeyCacheName)
+		}
+	default:
+		return nil, motmedelErrors.New(fmt.Errorf("cache get: %w", err), AccountKeyCacheName)
+	}
+
+	client, err := acme.New(key, manager.config.ClientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("acme new: %w", err)
+	}
+
+	if _, err := client.Register(ctx); err != nil {
+		return nil, fmt.Errorf("acme client register: %w", err)
+	}
+
+	manager.client = client
+
+	return client, nil
+}
+
+func (manager *Manager) solve(
+	client *acme.Client,
+) func(*acmeTypes.Authorization, *acmeTypes.Challenge) (func(), error) {
+	return func(authorization *acmeTypes.Authorization, challenge *acmeTypes.Challenge) (func(), error) {
+		token := challenge.Token
+		if token == "" {
+			return nil, motmedelErrors.NewWithTrace(empty_error.New("challenge token"), challenge.Url)
+		}
+
+		switch challenge.Type {
+		case acmeTypes.ChallengeTypeHttp01:
+			manager.mutex.Lock()
+			manager.keyAuthorizations[token] = client.KeyAuthorization(token)
+			manager.mutex.Unlock()
+
+			return func() {
+				manager.mutex.Lock()
+				delete(manager.keyAuthorizations, token)
+				manager.mutex.Unlock()
+			}, nil
+		case acmeTypes.ChallengeTypeTlsAlpn01:
+			name := normalizeHost(authorization.Identifier.Value)
+			certificate, err := client.TlsAlpnChallengeCertificate(name, token)
+			if err != nil {
+				return nil, fmt.Errorf("acme client tls alpn challenge certificate: %w", err)
+			}
+
+			manager.mutex.Lock()
+			manager.challengeCertificates[name] = certificate
+			manager.mutex.Unlock()
+
+			return func() {
+				manager.mutex.Lock()
+				delete(manager.challengeCertificates, name)
+				manager.mutex.Unlock()
+			}, nil
+		default:
+			return nil, motmedelErrors.NewWithTrace(ErrUnsupportedChallenge, challenge.Type)
+		}
+	}
+}
+
+// issue orders a certificate for the host and caches it. A certificate that could not be cached is
+// served all the same.
+func (manager *Manager) issue(ctx context.Context, host string) (*tls.Certificate, error) {
+	client, err := manager.getClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get client: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("ecdsa generate key: %w", err))
+	}
+
+	chain, err := client.ObtainCertificate(
+		ctx,
+		key,
+		[]string{host},
+		manager.config.ChallengeTypes,
+		manager.solve(client),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("acme client obtain certificate: %w", err)
+	}
+
+	certificate, err := makeCertificate(chain, key, host)
+	if err != nil {
+		return nil, fmt.Errorf("make certificate: %w", err)
+	}
+
+	data, err := encodeCertificate(certificate)
+	if err == nil {
+		err = manager.config.Cache.Put(ctx, host, data)
+	}
+	if err != nil {
+		slog.WarnContext(
+			motmedelContext.WithError(ctx, motmedelErrors.New(fmt.Errorf("cache put: %w", err), host)),
+			"An error occurred when caching an ACME certificate.",
+		)
+	}
+
+	return certificate, nil
+}
+
+// load returns the certificate cached for the host, or nil if none is.
+func (manager *Manager) load(ctx context.Context, host string) (*tls.Certificate, error) {
+	data, err := manager.config.Cache.Get(ctx, host)
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return nil, nil
+		}
+		return nil, motmedelErrors.New(fmt.Errorf("cache get: %w", err), host)
+	}
+
+	certificate, err := decodeCertificate(data, host)
+	if err != nil {
+		return nil, fmt.Errorf("decode certificate: %w", err)
+	}
+
+	return certificate, nil
+}
+
+// obtain serves the certificate cached for the host, or orders one if none that is still valid is.
+func (manager *Manager) obtain(host string, state *certificateState) {
+	ctx, cancel := context.WithTimeout(context.Background(), manager.config.IssueTimeout)
+	defer cancel()
+
+	certificate, err := manager.load(ctx, host)
+	if err != nil {
+		slog.WarnContext(
+			motmedelContext.WithError(ctx, fmt.Errorf("load: %w", err)),
+			"An error occurred when loading a cached ACME certificate.",
+		)
+	}
+
+	if certificate == nil || !manager.config.Now().Before(certificate.Leaf.NotAfter) {
+		certificate, err = manager.issue(ctx, host)
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	now := manager.config.Now()
+	if err != nil {
+		state.err = fmt.Errorf("issue: %w", err)
+		state.failedAt = now
+	} else {
+		state.certificate = certificate
+		state.err = nil
+		manager.maintain(host, state, now)
+	}
+
+	close(state.done)
+	state.done = nil
+}
+
+func (manager *Manager) renew(host string, state *certificateState) {
+	ctx, cancel := context.WithTimeout(context.Background(), manager.config.IssueTimeout)
+	defer cancel()
+
+	certificate, err := manager.issue(ctx, host)
+	if err != nil {
+		slog.WarnContext(
+			motmedelContext.WithError(ctx, fmt.Errorf("issue: %w", err)),
+			"An error occurred when renewing an ACME certificate.",
+		)
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	state.renewing = false
+	if err != nil {
+		state.renewNotBefore = manager.config.Now().Add(manager.config.RetryInterval)
+		return
+	}
+
+	state.certificate = certificate
+	state.revoked = false
+	state.stapleNotBefore = time.Time{}
+}
+
+func (manager *Manager) staple(host string, state *certificateState, certificate *tls.Certificate) {
+	ctx, cancel := context.WithTimeout(context.Background(), manager.config.IssueTimeout)
+	defer cancel()
+
+	var response *ocsp.Response
+	issuer, err := x509.ParseCertificate(certificate.Certificate[1])
+	if err != nil {
+		err = motmedelErrors.NewWithTrace(fmt.Errorf("x509 parse certificate (issuer): %w", err), host)
+	} else {
+		response, err = ocsp.Fetch(ctx, certificate.Leaf, issuer, manager.config.OcspFetchOptions...)
+	}
+	if err != nil {
+		slog.WarnContext(
+			motmedelContext.WithError(ctx, motmedelErrors.New(fmt.Errorf("ocsp fetch: %w", err), host)),
+			"An error occurred when fetching the OCSP response of an ACME certificate.",
+		)
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	now := manager.config.Now()
+	state.stapling = false
+	state.stapleNotBefore = now.Add(manager.config.RetryInterval)
+
+	// A certificate renewed meanwhile is stapled anew.
+	if err != nil || state.certificate != certificate {
+		return
+	}
+
+	switch response.Status {
+	case ocsp.StatusGood:
+		stapled := *certificate
+		stapled.OCSPStaple = response.Raw
+		state.certificate = &stapled
+
+		// The response is refreshed halfway through its validity, as RFC 6960 leaves to the
+		// client where the server says nothing of it.
+		if !response.NextUpdate.IsZero() {
+			state.stapleNotBefore = response.ThisUpdate.Add(response.NextUpdate.Sub(response.ThisUpdate) / 2)
+		}
+	case ocsp.StatusRevoked:
+		slog.WarnContext(
+			motmedelContext.WithError(
+				ctx,
+				motmedelErrors.New(ErrCertificateRevoked, host, response.RevokedAt),
+			),
+			"An ACME certificate has been revoked; it is being renewed.",
+		)
+		state.revoked = true
+		state.renewNotBefore = time.Time{}
+		manager.maintain(host, state, now)
+	}
+}
+
+// maintain starts the renewal of the host's certificate and the refreshing of its OCSP response when
+// they are due. The mutex is held by the caller.
+func (manager *Manager) maintain(host string, state *certificateState, now time.Time) {
+	certificate := state.certificate
+	if certificate == nil {
+		return
+	}
+
+	renewAt := certificate.Leaf.NotAfter.Add(-manager.config.RenewBefore)
+	if !state.renewing && (state.revoked || !now.Before(renewAt)) && !now.Before(state.renewNotBefore) {
+		state.renewing = true
+		go manager.renew(host, state)
+	}
+
+	if manager.config.OcspStapling &&
+		!state.stapling &&
+		!state.revoked &&
+		len(certificate.Leaf.OCSPServer) != 0 &&
+		len(certificate.Certificate) > 1 &&
+		!now.Before(state.stapleNotBefore) {
+		state.stapling = true
+		go manager.staple(host, state, certificate)
+	}
+}
+
+func (manager *Manager) certificate(ctx context.Context, host string) (*tls.Certificate, error) {
+	manager.mutex.Lock()
+
+	state := manager.states[host]
+	if state == nil {
+		state = &certificateState{}
+		manager.states[host] = state
+	}
+
+	now := manager.config.Now()
+	if certificate := state.certificate; certificate != nil && now.Before(certificate.Leaf.NotAfter) {
+		manager.maintain(host, state, now)
+		manager.mutex.Unlock()
+		return certificate, nil
+	}
+
+	// An obtaining that failed is not retried by every handshake that follows it.
+	if state.done == nil && state.err != nil && now.Before(state.failedAt.Add(manager.config.RetryInterval)) {
+		err := state.err
+		manager.mutex.Unlock()
+		return nil, err
+	}
+
+	if state.done == nil {
+		state.done = make(chan struct{})
+		go manager.obtain(host, state)
+	}
+	done := state.done
+	manager.mutex.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("context done: %w", ctx.Err()), host)
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if state.certificate == nil {
+		return nil, state.err
+	}
+
+	return state.certificate, nil
+}
+
+// GetCertificate returns the certificate of the host the handshake is for, as
+// tls.Config.GetCertificate. A certificate not yet obtained is obtained before the handshake
+// continues; one due for renewal is served while it is renewed.
+func (manager *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if hello == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("client hello info"))
+	}
+
+	host := normalizeHost(hello.ServerName)
+	if host == "" {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("server name"))
+	}
+
+	if slices.Contains(hello.SupportedProtos, acme.TlsAlpnProtocol) {
+		manager.mutex.Lock()
+		certificate := manager.challengeCertificates[host]
+		manager.mutex.Unlock()
+
+		if certificate == nil {
+			return nil, motmedelErrors.NewWithTrace(ErrNoChallengeResponse, host)
+		}
+
+		return certificate, nil
+	}
+
+	if _, ok := manager.hosts[host]; !ok {
+		return nil, motmedelErrors.NewWithTrace(ErrHostNotAllowed, host)
+	}
+
+	ctx := hello.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	certificate, err := manager.certificate(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("certificate: %w", err)
+	}
+
+	return certificate, nil
+}
+
+// TlsConfig is a TLS configuration that serves the manager's certificates and answers TLS-ALPN-01
+// challenges.
+func (manager *Manager) TlsConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: manager.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1", acme.TlsAlpnProtocol},
+	}
+}
+
+// HttpChallengeEndpoint is the endpoint HTTP-01 challenges are answered at. It is to be served over
+// plain HTTP on port 80, where the ACME server looks for it.
+func (manager *Manager) HttpChallengeEndpoint() *endpoint.Endpoint {
+	return &endpoint.Endpoint{
+		Path:   acme.HttpChallengePathPrefix + "{token}",
+		Method: http.MethodGet,
+		Handler: func(request *http.Request, _ []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			manager.mutex.Lock()
+			keyAuthorization, ok := manager.keyAuthorizations[request.PathValue("token")]
+			manager.mutex.Unlock()
+
+			if !ok {
+				return nil, &muxResponseError.ResponseError{ProblemDetail: problem_detail.New(http.StatusNotFound)}
+			}
+
+			return &muxResponse.Response{
+				Headers: []*muxResponse.HeaderEntry{{Name: "Content-Type", Value: "text/plain"}},
+				Body:    []byte(keyAuthorization),
+			}, nil
+		},
+		Public: true,
+	}
+}
+
+func New(options ...manager_config.Option) (*Manager, error) {
+	config := manager_config.New(options...)
+
+	if len(config.Hosts) == 0 {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("hosts"))
+	}
+
+	hosts := make(map[string]struct{}, len(config.Hosts))
+	for _, host := range config.Hosts {
+		host = normalizeHost(host)
+		if host == "" {
+			return nil, motmedelErrors.NewWithTrace(empty_error.New("host"))
+		}
+		// Neither challenge type the manager answers can validate a wildcard name.
+		if strings.Contains(host, "*") {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: wildcard host", motmedelErrors.ErrValidationError),
+				host,
+			)
+		}
+		hosts[host] = struct{}{}
+	}
+
+	return &Manager{
+		config:                config,
+		hosts:                 hosts,
+		states:                make(map[string]*certificateState),
+		keyAuthorizations:     make(map[string]string),
+		challengeCertificates: make(map[string]*tls.Certificate),
+	}, nil
+}
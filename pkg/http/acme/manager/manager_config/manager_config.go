@@ -0,0 +1,144 @@
+package manager_config
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/acme/acme_config"
+	"github.com/Motmedel/utils_go/pkg/http/acme/cache"
+	"github.com/Motmedel/utils_go/pkg/http/acme/cache/directory_cache"
+	acmeTypes "github.com/Motmedel/utils_go/pkg/http/acme/types"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
+)
+
+const (
+	// DefaultRenewBefore is how long before a certificate expires it is renewed: a third of the 90
+	// days a Let's Encrypt certificate is valid for, as Let's Encrypt recommends.
+	DefaultRenewBefore = 30 * 24 * time.Hour
+	// DefaultRetryInterval is how long a failed renewal or OCSP fetch is left before it is tried
+	// again.
+	DefaultRetryInterval = time.Hour
+	// DefaultIssueTimeout bounds an order from its creation to the certificate being downloaded.
+	DefaultIssueTimeout = 5 * time.Minute
+	// DefaultCacheDirectoryName is the directory, within the user's cache directory, of the
+	// default cache.
+	DefaultCacheDirectoryName = "acme"
+)
+
+// DefaultChallengeTypes are the challenge types used, in order of preference. TLS-ALPN-01 is
+// answered on the port the service serves TLS on, and HTTP-01 needs port 80 as well.
+var DefaultChallengeTypes = []string{acmeTypes.ChallengeTypeTlsAlpn01, acmeTypes.ChallengeTypeHttp01}
+
+type Config struct {
+	// Hosts are the hosts certificates are obtained for. A handshake for any other host is refused,
+	// so that a client cannot make the manager order certificates for names it pleases.
+	Hosts []string
+	// Cache holds the account key and the certificates; a directory cache in the user's cache
+	// directory is used when none is set.
+	Cache          cache.Cache
+	RenewBefore    time.Duration
+	RetryInterval  time.Duration
+	IssueTimeout   time.Duration
+	ChallengeTypes []string
+	// OcspStapling staples the OCSP response of the certificate's issuer to the handshake, so that a
+	// client does not ask the issuer itself.
+	OcspStapling     bool
+	OcspFetchOptions []fetch_config.Option
+	ClientOptions    []acme_config.Option
+	Now              func() time.Time
+}
+
+type Option func(*Config)
+
+func defaultCache() cache.Cache {
+	directory, err := os.UserCacheDir()
+	if err != nil {
+		directory = os.TempDir()
+	}
+
+	return directory_cache.New(filepath.Join(directory, DefaultCacheDirectoryName))
+}
+
+func New(options ...Option) *Config {
+	config := &Config{
+		RenewBefore:    DefaultRenewBefore,
+		RetryInterval:  DefaultRetryInterval,
+		IssueTimeout:   DefaultIssueTimeout,
+		ChallengeTypes: DefaultChallengeTypes,
+		OcspStapling:   true,
+		Now:            time.Now,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	if config.Cache == nil {
+		config.Cache = defaultCache()
+	}
+
+	return config
+}
+
+func WithHosts(hosts ...string) Option {
+	return func(config *Config) {
+		config.Hosts = append(config.Hosts, hosts...)
+	}
+}
+
+func WithCache(cache cache.Cache) Option {
+	return func(config *Config) {
+		config.Cache = cache
+	}
+}
+
+func WithRenewBefore(renewBefore time.Duration) Option {
+	return func(config *Config) {
+		config.RenewBefore = renewBefore
+	}
+}
+
+func WithRetryInterval(retryInterval time.Duration) Option {
+	return func(config *Config) {
+		config.RetryInterval = retryInterval
+	}
+}
+
+func WithIssueTimeout(issueTimeout time.Duration) Option {
+	return func(config *Config) {
+		config.IssueTimeout = issueTimeout
+	}
+}
+
+// WithChallengeTypes sets the challenge types used, in order of preference.
+func WithChallengeTypes(challengeTypes ...string) Option {
+	return func(config *Config) {
+		config.ChallengeTypes = challengeTypes
+	}
+}
+
+func WithOcspStapling(ocspStapling bool) Option {
+	return func(config *Config) {
+		config.OcspStapling = ocspStapling
+	}
+}
+
+func WithOcspFetchOptions(ocspFetchOptions ...fetch_config.Option) Option {
+	return func(config *Config) {
+		config.OcspFetchOptions = append(config.OcspFetchOptions, ocspFetchOptions...)
+	}
+}
+
+// WithClientOptions configures the ACME client: the directory, the account's contacts, the
+// agreement to the terms of service.
+func WithClientOptions(clientOptions ...acme_config.Option) Option {
+	return func(config *Config) {
+		config.ClientOptions = append(config.ClientOptions, clientOptions...)
+	}
+}
+
+func WithNow(now func() time.Time) Option {
+	return func(config *Config) {
+		config.Now = now
+	}
+}
@@ -0,0 +1,90 @@
+package manager_config
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/acme/acme_config"
+	"github.com/Motmedel/utils_go/pkg/http/acme/cache/directory_cache"
+	"github.com/Motmedel/utils_go/pkg/http/acme/cache/memory_cache"
+	acmeTypes "github.com/Motmedel/utils_go/pkg/http/acme/types"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.RenewBefore != DefaultRenewBefore {
+		t.Errorf("renew before: got %v", config.RenewBefore)
+	}
+	if config.RetryInterval != DefaultRetryInterval {
+		t.Errorf("retry interval: got %v", config.RetryInterval)
+	}
+	if config.IssueTimeout != DefaultIssueTimeout {
+		t.Errorf("issue timeout: got %v", config.IssueTimeout)
+	}
+	if !slices.Equal(config.ChallengeTypes, DefaultChallengeTypes) {
+		t.Errorf("challenge types: got %v", config.ChallengeTypes)
+	}
+	if !config.OcspStapling {
+		t.Error("expected ocsp stapling by default")
+	}
+	if config.Now == nil {
+		t.Error("expected a default now function")
+	}
+	if _, ok := config.Cache.(*directory_cache.Cache); !ok {
+		t.Errorf("expected a default directory cache, got %T", config.Cache)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	cache := memory_cache.New()
+	now := time.Unix(1, 0)
+
+	config := New(
+		WithHosts("example.com"),
+		WithHosts("www.example.com"),
+		WithCache(cache),
+		WithRenewBefore(time.Hour),
+		WithRetryInterval(time.Minute),
+		WithIssueTimeout(time.Second),
+		WithChallengeTypes(acmeTypes.ChallengeTypeHttp01),
+		WithOcspStapling(false),
+		WithOcspFetchOptions(fetch_config.WithHttpClient(&http.Client{})),
+		WithClientOptions(acme_config.WithTermsOfServiceAgreed(true)),
+		WithNow(func() time.Time { return now }),
+	)
+
+	if !slices.Equal(config.Hosts, []string{"example.com", "www.example.com"}) {
+		t.Errorf("expected the hosts to accumulate, got %v", config.Hosts)
+	}
+	if config.Cache != cache {
+		t.Errorf("cache: got %v", config.Cache)
+	}
+	if config.RenewBefore != time.Hour {
+		t.Errorf("renew before: got %v", config.RenewBefore)
+	}
+	if config.RetryInterval != time.Minute {
+		t.Errorf("retry interval: got %v", config.RetryInterval)
+	}
+	if config.IssueTimeout != time.Second {
+		t.Errorf("issue timeout: got %v", config.IssueTimeout)
+	}
+	if !slices.Equal(config.ChallengeTypes, []string{acmeTypes.ChallengeTypeHttp01}) {
+		t.Errorf("challenge types: got %v", config.ChallengeTypes)
+	}
+	if config.OcspStapling {
+		t.Error("expected ocsp stapling to be off")
+	}
+	if len(config.OcspFetchOptions) != 1 || len(config.ClientOptions) != 1 {
+		t.Errorf("expected one ocsp fetch option and one client option, got %d %d", len(config.OcspFetchOptions), len(config.ClientOptions))
+	}
+	if !config.Now().Equal(now) {
+		t.Errorf("now: got %v", config.Now())
+	}
+}
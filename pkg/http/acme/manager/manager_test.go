@@ -0,0 +1,341 @@
+package manager
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/acme"
+	"github.com/Motmedel/utils_go/pkg/http/acme/acme_config"
+	"github.com/Motmedel/utils_go/pkg/http/acme/cache/memory_cache"
+	"github.com/Motmedel/utils_go/pkg/http/acme/manager/manager_config"
+	acmeTypes "github.com/Motmedel/utils_go/pkg/http/acme/types"
+	motmedelMux "github.com/Motmedel/utils_go/pkg/http/mux"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
+	"github.com/Motmedel/utils_go/pkg/testing/acme_server"
+	"github.com/Motmedel/utils_go/pkg/tls/ocsp"
+)
+
+const testHost = "example.com"
+
+func newServer(t *testing.T) *acme_server.Server {
+	t.Helper()
+
+	server, err := acme_server.New()
+	if err != nil {
+		t.Fatalf("acme server new: %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newManager makes a manager of the stand-in server, and serves its challenge responses where the
+// server looks for them.
+func newManager(t *testing.T, server *acme_server.Server, options ...manager_config.Option) *Manager {
+	t.Helper()
+
+	manager, err := New(
+		append(
+			[]manager_config.Option{
+				manager_config.WithHosts(testHost),
+				manager_config.WithCache(memory_cache.New()),
+				manager_config.WithOcspStapling(false),
+				manager_config.WithOcspFetchOptions(fetch_config.WithHttpClient(server.Client())),
+				manager_config.WithClientOptions(
+					acme_config.WithDirectoryUrl(server.DirectoryUrl),
+					acme_config.WithTermsOfServiceAgreed(true),
+					acme_config.WithPollInterval(10*time.Millisecond),
+					acme_config.WithFetchOptions(fetch_config.WithHttpClient(server.Client())),
+				),
+			},
+			options...,
+		)...,
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	httpServer := httptest.NewServer(motmedelMux.New(manager.HttpChallengeEndpoint()))
+	t.Cleanup(httpServer.Close)
+	server.SetHttpChallengeAddress(httpServer.Listener.Addr().String())
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", manager.TlsConfig())
+	if err != nil {
+		t.Fatalf("tls listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			connection, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(connection net.Conn) {
+				_ = connection.(*tls.Conn).Handshake()
+				_ = connection.Close()
+			}(connection)
+		}
+	}()
+	server.SetTlsChallengeAddress(listener.Addr().String())
+
+	return manager
+}
+
+func getCertificate(t *testing.T, manager *Manager) *tls.Certificate {
+	t.Helper()
+
+	certificate, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: testHost})
+	if err != nil {
+		t.Fatalf("get certificate: %v", err)
+	}
+
+	return certificate
+}
+
+// eventually polls condition until it holds, failing the test if it does not within a few seconds.
+func eventually(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManager_GetCertificate(t *testing.T) {
+	t.Parallel()
+
+	for _, challengeType := range []string{acmeTypes.ChallengeTypeTlsAlpn01, acmeTypes.ChallengeTypeHttp01} {
+		t.Run(challengeType, func(t *testing.T) {
+			t.Parallel()
+
+			server := newServer(t)
+			manager := newManager(t, server, manager_config.WithChallengeTypes(challengeType))
+
+			certificate := getCertificate(t, manager)
+			if _, err := certificate.Leaf.Verify(x509.VerifyOptions{DNSName: testHost, Roots: server.Roots()}); err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+
+			if again := getCertificate(t, manager); again != certificate {
+				t.Error("expected the certificate to be served from memory")
+			}
+			if issued := server.Issued(); issued != 1 {
+				t.Errorf("expected one certificate to be issued, got %d", issued)
+			}
+		})
+	}
+}
+
+func TestManager_Cache(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t)
+	sharedCache := memory_cache.New()
+
+	first := getCertificate(t, newManager(t, server, manager_config.WithCache(sharedCache)))
+	second := getCertificate(t, newManager(t, server, manager_config.WithCache(sharedCache)))
+
+	if !second.Leaf.Equal(first.Leaf) {
+		t.Error("expected the cached certificate to be served")
+	}
+	if issued := server.Issued(); issued != 1 {
+		t.Errorf("expected one certificate to be issued, got %d", issued)
+	}
+	if _, err := sharedCache.Get(context.Background(), AccountKeyCacheName); err != nil {
+		t.Errorf("expected the account key to be cached: %v", err)
+	}
+}
+
+func TestManager_GetCertificate_Refused(t *testing.T) {
+	t.Parallel()
+
+	manager := newManager(t, newServer(t))
+
+	testCases := []struct {
+		name    string
+		hello   *tls.ClientHelloInfo
+		wantErr error
+	}{
+		{
+			name:    "other host",
+			hello:   &tls.ClientHelloInfo{ServerName: "other.example.com"},
+			wantErr: ErrHostNotAllowed,
+		},
+		{
+			name:  "no server name",
+			hello: &tls.ClientHelloInfo{},
+		},
+		{
+			name:    "no challenge pending",
+			hello:   &tls.ClientHelloInfo{ServerName: testHost, SupportedProtos: []string{acme.TlsAlpnProtocol}},
+			wantErr: ErrNoChallengeResponse,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := manager.GetCertificate(testCase.hello)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if testCase.wantErr != nil && !errors.Is(err, testCase.wantErr) {
+				t.Errorf("expected %v, got %v", testCase.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestManager_GetCertificate_Failure(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t)
+	manager := newManager(t, server)
+	// Nothing answers the challenges.
+	server.SetHttpChallengeAddress("127.0.0.1:1")
+	server.SetTlsChallengeAddress("127.0.0.1:1")
+
+	if _, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: testHost}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: testHost}); err == nil {
+		t.Fatal("expected the failure to be remembered")
+	}
+}
+
+func TestManager_Renewal(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t)
+	// Every certificate is due for renewal as soon as it is issued.
+	manager := newManager(t, server, manager_config.WithRenewBefore(2*acme_server.DefaultValidity))
+
+	first := getCertificate(t, manager)
+	eventually(t, func() bool {
+		return !getCertificate(t, manager).Leaf.Equal(first.Leaf)
+	})
+}
+
+func TestManager_OcspStapling(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t)
+
+	var now atomic.Pointer[time.Time]
+	start := time.Now()
+	now.Store(&start)
+
+	manager := newManager(
+		t,
+		server,
+		manager_config.WithOcspStapling(true),
+		manager_config.WithNow(func() time.Time { return *now.Load() }),
+	)
+
+	var stapled *tls.Certificate
+	eventually(t, func() bool {
+		stapled = getCertificate(t, manager)
+		return len(stapled.OCSPStaple) != 0
+	})
+
+	issuer, err := x509.ParseCertificate(stapled.Certificate[1])
+	if err != nil {
+		t.Fatalf("x509 parse certificate: %v", err)
+	}
+	response, err := ocsp.ParseResponse(stapled.OCSPStaple, stapled.Leaf, issuer, time.Now())
+	if err != nil {
+		t.Fatalf("ocsp parse response: %v", err)
+	}
+	if response.Status != ocsp.StatusGood {
+		t.Fatalf("expected a good status, got %v", response.Status)
+	}
+
+	// Once revoked, the certificate is replaced when its response is refreshed.
+	server.Revoke(stapled.Leaf.SerialNumber)
+	later := start.Add(13 * time.Hour)
+	now.Store(&later)
+
+	eventually(t, func() bool {
+		return !getCertificate(t, manager).Leaf.Equal(stapled.Leaf)
+	})
+}
+
+func TestManager_HttpChallengeEndpoint(t *testing.T) {
+	t.Parallel()
+
+	manager := newManager(t, newServer(t))
+	httpServer := httptest.NewServer(motmedelMux.New(manager.HttpChallengeEndpoint()))
+	t.Cleanup(httpServer.Close)
+
+	response, err := http.Get(httpServer.URL + acme.HttpChallengePathPrefix + "unknown")
+	if err != nil {
+		t.Fatalf("http get: %v", err)
+	}
+	_ = response.Body.Close()
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown token, got %d", response.StatusCode)
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		options []manager_config.Option
+		wantErr bool
+	}{
+		{
+			name:    "hosts",
+			options: []manager_config.Option{manager_config.WithHosts("Example.com.")},
+		},
+		{
+			name:    "no hosts",
+			wantErr: true,
+		},
+		{
+			name:    "empty host",
+			options: []manager_config.Option{manager_config.WithHosts("")},
+			wantErr: true,
+		},
+		{
+			name:    "wildcard host",
+			options: []manager_config.Option{manager_config.WithHosts("*.example.com")},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			manager, err := New(append(testCase.options, manager_config.WithCache(memory_cache.New()))...)
+			if testCase.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("new: %v", err)
+			}
+			if _, ok := manager.hosts[testHost]; !ok {
+				t.Errorf("expected the host to be normalized, got %v", manager.hosts)
+			}
+		})
+	}
+}
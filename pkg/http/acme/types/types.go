@@ -0,0 +1,114 @@
+// Package types holds the objects of the Automatic Certificate Management Environment (RFC 8555).
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	StatusPending     = "pending"
+	StatusReady       = "ready"
+	StatusProcessing  = "processing"
+	StatusValid       = "valid"
+	StatusInvalid     = "invalid"
+	StatusDeactivated = "deactivated"
+	StatusRevoked     = "revoked"
+	StatusExpired     = "expired"
+)
+
+const (
+	ChallengeTypeHttp01    = "http-01"
+	ChallengeTypeTlsAlpn01 = "tls-alpn-01"
+	ChallengeTypeDns01     = "dns-01"
+)
+
+const IdentifierTypeDns = "dns"
+
+const (
+	ProblemTypeBadNonce     = "urn:ietf:params:acme:error:badNonce"
+	ProblemTypeRateLimited  = "urn:ietf:params:acme:error:rateLimited"
+	ProblemTypeUnauthorized = "urn:ietf:params:acme:error:unauthorized"
+)
+
+type DirectoryMeta struct {
+	TermsOfService          string   `json:"termsOfService,omitempty"`
+	Website                 string   `json:"website,omitempty"`
+	CaaIdentities           []string `json:"caaIdentities,omitempty"`
+	ExternalAccountRequired bool     `json:"externalAccountRequired,omitempty"`
+}
+
+type Directory struct {
+	NewNonce   string         `json:"newNonce"`
+	NewAccount string         `json:"newAccount"`
+	NewOrder   string         `json:"newOrder"`
+	NewAuthz   string         `json:"newAuthz,omitempty"`
+	RevokeCert string         `json:"revokeCert,omitempty"`
+	KeyChange  string         `json:"keyChange,omitempty"`
+	Meta       *DirectoryMeta `json:"meta,omitempty"`
+}
+
+type Identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Problem is a problem document (RFC 7807) an ACME server answers an error with.
+type Problem struct {
+	Type        string      `json:"type,omitempty"`
+	Detail      string      `json:"detail,omitempty"`
+	Status      int         `json:"status,omitempty"`
+	Identifier  *Identifier `json:"identifier,omitempty"`
+	Subproblems []*Problem  `json:"subproblems,omitempty"`
+}
+
+func (problem *Problem) Error() string {
+	if problem.Detail == "" {
+		return fmt.Sprintf("acme problem %s", problem.Type)
+	}
+
+	return fmt.Sprintf("acme problem %s: %s", problem.Type, problem.Detail)
+}
+
+type Account struct {
+	Status               string   `json:"status,omitempty"`
+	Contact              []string `json:"contact,omitempty"`
+	TermsOfServiceAgreed bool     `json:"termsOfServiceAgreed,omitempty"`
+	Orders               string   `json:"orders,omitempty"`
+
+	// Url is the account's URL, which identifies it in the requests it signs.
+	Url string `json:"-"`
+}
+
+type Order struct {
+	Status         string        `json:"status"`
+	Expires        time.Time     `json:"expires,omitzero"`
+	Identifiers    []*Identifier `json:"identifiers"`
+	NotBefore      time.Time     `json:"notBefore,omitzero"`
+	NotAfter       time.Time     `json:"notAfter,omitzero"`
+	Error          *Problem      `json:"error,omitempty"`
+	Authorizations []string      `json:"authorizations"`
+	Finalize       string        `json:"finalize"`
+	Certificate    string        `json:"certificate,omitempty"`
+
+	Url string `json:"-"`
+}
+
+type Challenge struct {
+	Type      string    `json:"type"`
+	Url       string    `json:"url"`
+	Status    string    `json:"status"`
+	Validated time.Time `json:"validated,omitzero"`
+	Error     *Problem  `json:"error,omitempty"`
+	Token     string    `json:"token,omitempty"`
+}
+
+type Authorization struct {
+	Identifier *Identifier  `json:"identifier"`
+	Status     string       `json:"status"`
+	Expires    time.Time    `json:"expires,omitzero"`
+	Challenges []*Challenge `json:"challenges"`
+	Wildcard   bool         `json:"wildcard,omitempty"`
+
+	Url string `json:"-"`
+}
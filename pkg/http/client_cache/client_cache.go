@@ -0,0 +1,730 @@
+// Package client_cache is a private HTTP cache (RFC 9111) for the client side: a round tripper that
+// answers the requests it can with the responses it has stored, revalidates those that have become
+// stale, and sends the rest on.
+//
+// Used as the transport of the client of utils.Fetch -- with fetch_config.WithTransport -- it
+// caches what is fetched with it, such as the JWK sets of a key handler and the metadata of OIDC
+// providers.
+package client_cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	motmedelContext "github.com/Motmedel/utils_go/pkg/context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/client_cache/client_cache_config"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_cache/store"
+	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
+	"github.com/Motmedel/utils_go/pkg/http/types/cache_control"
+)
+
+// cacheableStatusCodes are the status codes that are cacheable by default (RFC 9110, Section 15.1),
+// but for 206, ranges not being cached.
+var cacheableStatusCodes = map[int]struct{}{
+	http.StatusOK:                   {},
+	http.StatusNonAuthoritativeInfo: {},
+	http.StatusNoContent:            {},
+	http.StatusMultipleChoices:      {},
+	http.StatusMovedPermanently:     {},
+	http.StatusPermanentRedirect:    {},
+	http.StatusNotFound:             {},
+	http.StatusMethodNotAllowed:     {},
+	http.StatusGone:                 {},
+	http.StatusRequestURITooLong:    {},
+	http.StatusNotImplemented:       {},
+}
+
+// errorStatusCodes are the status codes of the responses that a stale response may be used in place
+// of, as an error (RFC 5861, Section 4).
+var errorStatusCodes = map[int]struct{}{
+	http.StatusInternalServerError: {},
+	http.StatusBadGateway:          {},
+	http.StatusServiceUnavailable:  {},
+	http.StatusGatewayTimeout:      {},
+}
+
+// notUpdatedHeaderNames are the headers of a 304 response that do not update the stored response
+// (RFC 9111, Section 3.2).
+var notUpdatedHeaderNames = map[string]struct{}{
+	"Content-Length":    {},
+	"Transfer-Encoding": {},
+	"Connection":        {},
+}
+
+// bypassHeaderNames are the request headers that make the cache send the request on as it is: the
+// requests for ranges, and the requests that are conditional already.
+var bypassHeaderNames = []string{
+	"Range",
+	"If-Match",
+	"If-None-Match",
+	"If-Modified-Since",
+	"If-Unmodified-Since",
+	"If-Range",
+}
+
+func toHeaderEntries(header http.Header) []*muxResponse.HeaderEntry {
+	var headerEntries []*muxResponse.HeaderEntry
+	for name, values := range header {
+		for _, value := range values {
+			headerEntries = append(headerEntries, &muxResponse.HeaderEntry{Name: name, Value: value})
+		}
+	}
+
+	return headerEntries
+}
+
+func toHeader(headerEntries []*muxResponse.HeaderEntry) http.Header {
+	header := make(http.Header)
+	for _, headerEntry := range headerEntries {
+		if headerEntry != nil {
+			header.Add(headerEntry.Name, headerEntry.Value)
+		}
+	}
+
+	return header
+}
+
+// parseCacheControl parses the Cache-Control of a message, which is empty when it has none. A
+// Cache-Control that cannot be parsed is reported as not ok.
+func parseCacheControl(values []string) (*motmedelHttpTypes.CacheControl, bool) {
+	if len(values) == 0 {
+		return &motmedelHttpTypes.CacheControl{}, true
+	}
+
+	cacheControl, err := cache_control.Parse([]byte(strings.Join(values, ", ")))
+	if err != nil || cacheControl == nil {
+		return &motmedelHttpTypes.CacheControl{}, false
+	}
+
+	return cacheControl, true
+}
+
+func getDeltaSeconds(method func() (int, error)) (time.Duration, bool) {
+	seconds, err := method()
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
+
+func parseVary(values []string) []string {
+	var names []string
+	for _, value := range values {
+		for name := range strings.SplitSeq(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+func makeVariantKey(key string, vary []string, requestHeader http.Header) string {
+	var builder strings.Builder
+	builder.WriteString(key)
+	for _, name := range vary {
+		builder.WriteString("\n")
+		builder.WriteString(name)
+		builder.WriteString(": ")
+		builder.WriteString(strings.Join(requestHeader.Values(name), ", "))
+	}
+
+	return builder.String()
+}
+
+// getDate returns the time of the Date of a response, or the fallback if it has none that is valid.
+func getDate(header http.Header, fallback time.Time) time.Time {
+	if date, err := http.ParseTime(header.Get("Date")); err == nil {
+		return date
+	}
+
+	return fallback
+}
+
+// getCorrectedInitialAge returns the age a response had as it was received (RFC 9111, Section
+// 4.2.3).
+func getCorrectedInitialAge(header http.Header, requestTime time.Time, responseTime time.Time) time.Duration {
+	apparentAge := max(responseTime.Sub(getDate(header, responseTime)), 0)
+
+	var ageValue time.Duration
+	if seconds, err := strconv.ParseInt(strings.TrimSpace(header.Get("Age")), 10, 64); err == nil && seconds > 0 {
+		ageValue = time.Duration(seconds) * time.Second
+	}
+
+	return max(apparentAge, ageValue+responseTime.Sub(requestTime))
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+func discard(response *http.Response) {
+	if response == nil || response.Body == nil {
+		return
+	}
+
+	_, _ = io.Copy(io.Discard, response.Body)
+	_ = response.Body.Close()
+}
+
+// cached is a stored response, with its headers parsed.
+type cached struct {
+	entry        *store.Entry
+	header       http.Header
+	cacheControl *motmedelHttpTypes.CacheControl
+}
+
+func newCached(entry *store.Entry) *cached {
+	header := toHeader(entry.Headers)
+	cacheControl, _ := parseCacheControl(header.Values("Cache-Control"))
+
+	return &cached{entry: entry, header: header, cacheControl: cacheControl}
+}
+
+// age returns the current age of the response; the time it was stored at is when its age was zero.
+func (c *cached) age(now time.Time) time.Duration {
+	return max(now.Sub(c.entry.Stored), 0)
+}
+
+func (c *cached) freshnessLifetime() time.Duration {
+	return c.entry.Expires.Sub(c.entry.Stored)
+}
+
+func (c *cached) staleness(now time.Time) time.Duration {
+	return c.age(now) - c.freshnessLifetime()
+}
+
+func (c *cached) hasValidator() bool {
+	return c.header.Get("Etag") != "" || c.header.Get("Last-Modified") != ""
+}
+
+func (c *cached) makeResponse(request *http.Request, now time.Time) *http.Response {
+	header := c.header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(c.age(now)/time.Second), 10))
+
+	statusCode := c.entry.StatusCode
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.entry.Body)),
+		ContentLength: int64(len(c.entry.Body)),
+		Request:       request,
+	}
+}
+
+// makeGatewayTimeoutResponse makes the response to a request that is to be answered from the cache
+// only, but cannot be (RFC 9111, Section 5.2.1.7).
+func makeGatewayTimeoutResponse(request *http.Request) *http.Response {
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", http.StatusGatewayTimeout, http.StatusText(http.StatusGatewayTimeout)),
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+		Request:    request,
+	}
+}
+
+// usability is how a stored response may be used to answer a request.
+type usability int
+
+const (
+	// unusable responses are to be revalidated, or replaced.
+	unusable usability = iota
+	usable
+	// usableWhileRevalidating responses are stale, but may be used while they are revalidated in the
+	// background (RFC 5861, Section 3).
+	usableWhileRevalidating
+)
+
+// Transport is a private cache of the responses to the GET requests it sends.
+//
+// A response is stored, keyed by the URL of the request and the request headers it varies by, if
+// its status code is cacheable by default or it states for how long it is fresh, and its
+// Cache-Control does not contain no-store. The response to a request with Authorization is stored,
+// and a stored response used to answer such a request, only if it is public. A response is fresh
+// for its max-age, for the time from its Date to its Expires, or, for a response that states
+// neither but has a Last-Modified, for a fraction of the time since it was last modified. A response is kept after it has become stale for as long as
+// it may be used under stale-while-revalidate and stale-if-error, and for as long as the stale
+// retention if it can be revalidated, which is done with conditional requests.
+//
+// The Cache-Control of a request is honoured: no-cache and max-age make the cache revalidate,
+// min-fresh and max-stale tighten and loosen what is fresh enough, only-if-cached makes the cache not
+// send the request, and no-store makes it not cache at all. Requests for ranges and conditional
+// requests are sent on as they are, as are requests with other methods than GET, a successful
+// request with an unsafe method invalidating what is stored for its URL.
+type Transport struct {
+	config *client_cache_config.Config
+
+	mutex        sync.Mutex
+	revalidating map[string]struct{}
+	waitGroup    sync.WaitGroup
+}
+
+func (transport *Transport) makeKey(request *http.Request) string {
+	requestUrl := *request.URL
+	requestUrl.Fragment = ""
+	requestUrl.RawFragment = ""
+	if requestUrl.Host == "" {
+		requestUrl.Host = request.Host
+	}
+
+	return transport.config.KeyPrefix + requestUrl.String()
+}
+
+func (transport *Transport) get(ctx context.Context, key string) *store.Entry {
+	entry, err := transport.config.Store.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(
+			motmedelContext.WithError(ctx, motmedelErrors.New(fmt.Errorf("store get: %w", err), key)),
+			"An error occurred when reading from the client cache.",
+		)
+		return nil
+	}
+
+	return entry
+}
+
+func (transport *Transport) put(ctx context.Context, key string, entry *store.Entry, ttl time.Duration) {
+	if err := transport.config.Store.Set(ctx, key, entry, ttl); err != nil {
+		slog.WarnContext(
+			motmedelContext.WithError(ctx, motmedelErrors.New(fmt.Errorf("store set: %w", err), key)),
+			"An error occurred when writing to the client cache.",
+		)
+	}
+}
+
+func (transport *Transport) delete(ctx context.Context, key string) {
+	if err := transport.config.Store.Delete(ctx, key); err != nil {
+		slog.WarnContext(
+			motmedelContext.WithError(ctx, motmedelErrors.New(fmt.Errorf("store delete: %w", err), key)),
+			"An error occurred when deleting from the client cache.",
+		)
+	}
+}
+
+// lookup returns the response stored for the request. A response that varies by request headers is
+// stored twice: under the key of the request without them, holding only Vary, and under the key of
+// the request with them, holding the response.
+func (transport *Transport) lookup(ctx context.Context, key string, requestHeader http.Header) *cached {
+	entry := transport.get(ctx, key)
+	if entry != nil && len(entry.Vary) != 0 {
+		entry = transport.get(ctx, makeVariantKey(key, entry.Vary, requestHeader))
+	}
+	if entry == nil {
+		return nil
+	}
+
+	return newCached(entry)
+}
+
+func (transport *Transport) set(
+	ctx context.Context,
+	key string,
+	requestHeader http.Header,
+	entry *store.Entry,
+	ttl time.Duration,
+) {
+	if len(entry.Vary) == 0 {
+		transport.put(ctx, key, entry, ttl)
+		return
+	}
+
+	transport.put(ctx, key, &store.Entry{Vary: entry.Vary, Stored: entry.Stored, Expires: entry.Expires}, ttl)
+	transport.put(ctx, makeVariantKey(key, entry.Vary, requestHeader), entry, ttl)
+}
+
+// getFreshnessLifetime returns for how long a response is fresh (RFC 9111, Section 4.2.1).
+func (transport *Transport) getFreshnessLifetime(
+	statusCode int,
+	header http.Header,
+	cacheControl *motmedelHttpTypes.CacheControl,
+	responseTime time.Time,
+) time.Duration {
+	if maxAge, ok := getDeltaSeconds(cacheControl.MaxAge); ok {
+		return maxAge
+	}
+
+	date := getDate(header, responseTime)
+
+	if len(header.Values("Expires")) != 0 {
+		// An Expires that is not a valid date is in the past.
+		expires, err := http.ParseTime(header.Get("Expires"))
+		if err != nil {
+			return 0
+		}
+		return max(expires.Sub(date), 0)
+	}
+
+	if _, ok := cacheableStatusCodes[statusCode]; !ok && !cacheControl.Public() {
+		return 0
+	}
+
+	lastModified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return 0
+	}
+
+	config := transport.config
+	lifetime := time.Duration(float64(date.Sub(lastModified)) * config.HeuristicFraction)
+
+	return min(max(lifetime, 0), config.MaxHeuristicFreshness)
+}
+
+// isAuthorized reports whether a request carries credentials, the response to which is not to be
+// shared with other requests unless it is public (RFC 9111, Section 3.5).
+func isAuthorized(requestHeader http.Header) bool {
+	return len(requestHeader.Values("Authorization")) != 0
+}
+
+// makeEntry makes the entry a response is stored as, and returns for how long it is to be kept,
+// which is not positive if it is not to be stored (RFC 9111, Section 3).
+func (transport *Transport) makeEntry(
+	requestHeader http.Header,
+	statusCode int,
+	header http.Header,
+	requestTime time.Time,
+	responseTime time.Time,
+) (*store.Entry, time.Duration) {
+	if statusCode < 200 || statusCode == http.StatusPartialContent {
+		return nil, 0
+	}
+
+	cacheControl, ok := parseCacheControl(header.Values("Cache-Control"))
+	if !ok || cacheControl.NoStore() {
+		return nil, 0
+	}
+
+	if isAuthorized(requestHeader) && !cacheControl.Public() {
+		return nil, 0
+	}
+
+	_, isCacheableByDefault := cacheableStatusCodes[statusCode]
+	_, maxAgeErr := cacheControl.MaxAge()
+	if !isCacheableByDefault && maxAgeErr != nil && len(header.Values("Expires")) == 0 && !cacheControl.Public() {
+		return nil, 0
+	}
+
+	vary := parseVary(header.Values("Vary"))
+	if slices.Contains(vary, "*") {
+		return nil, 0
+	}
+
+	storedHeader := header.Clone()
+	storedHeader.Del("Age")
+
+	stored := responseTime.Add(-getCorrectedInitialAge(header, requestTime, responseTime))
+	entry := &store.Entry{
+		StatusCode: statusCode,
+		Headers:    toHeaderEntries(storedHeader),
+		Vary:       vary,
+		Stored:     stored,
+		Expires:    stored.Add(transport.getFreshnessLifetime(statusCode, header, cacheControl, responseTime)),
+	}
+
+	var retention time.Duration
+	if staleWhileRevalidate, ok := getDeltaSeconds(cacheControl.StaleWhileRevalidate); ok {
+		retention = max(retention, staleWhileRevalidate)
+	}
+	if staleIfError, ok := getDeltaSeconds(cacheControl.StaleIfError); ok {
+		retention = max(retention, staleIfError)
+	}
+	if header.Get("Etag") != "" || header.Get("Last-Modified") != "" {
+		retention = max(retention, transport.config.StaleRetention)
+	}
+
+	return entry, entry.Expires.Add(retention).Sub(responseTime)
+}
+
+// evaluate determines how a stored response may be used to answer a request (RFC 9111, Section 4).
+func (transport *Transport) evaluate(
+	c *cached,
+	requestCacheControl *motmedelHttpTypes.CacheControl,
+	now time.Time,
+) usability {
+	responseCacheControl := c.cacheControl
+	if responseCacheControl.NoCache() || requestCacheControl.NoCache() {
+		return unusable
+	}
+
+	age := c.age(now)
+	if maxAge, ok := getDeltaSeconds(requestCacheControl.MaxAge); ok && age > maxAge {
+		return unusable
+	}
+
+	minFresh, _ := getDeltaSeconds(requestCacheControl.MinFresh)
+	if age+minFresh < c.freshnessLifetime() {
+		return usable
+	}
+
+	if responseCacheControl.MustRevalidate() {
+		return unusable
+	}
+
+	staleness := c.staleness(now)
+	if maxStale, hasValue, err := requestCacheControl.MaxStale(); err == nil {
+		if !hasValue || staleness <= time.Duration(maxStale)*time.Second {
+			return usable
+		}
+	}
+
+	if staleWhileRevalidate, ok := getDeltaSeconds(responseCacheControl.StaleWhileRevalidate); ok {
+		if staleness <= staleWhileRevalidate {
+			return usableWhileRevalidating
+		}
+	}
+
+	return unusable
+}
+
+// isUsableOnError reports whether a stored response may be used in place of an error, the request
+// failing or its response being an error (RFC 5861, Section 4).
+func isUsableOnError(
+	c *cached,
+	requestCacheControl *motmedelHttpTypes.CacheControl,
+	response *http.Response,
+	err error,
+	now time.Time,
+) bool {
+	if err == nil {
+		if _, ok := errorStatusCodes[response.StatusCode]; !ok {
+			return false
+		}
+	}
+
+	if c.cacheControl.MustRevalidate() || c.cacheControl.NoCache() {
+		return false
+	}
+
+	window, ok := getDeltaSeconds(requestCacheControl.StaleIfError)
+	if !ok {
+		window, ok = getDeltaSeconds(c.cacheControl.StaleIfError)
+	}
+
+	return ok && c.staleness(now) <= window
+}
+
+// store stores the response to the request, if it is to be, returning the response with its body
+// read where it is stored.
+func (transport *Transport) store(
+	request *http.Request,
+	key string,
+	response *http.Response,
+	requestTime time.Time,
+	responseTime time.Time,
+) (*http.Response, error) {
+	entry, ttl := transport.makeEntry(request.Header, response.StatusCode, response.Header, requestTime, responseTime)
+	if entry == nil || ttl <= 0 || response.Body == nil {
+		return response, nil
+	}
+
+	responseBody := response.Body
+	maxEntrySize := transport.config.MaxEntrySize
+	body, err := io.ReadAll(io.LimitReader(responseBody, maxEntrySize+1))
+	if err != nil {
+		_ = responseBody.Close()
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("io read all (response body): %w", err))
+	}
+
+	if int64(len(body)) > maxEntrySize {
+		// Too large to be stored; what was read is read again before the rest.
+		response.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), responseBody), responseBody}
+		return response, nil
+	}
+
+	_ = responseBody.Close()
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry.Body = body
+	transport.set(request.Context(), key, request.Header, entry, ttl)
+
+	return response, nil
+}
+
+// forward sends the request on, made conditional if there is a stored response it can revalidate.
+func (transport *Transport) forward(
+	request *http.Request,
+	key string,
+	c *cached,
+	requestCacheControl *motmedelHttpTypes.CacheControl,
+) (*http.Response, error) {
+	ctx := request.Context()
+
+	forwardRequest := request
+	if c != nil && c.hasValidator() {
+		forwardRequest = request.Clone(ctx)
+		if etag := c.header.Get("Etag"); etag != "" {
+			forwardRequest.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := c.header.Get("Last-Modified"); lastModified != "" {
+			forwardRequest.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	requestTime := transport.config.Now()
+	response, err := transport.config.Next.RoundTrip(forwardRequest)
+	responseTime := transport.config.Now()
+
+	if c != nil && ctx.Err() == nil && isUsableOnError(c, requestCacheControl, response, err, responseTime) {
+		if err == nil {
+			discard(response)
+		}
+		return c.makeResponse(request, responseTime), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("http response"))
+	}
+
+	if forwardRequest != request && response.StatusCode == http.StatusNotModified {
+		discard(response)
+
+		// The stored response is updated with the headers of the 304 response, and is fresh again.
+		header := c.header.Clone()
+		for name, values := range response.Header {
+			if _, ok := notUpdatedHeaderNames[name]; !ok {
+				header[name] = values
+			}
+		}
+
+		entry, ttl := transport.makeEntry(request.Header, c.entry.StatusCode, header, requestTime, responseTime)
+		if entry == nil || ttl <= 0 {
+			transport.delete(ctx, key)
+			return c.makeResponse(request, responseTime), nil
+		}
+
+		entry.Body = c.entry.Body
+		transport.set(ctx, key, request.Header, entry, ttl)
+
+		return newCached(entry).makeResponse(request, responseTime), nil
+	}
+
+	return transport.store(request, key, response, requestTime, responseTime)
+}
+
+// revalidateInBackground revalidates a stored response that is used while it is stale, unless it
+// is being revalidated already.
+func (transport *Transport) revalidateInBackground(request *http.Request, key string, c *cached) {
+	transport.mutex.Lock()
+	if _, ok := transport.revalidating[key]; ok {
+		transport.mutex.Unlock()
+		return
+	}
+	transport.revalidating[key] = struct{}{}
+	transport.mutex.Unlock()
+
+	// The revalidation outlives the request it is made for.
+	ctx := context.WithoutCancel(request.Context())
+	backgroundRequest := request.Clone(ctx)
+
+	transport.waitGroup.Go(func() {
+		defer func() {
+			transport.mutex.Lock()
+			delete(transport.revalidating, key)
+			transport.mutex.Unlock()
+		}()
+
+		response, err := transport.forward(backgroundRequest, key, c, &motmedelHttpTypes.CacheControl{})
+		if err != nil {
+			slog.WarnContext(
+				motmedelContext.WithError(ctx, motmedelErrors.New(fmt.Errorf("forward: %w", err), key)),
+				"An error occurred when revalidating a cached response in the background.",
+			)
+			return
+		}
+		discard(response)
+	})
+}
+
+func (transport *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("request"))
+	}
+	if request.URL == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("request url"))
+	}
+
+	ctx := request.Context()
+	key := transport.makeKey(request)
+	next := transport.config.Next
+
+	bypass := request.Method != http.MethodGet || slices.ContainsFunc(
+		bypassHeaderNames,
+		func(name string) bool { return len(request.Header.Values(name)) != 0 },
+	)
+
+	requestCacheControl, _ := parseCacheControl(request.Header.Values("Cache-Control"))
+	if bypass || requestCacheControl.NoStore() {
+		response, err := next.RoundTrip(request)
+		if err == nil && response != nil && !isSafeMethod(request.Method) && response.StatusCode < 400 {
+			transport.delete(ctx, key)
+		}
+		return response, err
+	}
+
+	now := transport.config.Now()
+	c := transport.lookup(ctx, key, request.Header)
+	if c != nil && isAuthorized(request.Header) && !c.cacheControl.Public() {
+		// The stored response was made for a request without the credentials.
+		c = nil
+	}
+	if c != nil {
+		switch transport.evaluate(c, requestCacheControl, now) {
+		case usable:
+			return c.makeResponse(request, now), nil
+		case usableWhileRevalidating:
+			transport.revalidateInBackground(request, key, c)
+			return c.makeResponse(request, now), nil
+		default:
+		}
+	}
+
+	if requestCacheControl.OnlyIfCached() {
+		return makeGatewayTimeoutResponse(request), nil
+	}
+
+	return transport.forward(request, key, c, requestCacheControl)
+}
+
+// Wait waits for the revalidations in the background to finish.
+func (transport *Transport) Wait() {
+	transport.waitGroup.Wait()
+}
+
+func New(options ...client_cache_config.Option) *Transport {
+	return &Transport{
+		config:       client_cache_config.New(options...),
+		revalidating: make(map[string]struct{}),
+	}
+}
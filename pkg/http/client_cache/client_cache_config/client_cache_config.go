@@ -0,0 +1,113 @@
+package client_cache_config
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_cache/store"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_cache/store/memory_store"
+)
+
+var (
+	DefaultMaxEntrySize int64 = 1 << 20
+)
+
+const (
+	DefaultHeuristicFraction     = 0.1
+	DefaultMaxHeuristicFreshness = 24 * time.Hour
+	DefaultStaleRetention        = 24 * time.Hour
+)
+
+type Config struct {
+	// Next sends the requests the cache cannot answer; http.DefaultTransport is used when none is
+	// set.
+	Next http.RoundTripper
+	// Store holds the cached responses; a new in-memory store is used when none is set. It is the
+	// store of the server-side response cache, so that the two can share a backend.
+	Store store.Store
+	// KeyPrefix is prepended to the keys in the store, so that caches can share one.
+	KeyPrefix string
+	// MaxEntrySize is the size of the largest body that is cached.
+	MaxEntrySize int64
+	// HeuristicFraction is the fraction of the time since a response was last modified that it is
+	// fresh for when it states no freshness lifetime (RFC 9111, Section 4.2.2); MaxHeuristicFreshness
+	// caps that lifetime.
+	HeuristicFraction     float64
+	MaxHeuristicFreshness time.Duration
+	// StaleRetention is for how long a stale response that can be revalidated is kept.
+	StaleRetention time.Duration
+	Now            func() time.Time
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		MaxEntrySize:          DefaultMaxEntrySize,
+		HeuristicFraction:     DefaultHeuristicFraction,
+		MaxHeuristicFreshness: DefaultMaxHeuristicFreshness,
+		StaleRetention:        DefaultStaleRetention,
+		Now:                   time.Now,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	if config.Next == nil {
+		config.Next = http.DefaultTransport
+	}
+
+	if config.Store == nil {
+		config.Store = memory_store.New(memory_store.DefaultMaxEntries, memory_store.DefaultMaxBytes)
+	}
+
+	return config
+}
+
+func WithNext(next http.RoundTripper) Option {
+	return func(config *Config) {
+		config.Next = next
+	}
+}
+
+func WithStore(store store.Store) Option {
+	return func(config *Config) {
+		config.Store = store
+	}
+}
+
+func WithKeyPrefix(keyPrefix string) Option {
+	return func(config *Config) {
+		config.KeyPrefix = keyPrefix
+	}
+}
+
+func WithMaxEntrySize(maxEntrySize int64) Option {
+	return func(config *Config) {
+		config.MaxEntrySize = maxEntrySize
+	}
+}
+
+func WithHeuristicFraction(heuristicFraction float64) Option {
+	return func(config *Config) {
+		config.HeuristicFraction = heuristicFraction
+	}
+}
+
+func WithMaxHeuristicFreshness(maxHeuristicFreshness time.Duration) Option {
+	return func(config *Config) {
+		config.MaxHeuristicFreshness = maxHeuristicFreshness
+	}
+}
+
+func WithStaleRetention(staleRetention time.Duration) Option {
+	return func(config *Config) {
+		config.StaleRetention = staleRetention
+	}
+}
+
+func WithNow(now func() time.Time) Option {
+	return func(config *Config) {
+		config.Now = now
+	}
+}
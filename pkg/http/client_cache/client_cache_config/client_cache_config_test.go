@@ -0,0 +1,74 @@
+package client_cache_config
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_cache/store/memory_store"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.Next != http.DefaultTransport {
+		t.Errorf("next: got %v", config.Next)
+	}
+	if config.MaxEntrySize != DefaultMaxEntrySize {
+		t.Errorf("max entry size: got %d", config.MaxEntrySize)
+	}
+	if config.HeuristicFraction != DefaultHeuristicFraction || config.MaxHeuristicFreshness != DefaultMaxHeuristicFreshness {
+		t.Errorf("heuristic: got %v, %v", config.HeuristicFraction, config.MaxHeuristicFreshness)
+	}
+	if config.StaleRetention != DefaultStaleRetention {
+		t.Errorf("stale retention: got %v", config.StaleRetention)
+	}
+	if config.Now == nil {
+		t.Error("expected a default now function")
+	}
+	if _, ok := config.Store.(*memory_store.Store); !ok {
+		t.Errorf("expected a default memory store, got %T", config.Store)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	next := &http.Transport{}
+	store := memory_store.New(1, 0)
+	now := time.Unix(1, 0)
+
+	config := New(
+		WithNext(next),
+		WithStore(store),
+		WithKeyPrefix("client:"),
+		WithMaxEntrySize(10),
+		WithHeuristicFraction(0.5),
+		WithMaxHeuristicFreshness(time.Hour),
+		WithStaleRetention(time.Minute),
+		WithNow(func() time.Time { return now }),
+	)
+
+	if config.Next != next {
+		t.Errorf("next: got %v", config.Next)
+	}
+	if config.Store != store {
+		t.Errorf("store: got %v", config.Store)
+	}
+	if config.KeyPrefix != "client:" {
+		t.Errorf("key prefix: got %q", config.KeyPrefix)
+	}
+	if config.MaxEntrySize != 10 {
+		t.Errorf("max entry size: got %d", config.MaxEntrySize)
+	}
+	if config.HeuristicFraction != 0.5 || config.MaxHeuristicFreshness != time.Hour {
+		t.Errorf("heuristic: got %v, %v", config.HeuristicFraction, config.MaxHeuristicFreshness)
+	}
+	if config.StaleRetention != time.Minute {
+		t.Errorf("stale retention: got %v", config.StaleRetention)
+	}
+	if !config.Now().Equal(now) {
+		t.Errorf("now: got %v", config.Now())
+	}
+}
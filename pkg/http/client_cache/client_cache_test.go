@@ -0,0 +1,603 @@
+package client_cache
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/client_cache/client_cache_config"
+)
+
+var (
+	testStart = time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	errOrigin = errors.New("origin error")
+)
+
+// origin is a next round tripper that answers with the responses a function makes, recording the
+// requests it is sent.
+type origin struct {
+	mutex    sync.Mutex
+	requests []*http.Request
+	respond  func(request *http.Request, calls int) (*http.Response, error)
+}
+
+func (o *origin) RoundTrip(request *http.Request) (*http.Response, error) {
+	o.mutex.Lock()
+	o.requests = append(o.requests, request)
+	calls := len(o.requests)
+	o.mutex.Unlock()
+
+	return o.respond(request, calls)
+}
+
+func (o *origin) calls() int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return len(o.requests)
+}
+
+func (o *origin) lastRequest() *http.Request {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.requests[len(o.requests)-1]
+}
+
+func makeOriginResponse(statusCode int, body string, header ...string) *http.Response {
+	response := &http.Response{
+		StatusCode: statusCode,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		response.Header.Add(header[i], header[i+1])
+	}
+
+	return response
+}
+
+// newTestTransport makes a transport whose clock is advanced by the returned function.
+func newTestTransport(
+	o *origin,
+	options ...client_cache_config.Option,
+) (*Transport, func(time.Duration)) {
+	var mutex sync.Mutex
+	now := testStart
+
+	transport := New(
+		append(
+			[]client_cache_config.Option{
+				client_cache_config.WithNext(o),
+				client_cache_config.WithNow(func() time.Time {
+					mutex.Lock()
+					defer mutex.Unlock()
+					return now
+				}),
+			},
+			options...,
+		)...,
+	)
+
+	return transport, func(duration time.Duration) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		now = now.Add(duration)
+	}
+}
+
+func get(t *testing.T, transport *Transport, target string, header ...string) (*http.Response, string) {
+	t.Helper()
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, target, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		request.Header.Add(header[i], header[i+1])
+	}
+
+	response, err := transport.RoundTrip(request)
+	if err != nil {
+		t.Fatalf("round trip: %v", err)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	_ = response.Body.Close()
+
+	return response, string(body)
+}
+
+func TestTransport_freshness(t *testing.T) {
+	t.Parallel()
+
+	date := testStart.Format(http.TimeFormat)
+
+	testCases := []struct {
+		name       string
+		header     []string
+		freshFor   time.Duration
+		wantStored bool
+	}{
+		{name: "max-age", header: []string{"Cache-Control", "max-age=60"}, freshFor: time.Minute, wantStored: true},
+		{
+			name:       "max-age over expires",
+			header:     []string{"Cache-Control", "max-age=60", "Expires", testStart.Add(time.Hour).Format(http.TimeFormat)},
+			freshFor:   time.Minute,
+			wantStored: true,
+		},
+		{
+			name:       "expires",
+			header:     []string{"Date", date, "Expires", testStart.Add(2 * time.Minute).Format(http.TimeFormat)},
+			freshFor:   2 * time.Minute,
+			wantStored: true,
+		},
+		{
+			name:       "age",
+			header:     []string{"Cache-Control", "max-age=60", "Age", "30"},
+			freshFor:   30 * time.Second,
+			wantStored: true,
+		},
+		{
+			name:       "heuristic",
+			header:     []string{"Date", date, "Last-Modified", testStart.Add(-100 * time.Minute).Format(http.TimeFormat)},
+			freshFor:   10 * time.Minute,
+			wantStored: true,
+		},
+		{
+			name:       "heuristic, capped",
+			header:     []string{"Date", date, "Last-Modified", testStart.Add(-100 * 24 * time.Hour).Format(http.TimeFormat)},
+			freshFor:   24 * time.Hour,
+			wantStored: true,
+		},
+		{name: "no-store", header: []string{"Cache-Control", "no-store, max-age=60"}},
+		{name: "vary all", header: []string{"Cache-Control", "max-age=60", "Vary", "*"}},
+		{name: "no freshness", header: []string{"Content-Type", "text/plain"}},
+		{name: "invalid cache control", header: []string{"Cache-Control", "max-age=x"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := &origin{
+				respond: func(_ *http.Request, calls int) (*http.Response, error) {
+					return makeOriginResponse(http.StatusOK, "body", testCase.header...), nil
+				},
+			}
+			transport, advance := newTestTransport(o)
+
+			get(t, transport, "https://example.com/a")
+			response, body := get(t, transport, "https://example.com/a")
+			if body != "body" {
+				t.Errorf("body: got %q", body)
+			}
+
+			if !testCase.wantStored {
+				if o.calls() != 2 {
+					t.Errorf("expected the response not to be stored, got %d calls", o.calls())
+				}
+				return
+			}
+			if o.calls() != 1 {
+				t.Fatalf("expected the response to be stored, got %d calls", o.calls())
+			}
+			if response.StatusCode != http.StatusOK || response.Header.Get("Age") == "" {
+				t.Errorf("unexpected cached response: %d, age %q", response.StatusCode, response.Header.Get("Age"))
+			}
+
+			advance(testCase.freshFor - time.Second)
+			if get(t, transport, "https://example.com/a"); o.calls() != 1 {
+				t.Errorf("expected the response to be fresh, got %d calls", o.calls())
+			}
+
+			advance(time.Second)
+			if get(t, transport, "https://example.com/a"); o.calls() != 2 {
+				t.Errorf("expected the response to be stale, got %d calls", o.calls())
+			}
+		})
+	}
+}
+
+func TestTransport_revalidation(t *testing.T) {
+	t.Parallel()
+
+	o := &origin{
+		respond: func(request *http.Request, calls int) (*http.Response, error) {
+			if request.Header.Get("If-None-Match") == `"v1"` {
+				return makeOriginResponse(http.StatusNotModified, "", "Cache-Control", "max-age=120", "Etag", `"v1"`), nil
+			}
+			return makeOriginResponse(
+				http.StatusOK, "body",
+				"Cache-Control", "max-age=60",
+				"Etag", `"v1"`,
+				"Last-Modified", testStart.Format(http.TimeFormat),
+				"Content-Type", "text/plain",
+			), nil
+		},
+	}
+	transport, advance := newTestTransport(o)
+
+	get(t, transport, "https://example.com/a")
+	advance(time.Minute)
+
+	response, body := get(t, transport, "https://example.com/a")
+	if o.calls() != 2 {
+		t.Fatalf("expected a revalidation, got %d calls", o.calls())
+	}
+	request := o.lastRequest()
+	if request.Header.Get("If-None-Match") != `"v1"` || request.Header.Get("If-Modified-Since") == "" {
+		t.Errorf("expected a conditional request, got %v", request.Header)
+	}
+	if response.StatusCode != http.StatusOK || body != "body" {
+		t.Errorf("expected the stored response, got %d %q", response.StatusCode, body)
+	}
+	if response.Header.Get("Content-Type") != "text/plain" || response.Header.Get("Cache-Control") != "max-age=120" {
+		t.Errorf("expected the stored headers updated, got %v", response.Header)
+	}
+
+	// The response is fresh for the max-age of the 304 response now.
+	advance(119 * time.Second)
+	if get(t, transport, "https://example.com/a"); o.calls() != 2 {
+		t.Errorf("expected the updated response to be fresh, got %d calls", o.calls())
+	}
+}
+
+func TestTransport_requestCacheControl(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		advance       time.Duration
+		cacheControl  string
+		responseCache string
+		wantCalls     int
+		wantStatus    int
+	}{
+		{name: "no-cache", cacheControl: "no-cache", wantCalls: 2, wantStatus: http.StatusOK},
+		{name: "max-age", advance: 30 * time.Second, cacheControl: "max-age=10", wantCalls: 2, wantStatus: http.StatusOK},
+		{name: "min-fresh", advance: 30 * time.Second, cacheControl: "min-fresh=40", wantCalls: 2, wantStatus: http.StatusOK},
+		{name: "max-stale", advance: 90 * time.Second, cacheControl: "max-stale=60", wantCalls: 1, wantStatus: http.StatusOK},
+		{name: "max-stale, any", advance: time.Hour, cacheControl: "max-stale", wantCalls: 1, wantStatus: http.StatusOK},
+		{
+			name:          "max-stale, must-revalidate",
+			advance:       90 * time.Second,
+			cacheControl:  "max-stale",
+			responseCache: ", must-revalidate",
+			wantCalls:     2,
+			wantStatus:    http.StatusOK,
+		},
+		{name: "only-if-cached", cacheControl: "only-if-cached", wantCalls: 1, wantStatus: http.StatusOK},
+		{
+			name:         "only-if-cached, stale",
+			advance:      90 * time.Second,
+			cacheControl: "only-if-cached",
+			wantCalls:    1,
+			wantStatus:   http.StatusGatewayTimeout,
+		},
+		{name: "no-store", cacheControl: "no-store", wantCalls: 2, wantStatus: http.StatusOK},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := &origin{
+				respond: func(*http.Request, int) (*http.Response, error) {
+					return makeOriginResponse(http.StatusOK, "body", "Cache-Control", "max-age=60"+testCase.responseCache), nil
+				},
+			}
+			transport, advance := newTestTransport(o)
+
+			get(t, transport, "https://example.com/a")
+			advance(testCase.advance)
+
+			response, _ := get(t, transport, "https://example.com/a", "Cache-Control", testCase.cacheControl)
+			if o.calls() != testCase.wantCalls {
+				t.Errorf("calls: got %d, want %d", o.calls(), testCase.wantCalls)
+			}
+			if response.StatusCode != testCase.wantStatus {
+				t.Errorf("status: got %d, want %d", response.StatusCode, testCase.wantStatus)
+			}
+		})
+	}
+}
+
+func TestTransport_vary(t *testing.T) {
+	t.Parallel()
+
+	o := &origin{
+		respond: func(request *http.Request, _ int) (*http.Response, error) {
+			return makeOriginResponse(
+				http.StatusOK, request.Header.Get("Accept-Language"),
+				"Cache-Control", "max-age=60",
+				"Vary", "Accept-Language",
+			), nil
+		},
+	}
+	transport, _ := newTestTransport(o)
+
+	if _, body := get(t, transport, "https://example.com/a", "Accept-Language", "sv"); body != "sv" {
+		t.Errorf("body: got %q", body)
+	}
+	if _, body := get(t, transport, "https://example.com/a", "Accept-Language", "sv"); body != "sv" || o.calls() != 1 {
+		t.Errorf("expected the stored variant, got %q after %d calls", body, o.calls())
+	}
+	if _, body := get(t, transport, "https://example.com/a", "Accept-Language", "en"); body != "en" || o.calls() != 2 {
+		t.Errorf("expected another variant, got %q after %d calls", body, o.calls())
+	}
+}
+
+func TestTransport_staleWhileRevalidate(t *testing.T) {
+	t.Parallel()
+
+	o := &origin{
+		respond: func(_ *http.Request, calls int) (*http.Response, error) {
+			body := "first"
+			if calls > 1 {
+				body = "second"
+			}
+			return makeOriginResponse(http.StatusOK, body, "Cache-Control", "max-age=60, stale-while-revalidate=30"), nil
+		},
+	}
+	transport, advance := newTestTransport(o)
+
+	get(t, transport, "https://example.com/a")
+	advance(80 * time.Second)
+
+	if _, body := get(t, transport, "https://example.com/a"); body != "first" {
+		t.Errorf("expected the stale response, got %q", body)
+	}
+	transport.Wait()
+	if o.calls() != 2 {
+		t.Fatalf("expected a revalidation in the background, got %d calls", o.calls())
+	}
+
+	if _, body := get(t, transport, "https://example.com/a"); body != "second" || o.calls() != 2 {
+		t.Errorf("expected the revalidated response, got %q after %d calls", body, o.calls())
+	}
+
+	// Past the window, the request waits for the response.
+	advance(100 * time.Second)
+	if _, body := get(t, transport, "https://example.com/a"); body != "second" || o.calls() != 3 {
+		t.Errorf("expected a new response, got %q after %d calls", body, o.calls())
+	}
+	transport.Wait()
+}
+
+func TestTransport_staleIfError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		responseCache string
+		requestCache  string
+		advance       time.Duration
+		failure       func() (*http.Response, error)
+		wantStale     bool
+	}{
+		{
+			name:          "error",
+			responseCache: "max-age=60, stale-if-error=60",
+			advance:       90 * time.Second,
+			failure:       func() (*http.Response, error) { return nil, errOrigin },
+			wantStale:     true,
+		},
+		{
+			name:          "status",
+			responseCache: "max-age=60, stale-if-error=60",
+			advance:       90 * time.Second,
+			failure:       func() (*http.Response, error) { return makeOriginResponse(http.StatusServiceUnavailable, "down"), nil },
+			wantStale:     true,
+		},
+		{
+			name:          "request directive",
+			responseCache: "max-age=60",
+			requestCache:  "stale-if-error=60",
+			advance:       90 * time.Second,
+			failure:       func() (*http.Response, error) { return makeOriginResponse(http.StatusBadGateway, "down"), nil },
+			wantStale:     true,
+		},
+		{
+			name:          "past the window",
+			responseCache: "max-age=60, stale-if-error=60",
+			advance:       150 * time.Second,
+			failure:       func() (*http.Response, error) { return makeOriginResponse(http.StatusServiceUnavailable, "down"), nil },
+		},
+		{
+			name:          "must-revalidate",
+			responseCache: "max-age=60, stale-if-error=60, must-revalidate",
+			advance:       90 * time.Second,
+			failure:       func() (*http.Response, error) { return makeOriginResponse(http.StatusServiceUnavailable, "down"), nil },
+		},
+		{
+			name:          "not an error",
+			responseCache: "max-age=60, stale-if-error=60",
+			advance:       90 * time.Second,
+			failure:       func() (*http.Response, error) { return makeOriginResponse(http.StatusNotFound, "gone"), nil },
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := &origin{
+				respond: func(_ *http.Request, calls int) (*http.Response, error) {
+					if calls > 1 {
+						return testCase.failure()
+					}
+					return makeOriginResponse(http.StatusOK, "body", "Cache-Control", testCase.responseCache), nil
+				},
+			}
+			transport, advance := newTestTransport(o)
+
+			get(t, transport, "https://example.com/a")
+			advance(testCase.advance)
+
+			request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "https://example.com/a", nil)
+			if err != nil {
+				t.Fatalf("new request: %v", err)
+			}
+			if testCase.requestCache != "" {
+				request.Header.Set("Cache-Control", testCase.requestCache)
+			}
+
+			response, err := transport.RoundTrip(request)
+			if o.calls() != 2 {
+				t.Fatalf("expected the request to be sent, got %d calls", o.calls())
+			}
+			isStale := err == nil && response.StatusCode == http.StatusOK
+			if isStale != testCase.wantStale {
+				t.Errorf("stale: got %v (%v, %v)", isStale, response, err)
+			}
+		})
+	}
+}
+
+func TestTransport_bypass(t *testing.T) {
+	t.Parallel()
+
+	o := &origin{
+		respond: func(*http.Request, int) (*http.Response, error) {
+			return makeOriginResponse(http.StatusOK, "body", "Cache-Control", "max-age=60", "Etag", `"v1"`), nil
+		},
+	}
+	transport, _ := newTestTransport(o)
+
+	get(t, transport, "https://example.com/a")
+	get(t, transport, "https://example.com/a", "Range", "bytes=0-1")
+	get(t, transport, "https://example.com/a", "If-None-Match", `"v0"`)
+	if o.calls() != 3 {
+		t.Fatalf("expected the ranged and conditional requests to be sent, got %d calls", o.calls())
+	}
+	if request := o.lastRequest(); request.Header.Get("If-None-Match") != `"v0"` {
+		t.Errorf("expected the conditional request as it was, got %v", request.Header)
+	}
+
+	// A successful unsafe request invalidates what is stored for its URL.
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "https://example.com/a", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	if _, err := transport.RoundTrip(request); err != nil {
+		t.Fatalf("round trip: %v", err)
+	}
+
+	get(t, transport, "https://example.com/a")
+	if o.calls() != 5 {
+		t.Errorf("expected the stored response to be invalidated, got %d calls", o.calls())
+	}
+}
+
+func TestTransport_authorization(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		cacheControl string
+		first        []string
+		second       []string
+		wantCalls    int
+		wantBody     string
+	}{
+		{
+			name:         "private response to an authorized request",
+			cacheControl: "max-age=60",
+			first:        []string{"Authorization", "Bearer alice"},
+			second:       []string{"Authorization", "Bearer bob"},
+			wantCalls:    2,
+			wantBody:     "Bearer bob",
+		},
+		{
+			name:         "public response to an authorized request",
+			cacheControl: "public, max-age=60",
+			first:        []string{"Authorization", "Bearer alice"},
+			second:       []string{"Authorization", "Bearer bob"},
+			wantCalls:    1,
+			wantBody:     "Bearer alice",
+		},
+		{
+			name:         "private response used for an authorized request",
+			cacheControl: "max-age=60",
+			second:       []string{"Authorization", "Bearer bob"},
+			wantCalls:    2,
+			wantBody:     "Bearer bob",
+		},
+		{
+			name:         "public response used for an authorized request",
+			cacheControl: "public, max-age=60",
+			second:       []string{"Authorization", "Bearer bob"},
+			wantCalls:    1,
+			wantBody:     "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := &origin{
+				respond: func(request *http.Request, _ int) (*http.Response, error) {
+					return makeOriginResponse(
+						http.StatusOK, request.Header.Get("Authorization"),
+						"Cache-Control", testCase.cacheControl,
+					), nil
+				},
+			}
+			transport, _ := newTestTransport(o)
+
+			get(t, transport, "https://example.com/a", testCase.first...)
+			_, body := get(t, transport, "https://example.com/a", testCase.second...)
+			if o.calls() != testCase.wantCalls {
+				t.Errorf("calls: got %d, want %d", o.calls(), testCase.wantCalls)
+			}
+			if body != testCase.wantBody {
+				t.Errorf("body: got %q, want %q", body, testCase.wantBody)
+			}
+		})
+	}
+}
+
+func TestTransport_maxEntrySize(t *testing.T) {
+	t.Parallel()
+
+	o := &origin{
+		respond: func(*http.Request, int) (*http.Response, error) {
+			return makeOriginResponse(http.StatusOK, "0123456789", "Cache-Control", "max-age=60"), nil
+		},
+	}
+	transport, _ := newTestTransport(o, client_cache_config.WithMaxEntrySize(4))
+
+	if _, body := get(t, transport, "https://example.com/a"); body != "0123456789" {
+		t.Errorf("expected the whole body, got %q", body)
+	}
+	if get(t, transport, "https://example.com/a"); o.calls() != 2 {
+		t.Errorf("expected the response not to be stored, got %d calls", o.calls())
+	}
+}
+
+func TestTransport_error(t *testing.T) {
+	t.Parallel()
+
+	o := &origin{respond: func(*http.Request, int) (*http.Response, error) { return nil, errOrigin }}
+	transport, _ := newTestTransport(o)
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "https://example.com/a", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	if _, err := transport.RoundTrip(request); !errors.Is(err, errOrigin) {
+		t.Errorf("expected the origin error, got %v", err)
+	}
+	if _, err := transport.RoundTrip(nil); err == nil {
+		t.Error("expected an error for a nil request")
+	}
+}
@@ -13,6 +13,14 @@ var (
 	ErrMultipleHeaderValues         = errors.New("multiple header values")
 	ErrMissingQueryParameter        = errors.New("missing query parameter")
 	ErrMultipleQueryParameterValues = errors.New("multiple query parameter values")
+	ErrDigestMismatch               = errors.New("digest mismatch")
+	ErrUnsupportedDigestAlgorithm   = errors.New("unsupported digest algorithm")
+	ErrMissingSignature             = errors.New("missing signature")
+	ErrUncoveredComponent           = errors.New("uncovered component")
+	ErrUnsupportedComponent         = errors.New("unsupported component")
+	ErrSignatureExpired             = errors.New("signature expired")
+	ErrCircuitOpen                  = errors.New("circuit open")
+	ErrResponseTooLarge             = errors.New("response too large")
 )
 
 type Non2xxStatusCodeError struct {
@@ -38,6 +46,25 @@ func (non2xxStatusCodeError *Non2xxStatusCodeError) GetCode() string {
 	return strconv.Itoa(non2xxStatusCodeError.StatusCode)
 }
 
+// ResponseTooLargeError records that a response body was larger than the limit. ContentLength is
+// that stated by the response, or -1 when the body was found too large as it was read.
+type ResponseTooLargeError struct {
+	Limit         int64
+	ContentLength int64
+}
+
+func (responseTooLargeError *ResponseTooLargeError) Is(target error) bool {
+	return target == ErrResponseTooLarge
+}
+
+func (responseTooLargeError *ResponseTooLargeError) Error() string {
+	return ErrResponseTooLarge.Error()
+}
+
+func (responseTooLargeError *ResponseTooLargeError) GetInput() any {
+	return responseTooLargeError.Limit
+}
+
 type ReattemptFailedError struct {
 	Attempt int
 	Cause   error
@@ -55,6 +55,25 @@ func TestNon2xxStatusCodeErrorIsFalse(t *testing.T) {
 	}
 }
 
+func TestResponseTooLargeError(t *testing.T) {
+	t.Parallel()
+
+	err := &ResponseTooLargeError{Limit: 10, ContentLength: -1}
+
+	if err.Error() != ErrResponseTooLarge.Error() {
+		t.Errorf("Error() = %q, want %q", err.Error(), ErrResponseTooLarge.Error())
+	}
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Error("errors.Is(err, ErrResponseTooLarge) = false, want true")
+	}
+	if errors.Is(err, ErrNon2xxStatusCode) {
+		t.Error("errors.Is(err, ErrNon2xxStatusCode) = true, want false")
+	}
+	if got := err.GetInput(); got != int64(10) {
+		t.Errorf("GetInput() = %v, want 10", got)
+	}
+}
+
 func TestReattemptFailedError(t *testing.T) {
 	t.Parallel()
 
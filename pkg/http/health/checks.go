@@ -0,0 +1,69 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	healthTypes "github.com/Motmedel/utils_go/pkg/http/health/types"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
+	motmedelHttpUtils "github.com/Motmedel/utils_go/pkg/http/utils"
+)
+
+// Pinger is what a database is checked through; *sql.DB is one.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingCheck checks a database by pinging it, which establishes a connection where the pool has
+// none.
+func PingCheck(name string, pinger Pinger) *healthTypes.Check {
+	return &healthTypes.Check{
+		Name: name,
+		Function: func(ctx context.Context) error {
+			if pinger == nil {
+				return motmedelErrors.NewWithTrace(nil_error.New("pinger"))
+			}
+
+			if err := pinger.PingContext(ctx); err != nil {
+				return motmedelErrors.NewWithTrace(fmt.Errorf("pinger ping context: %w", err))
+			}
+
+			return nil
+		},
+	}
+}
+
+// HttpCheck checks a service downstream by requesting the URL, commonly where the service says
+// whether it is ready itself. It passes on a 2xx status.
+func HttpCheck(name string, url string, options ...fetch_config.Option) *healthTypes.Check {
+	return &healthTypes.Check{
+		Name: name,
+		Function: func(ctx context.Context) error {
+			if _, _, err := motmedelHttpUtils.Fetch(ctx, url, options...); err != nil {
+				return fmt.Errorf("fetch: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// DialCheck checks a dependency that speaks no HTTP by connecting to it.
+func DialCheck(name string, network string, address string) *healthTypes.Check {
+	return &healthTypes.Check{
+		Name: name,
+		Function: func(ctx context.Context) error {
+			var dialer net.Dialer
+			connection, err := dialer.DialContext(ctx, network, address)
+			if err != nil {
+				return motmedelErrors.NewWithTrace(fmt.Errorf("dialer dial context: %w", err), network, address)
+			}
+			_ = connection.Close()
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,243 @@
+// Package health serves what a load balancer or an orchestrator probes a service with: whether the
+// process is healthy, and whether the service is ready to be sent requests.
+package health
+
+import (
+	"context"
+	"encoding/json/v2"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	motmedelContext "github.com/Motmedel/utils_go/pkg/context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/health/health_config"
+	healthTypes "github.com/Motmedel/utils_go/pkg/http/health/types"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail/problem_detail_config"
+)
+
+// DrainingCheckName is what a readiness report names the service draining as.
+const DrainingCheckName = "draining"
+
+// Checker runs the checks of a service's health and readiness.
+type Checker struct {
+	config   *health_config.Config
+	draining atomic.Bool
+}
+
+// Drain makes the readiness fail from now on, so that the service is sent no new requests while
+// it finishes those it is handling. It is not undone.
+func (checker *Checker) Drain() {
+	checker.draining.Store(true)
+}
+
+func (checker *Checker) Draining() bool {
+	return checker.draining.Load()
+}
+
+// run runs the checks concurrently, each bounded by its timeout. A check that does not return in
+// time is reported as failed without being waited for.
+func (checker *Checker) run(ctx context.Context, checks []*healthTypes.Check) *healthTypes.Report {
+	config := checker.config
+
+	type namedResult struct {
+		name   string
+		result *healthTypes.CheckResult
+	}
+
+	results := make(chan namedResult, len(checks))
+	for _, check := range checks {
+		go func() {
+			timeout := check.Timeout
+			if timeout <= 0 {
+				timeout = config.Timeout
+			}
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := config.Now()
+
+			// The check is run apart from the waiting, so that one that ignores its context is not
+			// waited for beyond the timeout.
+			done := make(chan error, 1)
+			go func() { done <- check.Function(checkCtx) }()
+
+			result := &healthTypes.CheckResult{Status: healthTypes.StatusPass}
+			select {
+			case err := <-done:
+				if err != nil {
+					result.Status = healthTypes.StatusFail
+					slog.WarnContext(
+						motmedelContext.WithError(ctx, fmt.Errorf("check function: %w", err)),
+						fmt.Sprintf("The %q health check failed.", check.Name),
+					)
+				}
+			case <-checkCtx.Done():
+				result.Status = healthTypes.StatusFail
+				result.Output = "The check timed out."
+			}
+			result.Duration = config.Now().Sub(start).String()
+
+			results <- namedResult{name: check.Name, result: result}
+		}()
+	}
+
+	report := &healthTypes.Report{Status: healthTypes.StatusPass}
+	if len(checks) != 0 {
+		report.Checks = make(map[string]*healthTypes.CheckResult, len(checks))
+	}
+	for range checks {
+		namedResult := <-results
+		report.Checks[namedResult.name] = namedResult.result
+		if namedResult.result.Status != healthTypes.StatusPass {
+			report.Status = healthTypes.StatusFail
+		}
+	}
+
+	return report
+}
+
+// Liveness runs the liveness checks.
+func (checker *Checker) Liveness(ctx context.Context) *healthTypes.Report {
+	return checker.run(ctx, checker.config.LivenessChecks)
+}
+
+// Readiness runs the readiness checks, unless the service is draining, in which case it fails
+// without troubling the dependencies.
+func (checker *Checker) Readiness(ctx context.Context) *healthTypes.Report {
+	if checker.Draining() {
+		return &healthTypes.Report{
+			Status: healthTypes.StatusFail,
+			Checks: map[string]*healthTypes.CheckResult{
+				DrainingCheckName: {
+					Status:   healthTypes.StatusFail,
+					Duration: time.Duration(0).String(),
+					Output:   "The service is shutting down.",
+				},
+			},
+		}
+	}
+
+	return checker.run(ctx, checker.config.ReadinessChecks)
+}
+
+// reportEndpoint serves a report as a problem detail: "200 OK" with the results of the checks
+// where they all passed, and "503 Service Unavailable" with them where one did not.
+func reportEndpoint(path string, makeReport func(context.Context) *healthTypes.Report) *endpoint.Endpoint {
+	return &endpoint.Endpoint{
+		Path:   path,
+		Method: http.MethodGet,
+		Handler: func(request *http.Request, _ []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			report := makeReport(request.Context())
+
+			statusCode := http.StatusOK
+			if !report.Passed() {
+				statusCode = http.StatusServiceUnavailable
+			}
+			detail := problem_detail.New(
+				statusCode,
+				problem_detail_config.WithExtension(map[string]any{"checks": report.Checks}),
+			)
+
+			if statusCode != http.StatusOK {
+				return nil, &muxResponseError.ResponseError{ProblemDetail: detail}
+			}
+
+			data, err := json.Marshal(detail)
+			if err != nil {
+				return nil, &muxResponseError.ResponseError{
+					ServerError: motmedelErrors.NewWithTrace(fmt.Errorf("json marshal (detail): %w", err)),
+				}
+			}
+
+			return &muxResponse.Response{
+				Headers: []*muxResponse.HeaderEntry{
+					{Name: "Content-Type", Value: "application/json"},
+					// A probe is answered as things are now, not as they were when it was cached.
+					{Name: "Cache-Control", Value: "no-store"},
+				},
+				Body: data,
+			}, nil
+		},
+		Public: true,
+	}
+}
+
+// HealthzEndpoint serves the liveness.
+func (checker *Checker) HealthzEndpoint() *endpoint.Endpoint {
+	return reportEndpoint(checker.config.HealthzPath, checker.Liveness)
+}
+
+// ReadyzEndpoint serves the readiness.
+func (checker *Checker) ReadyzEndpoint() *endpoint.Endpoint {
+	return reportEndpoint(checker.config.ReadyzPath, checker.Readiness)
+}
+
+func (checker *Checker) Endpoints() []*endpoint.Endpoint {
+	return []*endpoint.Endpoint{checker.HealthzEndpoint(), checker.ReadyzEndpoint()}
+}
+
+// Paths are the paths the health and the readiness are served at.
+func (checker *Checker) Paths() []string {
+	return []string{checker.config.HealthzPath, checker.config.ReadyzPath}
+}
+
+func validateChecks(checks []*healthTypes.Check) error {
+	names := make(map[string]struct{}, len(checks))
+	for _, check := range checks {
+		if check == nil {
+			return motmedelErrors.NewWithTrace(nil_error.New("check"))
+		}
+
+		name := check.Name
+		if name == "" {
+			return motmedelErrors.NewWithTrace(empty_error.New("check name"))
+		}
+		if check.Function == nil {
+			return motmedelErrors.NewWithTrace(nil_error.New("check function"), name)
+		}
+
+		// A report has one result per name; a second check of it would go unreported.
+		if _, found := names[name]; found {
+			return motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: a check name used more than once", motmedelErrors.ErrValidationError),
+				name,
+			)
+		}
+		names[name] = struct{}{}
+	}
+
+	return nil
+}
+
+func New(options ...health_config.Option) (*Checker, error) {
+	config := health_config.New(options...)
+
+	if config.HealthzPath == "" {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("healthz path"))
+	}
+	if config.ReadyzPath == "" {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("readyz path"))
+	}
+	if config.Now == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("now"))
+	}
+
+	if err := validateChecks(config.LivenessChecks); err != nil {
+		return nil, fmt.Errorf("validate checks (liveness): %w", err)
+	}
+	if err := validateChecks(config.ReadinessChecks); err != nil {
+		return nil, fmt.Errorf("validate checks (readiness): %w", err)
+	}
+
+	return &Checker{config: config}, nil
+}
@@ -0,0 +1,84 @@
+package health_config
+
+import (
+	"time"
+
+	healthTypes "github.com/Motmedel/utils_go/pkg/http/health/types"
+)
+
+const (
+	// DefaultTimeout bounds a check that sets no timeout of its own. It is under the second a
+	// Kubernetes probe waits by default, so that a hung check is reported as having timed out rather
+	// than the probe timing out with nothing said.
+	DefaultTimeout = 800 * time.Millisecond
+
+	DefaultHealthzPath = "/healthz"
+	DefaultReadyzPath  = "/readyz"
+)
+
+type Config struct {
+	// LivenessChecks are checked at the health path. A failing one says the process is beyond
+	// recovering by itself and is to be restarted, so they are kept to what a restart would fix.
+	LivenessChecks []*healthTypes.Check
+	// ReadinessChecks are checked at the readiness path. A failing one says the service is not to be
+	// sent requests for now: a dependency is unreachable, or the service is draining.
+	ReadinessChecks []*healthTypes.Check
+	Timeout         time.Duration
+	HealthzPath     string
+	ReadyzPath      string
+	Now             func() time.Time
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		Timeout:     DefaultTimeout,
+		HealthzPath: DefaultHealthzPath,
+		ReadyzPath:  DefaultReadyzPath,
+		Now:         time.Now,
+	}
+
+	for _, option := range options {
+		if option != nil {
+			option(config)
+		}
+	}
+
+	return config
+}
+
+// WithLivenessChecks adds checks of whether the process is healthy.
+func WithLivenessChecks(checks ...*healthTypes.Check) Option {
+	return func(config *Config) {
+		config.LivenessChecks = append(config.LivenessChecks, checks...)
+	}
+}
+
+// WithReadinessChecks adds checks of whether the service is ready to be sent requests.
+func WithReadinessChecks(checks ...*healthTypes.Check) Option {
+	return func(config *Config) {
+		config.ReadinessChecks = append(config.ReadinessChecks, checks...)
+	}
+}
+
+// WithTimeout bounds the checks that set no timeout of their own.
+func WithTimeout(timeout time.Duration) Option {
+	return func(config *Config) {
+		config.Timeout = timeout
+	}
+}
+
+// WithPaths sets the paths the health and the readiness are served at.
+func WithPaths(healthzPath string, readyzPath string) Option {
+	return func(config *Config) {
+		config.HealthzPath = healthzPath
+		config.ReadyzPath = readyzPath
+	}
+}
+
+func WithNow(now func() time.Time) Option {
+	return func(config *Config) {
+		config.Now = now
+	}
+}
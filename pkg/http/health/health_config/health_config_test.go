@@ -0,0 +1,58 @@
+package health_config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	healthTypes "github.com/Motmedel/utils_go/pkg/http/health/types"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.Timeout != DefaultTimeout {
+		t.Errorf("timeout: got %v", config.Timeout)
+	}
+	if config.HealthzPath != DefaultHealthzPath || config.ReadyzPath != DefaultReadyzPath {
+		t.Errorf("paths: got %q and %q", config.HealthzPath, config.ReadyzPath)
+	}
+	if config.Now == nil {
+		t.Error("expected a default now function")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	check := func(name string) *healthTypes.Check {
+		return &healthTypes.Check{Name: name, Function: func(context.Context) error { return nil }}
+	}
+	now := time.Unix(1, 0)
+
+	config := New(
+		WithLivenessChecks(check("a")),
+		WithLivenessChecks(check("b")),
+		WithReadinessChecks(check("c")),
+		WithTimeout(time.Second),
+		WithPaths("/live", "/ready"),
+		WithNow(func() time.Time { return now }),
+	)
+
+	if len(config.LivenessChecks) != 2 {
+		t.Errorf("expected the liveness checks to accumulate, got %d", len(config.LivenessChecks))
+	}
+	if len(config.ReadinessChecks) != 1 {
+		t.Errorf("readiness checks: got %d", len(config.ReadinessChecks))
+	}
+	if config.Timeout != time.Second {
+		t.Errorf("timeout: got %v", config.Timeout)
+	}
+	if config.HealthzPath != "/live" || config.ReadyzPath != "/ready" {
+		t.Errorf("paths: got %q and %q", config.HealthzPath, config.ReadyzPath)
+	}
+	if !config.Now().Equal(now) {
+		t.Errorf("now: got %v", config.Now())
+	}
+}
@@ -0,0 +1,256 @@
+package health
+
+import (
+	"context"
+	"encoding/json/v2"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/health/health_config"
+	healthTypes "github.com/Motmedel/utils_go/pkg/http/health/types"
+	motmedelMux "github.com/Motmedel/utils_go/pkg/http/mux"
+)
+
+var errCheck = errors.New("check error")
+
+func passing(name string) *healthTypes.Check {
+	return &healthTypes.Check{Name: name, Function: func(context.Context) error { return nil }}
+}
+
+func failing(name string) *healthTypes.Check {
+	return &healthTypes.Check{Name: name, Function: func(context.Context) error { return errCheck }}
+}
+
+// hanging is a check that ignores its context, returning only once the test is over.
+func hanging(t *testing.T, name string) *healthTypes.Check {
+	return &healthTypes.Check{
+		Name: name,
+		Function: func(context.Context) error {
+			<-t.Context().Done()
+			return nil
+		},
+	}
+}
+
+func TestChecker_Readiness(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		checks      func(t *testing.T) []*healthTypes.Check
+		drain       bool
+		wantStatus  string
+		wantResults map[string]string
+	}{
+		{
+			name:       "no checks",
+			wantStatus: healthTypes.StatusPass,
+		},
+		{
+			name:        "passing",
+			checks:      func(*testing.T) []*healthTypes.Check { return []*healthTypes.Check{passing("a"), passing("b")} },
+			wantStatus:  healthTypes.StatusPass,
+			wantResults: map[string]string{"a": healthTypes.StatusPass, "b": healthTypes.StatusPass},
+		},
+		{
+			name:        "failing",
+			checks:      func(*testing.T) []*healthTypes.Check { return []*healthTypes.Check{passing("a"), failing("b")} },
+			wantStatus:  healthTypes.StatusFail,
+			wantResults: map[string]string{"a": healthTypes.StatusPass, "b": healthTypes.StatusFail},
+		},
+		{
+			name: "timed out",
+			checks: func(t *testing.T) []*healthTypes.Check {
+				return []*healthTypes.Check{passing("a"), hanging(t, "b")}
+			},
+			wantStatus:  healthTypes.StatusFail,
+			wantResults: map[string]string{"a": healthTypes.StatusPass, "b": healthTypes.StatusFail},
+		},
+		{
+			name:        "draining",
+			checks:      func(*testing.T) []*healthTypes.Check { return []*healthTypes.Check{passing("a")} },
+			drain:       true,
+			wantStatus:  healthTypes.StatusFail,
+			wantResults: map[string]string{DrainingCheckName: healthTypes.StatusFail},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var checks []*healthTypes.Check
+			if testCase.checks != nil {
+				checks = testCase.checks(t)
+			}
+
+			checker, err := New(
+				health_config.WithReadinessChecks(checks...),
+				health_config.WithTimeout(50*time.Millisecond),
+			)
+			if err != nil {
+				t.Fatalf("new: %v", err)
+			}
+			if testCase.drain {
+				checker.Drain()
+			}
+
+			report := checker.Readiness(t.Context())
+			if report.Status != testCase.wantStatus {
+				t.Errorf("status: got %q, want %q", report.Status, testCase.wantStatus)
+			}
+			if len(report.Checks) != len(testCase.wantResults) {
+				t.Fatalf("checks: got %d, want %d", len(report.Checks), len(testCase.wantResults))
+			}
+			for name, wantStatus := range testCase.wantResults {
+				result := report.Checks[name]
+				if result == nil || result.Status != wantStatus {
+					t.Errorf("check %q: got %#v, want %q", name, result, wantStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestChecker_Endpoints(t *testing.T) {
+	t.Parallel()
+
+	checker, err := New(
+		health_config.WithLivenessChecks(passing("process")),
+		health_config.WithReadinessChecks(failing("database")),
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	mux := motmedelMux.New(checker.Endpoints()...)
+
+	testCases := []struct {
+		path           string
+		wantStatusCode int
+		wantCheck      string
+	}{
+		{path: health_config.DefaultHealthzPath, wantStatusCode: http.StatusOK, wantCheck: "process"},
+		{path: health_config.DefaultReadyzPath, wantStatusCode: http.StatusServiceUnavailable, wantCheck: "database"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.path, func(t *testing.T) {
+			t.Parallel()
+
+			request := httptest.NewRequestWithContext(t.Context(), http.MethodGet, testCase.path, nil)
+			request.Header.Set("Accept", "application/json, application/problem+json")
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, request)
+
+			if recorder.Code != testCase.wantStatusCode {
+				t.Fatalf("status code: got %d, want %d", recorder.Code, testCase.wantStatusCode)
+			}
+			if cacheControl := recorder.Header().Get("Cache-Control"); testCase.wantStatusCode == http.StatusOK && cacheControl != "no-store" {
+				t.Errorf("cache control: got %q", cacheControl)
+			}
+
+			var body struct {
+				Status int                                 `json:"status"`
+				Checks map[string]*healthTypes.CheckResult `json:"checks"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("json unmarshal: %v (%s)", err, recorder.Body.String())
+			}
+			if body.Status != testCase.wantStatusCode {
+				t.Errorf("body status: got %d", body.Status)
+			}
+			if body.Checks[testCase.wantCheck] == nil {
+				t.Errorf("expected the %q check in %s", testCase.wantCheck, recorder.Body.String())
+			}
+		})
+	}
+}
+
+type pinger struct {
+	err error
+}
+
+func (pinger *pinger) PingContext(context.Context) error {
+	return pinger.err
+}
+
+func TestChecks(t *testing.T) {
+	t.Parallel()
+
+	downstream := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/readyz" {
+			responseWriter.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(downstream.Close)
+
+	var listenConfig net.ListenConfig
+	listener, err := listenConfig.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen config listen: %v", err)
+	}
+	closedAddress := listener.Addr().String()
+	_ = listener.Close()
+
+	testCases := []struct {
+		name    string
+		check   *healthTypes.Check
+		wantErr bool
+	}{
+		{name: "ping", check: PingCheck("database", &pinger{})},
+		{name: "ping failing", check: PingCheck("database", &pinger{err: errCheck}), wantErr: true},
+		{name: "ping nil", check: PingCheck("database", nil), wantErr: true},
+		{name: "http", check: HttpCheck("downstream", downstream.URL+"/readyz")},
+		{name: "http failing", check: HttpCheck("downstream", downstream.URL+"/other"), wantErr: true},
+		{name: "dial", check: DialCheck("downstream", "tcp", downstream.Listener.Addr().String())},
+		{name: "dial failing", check: DialCheck("downstream", "tcp", closedAddress), wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := testCase.check.Function(t.Context())
+			if (err != nil) != testCase.wantErr {
+				t.Errorf("got %v, want an error: %v", err, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		options []health_config.Option
+	}{
+		{name: "nil check", options: []health_config.Option{health_config.WithLivenessChecks(nil)}},
+		{name: "empty name", options: []health_config.Option{health_config.WithReadinessChecks(passing(""))}},
+		{
+			name:    "no function",
+			options: []health_config.Option{health_config.WithReadinessChecks(&healthTypes.Check{Name: "a"})},
+		},
+		{
+			name:    "duplicate name",
+			options: []health_config.Option{health_config.WithReadinessChecks(passing("a"), failing("a"))},
+		},
+		{name: "empty path", options: []health_config.Option{health_config.WithPaths("", "/readyz")}},
+		{name: "no now", options: []health_config.Option{health_config.WithNow(nil)}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := New(testCase.options...); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
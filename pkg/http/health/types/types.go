@@ -0,0 +1,43 @@
+// Package types holds the checks of a service's health and readiness, and what they report.
+package types
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+)
+
+// Check is something the service depends on to be healthy or ready: a database it is connected to,
+// a service downstream of it.
+type Check struct {
+	// Name is what the check is reported as. It is unique among the checks it is run with.
+	Name string
+	// Function reports the check failing with an error. It is given a context that is cancelled
+	// when the check times out.
+	Function func(context.Context) error
+	// Timeout bounds the check, overriding the checker's timeout where set.
+	Timeout time.Duration
+}
+
+// CheckResult is what a check reported.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	// Output says why a check failed, where it was not the check itself saying so: it timed out, or
+	// the service is draining.
+	Output string `json:"output,omitempty"`
+}
+
+// Report is what the checks of a probe reported together. It passes only if every check passed.
+type Report struct {
+	Status string                  `json:"status"`
+	Checks map[string]*CheckResult `json:"checks,omitempty"`
+}
+
+func (report *Report) Passed() bool {
+	return report != nil && report.Status == StatusPass
+}
@@ -0,0 +1,686 @@
+// Package message_signature signs and verifies HTTP requests and responses with the message
+// signatures of RFC 9421.
+package message_signature
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	motmedelCrypto "github.com/Motmedel/utils_go/pkg/crypto"
+	motmedelCryptoInterfaces "github.com/Motmedel/utils_go/pkg/crypto/interfaces"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	motmedelHttpErrors "github.com/Motmedel/utils_go/pkg/http/errors"
+	"github.com/Motmedel/utils_go/pkg/http/message_signature/message_signature_config"
+	"github.com/Motmedel/utils_go/pkg/http/structured_field"
+	"github.com/Motmedel/utils_go/pkg/http/types/content_digest"
+	"github.com/Motmedel/utils_go/pkg/utils"
+)
+
+const (
+	SignatureHeaderName      = "Signature"
+	SignatureInputHeaderName = "Signature-Input"
+
+	ContentDigestComponent = "content-digest"
+)
+
+// algorithmNames maps the names of the signers and verifiers of the crypto packages to the algorithms
+// registered by RFC 9421, for the ones that have one.
+var algorithmNames = map[string]string{
+	motmedelCrypto.AlgRs256: "rsa-v1_5-sha256",
+	motmedelCrypto.AlgPs512: "rsa-pss-sha512",
+	motmedelCrypto.AlgEs256: "ecdsa-p256-sha256",
+	motmedelCrypto.AlgEs384: "ecdsa-p384-sha384",
+	motmedelCrypto.AlgEdDsa: "ed25519",
+	"HS256":                 "hmac-sha256",
+}
+
+// GetAlgorithm returns the RFC 9421 algorithm of a signer or verifier by its name, or an empty string
+// if it has none.
+func GetAlgorithm(name string) string {
+	return algorithmNames[name]
+}
+
+// KeyHandler looks up the verifier of a key by its id, returning nil if the key is unknown. The
+// handler of jwk/key_handler is one.
+type KeyHandler interface {
+	GetNamedVerifier(ctx context.Context, keyId string) (motmedelCryptoInterfaces.NamedVerifier, error)
+}
+
+// Signature is a signature of a request or a response, as stated in its Signature and
+// Signature-Input headers.
+type Signature struct {
+	Label string
+	// Components are the identifiers of the covered components, in order.
+	Components []string
+	Created    time.Time
+	Expires    time.Time
+	Nonce      string
+	Algorithm  string
+	KeyId      string
+	Tag        string
+	Value      []byte
+
+	componentItems []*structured_field.Item
+	parameters     *structured_field.InnerList
+}
+
+// Covers reports whether the signature covers the component.
+func (signature *Signature) Covers(component string) bool {
+	return slices.Contains(signature.Components, normalizeComponent(component))
+}
+
+// normalizeComponent returns a component identifier as it is stated in Components: lowercase, and
+// with its parameters canonically serialized.
+func normalizeComponent(component string) string {
+	item, err := parseComponent(component)
+	if err != nil {
+		return component
+	}
+	return formatComponent(item)
+}
+
+// parseComponent parses a component identifier, its name given unquoted.
+func parseComponent(component string) (*structured_field.Item, error) {
+	name, parameters, _ := strings.Cut(component, ";")
+	if parameters != "" {
+		parameters = ";" + parameters
+	}
+
+	item, err := structured_field.ParseItem([]byte(`"` + strings.ToLower(strings.TrimSpace(name)) + `"` + parameters))
+	if err != nil {
+		return nil, fmt.Errorf("parse item: %w", err)
+	}
+
+	return item, nil
+}
+
+func formatComponent(item *structured_field.Item) string {
+	name, _ := item.Value.(string)
+	serialized, err := structured_field.SerializeItem(&structured_field.Item{Value: "", Parameters: item.Parameters})
+	if err != nil {
+		return name
+	}
+
+	return name + strings.TrimPrefix(serialized, `""`)
+}
+
+func getScheme(request *http.Request) string {
+	if requestUrl := request.URL; requestUrl != nil && requestUrl.Scheme != "" {
+		return strings.ToLower(requestUrl.Scheme)
+	}
+	if request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func getAuthority(request *http.Request) string {
+	authority := request.Host
+	if authority == "" && request.URL != nil {
+		authority = request.URL.Host
+	}
+	authority = strings.ToLower(authority)
+
+	host, port, err := net.SplitHostPort(authority)
+	if err != nil {
+		return authority
+	}
+
+	// The default port of the scheme is left out.
+	switch scheme := getScheme(request); {
+	case scheme == "http" && port == "80", scheme == "https" && port == "443":
+		if strings.Contains(host, ":") {
+			return "[" + host + "]"
+		}
+		return host
+	}
+
+	return authority
+}
+
+func getPath(request *http.Request) string {
+	if request.URL == nil {
+		return "/"
+	}
+	if path := request.URL.EscapedPath(); path != "" {
+		return path
+	}
+	return "/"
+}
+
+func getQuery(request *http.Request) string {
+	if request.URL == nil {
+		return "?"
+	}
+	return "?" + request.URL.RawQuery
+}
+
+// percentEncode encodes a query parameter value as RFC 9421, Section 2.2.8 has it.
+func percentEncode(value string) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || strings.IndexByte("*-._", c) >= 0 {
+			builder.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&builder, "%%%02X", c)
+	}
+	return builder.String()
+}
+
+func getQueryParameter(request *http.Request, item *structured_field.Item) (string, error) {
+	nameValue, ok := item.Parameters.Get("name")
+	name, isString := nameValue.(string)
+	if !ok || !isString {
+		return "", motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: @query-param without a name", motmedelHttpErrors.ErrUnsupportedComponent),
+		)
+	}
+
+	var rawQuery string
+	if request.URL != nil {
+		rawQuery = request.URL.RawQuery
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", motmedelErrors.NewWithTrace(fmt.Errorf("url parse query: %w", err), rawQuery)
+	}
+
+	values := query[name]
+	if len(values) != 1 {
+		return "", motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: @query-param %q occurs %d times", motmedelHttpErrors.ErrUnsupportedComponent, name, len(values)),
+		)
+	}
+
+	return percentEncode(values[0]), nil
+}
+
+// message is the request, or the response, whose components are signed. The components of a
+// response are its status code and its header fields; those of the request it answers, with the req
+// parameter, are not supported.
+type message struct {
+	request    *http.Request
+	statusCode int
+	header     http.Header
+}
+
+func getRequestComponentValue(request *http.Request, item *structured_field.Item) (string, bool, error) {
+	name, _ := item.Value.(string)
+
+	switch name {
+	case "@method":
+		return request.Method, true, nil
+	case "@target-uri":
+		requestTarget := getPath(request)
+		if request.URL != nil && request.URL.RawQuery != "" {
+			requestTarget += getQuery(request)
+		}
+		return getScheme(request) + "://" + getAuthority(request) + requestTarget, true, nil
+	case "@authority":
+		return getAuthority(request), true, nil
+	case "@scheme":
+		return getScheme(request), true, nil
+	case "@request-target":
+		if request.RequestURI != "" {
+			return request.RequestURI, true, nil
+		}
+		if request.URL != nil {
+			return request.URL.RequestURI(), true, nil
+		}
+		return "/", true, nil
+	case "@path":
+		return getPath(request), true, nil
+	case "@query":
+		return getQuery(request), true, nil
+	case "@query-param":
+		value, err := getQueryParameter(request, item)
+		return value, true, err
+	}
+
+	return "", false, nil
+}
+
+func getComponentValue(message *message, item *structured_field.Item) (string, error) {
+	name, _ := item.Value.(string)
+
+	for _, parameter := range item.Parameters {
+		if name != "@query-param" || parameter.Key != "name" {
+			return "", motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: parameter %q of %q", motmedelHttpErrors.ErrUnsupportedComponent, parameter.Key, name),
+			)
+		}
+	}
+
+	if request := message.request; request != nil {
+		if value, ok, err := getRequestComponentValue(request, item); ok {
+			return value, err
+		}
+	} else if name == "@status" {
+		return strconv.Itoa(message.statusCode), nil
+	}
+
+	if strings.HasPrefix(name, "@") || name == "" {
+		return "", motmedelErrors.NewWithTrace(fmt.Errorf("%w: %q", motmedelHttpErrors.ErrUnsupportedComponent, name))
+	}
+
+	values := message.header.Values(name)
+	if len(values) == 0 {
+		return "", motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: %q", motmedelHttpErrors.ErrMissingHeader, name),
+		)
+	}
+
+	trimmed := make([]string, len(values))
+	for i, value := range values {
+		trimmed[i] = strings.TrimSpace(value)
+	}
+
+	return strings.Join(trimmed, ", "), nil
+}
+
+// makeSignatureBase makes the signature base of a message (RFC 9421, Section 2.5).
+func makeSignatureBase(message *message, parameters *structured_field.InnerList) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	seen := make(map[string]struct{}, len(parameters.Items))
+	for _, item := range parameters.Items {
+		identifier, err := structured_field.SerializeItem(item)
+		if err != nil {
+			return nil, fmt.Errorf("serialize item: %w", err)
+		}
+		if _, ok := seen[identifier]; ok {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: duplicate component %s", motmedelErrors.ErrSemanticError, identifier),
+			)
+		}
+		seen[identifier] = struct{}{}
+
+		value, err := getComponentValue(message, item)
+		if err != nil {
+			return nil, fmt.Errorf("get component value: %w", err)
+		}
+
+		buffer.WriteString(identifier + ": " + value + "\n")
+	}
+
+	signatureParams, err := structured_field.SerializeInnerList(parameters)
+	if err != nil {
+		return nil, fmt.Errorf("serialize inner list: %w", err)
+	}
+	buffer.WriteString(`"@signature-params": ` + signatureParams)
+
+	return buffer.Bytes(), nil
+}
+
+func getStringParameter(parameters structured_field.Parameters, key string) (string, error) {
+	value, ok := parameters.Get(key)
+	if !ok {
+		return "", nil
+	}
+	stringValue, ok := value.(string)
+	if !ok {
+		return "", motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: %s is not a string", motmedelErrors.ErrSemanticError, key),
+		)
+	}
+	return stringValue, nil
+}
+
+func getTimeParameter(parameters structured_field.Parameters, key string) (time.Time, error) {
+	value, ok := parameters.Get(key)
+	if !ok {
+		return time.Time{}, nil
+	}
+	integerValue, ok := value.(int64)
+	if !ok {
+		return time.Time{}, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: %s is not an integer", motmedelErrors.ErrSemanticError, key),
+		)
+	}
+	return time.Unix(integerValue, 0), nil
+}
+
+func newSignature(label string, parameters *structured_field.InnerList, value []byte) (*Signature, error) {
+	signature := &Signature{Label: label, Value: value, parameters: parameters}
+
+	for _, item := range parameters.Items {
+		if _, ok := item.Value.(string); !ok {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: component identifier is not a string", motmedelErrors.ErrSemanticError),
+			)
+		}
+		signature.componentItems = append(signature.componentItems, item)
+		signature.Components = append(signature.Components, formatComponent(item))
+	}
+
+	var err error
+	if signature.Created, err = getTimeParameter(parameters.Parameters, "created"); err != nil {
+		return nil, err
+	}
+	if signature.Expires, err = getTimeParameter(parameters.Parameters, "expires"); err != nil {
+		return nil, err
+	}
+	if signature.Nonce, err = getStringParameter(parameters.Parameters, "nonce"); err != nil {
+		return nil, err
+	}
+	if signature.Algorithm, err = getStringParameter(parameters.Parameters, "alg"); err != nil {
+		return nil, err
+	}
+	if signature.KeyId, err = getStringParameter(parameters.Parameters, "keyid"); err != nil {
+		return nil, err
+	}
+	if signature.Tag, err = getStringParameter(parameters.Parameters, "tag"); err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// ParseSignatures parses the signatures stated in the Signature and Signature-Input headers, in the
+// order of the latter. A signature input without a signature, or the other way around, is an error.
+func ParseSignatures(header http.Header) ([]*Signature, error) {
+	if header == nil {
+		return nil, nil
+	}
+
+	inputValue := strings.Join(header.Values(SignatureInputHeaderName), ", ")
+	signatureValue := strings.Join(header.Values(SignatureHeaderName), ", ")
+	if inputValue == "" && signatureValue == "" {
+		return nil, nil
+	}
+
+	inputs, err := structured_field.ParseDictionary([]byte(inputValue))
+	if err != nil {
+		return nil, fmt.Errorf("parse dictionary (signature input): %w", err)
+	}
+	values, err := structured_field.ParseDictionary([]byte(signatureValue))
+	if err != nil {
+		return nil, fmt.Errorf("parse dictionary (signature): %w", err)
+	}
+	if len(inputs) != len(values) {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: signature and signature input mismatch", motmedelErrors.ErrSemanticError),
+			inputValue, signatureValue,
+		)
+	}
+
+	signatures := make([]*Signature, 0, len(inputs))
+	for _, input := range inputs {
+		parameters, ok := input.Value.(*structured_field.InnerList)
+		if !ok {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: signature input %s is not an inner list", motmedelErrors.ErrSemanticError, input.Key),
+				inputValue,
+			)
+		}
+
+		member, _ := values.Get(input.Key)
+		item, ok := member.(*structured_field.Item)
+		if !ok {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: no signature for %s", motmedelErrors.ErrSemanticError, input.Key),
+				signatureValue,
+			)
+		}
+		value, ok := item.Value.([]byte)
+		if !ok {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: signature %s is not a byte sequence", motmedelErrors.ErrSemanticError, input.Key),
+				signatureValue,
+			)
+		}
+
+		signature, err := newSignature(input.Key, parameters, value)
+		if err != nil {
+			return nil, fmt.Errorf("new signature: %w", err)
+		}
+		signatures = append(signatures, signature)
+	}
+
+	return signatures, nil
+}
+
+// Verify verifies the signature of the request with the verifier. The signature is rejected if it
+// states an algorithm other than the verifier's.
+func (signature *Signature) Verify(request *http.Request, verifier motmedelCryptoInterfaces.NamedVerifier) error {
+	if request == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("request"))
+	}
+
+	return signature.verify(&message{request: request, header: request.Header}, verifier)
+}
+
+// VerifyResponse verifies the signature of the response with the verifier, as Verify does that of a
+// request.
+func (signature *Signature) VerifyResponse(response *http.Response, verifier motmedelCryptoInterfaces.NamedVerifier) error {
+	if response == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("response"))
+	}
+
+	return signature.verify(&message{statusCode: response.StatusCode, header: response.Header}, verifier)
+}
+
+func (signature *Signature) verify(message *message, verifier motmedelCryptoInterfaces.NamedVerifier) error {
+	if utils.IsNil(verifier) {
+		return motmedelErrors.NewWithTrace(nil_error.New("verifier"))
+	}
+	if signature.parameters == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("signature parameters"))
+	}
+
+	if algorithm := signature.Algorithm; algorithm != "" && algorithm != GetAlgorithm(verifier.GetName()) {
+		return motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: algorithm mismatch", motmedelErrors.ErrVerificationError),
+			algorithm, verifier.GetName(),
+		)
+	}
+
+	signatureBase, err := makeSignatureBase(message, signature.parameters)
+	if err != nil {
+		return fmt.Errorf("make signature base: %w", err)
+	}
+
+	if err := verifier.Verify(signatureBase, signature.Value); err != nil {
+		return motmedelErrors.New(
+			fmt.Errorf("%w: verifier verify: %w", motmedelErrors.ErrVerificationError, err),
+			signatureBase,
+		)
+	}
+
+	return nil
+}
+
+// Signer signs requests and responses.
+type Signer struct {
+	Signer motmedelCryptoInterfaces.NamedSigner
+	KeyId  string
+	config *message_signature_config.Config
+}
+
+// SignRequest signs the request, adding to its Signature and Signature-Input headers. A request
+// with a body is given a Content-Digest header, which the signature covers.
+func (signer *Signer) SignRequest(request *http.Request, body []byte) error {
+	if request == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("request"))
+	}
+	if request.Header == nil {
+		request.Header = make(http.Header)
+	}
+
+	return signer.sign(&message{request: request, header: request.Header}, body, signer.config.Components)
+}
+
+// SignResponse signs the response with the status code and the header, adding to its Signature and
+// Signature-Input headers, as SignRequest signs a request. The body is the content as sent, after
+// any content coding.
+func (signer *Signer) SignResponse(statusCode int, header http.Header, body []byte) error {
+	if header == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("header"))
+	}
+
+	return signer.sign(&message{statusCode: statusCode, header: header}, body, signer.config.ResponseComponents)
+}
+
+func (signer *Signer) sign(message *message, body []byte, components []string) error {
+	config := signer.config
+
+	if len(body) != 0 {
+		contentDigest, err := content_digest.MakeHeaderValue(body, config.ContentDigestAlgorithm)
+		if err != nil {
+			return fmt.Errorf("make header value (content digest): %w", err)
+		}
+		message.header.Set(content_digest.HeaderName, contentDigest)
+
+		if !slices.Contains(components, ContentDigestComponent) {
+			components = append(slices.Clone(components), ContentDigestComponent)
+		}
+	}
+
+	parameters := &structured_field.InnerList{}
+	for _, component := range components {
+		item, err := parseComponent(component)
+		if err != nil {
+			return fmt.Errorf("parse component: %w", err)
+		}
+		parameters.Items = append(parameters.Items, item)
+	}
+
+	now := config.Now()
+	parameters.Parameters = append(parameters.Parameters, &structured_field.Parameter{Key: "created", Value: now.Unix()})
+	if expiresIn := config.ExpiresIn; expiresIn > 0 {
+		parameters.Parameters = append(
+			parameters.Parameters,
+			&structured_field.Parameter{Key: "expires", Value: now.Add(expiresIn).Unix()},
+		)
+	}
+	if config.Nonce != nil {
+		nonce, err := config.Nonce()
+		if err != nil {
+			return fmt.Errorf("nonce: %w", err)
+		}
+		parameters.Parameters = append(parameters.Parameters, &structured_field.Parameter{Key: "nonce", Value: nonce})
+	}
+	if algorithm := GetAlgorithm(signer.Signer.GetName()); algorithm != "" {
+		parameters.Parameters = append(parameters.Parameters, &structured_field.Parameter{Key: "alg", Value: algorithm})
+	}
+	if keyId := signer.KeyId; keyId != "" {
+		parameters.Parameters = append(parameters.Parameters, &structured_field.Parameter{Key: "keyid", Value: keyId})
+	}
+	if tag := config.Tag; tag != "" {
+		parameters.Parameters = append(parameters.Parameters, &structured_field.Parameter{Key: "tag", Value: tag})
+	}
+
+	signatureBase, err := makeSignatureBase(message, parameters)
+	if err != nil {
+		return fmt.Errorf("make signature base: %w", err)
+	}
+
+	value, err := signer.Signer.Sign(signatureBase)
+	if err != nil {
+		return motmedelErrors.New(fmt.Errorf("signer sign: %w", err), signatureBase)
+	}
+
+	signatureInput, err := structured_field.SerializeDictionary(
+		structured_field.Dictionary{{Key: config.Label, Value: parameters}},
+	)
+	if err != nil {
+		return fmt.Errorf("serialize dictionary (signature input): %w", err)
+	}
+	signature, err := structured_field.SerializeDictionary(
+		structured_field.Dictionary{{Key: config.Label, Value: &structured_field.Item{Value: value}}},
+	)
+	if err != nil {
+		return fmt.Errorf("serialize dictionary (signature): %w", err)
+	}
+
+	message.header.Add(SignatureInputHeaderName, signatureInput)
+	message.header.Add(SignatureHeaderName, signature)
+
+	return nil
+}
+
+type roundTripper struct {
+	signer *Signer
+	next   http.RoundTripper
+}
+
+func (roundTripper *roundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("request"))
+	}
+
+	// A round tripper must not modify the request it is given.
+	signedRequest := request.Clone(request.Context())
+
+	var body []byte
+	if request.Body != nil && request.Body != http.NoBody {
+		var bodyReader io.ReadCloser = request.Body
+		if request.GetBody != nil {
+			var err error
+			bodyReader, err = request.GetBody()
+			if err != nil {
+				return nil, motmedelErrors.NewWithTrace(fmt.Errorf("request get body: %w", err))
+			}
+		}
+
+		var err error
+		body, err = io.ReadAll(bodyReader)
+		_ = bodyReader.Close()
+		if err != nil {
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("io read all (request body): %w", err))
+		}
+		signedRequest.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if err := roundTripper.signer.SignRequest(signedRequest, body); err != nil {
+		return nil, fmt.Errorf("sign request: %w", err)
+	}
+
+	return roundTripper.next.RoundTrip(signedRequest)
+}
+
+// RoundTripper returns a round tripper that signs the requests it sends with next, or with
+// http.DefaultTransport if next is nil. Used as the transport of the client of utils.Fetch and
+// utils.FetchWithRequest, it signs what they send, retries included.
+func (signer *Signer) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if utils.IsNil(next) {
+		next = http.DefaultTransport
+	}
+
+	return &roundTripper{signer: signer, next: next}
+}
+
+func NewSigner(
+	signer motmedelCryptoInterfaces.NamedSigner,
+	keyId string,
+	options ...message_signature_config.Option,
+) (*Signer, error) {
+	if utils.IsNil(signer) {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("signer"))
+	}
+
+	config := message_signature_config.New(options...)
+	if config.Label == "" {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("label"))
+	}
+	for _, component := range slices.Concat(config.Components, config.ResponseComponents) {
+		if _, err := parseComponent(component); err != nil {
+			return nil, fmt.Errorf("parse component: %w", err)
+		}
+	}
+
+	return &Signer{Signer: signer, KeyId: keyId, config: config}, nil
+}
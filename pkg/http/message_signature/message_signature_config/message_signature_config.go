@@ -0,0 +1,101 @@
+package message_signature_config
+
+import (
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/types/content_digest"
+)
+
+const DefaultLabel = "sig1"
+
+// DefaultComponents are the components a request signature covers unless others are specified;
+// content-digest is covered in addition when the request has a body.
+var DefaultComponents = []string{"@method", "@authority", "@path", "@query"}
+
+// DefaultResponseComponents are the components a response signature covers unless others are
+// specified; content-digest is covered in addition when the response has a body.
+var DefaultResponseComponents = []string{"@status"}
+
+type Config struct {
+	// Label is the name of the signature in the Signature and Signature-Input headers.
+	Label string
+	// Components are the identifiers of the covered components, e.g. "@method", "content-type" or
+	// `@query-param;name="id"`.
+	Components []string
+	// ResponseComponents are the identifiers of the components covered in responses, e.g. "@status"
+	// or "content-type".
+	ResponseComponents []string
+	// ContentDigestAlgorithm is what the Content-Digest of a body is made with.
+	ContentDigestAlgorithm string
+	// ExpiresIn, if positive, is for how long a signature is valid.
+	ExpiresIn time.Duration
+	// Nonce, if set, makes the nonce of each signature.
+	Nonce func() (string, error)
+	Tag   string
+	Now   func() time.Time
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		Label:                  DefaultLabel,
+		Components:             DefaultComponents,
+		ResponseComponents:     DefaultResponseComponents,
+		ContentDigestAlgorithm: content_digest.DefaultAlgorithm,
+		Now:                    time.Now,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	return config
+}
+
+func WithLabel(label string) Option {
+	return func(config *Config) {
+		config.Label = label
+	}
+}
+
+func WithComponents(components ...string) Option {
+	return func(config *Config) {
+		config.Components = components
+	}
+}
+
+func WithResponseComponents(responseComponents ...string) Option {
+	return func(config *Config) {
+		config.ResponseComponents = responseComponents
+	}
+}
+
+func WithContentDigestAlgorithm(contentDigestAlgorithm string) Option {
+	return func(config *Config) {
+		config.ContentDigestAlgorithm = contentDigestAlgorithm
+	}
+}
+
+func WithExpiresIn(expiresIn time.Duration) Option {
+	return func(config *Config) {
+		config.ExpiresIn = expiresIn
+	}
+}
+
+func WithNonce(nonce func() (string, error)) Option {
+	return func(config *Config) {
+		config.Nonce = nonce
+	}
+}
+
+func WithTag(tag string) Option {
+	return func(config *Config) {
+		config.Tag = tag
+	}
+}
+
+func WithNow(now func() time.Time) Option {
+	return func(config *Config) {
+		config.Now = now
+	}
+}
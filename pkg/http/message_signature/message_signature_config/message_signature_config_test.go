@@ -0,0 +1,75 @@
+package message_signature_config
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/types/content_digest"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.Label != DefaultLabel {
+		t.Errorf("label: got %q", config.Label)
+	}
+	if !slices.Equal(config.Components, DefaultComponents) {
+		t.Errorf("components: got %v", config.Components)
+	}
+	if !slices.Equal(config.ResponseComponents, DefaultResponseComponents) {
+		t.Errorf("response components: got %v", config.ResponseComponents)
+	}
+	if config.ContentDigestAlgorithm != content_digest.DefaultAlgorithm {
+		t.Errorf("content digest algorithm: got %q", config.ContentDigestAlgorithm)
+	}
+	if config.ExpiresIn != 0 || config.Nonce != nil || config.Tag != "" {
+		t.Errorf("expected no expiry, nonce or tag, got %v %t %q", config.ExpiresIn, config.Nonce != nil, config.Tag)
+	}
+	if config.Now == nil {
+		t.Error("expected a default now function")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1, 0)
+
+	config := New(
+		WithLabel("proxy"),
+		WithComponents("@method", "content-type"),
+		WithResponseComponents("@status"),
+		WithContentDigestAlgorithm(content_digest.AlgorithmSha512),
+		WithExpiresIn(time.Minute),
+		WithNonce(func() (string, error) { return "n", nil }),
+		WithTag("app"),
+		WithNow(func() time.Time { return now }),
+	)
+
+	if config.Label != "proxy" {
+		t.Errorf("label: got %q", config.Label)
+	}
+	if !slices.Equal(config.Components, []string{"@method", "content-type"}) {
+		t.Errorf("components: got %v", config.Components)
+	}
+	if !slices.Equal(config.ResponseComponents, []string{"@status"}) {
+		t.Errorf("response components: got %v", config.ResponseComponents)
+	}
+	if config.ContentDigestAlgorithm != content_digest.AlgorithmSha512 {
+		t.Errorf("content digest algorithm: got %q", config.ContentDigestAlgorithm)
+	}
+	if config.ExpiresIn != time.Minute {
+		t.Errorf("expires in: got %v", config.ExpiresIn)
+	}
+	if nonce, _ := config.Nonce(); nonce != "n" {
+		t.Errorf("nonce: got %q", nonce)
+	}
+	if config.Tag != "app" {
+		t.Errorf("tag: got %q", config.Tag)
+	}
+	if !config.Now().Equal(now) {
+		t.Errorf("now: got %v", config.Now())
+	}
+}
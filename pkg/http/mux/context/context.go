@@ -3,3 +3,21 @@ package context
 type contentNegotiationContextType struct{}
 
 var ContentNegotiationContextKey = &contentNegotiationContextType{}
+
+type httpContextContextType struct{}
+
+// HttpContextContextKey is the key of the http context the mux records a request and its response
+// in, for the parts of the mux's pipeline, such as a firewall, that add to it.
+var HttpContextContextKey = &httpContextContextType{}
+
+type metricsObservationContextType struct{}
+
+// MetricsObservationContextKey is the key of the observation the mux records the metrics of a
+// request in, for the parts of the pipeline that know what it is labelled with.
+var MetricsObservationContextKey = &metricsObservationContextType{}
+
+type cspNonceContextType struct{}
+
+// CspNonceContextKey is the key of the Content Security Policy nonce of the response to a request,
+// generated when the handler first asks for it.
+var CspNonceContextKey = &cspNonceContextType{}
@@ -17,4 +17,9 @@ var (
 	ErrUnexpectedResponseErrorType = fmt.Errorf("%w: unexpected response error type", ErrUnusableResponseError)
 	ErrUnexpectedContentEncoding   = errors.New("unexpected content encoding")
 	ErrUnsupportedFileExtension    = errors.New("unsupported file extension")
+	ErrMalformedPathPattern        = errors.New("malformed path pattern")
+	ErrRateLimitingContention      = errors.New("rate limiting store contention")
+	ErrMalformedRateLimitingState  = errors.New("malformed rate limiting state")
+	ErrMalformedFirewallRuleSet    = errors.New("malformed firewall rule set")
+	ErrMalformedEvent              = errors.New("malformed event")
 )
@@ -32,6 +32,11 @@ func TestSentinelErrorMessages(t *testing.T) {
 		},
 		{name: "ErrUnexpectedContentEncoding", err: ErrUnexpectedContentEncoding, want: "unexpected content encoding"},
 		{name: "ErrUnsupportedFileExtension", err: ErrUnsupportedFileExtension, want: "unsupported file extension"},
+		{name: "ErrMalformedPathPattern", err: ErrMalformedPathPattern, want: "malformed path pattern"},
+		{name: "ErrRateLimitingContention", err: ErrRateLimitingContention, want: "rate limiting store contention"},
+		{name: "ErrMalformedRateLimitingState", err: ErrMalformedRateLimitingState, want: "malformed rate limiting state"},
+		{name: "ErrMalformedFirewallRuleSet", err: ErrMalformedFirewallRuleSet, want: "malformed firewall rule set"},
+		{name: "ErrMalformedEvent", err: ErrMalformedEvent, want: "malformed event"},
 	}
 
 	for _, testCase := range testCases {
@@ -2,6 +2,7 @@ package mux
 
 import (
 	"io"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	endpointPkg "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
 	muxTypesStaticContent "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint/static_content"
+	muxTypesPathPattern "github.com/Motmedel/utils_go/pkg/http/mux/types/path_pattern"
 )
 
 func TestObtainRequestBody(t *testing.T) {
@@ -66,7 +68,7 @@ func TestGetEndpoint(t *testing.T) {
 
 	t.Run("empty map is 404", func(t *testing.T) {
 		t.Parallel()
-		_, _, responseError := GetEndpoint(nil, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/x", nil))
+		_, _, _, responseError := GetEndpoint(nil, nil, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/x", nil))
 		if responseError == nil || responseError.ProblemDetail == nil || responseError.ProblemDetail.Status != http.StatusNotFound {
 			t.Fatalf("expected 404, got %#v", responseError)
 		}
@@ -74,7 +76,7 @@ func TestGetEndpoint(t *testing.T) {
 
 	t.Run("nil request is a server error", func(t *testing.T) {
 		t.Parallel()
-		_, _, responseError := GetEndpoint(specMap, nil)
+		_, _, _, responseError := GetEndpoint(specMap, nil, nil)
 		if responseError == nil || responseError.ServerError == nil {
 			t.Fatalf("expected a server error, got %#v", responseError)
 		}
@@ -82,7 +84,7 @@ func TestGetEndpoint(t *testing.T) {
 
 	t.Run("nil url is a server error", func(t *testing.T) {
 		t.Parallel()
-		_, _, responseError := GetEndpoint(specMap, &http.Request{Method: http.MethodGet})
+		_, _, _, responseError := GetEndpoint(specMap, nil, &http.Request{Method: http.MethodGet})
 		if responseError == nil || responseError.ServerError == nil {
 			t.Fatalf("expected a server error, got %#v", responseError)
 		}
@@ -90,7 +92,7 @@ func TestGetEndpoint(t *testing.T) {
 
 	t.Run("unknown path is 404", func(t *testing.T) {
 		t.Parallel()
-		_, _, responseError := GetEndpoint(specMap, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/unknown", nil))
+		_, _, _, responseError := GetEndpoint(specMap, nil, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/unknown", nil))
 		if responseError == nil || responseError.ProblemDetail == nil || responseError.ProblemDetail.Status != http.StatusNotFound {
 			t.Fatalf("expected 404, got %#v", responseError)
 		}
@@ -98,7 +100,7 @@ func TestGetEndpoint(t *testing.T) {
 
 	t.Run("method match returns the endpoint", func(t *testing.T) {
 		t.Parallel()
-		endpoint, _, responseError := GetEndpoint(specMap, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/x", nil))
+		endpoint, _, _, responseError := GetEndpoint(specMap, nil, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/x", nil))
 		if responseError != nil {
 			t.Fatalf("unexpected error: %#v", responseError)
 		}
@@ -109,7 +111,7 @@ func TestGetEndpoint(t *testing.T) {
 
 	t.Run("HEAD is treated as GET", func(t *testing.T) {
 		t.Parallel()
-		endpoint, _, responseError := GetEndpoint(specMap, httptest.NewRequestWithContext(t.Context(), http.MethodHead, "/x", nil))
+		endpoint, _, _, responseError := GetEndpoint(specMap, nil, httptest.NewRequestWithContext(t.Context(), http.MethodHead, "/x", nil))
 		if responseError != nil {
 			t.Fatalf("unexpected error: %#v", responseError)
 		}
@@ -120,7 +122,7 @@ func TestGetEndpoint(t *testing.T) {
 
 	t.Run("unknown method returns the method map without an error", func(t *testing.T) {
 		t.Parallel()
-		endpoint, methodMap, responseError := GetEndpoint(specMap, httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/x", nil))
+		endpoint, methodMap, _, responseError := GetEndpoint(specMap, nil, httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/x", nil))
 		if responseError != nil {
 			t.Fatalf("unexpected error: %#v", responseError)
 		}
@@ -133,6 +135,128 @@ func TestGetEndpoint(t *testing.T) {
 	})
 }
 
+func TestGetEndpoint_PathPattern(t *testing.T) {
+	t.Parallel()
+
+	keyEndpoint := &endpointPkg.Endpoint{Path: "/users/{id}/keys/{kid}", Method: http.MethodGet}
+	specMap := map[string]map[string]*endpointPkg.Endpoint{
+		"/users/{id}/keys/{kid}": {http.MethodGet: keyEndpoint},
+	}
+
+	var tree muxTypesPathPattern.Tree
+	pattern, err := muxTypesPathPattern.Parse(keyEndpoint.Path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tree.Add(pattern)
+
+	t.Run("pattern match returns the parameters", func(t *testing.T) {
+		t.Parallel()
+		endpoint, _, parameters, responseError := GetEndpoint(specMap, &tree, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/users/1/keys/a%2Fb", nil))
+		if responseError != nil {
+			t.Fatalf("unexpected error: %#v", responseError)
+		}
+		if endpoint != keyEndpoint {
+			t.Fatalf("expected the pattern endpoint, got %#v", endpoint)
+		}
+		if parameters["id"] != "1" || parameters["kid"] != "a/b" {
+			t.Fatalf("unexpected parameters: %#v", parameters)
+		}
+	})
+
+	t.Run("no tree is 404", func(t *testing.T) {
+		t.Parallel()
+		_, _, _, responseError := GetEndpoint(specMap, nil, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/users/1/keys/2", nil))
+		if responseError == nil || responseError.ProblemDetail == nil || responseError.ProblemDetail.Status != http.StatusNotFound {
+			t.Fatalf("expected 404, got %#v", responseError)
+		}
+	})
+
+	t.Run("pattern absent from the map is 404", func(t *testing.T) {
+		t.Parallel()
+		_, _, _, responseError := GetEndpoint(
+			map[string]map[string]*endpointPkg.Endpoint{"/x": {}},
+			&tree,
+			httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/users/1/keys/2", nil),
+		)
+		if responseError == nil || responseError.ProblemDetail == nil || responseError.ProblemDetail.Status != http.StatusNotFound {
+			t.Fatalf("expected 404, got %#v", responseError)
+		}
+	})
+}
+
+func TestGetEndpoint_PatternText(t *testing.T) {
+	t.Parallel()
+
+	userEndpoint := &endpointPkg.Endpoint{Path: "/users/{id}", Method: http.MethodGet}
+	staticEndpoint := &endpointPkg.Endpoint{Path: "/static/*", Method: http.MethodGet}
+	specMap := map[string]map[string]*endpointPkg.Endpoint{
+		userEndpoint.Path:   {http.MethodGet: userEndpoint},
+		staticEndpoint.Path: {http.MethodGet: staticEndpoint},
+	}
+
+	var tree muxTypesPathPattern.Tree
+	for _, path := range []string{userEndpoint.Path, staticEndpoint.Path} {
+		pattern, err := muxTypesPathPattern.Parse(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		tree.Add(pattern)
+	}
+
+	testCases := []struct {
+		name           string
+		tree           *muxTypesPathPattern.Tree
+		target         string
+		wantEndpoint   *endpointPkg.Endpoint
+		wantParameters map[string]string
+	}{
+		{
+			name:           "encoded braces",
+			tree:           &tree,
+			target:         "/users/%7Bid%7D",
+			wantEndpoint:   userEndpoint,
+			wantParameters: map[string]string{"id": "{id}"},
+		},
+		{
+			name:           "literal wildcard",
+			tree:           &tree,
+			target:         "/static/*",
+			wantEndpoint:   staticEndpoint,
+			wantParameters: map[string]string{muxTypesPathPattern.WildcardName: "*"},
+		},
+		{name: "encoded braces without a tree", target: "/users/%7Bid%7D"},
+		{name: "literal wildcard without a tree", target: "/static/*"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			endpoint, _, parameters, responseError := GetEndpoint(
+				specMap,
+				testCase.tree,
+				httptest.NewRequestWithContext(t.Context(), http.MethodGet, testCase.target, nil),
+			)
+			if testCase.wantEndpoint == nil {
+				if responseError == nil || responseError.ProblemDetail == nil || responseError.ProblemDetail.Status != http.StatusNotFound {
+					t.Fatalf("expected 404, got %#v", responseError)
+				}
+				return
+			}
+			if responseError != nil {
+				t.Fatalf("unexpected error: %#v", responseError)
+			}
+			if endpoint != testCase.wantEndpoint {
+				t.Fatalf("endpoint: got %#v, want %#v", endpoint, testCase.wantEndpoint)
+			}
+			if !maps.Equal(parameters, testCase.wantParameters) {
+				t.Errorf("parameters: got %#v, want %#v", parameters, testCase.wantParameters)
+			}
+		})
+	}
+}
+
 func TestObtainIsCached(t *testing.T) {
 	t.Parallel()
 
@@ -22,6 +22,7 @@ import (
 	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
 	muxTypes "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
 	muxTypesStaticContent "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint/static_content"
+	muxTypesPathPattern "github.com/Motmedel/utils_go/pkg/http/mux/types/path_pattern"
 	muxTypesRateLimiting "github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting"
 	muxTypesResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
 	muxTypesResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
@@ -58,53 +59,77 @@ func HandleRateLimiting(
 		}
 	}
 
-	rateLimitingConfiguration.Lookup.Mutex.Lock()
-	if rateLimitingConfiguration.Lookup.Map == nil {
-		rateLimitingConfiguration.Lookup.Map = make(map[string]*muxTypesRateLimiting.TimerRateLimiter)
+	rateLimiter := rateLimitingConfiguration.GetRateLimiter()
+	if rateLimiter == nil {
+		return &muxTypesResponseError.ResponseError{
+			ServerError: motmedelErrors.NewWithTrace(nil_error.New("rate limiter")),
+		}
 	}
-	rateLimitingConfiguration.Lookup.Mutex.Unlock()
 
-	timerRateLimiter, ok := rateLimitingConfiguration.Lookup.Map[key]
-	if !ok {
-		timerRateLimiter = &muxTypesRateLimiting.TimerRateLimiter{
-			RateLimiter: muxTypesRateLimiting.RateLimiter{
-				Bucket:           make([]*time.Time, rateLimitingConfiguration.NumRequests),
-				NumSecondsExpiry: rateLimitingConfiguration.NumSecondsExpiration,
-			},
+	decision, err := rateLimiter.Allow(request.Context(), key)
+	if err != nil {
+		return &muxTypesResponseError.ResponseError{
+			ServerError: motmedelErrors.New(fmt.Errorf("rate limiter allow: %w", err), key),
 		}
-		rateLimitingConfiguration.Lookup.Map[key] = timerRateLimiter
 	}
-
-	if timerRateLimiter.Timer != nil {
-		timerRateLimiter.Timer.Stop()
+	if decision == nil {
+		return &muxTypesResponseError.ResponseError{
+			ServerError: motmedelErrors.NewWithTrace(nil_error.New("rate limiting decision"), key),
+		}
 	}
-	timerRateLimiter.Timer = time.AfterFunc(
-		time.Duration(2*timerRateLimiter.NumSecondsExpiry)*time.Second,
-		func() {
-			rateLimitingConfiguration.Lookup.Mutex.Lock()
-			defer rateLimitingConfiguration.Lookup.Mutex.Unlock()
-			if timerRateLimiter.NumOccupied == 0 {
-				delete(rateLimitingConfiguration.Lookup.Map, key)
-			}
-		},
-	)
 
-	expirationTime, full := timerRateLimiter.Claim()
-	if full {
+	if !decision.Allowed {
 		return &muxTypesResponseError.ResponseError{
 			ProblemDetail: problem_detail.New(http.StatusTooManyRequests),
-			Headers: []*muxTypesResponse.HeaderEntry{
-				{
-					Name:  "Retry-After",
-					Value: expirationTime.UTC().Format(http.TimeFormat),
-				},
-			},
+			Headers:       MakeRateLimitingHeaders(decision),
 		}
 	}
 
 	return nil
 }
 
+// ceilSeconds rounds the duration up to whole seconds, so that a client waiting for it does not
+// come back too early.
+func ceilSeconds(duration time.Duration) int64 {
+	return int64((max(duration, 0) + time.Second - 1) / time.Second)
+}
+
+// MakeRateLimitingHeaders makes the `RateLimit-*` headers of the IETF rate limit headers draft and
+// the `Retry-After` header for a decision.
+func MakeRateLimitingHeaders(decision *muxTypesRateLimiting.Decision) []*muxTypesResponse.HeaderEntry {
+	if decision == nil {
+		return nil
+	}
+
+	headers := []*muxTypesResponse.HeaderEntry{
+		{Name: "RateLimit-Limit", Value: strconv.Itoa(decision.Limit)},
+		{Name: "RateLimit-Remaining", Value: strconv.Itoa(decision.Remaining)},
+		{Name: "RateLimit-Reset", Value: strconv.FormatInt(ceilSeconds(decision.Reset), 10)},
+		{
+			Name:  "RateLimit-Policy",
+			Value: fmt.Sprintf("%d;w=%d", decision.Limit, ceilSeconds(decision.Window)),
+		},
+	}
+
+	if !decision.Allowed {
+		// The time is that of the rate limiter's clock; a rate limiter that does not say when is taken
+		// to mean from now.
+		retryAt := decision.RetryAt
+		if retryAt.IsZero() {
+			retryAt = time.Now().Add(decision.RetryAfter)
+		}
+		headers = append(
+			headers,
+			&muxTypesResponse.HeaderEntry{
+				Name:  "Retry-After",
+				Value: retryAt.Add(time.Second - 1).UTC().Truncate(time.Second).Format(http.TimeFormat),
+			},
+		)
+	}
+
+	return headers
+}
+
 func HandleFetchMetadata(requestHeader http.Header, method string) *muxTypesResponseError.ResponseError {
 	if requestHeader == nil {
 		return &muxTypesResponseError.ResponseError{
@@ -318,25 +343,39 @@ func ObtainRequestBody(
 	return nil, nil
 }
 
+// isPatternPath reports whether the path is that of a pattern with named segments or a wildcard,
+// whose endpoints are routed to by the path pattern tree only.
+func isPatternPath(path string) bool {
+	if !strings.ContainsAny(path, "{}*") {
+		return false
+	}
+
+	pattern, err := muxTypesPathPattern.Parse(path)
+	return err == nil && !pattern.IsLiteral()
+}
+
+// GetEndpoint locates the endpoint for the request: by its exact path first, then by the patterns in
+// the path pattern tree, in which case the values of the pattern's named segments are returned too.
 func GetEndpoint(
 	endpointSpecificationMap map[string]map[string]*muxTypes.Endpoint,
+	pathPatternTree *muxTypesPathPattern.Tree,
 	request *http.Request,
-) (*muxTypes.Endpoint, map[string]*muxTypes.Endpoint, *muxTypesResponseError.ResponseError) {
+) (*muxTypes.Endpoint, map[string]*muxTypes.Endpoint, map[string]string, *muxTypesResponseError.ResponseError) {
 	if len(endpointSpecificationMap) == 0 {
-		return nil, nil, &muxTypesResponseError.ResponseError{
+		return nil, nil, nil, &muxTypesResponseError.ResponseError{
 			ProblemDetail: problem_detail.New(http.StatusNotFound),
 		}
 	}
 
 	if request == nil {
-		return nil, nil, &muxTypesResponseError.ResponseError{
+		return nil, nil, nil, &muxTypesResponseError.ResponseError{
 			ServerError: motmedelErrors.NewWithTrace(nil_error.New("request")),
 		}
 	}
 
 	requestUrl := request.URL
 	if requestUrl == nil {
-		return nil, nil, &muxTypesResponseError.ResponseError{
+		return nil, nil, nil, &muxTypesResponseError.ResponseError{
 			ServerError: motmedelErrors.NewWithTrace(nil_error.New("request url")),
 		}
 	}
@@ -348,19 +387,33 @@ func GetEndpoint(
 		effectiveLookupMethod = http.MethodGet
 	}
 
+	var pathParameters map[string]string
+
 	methodToEndpointSpecification, ok := endpointSpecificationMap[requestUrl.Path]
+	if ok && isPatternPath(requestUrl.Path) {
+		// The path is the text of a pattern, which is not to be matched as if it were a literal path.
+		methodToEndpointSpecification, ok = nil, false
+	}
+	if !ok && pathPatternTree != nil {
+		var pattern *muxTypesPathPattern.Pattern
+		pattern, pathParameters = pathPatternTree.Match(requestUrl.EscapedPath())
+		if pattern != nil {
+			// NOTE: A pattern left in the tree by an entry removed from the map directly is not routed to.
+			methodToEndpointSpecification, ok = endpointSpecificationMap[pattern.Path]
+		}
+	}
 	if !ok {
-		return nil, nil, &muxTypesResponseError.ResponseError{
+		return nil, nil, nil, &muxTypesResponseError.ResponseError{
 			ProblemDetail: problem_detail.New(http.StatusNotFound),
 		}
 	}
 
 	endpointSpecification, ok := methodToEndpointSpecification[effectiveLookupMethod]
 	if !ok {
-		return nil, methodToEndpointSpecification, nil
+		return nil, methodToEndpointSpecification, pathParameters, nil
 	}
 
-	return endpointSpecification, methodToEndpointSpecification, nil
+	return endpointSpecification, methodToEndpointSpecification, pathParameters, nil
 }
 
 func ObtainIsCached(staticContent *muxTypesStaticContent.StaticContent, requestHeader http.Header) (bool, *muxTypesResponseError.ResponseError) {
@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"testing"
 	"testing/synctest"
+	"time"
 
 	muxTypesRateLimiting "github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting"
 )
@@ -28,6 +29,17 @@ func TestHandleRateLimiting(t *testing.T) {
 		}
 	})
 
+	t.Run("rate limiter error is a server error", func(t *testing.T) {
+		t.Parallel()
+		config := &muxTypesRateLimiting.RateLimitingConfiguration{
+			RateLimiter: muxTypesRateLimiting.NewGcraRateLimiter(0, 0),
+		}
+		request := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+		if responseError := HandleRateLimiting(config, request); responseError == nil || responseError.ServerError == nil {
+			t.Fatalf("expected a server error, got %#v", responseError)
+		}
+	})
+
 	t.Run("limits after capacity", func(t *testing.T) {
 		t.Parallel()
 		synctest.Test(t, func(t *testing.T) {
@@ -49,15 +61,46 @@ func TestHandleRateLimiting(t *testing.T) {
 				t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, responseError.ProblemDetail.Status)
 			}
 
-			var hasRetryAfter bool
+			headers := make(map[string]string)
 			for _, header := range responseError.Headers {
-				if header != nil && header.Name == "Retry-After" {
-					hasRetryAfter = true
+				if header != nil {
+					headers[header.Name] = header.Value
 				}
 			}
-			if !hasRetryAfter {
+			if headers["Retry-After"] == "" {
 				t.Error("expected a Retry-After header")
 			}
+			for name, want := range map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Policy":    "2;w=5",
+			} {
+				if got := headers[name]; got != want {
+					t.Errorf("%s: got %q, want %q", name, got, want)
+				}
+			}
+			if headers["RateLimit-Reset"] == "" {
+				t.Error("expected a RateLimit-Reset header")
+			}
+		})
+	})
+	t.Run("retry after is by the rate limiter's clock", func(t *testing.T) {
+		t.Parallel()
+
+		headers := MakeRateLimitingHeaders(&muxTypesRateLimiting.Decision{
+			Limit:      1,
+			RetryAfter: 2 * time.Second,
+			RetryAt:    time.Date(2030, time.January, 1, 0, 0, 1, int(500*time.Millisecond), time.UTC),
 		})
+
+		var retryAfter string
+		for _, header := range headers {
+			if header != nil && header.Name == "Retry-After" {
+				retryAfter = header.Value
+			}
+		}
+		if want := "Tue, 01 Jan 2030 00:00:02 GMT"; retryAfter != want {
+			t.Errorf("Retry-After: got %q, want %q", retryAfter, want)
+		}
 	})
 }
@@ -20,13 +20,17 @@ import (
 	muxInternalMux "github.com/Motmedel/utils_go/pkg/http/mux/internal/mux"
 	"github.com/Motmedel/utils_go/pkg/http/mux/types/body_loader/body_setting"
 	"github.com/Motmedel/utils_go/pkg/http/mux/types/body_parser"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/csp_nonce"
 	endpointPkg "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
 	muxTypesFirewall "github.com/Motmedel/utils_go/pkg/http/mux/types/firewall_verdict"
 	muxTypesMiddleware "github.com/Motmedel/utils_go/pkg/http/mux/types/middleware"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/mux_metrics"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/path_pattern"
 	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser"
 	muxTypesResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
 	muxTypesResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
 	muxTypesResponseWriter "github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer/compression_config"
 	"github.com/Motmedel/utils_go/pkg/http/mux/types/userer"
 	utils2 "github.com/Motmedel/utils_go/pkg/http/mux/utils"
 	muxUtilsContentNegotiation "github.com/Motmedel/utils_go/pkg/http/mux/utils/content_negotiation"
@@ -52,9 +56,7 @@ const (
 	ResponseServedMessage = muxInternal.ResponseServedMessage
 )
 
-type muxHttpContextContextType struct{}
-
-var MuxHttpContextContextKey muxHttpContextContextType
+var MuxHttpContextContextKey = muxContext.HttpContextContextKey
 
 // TODO: Do all of these need to be here, or can they be moved to the `Mux` struct?
 type baseMux struct {
@@ -64,8 +66,17 @@ type baseMux struct {
 	FirewallParser          request_parser.RequestParser[muxTypesFirewall.Verdict]
 	DefaultHeaders          map[string]string
 	DefaultDocumentHeaders  map[string]string
-	Middleware              []muxTypesMiddleware.Middleware
-	ProblemDetailConverter  muxTypesResponseError.ProblemDetailConverter
+	// Compression is how response bodies are compressed; the defaults of compression_config apply
+	// when it is nil.
+	Compression *compression_config.Config
+	Middleware  []muxTypesMiddleware.Middleware
+	// HandlerMiddleware wraps the handling of each request that passes the firewall, the first
+	// middleware being the outermost.
+	HandlerMiddleware      []muxTypesMiddleware.HandlerMiddleware
+	ProblemDetailConverter muxTypesResponseError.ProblemDetailConverter
+	// Metrics records the requests served, labelled by the path of the endpoint they matched. A mux
+	// nested in another that records them too has its requests recorded by both.
+	Metrics *mux_metrics.Metrics
 }
 
 //nolint:contextcheck,fatcontext // The request context is deliberately extended and reassigned via request.WithContext; contextcheck cannot track the chain, and the loop builds one derived context from the configured pairs.
@@ -136,22 +147,49 @@ func (bm *baseMux) ServeHttpWithCallback(
 
 		responseWriter.DefaultHeaders = bm.DefaultHeaders
 		responseWriter.DefaultDocumentHeaders = bm.DefaultDocumentHeaders
+		responseWriter.Compression = bm.Compression
 	} else {
 		responseWriter = &muxTypesResponseWriter.ResponseWriter{
 			ResponseWriter:         originalResponseWriter,
 			DefaultHeaders:         bm.DefaultHeaders,
 			DefaultDocumentHeaders: bm.DefaultDocumentHeaders,
+			Compression:            bm.Compression,
 		}
 	}
 
 	responseWriter.IsHeadRequest = strings.ToUpper(request.Method) == http.MethodHead
 
+	// The response's nonce is generated where the handler asks for it, through the context, and
+	// merged into the response's policy then.
+	cspNonce := &csp_nonce.Nonce{}
+	responseWriter.CspNonce = cspNonce
+	request = request.WithContext(context.WithValue(request.Context(), muxContext.CspNonceContextKey, cspNonce))
+
+	// Observe the request, for as long as it is handled; the endpoint it matches is recorded in the
+	// observation along the way.
+
+	metrics := bm.Metrics
+	if metrics != nil {
+		observation := metrics.Start(request)
+		request = request.WithContext(
+			context.WithValue(request.Context(), muxContext.MetricsObservationContextKey, observation),
+		)
+		defer func() {
+			var statusCode int
+			if responseWriter.WriteHeaderCalled {
+				statusCode = responseWriter.WrittenStatusCode
+			}
+			metrics.Finish(observation, statusCode, responseWriter.WrittenSize)
+		}()
+	}
+
 	// Perform firewall check.
 
 	verdict := muxTypesFirewall.Accept
 	var firewallResponseError *muxTypesResponseError.ResponseError
 	if firewallParser := bm.FirewallParser; !utils.IsNil(firewallParser) {
 		verdict, firewallResponseError = firewallParser.Parse(request)
+		metrics.ObserveFirewallVerdict(verdict)
 	}
 
 	switch verdict {
@@ -215,7 +253,8 @@ func (bm *baseMux) ServeHttpWithCallback(
 
 		// Respond to the request.
 
-		response, responseError := callback(request, responseWriter)
+		handler := muxTypesMiddleware.Chain(callback, bm.HandlerMiddleware...)
+		response, responseError := handler(request, responseWriter)
 
 		if !responseWriter.WriteHeaderCalled {
 			if responseError != nil {
@@ -267,6 +306,9 @@ func (bm *baseMux) ServeHttpWithCallback(
 type Mux struct {
 	baseMux
 	EndpointMap map[string]map[string]*endpointPkg.Endpoint
+	// pathPatternTree routes the endpoints whose paths have named segments or a wildcard; those
+	// endpoints must be registered with Add to be routed to.
+	pathPatternTree *path_pattern.Tree
 }
 
 func muxHandleRequest(
@@ -307,14 +349,37 @@ func muxHandleRequest(
 
 	// Locate the endpoint.
 
-	endpoint, methodToEndpoint, responseError := muxInternalMux.GetEndpoint(
+	endpoint, methodToEndpoint, pathParameters, responseError := muxInternalMux.GetEndpoint(
 		mux.EndpointMap,
+		mux.pathPatternTree,
 		request,
 	)
 	if responseError != nil {
 		return nil, responseError
 	}
 
+	observation := mux_metrics.ObservationFromContext(request.Context())
+	if endpoint != nil {
+		observation.SetEndpoint(endpoint.Path)
+	} else {
+		// The endpoints of the other methods are all of the path requested.
+		for _, methodEndpoint := range methodToEndpoint {
+			if methodEndpoint != nil {
+				observation.SetEndpoint(methodEndpoint.Path)
+				break
+			}
+		}
+	}
+
+	if len(pathParameters) != 0 {
+		for name, value := range pathParameters {
+			request.SetPathValue(name, value)
+		}
+		request = request.WithContext(
+			context.WithValue(request.Context(), utils2.PathParametersContextKey, pathParameters),
+		)
+	}
+
 	// There exists no endpoint for the given method,
 	if endpoint == nil {
 		// and for no other methods either, which is an error (as "Not Found" should be produced by `GetEndpoint`)
@@ -336,6 +401,9 @@ func muxHandleRequest(
 
 	if rateLimitingConfiguration := endpoint.RateLimitingConfiguration; rateLimitingConfiguration != nil {
 		if responseError := muxInternalMux.HandleRateLimiting(rateLimitingConfiguration, request); responseError != nil {
+			if problemDetail := responseError.ProblemDetail; problemDetail != nil && problemDetail.Status == http.StatusTooManyRequests {
+				observation.SetRateLimited()
+			}
 			responseError.Headers = append(responseError.Headers, corsHeaderEntries...)
 			return nil, responseError
 		}
@@ -405,8 +473,18 @@ func muxHandleRequest(
 		return nil, responseError
 	}
 
-	// Produce the response (handler and/or static content).
-	response, responseError := produceResponse(endpoint, request, requestBody, requestHeader)
+	// Produce the response (handler and/or static content), wrapped in the endpoint's middleware.
+	handler := muxTypesMiddleware.Chain(
+		func(
+			request *http.Request,
+			_ *muxTypesResponseWriter.ResponseWriter,
+		) (*muxTypesResponse.Response, *muxTypesResponseError.ResponseError) {
+			return produceResponse(endpoint, request, requestBody, requestHeader)
+		},
+		endpoint.Middleware...,
+	)
+	muxResponseWriter, _ := responseWriter.(*muxTypesResponseWriter.ResponseWriter)
+	response, responseError := handler(request, muxResponseWriter)
 	if responseError != nil {
 		responseError.Headers = append(responseError.Headers, corsHeaderEntries...)
 		return nil, responseError
@@ -821,6 +899,7 @@ func (mux *Mux) Add(endpoints ...*endpointPkg.Endpoint) {
 		if !ok {
 			methodToEndpoint = make(map[string]*endpointPkg.Endpoint)
 			endpointMap[endpoint.Path] = methodToEndpoint
+			mux.addPathPattern(endpoint.Path)
 		}
 
 		methodToEndpoint[strings.ToUpper(endpoint.Method)] = endpoint
@@ -849,10 +928,40 @@ func (mux *Mux) Delete(endpoints ...*endpointPkg.Endpoint) {
 
 		if len(methodToEndpoint) == 0 {
 			delete(endpointSpecificationMap, endpoint.Path)
+			if mux.pathPatternTree != nil {
+				mux.pathPatternTree.Delete(endpoint.Path)
+			}
 		}
 	}
 }
 
+// addPathPattern adds the path to the path pattern tree if it has named segments or a wildcard.
+func (mux *Mux) addPathPattern(path string) {
+	if !strings.ContainsAny(path, "{}*") {
+		return
+	}
+
+	pattern, err := path_pattern.Parse(path)
+	if err != nil {
+		slog.WarnContext(
+			motmedelContext.WithError(context.Background(), fmt.Errorf("path pattern parse: %w", err)),
+			fmt.Sprintf("Endpoint with a malformed path pattern; it is only matched exactly: %s.", path),
+		)
+		return
+	}
+	if pattern.IsLiteral() {
+		return
+	}
+
+	if mux.pathPatternTree == nil {
+		mux.pathPatternTree = &path_pattern.Tree{}
+	}
+
+	if previous := mux.pathPatternTree.Add(pattern); previous != nil && previous.Path != path {
+		slog.Warn(fmt.Sprintf("Endpoint path pattern %s replaces the equivalent %s.", path, previous.Path))
+	}
+}
+
 func (mux *Mux) Get(path string, method string) *endpointPkg.Endpoint {
 	endpointMap := mux.EndpointMap
 	if endpointMap == nil {
@@ -2,11 +2,15 @@ package mux
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	endpointPkg "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
 	staticContentPkg "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint/static_content"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser"
 	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	muxUtils "github.com/Motmedel/utils_go/pkg/http/mux/utils"
 )
 
 func TestNew(t *testing.T) {
@@ -169,3 +173,63 @@ func TestMux_ContentSecurityPolicy(t *testing.T) {
 		t.Fatal("expected an error when default document headers are nil")
 	}
 }
+
+func TestMux_PathPattern(t *testing.T) {
+	t.Parallel()
+
+	var urlParserId string
+	patternEndpoint := &endpointPkg.Endpoint{
+		Path:   "/users/{id}/keys/{kid}",
+		Method: http.MethodGet,
+		Public: true,
+		UrlParser: request_parser.New(
+			func(request *http.Request) (any, *muxResponseError.ResponseError) {
+				urlParserId = request.PathValue("id")
+				return nil, nil
+			},
+		),
+		Handler: func(request *http.Request, _ []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			parameters, responseError := muxUtils.GetServerPathParameters(request.Context())
+			if responseError != nil {
+				return nil, responseError
+			}
+			return &muxResponse.Response{Body: []byte(parameters["id"] + ":" + request.PathValue("kid"))}, nil
+		},
+	}
+	literalEndpoint := &endpointPkg.Endpoint{
+		Path:   "/users/me/keys/current",
+		Method: http.MethodGet,
+		Public: true,
+		Handler: func(*http.Request, []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			return &muxResponse.Response{Body: []byte("literal")}, nil
+		},
+	}
+
+	mux := New(patternEndpoint, literalEndpoint)
+
+	serve := func(method string, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequestWithContext(t.Context(), method, path, nil))
+		return recorder
+	}
+
+	if recorder := serve(http.MethodGet, "/users/42/keys/k1"); recorder.Code != http.StatusOK || recorder.Body.String() != "42:k1" {
+		t.Fatalf("got %d %q, want 200 %q", recorder.Code, recorder.Body.String(), "42:k1")
+	}
+	if urlParserId != "42" {
+		t.Fatalf("url parser saw id %q, want %q", urlParserId, "42")
+	}
+
+	if recorder := serve(http.MethodGet, "/users/me/keys/current"); recorder.Body.String() != "literal" {
+		t.Fatalf("expected the literal endpoint to take precedence, got %q", recorder.Body.String())
+	}
+
+	if recorder := serve(http.MethodPost, "/users/42/keys/k1"); recorder.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("got %d, want %d", recorder.Code, http.StatusMethodNotAllowed)
+	}
+
+	mux.Delete(patternEndpoint)
+	if recorder := serve(http.MethodGet, "/users/42/keys/k1"); recorder.Code != http.StatusNotFound {
+		t.Fatalf("got %d after delete, want %d", recorder.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,111 @@
+package mux
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/firewall_verdict"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/mux_metrics"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser"
+	muxTypesResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+)
+
+func TestMux_Metrics(t *testing.T) {
+	t.Parallel()
+
+	metrics, err := mux_metrics.New()
+	if err != nil {
+		t.Fatalf("mux metrics new: %v", err)
+	}
+
+	mux := New(
+		&endpoint.Endpoint{
+			Path:   "/users/{id}",
+			Method: http.MethodGet,
+			Handler: func(*http.Request, []byte) (*muxTypesResponse.Response, *response_error.ResponseError) {
+				return &muxTypesResponse.Response{Body: []byte("hello")}, nil
+			},
+			Public: true,
+		},
+		&endpoint.Endpoint{
+			Path:   "/limited",
+			Method: http.MethodGet,
+			RateLimitingConfiguration: &rate_limiting.RateLimitingConfiguration{
+				NumRequests:          1,
+				NumSecondsExpiration: 60,
+			},
+			Public: true,
+		},
+	)
+	mux.Metrics = metrics
+	mux.FirewallParser = request_parser.New(
+		func(request *http.Request) (firewall_verdict.Verdict, *response_error.ResponseError) {
+			if request.URL.Path == "/blocked" {
+				return firewall_verdict.Reject, nil
+			}
+			return firewall_verdict.Accept, nil
+		},
+	)
+
+	for _, target := range []string{"/users/1", "/users/2", "/missing", "/blocked", "/limited", "/limited"} {
+		request := httptest.NewRequestWithContext(t.Context(), http.MethodGet, target, nil)
+		request.RemoteAddr = "192.0.2.1:1234"
+		mux.ServeHTTP(httptest.NewRecorder(), request)
+	}
+	mux.ServeHTTP(
+		httptest.NewRecorder(),
+		httptest.NewRequestWithContext(t.Context(), "MADE-UP", "/users/1", nil),
+	)
+
+	testCases := []struct {
+		name        string
+		labelValues []string
+		want        float64
+	}{
+		{name: "registered path", labelValues: []string{"", http.MethodGet, "/users/{id}", "2xx"}, want: 2},
+		{name: "unmatched", labelValues: []string{"", http.MethodGet, mux_metrics.UnmatchedEndpoint, "4xx"}, want: 2},
+		{name: "limited", labelValues: []string{"", http.MethodGet, "/limited", "2xx"}, want: 1},
+		{name: "rejected", labelValues: []string{"", http.MethodGet, "/limited", "4xx"}, want: 1},
+		{name: "other method", labelValues: []string{"", mux_metrics.OtherMethod, "/users/{id}", "4xx"}, want: 1},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := metrics.Requests.With(testCase.labelValues...).Value(); got != testCase.want {
+				t.Errorf("requests %v: got %v, want %v", testCase.labelValues, got, testCase.want)
+			}
+		})
+	}
+
+	if got := metrics.RateLimitRejections.With("", http.MethodGet, "/limited").Value(); got != 1 {
+		t.Errorf("rate limit rejections: got %v", got)
+	}
+	if got := metrics.FirewallVerdicts.With(firewall_verdict.Reject.String()).Value(); got != 1 {
+		t.Errorf("reject verdicts: got %v", got)
+	}
+	if got := metrics.FirewallVerdicts.With(firewall_verdict.Accept.String()).Value(); got != 6 {
+		t.Errorf("accept verdicts: got %v", got)
+	}
+	if got := metrics.ActiveRequests.With(http.MethodGet).Value(); got != 0 {
+		t.Errorf("active requests: got %v", got)
+	}
+	if got := metrics.ResponseBodySize.With("", http.MethodGet, "/users/{id}").Sum(); got != 10 {
+		t.Errorf("response body size: got %v", got)
+	}
+
+	var buffer bytes.Buffer
+	if err := metrics.Registry.WriteOpenMetrics(&buffer); err != nil {
+		t.Fatalf("write open metrics: %v", err)
+	}
+	if !strings.Contains(buffer.String(), `http_server_requests_total{host="",method="GET",endpoint="/users/{id}",status_class="2xx"} 2`) {
+		t.Errorf("exposition:\n%s", buffer.String())
+	}
+}
@@ -4,17 +4,22 @@ import (
 	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/csp_nonce"
 	endpointPkg "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
 	"github.com/Motmedel/utils_go/pkg/http/mux/types/firewall_verdict"
 	muxTypesMiddleware "github.com/Motmedel/utils_go/pkg/http/mux/types/middleware"
 	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser"
 	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
 	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	muxTypesResponseWriter "github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+	muxUtils "github.com/Motmedel/utils_go/pkg/http/mux/utils"
 	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail"
 )
 
@@ -154,3 +159,149 @@ func TestMux_ServeHTTP_MiddlewareContextAndDoneCallback(t *testing.T) {
 		t.Error("expected the done callback to run")
 	}
 }
+
+func TestMux_ServeHTTP_HandlerMiddleware(t *testing.T) {
+	t.Parallel()
+
+	recoverPanic := func(next muxTypesMiddleware.Handler) muxTypesMiddleware.Handler {
+		return func(
+			request *http.Request,
+			responseWriter *muxTypesResponseWriter.ResponseWriter,
+		) (response *muxResponse.Response, responseError *muxResponseError.ResponseError) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					response = nil
+					responseError = &muxResponseError.ResponseError{ServerError: fmt.Errorf("panic: %v", recovered)}
+				}
+			}()
+			return next(request, responseWriter)
+		}
+	}
+
+	addHeader := func(next muxTypesMiddleware.Handler) muxTypesMiddleware.Handler {
+		return func(
+			request *http.Request,
+			responseWriter *muxTypesResponseWriter.ResponseWriter,
+		) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			response, responseError := next(request, responseWriter)
+			if response != nil {
+				response.Headers = append(response.Headers, &muxResponse.HeaderEntry{Name: "X-Observed", Value: "1"})
+			}
+			return response, responseError
+		}
+	}
+
+	var parsedAuthentication any
+	endpointMiddleware := func(next muxTypesMiddleware.Handler) muxTypesMiddleware.Handler {
+		return func(
+			request *http.Request,
+			responseWriter *muxTypesResponseWriter.ResponseWriter,
+		) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			parsedAuthentication = request.Context().Value(muxUtils.ParsedRequestAuthenticationContextKey)
+			if request.Header.Get("X-Deny") != "" {
+				return nil, &muxResponseError.ResponseError{ProblemDetail: problem_detail.New(http.StatusConflict)}
+			}
+			return next(request, responseWriter)
+		}
+	}
+
+	mux := New(
+		&endpointPkg.Endpoint{
+			Path:   "/x",
+			Method: http.MethodGet,
+			AuthenticationParser: request_parser.New(func(*http.Request) (any, *muxResponseError.ResponseError) {
+				return "user", nil
+			}),
+			Handler: func(*http.Request, []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+				return &muxResponse.Response{Body: []byte("ok")}, nil
+			},
+			Middleware: []muxTypesMiddleware.HandlerMiddleware{endpointMiddleware},
+		},
+		&endpointPkg.Endpoint{
+			Path:   "/panic",
+			Method: http.MethodGet,
+			Public: true,
+			Handler: func(*http.Request, []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+				panic("boom")
+			},
+		},
+	)
+	mux.DoneCallback = nil
+	mux.ResponseErrorHandler = func(
+		_ context.Context,
+		responseError *muxResponseError.ResponseError,
+		responseWriter *muxTypesResponseWriter.ResponseWriter,
+	) {
+		statusCode := http.StatusInternalServerError
+		if responseError.ProblemDetail != nil {
+			statusCode = responseError.ProblemDetail.Status
+		}
+		responseWriter.WriteHeader(statusCode)
+	}
+	mux.HandlerMiddleware = []muxTypesMiddleware.HandlerMiddleware{recoverPanic, addHeader}
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/x", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "ok" {
+		t.Fatalf("got %d %q, want 200 ok", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("X-Observed") != "1" {
+		t.Error("expected the mux middleware to amend the response")
+	}
+	if parsedAuthentication != "user" {
+		t.Errorf("expected the endpoint middleware to see the parsed authentication, got %v", parsedAuthentication)
+	}
+
+	request := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/x", nil)
+	request.Header.Set("X-Deny", "1")
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("expected the endpoint middleware to short-circuit with 409, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/panic", nil))
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("expected the recovered panic to produce 500, got %d", recorder.Code)
+	}
+}
+
+func TestMux_ServeHTTP_CspNonce(t *testing.T) {
+	t.Parallel()
+
+	mux := New(
+		&endpointPkg.Endpoint{
+			Path:   "/document",
+			Method: http.MethodGet,
+			Public: true,
+			Handler: func(request *http.Request, _ []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+				nonce := csp_nonce.FromContext(request.Context())
+				return &muxResponse.Response{
+					Headers: []*muxResponse.HeaderEntry{{Name: "Content-Type", Value: "text/html"}},
+					Body:    []byte(`<script nonce="` + nonce + `">run()</script>`),
+				}, nil
+			},
+		},
+	)
+
+	var nonces []string
+	for range 2 {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/document", nil))
+
+		nonce := strings.TrimSuffix(strings.TrimPrefix(recorder.Body.String(), `<script nonce="`), `">run()</script>`)
+		if nonce == "" {
+			t.Fatalf("expected a nonce in the body, got %q", recorder.Body.String())
+		}
+		policy := recorder.Header().Get("Content-Security-Policy")
+		if !strings.Contains(policy, "script-src 'self' 'nonce-"+nonce+"'") {
+			t.Errorf("expected the nonce of the body in the policy, got %q", policy)
+		}
+		nonces = append(nonces, nonce)
+	}
+
+	if nonces[0] == nonces[1] {
+		t.Error("expected a nonce per response")
+	}
+}
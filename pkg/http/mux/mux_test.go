@@ -624,10 +624,10 @@ func TestMux(t *testing.T) {
 func TestRateLimiting(t *testing.T) {
 	t.Parallel()
 
-	// The rate limiter is driven by wall-clock timers (time.Now/time.AfterFunc). Run
-	// the test inside a synctest bubble with a fake clock and drive the mux in-process
-	// (synctest cannot virtualize a real server's socket I/O). time.Sleep then advances
-	// the fake clock instantly rather than waiting for the real rate-limit window.
+	// The rate limiter is driven by the wall clock (time.Now). Run the test inside a
+	// synctest bubble with a fake clock and drive the mux in-process (synctest cannot
+	// virtualize a real server's socket I/O). time.Sleep then advances the fake clock
+	// instantly rather than waiting for the real rate-limit window.
 	synctest.Test(t, func(t *testing.T) {
 		mux := &Mux{}
 		mux.Add(
@@ -675,11 +675,8 @@ func TestRateLimiting(t *testing.T) {
 			t.Fatal("invalid Retry-After wait time")
 		}
 
-		// Advance the fake clock past the rate-limit window; the bucket-freeing timers
-		// fire during this sleep. synctest.Wait then ensures those timer goroutines have
-		// completed before the next request is issued.
+		// Advance the fake clock to the time the client was told to retry at.
 		time.Sleep(time.Until(waitTime))
-		synctest.Wait()
 
 		if recorder := get(); recorder.Code != http.StatusNoContent {
 			t.Errorf("got status code %d, expected %d", recorder.Code, http.StatusNoContent)
@@ -0,0 +1,63 @@
+// Package csp_nonce provides the Content Security Policy nonce of a document response, with which
+// the inline scripts and styles of a server-rendered document are permitted whatever their content.
+package csp_nonce
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+
+	muxContext "github.com/Motmedel/utils_go/pkg/http/mux/context"
+)
+
+// Nonce is the nonce of a response. It is generated when first asked for, and only a response
+// whose nonce was asked for has it merged into its policy: a nonce in a source list makes a browser
+// disregard 'unsafe-inline' there, which a response that embeds none may rely on.
+//
+// A nonce is of the response it was generated for; a response that embeds one is not to be cached
+// and served again, as what makes it a nonce is that it cannot be predicted.
+type Nonce struct {
+	mutex sync.Mutex
+	value string
+}
+
+// Value returns the nonce, generating it if it has not been already. It is 128 random bits,
+// encoded with an alphabet that is a subset of that of base64.
+func (nonce *Nonce) Value() string {
+	if nonce == nil {
+		return ""
+	}
+
+	nonce.mutex.Lock()
+	defer nonce.mutex.Unlock()
+
+	if nonce.value == "" {
+		nonce.value = rand.Text()
+	}
+
+	return nonce.value
+}
+
+// Generated returns the nonce and whether it has been generated, without generating it.
+func (nonce *Nonce) Generated() (string, bool) {
+	if nonce == nil {
+		return "", false
+	}
+
+	nonce.mutex.Lock()
+	defer nonce.mutex.Unlock()
+
+	return nonce.value, nonce.value != ""
+}
+
+// FromContext returns the nonce of the response to the request the context is of, for the
+// handler to embed in the document's script and style elements. It is empty where the request is
+// not served by a mux.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	nonce, _ := ctx.Value(muxContext.CspNonceContextKey).(*Nonce)
+	return nonce.Value()
+}
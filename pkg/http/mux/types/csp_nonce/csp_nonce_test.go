@@ -0,0 +1,51 @@
+package csp_nonce
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	muxContext "github.com/Motmedel/utils_go/pkg/http/mux/context"
+)
+
+// base64ValuePattern is the grammar of the value of a nonce source.
+var base64ValuePattern = regexp.MustCompile(`^[A-Za-z0-9+/_-]+=*$`)
+
+func TestNonce(t *testing.T) {
+	t.Parallel()
+
+	nonce := &Nonce{}
+	if _, ok := nonce.Generated(); ok {
+		t.Fatal("expected the nonce not to be generated before it is asked for")
+	}
+
+	value := nonce.Value()
+	if !base64ValuePattern.MatchString(value) {
+		t.Errorf("expected a base64 value, got %q", value)
+	}
+	if again := nonce.Value(); again != value {
+		t.Errorf("expected the same nonce when asked again, got %q and %q", value, again)
+	}
+	if generated, ok := nonce.Generated(); !ok || generated != value {
+		t.Errorf("generated: got %q, %t", generated, ok)
+	}
+
+	if other := (&Nonce{}).Value(); other == value {
+		t.Error("expected nonces to differ")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	if value := FromContext(t.Context()); value != "" {
+		t.Errorf("expected no nonce outside of a mux, got %q", value)
+	}
+
+	nonce := &Nonce{}
+	ctx := context.WithValue(t.Context(), muxContext.CspNonceContextKey, nonce)
+	value := FromContext(ctx)
+	if generated, ok := nonce.Generated(); !ok || generated != value {
+		t.Errorf("expected the nonce of the context to be generated, got %q, %t", generated, ok)
+	}
+}
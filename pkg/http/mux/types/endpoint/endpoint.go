@@ -23,6 +23,7 @@ import (
 	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
 	"github.com/Motmedel/utils_go/pkg/http/mux/types/body_loader"
 	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint/static_content"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/middleware"
 	muxTypesRateLimiting "github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting"
 	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser"
 	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
@@ -58,6 +59,9 @@ type Endpoint struct {
 	Hint                      *Hint
 	Handler                   Handler
 	StaticContent             *static_content.StaticContent
+	// Middleware wraps the production of the endpoint's response, once the request has been
+	// authenticated and its URL, header and body parsed. The first middleware is the outermost.
+	Middleware []middleware.HandlerMiddleware
 }
 
 // Duplicate returns the endpoint as it would be served at each of the paths, for a response that
@@ -0,0 +1,167 @@
+package event_stream
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/event_stream/event_stream_config"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+)
+
+const (
+	ContentType           = "text/event-stream"
+	LastEventIdHeaderName = "Last-Event-ID"
+)
+
+var keepAliveComment = []byte(":\n\n")
+
+var lineBreakReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// Event is a server-sent event.
+type Event struct {
+	// Id is what the client sends back in Last-Event-ID when it reconnects.
+	Id string
+	// Event is the type of the event; the client dispatches an event without one as "message".
+	Event string
+	Data  string
+	// Retry is the reconnection time the client is to use from then on, if positive.
+	Retry time.Duration
+	// Comment is sent as a comment, which the client disregards.
+	Comment string
+}
+
+func writeLines(buffer *bytes.Buffer, field string, value string) {
+	for line := range strings.SplitSeq(lineBreakReplacer.Replace(value), "\n") {
+		buffer.WriteString(field)
+		if line != "" {
+			buffer.WriteString(" ")
+			buffer.WriteString(line)
+		}
+		buffer.WriteString("\n")
+	}
+}
+
+// Encode encodes the event in the event stream format. An id or type that spans lines, or an id
+// that contains NULL, which the client would disregard, is an error.
+func (event *Event) Encode() ([]byte, error) {
+	if event == nil {
+		return nil, nil
+	}
+
+	if strings.ContainsAny(event.Id, "\r\n\x00") {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("%w: bad id", muxErrors.ErrMalformedEvent), event.Id)
+	}
+	if strings.ContainsAny(event.Event, "\r\n") {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("%w: bad type", muxErrors.ErrMalformedEvent), event.Event)
+	}
+
+	var buffer bytes.Buffer
+
+	if event.Comment != "" {
+		writeLines(&buffer, ":", event.Comment)
+	}
+	if event.Event != "" {
+		buffer.WriteString("event: " + event.Event + "\n")
+	}
+	if event.Id != "" {
+		buffer.WriteString("id: " + event.Id + "\n")
+	}
+	if event.Retry > 0 {
+		buffer.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	if event.Data != "" || event.Event != "" {
+		writeLines(&buffer, "data:", event.Data)
+	}
+	buffer.WriteString("\n")
+
+	return buffer.Bytes(), nil
+}
+
+// GetLastEventId returns the id of the last event a reconnecting client received, or an empty
+// string if it is not reconnecting.
+func GetLastEventId(requestHeader http.Header) string {
+	if requestHeader == nil {
+		return ""
+	}
+
+	return requestHeader.Get(LastEventIdHeaderName)
+}
+
+// New makes a response that streams the events received from the channel until it is closed, the
+// context -- which should be that of the request, done when the client disconnects -- is done, or
+// the client cannot be written to. The stream is flushed after each event, and a keep-alive comment
+// is sent when it has been idle for the configured interval.
+func New(ctx context.Context, events <-chan *Event, options ...event_stream_config.Option) *muxResponse.Response {
+	config := event_stream_config.New(options...)
+
+	return &muxResponse.Response{
+		StatusCode: http.StatusOK,
+		Headers: []*muxResponse.HeaderEntry{
+			{Name: "Content-Type", Value: ContentType},
+			// Keep reverse proxies that buffer responses from holding back the events.
+			{Name: "X-Accel-Buffering", Value: "no"},
+		},
+		BodyStreamer: func(yield func([]byte, error) bool) {
+			// The first chunk is written and flushed at once, sending the headers to the client.
+			var first []byte
+			if retry := config.Retry; retry > 0 {
+				first, _ = (&Event{Retry: retry}).Encode()
+			}
+			if !yield(first, nil) {
+				return
+			}
+
+			// The keep-alive timer is reset whenever something is sent, so that only an idle stream
+			// gets comments.
+			interval := config.KeepAliveInterval
+			var keepAliveTimer *time.Timer
+			var keepAlive <-chan time.Time
+			if interval > 0 {
+				keepAliveTimer = time.NewTimer(interval)
+				defer keepAliveTimer.Stop()
+				keepAlive = keepAliveTimer.C
+			}
+			resetKeepAlive := func() {
+				if keepAliveTimer != nil {
+					keepAliveTimer.Reset(interval)
+				}
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-keepAlive:
+					if !yield(keepAliveComment, nil) {
+						return
+					}
+					resetKeepAlive()
+				case event, ok := <-events:
+					if !ok {
+						return
+					}
+
+					data, err := event.Encode()
+					if err != nil {
+						yield(nil, fmt.Errorf("event encode: %w", err))
+						return
+					}
+					if len(data) == 0 {
+						continue
+					}
+					if !yield(data, nil) {
+						return
+					}
+					resetKeepAlive()
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package event_stream_config
+
+import (
+	"time"
+)
+
+var (
+	DefaultKeepAliveInterval = 15 * time.Second
+)
+
+type Config struct {
+	// KeepAliveInterval is how long the stream may be idle before a comment is sent to keep
+	// intermediaries from closing the connection; an interval that is not positive sends none.
+	KeepAliveInterval time.Duration
+	// Retry is the reconnection time sent to the client at the start of the stream, if positive.
+	Retry time.Duration
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		KeepAliveInterval: DefaultKeepAliveInterval,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	return config
+}
+
+func WithKeepAliveInterval(keepAliveInterval time.Duration) Option {
+	return func(config *Config) {
+		config.KeepAliveInterval = keepAliveInterval
+	}
+}
+
+func WithRetry(retry time.Duration) Option {
+	return func(config *Config) {
+		config.Retry = retry
+	}
+}
@@ -0,0 +1,30 @@
+package event_stream_config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.KeepAliveInterval != DefaultKeepAliveInterval {
+		t.Errorf("keep-alive interval: got %v", config.KeepAliveInterval)
+	}
+	if config.Retry != 0 {
+		t.Errorf("retry: got %v", config.Retry)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	config := New(WithKeepAliveInterval(time.Second), WithRetry(3*time.Second))
+	if config.KeepAliveInterval != time.Second {
+		t.Errorf("keep-alive interval: got %v", config.KeepAliveInterval)
+	}
+	if config.Retry != 3*time.Second {
+		t.Errorf("retry: got %v", config.Retry)
+	}
+}
@@ -0,0 +1,202 @@
+package event_stream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/event_stream/event_stream_config"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+)
+
+func TestEvent_Encode(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		event   *Event
+		want    string
+		wantErr error
+	}{
+		{name: "nil", event: nil, want: ""},
+		{name: "data", event: &Event{Data: "hello"}, want: "data: hello\n\n"},
+		{
+			name:  "all fields",
+			event: &Event{Id: "7", Event: "update", Data: "x", Retry: 3 * time.Second, Comment: "note"},
+			want:  ": note\nevent: update\nid: 7\nretry: 3000\ndata: x\n\n",
+		},
+		{name: "multiline data", event: &Event{Data: "a\r\nb\rc\n"}, want: "data: a\ndata: b\ndata: c\ndata:\n\n"},
+		{name: "event without data", event: &Event{Event: "ping"}, want: "event: ping\ndata:\n\n"},
+		{name: "comment only", event: &Event{Comment: "a\nb"}, want: ": a\n: b\n\n"},
+		{name: "id with newline", event: &Event{Id: "a\nb"}, wantErr: muxErrors.ErrMalformedEvent},
+		{name: "id with null", event: &Event{Id: "a\x00"}, wantErr: muxErrors.ErrMalformedEvent},
+		{name: "event with newline", event: &Event{Event: "a\rb"}, wantErr: muxErrors.ErrMalformedEvent},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := testCase.event.Encode()
+			if !errors.Is(err, testCase.wantErr) {
+				t.Fatalf("got error %v, want %v", err, testCase.wantErr)
+			}
+			if string(data) != testCase.want {
+				t.Errorf("got %q, want %q", data, testCase.want)
+			}
+		})
+	}
+}
+
+func TestGetLastEventId(t *testing.T) {
+	t.Parallel()
+
+	if id := GetLastEventId(nil); id != "" {
+		t.Errorf("got %q for a nil header", id)
+	}
+
+	header := http.Header{}
+	header.Set(LastEventIdHeaderName, "42")
+	if id := GetLastEventId(header); id != "42" {
+		t.Errorf("got %q, want %q", id, "42")
+	}
+}
+
+func collect(t *testing.T, ctx context.Context, events <-chan *Event, options ...event_stream_config.Option) ([]string, error) {
+	t.Helper()
+
+	var chunks []string
+	for chunk, err := range New(ctx, events, options...).BodyStreamer {
+		if err != nil {
+			return chunks, err
+		}
+		chunks = append(chunks, string(chunk))
+	}
+
+	return chunks, nil
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan *Event, 3)
+	events <- &Event{Id: "1", Data: "a"}
+	events <- nil
+	events <- &Event{Id: "2", Data: "b"}
+	close(events)
+
+	chunks, err := collect(t, context.Background(), events, event_stream_config.WithRetry(time.Second))
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+
+	want := []string{"retry: 1000\n\n", "id: 1\ndata: a\n\n", "id: 2\ndata: b\n\n"}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", chunks, want)
+	}
+}
+
+func TestNew_MalformedEvent(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan *Event, 1)
+	events <- &Event{Id: "\n"}
+
+	if _, err := collect(t, context.Background(), events); !errors.Is(err, muxErrors.ErrMalformedEvent) {
+		t.Fatalf("got %v, want %v", err, muxErrors.ErrMalformedEvent)
+	}
+}
+
+func TestNew_KeepAliveAndCancel(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		events := make(chan *Event)
+
+		done := make(chan []string)
+		go func() {
+			chunks, _ := collect(t, ctx, events, event_stream_config.WithKeepAliveInterval(time.Second))
+			done <- chunks
+		}()
+
+		time.Sleep(2500 * time.Millisecond)
+		events <- &Event{Data: "x"}
+		synctest.Wait()
+		cancel()
+
+		chunks := <-done
+		want := []string{"", ":\n\n", ":\n\n", "data: x\n\n"}
+		if strings.Join(chunks, "|") != strings.Join(want, "|") {
+			t.Errorf("got %q, want %q", chunks, want)
+		}
+	})
+}
+
+func TestNew_KeepAliveWhenIdleOnly(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		events := make(chan *Event)
+
+		done := make(chan []string)
+		go func() {
+			chunks, _ := collect(t, ctx, events, event_stream_config.WithKeepAliveInterval(time.Second))
+			done <- chunks
+		}()
+
+		for _, data := range []string{"a", "b", "c"} {
+			time.Sleep(600 * time.Millisecond)
+			events <- &Event{Data: data}
+		}
+		time.Sleep(1500 * time.Millisecond)
+		synctest.Wait()
+		cancel()
+
+		chunks := <-done
+		want := []string{"", "data: a\n\n", "data: b\n\n", "data: c\n\n", ":\n\n"}
+		if strings.Join(chunks, "|") != strings.Join(want, "|") {
+			t.Errorf("got %q, want %q", chunks, want)
+		}
+	})
+}
+
+func TestNew_WriteResponse(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan *Event, 1)
+	events <- &Event{Event: "greeting", Data: "hello"}
+	close(events)
+
+	recorder := httptest.NewRecorder()
+	responseWriter := &response_writer.ResponseWriter{ResponseWriter: recorder}
+	if err := responseWriter.WriteResponse(context.Background(), New(context.Background(), events), nil); err != nil {
+		t.Fatalf("write response: %v", err)
+	}
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("status code: got %d", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != ContentType {
+		t.Errorf("content type: got %q", contentType)
+	}
+	if cacheControl := recorder.Header().Get("Cache-Control"); cacheControl != "no-store" {
+		t.Errorf("cache control: got %q", cacheControl)
+	}
+	if body := recorder.Body.String(); body != "event: greeting\ndata: hello\n\n" {
+		t.Errorf("body: got %q", body)
+	}
+	if !recorder.Flushed {
+		t.Error("expected the stream to be flushed")
+	}
+	if len(responseWriter.WrittenBody) != 0 {
+		t.Errorf("expected the body not to be stored, got %q", responseWriter.WrittenBody)
+	}
+}
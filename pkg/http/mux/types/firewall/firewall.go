@@ -0,0 +1,636 @@
+package firewall
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	motmedelContext "github.com/Motmedel/utils_go/pkg/context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	motmedelHttpContext "github.com/Motmedel/utils_go/pkg/http/context"
+	muxContext "github.com/Motmedel/utils_go/pkg/http/mux/context"
+	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/firewall/firewall_config"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/firewall_verdict"
+	muxTypesResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
+	"github.com/Motmedel/utils_go/pkg/http/types/forwarded"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail"
+	"github.com/Motmedel/utils_go/pkg/schema"
+)
+
+const DefaultWatchInterval = 5 * time.Second
+
+// Rule decides on the requests it matches. A rule matches a request when each of its conditions
+// that is set does; a condition with several values is met by any of them.
+type Rule struct {
+	// Id identifies the rule in the counts and the logs; it must be unique within the rule set.
+	Id          string                   `json:"id"`
+	Name        string                   `json:"name,omitempty"`
+	Description string                   `json:"description,omitempty"`
+	Verdict     firewall_verdict.Verdict `json:"verdict"`
+	// Reason is why the verdict is reached, recorded in the HTTP context of the request.
+	Reason string `json:"reason,omitempty"`
+
+	// Ips are addresses and CIDR prefixes the client address is matched against.
+	Ips []string `json:"ips,omitempty"`
+	// ForwardedIps are addresses and CIDR prefixes any address in the Forwarded chain is matched
+	// against.
+	ForwardedIps []string `json:"forwarded_ips,omitempty"`
+	// Paths are globs the request path is matched against; `*` and `?` do not match a slash, while
+	// `**` matches anything.
+	Paths   []string `json:"paths,omitempty"`
+	Methods []string `json:"methods,omitempty"`
+	// Headers maps a header name to globs that any of its values are matched against,
+	// case-insensitively; a name without globs is matched by the presence of the header.
+	Headers map[string][]string `json:"headers,omitempty"`
+	// UserAgents are globs the User-Agent is matched against, case-insensitively.
+	UserAgents []string `json:"user_agents,omitempty"`
+	// Countries are ISO 3166-1 alpha-2 codes the country of the client address is matched against.
+	Countries []string `json:"countries,omitempty"`
+}
+
+// RuleSet holds rules that are evaluated in order, the first rule that matches a request deciding
+// on it. A request that no rule matches gets the default verdict.
+type RuleSet struct {
+	Name           string                   `json:"name,omitempty"`
+	DefaultVerdict firewall_verdict.Verdict `json:"default_verdict,omitzero"`
+	Rules          []*Rule                  `json:"rules"`
+}
+
+type compiledRule struct {
+	rule                *Rule
+	schemaRule          *schema.Rule
+	ipPrefixes          []netip.Prefix
+	forwardedIpPrefixes []netip.Prefix
+	methods             map[string]struct{}
+	headers             map[string][]string
+	userAgents          []string
+	countries           map[string]struct{}
+}
+
+type compiledRuleSet struct {
+	defaultVerdict firewall_verdict.Verdict
+	rules          []*compiledRule
+}
+
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, value := range values {
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, motmedelErrors.NewWithTrace(fmt.Errorf("netip parse prefix: %w", err), value)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		address, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("netip parse addr: %w", err), value)
+		}
+		address = address.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(address, address.BitLen()))
+	}
+
+	return prefixes, nil
+}
+
+func containsAddress(prefixes []netip.Prefix, address netip.Addr) bool {
+	if !address.IsValid() {
+		return false
+	}
+
+	for _, prefix := range prefixes {
+		if prefix.Contains(address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func compile(ruleSet *RuleSet) (*compiledRuleSet, error) {
+	if ruleSet == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("rule set"))
+	}
+
+	compiled := &compiledRuleSet{defaultVerdict: ruleSet.DefaultVerdict}
+	seenIds := make(map[string]struct{})
+
+	for i, rule := range ruleSet.Rules {
+		if rule == nil {
+			continue
+		}
+
+		if rule.Id == "" {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: rule %d has no id", muxErrors.ErrMalformedFirewallRuleSet, i),
+			)
+		}
+		if _, ok := seenIds[rule.Id]; ok {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: duplicate rule id %q", muxErrors.ErrMalformedFirewallRuleSet, rule.Id),
+			)
+		}
+		seenIds[rule.Id] = struct{}{}
+
+		ipPrefixes, err := parsePrefixes(rule.Ips)
+		if err != nil {
+			return nil, motmedelErrors.New(
+				fmt.Errorf("%w: rule %q: parse prefixes (ips): %w", muxErrors.ErrMalformedFirewallRuleSet, rule.Id, err),
+			)
+		}
+		forwardedIpPrefixes, err := parsePrefixes(rule.ForwardedIps)
+		if err != nil {
+			return nil, motmedelErrors.New(
+				fmt.Errorf(
+					"%w: rule %q: parse prefixes (forwarded ips): %w",
+					muxErrors.ErrMalformedFirewallRuleSet, rule.Id, err,
+				),
+			)
+		}
+
+		compiledRule := &compiledRule{
+			rule: rule,
+			schemaRule: &schema.Rule{
+				Id:          rule.Id,
+				Name:        rule.Name,
+				Description: rule.Description,
+				Ruleset:     ruleSet.Name,
+				Category:    "firewall",
+			},
+			ipPrefixes:          ipPrefixes,
+			forwardedIpPrefixes: forwardedIpPrefixes,
+		}
+
+		if len(rule.Methods) != 0 {
+			compiledRule.methods = make(map[string]struct{}, len(rule.Methods))
+			for _, method := range rule.Methods {
+				compiledRule.methods[strings.ToUpper(method)] = struct{}{}
+			}
+		}
+
+		if len(rule.Headers) != 0 {
+			compiledRule.headers = make(map[string][]string, len(rule.Headers))
+			for name, globs := range rule.Headers {
+				lowerGlobs := make([]string, len(globs))
+				for j, glob := range globs {
+					lowerGlobs[j] = strings.ToLower(glob)
+				}
+				compiledRule.headers[http.CanonicalHeaderKey(name)] = lowerGlobs
+			}
+		}
+
+		for _, userAgent := range rule.UserAgents {
+			compiledRule.userAgents = append(compiledRule.userAgents, strings.ToLower(userAgent))
+		}
+
+		if len(rule.Countries) != 0 {
+			compiledRule.countries = make(map[string]struct{}, len(rule.Countries))
+			for _, country := range rule.Countries {
+				compiledRule.countries[strings.ToUpper(country)] = struct{}{}
+			}
+		}
+
+		compiled.rules = append(compiled.rules, compiledRule)
+	}
+
+	return compiled, nil
+}
+
+// matchGlob reports whether the value matches the glob, in which `?` matches one byte and `*` any
+// run of bytes -- both but the separator, unless it is zero -- and `**` any run of bytes at all. It
+// runs in time proportional to the product of the lengths, whatever the glob.
+func matchGlob(glob string, value string, separator byte) bool {
+	matches := make([]bool, len(value)+1)
+	matches[0] = true
+	next := make([]bool, len(value)+1)
+
+	for i := 0; i < len(glob); i++ {
+		clear(next)
+
+		switch {
+		case glob[i] == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			i++
+			for j := 0; j <= len(value); j++ {
+				next[j] = matches[j] || (j > 0 && next[j-1])
+			}
+		case glob[i] == '*':
+			for j := 0; j <= len(value); j++ {
+				next[j] = matches[j] || (j > 0 && next[j-1] && (separator == 0 || value[j-1] != separator))
+			}
+		case glob[i] == '?':
+			for j := 0; j < len(value); j++ {
+				next[j+1] = matches[j] && (separator == 0 || value[j] != separator)
+			}
+		default:
+			for j := 0; j < len(value); j++ {
+				next[j+1] = matches[j] && value[j] == glob[i]
+			}
+		}
+
+		matches, next = next, matches
+	}
+
+	return matches[len(value)]
+}
+
+func matchAnyGlob(globs []string, value string, separator byte) bool {
+	for _, glob := range globs {
+		if matchGlob(glob, value, separator) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNode parses a node of the Forwarded header, which may be bracketed and carry a port. An
+// obfuscated or unknown node yields an invalid address.
+func parseNode(node string) netip.Addr {
+	node = strings.TrimSpace(node)
+	if strings.HasPrefix(node, "[") {
+		end := strings.Index(node, "]")
+		if end < 0 {
+			return netip.Addr{}
+		}
+		node = node[1:end]
+	} else if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+
+	address, err := netip.ParseAddr(node)
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	return address.Unmap()
+}
+
+// requestInfo computes what the rules match against once per request, and only if a rule needs it.
+type requestInfo struct {
+	request *http.Request
+	config  *firewall_config.Config
+
+	forwardedChain        []netip.Addr
+	forwardedChainParsed  bool
+	clientAddress         netip.Addr
+	clientAddressResolved bool
+	country               string
+	countryLookedUp       bool
+}
+
+func (info *requestInfo) getForwardedChain() []netip.Addr {
+	if info.forwardedChainParsed {
+		return info.forwardedChain
+	}
+	info.forwardedChainParsed = true
+
+	values := info.request.Header.Values("Forwarded")
+	if len(values) == 0 {
+		return nil
+	}
+
+	data := []byte(strings.Join(values, ", "))
+	parsedForwarded, err := forwarded.Parse(data)
+	if err != nil {
+		slog.DebugContext(
+			motmedelContext.WithError(info.request.Context(), fmt.Errorf("forwarded parse: %w", err)),
+			"The Forwarded header could not be parsed; it is disregarded by the firewall.",
+		)
+		return nil
+	}
+
+	for _, element := range parsedForwarded.Elements {
+		if element != nil {
+			info.forwardedChain = append(info.forwardedChain, parseNode(element.For))
+		}
+	}
+
+	return info.forwardedChain
+}
+
+func (info *requestInfo) getClientAddress() netip.Addr {
+	if info.clientAddressResolved {
+		return info.clientAddress
+	}
+	info.clientAddressResolved = true
+
+	remoteAddress := parseNode(info.request.RemoteAddr)
+	info.clientAddress = remoteAddress
+
+	trustedProxies := info.config.TrustedProxies
+	if len(trustedProxies) == 0 || !containsAddress(trustedProxies, remoteAddress) {
+		return info.clientAddress
+	}
+
+	chain := info.getForwardedChain()
+	for i := len(chain) - 1; i >= 0; i-- {
+		address := chain[i]
+		if !address.IsValid() {
+			// The client is hidden behind a node that cannot be told to be trusted.
+			info.clientAddress = netip.Addr{}
+			return info.clientAddress
+		}
+		info.clientAddress = address
+		if !containsAddress(trustedProxies, address) {
+			break
+		}
+	}
+
+	return info.clientAddress
+}
+
+func (info *requestInfo) getCountry() string {
+	if info.countryLookedUp {
+		return info.country
+	}
+	info.countryLookedUp = true
+
+	countryLookup := info.config.CountryLookup
+	clientAddress := info.getClientAddress()
+	if countryLookup == nil || !clientAddress.IsValid() {
+		return ""
+	}
+
+	country, err := countryLookup(info.request.Context(), clientAddress)
+	if err != nil {
+		slog.WarnContext(
+			motmedelContext.WithError(
+				info.request.Context(),
+				motmedelErrors.New(fmt.Errorf("country lookup: %w", err), clientAddress),
+			),
+			"An error occurred when looking up the country of a client; country rules do not match it.",
+		)
+		return ""
+	}
+
+	info.country = strings.ToUpper(country)
+	return info.country
+}
+
+func (rule *compiledRule) matches(info *requestInfo) bool {
+	request := info.request
+
+	if rule.methods != nil {
+		if _, ok := rule.methods[strings.ToUpper(request.Method)]; !ok {
+			return false
+		}
+	}
+
+	if len(rule.rule.Paths) != 0 {
+		var requestPath string
+		if request.URL != nil {
+			requestPath = request.URL.Path
+		}
+		if !matchAnyGlob(rule.rule.Paths, requestPath, '/') {
+			return false
+		}
+	}
+
+	for name, globs := range rule.headers {
+		values := request.Header.Values(name)
+		if len(values) == 0 {
+			return false
+		}
+		if len(globs) == 0 {
+			continue
+		}
+
+		var matched bool
+		for _, value := range values {
+			if matchAnyGlob(globs, strings.ToLower(value), 0) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(rule.userAgents) != 0 && !matchAnyGlob(rule.userAgents, strings.ToLower(request.UserAgent()), 0) {
+		return false
+	}
+
+	if len(rule.ipPrefixes) != 0 && !containsAddress(rule.ipPrefixes, info.getClientAddress()) {
+		return false
+	}
+
+	if len(rule.forwardedIpPrefixes) != 0 {
+		var matched bool
+		for _, address := range info.getForwardedChain() {
+			if containsAddress(rule.forwardedIpPrefixes, address) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if rule.countries != nil {
+		if _, ok := rule.countries[info.getCountry()]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Firewall is a FirewallParser that decides on requests by a rule set, which may be replaced -- by
+// Load, LoadFile or Watch -- while requests are being decided on.
+type Firewall struct {
+	ruleSet  atomic.Pointer[compiledRuleSet]
+	counters sync.Map
+	config   *firewall_config.Config
+}
+
+func (firewall *Firewall) count(id string) {
+	counter, ok := firewall.counters.Load(id)
+	if !ok {
+		counter, _ = firewall.counters.LoadOrStore(id, &atomic.Uint64{})
+	}
+	counter.(*atomic.Uint64).Add(1)
+}
+
+// Counts returns the number of requests each rule has decided on, by rule id, counted since the
+// firewall was made and across reloads. The requests decided on by the default verdict are counted
+// under the empty id.
+func (firewall *Firewall) Counts() map[string]uint64 {
+	counts := make(map[string]uint64)
+	firewall.counters.Range(func(key, value any) bool {
+		counts[key.(string)] = value.(*atomic.Uint64).Load()
+		return true
+	})
+
+	return counts
+}
+
+func (firewall *Firewall) respond(verdict firewall_verdict.Verdict) *muxTypesResponseError.ResponseError {
+	if verdict != firewall_verdict.Reject {
+		return nil
+	}
+
+	return &muxTypesResponseError.ResponseError{
+		ProblemDetail: problem_detail.New(firewall.config.RejectStatusCode),
+	}
+}
+
+func (firewall *Firewall) Parse(request *http.Request) (firewall_verdict.Verdict, *muxTypesResponseError.ResponseError) {
+	if request == nil {
+		return firewall_verdict.Accept, &muxTypesResponseError.ResponseError{
+			ServerError: motmedelErrors.NewWithTrace(nil_error.New("request")),
+		}
+	}
+
+	ruleSet := firewall.ruleSet.Load()
+	if ruleSet == nil {
+		return firewall_verdict.Accept, nil
+	}
+
+	info := &requestInfo{request: request, config: firewall.config}
+
+	for _, rule := range ruleSet.rules {
+		if !rule.matches(info) {
+			continue
+		}
+
+		firewall.count(rule.rule.Id)
+
+		ctx := request.Context()
+		if httpContext, ok := ctx.Value(muxContext.HttpContextContextKey).(*motmedelHttpTypes.HttpContext); ok && httpContext != nil {
+			httpContext.Rule = rule.schemaRule
+			httpContext.Reason = rule.rule.Reason
+
+			// NOTE: A dropped request gets no response, and is therefore not logged as served.
+			if rule.rule.Verdict == firewall_verdict.Drop {
+				slog.InfoContext(
+					motmedelHttpContext.WithHttpContextValue(ctx, httpContext),
+					"A request was dropped by the firewall.",
+				)
+			}
+		}
+
+		return rule.rule.Verdict, firewall.respond(rule.rule.Verdict)
+	}
+
+	firewall.count("")
+
+	return ruleSet.defaultVerdict, firewall.respond(ruleSet.defaultVerdict)
+}
+
+// Load replaces the rule set. A rule set that is malformed is not loaded.
+func (firewall *Firewall) Load(ruleSet *RuleSet) error {
+	compiled, err := compile(ruleSet)
+	if err != nil {
+		return motmedelErrors.New(fmt.Errorf("compile: %w", err), ruleSet)
+	}
+
+	firewall.ruleSet.Store(compiled)
+
+	return nil
+}
+
+// LoadFile replaces the rule set by the one in the file, decoded according to its extension.
+func (firewall *Firewall) LoadFile(path string) error {
+	extension := strings.ToLower(filepath.Ext(path))
+	unmarshal := firewall.config.Unmarshals[extension]
+	if unmarshal == nil {
+		return motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: %s", muxErrors.ErrUnsupportedFileExtension, extension),
+			path,
+		)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("os read file: %w", err), path)
+	}
+
+	var ruleSet RuleSet
+	if err := unmarshal(data, &ruleSet); err != nil {
+		return motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: unmarshal: %w", muxErrors.ErrMalformedFirewallRuleSet, err),
+			path,
+		)
+	}
+
+	if err := firewall.Load(&ruleSet); err != nil {
+		return motmedelErrors.New(fmt.Errorf("load: %w", err), path)
+	}
+
+	return nil
+}
+
+// Watch reloads the rule set from the file whenever its modification time or size changes, polling
+// at the interval, until the context is done. A rule set that fails to load is logged and the one
+// in use kept.
+func (firewall *Firewall) Watch(ctx context.Context, path string, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	var modTime time.Time
+	var size int64
+	if fileInfo, err := os.Stat(path); err == nil {
+		modTime, size = fileInfo.ModTime(), fileInfo.Size()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			slog.WarnContext(
+				motmedelContext.WithError(ctx, motmedelErrors.NewWithTrace(fmt.Errorf("os stat: %w", err), path)),
+				"An error occurred when checking the firewall rule set file.",
+			)
+			continue
+		}
+		if fileInfo.ModTime().Equal(modTime) && fileInfo.Size() == size {
+			continue
+		}
+		modTime, size = fileInfo.ModTime(), fileInfo.Size()
+
+		if err := firewall.LoadFile(path); err != nil {
+			slog.ErrorContext(
+				motmedelContext.WithError(ctx, fmt.Errorf("load file: %w", err)),
+				"An error occurred when reloading the firewall rule set; the previous one is kept.",
+			)
+			continue
+		}
+
+		slog.InfoContext(ctx, fmt.Sprintf("The firewall rule set was reloaded from %s.", path))
+	}
+}
+
+func New(ruleSet *RuleSet, options ...firewall_config.Option) (*Firewall, error) {
+	firewall := &Firewall{config: firewall_config.New(options...)}
+	if ruleSet != nil {
+		if err := firewall.Load(ruleSet); err != nil {
+			return nil, motmedelErrors.New(fmt.Errorf("load: %w", err))
+		}
+	}
+
+	return firewall, nil
+}
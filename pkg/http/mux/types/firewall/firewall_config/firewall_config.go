@@ -0,0 +1,74 @@
+package firewall_config
+
+import (
+	"context"
+	"encoding/json/v2"
+	"net/http"
+	"net/netip"
+)
+
+var (
+	DefaultRejectStatusCode = http.StatusForbidden
+)
+
+// CountryLookup returns the ISO 3166-1 alpha-2 code of the country an address is located in, or
+// an empty string if it is not known.
+type CountryLookup func(ctx context.Context, address netip.Addr) (string, error)
+
+// Unmarshal decodes a rule set document, such as json.Unmarshal does for JSON.
+type Unmarshal func(data []byte, value any) error
+
+type Config struct {
+	CountryLookup CountryLookup
+	// TrustedProxies are the proxies whose account of the client, in the Forwarded header, is
+	// believed. The client address is the last one in the Forwarded chain that is not a trusted
+	// proxy, provided that the request arrived from one.
+	TrustedProxies []netip.Prefix
+	// Unmarshals maps the extension of a rule set file, such as ".json", to how it is decoded. The
+	// module has no YAML decoder of its own; one is plugged in with WithUnmarshal(".yaml", ...).
+	Unmarshals       map[string]Unmarshal
+	RejectStatusCode int
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		Unmarshals: map[string]Unmarshal{
+			".json": func(data []byte, value any) error { return json.Unmarshal(data, value) },
+		},
+		RejectStatusCode: DefaultRejectStatusCode,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	return config
+}
+
+func WithCountryLookup(countryLookup CountryLookup) Option {
+	return func(config *Config) {
+		config.CountryLookup = countryLookup
+	}
+}
+
+func WithTrustedProxies(trustedProxies ...netip.Prefix) Option {
+	return func(config *Config) {
+		config.TrustedProxies = trustedProxies
+	}
+}
+
+func WithUnmarshal(extension string, unmarshal Unmarshal) Option {
+	return func(config *Config) {
+		if config.Unmarshals == nil {
+			config.Unmarshals = make(map[string]Unmarshal)
+		}
+		config.Unmarshals[extension] = unmarshal
+	}
+}
+
+func WithRejectStatusCode(statusCode int) Option {
+	return func(config *Config) {
+		config.RejectStatusCode = statusCode
+	}
+}
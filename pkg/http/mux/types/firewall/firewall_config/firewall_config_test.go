@@ -0,0 +1,49 @@
+package firewall_config
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.RejectStatusCode != DefaultRejectStatusCode {
+		t.Errorf("reject status code: got %d", config.RejectStatusCode)
+	}
+	if config.Unmarshals[".json"] == nil {
+		t.Error("expected a default JSON unmarshal")
+	}
+	if config.CountryLookup != nil || config.TrustedProxies != nil {
+		t.Errorf("expected no country lookup or trusted proxies, got %#v", config)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	prefix := netip.MustParsePrefix("10.0.0.0/8")
+
+	config := New(
+		WithCountryLookup(func(context.Context, netip.Addr) (string, error) { return "SE", nil }),
+		WithTrustedProxies(prefix),
+		WithUnmarshal(".yaml", func([]byte, any) error { return nil }),
+		WithRejectStatusCode(http.StatusNotFound),
+	)
+
+	if country, _ := config.CountryLookup(context.Background(), netip.Addr{}); country != "SE" {
+		t.Errorf("country lookup: got %q", country)
+	}
+	if len(config.TrustedProxies) != 1 || config.TrustedProxies[0] != prefix {
+		t.Errorf("trusted proxies: got %v", config.TrustedProxies)
+	}
+	if config.Unmarshals[".yaml"] == nil || config.Unmarshals[".json"] == nil {
+		t.Errorf("expected both the YAML and the JSON unmarshal, got %v", config.Unmarshals)
+	}
+	if config.RejectStatusCode != http.StatusNotFound {
+		t.Errorf("reject status code: got %d", config.RejectStatusCode)
+	}
+}
@@ -0,0 +1,268 @@
+package firewall
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	muxContext "github.com/Motmedel/utils_go/pkg/http/mux/context"
+	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/firewall/firewall_config"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/firewall_verdict"
+	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
+)
+
+func TestMatchGlob(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		glob      string
+		value     string
+		separator byte
+		want      bool
+	}{
+		{glob: "/admin", value: "/admin", separator: '/', want: true},
+		{glob: "/admin", value: "/admin/", separator: '/'},
+		{glob: "/admin/*", value: "/admin/users", separator: '/', want: true},
+		{glob: "/admin/*", value: "/admin/users/1", separator: '/'},
+		{glob: "/admin/**", value: "/admin/users/1", separator: '/', want: true},
+		{glob: "/**/.env", value: "/a/b/.env", separator: '/', want: true},
+		{glob: "/*.php", value: "/index.php", separator: '/', want: true},
+		{glob: "/?", value: "/a", separator: '/', want: true},
+		{glob: "/?", value: "//", separator: '/'},
+		{glob: "*curl*", value: "x curl/8.0", want: true},
+		{glob: "", value: "", want: true},
+		{glob: "", value: "a"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.glob+" "+testCase.value, func(t *testing.T) {
+			t.Parallel()
+
+			if got := matchGlob(testCase.glob, testCase.value, testCase.separator); got != testCase.want {
+				t.Errorf("got %t, want %t", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestFirewall_Parse(t *testing.T) {
+	t.Parallel()
+
+	ruleSet := &RuleSet{
+		Name:           "edge",
+		DefaultVerdict: firewall_verdict.Accept,
+		Rules: []*Rule{
+			{Id: "allow-office", Verdict: firewall_verdict.Accept, Ips: []string{"203.0.113.0/25"}},
+			{Id: "admin", Verdict: firewall_verdict.Reject, Reason: "admin", Paths: []string{"/admin/**"}},
+			{Id: "scanner", Verdict: firewall_verdict.Drop, UserAgents: []string{"*sqlmap*"}},
+			{Id: "delete", Verdict: firewall_verdict.Reject, Methods: []string{"delete"}, Paths: []string{"/items/*"}},
+			{Id: "debug", Verdict: firewall_verdict.Reject, Headers: map[string][]string{"x-debug": nil}},
+			{Id: "accept-language", Verdict: firewall_verdict.Reject, Headers: map[string][]string{"Accept-Language": {"xx*"}}},
+			{Id: "bad-hop", Verdict: firewall_verdict.Reject, ForwardedIps: []string{"203.0.113.200"}},
+			{Id: "country", Verdict: firewall_verdict.Reject, Countries: []string{"kp"}},
+		},
+	}
+
+	firewall, err := New(
+		ruleSet,
+		firewall_config.WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8")),
+		firewall_config.WithCountryLookup(
+			func(_ context.Context, address netip.Addr) (string, error) {
+				if address == netip.MustParseAddr("198.51.100.7") {
+					return "KP", nil
+				}
+				return "", errors.New("unknown")
+			},
+		),
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		method      string
+		target      string
+		remoteAddr  string
+		headers     map[string]string
+		wantVerdict firewall_verdict.Verdict
+		wantRule    string
+	}{
+		{name: "default", target: "/", wantVerdict: firewall_verdict.Accept},
+		{name: "path", target: "/admin/users", wantVerdict: firewall_verdict.Reject, wantRule: "admin"},
+		{
+			name:        "allowlisted before path",
+			target:      "/admin/users",
+			remoteAddr:  "203.0.113.10:1234",
+			wantVerdict: firewall_verdict.Accept,
+			wantRule:    "allow-office",
+		},
+		{
+			name:        "client behind trusted proxy",
+			target:      "/admin/users",
+			remoteAddr:  "10.0.0.1:1234",
+			headers:     map[string]string{"Forwarded": `for="[2001:db8::1]:80", for="203.0.113.10:5000", for=10.1.1.1`},
+			wantVerdict: firewall_verdict.Accept,
+			wantRule:    "allow-office",
+		},
+		{
+			name:        "forwarded from untrusted peer",
+			target:      "/admin/users",
+			headers:     map[string]string{"Forwarded": "for=203.0.113.10"},
+			wantVerdict: firewall_verdict.Reject,
+			wantRule:    "admin",
+		},
+		{name: "user agent", target: "/", headers: map[string]string{"User-Agent": "SQLMap/1.0"}, wantVerdict: firewall_verdict.Drop, wantRule: "scanner"},
+		{name: "method and path", method: http.MethodDelete, target: "/items/1", wantVerdict: firewall_verdict.Reject, wantRule: "delete"},
+		{name: "method only", method: http.MethodGet, target: "/items/1", wantVerdict: firewall_verdict.Accept},
+		{name: "header presence", target: "/", headers: map[string]string{"X-Debug": ""}, wantVerdict: firewall_verdict.Reject, wantRule: "debug"},
+		{name: "header glob", target: "/", headers: map[string]string{"Accept-Language": "XX-yy"}, wantVerdict: firewall_verdict.Reject, wantRule: "accept-language"},
+		{name: "forwarded ip", target: "/", headers: map[string]string{"Forwarded": "for=203.0.113.200"}, wantVerdict: firewall_verdict.Reject, wantRule: "bad-hop"},
+		{name: "country", target: "/", remoteAddr: "198.51.100.7:1", wantVerdict: firewall_verdict.Reject, wantRule: "country"},
+		{name: "failed country lookup", target: "/", remoteAddr: "198.51.100.8:1", wantVerdict: firewall_verdict.Accept},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			method := testCase.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			request := httptest.NewRequest(method, testCase.target, nil)
+			if testCase.remoteAddr != "" {
+				request.RemoteAddr = testCase.remoteAddr
+			}
+			for name, value := range testCase.headers {
+				request.Header.Set(name, value)
+			}
+
+			httpContext := &motmedelHttpTypes.HttpContext{}
+			request = request.WithContext(
+				context.WithValue(request.Context(), muxContext.HttpContextContextKey, httpContext),
+			)
+
+			verdict, responseError := firewall.Parse(request)
+			if verdict != testCase.wantVerdict {
+				t.Fatalf("verdict: got %v, want %v", verdict, testCase.wantVerdict)
+			}
+			if (verdict == firewall_verdict.Reject) != (responseError != nil) {
+				t.Fatalf("unexpected response error: %v", responseError)
+			}
+			if responseError != nil && responseError.ProblemDetail.Status != http.StatusForbidden {
+				t.Errorf("status: got %d", responseError.ProblemDetail.Status)
+			}
+
+			if testCase.wantRule == "" {
+				if httpContext.Rule != nil {
+					t.Errorf("expected no rule, got %#v", httpContext.Rule)
+				}
+				return
+			}
+			if httpContext.Rule == nil || httpContext.Rule.Id != testCase.wantRule || httpContext.Rule.Ruleset != "edge" {
+				t.Fatalf("rule: got %#v", httpContext.Rule)
+			}
+			if testCase.wantRule == "admin" && httpContext.Reason != "admin" {
+				t.Errorf("reason: got %q", httpContext.Reason)
+			}
+		})
+	}
+}
+
+func TestFirewall_Counts(t *testing.T) {
+	t.Parallel()
+
+	firewall, err := New(&RuleSet{Rules: []*Rule{{Id: "a", Paths: []string{"/a"}}}})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	for _, target := range []string{"/a", "/a", "/b"} {
+		firewall.Parse(httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	if err := firewall.Load(&RuleSet{Rules: []*Rule{{Id: "a", Paths: []string{"/b"}}}}); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	firewall.Parse(httptest.NewRequest(http.MethodGet, "/b", nil))
+
+	counts := firewall.Counts()
+	if counts["a"] != 3 || counts[""] != 1 {
+		t.Errorf("counts: got %v", counts)
+	}
+}
+
+func TestFirewall_Load_Malformed(t *testing.T) {
+	t.Parallel()
+
+	firewall, err := New(&RuleSet{DefaultVerdict: firewall_verdict.Reject})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	for _, ruleSet := range []*RuleSet{
+		{Rules: []*Rule{{}}},
+		{Rules: []*Rule{{Id: "a"}, {Id: "a"}}},
+		{Rules: []*Rule{{Id: "a", Ips: []string{"not-an-ip"}}}},
+		{Rules: []*Rule{{Id: "a", ForwardedIps: []string{"10.0.0.0/99"}}}},
+	} {
+		if err := firewall.Load(ruleSet); !errors.Is(err, muxErrors.ErrMalformedFirewallRuleSet) {
+			t.Errorf("expected a malformed rule set error, got %v", err)
+		}
+	}
+
+	if verdict, _ := firewall.Parse(httptest.NewRequest(http.MethodGet, "/", nil)); verdict != firewall_verdict.Reject {
+		t.Errorf("expected the previous rule set to be kept, got %v", verdict)
+	}
+}
+
+func TestFirewall_LoadFile(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+
+	path := filepath.Join(directory, "rules.json")
+	data := []byte(`{"name":"file","default_verdict":"reject","rules":[{"id":"ok","verdict":"accept","paths":["/ok"]}]}`)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("os write file: %v", err)
+	}
+
+	firewall, err := New(nil, firewall_config.WithRejectStatusCode(http.StatusNotFound))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := firewall.LoadFile(path); err != nil {
+		t.Fatalf("load file: %v", err)
+	}
+
+	if verdict, _ := firewall.Parse(httptest.NewRequest(http.MethodGet, "/ok", nil)); verdict != firewall_verdict.Accept {
+		t.Errorf("expected the rule to accept, got %v", verdict)
+	}
+	verdict, responseError := firewall.Parse(httptest.NewRequest(http.MethodGet, "/other", nil))
+	if verdict != firewall_verdict.Reject || responseError == nil || responseError.ProblemDetail.Status != http.StatusNotFound {
+		t.Errorf("expected a rejection with the configured status, got %v %v", verdict, responseError)
+	}
+
+	yamlPath := filepath.Join(directory, "rules.yaml")
+	if err := os.WriteFile(yamlPath, data, 0600); err != nil {
+		t.Fatalf("os write file: %v", err)
+	}
+	if err := firewall.LoadFile(yamlPath); !errors.Is(err, muxErrors.ErrUnsupportedFileExtension) {
+		t.Errorf("expected an unsupported file extension error, got %v", err)
+	}
+
+	malformedPath := filepath.Join(directory, "malformed.json")
+	if err := os.WriteFile(malformedPath, []byte(`{"rules":[{"id":"x","verdict":"maybe"}]}`), 0600); err != nil {
+		t.Fatalf("os write file: %v", err)
+	}
+	if err := firewall.LoadFile(malformedPath); !errors.Is(err, muxErrors.ErrMalformedFirewallRuleSet) {
+		t.Errorf("expected a malformed rule set error, got %v", err)
+	}
+}
@@ -1,5 +1,12 @@
 package firewall_verdict
 
+import (
+	"fmt"
+	"strings"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+)
+
 type Verdict int
 
 const (
@@ -7,3 +14,46 @@ const (
 	Drop
 	Reject
 )
+
+func (verdict Verdict) String() string {
+	switch verdict {
+	case Accept:
+		return "accept"
+	case Drop:
+		return "drop"
+	case Reject:
+		return "reject"
+	default:
+		return fmt.Sprintf("Verdict(%d)", int(verdict))
+	}
+}
+
+func (verdict Verdict) MarshalText() ([]byte, error) {
+	switch verdict {
+	case Accept, Drop, Reject:
+		return []byte(verdict.String()), nil
+	default:
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: unknown verdict", motmedelErrors.ErrSemanticError),
+			int(verdict),
+		)
+	}
+}
+
+func (verdict *Verdict) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "accept":
+		*verdict = Accept
+	case "drop":
+		*verdict = Drop
+	case "reject":
+		*verdict = Reject
+	default:
+		return motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: unknown verdict", motmedelErrors.ErrSemanticError),
+			string(text),
+		)
+	}
+
+	return nil
+}
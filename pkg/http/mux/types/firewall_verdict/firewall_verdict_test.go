@@ -43,3 +43,33 @@ func TestVerdictConstantsAreDistinct(t *testing.T) {
 			int(Accept), int(Drop), int(Reject))
 	}
 }
+
+func TestVerdictText(t *testing.T) {
+	t.Parallel()
+
+	for _, verdict := range []Verdict{Accept, Drop, Reject} {
+		text, err := verdict.MarshalText()
+		if err != nil {
+			t.Fatalf("marshal text %d: %v", int(verdict), err)
+		}
+
+		var unmarshaled Verdict
+		if err := unmarshaled.UnmarshalText(text); err != nil {
+			t.Fatalf("unmarshal text %q: %v", text, err)
+		}
+		if unmarshaled != verdict {
+			t.Errorf("round trip of %q: got %d, want %d", text, int(unmarshaled), int(verdict))
+		}
+	}
+
+	var verdict Verdict
+	if err := verdict.UnmarshalText([]byte("REJECT")); err != nil || verdict != Reject {
+		t.Errorf("expected a case-insensitive match, got %d (%v)", int(verdict), err)
+	}
+	if err := verdict.UnmarshalText([]byte("allow")); err == nil {
+		t.Error("expected an error for an unknown verdict")
+	}
+	if _, err := Verdict(7).MarshalText(); err == nil {
+		t.Error("expected an error for an unknown verdict")
+	}
+}
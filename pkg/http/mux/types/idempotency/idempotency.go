@@ -0,0 +1,261 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	motmedelContext "github.com/Motmedel/utils_go/pkg/context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	muxContext "github.com/Motmedel/utils_go/pkg/http/mux/context"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/idempotency/idempotency_config"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/idempotency/store"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/middleware"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	muxResponseWriter "github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+	muxUtils "github.com/Motmedel/utils_go/pkg/http/mux/utils"
+	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail/problem_detail_config"
+	"github.com/Motmedel/utils_go/pkg/utils"
+)
+
+const ReplayedHeaderName = "Idempotent-Replayed"
+
+// ErrNoRequestBody is the error of a request with a key whose body has not been obtained by the mux
+// -- as when the middleware is used outside of it, or the body is of unknown length -- and that
+// cannot be told apart from another request with the key by its body.
+var ErrNoRequestBody = errors.New("the request body has not been obtained")
+
+// Keyer is implemented by what an endpoint's header parser produces if it provides the idempotency
+// key of a request itself, rather than the key being read from the configured header.
+type Keyer interface {
+	GetIdempotencyKey() string
+}
+
+// Idempotency makes the unsafe requests to the endpoints whose middleware it is part of idempotent
+// by key (draft-ietf-httpapi-idempotency-key-header).
+//
+// The first request made with a key by a principal -- the authenticated user, if any -- is handled
+// and its response stored; a later request with the same key and principal is answered with the
+// stored response, marked with the Idempotent-Replayed header. A request with the key while the
+// first is being handled is answered with 409, and one for another endpoint or with another body
+// with 422.
+//
+// A key is released, so that the request can be retried, if the handler produces a response error,
+// a server error response or a streamed response, which cannot be stored. A request with a key whose
+// body has not been obtained is refused with a server error.
+//
+// A key of a request without a principal is refused with 400 unless anonymous requests are allowed,
+// in which case they share one: an anonymous client may then be answered with the response stored
+// for another's request with the same key and body. The Set-Cookie headers of a response are never
+// stored, so that they are not replayed.
+type Idempotency struct {
+	config *idempotency_config.Config
+}
+
+func getKey(request *http.Request, headerName string) string {
+	if keyer, ok := request.Context().Value(muxUtils.ParsedRequestHeaderContextKey).(Keyer); ok && !utils.IsNil(keyer) {
+		return keyer.GetIdempotencyKey()
+	}
+
+	key := strings.TrimSpace(request.Header.Get(headerName))
+	// The key is a structured field string, but is commonly sent unquoted.
+	if len(key) >= 2 && strings.HasPrefix(key, `"`) && strings.HasSuffix(key, `"`) {
+		key = key[1 : len(key)-1]
+	}
+
+	return key
+}
+
+func isValidKey(key string, maxLength int) bool {
+	if key == "" || (maxLength > 0 && len(key) > maxLength) {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getPrincipal returns the principal a key is scoped to, which is empty for an anonymous request.
+func getPrincipal(httpContext *motmedelHttpTypes.HttpContext) string {
+	if httpContext == nil || httpContext.User == nil {
+		return ""
+	}
+
+	user := httpContext.User
+	for _, principal := range []string{user.Id, user.Email, user.Name} {
+		if principal != "" {
+			return principal
+		}
+	}
+
+	return ""
+}
+
+// storedHeaders returns the headers of a response that are stored, and replayed: all but Set-Cookie,
+// which would hand a session of one client to another.
+func storedHeaders(headers []*muxResponse.HeaderEntry) []*muxResponse.HeaderEntry {
+	var stored []*muxResponse.HeaderEntry
+	for _, header := range headers {
+		if header != nil && !strings.EqualFold(header.Name, "Set-Cookie") {
+			stored = append(stored, header)
+		}
+	}
+
+	return stored
+}
+
+func makeFingerprint(request *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(request.Method + "\n"))
+	if requestUrl := request.URL; requestUrl != nil {
+		hash.Write([]byte(requestUrl.Path))
+	}
+	hash.Write([]byte("\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (idempotency *Idempotency) release(ctx context.Context, key string) {
+	if err := idempotency.config.Store.Release(ctx, key); err != nil {
+		slog.WarnContext(
+			motmedelContext.WithError(ctx, motmedelErrors.New(fmt.Errorf("store release: %w", err), key)),
+			"An error occurred when releasing an idempotency key.",
+		)
+	}
+}
+
+func makeClientError(statusCode int, detail string) *muxResponseError.ResponseError {
+	return &muxResponseError.ResponseError{
+		ProblemDetail: problem_detail.New(statusCode, problem_detail_config.WithDetail(detail)),
+	}
+}
+
+// Middleware is a handler middleware that makes the requests to the handler it wraps idempotent.
+func (idempotency *Idempotency) Middleware(next middleware.Handler) middleware.Handler {
+	return func(
+		request *http.Request,
+		responseWriter *muxResponseWriter.ResponseWriter,
+	) (*muxResponse.Response, *muxResponseError.ResponseError) {
+		config := idempotency.config
+		if request == nil || !slices.Contains(config.Methods, request.Method) {
+			return next(request, responseWriter)
+		}
+
+		key := getKey(request, config.HeaderName)
+		if key == "" {
+			if config.Required {
+				return nil, makeClientError(http.StatusBadRequest, fmt.Sprintf("A %s header is expected.", config.HeaderName))
+			}
+			return next(request, responseWriter)
+		}
+		if !isValidKey(key, config.MaxKeyLength) {
+			return nil, makeClientError(http.StatusBadRequest, fmt.Sprintf("Malformed %s header.", config.HeaderName))
+		}
+
+		ctx := request.Context()
+		httpContext, _ := ctx.Value(muxContext.HttpContextContextKey).(*motmedelHttpTypes.HttpContext)
+
+		if httpContext == nil || (httpContext.RequestBody == nil && request.ContentLength != 0) {
+			return nil, &muxResponseError.ResponseError{ServerError: motmedelErrors.NewWithTrace(ErrNoRequestBody)}
+		}
+		body := httpContext.RequestBody
+
+		principal := getPrincipal(httpContext)
+		if principal == "" && !config.AllowAnonymous {
+			return nil, makeClientError(
+				http.StatusBadRequest,
+				fmt.Sprintf("A %s header is not accepted from an anonymous client.", config.HeaderName),
+			)
+		}
+
+		storeKey := config.KeyPrefix + principal + "\n" + key
+		fingerprint := makeFingerprint(request, body)
+
+		existing, err := config.Store.Reserve(
+			ctx,
+			storeKey,
+			&store.Record{Fingerprint: fingerprint, Created: config.Now()},
+			config.Ttl,
+		)
+		if err != nil {
+			return nil, &muxResponseError.ResponseError{
+				ServerError: motmedelErrors.New(fmt.Errorf("store reserve: %w", err), storeKey),
+			}
+		}
+		if existing != nil {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				return nil, makeClientError(
+					http.StatusUnprocessableEntity,
+					fmt.Sprintf("The %s was used with another request.", config.HeaderName),
+				)
+			case !existing.Completed:
+				return nil, makeClientError(
+					http.StatusConflict,
+					fmt.Sprintf("A request with the %s is being processed.", config.HeaderName),
+				)
+			}
+
+			return &muxResponse.Response{
+				StatusCode: existing.StatusCode,
+				Headers: append(
+					storedHeaders(existing.Headers),
+					&muxResponse.HeaderEntry{Name: ReplayedHeaderName, Value: "true"},
+				),
+				Body: existing.Body,
+			}, nil
+		}
+
+		// The key is released however the handler ends, a panic included, unless its response is stored.
+		storeCtx := context.WithoutCancel(ctx)
+		completed := false
+		defer func() {
+			if !completed {
+				idempotency.release(storeCtx, storeKey)
+			}
+		}()
+
+		response, responseError := next(request, responseWriter)
+		if responseError != nil || response == nil || response.BodyStreamer != nil || response.StatusCode >= 500 {
+			return response, responseError
+		}
+
+		record := &store.Record{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			StatusCode:  response.StatusCode,
+			Headers:     storedHeaders(response.Headers),
+			Body:        response.Body,
+			Created:     config.Now(),
+		}
+		if err := config.Store.Complete(storeCtx, storeKey, record, config.Ttl); err != nil {
+			slog.WarnContext(
+				motmedelContext.WithError(ctx, motmedelErrors.New(fmt.Errorf("store complete: %w", err), storeKey)),
+				"An error occurred when storing an idempotent response.",
+			)
+			return response, nil
+		}
+		completed = true
+
+		return response, nil
+	}
+}
+
+func New(options ...idempotency_config.Option) *Idempotency {
+	return &Idempotency{config: idempotency_config.New(options...)}
+}
@@ -0,0 +1,112 @@
+package idempotency_config
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/idempotency/store"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/idempotency/store/memory_store"
+)
+
+const (
+	DefaultHeaderName   = "Idempotency-Key"
+	DefaultTtl          = 24 * time.Hour
+	DefaultMaxKeyLength = 255
+)
+
+var DefaultMethods = []string{http.MethodPost, http.MethodPatch}
+
+type Config struct {
+	// Store holds the records of the keys; a new in-memory store is used when none is set.
+	Store store.Store
+	// KeyPrefix is prepended to the keys in the store, so that endpoints or services can share one.
+	KeyPrefix  string
+	HeaderName string
+	// Ttl is for how long a key is remembered.
+	Ttl          time.Duration
+	MaxKeyLength int
+	// Methods are the methods of the requests that keys apply to.
+	Methods []string
+	// Required makes a request without a key a client error rather than one that is not protected.
+	Required bool
+	// AllowAnonymous makes a key of a request without a principal be accepted rather than a client
+	// error; the anonymous requests share the keys of one, and are answered with each other's
+	// responses.
+	AllowAnonymous bool
+	Now            func() time.Time
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		HeaderName:   DefaultHeaderName,
+		Ttl:          DefaultTtl,
+		MaxKeyLength: DefaultMaxKeyLength,
+		Methods:      DefaultMethods,
+		Now:          time.Now,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	if config.Store == nil {
+		config.Store = memory_store.New()
+	}
+
+	return config
+}
+
+func WithStore(store store.Store) Option {
+	return func(config *Config) {
+		config.Store = store
+	}
+}
+
+func WithKeyPrefix(keyPrefix string) Option {
+	return func(config *Config) {
+		config.KeyPrefix = keyPrefix
+	}
+}
+
+func WithHeaderName(headerName string) Option {
+	return func(config *Config) {
+		config.HeaderName = headerName
+	}
+}
+
+func WithTtl(ttl time.Duration) Option {
+	return func(config *Config) {
+		config.Ttl = ttl
+	}
+}
+
+func WithMaxKeyLength(maxKeyLength int) Option {
+	return func(config *Config) {
+		config.MaxKeyLength = maxKeyLength
+	}
+}
+
+func WithMethods(methods ...string) Option {
+	return func(config *Config) {
+		config.Methods = methods
+	}
+}
+
+func WithRequired(required bool) Option {
+	return func(config *Config) {
+		config.Required = required
+	}
+}
+
+func WithAllowAnonymous(allowAnonymous bool) Option {
+	return func(config *Config) {
+		config.AllowAnonymous = allowAnonymous
+	}
+}
+
+func WithNow(now func() time.Time) Option {
+	return func(config *Config) {
+		config.Now = now
+	}
+}
@@ -0,0 +1,87 @@
+package idempotency_config
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/idempotency/store/memory_store"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.HeaderName != DefaultHeaderName {
+		t.Errorf("header name: got %q", config.HeaderName)
+	}
+	if config.Ttl != DefaultTtl {
+		t.Errorf("ttl: got %v", config.Ttl)
+	}
+	if config.MaxKeyLength != DefaultMaxKeyLength {
+		t.Errorf("max key length: got %d", config.MaxKeyLength)
+	}
+	if !slices.Equal(config.Methods, DefaultMethods) {
+		t.Errorf("methods: got %v", config.Methods)
+	}
+	if config.Required {
+		t.Error("expected a key not to be required by default")
+	}
+	if config.AllowAnonymous {
+		t.Error("expected the keys of anonymous requests not to be accepted by default")
+	}
+	if config.Now == nil {
+		t.Error("expected a default now function")
+	}
+	if _, ok := config.Store.(*memory_store.Store); !ok {
+		t.Errorf("expected a default memory store, got %T", config.Store)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	store := memory_store.New()
+	now := time.Unix(1, 0)
+
+	config := New(
+		WithStore(store),
+		WithKeyPrefix("orders:"),
+		WithHeaderName("X-Request-Id"),
+		WithTtl(time.Hour),
+		WithMaxKeyLength(10),
+		WithMethods(http.MethodPut),
+		WithRequired(true),
+		WithAllowAnonymous(true),
+		WithNow(func() time.Time { return now }),
+	)
+
+	if config.Store != store {
+		t.Errorf("store: got %v", config.Store)
+	}
+	if config.KeyPrefix != "orders:" {
+		t.Errorf("key prefix: got %q", config.KeyPrefix)
+	}
+	if config.HeaderName != "X-Request-Id" {
+		t.Errorf("header name: got %q", config.HeaderName)
+	}
+	if config.Ttl != time.Hour {
+		t.Errorf("ttl: got %v", config.Ttl)
+	}
+	if config.MaxKeyLength != 10 {
+		t.Errorf("max key length: got %d", config.MaxKeyLength)
+	}
+	if !slices.Equal(config.Methods, []string{http.MethodPut}) {
+		t.Errorf("methods: got %v", config.Methods)
+	}
+	if !config.Required {
+		t.Error("expected a key to be required")
+	}
+	if !config.AllowAnonymous {
+		t.Error("expected the keys of anonymous requests to be accepted")
+	}
+	if !config.Now().Equal(now) {
+		t.Errorf("now: got %v", config.Now())
+	}
+}
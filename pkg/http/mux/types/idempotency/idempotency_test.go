@@ -0,0 +1,402 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	muxContext "github.com/Motmedel/utils_go/pkg/http/mux/context"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/idempotency/idempotency_config"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/middleware"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	muxResponseWriter "github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+	muxUtils "github.com/Motmedel/utils_go/pkg/http/mux/utils"
+	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
+	"github.com/Motmedel/utils_go/pkg/schema"
+)
+
+type parsedHeader struct {
+	key string
+}
+
+func (header *parsedHeader) GetIdempotencyKey() string {
+	return header.key
+}
+
+// makeHandler returns a handler that responds with its number of calls, and a cookie.
+func makeHandler(calls *atomic.Int64, statusCode int) middleware.Handler {
+	return func(*http.Request, *muxResponseWriter.ResponseWriter) (*muxResponse.Response, *muxResponseError.ResponseError) {
+		return &muxResponse.Response{
+			StatusCode: statusCode,
+			Headers: []*muxResponse.HeaderEntry{
+				{Name: "Content-Type", Value: "text/plain"},
+				{Name: "Set-Cookie", Value: "session=x"},
+			},
+			Body: []byte(strconv.FormatInt(calls.Add(1), 10)),
+		}, nil
+	}
+}
+
+type requestInput struct {
+	method    string
+	path      string
+	key       string
+	body      string
+	user      string
+	anonymous bool
+}
+
+func makeRequest(input requestInput) *http.Request {
+	method := input.method
+	if method == "" {
+		method = http.MethodPost
+	}
+	path := input.path
+	if path == "" {
+		path = "/orders"
+	}
+
+	request := httptest.NewRequest(method, path, strings.NewReader(input.body))
+	if input.key != "" {
+		request.Header.Set(idempotency_config.DefaultHeaderName, input.key)
+	}
+
+	user := input.user
+	if user == "" {
+		user = "alice"
+	}
+
+	httpContext := &motmedelHttpTypes.HttpContext{RequestBody: []byte(input.body)}
+	if !input.anonymous {
+		httpContext.User = &schema.User{Id: user}
+	}
+
+	return request.WithContext(context.WithValue(request.Context(), muxContext.HttpContextContextKey, httpContext))
+}
+
+func getStatusCode(response *muxResponse.Response, responseError *muxResponseError.ResponseError) int {
+	if responseError != nil {
+		if responseError.ProblemDetail != nil {
+			return responseError.ProblemDetail.Status
+		}
+		return http.StatusInternalServerError
+	}
+	return response.StatusCode
+}
+
+func TestIdempotency_Middleware(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		options        []idempotency_config.Option
+		handlerStatus  int
+		first          requestInput
+		second         requestInput
+		wantFirst      int
+		wantSecond     int
+		wantCalls      int64
+		wantReplayed   bool
+		wantSecondBody string
+	}{
+		{
+			name:           "replay",
+			first:          requestInput{key: "a", body: "x"},
+			second:         requestInput{key: "a", body: "x"},
+			wantFirst:      http.StatusCreated,
+			wantSecond:     http.StatusCreated,
+			wantCalls:      1,
+			wantReplayed:   true,
+			wantSecondBody: "1",
+		},
+		{
+			name:           "quoted key",
+			first:          requestInput{key: `"a"`, body: "x"},
+			second:         requestInput{key: "a", body: "x"},
+			wantFirst:      http.StatusCreated,
+			wantSecond:     http.StatusCreated,
+			wantCalls:      1,
+			wantReplayed:   true,
+			wantSecondBody: "1",
+		},
+		{
+			name:           "other key",
+			first:          requestInput{key: "a", body: "x"},
+			second:         requestInput{key: "b", body: "x"},
+			wantFirst:      http.StatusCreated,
+			wantSecond:     http.StatusCreated,
+			wantCalls:      2,
+			wantSecondBody: "2",
+		},
+		{
+			name:           "other principal",
+			first:          requestInput{key: "a", body: "x", user: "alice"},
+			second:         requestInput{key: "a", body: "x", user: "bob"},
+			wantFirst:      http.StatusCreated,
+			wantSecond:     http.StatusCreated,
+			wantCalls:      2,
+			wantSecondBody: "2",
+		},
+		{
+			name:       "anonymous",
+			first:      requestInput{key: "a", body: "x", anonymous: true},
+			second:     requestInput{key: "a", body: "x", anonymous: true},
+			wantFirst:  http.StatusBadRequest,
+			wantSecond: http.StatusBadRequest,
+		},
+		{
+			name:           "anonymous allowed",
+			options:        []idempotency_config.Option{idempotency_config.WithAllowAnonymous(true)},
+			first:          requestInput{key: "a", body: "x", anonymous: true},
+			second:         requestInput{key: "a", body: "x", anonymous: true},
+			wantFirst:      http.StatusCreated,
+			wantSecond:     http.StatusCreated,
+			wantCalls:      1,
+			wantReplayed:   true,
+			wantSecondBody: "1",
+		},
+		{
+			name:           "anonymous without a key",
+			first:          requestInput{body: "x", anonymous: true},
+			second:         requestInput{body: "x", anonymous: true},
+			wantFirst:      http.StatusCreated,
+			wantSecond:     http.StatusCreated,
+			wantCalls:      2,
+			wantSecondBody: "2",
+		},
+		{
+			name:       "other body",
+			first:      requestInput{key: "a", body: "x"},
+			second:     requestInput{key: "a", body: "y"},
+			wantFirst:  http.StatusCreated,
+			wantSecond: http.StatusUnprocessableEntity,
+			wantCalls:  1,
+		},
+		{
+			name:       "other path",
+			first:      requestInput{key: "a", body: "x"},
+			second:     requestInput{key: "a", body: "x", path: "/payments"},
+			wantFirst:  http.StatusCreated,
+			wantSecond: http.StatusUnprocessableEntity,
+			wantCalls:  1,
+		},
+		{
+			name:           "no key",
+			first:          requestInput{body: "x"},
+			second:         requestInput{body: "x"},
+			wantFirst:      http.StatusCreated,
+			wantSecond:     http.StatusCreated,
+			wantCalls:      2,
+			wantSecondBody: "2",
+		},
+		{
+			name:           "required key",
+			options:        []idempotency_config.Option{idempotency_config.WithRequired(true)},
+			first:          requestInput{body: "x"},
+			second:         requestInput{key: "a", body: "x"},
+			wantFirst:      http.StatusBadRequest,
+			wantSecond:     http.StatusCreated,
+			wantCalls:      1,
+			wantSecondBody: "1",
+		},
+		{
+			name:       "malformed key",
+			options:    []idempotency_config.Option{idempotency_config.WithMaxKeyLength(3)},
+			first:      requestInput{key: "a b", body: "x"},
+			second:     requestInput{key: "abcd", body: "x"},
+			wantFirst:  http.StatusBadRequest,
+			wantSecond: http.StatusBadRequest,
+		},
+		{
+			name:           "unprotected method",
+			first:          requestInput{method: http.MethodPut, key: "a", body: "x"},
+			second:         requestInput{method: http.MethodPut, key: "a", body: "x"},
+			wantFirst:      http.StatusCreated,
+			wantSecond:     http.StatusCreated,
+			wantCalls:      2,
+			wantSecondBody: "2",
+		},
+		{
+			name:           "server error released",
+			handlerStatus:  http.StatusServiceUnavailable,
+			first:          requestInput{key: "a", body: "x"},
+			second:         requestInput{key: "a", body: "x"},
+			wantFirst:      http.StatusServiceUnavailable,
+			wantSecond:     http.StatusServiceUnavailable,
+			wantCalls:      2,
+			wantSecondBody: "2",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			handlerStatus := testCase.handlerStatus
+			if handlerStatus == 0 {
+				handlerStatus = http.StatusCreated
+			}
+
+			var calls atomic.Int64
+			handler := New(testCase.options...).Middleware(makeHandler(&calls, handlerStatus))
+
+			if statusCode := getStatusCode(handler(makeRequest(testCase.first), nil)); statusCode != testCase.wantFirst {
+				t.Fatalf("first status code: got %d, want %d", statusCode, testCase.wantFirst)
+			}
+
+			response, responseError := handler(makeRequest(testCase.second), nil)
+			if statusCode := getStatusCode(response, responseError); statusCode != testCase.wantSecond {
+				t.Fatalf("second status code: got %d, want %d", statusCode, testCase.wantSecond)
+			}
+			if calls.Load() != testCase.wantCalls {
+				t.Errorf("calls: got %d, want %d", calls.Load(), testCase.wantCalls)
+			}
+			if response == nil {
+				return
+			}
+
+			if string(response.Body) != testCase.wantSecondBody {
+				t.Errorf("body: got %q, want %q", response.Body, testCase.wantSecondBody)
+			}
+
+			var replayed, hasCookie, hasContentType bool
+			for _, header := range response.Headers {
+				replayed = replayed || header.Name == ReplayedHeaderName
+				hasCookie = hasCookie || header.Name == "Set-Cookie"
+				hasContentType = hasContentType || header.Name == "Content-Type"
+			}
+			if replayed != testCase.wantReplayed {
+				t.Errorf("replayed: got %t, want %t", replayed, testCase.wantReplayed)
+			}
+			if !hasContentType {
+				t.Errorf("expected the content type, got %v", response.Headers)
+			}
+			// A cookie is sent with the response it was made for only, never replayed.
+			if hasCookie == replayed {
+				t.Errorf("cookie: got %t with replayed %t", hasCookie, replayed)
+			}
+		})
+	}
+}
+
+func TestIdempotency_Middleware_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	proceed := make(chan struct{})
+
+	var calls atomic.Int64
+	handler := New().Middleware(
+		func(request *http.Request, responseWriter *muxResponseWriter.ResponseWriter) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			close(started)
+			<-proceed
+			return makeHandler(&calls, http.StatusCreated)(request, responseWriter)
+		},
+	)
+
+	done := make(chan int)
+	go func() {
+		done <- getStatusCode(handler(makeRequest(requestInput{key: "a", body: "x"}), nil))
+	}()
+
+	<-started
+	if statusCode := getStatusCode(handler(makeRequest(requestInput{key: "a", body: "x"}), nil)); statusCode != http.StatusConflict {
+		t.Errorf("concurrent status code: got %d, want %d", statusCode, http.StatusConflict)
+	}
+	close(proceed)
+
+	if statusCode := <-done; statusCode != http.StatusCreated {
+		t.Errorf("first status code: got %d", statusCode)
+	}
+	if statusCode := getStatusCode(handler(makeRequest(requestInput{key: "a", body: "x"}), nil)); statusCode != http.StatusCreated {
+		t.Errorf("replayed status code: got %d", statusCode)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls: got %d", calls.Load())
+	}
+}
+
+func TestIdempotency_Middleware_ResponseErrorReleases(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int64
+	handler := New().Middleware(
+		func(*http.Request, *muxResponseWriter.ResponseWriter) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			calls.Add(1)
+			return nil, &muxResponseError.ResponseError{ServerError: context.Canceled}
+		},
+	)
+
+	for range 2 {
+		if _, responseError := handler(makeRequest(requestInput{key: "a", body: "x"}), nil); responseError == nil || responseError.ServerError == nil {
+			t.Fatalf("expected the server error, got %v", responseError)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls: got %d", calls.Load())
+	}
+}
+
+func TestIdempotency_Middleware_Keyer(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int64
+	handler := New().Middleware(makeHandler(&calls, http.StatusCreated))
+
+	for range 2 {
+		request := makeRequest(requestInput{body: "x"})
+		request = request.WithContext(
+			context.WithValue(request.Context(), muxUtils.ParsedRequestHeaderContextKey, &parsedHeader{key: "parsed"}),
+		)
+		if statusCode := getStatusCode(handler(request, nil)); statusCode != http.StatusCreated {
+			t.Fatalf("status code: got %d", statusCode)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls: got %d", calls.Load())
+	}
+}
+
+func TestIdempotency_Middleware_NoRequestBody(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		httpContext *motmedelHttpTypes.HttpContext
+		body        string
+	}{
+		{name: "no http context", body: "x"},
+		{name: "body not obtained", httpContext: &motmedelHttpTypes.HttpContext{}, body: "x"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int64
+			handler := New().Middleware(makeHandler(&calls, http.StatusCreated))
+
+			request := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(testCase.body))
+			request.Header.Set(idempotency_config.DefaultHeaderName, "a")
+			if testCase.httpContext != nil {
+				request = request.WithContext(
+					context.WithValue(request.Context(), muxContext.HttpContextContextKey, testCase.httpContext),
+				)
+			}
+
+			_, responseError := handler(request, nil)
+			if responseError == nil || !errors.Is(responseError.ServerError, ErrNoRequestBody) {
+				t.Fatalf("expected ErrNoRequestBody, got %v", responseError)
+			}
+			if calls.Load() != 0 {
+				t.Errorf("calls: got %d", calls.Load())
+			}
+		})
+	}
+}
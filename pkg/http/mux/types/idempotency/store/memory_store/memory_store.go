@@ -0,0 +1,85 @@
+package memory_store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/idempotency/store"
+)
+
+// sweepInterval is the number of reservations after which expired records are evicted.
+const sweepInterval = 1024
+
+type element struct {
+	record    *store.Record
+	expiresAt time.Time
+}
+
+// Store is an in-process store. Expired records are evicted when read and periodically when
+// reserving.
+type Store struct {
+	mutex        sync.Mutex
+	elements     map[string]*element
+	reservations int
+}
+
+func (memoryStore *Store) sweep(now time.Time) {
+	for key, storeElement := range memoryStore.elements {
+		if !now.Before(storeElement.expiresAt) {
+			delete(memoryStore.elements, key)
+		}
+	}
+}
+
+func (memoryStore *Store) Reserve(_ context.Context, key string, record *store.Record, ttl time.Duration) (*store.Record, error) {
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	now := time.Now()
+
+	memoryStore.reservations++
+	if memoryStore.reservations%sweepInterval == 0 {
+		memoryStore.sweep(now)
+	}
+
+	if storeElement, ok := memoryStore.elements[key]; ok {
+		if now.Before(storeElement.expiresAt) {
+			return storeElement.record, nil
+		}
+	}
+
+	memoryStore.elements[key] = &element{record: record, expiresAt: now.Add(ttl)}
+
+	return nil, nil
+}
+
+func (memoryStore *Store) Complete(_ context.Context, key string, record *store.Record, ttl time.Duration) error {
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	memoryStore.elements[key] = &element{record: record, expiresAt: time.Now().Add(ttl)}
+
+	return nil
+}
+
+func (memoryStore *Store) Release(_ context.Context, key string) error {
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	delete(memoryStore.elements, key)
+
+	return nil
+}
+
+// Len returns the number of records held, expired ones among them.
+func (memoryStore *Store) Len() int {
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	return len(memoryStore.elements)
+}
+
+func New() *Store {
+	return &Store{elements: make(map[string]*element)}
+}
@@ -0,0 +1,62 @@
+package memory_store
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/idempotency/store"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	memoryStore := New()
+	ctx := t.Context()
+
+	first := &store.Record{Fingerprint: "a"}
+	if existing, err := memoryStore.Reserve(ctx, "key", first, time.Minute); err != nil || existing != nil {
+		t.Fatalf("reserve: got %v, %v", existing, err)
+	}
+	if existing, _ := memoryStore.Reserve(ctx, "key", &store.Record{Fingerprint: "b"}, time.Minute); existing != first {
+		t.Fatalf("expected the first record, got %v", existing)
+	}
+
+	completed := &store.Record{Fingerprint: "a", Completed: true, StatusCode: 201}
+	if err := memoryStore.Complete(ctx, "key", completed, time.Minute); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if existing, _ := memoryStore.Reserve(ctx, "key", first, time.Minute); existing != completed {
+		t.Fatalf("expected the completed record, got %v", existing)
+	}
+
+	if err := memoryStore.Release(ctx, "key"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if memoryStore.Len() != 0 {
+		t.Errorf("len: got %d", memoryStore.Len())
+	}
+	if existing, _ := memoryStore.Reserve(ctx, "key", first, time.Minute); existing != nil {
+		t.Fatalf("expected the key to be free after release, got %v", existing)
+	}
+}
+
+func TestStore_Expiry(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		memoryStore := New()
+		ctx := t.Context()
+
+		_, _ = memoryStore.Reserve(ctx, "key", &store.Record{Fingerprint: "a"}, time.Minute)
+		time.Sleep(time.Minute)
+
+		second := &store.Record{Fingerprint: "b"}
+		if existing, _ := memoryStore.Reserve(ctx, "key", second, time.Minute); existing != nil {
+			t.Fatalf("expected the expired record to be replaced, got %v", existing)
+		}
+		if existing, _ := memoryStore.Reserve(ctx, "key", &store.Record{}, time.Minute); existing != second {
+			t.Fatalf("expected the second record, got %v", existing)
+		}
+	})
+}
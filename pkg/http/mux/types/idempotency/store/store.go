@@ -0,0 +1,32 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+)
+
+// Record is what is known of the request first made with an idempotency key. It is in progress until
+// it holds the response to the request.
+type Record struct {
+	// Fingerprint identifies the request, which a request reusing the key must match.
+	Fingerprint string
+	Completed   bool
+	StatusCode  int
+	Headers     []*muxResponse.HeaderEntry
+	Body        []byte
+	Created     time.Time
+}
+
+// Store holds the records of idempotency keys. A store that several replicas share makes them share
+// the keys; its Reserve must then be atomic across them.
+type Store interface {
+	// Reserve stores the record at the key unless one is stored there already, in which case that
+	// record is returned instead. The record expires after ttl.
+	Reserve(ctx context.Context, key string, record *Record, ttl time.Duration) (*Record, error)
+	// Complete replaces the record at the key with a completed one. The record expires after ttl.
+	Complete(ctx context.Context, key string, record *Record, ttl time.Duration) error
+	// Release deletes the record at the key, so that the key may be used again.
+	Release(ctx context.Context, key string) error
+}
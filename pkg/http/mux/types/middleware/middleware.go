@@ -1,5 +1,38 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
 
+	muxTypesResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxTypesResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	muxTypesResponseWriter "github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+)
+
+// Middleware rewrites a request before it is handled.
 type Middleware func(request *http.Request) *http.Request
+
+// Handler produces the response to a request. It may also write to the response writer itself, as
+// a streaming handler does, in which case the response it returns is not written.
+type Handler func(
+	*http.Request,
+	*muxTypesResponseWriter.ResponseWriter,
+) (*muxTypesResponse.Response, *muxTypesResponseError.ResponseError)
+
+// HandlerMiddleware wraps a handler. The handler it returns may act before and after calling the
+// wrapped handler -- to time it, recover from its panics or amend its response -- or respond in its
+// stead by not calling it at all.
+type HandlerMiddleware func(next Handler) Handler
+
+// Chain wraps the handler in the middleware, the first of which is the outermost.
+func Chain(handler Handler, middleware ...HandlerMiddleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if middleware[i] == nil {
+			continue
+		}
+		if wrappedHandler := middleware[i](handler); wrappedHandler != nil {
+			handler = wrappedHandler
+		}
+	}
+
+	return handler
+}
@@ -4,7 +4,13 @@ import (
 	stdcontext "context"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
+
+	muxTypesResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxTypesResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	muxTypesResponseWriter "github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail"
 )
 
 func TestMiddleware_IdentityPassesRequestThrough(t *testing.T) {
@@ -60,3 +66,72 @@ func TestMiddleware_Composition(t *testing.T) {
 		t.Error("second middleware header missing")
 	}
 }
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	record := func(name string) HandlerMiddleware {
+		return func(next Handler) Handler {
+			return func(
+				request *http.Request,
+				responseWriter *muxTypesResponseWriter.ResponseWriter,
+			) (*muxTypesResponse.Response, *muxTypesResponseError.ResponseError) {
+				calls = append(calls, name+" before")
+				response, responseError := next(request, responseWriter)
+				calls = append(calls, name+" after")
+				return response, responseError
+			}
+		}
+	}
+
+	handler := Chain(
+		func(*http.Request, *muxTypesResponseWriter.ResponseWriter) (*muxTypesResponse.Response, *muxTypesResponseError.ResponseError) {
+			calls = append(calls, "handler")
+			return &muxTypesResponse.Response{Body: []byte("ok")}, nil
+		},
+		record("a"),
+		nil,
+		record("b"),
+	)
+
+	request := httptest.NewRequestWithContext(stdcontext.Background(), http.MethodGet, "/path", nil)
+	response, responseError := handler(request, nil)
+	if responseError != nil {
+		t.Fatalf("unexpected response error: %v", responseError)
+	}
+	if response == nil || string(response.Body) != "ok" {
+		t.Fatalf("unexpected response: %#v", response)
+	}
+
+	want := []string{"a before", "b before", "handler", "b after", "a after"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("got calls %v, want %v", calls, want)
+	}
+}
+
+func TestChain_ShortCircuit(t *testing.T) {
+	t.Parallel()
+
+	var handlerCalled bool
+
+	handler := Chain(
+		func(*http.Request, *muxTypesResponseWriter.ResponseWriter) (*muxTypesResponse.Response, *muxTypesResponseError.ResponseError) {
+			handlerCalled = true
+			return nil, nil
+		},
+		func(Handler) Handler {
+			return func(*http.Request, *muxTypesResponseWriter.ResponseWriter) (*muxTypesResponse.Response, *muxTypesResponseError.ResponseError) {
+				return nil, &muxTypesResponseError.ResponseError{ProblemDetail: problem_detail.New(http.StatusForbidden)}
+			}
+		},
+	)
+
+	request := httptest.NewRequestWithContext(stdcontext.Background(), http.MethodGet, "/path", nil)
+	if _, responseError := handler(request, nil); responseError == nil || responseError.ProblemDetail == nil {
+		t.Fatalf("expected a problem detail, got %#v", responseError)
+	}
+	if handlerCalled {
+		t.Error("expected the handler not to be called")
+	}
+}
@@ -0,0 +1,287 @@
+// Package mux_metrics records the metrics of the requests a mux serves: how many, how long they
+// took, how large their responses were, and what the rate limiting and the firewall made of them.
+package mux_metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	muxContext "github.com/Motmedel/utils_go/pkg/http/mux/context"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	muxTypesFirewall "github.com/Motmedel/utils_go/pkg/http/mux/types/firewall_verdict"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/mux_metrics/mux_metrics_config"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	"github.com/Motmedel/utils_go/pkg/metrics"
+)
+
+// DefaultPath is where the metrics are commonly served, and scraped from by default.
+const DefaultPath = "/metrics"
+
+const (
+	// UnmatchedEndpoint labels a request that matched no endpoint. A registered path begins with a
+	// slash, so it cannot be mistaken for one.
+	UnmatchedEndpoint = "unmatched"
+	// OtherMethod labels a request of a method that is not a standard one, which a client may
+	// otherwise make up as many of as it pleases.
+	OtherMethod = "_OTHER"
+)
+
+var standardMethods = map[string]struct{}{
+	http.MethodGet:     {},
+	http.MethodHead:    {},
+	http.MethodPost:    {},
+	http.MethodPut:     {},
+	http.MethodPatch:   {},
+	http.MethodDelete:  {},
+	http.MethodConnect: {},
+	http.MethodOptions: {},
+	http.MethodTrace:   {},
+}
+
+// Observation is what is recorded of a request while it is handled.
+type Observation struct {
+	host        string
+	method      string
+	start       time.Time
+	endpoint    string
+	rateLimited bool
+}
+
+// SetEndpoint records the path of the endpoint the request matched, as registered rather than as
+// requested, so that the requests of a path with parameters are labelled alike.
+func (observation *Observation) SetEndpoint(path string) {
+	if observation == nil {
+		return
+	}
+
+	observation.endpoint = path
+}
+
+// SetRateLimited records the request having been refused by the rate limiting.
+func (observation *Observation) SetRateLimited() {
+	if observation == nil {
+		return
+	}
+
+	observation.rateLimited = true
+}
+
+// ObservationFromContext returns the observation of the request the context is of; it is nil,
+// which its methods ignore, where the mux records no metrics.
+func ObservationFromContext(ctx context.Context) *Observation {
+	if ctx == nil {
+		return nil
+	}
+
+	observation, _ := ctx.Value(muxContext.MetricsObservationContextKey).(*Observation)
+	return observation
+}
+
+// Metrics are the metrics of the requests a mux serves. Muxes recording in the same registry share
+// one, the registry refusing the same names twice; the muxes of virtual hosts are told apart with
+// WithHost.
+type Metrics struct {
+	Registry *metrics.Registry
+	// Requests are counted by host, method, endpoint and status class.
+	Requests *metrics.Counter
+	// RequestDuration is by host, method, endpoint and status class.
+	RequestDuration *metrics.Histogram
+	// ResponseBodySize is the size of the body as written, after any compression, by host, method
+	// and endpoint.
+	ResponseBodySize *metrics.Histogram
+	// ActiveRequests are the requests being handled, by method.
+	ActiveRequests *metrics.Gauge
+	// RateLimitRejections are counted by host, method and endpoint.
+	RateLimitRejections *metrics.Counter
+	// FirewallVerdicts are counted by verdict.
+	FirewallVerdicts *metrics.Counter
+
+	// host labels the requests the metrics record; it is empty but for those of a virtual host.
+	host string
+	now  func() time.Time
+}
+
+// WithHost returns metrics that record in the same series, with the requests labelled by the host,
+// such as the pattern of the virtual host whose mux records them.
+func (muxMetrics *Metrics) WithHost(host string) *Metrics {
+	if muxMetrics == nil {
+		return nil
+	}
+
+	hostMetrics := *muxMetrics
+	hostMetrics.host = host
+
+	return &hostMetrics
+}
+
+func normalizeMethod(method string) string {
+	if _, ok := standardMethods[method]; ok {
+		return method
+	}
+
+	return OtherMethod
+}
+
+// Start begins the observation of a request, which is counted as active until it is finished.
+func (muxMetrics *Metrics) Start(request *http.Request) *Observation {
+	if muxMetrics == nil || request == nil {
+		return nil
+	}
+
+	method := normalizeMethod(request.Method)
+	muxMetrics.ActiveRequests.With(method).Inc()
+
+	return &Observation{host: muxMetrics.host, method: method, start: muxMetrics.now(), endpoint: UnmatchedEndpoint}
+}
+
+// Finish ends the observation of a request, recording what it was answered with. A request that
+// was never answered -- its connection dropped by the firewall, or its handler panicking -- is
+// recorded as no longer active only, there being no status to count it by.
+func (muxMetrics *Metrics) Finish(observation *Observation, statusCode int, responseBodySize int64) {
+	if muxMetrics == nil || observation == nil {
+		return
+	}
+
+	method := observation.method
+	muxMetrics.ActiveRequests.With(method).Dec()
+
+	if statusCode == 0 {
+		return
+	}
+
+	host := observation.host
+	endpointPath := observation.endpoint
+	statusClass := strconv.Itoa(statusCode/100) + "xx"
+
+	muxMetrics.Requests.With(host, method, endpointPath, statusClass).Inc()
+	muxMetrics.RequestDuration.With(host, method, endpointPath, statusClass).Observe(
+		muxMetrics.now().Sub(observation.start).Seconds(),
+	)
+	muxMetrics.ResponseBodySize.With(host, method, endpointPath).Observe(float64(responseBodySize))
+	if observation.rateLimited {
+		muxMetrics.RateLimitRejections.With(host, method, endpointPath).Inc()
+	}
+}
+
+func (muxMetrics *Metrics) ObserveFirewallVerdict(verdict muxTypesFirewall.Verdict) {
+	if muxMetrics == nil {
+		return
+	}
+
+	muxMetrics.FirewallVerdicts.With(verdict.String()).Inc()
+}
+
+// Endpoint serves the metrics of the registry at the path, in the OpenMetrics text format.
+func Endpoint(registry *metrics.Registry, path string) *endpoint.Endpoint {
+	return &endpoint.Endpoint{
+		Path:   path,
+		Method: http.MethodGet,
+		Handler: func(_ *http.Request, _ []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			if registry == nil {
+				return nil, &muxResponseError.ResponseError{
+					ServerError: motmedelErrors.NewWithTrace(nil_error.New("metrics registry")),
+				}
+			}
+
+			var body bytes.Buffer
+			if err := registry.WriteOpenMetrics(&body); err != nil {
+				return nil, &muxResponseError.ResponseError{
+					ServerError: fmt.Errorf("registry write open metrics: %w", err),
+				}
+			}
+
+			return &muxResponse.Response{
+				Headers: []*muxResponse.HeaderEntry{
+					{Name: "Content-Type", Value: metrics.OpenMetricsContentType},
+					{Name: "Cache-Control", Value: "no-store"},
+				},
+				Body: body.Bytes(),
+			}, nil
+		},
+		Public: true,
+	}
+}
+
+func New(options ...mux_metrics_config.Option) (*Metrics, error) {
+	config := mux_metrics_config.New(options...)
+
+	if config.Now == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("now"))
+	}
+
+	registry := config.Registry
+
+	requests, err := registry.NewCounter(
+		"http_server_requests_total",
+		"The requests served, by host, method, endpoint and status class.",
+		"host", "method", "endpoint", "status_class",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("registry new counter (requests): %w", err)
+	}
+
+	requestDuration, err := registry.NewHistogram(
+		"http_server_request_duration_seconds",
+		"How long the requests took to serve, by host, method, endpoint and status class.",
+		config.DurationBuckets,
+		"host", "method", "endpoint", "status_class",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("registry new histogram (request duration): %w", err)
+	}
+
+	responseBodySize, err := registry.NewHistogram(
+		"http_server_response_body_size_bytes",
+		"The sizes of the response bodies as written, by host, method and endpoint.",
+		config.ResponseBodySizeBuckets,
+		"host", "method", "endpoint",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("registry new histogram (response body size): %w", err)
+	}
+
+	activeRequests, err := registry.NewGauge(
+		"http_server_active_requests",
+		"The requests being handled, by method.",
+		"method",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("registry new gauge (active requests): %w", err)
+	}
+
+	rateLimitRejections, err := registry.NewCounter(
+		"http_server_rate_limit_rejections_total",
+		"The requests refused by the rate limiting, by host, method and endpoint.",
+		"host", "method", "endpoint",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("registry new counter (rate limit rejections): %w", err)
+	}
+
+	firewallVerdicts, err := registry.NewCounter(
+		"http_server_firewall_verdicts_total",
+		"The verdicts of the firewall, by verdict.",
+		"verdict",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("registry new counter (firewall verdicts): %w", err)
+	}
+
+	return &Metrics{
+		Registry:            registry,
+		Requests:            requests,
+		RequestDuration:     requestDuration,
+		ResponseBodySize:    responseBodySize,
+		ActiveRequests:      activeRequests,
+		RateLimitRejections: rateLimitRejections,
+		FirewallVerdicts:    firewallVerdicts,
+		now:                 config.Now,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package mux_metrics_config
+
+import (
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/metrics"
+)
+
+// DefaultResponseBodySizeBuckets bound the sizes of response bodies in bytes, from a hundred bytes
+// to ten megabytes.
+var DefaultResponseBodySizeBuckets = metrics.ExponentialBuckets(100, 10, 6)
+
+type Config struct {
+	// Registry holds the metrics; a new registry is used when none is set.
+	Registry                *metrics.Registry
+	DurationBuckets         []float64
+	ResponseBodySizeBuckets []float64
+	Now                     func() time.Time
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		DurationBuckets:         metrics.DefaultBuckets,
+		ResponseBodySizeBuckets: DefaultResponseBodySizeBuckets,
+		Now:                     time.Now,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(config)
+		}
+	}
+
+	if config.Registry == nil {
+		config.Registry = metrics.NewRegistry()
+	}
+
+	return config
+}
+
+func WithRegistry(registry *metrics.Registry) Option {
+	return func(config *Config) {
+		config.Registry = registry
+	}
+}
+
+// WithDurationBuckets sets the upper bounds, in seconds, of the buckets request durations are
+// counted in.
+func WithDurationBuckets(buckets ...float64) Option {
+	return func(config *Config) {
+		config.DurationBuckets = buckets
+	}
+}
+
+// WithResponseBodySizeBuckets sets the upper bounds, in bytes, of the buckets response body sizes
+// are counted in.
+func WithResponseBodySizeBuckets(buckets ...float64) Option {
+	return func(config *Config) {
+		config.ResponseBodySizeBuckets = buckets
+	}
+}
+
+func WithNow(now func() time.Time) Option {
+	return func(config *Config) {
+		config.Now = now
+	}
+}
@@ -0,0 +1,54 @@
+package mux_metrics_config
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/metrics"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.Registry == nil {
+		t.Error("expected a default registry")
+	}
+	if !slices.Equal(config.DurationBuckets, metrics.DefaultBuckets) {
+		t.Errorf("duration buckets: got %v", config.DurationBuckets)
+	}
+	if !slices.Equal(config.ResponseBodySizeBuckets, DefaultResponseBodySizeBuckets) {
+		t.Errorf("response body size buckets: got %v", config.ResponseBodySizeBuckets)
+	}
+	if config.Now == nil {
+		t.Error("expected a default now function")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	now := time.Unix(1, 0)
+
+	config := New(
+		WithRegistry(registry),
+		WithDurationBuckets(1, 2),
+		WithResponseBodySizeBuckets(10),
+		WithNow(func() time.Time { return now }),
+	)
+
+	if config.Registry != registry {
+		t.Error("expected the registry")
+	}
+	if !slices.Equal(config.DurationBuckets, []float64{1, 2}) {
+		t.Errorf("duration buckets: got %v", config.DurationBuckets)
+	}
+	if !slices.Equal(config.ResponseBodySizeBuckets, []float64{10}) {
+		t.Errorf("response body size buckets: got %v", config.ResponseBodySizeBuckets)
+	}
+	if !config.Now().Equal(now) {
+		t.Errorf("now: got %v", config.Now())
+	}
+}
@@ -0,0 +1,145 @@
+package mux_metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	muxContext "github.com/Motmedel/utils_go/pkg/http/mux/context"
+	muxTypesFirewall "github.com/Motmedel/utils_go/pkg/http/mux/types/firewall_verdict"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/mux_metrics/mux_metrics_config"
+	"github.com/Motmedel/utils_go/pkg/metrics"
+)
+
+func TestMetrics_Observation(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	muxMetrics, err := New(mux_metrics_config.WithNow(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	request := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/items/1", nil)
+	observation := muxMetrics.Start(request)
+	if got := muxMetrics.ActiveRequests.With(http.MethodPost).Value(); got != 1 {
+		t.Errorf("active requests while handled: got %v", got)
+	}
+
+	ctx := context.WithValue(t.Context(), muxContext.MetricsObservationContextKey, observation)
+	ObservationFromContext(ctx).SetEndpoint("/items/{id}")
+	ObservationFromContext(ctx).SetRateLimited()
+
+	now = now.Add(300 * time.Millisecond)
+	muxMetrics.Finish(observation, http.StatusTooManyRequests, 42)
+
+	if got := muxMetrics.ActiveRequests.With(http.MethodPost).Value(); got != 0 {
+		t.Errorf("active requests once finished: got %v", got)
+	}
+	if got := muxMetrics.Requests.With("", http.MethodPost, "/items/{id}", "4xx").Value(); got != 1 {
+		t.Errorf("requests: got %v", got)
+	}
+	if got := muxMetrics.RequestDuration.With("", http.MethodPost, "/items/{id}", "4xx").Sum(); got != 0.3 {
+		t.Errorf("request duration: got %v", got)
+	}
+	if got := muxMetrics.ResponseBodySize.With("", http.MethodPost, "/items/{id}").Sum(); got != 42 {
+		t.Errorf("response body size: got %v", got)
+	}
+	if got := muxMetrics.RateLimitRejections.With("", http.MethodPost, "/items/{id}").Value(); got != 1 {
+		t.Errorf("rate limit rejections: got %v", got)
+	}
+
+	// A request that was never answered is no longer active, and counted nowhere else.
+	unanswered := muxMetrics.Start(request)
+	muxMetrics.Finish(unanswered, 0, 0)
+	if got := muxMetrics.ActiveRequests.With(http.MethodPost).Value(); got != 0 {
+		t.Errorf("active requests once unanswered: got %v", got)
+	}
+	if got := muxMetrics.Requests.With("", http.MethodPost, UnmatchedEndpoint, "0xx").Value(); got != 0 {
+		t.Errorf("expected the unanswered request not to be counted, got %v", got)
+	}
+
+	// The metrics of a host record in the same series, labelled by it.
+	hostMetrics := muxMetrics.WithHost("a.example")
+	hostMetrics.Finish(hostMetrics.Start(request), http.StatusOK, 0)
+	if got := muxMetrics.Requests.With("a.example", http.MethodPost, UnmatchedEndpoint, "2xx").Value(); got != 1 {
+		t.Errorf("host requests: got %v", got)
+	}
+	if got := muxMetrics.Requests.With("", http.MethodPost, UnmatchedEndpoint, "2xx").Value(); got != 0 {
+		t.Errorf("expected the host request not to be counted without the host, got %v", got)
+	}
+
+	muxMetrics.ObserveFirewallVerdict(muxTypesFirewall.Drop)
+	if got := muxMetrics.FirewallVerdicts.With("drop").Value(); got != 1 {
+		t.Errorf("firewall verdicts: got %v", got)
+	}
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	t.Parallel()
+
+	var muxMetrics *Metrics
+	observation := muxMetrics.Start(httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil))
+	observation.SetEndpoint("/")
+	observation.SetRateLimited()
+	muxMetrics.Finish(observation, http.StatusOK, 0)
+	muxMetrics.ObserveFirewallVerdict(muxTypesFirewall.Accept)
+	if muxMetrics.WithHost("a.example") != nil {
+		t.Error("expected no host metrics")
+	}
+
+	if ObservationFromContext(t.Context()) != nil {
+		t.Error("expected no observation")
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	t.Parallel()
+
+	muxMetrics, err := New()
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	metricsEndpoint := Endpoint(muxMetrics.Registry, DefaultPath)
+	if metricsEndpoint.Path != DefaultPath {
+		t.Errorf("path: got %q", metricsEndpoint.Path)
+	}
+
+	response, responseError := metricsEndpoint.Handler(nil, nil)
+	if responseError != nil {
+		t.Fatalf("handler: %#v", responseError)
+	}
+	if len(response.Headers) == 0 || response.Headers[0].Value != metrics.OpenMetricsContentType {
+		t.Errorf("headers: got %#v", response.Headers)
+	}
+	body := string(response.Body)
+	if !strings.Contains(body, "# TYPE http_server_requests counter") || !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("body:\n%s", body)
+	}
+
+	if _, responseError := Endpoint(nil, DefaultPath).Handler(nil, nil); responseError == nil || responseError.ServerError == nil {
+		t.Error("expected a server error without a registry")
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	if _, err := New(mux_metrics_config.WithRegistry(registry)); err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if _, err := New(mux_metrics_config.WithRegistry(registry)); err == nil {
+		t.Error("expected an error registering the metrics twice")
+	}
+	if _, err := New(mux_metrics_config.WithDurationBuckets(2, 1)); err == nil {
+		t.Error("expected an error with decreasing buckets")
+	}
+	if _, err := New(mux_metrics_config.WithNow(nil)); err == nil {
+		t.Error("expected an error without a now function")
+	}
+}
@@ -0,0 +1,48 @@
+package ndjson
+
+import (
+	"encoding/json/v2"
+	"fmt"
+	"iter"
+	"net/http"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+)
+
+const ContentType = "application/x-ndjson"
+
+// New makes a response that streams the values as newline-delimited JSON, one value per line,
+// flushing the stream after each. The stream ends at the first error, which is returned by the
+// response writer, or when the client cannot be written to.
+func New[T any](values iter.Seq2[T, error]) *muxResponse.Response {
+	return &muxResponse.Response{
+		StatusCode: http.StatusOK,
+		Headers: []*muxResponse.HeaderEntry{
+			{Name: "Content-Type", Value: ContentType},
+			{Name: "X-Accel-Buffering", Value: "no"},
+		},
+		BodyStreamer: func(yield func([]byte, error) bool) {
+			if values == nil {
+				return
+			}
+
+			for value, err := range values {
+				if err != nil {
+					yield(nil, fmt.Errorf("values: %w", err))
+					return
+				}
+
+				data, err := json.Marshal(value)
+				if err != nil {
+					yield(nil, motmedelErrors.NewWithTrace(fmt.Errorf("json marshal: %w", err), value))
+					return
+				}
+
+				if !yield(append(data, '\n'), nil) {
+					return
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,69 @@
+package ndjson
+
+import (
+	"errors"
+	"iter"
+	"testing"
+)
+
+type item struct {
+	Id int `json:"id"`
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	errValues := errors.New("values error")
+
+	testCases := []struct {
+		name    string
+		values  iter.Seq2[*item, error]
+		want    string
+		wantErr error
+	}{
+		{name: "nil", values: nil, want: ""},
+		{
+			name: "values",
+			values: func(yield func(*item, error) bool) {
+				_ = yield(&item{Id: 1}, nil) && yield(&item{Id: 2}, nil)
+			},
+			want: "{\"id\":1}\n{\"id\":2}\n",
+		},
+		{
+			name: "error",
+			values: func(yield func(*item, error) bool) {
+				_ = yield(&item{Id: 1}, nil) && yield(nil, errValues) && yield(&item{Id: 3}, nil)
+			},
+			want:    "{\"id\":1}\n",
+			wantErr: errValues,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			response := New(testCase.values)
+			if response.Headers[0].Value != ContentType {
+				t.Errorf("content type: got %q", response.Headers[0].Value)
+			}
+
+			var body []byte
+			var err error
+			for chunk, chunkErr := range response.BodyStreamer {
+				if chunkErr != nil {
+					err = chunkErr
+					break
+				}
+				body = append(body, chunk...)
+			}
+
+			if !errors.Is(err, testCase.wantErr) {
+				t.Fatalf("got error %v, want %v", err, testCase.wantErr)
+			}
+			if string(body) != testCase.want {
+				t.Errorf("got %q, want %q", body, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package path_pattern
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
+)
+
+// WildcardName is the name under which the value of an unnamed trailing wildcard (`*`) is recorded.
+const WildcardName = "*"
+
+type SegmentKind int
+
+const (
+	Literal SegmentKind = iota
+	Parameter
+	Wildcard
+)
+
+type Segment struct {
+	Kind  SegmentKind
+	Value string
+}
+
+// Pattern is a parsed endpoint path. A segment of the form `{name}` matches any one non-empty
+// segment, and a final segment of the form `{name...}` or `*` matches the remainder of the path,
+// including the empty remainder.
+type Pattern struct {
+	Path     string
+	Segments []*Segment
+}
+
+// IsLiteral reports whether the pattern matches only the path it was written as.
+func (pattern *Pattern) IsLiteral() bool {
+	if pattern == nil {
+		return true
+	}
+
+	for _, segment := range pattern.Segments {
+		if segment != nil && segment.Kind != Literal {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Names returns the names of the pattern's parameters and wildcard, in path order.
+func (pattern *Pattern) Names() []string {
+	if pattern == nil {
+		return nil
+	}
+
+	var names []string
+	for _, segment := range pattern.Segments {
+		if segment != nil && segment.Kind != Literal {
+			names = append(names, segment.Value)
+		}
+	}
+
+	return names
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+func Parse(path string) (*Pattern, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: the path does not start with a slash", muxErrors.ErrMalformedPathPattern),
+			path,
+		)
+	}
+
+	pattern := &Pattern{Path: path}
+	seenNames := make(map[string]struct{})
+
+	rawSegments := splitPath(path)
+	for i, rawSegment := range rawSegments {
+		isLast := i == len(rawSegments)-1
+
+		var segment *Segment
+		switch {
+		case rawSegment == "*":
+			segment = &Segment{Kind: Wildcard, Value: WildcardName}
+		case strings.HasPrefix(rawSegment, "{") && strings.HasSuffix(rawSegment, "}"):
+			name := rawSegment[1 : len(rawSegment)-1]
+			kind := Parameter
+			if trimmedName, ok := strings.CutSuffix(name, "..."); ok {
+				name = trimmedName
+				kind = Wildcard
+			}
+			if name == "" || strings.ContainsAny(name, "{}/") {
+				return nil, motmedelErrors.NewWithTrace(
+					fmt.Errorf("%w: bad segment name %q", muxErrors.ErrMalformedPathPattern, rawSegment),
+					path,
+				)
+			}
+			segment = &Segment{Kind: kind, Value: name}
+		case strings.ContainsAny(rawSegment, "{}"):
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: partial segment %q", muxErrors.ErrMalformedPathPattern, rawSegment),
+				path,
+			)
+		default:
+			segment = &Segment{Kind: Literal, Value: rawSegment}
+		}
+
+		if segment.Kind == Wildcard && !isLast {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: a wildcard must be the final segment", muxErrors.ErrMalformedPathPattern),
+				path,
+			)
+		}
+
+		if segment.Kind != Literal {
+			if _, ok := seenNames[segment.Value]; ok {
+				return nil, motmedelErrors.NewWithTrace(
+					fmt.Errorf("%w: duplicate segment name %q", muxErrors.ErrMalformedPathPattern, segment.Value),
+					path,
+				)
+			}
+			seenNames[segment.Value] = struct{}{}
+		}
+
+		pattern.Segments = append(pattern.Segments, segment)
+	}
+
+	return pattern, nil
+}
+
+type node struct {
+	literalChildren map[string]*node
+	parameterChild  *node
+	wildcard        *Pattern
+	pattern         *Pattern
+}
+
+// Tree routes a request path to the pattern that matches it. At each segment a literal beats a
+// parameter, which beats a wildcard; the tree backtracks, so `/users/me/keys` still matches
+// `/users/{id}/keys` when `/users/me` is the only literal route below `/users`.
+//
+// A Tree is safe for concurrent use.
+type Tree struct {
+	root  node
+	mutex sync.RWMutex
+}
+
+// Add inserts the pattern into the tree. It returns the pattern that was previously routed to by
+// the same shape, such as `/users/{id}` for `/users/{name}`, which the new pattern replaces.
+func (tree *Tree) Add(pattern *Pattern) *Pattern {
+	if pattern == nil {
+		return nil
+	}
+
+	tree.mutex.Lock()
+	defer tree.mutex.Unlock()
+
+	current := &tree.root
+	for _, segment := range pattern.Segments {
+		if segment == nil {
+			continue
+		}
+
+		switch segment.Kind {
+		case Literal:
+			if current.literalChildren == nil {
+				current.literalChildren = make(map[string]*node)
+			}
+			child, ok := current.literalChildren[segment.Value]
+			if !ok {
+				child = &node{}
+				current.literalChildren[segment.Value] = child
+			}
+			current = child
+		case Parameter:
+			if current.parameterChild == nil {
+				current.parameterChild = &node{}
+			}
+			current = current.parameterChild
+		case Wildcard:
+			previous := current.wildcard
+			current.wildcard = pattern
+			return previous
+		}
+	}
+
+	previous := current.pattern
+	current.pattern = pattern
+	return previous
+}
+
+// Delete removes the pattern registered at the path, if any.
+func (tree *Tree) Delete(path string) {
+	pattern, err := Parse(path)
+	if err != nil {
+		return
+	}
+
+	tree.mutex.Lock()
+	defer tree.mutex.Unlock()
+
+	current := &tree.root
+	for _, segment := range pattern.Segments {
+		if current == nil {
+			return
+		}
+
+		switch segment.Kind {
+		case Literal:
+			current = current.literalChildren[segment.Value]
+		case Parameter:
+			current = current.parameterChild
+		case Wildcard:
+			if current.wildcard != nil && current.wildcard.Path == path {
+				current.wildcard = nil
+			}
+			return
+		}
+	}
+
+	if current != nil && current.pattern != nil && current.pattern.Path == path {
+		current.pattern = nil
+	}
+}
+
+func (current *node) match(segments []string, values []string) (*Pattern, []string) {
+	if current == nil {
+		return nil, nil
+	}
+
+	if len(segments) == 0 {
+		// NOTE: A wildcard is not matched here; `/static/*` matches `/static/` but not `/static`.
+		return current.pattern, values
+	}
+
+	segment := segments[0]
+
+	if child, ok := current.literalChildren[segment]; ok {
+		if pattern, matchValues := child.match(segments[1:], values); pattern != nil {
+			return pattern, matchValues
+		}
+	}
+
+	if segment != "" && current.parameterChild != nil {
+		if pattern, matchValues := current.parameterChild.match(segments[1:], append(values, segment)); pattern != nil {
+			return pattern, matchValues
+		}
+	}
+
+	if current.wildcard != nil {
+		return current.wildcard, append(values, strings.Join(segments, "/"))
+	}
+
+	return nil, nil
+}
+
+// Match returns the pattern that routes the escaped path and the values of its named segments. The
+// values are unescaped segment by segment, so that an escaped slash stays within its segment.
+func (tree *Tree) Match(escapedPath string) (*Pattern, map[string]string) {
+	if !strings.HasPrefix(escapedPath, "/") {
+		return nil, nil
+	}
+
+	rawSegments := splitPath(escapedPath)
+	segments := make([]string, len(rawSegments))
+	for i, rawSegment := range rawSegments {
+		segment, err := url.PathUnescape(rawSegment)
+		if err != nil {
+			return nil, nil
+		}
+		segments[i] = segment
+	}
+
+	tree.mutex.RLock()
+	defer tree.mutex.RUnlock()
+
+	pattern, values := tree.root.match(segments, nil)
+	if pattern == nil {
+		return nil, nil
+	}
+
+	names := pattern.Names()
+	if len(names) != len(values) {
+		return nil, nil
+	}
+
+	parameters := make(map[string]string, len(names))
+	for i, name := range names {
+		parameters[name] = values[i]
+	}
+
+	return pattern, parameters
+}
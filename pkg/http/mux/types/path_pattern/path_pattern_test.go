@@ -0,0 +1,207 @@
+package path_pattern
+
+import (
+	"errors"
+	"testing"
+
+	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		path      string
+		wantNames []string
+		literal   bool
+		wantErr   bool
+	}{
+		{name: "literal", path: "/users/me", literal: true},
+		{name: "root", path: "/", literal: true},
+		{name: "parameters", path: "/users/{id}/keys/{kid}", wantNames: []string{"id", "kid"}},
+		{name: "unnamed wildcard", path: "/static/*", wantNames: []string{WildcardName}},
+		{name: "named wildcard", path: "/files/{path...}", wantNames: []string{"path"}},
+		{name: "no leading slash", path: "users", wantErr: true},
+		{name: "empty name", path: "/users/{}", wantErr: true},
+		{name: "partial segment", path: "/users/id-{id}", wantErr: true},
+		{name: "wildcard not last", path: "/static/*/x", wantErr: true},
+		{name: "duplicate name", path: "/a/{id}/b/{id}", wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			pattern, err := Parse(testCase.path)
+			if testCase.wantErr {
+				if !errors.Is(err, muxErrors.ErrMalformedPathPattern) {
+					t.Fatalf("expected a malformed path pattern error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if pattern.IsLiteral() != testCase.literal {
+				t.Errorf("IsLiteral() = %v, want %v", pattern.IsLiteral(), testCase.literal)
+			}
+
+			names := pattern.Names()
+			if len(names) != len(testCase.wantNames) {
+				t.Fatalf("Names() = %v, want %v", names, testCase.wantNames)
+			}
+			for i := range names {
+				if names[i] != testCase.wantNames[i] {
+					t.Errorf("Names()[%d] = %q, want %q", i, names[i], testCase.wantNames[i])
+				}
+			}
+		})
+	}
+}
+
+func newTree(t *testing.T, paths ...string) *Tree {
+	t.Helper()
+
+	var tree Tree
+	for _, path := range paths {
+		pattern, err := Parse(path)
+		if err != nil {
+			t.Fatalf("parse %q: %v", path, err)
+		}
+		tree.Add(pattern)
+	}
+
+	return &tree
+}
+
+func TestTree_Match(t *testing.T) {
+	t.Parallel()
+
+	tree := newTree(
+		t,
+		"/users/{id}",
+		"/users/me",
+		"/users/{id}/keys/{kid}",
+		"/users/me/settings",
+		"/static/*",
+		"/files/{path...}",
+		"/files/readme/{format}",
+	)
+
+	testCases := []struct {
+		name           string
+		path           string
+		wantPattern    string
+		wantParameters map[string]string
+	}{
+		{name: "literal beats parameter", path: "/users/me", wantPattern: "/users/me"},
+		{name: "parameter", path: "/users/42", wantPattern: "/users/{id}", wantParameters: map[string]string{"id": "42"}},
+		{
+			name:           "backtracks from a literal",
+			path:           "/users/me/keys/k1",
+			wantPattern:    "/users/{id}/keys/{kid}",
+			wantParameters: map[string]string{"id": "me", "kid": "k1"},
+		},
+		{name: "literal below a literal", path: "/users/me/settings", wantPattern: "/users/me/settings"},
+		{
+			name:           "escaped slash stays in its segment",
+			path:           "/users/a%2Fb",
+			wantPattern:    "/users/{id}",
+			wantParameters: map[string]string{"id": "a/b"},
+		},
+		{name: "empty segment is not a parameter", path: "/users/"},
+		{name: "wildcard remainder", path: "/static/js/app.js", wantPattern: "/static/*", wantParameters: map[string]string{"*": "js/app.js"}},
+		{name: "wildcard empty remainder", path: "/static/", wantPattern: "/static/*", wantParameters: map[string]string{"*": ""}},
+		{name: "wildcard requires its prefix segment", path: "/static"},
+		{
+			name:           "parameter beats wildcard",
+			path:           "/files/readme/md",
+			wantPattern:    "/files/readme/{format}",
+			wantParameters: map[string]string{"format": "md"},
+		},
+		{
+			name:           "wildcard after failed parameter",
+			path:           "/files/readme/md/x",
+			wantPattern:    "/files/{path...}",
+			wantParameters: map[string]string{"path": "readme/md/x"},
+		},
+		{name: "no match", path: "/unknown"},
+		{name: "not absolute", path: "users/42"},
+		{name: "bad escape", path: "/users/%zz"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			pattern, parameters := tree.Match(testCase.path)
+			if testCase.wantPattern == "" {
+				if pattern != nil {
+					t.Fatalf("expected no match, got %q", pattern.Path)
+				}
+				return
+			}
+			if pattern == nil {
+				t.Fatalf("expected %q, got no match", testCase.wantPattern)
+			}
+			if pattern.Path != testCase.wantPattern {
+				t.Fatalf("pattern = %q, want %q", pattern.Path, testCase.wantPattern)
+			}
+			if len(parameters) != len(testCase.wantParameters) {
+				t.Fatalf("parameters = %v, want %v", parameters, testCase.wantParameters)
+			}
+			for name, value := range testCase.wantParameters {
+				if parameters[name] != value {
+					t.Errorf("parameters[%q] = %q, want %q", name, parameters[name], value)
+				}
+			}
+		})
+	}
+}
+
+func TestTree_AddReplacesEquivalent(t *testing.T) {
+	t.Parallel()
+
+	tree := newTree(t, "/users/{id}")
+
+	pattern, err := Parse("/users/{name}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	previous := tree.Add(pattern)
+	if previous == nil || previous.Path != "/users/{id}" {
+		t.Fatalf("expected the equivalent pattern to be returned, got %#v", previous)
+	}
+
+	_, parameters := tree.Match("/users/x")
+	if parameters["name"] != "x" {
+		t.Fatalf("expected the replacing pattern's names, got %v", parameters)
+	}
+}
+
+func TestTree_Delete(t *testing.T) {
+	t.Parallel()
+
+	tree := newTree(t, "/users/{id}", "/static/*")
+
+	tree.Delete("/users/{other}")
+	if pattern, _ := tree.Match("/users/1"); pattern == nil {
+		t.Fatal("expected deleting another path of the same shape to be a no-op")
+	}
+
+	tree.Delete("/users/{id}")
+	if pattern, _ := tree.Match("/users/1"); pattern != nil {
+		t.Fatalf("expected no match after delete, got %q", pattern.Path)
+	}
+
+	tree.Delete("/static/*")
+	if pattern, _ := tree.Match("/static/x"); pattern != nil {
+		t.Fatalf("expected no match after delete, got %q", pattern.Path)
+	}
+
+	// Deleting an unknown or malformed path is a no-op.
+	tree.Delete("/unknown/{x}")
+	tree.Delete("malformed")
+}
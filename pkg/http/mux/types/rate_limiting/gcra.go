@@ -0,0 +1,65 @@
+package rate_limiting
+
+import (
+	"context"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting/rate_limiter_config"
+)
+
+// GcraRateLimiter implements the generic cell rate algorithm: requests are spaced Period/Limit
+// apart, with a burst of up to Limit requests allowed. Its state is a single timestamp per key --
+// the theoretical arrival time of the next request.
+type GcraRateLimiter struct {
+	Limit  int
+	Period time.Duration
+	config *rate_limiter_config.Config
+}
+
+func (limiter *GcraRateLimiter) Allow(ctx context.Context, key string) (*Decision, error) {
+	if err := validateLimit(limiter.Limit, limiter.Period); err != nil {
+		return nil, err
+	}
+
+	limit := limiter.Limit
+	period := limiter.Period
+	interval := period / time.Duration(limit)
+
+	return apply(
+		ctx,
+		limiter.config,
+		key,
+		func(state []byte, now time.Time) ([]byte, time.Duration, *Decision, error) {
+			theoreticalArrival := now
+			if state != nil {
+				values, err := decodeState(state, 1)
+				if err != nil {
+					return nil, 0, nil, err
+				}
+				if storedArrival := time.Unix(0, values[0]); storedArrival.After(now) {
+					theoreticalArrival = storedArrival
+				}
+			}
+
+			nextArrival := theoreticalArrival.Add(interval)
+			decision := &Decision{Limit: limit, Window: period}
+
+			if allowedAt := nextArrival.Add(-period); now.Before(allowedAt) {
+				decision.Reset = theoreticalArrival.Sub(now)
+				decision.RetryAfter = allowedAt.Sub(now)
+				return nil, 0, decision, nil
+			}
+
+			ttl := nextArrival.Sub(now)
+			decision.Allowed = true
+			decision.Reset = ttl
+			decision.Remaining = int((period - ttl) / interval)
+
+			return encodeState(nextArrival.UnixNano()), ttl, decision, nil
+		},
+	)
+}
+
+func NewGcraRateLimiter(limit int, period time.Duration, options ...rate_limiter_config.Option) *GcraRateLimiter {
+	return &GcraRateLimiter{Limit: limit, Period: period, config: rate_limiter_config.New(options...)}
+}
@@ -0,0 +1,66 @@
+package rate_limiter_config
+
+import (
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting/store"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting/store/memory_store"
+)
+
+var (
+	DefaultMaxAttempts = 8
+)
+
+type Config struct {
+	// Store holds the limiter's state; a new in-memory store, whose entries expire by Now, is used when
+	// none is set.
+	Store store.Store
+	// KeyPrefix is prepended to the keys in the store, so that limiters can share one.
+	KeyPrefix string
+	// MaxAttempts is the number of times a limiter tries to update a key's state before giving up on
+	// a store contended by other writers.
+	MaxAttempts int
+	Now         func() time.Time
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		MaxAttempts: DefaultMaxAttempts,
+		Now:         time.Now,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	if config.Store == nil {
+		config.Store = memory_store.New(memory_store.DefaultNumShards, memory_store.WithNow(config.Now))
+	}
+
+	return config
+}
+
+func WithStore(store store.Store) Option {
+	return func(config *Config) {
+		config.Store = store
+	}
+}
+
+func WithKeyPrefix(keyPrefix string) Option {
+	return func(config *Config) {
+		config.KeyPrefix = keyPrefix
+	}
+}
+
+func WithMaxAttempts(maxAttempts int) Option {
+	return func(config *Config) {
+		config.MaxAttempts = maxAttempts
+	}
+}
+
+func WithNow(now func() time.Time) Option {
+	return func(config *Config) {
+		config.Now = now
+	}
+}
@@ -0,0 +1,50 @@
+package rate_limiter_config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting/store/memory_store"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.MaxAttempts != DefaultMaxAttempts {
+		t.Errorf("max attempts: got %d", config.MaxAttempts)
+	}
+	if config.Now == nil {
+		t.Error("expected a default now function")
+	}
+	if _, ok := config.Store.(*memory_store.Store); !ok {
+		t.Errorf("expected a default memory store, got %T", config.Store)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	store := memory_store.New(1)
+	now := time.Unix(1, 0)
+
+	config := New(
+		WithStore(store),
+		WithKeyPrefix("login:"),
+		WithMaxAttempts(2),
+		WithNow(func() time.Time { return now }),
+	)
+
+	if config.Store != store {
+		t.Errorf("store: got %v", config.Store)
+	}
+	if config.KeyPrefix != "login:" {
+		t.Errorf("key prefix: got %q", config.KeyPrefix)
+	}
+	if config.MaxAttempts != 2 {
+		t.Errorf("max attempts: got %d", config.MaxAttempts)
+	}
+	if !config.Now().Equal(now) {
+		t.Errorf("now: got %v", config.Now())
+	}
+}
@@ -1,50 +1,118 @@
 package rate_limiting
 
 import (
+	"context"
+	"encoding/binary"
 	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
 	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting/rate_limiter_config"
 	motmedelNet "github.com/Motmedel/utils_go/pkg/net"
 )
 
 // TODO: This does not need to be in `mux`?
 
-type RateLimiter struct {
-	insertionIndex   int
-	Bucket           []*time.Time
-	mutex            sync.Mutex
-	NumOccupied      int
-	NumSecondsExpiry int
+// Decision is a rate limiter's verdict on a request.
+type Decision struct {
+	Allowed bool
+	// Limit is the number of requests allowed per window.
+	Limit  int
+	Window time.Duration
+	// Remaining is the number of requests that would be allowed right after this one.
+	Remaining int
+	// Reset is the time until the quota is fully restored.
+	Reset time.Duration
+	// RetryAfter is the time until a request that is not allowed would be.
+	RetryAfter time.Duration
+	// RetryAt is when a request that is not allowed would be, by the clock of the rate limiter.
+	RetryAt time.Time
 }
 
-func (rateLimiter *RateLimiter) Claim() (*time.Time, bool) {
-	rateLimiter.mutex.Lock()
-	defer rateLimiter.mutex.Unlock()
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (*Decision, error)
+}
+
+// transition computes a key's next state from its current one, which is nil when there is none. A
+// nil next state means that the state is left as it is, as for a request that is not allowed.
+type transition func(state []byte, now time.Time) ([]byte, time.Duration, *Decision, error)
 
-	if rateLimiter.NumOccupied == len(rateLimiter.Bucket) {
-		return rateLimiter.Bucket[rateLimiter.insertionIndex], true
+func apply(ctx context.Context, config *rate_limiter_config.Config, key string, next transition) (*Decision, error) {
+	if config == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("rate limiter config"))
 	}
 
-	expirationTime := time.Now().Add(time.Duration(rateLimiter.NumSecondsExpiry) * time.Second)
+	rateLimiterStore := config.Store
+	if rateLimiterStore == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("rate limiter store"))
+	}
 
-	currentInsertionIndex := rateLimiter.insertionIndex
-	rateLimiter.Bucket[currentInsertionIndex] = &expirationTime
-	rateLimiter.insertionIndex = (currentInsertionIndex + 1) % len(rateLimiter.Bucket)
-	rateLimiter.NumOccupied += 1
+	storeKey := config.KeyPrefix + key
+
+	for range max(config.MaxAttempts, 1) {
+		state, err := rateLimiterStore.Get(ctx, storeKey)
+		if err != nil {
+			return nil, motmedelErrors.New(fmt.Errorf("store get: %w", err), storeKey)
+		}
+
+		now := config.Now()
+		nextState, ttl, decision, err := next(state, now)
+		if err != nil {
+			return nil, motmedelErrors.New(err, storeKey, state)
+		}
+		if decision != nil && !decision.Allowed {
+			decision.RetryAt = now.Add(decision.RetryAfter)
+		}
+		if nextState == nil {
+			return decision, nil
+		}
+
+		swapped, err := rateLimiterStore.CompareAndSwap(ctx, storeKey, state, nextState, ttl)
+		if err != nil {
+			return nil, motmedelErrors.New(fmt.Errorf("store compare and swap: %w", err), storeKey)
+		}
+		if swapped {
+			return decision, nil
+		}
+	}
 
-	// NOTE: Arbitrarily decreasing the wait time by one second.
-	time.AfterFunc(time.Until(expirationTime)-time.Second, func() {
-		rateLimiter.mutex.Lock()
-		defer rateLimiter.mutex.Unlock()
+	return nil, motmedelErrors.NewWithTrace(muxErrors.ErrRateLimitingContention, storeKey)
+}
 
-		rateLimiter.Bucket[currentInsertionIndex] = nil
-		rateLimiter.NumOccupied -= 1
-	})
+func encodeState(values ...int64) []byte {
+	state := make([]byte, 0, 8*len(values))
+	for _, value := range values {
+		state = binary.BigEndian.AppendUint64(state, uint64(value))
+	}
+	return state
+}
+
+func decodeState(state []byte, numValues int) ([]int64, error) {
+	if len(state) != 8*numValues {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: unexpected length %d", muxErrors.ErrMalformedRateLimitingState, len(state)),
+		)
+	}
 
-	return &expirationTime, false
+	values := make([]int64, numValues)
+	for i := range values {
+		values[i] = int64(binary.BigEndian.Uint64(state[8*i:]))
+	}
+	return values, nil
+}
+
+func validateLimit(limit int, period time.Duration) error {
+	if limit <= 0 || period <= 0 {
+		return motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: non-positive rate limit", muxErrors.ErrUnusableMuxSpecification),
+			limit, period,
+		)
+	}
+	return nil
 }
 
 func DefaultGetRateLimitingKey(request *http.Request) (string, error) {
@@ -61,19 +129,33 @@ func DefaultGetRateLimitingKey(request *http.Request) (string, error) {
 	return ipAddress, nil
 }
 
-type TimerRateLimiter struct {
-	RateLimiter
-	Timer *time.Timer
-}
-
-type RateLimitingLookup struct {
-	Map   map[string]*TimerRateLimiter
-	Mutex sync.Mutex
-}
-
 type RateLimitingConfiguration struct {
 	NumRequests          int
 	NumSecondsExpiration int
 	GetKey               func(*http.Request) (string, error)
-	Lookup               RateLimitingLookup
+	// RateLimiter decides on the requests. When it is nil, a GCRA limiter of NumRequests requests
+	// per NumSecondsExpiration seconds, with an in-memory store, is used.
+	RateLimiter RateLimiter
+
+	defaultRateLimiterOnce sync.Once
+	defaultRateLimiter     RateLimiter
+}
+
+func (configuration *RateLimitingConfiguration) GetRateLimiter() RateLimiter {
+	if configuration == nil {
+		return nil
+	}
+
+	if configuration.RateLimiter != nil {
+		return configuration.RateLimiter
+	}
+
+	configuration.defaultRateLimiterOnce.Do(func() {
+		configuration.defaultRateLimiter = NewGcraRateLimiter(
+			configuration.NumRequests,
+			time.Duration(configuration.NumSecondsExpiration)*time.Second,
+		)
+	})
+
+	return configuration.defaultRateLimiter
 }
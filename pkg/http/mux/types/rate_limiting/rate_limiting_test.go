@@ -1,45 +1,245 @@
 package rate_limiting
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"testing"
-	"testing/synctest"
 	"time"
+
+	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting/rate_limiter_config"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting/store/memory_store"
 )
 
-func TestClaim(t *testing.T) {
+type fakeClock struct {
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	return clock.now
+}
+
+func (clock *fakeClock) Advance(duration time.Duration) {
+	clock.now = clock.now.Add(duration)
+}
+
+type contendedStore struct{}
+
+func (contendedStore) Get(context.Context, string) ([]byte, error) {
+	return nil, nil
+}
+
+func (contendedStore) CompareAndSwap(context.Context, string, []byte, []byte, time.Duration) (bool, error) {
+	return false, nil
+}
+
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) ([]byte, error) {
+	return nil, errStore
+}
+
+func (failingStore) CompareAndSwap(context.Context, string, []byte, []byte, time.Duration) (bool, error) {
+	return false, errStore
+}
+
+var errStore = errors.New("store error")
+
+func allow(t *testing.T, rateLimiter RateLimiter, key string) *Decision {
+	t.Helper()
+
+	decision, err := rateLimiter.Allow(t.Context(), key)
+	if err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	if decision == nil {
+		t.Fatal("nil decision")
+	}
+
+	return decision
+}
+
+func TestRateLimiters(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		make func(now func() time.Time) RateLimiter
+		// The time after which a rejected request, made right after the burst, would be allowed.
+		wantRetryAfter time.Duration
+	}{
+		{
+			name: "gcra",
+			make: func(now func() time.Time) RateLimiter {
+				return NewGcraRateLimiter(3, 3*time.Second, rate_limiter_config.WithNow(now))
+			},
+			wantRetryAfter: time.Second,
+		},
+		{
+			name: "token bucket",
+			make: func(now func() time.Time) RateLimiter {
+				return NewTokenBucketRateLimiter(3, 3*time.Second, rate_limiter_config.WithNow(now))
+			},
+			wantRetryAfter: time.Second,
+		},
+		{
+			name: "sliding window",
+			make: func(now func() time.Time) RateLimiter {
+				return NewSlidingWindowRateLimiter(3, 3*time.Second, rate_limiter_config.WithNow(now))
+			},
+			// The burst fills the current window; the window after it must slide a third past it.
+			wantRetryAfter: 4 * time.Second,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &fakeClock{now: time.Unix(3000, 0)}
+			rateLimiter := testCase.make(clock.Now)
+
+			for i := range 3 {
+				decision := allow(t, rateLimiter, "a")
+				if !decision.Allowed {
+					t.Fatalf("request %d should be allowed", i)
+				}
+				if decision.Limit != 3 || decision.Window != 3*time.Second {
+					t.Fatalf("unexpected policy: %#v", decision)
+				}
+				if decision.Remaining != 2-i {
+					t.Fatalf("request %d: remaining = %d, want %d", i, decision.Remaining, 2-i)
+				}
+			}
+
+			decision := allow(t, rateLimiter, "a")
+			if decision.Allowed {
+				t.Fatal("a request beyond the limit must not be allowed")
+			}
+			if decision.Remaining != 0 {
+				t.Fatalf("remaining = %d, want 0", decision.Remaining)
+			}
+			if decision.RetryAfter != testCase.wantRetryAfter {
+				t.Fatalf("retry after = %v, want %v", decision.RetryAfter, testCase.wantRetryAfter)
+			}
+			if wantRetryAt := clock.Now().Add(testCase.wantRetryAfter); !decision.RetryAt.Equal(wantRetryAt) {
+				t.Fatalf("retry at = %v, want %v", decision.RetryAt, wantRetryAt)
+			}
+			if decision.Reset < decision.RetryAfter {
+				t.Fatalf("reset %v is before retry after %v", decision.Reset, decision.RetryAfter)
+			}
+
+			if !allow(t, rateLimiter, "b").Allowed {
+				t.Fatal("another key must not be limited")
+			}
+
+			clock.Advance(decision.RetryAfter - time.Millisecond)
+			if allow(t, rateLimiter, "a").Allowed {
+				t.Fatal("a request before retry after must not be allowed")
+			}
+
+			clock.Advance(time.Millisecond)
+			if !allow(t, rateLimiter, "a").Allowed {
+				t.Fatal("a request at retry after must be allowed")
+			}
+		})
+	}
+}
+
+func TestRateLimiters_Errors(t *testing.T) {
 	t.Parallel()
 
-	synctest.Test(t, func(t *testing.T) {
-		rateLimiter := &RateLimiter{Bucket: make([]*time.Time, 2), NumSecondsExpiry: 5}
-
-		wantExpiry := time.Now().Add(5 * time.Second)
-
-		expiry1, full1 := rateLimiter.Claim()
-		expiry2, full2 := rateLimiter.Claim()
-		if full1 || full2 {
-			t.Fatalf("claims within capacity must not be limited (full1=%v full2=%v)", full1, full2)
-		}
-		if expiry1 == nil || !expiry1.Equal(wantExpiry) {
-			t.Fatalf("expiry1 = %v, want %v", expiry1, wantExpiry)
-		}
-		if expiry2 == nil || !expiry2.Equal(wantExpiry) {
-			t.Fatalf("expiry2 = %v, want %v", expiry2, wantExpiry)
-		}
-
-		if _, full := rateLimiter.Claim(); !full {
-			t.Fatal("a claim beyond capacity must be limited")
-		}
-
-		// Bucket slots are freed one second before expiry; advancing past the window
-		// fires those timers.
-		time.Sleep(5 * time.Second)
-		synctest.Wait()
-
-		if _, full := rateLimiter.Claim(); full {
-			t.Fatal("a claim after the window elapsed must not be limited")
-		}
-	})
+	testCases := []struct {
+		name        string
+		rateLimiter RateLimiter
+		wantErr     error
+	}{
+		{
+			name:        "non-positive limit",
+			rateLimiter: NewGcraRateLimiter(0, time.Second),
+			wantErr:     muxErrors.ErrUnusableMuxSpecification,
+		},
+		{
+			name:        "non-positive period",
+			rateLimiter: NewTokenBucketRateLimiter(1, 0),
+			wantErr:     muxErrors.ErrUnusableMuxSpecification,
+		},
+		{
+			name:        "contention",
+			rateLimiter: NewSlidingWindowRateLimiter(1, time.Second, rate_limiter_config.WithStore(contendedStore{})),
+			wantErr:     muxErrors.ErrRateLimitingContention,
+		},
+		{
+			name:        "store error",
+			rateLimiter: NewGcraRateLimiter(1, time.Second, rate_limiter_config.WithStore(failingStore{})),
+			wantErr:     errStore,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := testCase.rateLimiter.Allow(t.Context(), "a"); !errors.Is(err, testCase.wantErr) {
+				t.Fatalf("got %v, want %v", err, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateLimiters_MalformedState(t *testing.T) {
+	t.Parallel()
+
+	store := memory_store.New(1)
+	if _, err := store.CompareAndSwap(t.Context(), "a", nil, []byte("xyz"), time.Minute); err != nil {
+		t.Fatalf("compare and swap: %v", err)
+	}
+
+	rateLimiter := NewGcraRateLimiter(1, time.Second, rate_limiter_config.WithStore(store))
+	if _, err := rateLimiter.Allow(t.Context(), "a"); !errors.Is(err, muxErrors.ErrMalformedRateLimitingState) {
+		t.Fatalf("got %v, want %v", err, muxErrors.ErrMalformedRateLimitingState)
+	}
+}
+
+func TestRateLimiters_SharedStore(t *testing.T) {
+	t.Parallel()
+
+	// Two replicas sharing a store share the limit.
+	store := memory_store.New(0)
+	replicaA := NewGcraRateLimiter(2, time.Minute, rate_limiter_config.WithStore(store))
+	replicaB := NewGcraRateLimiter(2, time.Minute, rate_limiter_config.WithStore(store))
+
+	if !allow(t, replicaA, "a").Allowed || !allow(t, replicaB, "a").Allowed {
+		t.Fatal("requests within the limit must be allowed")
+	}
+	if allow(t, replicaA, "a").Allowed {
+		t.Fatal("a request beyond the shared limit must not be allowed")
+	}
+}
+
+func TestRateLimitingConfiguration_GetRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	configuration := &RateLimitingConfiguration{NumRequests: 1, NumSecondsExpiration: 5}
+
+	rateLimiter := configuration.GetRateLimiter()
+	gcraRateLimiter, ok := rateLimiter.(*GcraRateLimiter)
+	if !ok {
+		t.Fatalf("expected a default GCRA rate limiter, got %T", rateLimiter)
+	}
+	if gcraRateLimiter.Limit != 1 || gcraRateLimiter.Period != 5*time.Second {
+		t.Fatalf("unexpected default rate limiter: %#v", gcraRateLimiter)
+	}
+	if configuration.GetRateLimiter() != rateLimiter {
+		t.Fatal("expected the default rate limiter to be kept")
+	}
+
+	custom := NewTokenBucketRateLimiter(1, time.Second)
+	configuration = &RateLimitingConfiguration{RateLimiter: custom}
+	if configuration.GetRateLimiter() != custom {
+		t.Fatal("expected the configured rate limiter")
+	}
 }
 
 func TestDefaultGetRateLimitingKey(t *testing.T) {
@@ -0,0 +1,98 @@
+package rate_limiting
+
+import (
+	"context"
+	"math"
+	"math/bits"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting/rate_limiter_config"
+)
+
+// SlidingWindowRateLimiter allows Limit requests per sliding window of Period. Rather than
+// remembering each request, it counts the requests of the current and the previous fixed window and
+// weighs the previous count by how much of the previous window the sliding window still covers.
+type SlidingWindowRateLimiter struct {
+	Limit  int
+	Period time.Duration
+	config *rate_limiter_config.Config
+}
+
+func (limiter *SlidingWindowRateLimiter) Allow(ctx context.Context, key string) (*Decision, error) {
+	if err := validateLimit(limiter.Limit, limiter.Period); err != nil {
+		return nil, err
+	}
+
+	limit := limiter.Limit
+	period := limiter.Period
+
+	return apply(
+		ctx,
+		limiter.config,
+		key,
+		func(state []byte, now time.Time) ([]byte, time.Duration, *Decision, error) {
+			nowNano := now.UnixNano()
+			windowStart := nowNano - nowNano%int64(period)
+
+			var current, previous int64
+			if state != nil {
+				values, err := decodeState(state, 3)
+				if err != nil {
+					return nil, 0, nil, err
+				}
+				switch storedStart := values[0]; storedStart {
+				case windowStart:
+					current, previous = values[1], values[2]
+				case windowStart - int64(period):
+					previous = values[1]
+				}
+			}
+
+			elapsed := time.Duration(nowNano - windowStart)
+			untilWindowEnd := period - elapsed
+			// The share of the previous window that the sliding window still covers.
+			previousWeight := float64(untilWindowEnd) / float64(period)
+			estimate := float64(previous)*previousWeight + float64(current)
+
+			decision := &Decision{Limit: limit, Window: period, Reset: untilWindowEnd}
+
+			if estimate+1 > float64(limit) {
+				// The time into a window at which the weighted count of the window before it, which
+				// held the given count, leaves room for the given number of requests.
+				roomAt := func(count int64, room int64) time.Duration {
+					// NOTE: ceil(period * (count - room) / count), in integers so as to be exact.
+					high, low := bits.Mul64(uint64(period), uint64(count-room))
+					quotient, remainder := bits.Div64(high, low, uint64(count))
+					if remainder != 0 {
+						quotient += 1
+					}
+					return time.Duration(quotient)
+				}
+
+				if current+1 > int64(limit) {
+					decision.RetryAfter = untilWindowEnd + roomAt(current, int64(limit-1))
+					decision.Reset += period
+				} else {
+					decision.RetryAfter = roomAt(previous, int64(limit)-1-current) - elapsed
+				}
+				decision.RetryAfter = max(decision.RetryAfter, 1)
+
+				return nil, 0, decision, nil
+			}
+
+			current += 1
+			decision.Allowed = true
+			decision.Remaining = max(int(math.Floor(float64(limit)-estimate-1)), 0)
+
+			return encodeState(windowStart, current, previous), untilWindowEnd + period, decision, nil
+		},
+	)
+}
+
+func NewSlidingWindowRateLimiter(
+	limit int,
+	period time.Duration,
+	options ...rate_limiter_config.Option,
+) *SlidingWindowRateLimiter {
+	return &SlidingWindowRateLimiter{Limit: limit, Period: period, config: rate_limiter_config.New(options...)}
+}
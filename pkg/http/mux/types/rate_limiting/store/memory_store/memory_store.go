@@ -0,0 +1,145 @@
+package memory_store
+
+import (
+	"bytes"
+	"context"
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultNumShards     = 64
+	DefaultSweepInterval = time.Minute
+)
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+type shard struct {
+	mutex     sync.Mutex
+	entries   map[string]*entry
+	nextSweep time.Time
+}
+
+type Option func(*Store)
+
+// WithNow sets the clock the entries expire by, which is to be that of the limiters using the store.
+func WithNow(now func() time.Time) Option {
+	return func(store *Store) {
+		store.now = now
+	}
+}
+
+// Store is an in-process store. Its keys are spread over shards with a lock each, and expired
+// entries are evicted lazily -- when read, and in a sweep of a shard that is written to at most once
+// per sweep interval -- so that the store runs no timers or goroutines of its own.
+type Store struct {
+	seed          maphash.Seed
+	shards        []*shard
+	SweepInterval time.Duration
+
+	now func() time.Time
+}
+
+func (store *Store) getShard(key string) *shard {
+	return store.shards[maphash.String(store.seed, key)%uint64(len(store.shards))]
+}
+
+func (store *Store) Get(_ context.Context, key string) ([]byte, error) {
+	keyShard := store.getShard(key)
+
+	keyShard.mutex.Lock()
+	defer keyShard.mutex.Unlock()
+
+	keyEntry, ok := keyShard.entries[key]
+	if !ok {
+		return nil, nil
+	}
+
+	if !store.now().Before(keyEntry.expiresAt) {
+		delete(keyShard.entries, key)
+		return nil, nil
+	}
+
+	return keyEntry.value, nil
+}
+
+func (store *Store) CompareAndSwap(
+	_ context.Context,
+	key string,
+	old []byte,
+	new []byte,
+	ttl time.Duration,
+) (bool, error) {
+	keyShard := store.getShard(key)
+	now := store.now()
+
+	keyShard.mutex.Lock()
+	defer keyShard.mutex.Unlock()
+
+	if !now.Before(keyShard.nextSweep) {
+		for entryKey, keyEntry := range keyShard.entries {
+			if !now.Before(keyEntry.expiresAt) {
+				delete(keyShard.entries, entryKey)
+			}
+		}
+		keyShard.nextSweep = now.Add(store.SweepInterval)
+	}
+
+	var current []byte
+	if keyEntry, ok := keyShard.entries[key]; ok && now.Before(keyEntry.expiresAt) {
+		current = keyEntry.value
+	}
+
+	if (old == nil) != (current == nil) || !bytes.Equal(old, current) {
+		return false, nil
+	}
+
+	if ttl <= 0 {
+		delete(keyShard.entries, key)
+		return true, nil
+	}
+
+	keyShard.entries[key] = &entry{value: bytes.Clone(new), expiresAt: now.Add(ttl)}
+
+	return true, nil
+}
+
+// Len returns the number of entries held, including expired ones not yet evicted.
+func (store *Store) Len() int {
+	var length int
+	for _, keyShard := range store.shards {
+		keyShard.mutex.Lock()
+		length += len(keyShard.entries)
+		keyShard.mutex.Unlock()
+	}
+
+	return length
+}
+
+func New(numShards int, options ...Option) *Store {
+	if numShards <= 0 {
+		numShards = DefaultNumShards
+	}
+
+	shards := make([]*shard, numShards)
+	for i := range shards {
+		shards[i] = &shard{entries: make(map[string]*entry)}
+	}
+
+	store := &Store{seed: maphash.MakeSeed(), shards: shards, SweepInterval: DefaultSweepInterval, now: time.Now}
+	for _, option := range options {
+		if option != nil {
+			option(store)
+		}
+	}
+
+	if store.now == nil {
+		store.now = time.Now
+	}
+
+	return store
+}
@@ -0,0 +1,102 @@
+package memory_store
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestStore_CompareAndSwap(t *testing.T) {
+	t.Parallel()
+
+	store := New(0)
+	ctx := t.Context()
+
+	if swapped, err := store.CompareAndSwap(ctx, "a", []byte("x"), []byte("y"), time.Minute); err != nil || swapped {
+		t.Fatalf("a swap from a state that is not stored must fail (swapped=%v err=%v)", swapped, err)
+	}
+	if swapped, err := store.CompareAndSwap(ctx, "a", nil, []byte("x"), time.Minute); err != nil || !swapped {
+		t.Fatalf("a swap from no state must succeed (swapped=%v err=%v)", swapped, err)
+	}
+	if swapped, _ := store.CompareAndSwap(ctx, "a", nil, []byte("y"), time.Minute); swapped {
+		t.Fatal("a swap from no state must fail when there is one")
+	}
+	if swapped, _ := store.CompareAndSwap(ctx, "a", []byte("x"), []byte("y"), time.Minute); !swapped {
+		t.Fatal("a swap from the stored state must succeed")
+	}
+
+	value, err := store.Get(ctx, "a")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if string(value) != "y" {
+		t.Fatalf("got %q, want %q", value, "y")
+	}
+
+	if swapped, _ := store.CompareAndSwap(ctx, "a", []byte("y"), []byte("z"), 0); !swapped {
+		t.Fatal("a swap with no ttl must succeed")
+	}
+	if value, _ := store.Get(ctx, "a"); value != nil {
+		t.Fatalf("expected a swap with no ttl to delete the state, got %q", value)
+	}
+}
+
+func TestStore_Expiry(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		store := New(1)
+		store.SweepInterval = time.Minute
+		ctx := t.Context()
+
+		if _, err := store.CompareAndSwap(ctx, "a", nil, []byte("x"), time.Second); err != nil {
+			t.Fatalf("compare and swap: %v", err)
+		}
+		if _, err := store.CompareAndSwap(ctx, "b", nil, []byte("x"), time.Second); err != nil {
+			t.Fatalf("compare and swap: %v", err)
+		}
+
+		time.Sleep(time.Second)
+
+		if value, _ := store.Get(ctx, "a"); value != nil {
+			t.Fatalf("expected the state to have expired, got %q", value)
+		}
+		if swapped, _ := store.CompareAndSwap(ctx, "a", nil, []byte("y"), time.Second); !swapped {
+			t.Fatal("a swap from no state must succeed once the state has expired")
+		}
+		if store.Len() != 2 {
+			t.Fatalf("expected the expired entry to be kept until the sweep, got %d entries", store.Len())
+		}
+
+		time.Sleep(time.Minute)
+
+		if _, err := store.CompareAndSwap(ctx, "c", nil, []byte("x"), time.Second); err != nil {
+			t.Fatalf("compare and swap: %v", err)
+		}
+		if store.Len() != 1 {
+			t.Fatalf("expected the sweep to evict the expired entries, got %d entries", store.Len())
+		}
+	})
+}
+
+func TestStore_WithNow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_000_000, 0)
+	store := New(1, WithNow(func() time.Time { return now }))
+	ctx := t.Context()
+
+	if _, err := store.CompareAndSwap(ctx, "a", nil, []byte("x"), time.Second); err != nil {
+		t.Fatalf("compare and swap: %v", err)
+	}
+
+	now = now.Add(999 * time.Millisecond)
+	if value, _ := store.Get(ctx, "a"); string(value) != "x" {
+		t.Fatalf("expected the state to be kept by the clock, got %q", value)
+	}
+
+	now = now.Add(time.Millisecond)
+	if value, _ := store.Get(ctx, "a"); value != nil {
+		t.Fatalf("expected the state to have expired by the clock, got %q", value)
+	}
+}
@@ -0,0 +1,17 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Store holds the state of rate limiters by key. A limiter reads the state of a key, computes its
+// successor and writes it back with CompareAndSwap, retrying when another writer got there first;
+// a store that several replicas share, such as a Redis-like server, thereby makes them share limits.
+type Store interface {
+	// Get returns the state stored at the key, or nil if there is none or it has expired.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// CompareAndSwap stores the new state at the key if the state stored there equals old, a nil old
+	// requiring that there be none, and reports whether it did. The new state expires after ttl.
+	CompareAndSwap(ctx context.Context, key string, old []byte, new []byte, ttl time.Duration) (bool, error)
+}
@@ -0,0 +1,73 @@
+package rate_limiting
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting/rate_limiter_config"
+)
+
+// TokenBucketRateLimiter holds a bucket of up to Limit tokens per key, refilled at Limit tokens per
+// Period; a request takes a token, and is not allowed when there is none. The refill is computed
+// from the time elapsed since the bucket was last taken from rather than by a timer.
+type TokenBucketRateLimiter struct {
+	Limit  int
+	Period time.Duration
+	config *rate_limiter_config.Config
+}
+
+func (limiter *TokenBucketRateLimiter) Allow(ctx context.Context, key string) (*Decision, error) {
+	if err := validateLimit(limiter.Limit, limiter.Period); err != nil {
+		return nil, err
+	}
+
+	limit := limiter.Limit
+	capacity := float64(limit)
+	period := limiter.Period
+	// The time it takes to refill the given number of tokens.
+	refillTime := func(tokens float64) time.Duration {
+		return time.Duration(math.Ceil(tokens * float64(period) / capacity))
+	}
+
+	return apply(
+		ctx,
+		limiter.config,
+		key,
+		func(state []byte, now time.Time) ([]byte, time.Duration, *Decision, error) {
+			tokens := capacity
+			if state != nil {
+				values, err := decodeState(state, 2)
+				if err != nil {
+					return nil, 0, nil, err
+				}
+				elapsed := max(now.Sub(time.Unix(0, values[1])), 0)
+				tokens = min(capacity, math.Float64frombits(uint64(values[0]))+float64(elapsed)*capacity/float64(period))
+			}
+
+			decision := &Decision{Limit: limit, Window: period}
+
+			if tokens < 1 {
+				decision.Reset = refillTime(capacity - tokens)
+				decision.RetryAfter = refillTime(1 - tokens)
+				return nil, 0, decision, nil
+			}
+
+			tokens -= 1
+			ttl := refillTime(capacity - tokens)
+			decision.Allowed = true
+			decision.Remaining = int(tokens)
+			decision.Reset = ttl
+
+			return encodeState(int64(math.Float64bits(tokens)), now.UnixNano()), ttl, decision, nil
+		},
+	)
+}
+
+func NewTokenBucketRateLimiter(
+	limit int,
+	period time.Duration,
+	options ...rate_limiter_config.Option,
+) *TokenBucketRateLimiter {
+	return &TokenBucketRateLimiter{Limit: limit, Period: period, config: rate_limiter_config.New(options...)}
+}
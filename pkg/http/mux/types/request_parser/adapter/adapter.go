@@ -15,6 +15,11 @@ func (adapter Adapter[T]) Parse(request *http.Request) (any, *response_error.Res
 	return adapter.Parser.Parse(request)
 }
 
+// GetParser returns the adapted parser, for code that describes a parser by what it wraps.
+func (adapter Adapter[T]) GetParser() any {
+	return adapter.Parser
+}
+
 func New[T any](parser request_parser.RequestParser[T]) Adapter[T] {
 	return Adapter[T]{Parser: parser}
 }
@@ -0,0 +1,143 @@
+package client_certificate_verifier
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	muxContext "github.com/Motmedel/utils_go/pkg/http/mux/context"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/client_certificate_verifier/client_certificate_verifier_config"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail/problem_detail_config"
+	"github.com/Motmedel/utils_go/pkg/schema"
+	motmedelTlsTypes "github.com/Motmedel/utils_go/pkg/tls/types"
+)
+
+// Client is a client authenticated by its certificate.
+type Client struct {
+	Certificate    *x509.Certificate
+	VerifiedChains [][]*x509.Certificate
+	User           *schema.User
+}
+
+func (client *Client) GetUser() *schema.User {
+	if client == nil {
+		return nil
+	}
+
+	return client.User
+}
+
+// Parser authenticates requests by the certificate the client presented in the TLS handshake
+// (mutual TLS), producing the client it identifies.
+//
+// The certificate is verified against the configured roots for client authentication, whatever the
+// TLS configuration did with it, and the chain it was verified by is recorded in the request's TLS
+// context, so that what is logged of the request says who it came from.
+type Parser struct {
+	config *client_certificate_verifier_config.Config
+}
+
+func (p *Parser) Parse(request *http.Request) (*Client, *muxResponseError.ResponseError) {
+	if request == nil {
+		return nil, &muxResponseError.ResponseError{
+			ServerError: motmedelErrors.NewWithTrace(nil_error.New("request")),
+		}
+	}
+
+	connectionState := request.TLS
+	if connectionState == nil || len(connectionState.PeerCertificates) == 0 {
+		return nil, &muxResponseError.ResponseError{
+			ProblemDetail: problem_detail.New(
+				http.StatusUnauthorized,
+				problem_detail_config.WithDetail("A client certificate is required."),
+			),
+		}
+	}
+
+	config := p.config
+
+	var roots *x509.CertPool
+	if config.Roots != nil {
+		roots = config.Roots()
+	}
+	if roots == nil {
+		return nil, &muxResponseError.ResponseError{
+			ServerError: motmedelErrors.NewWithTrace(nil_error.New("roots")),
+		}
+	}
+
+	certificate := connectionState.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range connectionState.PeerCertificates[1:] {
+		intermediates.AddCert(intermediate)
+	}
+
+	verifiedChains, err := certificate.Verify(
+		x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			CurrentTime:   config.Now(),
+		},
+	)
+	if err != nil {
+		return nil, &muxResponseError.ResponseError{
+			ClientError: motmedelErrors.NewWithTrace(fmt.Errorf("x509 certificate verify: %w", err)),
+			ProblemDetail: problem_detail.New(
+				http.StatusUnauthorized,
+				problem_detail_config.WithDetail("Invalid client certificate."),
+			),
+		}
+	}
+
+	verifiedConnectionState := *connectionState
+	verifiedConnectionState.VerifiedChains = verifiedChains
+	if httpContext, ok := request.Context().Value(muxContext.HttpContextContextKey).(*motmedelHttpTypes.HttpContext); ok && httpContext != nil {
+		tlsContext := httpContext.TlsContext
+		if tlsContext == nil {
+			tlsContext = &motmedelTlsTypes.TlsContext{}
+			httpContext.TlsContext = tlsContext
+		}
+		tlsContext.ConnectionState = &verifiedConnectionState
+	}
+
+	user, err := config.UserMapper(certificate)
+	if err != nil {
+		return nil, &muxResponseError.ResponseError{
+			ServerError: motmedelErrors.New(fmt.Errorf("user mapper: %w", err), certificate.Subject.String()),
+		}
+	}
+	if user == nil {
+		return nil, &muxResponseError.ResponseError{
+			ClientError: motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: no user for the client certificate", motmedelErrors.ErrValidationError),
+				certificate.Subject.String(),
+			),
+			ProblemDetail: problem_detail.New(
+				http.StatusForbidden,
+				problem_detail_config.WithDetail("The client certificate is not allowed to access this resource."),
+			),
+		}
+	}
+
+	return &Client{Certificate: certificate, VerifiedChains: verifiedChains, User: user}, nil
+}
+
+func New(options ...client_certificate_verifier_config.Option) (*Parser, error) {
+	config := client_certificate_verifier_config.New(options...)
+
+	if config.Roots == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("roots"))
+	}
+
+	if config.UserMapper == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("user mapper"))
+	}
+
+	return &Parser{config: config}, nil
+}
@@ -0,0 +1,86 @@
+package client_certificate_verifier_config
+
+import (
+	"crypto/x509"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/schema"
+)
+
+// UserMapper maps a verified client certificate to the user it identifies. A certificate that
+// identifies no user allowed in is mapped to nil.
+type UserMapper func(certificate *x509.Certificate) (*schema.User, error)
+
+// DefaultUserMapper identifies the user by the certificate's subject alternative names and common
+// name: the first URI -- a SPIFFE ID, commonly -- is the id, the first email address the email, and
+// the common name, or failing it the first DNS name, the name.
+func DefaultUserMapper(certificate *x509.Certificate) (*schema.User, error) {
+	if certificate == nil {
+		return nil, nil
+	}
+
+	user := &schema.User{Name: certificate.Subject.CommonName}
+	if len(certificate.URIs) != 0 {
+		user.Id = certificate.URIs[0].String()
+	}
+	if len(certificate.EmailAddresses) != 0 {
+		user.Email = certificate.EmailAddresses[0]
+	}
+	if user.Name == "" && len(certificate.DNSNames) != 0 {
+		user.Name = certificate.DNSNames[0]
+	}
+
+	if user.Id == "" && user.Email == "" && user.Name == "" {
+		return nil, nil
+	}
+
+	return user, nil
+}
+
+type Config struct {
+	// Roots returns the certificate authorities a client certificate is verified against, asked
+	// for each request so that a pool that is reloaded is verified against as it is then.
+	Roots      func() *x509.CertPool
+	UserMapper UserMapper
+	Now        func() time.Time
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		UserMapper: DefaultUserMapper,
+		Now:        time.Now,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	return config
+}
+
+func WithRoots(roots *x509.CertPool) Option {
+	return func(config *Config) {
+		config.Roots = func() *x509.CertPool { return roots }
+	}
+}
+
+// WithRootsFunction verifies against the pool the function returns at the time of each request, as
+// certificate_reloader.Reloader.ClientCas does.
+func WithRootsFunction(roots func() *x509.CertPool) Option {
+	return func(config *Config) {
+		config.Roots = roots
+	}
+}
+
+func WithUserMapper(userMapper UserMapper) Option {
+	return func(config *Config) {
+		config.UserMapper = userMapper
+	}
+}
+
+func WithNow(now func() time.Time) Option {
+	return func(config *Config) {
+		config.Now = now
+	}
+}
@@ -0,0 +1,108 @@
+package client_certificate_verifier_config
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/schema"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.Roots != nil {
+		t.Error("expected no roots by default")
+	}
+	if config.UserMapper == nil || config.Now == nil {
+		t.Error("expected a default user mapper and now function")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	roots := x509.NewCertPool()
+	now := time.Unix(1, 0)
+	user := &schema.User{Name: "mapped"}
+
+	config := New(
+		WithRoots(roots),
+		WithUserMapper(func(*x509.Certificate) (*schema.User, error) { return user, nil }),
+		WithNow(func() time.Time { return now }),
+	)
+
+	if config.Roots() != roots {
+		t.Error("expected the roots")
+	}
+	if mapped, _ := config.UserMapper(nil); mapped != user {
+		t.Errorf("user mapper: got %v", mapped)
+	}
+	if !config.Now().Equal(now) {
+		t.Errorf("now: got %v", config.Now())
+	}
+
+	other := x509.NewCertPool()
+	if New(WithRootsFunction(func() *x509.CertPool { return other })).Roots() != other {
+		t.Error("expected the roots function")
+	}
+}
+
+func TestDefaultUserMapper(t *testing.T) {
+	t.Parallel()
+
+	spiffeId, _ := url.Parse("spiffe://example.com/service")
+
+	testCases := []struct {
+		name        string
+		certificate *x509.Certificate
+		want        *schema.User
+	}{
+		{
+			name: "all names",
+			certificate: &x509.Certificate{
+				Subject:        pkix.Name{CommonName: "client"},
+				URIs:           []*url.URL{spiffeId},
+				EmailAddresses: []string{"client@example.com"},
+				DNSNames:       []string{"client.example.com"},
+			},
+			want: &schema.User{Id: spiffeId.String(), Email: "client@example.com", Name: "client"},
+		},
+		{
+			name:        "dns name",
+			certificate: &x509.Certificate{DNSNames: []string{"client.example.com"}},
+			want:        &schema.User{Name: "client.example.com"},
+		},
+		{
+			name:        "no names",
+			certificate: &x509.Certificate{},
+		},
+		{
+			name: "nil",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			user, err := DefaultUserMapper(testCase.certificate)
+			if err != nil {
+				t.Fatalf("default user mapper: %v", err)
+			}
+
+			if testCase.want == nil {
+				if user != nil {
+					t.Fatalf("expected no user, got %#v", user)
+				}
+				return
+			}
+			if user == nil || user.Id != testCase.want.Id || user.Email != testCase.want.Email || user.Name != testCase.want.Name {
+				t.Errorf("got %#v, want %#v", user, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package client_certificate_verifier
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	muxContext "github.com/Motmedel/utils_go/pkg/http/mux/context"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/client_certificate_verifier/client_certificate_verifier_config"
+	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
+	"github.com/Motmedel/utils_go/pkg/schema"
+)
+
+var errMapper = errors.New("mapper error")
+
+type authority struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+func issue(t *testing.T, signer *authority, template *x509.Certificate) *authority {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa generate key: %v", err)
+	}
+
+	parent, parentKey := template, key
+	if signer != nil {
+		parent, parentKey = signer.certificate, signer.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("x509 create certificate: %v", err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509 parse certificate: %v", err)
+	}
+
+	return &authority{certificate: certificate, key: key}
+}
+
+func TestParser_Parse(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	newAuthority := func(name string) *authority {
+		return issue(t, nil, &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             now.Add(-time.Hour),
+			NotAfter:              now.Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		})
+	}
+	trusted := newAuthority("trusted")
+	untrusted := newAuthority("untrusted")
+
+	spiffeId, _ := url.Parse("spiffe://example.com/service")
+	newClient := func(signer *authority, extKeyUsage x509.ExtKeyUsage) *x509.Certificate {
+		return issue(t, signer, &x509.Certificate{
+			SerialNumber:   big.NewInt(2),
+			Subject:        pkix.Name{CommonName: "client"},
+			EmailAddresses: []string{"client@example.com"},
+			URIs:           []*url.URL{spiffeId},
+			NotBefore:      now.Add(-time.Hour),
+			NotAfter:       now.Add(time.Hour),
+			ExtKeyUsage:    []x509.ExtKeyUsage{extKeyUsage},
+		}).certificate
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(trusted.certificate)
+
+	testCases := []struct {
+		name           string
+		certificates   []*x509.Certificate
+		plain          bool
+		options        []client_certificate_verifier_config.Option
+		wantStatusCode int
+		wantServer     bool
+		wantUser       *schema.User
+	}{
+		{
+			name:         "valid",
+			certificates: []*x509.Certificate{newClient(trusted, x509.ExtKeyUsageClientAuth)},
+			wantUser:     &schema.User{Id: spiffeId.String(), Email: "client@example.com", Name: "client"},
+		},
+		{
+			name:           "plain http",
+			plain:          true,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "no certificate",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "untrusted",
+			certificates:   []*x509.Certificate{newClient(untrusted, x509.ExtKeyUsageClientAuth)},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "server certificate",
+			certificates:   []*x509.Certificate{newClient(trusted, x509.ExtKeyUsageServerAuth)},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "expired",
+			certificates: []*x509.Certificate{newClient(trusted, x509.ExtKeyUsageClientAuth)},
+			options: []client_certificate_verifier_config.Option{
+				client_certificate_verifier_config.WithNow(func() time.Time { return now.Add(2 * time.Hour) }),
+			},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "not mapped",
+			certificates: []*x509.Certificate{newClient(trusted, x509.ExtKeyUsageClientAuth)},
+			options: []client_certificate_verifier_config.Option{
+				client_certificate_verifier_config.WithUserMapper(func(*x509.Certificate) (*schema.User, error) { return nil, nil }),
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:         "mapper error",
+			certificates: []*x509.Certificate{newClient(trusted, x509.ExtKeyUsageClientAuth)},
+			options: []client_certificate_verifier_config.Option{
+				client_certificate_verifier_config.WithUserMapper(func(*x509.Certificate) (*schema.User, error) { return nil, errMapper }),
+			},
+			wantServer: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			parser, err := New(
+				append(
+					[]client_certificate_verifier_config.Option{client_certificate_verifier_config.WithRoots(roots)},
+					testCase.options...,
+				)...,
+			)
+			if err != nil {
+				t.Fatalf("new: %v", err)
+			}
+
+			httpContext := &motmedelHttpTypes.HttpContext{}
+			request := httptest.NewRequestWithContext(
+				context.WithValue(t.Context(), muxContext.HttpContextContextKey, httpContext),
+				http.MethodGet,
+				"https://example.com/",
+				nil,
+			)
+			if testCase.plain {
+				request.TLS = nil
+			} else {
+				request.TLS = &tls.ConnectionState{PeerCertificates: testCase.certificates}
+			}
+
+			client, responseError := parser.Parse(request)
+
+			switch {
+			case testCase.wantServer:
+				if responseError == nil || responseError.ServerError == nil {
+					t.Fatalf("expected a server error, got %#v", responseError)
+				}
+				return
+			case testCase.wantStatusCode != 0:
+				if responseError == nil || responseError.ProblemDetail == nil {
+					t.Fatalf("expected a problem detail, got %#v", responseError)
+				}
+				if responseError.ProblemDetail.Status != testCase.wantStatusCode {
+					t.Fatalf("status: got %d, want %d", responseError.ProblemDetail.Status, testCase.wantStatusCode)
+				}
+				return
+			}
+
+			if responseError != nil {
+				t.Fatalf("parse: %#v", responseError)
+			}
+			if user := client.GetUser(); user.Id != testCase.wantUser.Id || user.Email != testCase.wantUser.Email || user.Name != testCase.wantUser.Name {
+				t.Errorf("user: got %#v", user)
+			}
+			if len(client.VerifiedChains) != 1 {
+				t.Errorf("expected one verified chain, got %d", len(client.VerifiedChains))
+			}
+
+			tlsContext := httpContext.TlsContext
+			if tlsContext == nil || tlsContext.ConnectionState == nil || len(tlsContext.ConnectionState.VerifiedChains) != 1 {
+				t.Fatalf("expected the verified chain to be recorded, got %#v", tlsContext)
+			}
+			if tlsContext.ClientInitiated {
+				t.Error("expected the connection to be recorded as the server's")
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(); err == nil {
+		t.Error("expected an error without roots")
+	}
+
+	if _, err := New(
+		client_certificate_verifier_config.WithRoots(x509.NewCertPool()),
+		client_certificate_verifier_config.WithUserMapper(nil),
+	); err == nil {
+		t.Error("expected an error without a user mapper")
+	}
+}
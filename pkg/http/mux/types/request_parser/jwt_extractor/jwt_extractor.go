@@ -108,6 +108,12 @@ func (p *Parser[T]) Parse(request *http.Request) (*authenticated_token.Token, *m
 	return nil, &muxResponseError.ResponseError{ServerError: errors.Join(authenticatorErrs...)}
 }
 
+// GetTokenExtractor returns the parser the token is extracted with, for code that describes the
+// parser by where the token is read from.
+func (p *Parser[T]) GetTokenExtractor() request_parser.RequestParser[string] {
+	return p.TokenExtractor
+}
+
 func New[T request_parser.RequestParser[string]](
 	tokenExtractor T,
 	authenticators ...authenticatorPkg.Authenticator[*authenticated_token.Token, string],
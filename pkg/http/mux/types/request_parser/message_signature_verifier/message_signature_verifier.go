@@ -0,0 +1,190 @@
+package message_signature_verifier
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	motmedelHttpErrors "github.com/Motmedel/utils_go/pkg/http/errors"
+	"github.com/Motmedel/utils_go/pkg/http/message_signature"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/message_signature_verifier/message_signature_verifier_config"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	"github.com/Motmedel/utils_go/pkg/http/types/content_digest"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail/problem_detail_config"
+	"github.com/Motmedel/utils_go/pkg/utils"
+)
+
+var errServer = errors.New("server error")
+
+func makeClientError(statusCode int, detail string, err error) *muxResponseError.ResponseError {
+	return &muxResponseError.ResponseError{
+		ClientError:   err,
+		ProblemDetail: problem_detail.New(statusCode, problem_detail_config.WithDetail(detail)),
+	}
+}
+
+// Parser authenticates requests by their HTTP message signatures (RFC 9421), producing the signature
+// that was verified.
+//
+// A signature is verified if it covers the required components -- content-digest among them when the
+// request has a body, whose Content-Digest (RFC 9530) is then verified too -- is not older than the
+// max age or expired, and is made by the key its keyid names.
+type Parser struct {
+	KeyHandler message_signature.KeyHandler
+	config     *message_signature_verifier_config.Config
+}
+
+func hasBody(request *http.Request) bool {
+	return request.ContentLength > 0 || (request.ContentLength < 0 && request.Body != nil && request.Body != http.NoBody)
+}
+
+// readBody reads the body of the request, replacing it with one that yields what was read.
+func (p *Parser) readBody(request *http.Request) ([]byte, *muxResponseError.ResponseError) {
+	if request.Body == nil {
+		return nil, nil
+	}
+
+	maxBodySize := p.config.MaxBodySize
+	body, err := io.ReadAll(io.LimitReader(request.Body, maxBodySize+1))
+	if err != nil {
+		return nil, &muxResponseError.ResponseError{
+			ServerError: motmedelErrors.NewWithTrace(fmt.Errorf("io read all (request body): %w", err)),
+		}
+	}
+	if int64(len(body)) > maxBodySize {
+		return nil, makeClientError(http.StatusRequestEntityTooLarge, "The body is too large to verify.", nil)
+	}
+
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// check checks a signature against the policy and verifies it. Errors that are not the client's fault
+// are wrapped with errServer.
+func (p *Parser) check(request *http.Request, signature *message_signature.Signature) error {
+	config := p.config
+
+	requiredComponents := config.RequiredComponents
+	if hasBody(request) {
+		requiredComponents = append(requiredComponents[:len(requiredComponents):len(requiredComponents)], message_signature.ContentDigestComponent)
+	}
+	for _, component := range requiredComponents {
+		if !signature.Covers(component) {
+			return motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: %s", motmedelHttpErrors.ErrUncoveredComponent, component),
+				signature.Components,
+			)
+		}
+	}
+
+	now := config.Now()
+	if maxAge := config.MaxAge; maxAge > 0 {
+		created := signature.Created
+		if created.IsZero() || now.Sub(created) > maxAge || created.Sub(now) > config.MaxClockSkew {
+			return motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: created %v", motmedelHttpErrors.ErrSignatureExpired, created),
+			)
+		}
+	}
+	if expires := signature.Expires; !expires.IsZero() && now.Sub(expires) > config.MaxClockSkew {
+		return motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: expires %v", motmedelHttpErrors.ErrSignatureExpired, expires),
+		)
+	}
+
+	if signature.KeyId == "" {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("%w: no keyid", motmedelErrors.ErrVerificationError))
+	}
+
+	verifier, err := p.KeyHandler.GetNamedVerifier(request.Context(), signature.KeyId)
+	if err != nil {
+		return motmedelErrors.New(fmt.Errorf("%w: key handler get named verifier: %w", errServer, err), signature.KeyId)
+	}
+	if utils.IsNil(verifier) {
+		return motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: unknown keyid", motmedelErrors.ErrVerificationError),
+			signature.KeyId,
+		)
+	}
+
+	if err := signature.Verify(request, verifier); err != nil {
+		return fmt.Errorf("signature verify: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Parser) Parse(request *http.Request) (*message_signature.Signature, *muxResponseError.ResponseError) {
+	if request == nil {
+		return nil, &muxResponseError.ResponseError{ServerError: motmedelErrors.NewWithTrace(nil_error.New("request"))}
+	}
+	if utils.IsNil(p.KeyHandler) {
+		return nil, &muxResponseError.ResponseError{ServerError: motmedelErrors.NewWithTrace(nil_error.New("key handler"))}
+	}
+
+	signatures, err := message_signature.ParseSignatures(request.Header)
+	if err != nil {
+		return nil, makeClientError(http.StatusBadRequest, "Malformed signature.", fmt.Errorf("parse signatures: %w", err))
+	}
+
+	config := p.config
+
+	var errs []error
+	for _, signature := range signatures {
+		if (config.Label != "" && signature.Label != config.Label) || (config.Tag != "" && signature.Tag != config.Tag) {
+			continue
+		}
+
+		err := p.check(request, signature)
+		if err == nil {
+			if signature.Covers(message_signature.ContentDigestComponent) {
+				body, responseError := p.readBody(request)
+				if responseError != nil {
+					return nil, responseError
+				}
+				if err := content_digest.Verify(strings.Join(request.Header.Values(content_digest.HeaderName), ", "), body); err != nil {
+					return nil, makeClientError(
+						http.StatusBadRequest,
+						"The content digest does not match the body.",
+						fmt.Errorf("content digest verify: %w", err),
+					)
+				}
+			}
+
+			return signature, nil
+		}
+		if errors.Is(err, errServer) {
+			return nil, &muxResponseError.ResponseError{ServerError: err}
+		}
+
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return nil, makeClientError(
+			http.StatusUnauthorized,
+			"Missing signature.",
+			motmedelErrors.NewWithTrace(motmedelHttpErrors.ErrMissingSignature),
+		)
+	}
+
+	return nil, makeClientError(http.StatusUnauthorized, "Invalid signature.", errors.Join(errs...))
+}
+
+func New(
+	keyHandler message_signature.KeyHandler,
+	options ...message_signature_verifier_config.Option,
+) (*Parser, error) {
+	if utils.IsNil(keyHandler) {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("key handler"))
+	}
+
+	return &Parser{KeyHandler: keyHandler, config: message_signature_verifier_config.New(options...)}, nil
+}
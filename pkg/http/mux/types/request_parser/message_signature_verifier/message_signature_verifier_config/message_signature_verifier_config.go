@@ -0,0 +1,86 @@
+package message_signature_verifier_config
+
+import "time"
+
+var (
+	// DefaultRequiredComponents are the components a signature must cover; content-digest must be
+	// covered in addition when the request has a body.
+	DefaultRequiredComponents       = []string{"@method", "@authority", "@path"}
+	DefaultMaxAge                   = 5 * time.Minute
+	DefaultMaxClockSkew             = 30 * time.Second
+	DefaultMaxBodySize        int64 = 1 << 20
+)
+
+type Config struct {
+	// Label, if set, is the label of the signature to verify; otherwise, any signature is.
+	Label string
+	// Tag, if set, is the tag a signature must have.
+	Tag                string
+	RequiredComponents []string
+	// MaxAge is how old a signature may be by its created parameter, which it must have unless
+	// MaxAge is zero.
+	MaxAge       time.Duration
+	MaxClockSkew time.Duration
+	// MaxBodySize is the size of the largest body that is read to verify its Content-Digest.
+	MaxBodySize int64
+	Now         func() time.Time
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		RequiredComponents: DefaultRequiredComponents,
+		MaxAge:             DefaultMaxAge,
+		MaxClockSkew:       DefaultMaxClockSkew,
+		MaxBodySize:        DefaultMaxBodySize,
+		Now:                time.Now,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	return config
+}
+
+func WithLabel(label string) Option {
+	return func(config *Config) {
+		config.Label = label
+	}
+}
+
+func WithTag(tag string) Option {
+	return func(config *Config) {
+		config.Tag = tag
+	}
+}
+
+func WithRequiredComponents(requiredComponents ...string) Option {
+	return func(config *Config) {
+		config.RequiredComponents = requiredComponents
+	}
+}
+
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(config *Config) {
+		config.MaxAge = maxAge
+	}
+}
+
+func WithMaxClockSkew(maxClockSkew time.Duration) Option {
+	return func(config *Config) {
+		config.MaxClockSkew = maxClockSkew
+	}
+}
+
+func WithMaxBodySize(maxBodySize int64) Option {
+	return func(config *Config) {
+		config.MaxBodySize = maxBodySize
+	}
+}
+
+func WithNow(now func() time.Time) Option {
+	return func(config *Config) {
+		config.Now = now
+	}
+}
@@ -0,0 +1,69 @@
+package message_signature_verifier_config
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.Label != "" || config.Tag != "" {
+		t.Errorf("expected no label or tag, got %q %q", config.Label, config.Tag)
+	}
+	if !slices.Equal(config.RequiredComponents, DefaultRequiredComponents) {
+		t.Errorf("required components: got %v", config.RequiredComponents)
+	}
+	if config.MaxAge != DefaultMaxAge {
+		t.Errorf("max age: got %v", config.MaxAge)
+	}
+	if config.MaxClockSkew != DefaultMaxClockSkew {
+		t.Errorf("max clock skew: got %v", config.MaxClockSkew)
+	}
+	if config.MaxBodySize != DefaultMaxBodySize {
+		t.Errorf("max body size: got %d", config.MaxBodySize)
+	}
+	if config.Now == nil {
+		t.Error("expected a default now function")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1, 0)
+
+	config := New(
+		WithLabel("sig1"),
+		WithTag("app"),
+		WithRequiredComponents("@method"),
+		WithMaxAge(time.Hour),
+		WithMaxClockSkew(time.Second),
+		WithMaxBodySize(10),
+		WithNow(func() time.Time { return now }),
+	)
+
+	if config.Label != "sig1" {
+		t.Errorf("label: got %q", config.Label)
+	}
+	if config.Tag != "app" {
+		t.Errorf("tag: got %q", config.Tag)
+	}
+	if !slices.Equal(config.RequiredComponents, []string{"@method"}) {
+		t.Errorf("required components: got %v", config.RequiredComponents)
+	}
+	if config.MaxAge != time.Hour {
+		t.Errorf("max age: got %v", config.MaxAge)
+	}
+	if config.MaxClockSkew != time.Second {
+		t.Errorf("max clock skew: got %v", config.MaxClockSkew)
+	}
+	if config.MaxBodySize != 10 {
+		t.Errorf("max body size: got %d", config.MaxBodySize)
+	}
+	if !config.Now().Equal(now) {
+		t.Errorf("now: got %v", config.Now())
+	}
+}
@@ -37,6 +37,16 @@ func (p *Parser) Parse(request *http.Request) (string, *response_error.ResponseE
 	return headerValue, nil
 }
 
+// GetHeaderName returns the name of the header the token is extracted from.
+func (p *Parser) GetHeaderName() string {
+	return p.config.HeaderName
+}
+
+// GetHeaderValuePrefix returns the prefix stripped from the header value, such as "Bearer ".
+func (p *Parser) GetHeaderValuePrefix() string {
+	return p.config.HeaderValuePrefix
+}
+
 func New(options ...token_header_extractor_config.Option) *Parser {
 	return &Parser{config: token_header_extractor_config.New(options...)}
 }
@@ -0,0 +1,395 @@
+package response_cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	motmedelContext "github.com/Motmedel/utils_go/pkg/context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/middleware"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_cache/response_cache_config"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_cache/store"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	muxResponseWriter "github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
+	"github.com/Motmedel/utils_go/pkg/http/types/cache_control"
+	motmedelHttpUtils "github.com/Motmedel/utils_go/pkg/http/utils"
+)
+
+// cacheableStatusCodes are the status codes that are cacheable by default (RFC 9110, Section 15.1).
+var cacheableStatusCodes = map[int]struct{}{
+	http.StatusOK:                   {},
+	http.StatusNonAuthoritativeInfo: {},
+	http.StatusNoContent:            {},
+	http.StatusMultipleChoices:      {},
+	http.StatusMovedPermanently:     {},
+	http.StatusPermanentRedirect:    {},
+	http.StatusNotFound:             {},
+	http.StatusMethodNotAllowed:     {},
+	http.StatusGone:                 {},
+	http.StatusRequestURITooLong:    {},
+	http.StatusNotImplemented:       {},
+}
+
+// notModifiedHeaderNames are the headers a 304 response repeats from the response it stands in for
+// (RFC 9110, Section 15.4.5).
+var notModifiedHeaderNames = map[string]struct{}{
+	"Cache-Control":    {},
+	"Content-Location": {},
+	"Date":             {},
+	"Etag":             {},
+	"Expires":          {},
+	"Last-Modified":    {},
+	"Vary":             {},
+}
+
+func getHeaderValues(headers []*muxResponse.HeaderEntry, name string) []string {
+	var values []string
+	for _, header := range headers {
+		if header != nil && http.CanonicalHeaderKey(header.Name) == name {
+			values = append(values, header.Value)
+		}
+	}
+	return values
+}
+
+func parseCacheControl(values []string) *motmedelHttpTypes.CacheControl {
+	if len(values) == 0 {
+		return nil
+	}
+
+	cacheControl, err := cache_control.Parse([]byte(strings.Join(values, ", ")))
+	if err != nil {
+		return nil
+	}
+
+	return cacheControl
+}
+
+func parseVary(values []string) []string {
+	var names []string
+	for _, value := range values {
+		for name := range strings.SplitSeq(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// parseEntityTags returns the opaque tags of a list of entity tags, with the weakness indicators
+// removed, and whether the list is "*".
+func parseEntityTags(value string) ([]string, bool) {
+	if strings.TrimSpace(value) == "*" {
+		return nil, true
+	}
+
+	var tags []string
+	for i := 0; i < len(value); {
+		switch {
+		case value[i] == ' ' || value[i] == '\t' || value[i] == ',':
+			i++
+			continue
+		case strings.HasPrefix(value[i:], "W/"):
+			i += 2
+		}
+
+		if i >= len(value) || value[i] != '"' {
+			return tags, false
+		}
+		end := strings.IndexByte(value[i+1:], '"')
+		if end < 0 {
+			return tags, false
+		}
+		tags = append(tags, value[i:i+end+2])
+		i += end + 2
+	}
+
+	return tags, false
+}
+
+// getStatusCode returns the status code of a response, which is 200 when it is not set.
+func getStatusCode(response *muxResponse.Response) int {
+	if response.StatusCode == 0 {
+		return http.StatusOK
+	}
+
+	return response.StatusCode
+}
+
+// isNotModified evaluates the If-None-Match and If-Modified-Since preconditions of a request
+// against a response (RFC 9110, Section 13.2.2).
+func isNotModified(requestHeader http.Header, response *muxResponse.Response) bool {
+	if getStatusCode(response) != http.StatusOK {
+		return false
+	}
+
+	if ifNoneMatch := requestHeader.Values("If-None-Match"); len(ifNoneMatch) != 0 {
+		etags := getHeaderValues(response.Headers, "Etag")
+		if len(etags) == 0 {
+			return false
+		}
+		etag := strings.TrimPrefix(etags[0], "W/")
+
+		tags, isAny := parseEntityTags(strings.Join(ifNoneMatch, ", "))
+		return isAny || slices.Contains(tags, etag)
+	}
+
+	lastModified := getHeaderValues(response.Headers, "Last-Modified")
+	if len(lastModified) == 0 {
+		return false
+	}
+
+	// NOTE: An If-Modified-Since that is not a valid date is ignored.
+	isCached, _ := motmedelHttpUtils.IfModifiedSinceCacheHit(requestHeader.Get("If-Modified-Since"), lastModified[0])
+	return isCached
+}
+
+func makeNotModifiedResponse(response *muxResponse.Response) *muxResponse.Response {
+	notModifiedResponse := &muxResponse.Response{StatusCode: http.StatusNotModified}
+	for _, header := range response.Headers {
+		if header == nil {
+			continue
+		}
+		if _, ok := notModifiedHeaderNames[http.CanonicalHeaderKey(header.Name)]; ok {
+			notModifiedResponse.Headers = append(notModifiedResponse.Headers, header)
+		}
+	}
+
+	return notModifiedResponse
+}
+
+// Cache is a server-side cache of the responses of the endpoints whose middleware it is part of.
+//
+// A response is cached, keyed by the method, path and query of the request and the request headers
+// it varies by, if it is to a GET or HEAD request, has a status code that is cacheable by default
+// and a body that is not streamed, and is fresh by its Cache-Control -- which must not contain
+// no-store, no-cache or private, and which must contain public, s-maxage or must-revalidate if the
+// request has an Authorization header, and public if it has a Cookie header, the key having no
+// principal in it. A response that sets a cookie, or that embeds a Content Security Policy nonce,
+// is not cached. A request may bypass the cache with the no-cache, no-store or max-age=0
+// Cache-Control directives.
+//
+// The Cache-Control of a response reaches the client only if its header entry has Overwrite set,
+// the response writer having a default one.
+//
+// A response with a body that has no ETag is given a weak one, and a conditional request that
+// the response satisfies is answered with 304, whether it is cached or not.
+type Cache struct {
+	config *response_cache_config.Config
+}
+
+func (cache *Cache) makeKey(request *http.Request) string {
+	method := request.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+
+	var path, query string
+	if requestUrl := request.URL; requestUrl != nil {
+		path = requestUrl.Path
+		query = requestUrl.Query().Encode()
+	}
+
+	return cache.config.KeyPrefix + method + " " + path + "?" + query
+}
+
+func makeVariantKey(key string, vary []string, requestHeader http.Header) string {
+	var builder strings.Builder
+	builder.WriteString(key)
+	for _, name := range vary {
+		builder.WriteString("\n")
+		builder.WriteString(name)
+		builder.WriteString(": ")
+		builder.WriteString(strings.Join(requestHeader.Values(name), ", "))
+	}
+
+	return builder.String()
+}
+
+func (cache *Cache) get(ctx context.Context, key string, now time.Time) *store.Entry {
+	entry, err := cache.config.Store.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(
+			motmedelContext.WithError(ctx, motmedelErrors.New(fmt.Errorf("store get: %w", err), key)),
+			"An error occurred when reading from the response cache.",
+		)
+		return nil
+	}
+	if entry == nil || !now.Before(entry.Expires) {
+		return nil
+	}
+
+	return entry
+}
+
+func (cache *Cache) set(ctx context.Context, key string, entry *store.Entry, ttl time.Duration) {
+	if err := cache.config.Store.Set(ctx, key, entry, ttl); err != nil {
+		slog.WarnContext(
+			motmedelContext.WithError(ctx, motmedelErrors.New(fmt.Errorf("store set: %w", err), key)),
+			"An error occurred when writing to the response cache.",
+		)
+	}
+}
+
+func (cache *Cache) lookup(ctx context.Context, key string, requestHeader http.Header, now time.Time) *store.Entry {
+	entry := cache.get(ctx, key, now)
+	if entry == nil || len(entry.Vary) == 0 {
+		return entry
+	}
+
+	return cache.get(ctx, makeVariantKey(key, entry.Vary, requestHeader), now)
+}
+
+// getTtl returns for how long a response is to be cached, which is not positive if it is not to be.
+func (cache *Cache) getTtl(request *http.Request, response *muxResponse.Response) time.Duration {
+	if _, ok := cacheableStatusCodes[getStatusCode(response)]; !ok {
+		return 0
+	}
+	if response.BodyStreamer != nil || int64(len(response.Body)) > cache.config.MaxEntrySize {
+		return 0
+	}
+	if len(getHeaderValues(response.Headers, "Set-Cookie")) != 0 {
+		return 0
+	}
+
+	cacheControlValues := getHeaderValues(response.Headers, "Cache-Control")
+	cacheControl := parseCacheControl(cacheControlValues)
+	if cacheControl == nil {
+		if len(cacheControlValues) != 0 {
+			return 0
+		}
+		cacheControl = &motmedelHttpTypes.CacheControl{}
+	}
+
+	if cacheControl.NoStore() || cacheControl.NoCache() || cacheControl.Private() {
+		return 0
+	}
+
+	// A cookie may authenticate the request, as a session or token cookie does, and only a response
+	// that says it is public is known to be the same for all users.
+	if request.Header.Get("Cookie") != "" && !cacheControl.Public() {
+		return 0
+	}
+
+	sMaxAge, sMaxAgeErr := cacheControl.SMaxAge()
+	if request.Header.Get("Authorization") != "" {
+		if !cacheControl.Public() && sMaxAgeErr != nil && !cacheControl.MustRevalidate() {
+			return 0
+		}
+	}
+
+	if sMaxAgeErr == nil {
+		return time.Duration(sMaxAge) * time.Second
+	}
+	if maxAge, err := cacheControl.MaxAge(); err == nil {
+		return time.Duration(maxAge) * time.Second
+	}
+
+	return cache.config.DefaultTtl
+}
+
+// Middleware is a handler middleware that serves the responses of the handler it wraps from the
+// cache.
+func (cache *Cache) Middleware(next middleware.Handler) middleware.Handler {
+	return func(
+		request *http.Request,
+		responseWriter *muxResponseWriter.ResponseWriter,
+	) (*muxResponse.Response, *muxResponseError.ResponseError) {
+		if request == nil || (request.Method != http.MethodGet && request.Method != http.MethodHead) {
+			return next(request, responseWriter)
+		}
+
+		ctx := request.Context()
+		requestHeader := request.Header
+		now := cache.config.Now()
+		key := cache.makeKey(request)
+
+		var bypass, noStore bool
+		if requestCacheControl := parseCacheControl(requestHeader.Values("Cache-Control")); requestCacheControl != nil {
+			maxAge, err := requestCacheControl.MaxAge()
+			noStore = requestCacheControl.NoStore()
+			bypass = noStore || requestCacheControl.NoCache() || (err == nil && maxAge == 0)
+		}
+
+		if !bypass {
+			if entry := cache.lookup(ctx, key, requestHeader, now); entry != nil {
+				response := &muxResponse.Response{
+					StatusCode: entry.StatusCode,
+					Headers: append(
+						slices.Clone(entry.Headers),
+						&muxResponse.HeaderEntry{
+							Name:  "Age",
+							Value: strconv.FormatInt(int64(now.Sub(entry.Stored)/time.Second), 10),
+						},
+					),
+					Body: entry.Body,
+				}
+				if isNotModified(requestHeader, response) {
+					return makeNotModifiedResponse(response), nil
+				}
+				return response, nil
+			}
+		}
+
+		response, responseError := next(request, responseWriter)
+		if responseError != nil || response == nil || response.BodyStreamer != nil {
+			return response, responseError
+		}
+
+		// A response that embeds a Content Security Policy nonce is of the request it was made for.
+		if responseWriter != nil {
+			if _, ok := responseWriter.CspNonce.Generated(); ok {
+				return response, nil
+			}
+		}
+
+		response.Headers = slices.Clone(response.Headers)
+		if len(response.Body) != 0 && len(getHeaderValues(response.Headers, "Etag")) == 0 {
+			response.Headers = append(
+				response.Headers,
+				// The tag is weak: the response writer may encode the body per the Accept-Encoding of
+				// a request, and a strong tag would then stand for several representations.
+				&muxResponse.HeaderEntry{Name: "ETag", Value: "W/" + motmedelHttpUtils.MakeStrongEtag(response.Body)},
+			)
+		}
+
+		vary := parseVary(getHeaderValues(response.Headers, "Vary"))
+		if ttl := cache.getTtl(request, response); !noStore && ttl > 0 && !slices.Contains(vary, "*") {
+			entry := &store.Entry{
+				StatusCode: response.StatusCode,
+				Headers:    slices.Clone(response.Headers),
+				Body:       response.Body,
+				Stored:     now,
+				Expires:    now.Add(ttl),
+			}
+
+			if len(vary) == 0 {
+				cache.set(ctx, key, entry, ttl)
+			} else {
+				cache.set(ctx, key, &store.Entry{Vary: vary, Stored: now, Expires: entry.Expires}, ttl)
+				cache.set(ctx, makeVariantKey(key, vary, requestHeader), entry, ttl)
+			}
+		}
+
+		if isNotModified(requestHeader, response) {
+			return makeNotModifiedResponse(response), nil
+		}
+
+		return response, nil
+	}
+}
+
+func New(options ...response_cache_config.Option) *Cache {
+	return &Cache{config: response_cache_config.New(options...)}
+}
@@ -0,0 +1,73 @@
+package response_cache_config
+
+import (
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_cache/store"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_cache/store/memory_store"
+)
+
+var (
+	DefaultMaxEntrySize int64 = 1 << 20
+)
+
+type Config struct {
+	// Store holds the cached responses; a new in-memory store is used when none is set.
+	Store store.Store
+	// KeyPrefix is prepended to the keys in the store, so that caches can share one.
+	KeyPrefix string
+	// DefaultTtl is for how long a response is fresh that states no max-age or s-maxage; a response
+	// that states neither is not cached when it is zero.
+	DefaultTtl time.Duration
+	// MaxEntrySize is the size of the largest body that is cached.
+	MaxEntrySize int64
+	Now          func() time.Time
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		MaxEntrySize: DefaultMaxEntrySize,
+		Now:          time.Now,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	if config.Store == nil {
+		config.Store = memory_store.New(memory_store.DefaultMaxEntries, memory_store.DefaultMaxBytes)
+	}
+
+	return config
+}
+
+func WithStore(store store.Store) Option {
+	return func(config *Config) {
+		config.Store = store
+	}
+}
+
+func WithKeyPrefix(keyPrefix string) Option {
+	return func(config *Config) {
+		config.KeyPrefix = keyPrefix
+	}
+}
+
+func WithDefaultTtl(defaultTtl time.Duration) Option {
+	return func(config *Config) {
+		config.DefaultTtl = defaultTtl
+	}
+}
+
+func WithMaxEntrySize(maxEntrySize int64) Option {
+	return func(config *Config) {
+		config.MaxEntrySize = maxEntrySize
+	}
+}
+
+func WithNow(now func() time.Time) Option {
+	return func(config *Config) {
+		config.Now = now
+	}
+}
@@ -0,0 +1,57 @@
+package response_cache_config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_cache/store/memory_store"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.MaxEntrySize != DefaultMaxEntrySize {
+		t.Errorf("max entry size: got %d", config.MaxEntrySize)
+	}
+	if config.DefaultTtl != 0 {
+		t.Errorf("default ttl: got %v", config.DefaultTtl)
+	}
+	if config.Now == nil {
+		t.Error("expected a default now function")
+	}
+	if _, ok := config.Store.(*memory_store.Store); !ok {
+		t.Errorf("expected a default memory store, got %T", config.Store)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	store := memory_store.New(1, 0)
+	now := time.Unix(1, 0)
+
+	config := New(
+		WithStore(store),
+		WithKeyPrefix("pages:"),
+		WithDefaultTtl(time.Minute),
+		WithMaxEntrySize(10),
+		WithNow(func() time.Time { return now }),
+	)
+
+	if config.Store != store {
+		t.Errorf("store: got %v", config.Store)
+	}
+	if config.KeyPrefix != "pages:" {
+		t.Errorf("key prefix: got %q", config.KeyPrefix)
+	}
+	if config.DefaultTtl != time.Minute {
+		t.Errorf("default ttl: got %v", config.DefaultTtl)
+	}
+	if config.MaxEntrySize != 10 {
+		t.Errorf("max entry size: got %d", config.MaxEntrySize)
+	}
+	if !config.Now().Equal(now) {
+		t.Errorf("now: got %v", config.Now())
+	}
+}
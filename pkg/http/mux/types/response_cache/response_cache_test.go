@@ -0,0 +1,331 @@
+package response_cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/csp_nonce"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/middleware"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_cache/response_cache_config"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	muxResponseWriter "github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+	motmedelHttpUtils "github.com/Motmedel/utils_go/pkg/http/utils"
+)
+
+// makeHandler returns a handler that responds with its number of calls and the headers.
+func makeHandler(calls *atomic.Int64, headers ...*muxResponse.HeaderEntry) middleware.Handler {
+	return makeStatusHandler(calls, 0, headers...)
+}
+
+// makeStatusHandler returns a handler like makeHandler's, but with a status code; handlers commonly
+// leave it unset for 200.
+func makeStatusHandler(calls *atomic.Int64, statusCode int, headers ...*muxResponse.HeaderEntry) middleware.Handler {
+	return func(*http.Request, *muxResponseWriter.ResponseWriter) (*muxResponse.Response, *muxResponseError.ResponseError) {
+		return &muxResponse.Response{
+			StatusCode: statusCode,
+			Headers:    headers,
+			Body:       []byte(strconv.FormatInt(calls.Add(1), 10)),
+		}, nil
+	}
+}
+
+func cacheControl(value string) *muxResponse.HeaderEntry {
+	return &muxResponse.HeaderEntry{Name: "Cache-Control", Value: value, Overwrite: true}
+}
+
+func TestCache_Middleware(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		statusCode     int
+		headers        []*muxResponse.HeaderEntry
+		options        []response_cache_config.Option
+		requestHeaders [][2]string
+		secondTarget   string
+		secondHeaders  [][2]string
+		wantCached     bool
+	}{
+		{name: "max-age", headers: []*muxResponse.HeaderEntry{cacheControl("public, max-age=60")}, wantCached: true},
+		{
+			name:       "status code 200",
+			statusCode: http.StatusOK,
+			headers:    []*muxResponse.HeaderEntry{cacheControl("max-age=60")},
+			wantCached: true,
+		},
+		{
+			name:       "status code 404",
+			statusCode: http.StatusNotFound,
+			headers:    []*muxResponse.HeaderEntry{cacheControl("max-age=60")},
+			wantCached: true,
+		},
+		{
+			name:       "status code 201",
+			statusCode: http.StatusCreated,
+			headers:    []*muxResponse.HeaderEntry{cacheControl("max-age=60")},
+		},
+		{name: "s-maxage", headers: []*muxResponse.HeaderEntry{cacheControl("s-maxage=60")}, wantCached: true},
+		{name: "no freshness", headers: nil},
+		{name: "default ttl", options: []response_cache_config.Option{response_cache_config.WithDefaultTtl(time.Minute)}, wantCached: true},
+		{name: "no-store", headers: []*muxResponse.HeaderEntry{cacheControl("no-store, max-age=60")}},
+		{name: "private", headers: []*muxResponse.HeaderEntry{cacheControl("private, max-age=60")}},
+		{name: "no-cache", headers: []*muxResponse.HeaderEntry{cacheControl("no-cache, max-age=60")}},
+		{
+			name:    "set-cookie",
+			headers: []*muxResponse.HeaderEntry{cacheControl("max-age=60"), {Name: "Set-Cookie", Value: "a=b"}},
+		},
+		{
+			name:           "authorization",
+			headers:        []*muxResponse.HeaderEntry{cacheControl("max-age=60")},
+			requestHeaders: [][2]string{{"Authorization", "Bearer x"}},
+		},
+		{
+			name:           "authorization and public",
+			headers:        []*muxResponse.HeaderEntry{cacheControl("public, max-age=60")},
+			requestHeaders: [][2]string{{"Authorization", "Bearer x"}},
+			wantCached:     true,
+		},
+		{
+			name:           "cookie",
+			headers:        []*muxResponse.HeaderEntry{cacheControl("max-age=60")},
+			requestHeaders: [][2]string{{"Cookie", "session=x"}},
+		},
+		{
+			name:           "cookie and s-maxage",
+			headers:        []*muxResponse.HeaderEntry{cacheControl("s-maxage=60")},
+			requestHeaders: [][2]string{{"Cookie", "session=x"}},
+		},
+		{
+			name:           "cookie and public",
+			headers:        []*muxResponse.HeaderEntry{cacheControl("public, max-age=60")},
+			requestHeaders: [][2]string{{"Cookie", "session=x"}},
+			wantCached:     true,
+		},
+		{
+			name:          "request no-cache",
+			headers:       []*muxResponse.HeaderEntry{cacheControl("max-age=60")},
+			secondHeaders: [][2]string{{"Cache-Control", "no-cache"}},
+		},
+		{
+			name:          "request max-age=0",
+			headers:       []*muxResponse.HeaderEntry{cacheControl("max-age=60")},
+			secondHeaders: [][2]string{{"Cache-Control", "max-age=0"}},
+		},
+		{
+			name:         "query order",
+			headers:      []*muxResponse.HeaderEntry{cacheControl("max-age=60")},
+			secondTarget: "/items?b=2&a=1",
+			wantCached:   true,
+		},
+		{
+			name:         "other query",
+			headers:      []*muxResponse.HeaderEntry{cacheControl("max-age=60")},
+			secondTarget: "/items?a=2&b=2",
+		},
+		{
+			name:           "same vary",
+			headers:        []*muxResponse.HeaderEntry{cacheControl("max-age=60"), {Name: "Vary", Value: "Accept-Language"}},
+			requestHeaders: [][2]string{{"Accept-Language", "sv"}},
+			secondHeaders:  [][2]string{{"Accept-Language", "sv"}},
+			wantCached:     true,
+		},
+		{
+			name:           "other vary",
+			headers:        []*muxResponse.HeaderEntry{cacheControl("max-age=60"), {Name: "Vary", Value: "Accept-Language"}},
+			requestHeaders: [][2]string{{"Accept-Language", "sv"}},
+			secondHeaders:  [][2]string{{"Accept-Language", "en"}},
+		},
+		{
+			name:    "vary star",
+			headers: []*muxResponse.HeaderEntry{cacheControl("max-age=60"), {Name: "Vary", Value: "*"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int64
+			handler := New(testCase.options...).Middleware(
+				makeStatusHandler(&calls, testCase.statusCode, testCase.headers...),
+			)
+
+			request := httptest.NewRequest(http.MethodGet, "/items?a=1&b=2", nil)
+			for _, header := range testCase.requestHeaders {
+				request.Header.Set(header[0], header[1])
+			}
+			if _, responseError := handler(request, nil); responseError != nil {
+				t.Fatalf("unexpected response error: %v", responseError)
+			}
+
+			secondTarget := testCase.secondTarget
+			if secondTarget == "" {
+				secondTarget = "/items?a=1&b=2"
+			}
+			secondRequest := httptest.NewRequest(http.MethodGet, secondTarget, nil)
+			for _, header := range testCase.requestHeaders {
+				secondRequest.Header.Set(header[0], header[1])
+			}
+			for _, header := range testCase.secondHeaders {
+				secondRequest.Header.Set(header[0], header[1])
+			}
+			response, _ := handler(secondRequest, nil)
+
+			if cached := string(response.Body) == "1"; cached != testCase.wantCached {
+				t.Errorf("cached: got %t, want %t", cached, testCase.wantCached)
+			}
+			if testCase.wantCached && len(getHeaderValues(response.Headers, "Age")) != 1 {
+				t.Errorf("expected an Age header, got %v", response.Headers)
+			}
+		})
+	}
+}
+
+func TestCache_Middleware_Expiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_000_000, 0)
+	var calls atomic.Int64
+	handler := New(response_cache_config.WithNow(func() time.Time { return now })).Middleware(
+		makeHandler(&calls, cacheControl("max-age=10")),
+	)
+
+	handler(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+
+	now = now.Add(9 * time.Second)
+	response, _ := handler(httptest.NewRequest(http.MethodHead, "/", nil), nil)
+	if string(response.Body) != "1" {
+		t.Fatalf("expected a cached response for a HEAD request, got %q", response.Body)
+	}
+	if age := getHeaderValues(response.Headers, "Age"); len(age) != 1 || age[0] != "9" {
+		t.Errorf("age: got %v", age)
+	}
+
+	now = now.Add(time.Second)
+	if response, _ := handler(httptest.NewRequest(http.MethodGet, "/", nil), nil); string(response.Body) != "2" {
+		t.Errorf("expected an expired response to be produced anew, got %q", response.Body)
+	}
+}
+
+func TestCache_Middleware_Conditional(t *testing.T) {
+	t.Parallel()
+
+	lastModified := time.Unix(1_000_000, 0).UTC().Format(http.TimeFormat)
+	strongEtag := motmedelHttpUtils.MakeStrongEtag([]byte("1"))
+	etag := "W/" + strongEtag
+
+	testCases := []struct {
+		name           string
+		statusCode     int
+		requestHeaders [][2]string
+		wantStatusCode int
+	}{
+		{name: "unconditional", wantStatusCode: http.StatusOK},
+		{
+			name:           "if-none-match status code 200",
+			statusCode:     http.StatusOK,
+			requestHeaders: [][2]string{{"If-None-Match", etag}},
+			wantStatusCode: http.StatusNotModified,
+		},
+		{
+			name:           "if-none-match status code 404",
+			statusCode:     http.StatusNotFound,
+			requestHeaders: [][2]string{{"If-None-Match", etag}},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{name: "if-none-match", requestHeaders: [][2]string{{"If-None-Match", etag}}, wantStatusCode: http.StatusNotModified},
+		{name: "if-none-match strong", requestHeaders: [][2]string{{"If-None-Match", `"x", ` + strongEtag}}, wantStatusCode: http.StatusNotModified},
+		{name: "if-none-match star", requestHeaders: [][2]string{{"If-None-Match", "*"}}, wantStatusCode: http.StatusNotModified},
+		{name: "if-none-match other", requestHeaders: [][2]string{{"If-None-Match", `"x"`}}, wantStatusCode: http.StatusOK},
+		{name: "if-modified-since", requestHeaders: [][2]string{{"If-Modified-Since", lastModified}}, wantStatusCode: http.StatusNotModified},
+		{
+			name:           "if-none-match before if-modified-since",
+			requestHeaders: [][2]string{{"If-None-Match", `"x"`}, {"If-Modified-Since", lastModified}},
+			wantStatusCode: http.StatusOK,
+		},
+		{name: "bad if-modified-since", requestHeaders: [][2]string{{"If-Modified-Since", "x"}}, wantStatusCode: http.StatusOK},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int64
+			handler := New().Middleware(
+				makeStatusHandler(
+					&calls,
+					testCase.statusCode,
+					cacheControl("no-cache"),
+					&muxResponse.HeaderEntry{Name: "Last-Modified", Value: lastModified},
+				),
+			)
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, header := range testCase.requestHeaders {
+				request.Header.Set(header[0], header[1])
+			}
+
+			response, _ := handler(request, nil)
+			if statusCode := getStatusCode(response); statusCode != testCase.wantStatusCode {
+				t.Fatalf("status code: got %d, want %d", statusCode, testCase.wantStatusCode)
+			}
+			if etags := getHeaderValues(response.Headers, "Etag"); len(etags) != 1 || etags[0] != etag {
+				t.Errorf("etag: got %v", etags)
+			}
+			if response.StatusCode == http.StatusNotModified {
+				if len(response.Body) != 0 || len(getHeaderValues(response.Headers, "Cache-Control")) != 1 {
+					t.Errorf("unexpected not modified response: %#v", response)
+				}
+			}
+		})
+	}
+}
+
+func TestCache_Middleware_Uncacheable(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int64
+	handler := New().Middleware(makeHandler(&calls, cacheControl("max-age=60")))
+
+	for range 2 {
+		handler(httptest.NewRequest(http.MethodPost, "/", nil), nil)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected POST requests not to be cached, got %d calls", calls.Load())
+	}
+
+	streamed := New().Middleware(
+		func(*http.Request, *muxResponseWriter.ResponseWriter) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			calls.Add(1)
+			return &muxResponse.Response{
+				StatusCode:   http.StatusOK,
+				Headers:      []*muxResponse.HeaderEntry{cacheControl("max-age=60")},
+				BodyStreamer: func(func([]byte, error) bool) {},
+			}, nil
+		},
+	)
+	for range 2 {
+		streamed(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("expected streamed responses not to be cached, got %d calls", calls.Load())
+	}
+
+	nonced := New().Middleware(
+		func(request *http.Request, responseWriter *muxResponseWriter.ResponseWriter) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			_ = responseWriter.CspNonce.Value()
+			return makeHandler(&calls, cacheControl("max-age=60"))(request, responseWriter)
+		},
+	)
+	for range 2 {
+		nonced(httptest.NewRequest(http.MethodGet, "/", nil), &muxResponseWriter.ResponseWriter{CspNonce: &csp_nonce.Nonce{}})
+	}
+	if calls.Load() != 6 {
+		t.Errorf("expected responses embedding a nonce not to be cached, got %d calls", calls.Load())
+	}
+}
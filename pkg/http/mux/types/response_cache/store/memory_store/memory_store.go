@@ -0,0 +1,127 @@
+package memory_store
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_cache/store"
+)
+
+const (
+	DefaultMaxEntries = 1024
+	DefaultMaxBytes   = 64 << 20
+)
+
+type element struct {
+	key       string
+	entry     *store.Entry
+	size      int64
+	expiresAt time.Time
+}
+
+// Store is an in-process store that evicts the least recently used entries once it holds more than
+// its maximum number of entries or bytes; a maximum that is not positive does not apply. Expired
+// entries are evicted when read.
+type Store struct {
+	mutex      sync.Mutex
+	elements   map[string]*list.Element
+	order      *list.List
+	size       int64
+	maxEntries int
+	maxBytes   int64
+}
+
+func (memoryStore *Store) remove(listElement *list.Element) {
+	storeElement := memoryStore.order.Remove(listElement).(*element)
+	delete(memoryStore.elements, storeElement.key)
+	memoryStore.size -= storeElement.size
+}
+
+func (memoryStore *Store) Get(_ context.Context, key string) (*store.Entry, error) {
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	listElement, ok := memoryStore.elements[key]
+	if !ok {
+		return nil, nil
+	}
+
+	storeElement := listElement.Value.(*element)
+	if !time.Now().Before(storeElement.expiresAt) {
+		memoryStore.remove(listElement)
+		return nil, nil
+	}
+
+	memoryStore.order.MoveToFront(listElement)
+
+	return storeElement.entry, nil
+}
+
+func (memoryStore *Store) Set(_ context.Context, key string, entry *store.Entry, ttl time.Duration) error {
+	if entry == nil || ttl <= 0 {
+		return nil
+	}
+
+	size := int64(len(key)) + entry.Size()
+
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	if listElement, ok := memoryStore.elements[key]; ok {
+		memoryStore.remove(listElement)
+	}
+
+	if memoryStore.maxBytes > 0 && size > memoryStore.maxBytes {
+		return nil
+	}
+
+	memoryStore.elements[key] = memoryStore.order.PushFront(
+		&element{key: key, entry: entry, size: size, expiresAt: time.Now().Add(ttl)},
+	)
+	memoryStore.size += size
+
+	for (memoryStore.maxEntries > 0 && memoryStore.order.Len() > memoryStore.maxEntries) ||
+		(memoryStore.maxBytes > 0 && memoryStore.size > memoryStore.maxBytes) {
+		memoryStore.remove(memoryStore.order.Back())
+	}
+
+	return nil
+}
+
+func (memoryStore *Store) Delete(_ context.Context, key string) error {
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	if listElement, ok := memoryStore.elements[key]; ok {
+		memoryStore.remove(listElement)
+	}
+
+	return nil
+}
+
+// Len returns the number of entries held, expired ones included.
+func (memoryStore *Store) Len() int {
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	return memoryStore.order.Len()
+}
+
+// Size returns the number of bytes held, as approximated by the entries.
+func (memoryStore *Store) Size() int64 {
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	return memoryStore.size
+}
+
+func New(maxEntries int, maxBytes int64) *Store {
+	return &Store{
+		elements:   make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
@@ -0,0 +1,102 @@
+package memory_store
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_cache/store"
+)
+
+func makeEntry(body string) *store.Entry {
+	return &store.Entry{Body: []byte(body)}
+}
+
+func TestStore_Eviction(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int64
+		wantKeys   []string
+		wantGone   []string
+	}{
+		{name: "max entries", maxEntries: 2, wantKeys: []string{"a", "c"}, wantGone: []string{"b"}},
+		{name: "max bytes", maxBytes: 12, wantKeys: []string{"a", "c"}, wantGone: []string{"b"}},
+		{name: "unlimited", wantKeys: []string{"a", "b", "c"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			memoryStore := New(testCase.maxEntries, testCase.maxBytes)
+			ctx := t.Context()
+
+			_ = memoryStore.Set(ctx, "a", makeEntry("aaaaa"), time.Minute)
+			_ = memoryStore.Set(ctx, "b", makeEntry("bbbbb"), time.Minute)
+			// Reading "a" makes "b" the least recently used.
+			if entry, _ := memoryStore.Get(ctx, "a"); entry == nil {
+				t.Fatal("expected an entry for a")
+			}
+			_ = memoryStore.Set(ctx, "c", makeEntry("ccccc"), time.Minute)
+
+			for _, key := range testCase.wantKeys {
+				if entry, _ := memoryStore.Get(ctx, key); entry == nil {
+					t.Errorf("expected an entry for %s", key)
+				}
+			}
+			for _, key := range testCase.wantGone {
+				if entry, _ := memoryStore.Get(ctx, key); entry != nil {
+					t.Errorf("expected %s to be evicted", key)
+				}
+			}
+			if memoryStore.Len() != len(testCase.wantKeys) {
+				t.Errorf("len: got %d", memoryStore.Len())
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	memoryStore := New(0, 10)
+	ctx := t.Context()
+
+	if err := memoryStore.Set(ctx, "large", makeEntry("0123456789"), time.Minute); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if memoryStore.Len() != 0 {
+		t.Error("expected an entry larger than the store not to be stored")
+	}
+
+	_ = memoryStore.Set(ctx, "a", makeEntry("x"), time.Minute)
+	_ = memoryStore.Set(ctx, "a", makeEntry("yy"), time.Minute)
+	if entry, _ := memoryStore.Get(ctx, "a"); entry == nil || string(entry.Body) != "yy" {
+		t.Fatalf("expected the replaced entry, got %v", entry)
+	}
+	if memoryStore.Size() != 3 {
+		t.Errorf("size: got %d", memoryStore.Size())
+	}
+
+	synctest.Test(t, func(t *testing.T) {
+		expiringStore := New(0, 0)
+		_ = expiringStore.Set(t.Context(), "expiring", makeEntry("z"), time.Second)
+		time.Sleep(time.Second)
+		if entry, _ := expiringStore.Get(t.Context(), "expiring"); entry != nil {
+			t.Error("expected an expired entry not to be returned")
+		}
+		if expiringStore.Len() != 0 {
+			t.Error("expected an expired entry to be evicted when read")
+		}
+	})
+
+	if err := memoryStore.Delete(ctx, "a"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if memoryStore.Len() != 0 || memoryStore.Size() != 0 {
+		t.Errorf("expected an empty store, got %d entries of %d bytes", memoryStore.Len(), memoryStore.Size())
+	}
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+)
+
+// Entry is a response held by a cache. An entry for a response that varies by request headers is
+// held twice: under the key of the request without them, holding only Vary, and under the key of
+// the request with them, holding the response.
+type Entry struct {
+	StatusCode int
+	Headers    []*muxResponse.HeaderEntry
+	Body       []byte
+	// Vary holds the canonical names of the request headers the response varies by.
+	Vary    []string
+	Stored  time.Time
+	Expires time.Time
+}
+
+// Size approximates the number of bytes the entry occupies.
+func (entry *Entry) Size() int64 {
+	if entry == nil {
+		return 0
+	}
+
+	size := int64(len(entry.Body))
+	for _, header := range entry.Headers {
+		if header != nil {
+			size += int64(len(header.Name) + len(header.Value))
+		}
+	}
+	for _, name := range entry.Vary {
+		size += int64(len(name))
+	}
+
+	return size
+}
+
+// Store holds cached responses by key. A store that several replicas share makes them share a
+// cache.
+type Store interface {
+	// Get returns the entry stored at the key, or nil if there is none or it has expired.
+	Get(ctx context.Context, key string) (*Entry, error)
+	// Set stores the entry at the key, replacing any entry stored there. The entry expires after ttl.
+	Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
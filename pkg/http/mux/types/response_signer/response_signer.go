@@ -0,0 +1,85 @@
+// Package response_signer signs the responses of the handlers whose middleware it is part of with
+// the message signatures of RFC 9421.
+package response_signer
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/message_signature"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/middleware"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	muxResponseWriter "github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+	"github.com/Motmedel/utils_go/pkg/http/types/content_digest"
+)
+
+// signatureHeaderNames are the headers a signer adds to a response.
+var signatureHeaderNames = []string{
+	content_digest.HeaderName,
+	message_signature.SignatureInputHeaderName,
+	message_signature.SignatureHeaderName,
+}
+
+// ResponseSigner signs the responses of the handlers it wraps, covering the response components of
+// the signer's configuration and the headers of the response as the handler produced it -- not the
+// default headers of the response writer. A signed body is not compressed, as its Content-Digest is
+// of the body as it is, and a response with a streamed body is not signed.
+type ResponseSigner struct {
+	Signer *message_signature.Signer
+}
+
+// Middleware is a handler middleware that signs the responses of the handler it wraps.
+func (responseSigner *ResponseSigner) Middleware(next middleware.Handler) middleware.Handler {
+	return func(
+		request *http.Request,
+		responseWriter *muxResponseWriter.ResponseWriter,
+	) (*muxResponse.Response, *muxResponseError.ResponseError) {
+		response, responseError := next(request, responseWriter)
+		if responseError != nil || response == nil || response.BodyStreamer != nil {
+			return response, responseError
+		}
+
+		header := make(http.Header)
+		for _, entry := range response.Headers {
+			if entry != nil {
+				header.Add(entry.Name, entry.Value)
+			}
+		}
+
+		statusCode := response.StatusCode
+		if statusCode == 0 {
+			statusCode = http.StatusOK
+		}
+
+		if err := responseSigner.Signer.SignResponse(statusCode, header, response.Body); err != nil {
+			return nil, &muxResponseError.ResponseError{ServerError: fmt.Errorf("sign response: %w", err)}
+		}
+
+		headers := slices.DeleteFunc(slices.Clone(response.Headers), func(entry *muxResponse.HeaderEntry) bool {
+			return entry != nil && slices.Contains(signatureHeaderNames, http.CanonicalHeaderKey(entry.Name))
+		})
+		for _, name := range signatureHeaderNames {
+			for _, value := range header.Values(name) {
+				headers = append(headers, &muxResponse.HeaderEntry{Name: name, Value: value})
+			}
+		}
+
+		signedResponse := *response
+		signedResponse.Headers = headers
+		signedResponse.SensitiveBody = true
+
+		return &signedResponse, nil
+	}
+}
+
+func New(signer *message_signature.Signer) (*ResponseSigner, error) {
+	if signer == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("signer"))
+	}
+
+	return &ResponseSigner{Signer: signer}, nil
+}
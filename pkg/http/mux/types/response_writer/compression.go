@@ -0,0 +1,94 @@
+package response_writer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	motmedelBrotli "github.com/Motmedel/utils_go/pkg/brotli"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer/compression_config"
+	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
+	motmedelZstd "github.com/Motmedel/utils_go/pkg/zstd"
+)
+
+var defaultCompressionConfig = compression_config.New()
+
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+func newCompressor(writer io.Writer, encoding string, config *compression_config.Config) (compressor, error) {
+	switch encoding {
+	case "gzip":
+		gzipWriter, err := gzip.NewWriterLevel(writer, config.GzipLevel)
+		if err != nil {
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("gzip new writer level: %w", err), config.GzipLevel)
+		}
+		return gzipWriter, nil
+	case "br":
+		return motmedelBrotli.NewWriter(writer, config.BrotliQuality), nil
+	case "zstd":
+		zstdWriter, err := motmedelZstd.NewWriter(writer, config.ZstdLevel)
+		if err != nil {
+			return nil, motmedelErrors.New(fmt.Errorf("zstd new writer: %w", err), config.ZstdLevel)
+		}
+		return zstdWriter, nil
+	default:
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: %s", muxErrors.ErrUnexpectedContentEncoding, encoding),
+			encoding,
+		)
+	}
+}
+
+func compress(data []byte, encoding string, config *compression_config.Config) ([]byte, error) {
+	var buffer bytes.Buffer
+	bodyCompressor, err := newCompressor(&buffer, encoding, config)
+	if err != nil {
+		return nil, fmt.Errorf("new compressor: %w", err)
+	}
+
+	if _, err := bodyCompressor.Write(data); err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("compressor write: %w", err), encoding)
+	}
+	if err := bodyCompressor.Close(); err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("compressor close: %w", err), encoding)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func isCompressibleContentType(contentType *motmedelHttpTypes.ContentType, patterns []string) bool {
+	if contentType == nil {
+		return true
+	}
+
+	fullType := contentType.GetFullType(true)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), fullType); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// weakenEtag makes a strong ETag of a response weak once a content coding has been applied to its
+// body: a strong validator differs per representation (RFC 9110, Section 8.8.3), and the tag was
+// made for the body as it was before the coding. If-None-Match compares tags weakly, so the
+// encoded response still revalidates.
+func weakenEtag(header http.Header) {
+	etag := header.Get("Etag")
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		return
+	}
+
+	header.Set("Etag", "W/"+etag)
+}
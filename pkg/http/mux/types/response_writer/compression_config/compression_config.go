@@ -0,0 +1,96 @@
+package compression_config
+
+import (
+	"compress/gzip"
+)
+
+var (
+	// DefaultEncodings are the encodings applied, in the order they are preferred when a client
+	// accepts several equally.
+	DefaultEncodings = []string{"zstd", "br", "gzip"}
+	DefaultMinSize   = 1000
+	// DefaultContentTypes are the media types of bodies that are worth compressing.
+	DefaultContentTypes = []string{
+		"text/*",
+		"application/json",
+		"application/*+json",
+		"application/x-ndjson",
+		"application/xml",
+		"application/*+xml",
+		"application/javascript",
+		"application/wasm",
+		"image/svg+xml",
+	}
+	DefaultGzipLevel     = gzip.DefaultCompression
+	DefaultBrotliQuality = 4
+	DefaultZstdLevel     = 3
+)
+
+type Config struct {
+	// Encodings are the content codings applied -- "gzip", "br" and "zstd" -- in the order they are
+	// preferred when a client accepts several equally; none disables compression.
+	Encodings []string
+	// MinSize is the size of the smallest body that is compressed; a streamed body, whose size is
+	// not known in advance, is compressed whatever its size.
+	MinSize int
+	// ContentTypes are patterns, such as "text/*" and "application/*+json", matched against the media
+	// type of a body; a body with no Content-Type is compressed.
+	ContentTypes  []string
+	GzipLevel     int
+	BrotliQuality int
+	ZstdLevel     int
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		Encodings:     DefaultEncodings,
+		MinSize:       DefaultMinSize,
+		ContentTypes:  DefaultContentTypes,
+		GzipLevel:     DefaultGzipLevel,
+		BrotliQuality: DefaultBrotliQuality,
+		ZstdLevel:     DefaultZstdLevel,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	return config
+}
+
+func WithEncodings(encodings ...string) Option {
+	return func(config *Config) {
+		config.Encodings = encodings
+	}
+}
+
+func WithMinSize(minSize int) Option {
+	return func(config *Config) {
+		config.MinSize = minSize
+	}
+}
+
+func WithContentTypes(contentTypes ...string) Option {
+	return func(config *Config) {
+		config.ContentTypes = contentTypes
+	}
+}
+
+func WithGzipLevel(gzipLevel int) Option {
+	return func(config *Config) {
+		config.GzipLevel = gzipLevel
+	}
+}
+
+func WithBrotliQuality(brotliQuality int) Option {
+	return func(config *Config) {
+		config.BrotliQuality = brotliQuality
+	}
+}
+
+func WithZstdLevel(zstdLevel int) Option {
+	return func(config *Config) {
+		config.ZstdLevel = zstdLevel
+	}
+}
@@ -0,0 +1,50 @@
+package compression_config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if !slices.Equal(config.Encodings, DefaultEncodings) {
+		t.Errorf("encodings: got %v", config.Encodings)
+	}
+	if config.MinSize != DefaultMinSize {
+		t.Errorf("min size: got %d", config.MinSize)
+	}
+	if !slices.Equal(config.ContentTypes, DefaultContentTypes) {
+		t.Errorf("content types: got %v", config.ContentTypes)
+	}
+	if config.GzipLevel != DefaultGzipLevel || config.BrotliQuality != DefaultBrotliQuality || config.ZstdLevel != DefaultZstdLevel {
+		t.Errorf("levels: got %d, %d, %d", config.GzipLevel, config.BrotliQuality, config.ZstdLevel)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	config := New(
+		WithEncodings("gzip"),
+		WithMinSize(10),
+		WithContentTypes("application/json"),
+		WithGzipLevel(1),
+		WithBrotliQuality(2),
+		WithZstdLevel(3),
+	)
+
+	if !slices.Equal(config.Encodings, []string{"gzip"}) {
+		t.Errorf("encodings: got %v", config.Encodings)
+	}
+	if config.MinSize != 10 {
+		t.Errorf("min size: got %d", config.MinSize)
+	}
+	if !slices.Equal(config.ContentTypes, []string{"application/json"}) {
+		t.Errorf("content types: got %v", config.ContentTypes)
+	}
+	if config.GzipLevel != 1 || config.BrotliQuality != 2 || config.ZstdLevel != 3 {
+		t.Errorf("levels: got %d, %d, %d", config.GzipLevel, config.BrotliQuality, config.ZstdLevel)
+	}
+}
@@ -0,0 +1,259 @@
+package response_writer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	motmedelBrotli "github.com/Motmedel/utils_go/pkg/brotli"
+	muxTypesResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer/compression_config"
+	acceptEncodingParsing "github.com/Motmedel/utils_go/pkg/http/types/accept_encoding"
+	motmedelZstd "github.com/Motmedel/utils_go/pkg/zstd"
+)
+
+func decompress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var reader io.Reader
+	switch encoding {
+	case "gzip":
+		gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("gzip new reader: %v", err)
+		}
+		reader = gzipReader
+	case "br":
+		reader = motmedelBrotli.NewReader(bytes.NewReader(data))
+	case "zstd":
+		zstdReader, err := motmedelZstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("zstd new reader: %v", err)
+		}
+		defer zstdReader.Close()
+		reader = zstdReader
+	default:
+		return data
+	}
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read all: %v", err)
+	}
+
+	return decompressed
+}
+
+func TestWriteResponse_CompressionNegotiation(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(strings.Repeat(`{"id":1,"name":"item"},`, 200))
+	jsonHeader := &muxTypesResponse.HeaderEntry{Name: "Content-Type", Value: "application/json"}
+
+	testCases := []struct {
+		name           string
+		acceptEncoding string
+		headers        []*muxTypesResponse.HeaderEntry
+		body           []byte
+		options        []compression_config.Option
+		wantEncoding   string
+		wantVary       bool
+	}{
+		{name: "zstd preferred", acceptEncoding: "gzip, br, zstd", wantEncoding: "zstd", wantVary: true},
+		{name: "client preference", acceptEncoding: "gzip;q=1, br;q=0.5", wantEncoding: "gzip", wantVary: true},
+		{name: "brotli", acceptEncoding: "br", wantEncoding: "br", wantVary: true},
+		{name: "identity", acceptEncoding: "identity", wantVary: true},
+		{name: "unsupported", acceptEncoding: "deflate", wantVary: true},
+		{
+			name:           "configured encodings",
+			acceptEncoding: "zstd, gzip",
+			options:        []compression_config.Option{compression_config.WithEncodings("gzip")},
+			wantEncoding:   "gzip",
+			wantVary:       true,
+		},
+		{
+			name:           "problem json",
+			acceptEncoding: "gzip",
+			headers:        []*muxTypesResponse.HeaderEntry{{Name: "Content-Type", Value: "application/problem+json"}},
+			wantEncoding:   "gzip",
+		},
+		{
+			name:           "content type not allowed",
+			acceptEncoding: "gzip",
+			headers:        []*muxTypesResponse.HeaderEntry{{Name: "Content-Type", Value: "image/png"}},
+		},
+		{name: "below minimum size", acceptEncoding: "gzip", body: body[:100]},
+		{
+			name:           "lowered minimum size",
+			acceptEncoding: "gzip",
+			body:           []byte(strings.Repeat("a", 100)),
+			options:        []compression_config.Option{compression_config.WithMinSize(10)},
+			wantEncoding:   "gzip",
+			wantVary:       true,
+		},
+		{
+			name:           "no-transform",
+			acceptEncoding: "gzip",
+			headers: []*muxTypesResponse.HeaderEntry{
+				jsonHeader,
+				{Name: "Cache-Control", Value: "no-cache, no-transform", Overwrite: true},
+			},
+		},
+		{
+			name:           "no vary when not stored",
+			acceptEncoding: "gzip",
+			headers: []*muxTypesResponse.HeaderEntry{
+				jsonHeader,
+				{Name: "Cache-Control", Value: "no-store", Overwrite: true},
+			},
+			wantEncoding: "gzip",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			acceptEncoding, err := acceptEncodingParsing.Parse([]byte(testCase.acceptEncoding))
+			if err != nil {
+				t.Fatalf("parse accept encoding: %v", err)
+			}
+
+			headers := testCase.headers
+			if headers == nil {
+				headers = []*muxTypesResponse.HeaderEntry{
+					jsonHeader,
+					{Name: "Cache-Control", Value: "no-cache", Overwrite: true},
+				}
+			}
+			responseBody := testCase.body
+			if responseBody == nil {
+				responseBody = body
+			}
+
+			recorder := httptest.NewRecorder()
+			writer := &ResponseWriter{ResponseWriter: recorder, Compression: compression_config.New(testCase.options...)}
+			response := &muxTypesResponse.Response{Body: responseBody, Headers: headers}
+			if err := writer.WriteResponse(t.Context(), response, acceptEncoding); err != nil {
+				t.Fatalf("write response: %v", err)
+			}
+
+			header := recorder.Header()
+			if got := header.Get("Content-Encoding"); got != testCase.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, testCase.wantEncoding)
+			}
+			if got := strings.Contains(header.Get("Vary"), "Accept-Encoding"); got != testCase.wantVary {
+				t.Errorf("Vary = %q, want Accept-Encoding: %t", header.Get("Vary"), testCase.wantVary)
+			}
+
+			if testCase.wantEncoding != "" {
+				if got := header.Get("Content-Length"); got != strconv.Itoa(recorder.Body.Len()) {
+					t.Errorf("Content-Length = %q, want %d", got, recorder.Body.Len())
+				}
+			}
+			if !bytes.Equal(decompress(t, testCase.wantEncoding, recorder.Body.Bytes()), responseBody) {
+				t.Error("expected the decompressed body to equal the response body")
+			}
+		})
+	}
+}
+
+func TestWriteResponse_CompressionWeakensStrongEtag(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(strings.Repeat(`{"id":1,"name":"item"},`, 200))
+
+	testCases := []struct {
+		name           string
+		acceptEncoding string
+		etag           string
+		wantEtag       string
+	}{
+		{name: "encoded", acceptEncoding: "gzip", etag: `"abc"`, wantEtag: `W/"abc"`},
+		{name: "already weak", acceptEncoding: "gzip", etag: `W/"abc"`, wantEtag: `W/"abc"`},
+		{name: "identity", acceptEncoding: "identity", etag: `"abc"`, wantEtag: `"abc"`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			acceptEncoding, err := acceptEncodingParsing.Parse([]byte(testCase.acceptEncoding))
+			if err != nil {
+				t.Fatalf("parse accept encoding: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			writer := &ResponseWriter{ResponseWriter: recorder}
+			response := &muxTypesResponse.Response{
+				Body: body,
+				Headers: []*muxTypesResponse.HeaderEntry{
+					{Name: "Content-Type", Value: "application/json"},
+					{Name: "Cache-Control", Value: "no-cache", Overwrite: true},
+					{Name: "ETag", Value: testCase.etag},
+				},
+			}
+			if err := writer.WriteResponse(t.Context(), response, acceptEncoding); err != nil {
+				t.Fatalf("write response: %v", err)
+			}
+
+			if got := recorder.Header().Get("Etag"); got != testCase.wantEtag {
+				t.Errorf("ETag = %q, want %q", got, testCase.wantEtag)
+			}
+		})
+	}
+}
+
+func TestWriteResponse_StreamingCompression(t *testing.T) {
+	t.Parallel()
+
+	chunks := []string{`{"n":1}` + "\n", `{"n":2}` + "\n", `{"n":3}` + "\n"}
+
+	for _, encoding := range []string{"gzip", "br", "zstd"} {
+		t.Run(encoding, func(t *testing.T) {
+			t.Parallel()
+
+			acceptEncoding, err := acceptEncodingParsing.Parse([]byte(encoding))
+			if err != nil {
+				t.Fatalf("parse accept encoding: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			writer := &ResponseWriter{ResponseWriter: recorder}
+			response := &muxTypesResponse.Response{
+				Headers: []*muxTypesResponse.HeaderEntry{
+					{Name: "Content-Type", Value: "application/x-ndjson"},
+					{Name: "Content-Length", Value: "24"},
+				},
+				BodyStreamer: func(yield func([]byte, error) bool) {
+					for _, chunk := range chunks {
+						if !yield([]byte(chunk), nil) {
+							return
+						}
+					}
+				},
+			}
+			if err := writer.WriteResponse(t.Context(), response, acceptEncoding); err != nil {
+				t.Fatalf("write response: %v", err)
+			}
+
+			header := recorder.Header()
+			if got := header.Get("Content-Encoding"); got != encoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, encoding)
+			}
+			if got := header.Get("Content-Length"); got != "" {
+				t.Errorf("expected no Content-Length for a compressed stream, got %q", got)
+			}
+			if !recorder.Flushed {
+				t.Error("expected the stream to be flushed")
+			}
+			if got := string(decompress(t, encoding, recorder.Body.Bytes())); got != strings.Join(chunks, "") {
+				t.Errorf("decompressed body = %q", got)
+			}
+		})
+	}
+}
@@ -15,6 +15,46 @@ const contentSecurityPolicyHeaderName = "Content-Security-Policy"
 
 var ErrInvalidInlineScriptHash = fmt.Errorf("invalid inline script hash")
 
+// sourceDirective is a directive whose source list can be read and replaced.
+type sourceDirective interface {
+	GetSources() []contentSecurityPolicy.SourceI
+	SetSources(sources []contentSecurityPolicy.SourceI)
+}
+
+// sourceDirectivePointer is any concrete source-directive pointer (e.g.
+// *contentSecurityPolicy.ScriptSrcDirective) whose source list can be read
+// and replaced.
+type sourceDirectivePointer[T any] interface {
+	*T
+	contentSecurityPolicy.DirectiveI
+	sourceDirective
+}
+
+// seedSourceDirective returns the policy's source directive of type T. A
+// missing one is seeded from default-src, which it stops inheriting from, so
+// that it permits what was permitted before it is added to. It is nil where
+// the policy restricts neither, and what the directive governs is permitted
+// already.
+func seedSourceDirective[T any, PT sourceDirectivePointer[T]](
+	policy *contentSecurityPolicy.ContentSecurityPolicy,
+) PT {
+	directive := PT(new(T))
+	if existingDirective, found := policy.GetDirective(directive.GetName()); found {
+		existingSourceDirective, _ := existingDirective.(PT)
+		return existingSourceDirective
+	}
+
+	defaultSrc := policy.GetDefaultSrc()
+	if defaultSrc == nil {
+		return nil
+	}
+
+	directive.SetSources(slices.Clone(defaultSrc.Sources))
+	policy.Directives = append(policy.Directives, directive)
+
+	return directive
+}
+
 // applyInlineScriptHashesCache caches merged policies keyed by the policy
 // string and hash list, as the combinations are few and stable.
 var applyInlineScriptHashesCache sync.Map
@@ -41,20 +81,10 @@ func applyInlineScriptHashes(policyString string, inlineScriptHashes []string) (
 		return "", motmedelErrors.NewWithTrace(nil_error.New("content security policy"))
 	}
 
-	scriptSrc := policy.GetScriptSrc()
+	scriptSrc := seedSourceDirective[contentSecurityPolicy.ScriptSrcDirective](policy)
 	if scriptSrc == nil {
-		defaultSrc := policy.GetDefaultSrc()
-		if defaultSrc == nil {
-			applyInlineScriptHashesCache.Store(cacheKey, policyString)
-			return policyString, nil
-		}
-
-		scriptSrc = &contentSecurityPolicy.ScriptSrcDirective{
-			SourceDirective: contentSecurityPolicy.SourceDirective{
-				Sources: slices.Clone(defaultSrc.Sources),
-			},
-		}
-		policy.Directives = append(policy.Directives, scriptSrc)
+		applyInlineScriptHashesCache.Store(cacheKey, policyString)
+		return policyString, nil
 	}
 
 	presentSources := make(map[string]struct{})
@@ -88,3 +118,52 @@ func applyInlineScriptHashes(policyString string, inlineScriptHashes []string) (
 	applyInlineScriptHashesCache.Store(cacheKey, mergedPolicyString)
 	return mergedPolicyString, nil
 }
+
+// applyCspNonce merges the nonce source of a response into the script-src and
+// style-src directives of its policy, seeding missing ones from default-src,
+// and into script-src-elem and style-src-elem where the policy has them, as
+// they take precedence for elements. The merged policy is not cached, a nonce
+// being of one response only.
+func applyCspNonce(policyString string, nonce string) (string, error) {
+	if nonce == "" {
+		return policyString, nil
+	}
+
+	policy, err := contentSecurityPolicy.Parse([]byte(policyString))
+	if err != nil {
+		return "", motmedelErrors.New(
+			fmt.Errorf("content security policy parse: %w", err),
+			policyString,
+		)
+	}
+	if policy == nil {
+		return "", motmedelErrors.NewWithTrace(nil_error.New("content security policy"))
+	}
+
+	nonceSource := &contentSecurityPolicy.NonceSource{Base64Value: nonce}
+
+	var directives []sourceDirective
+	if scriptSrc := seedSourceDirective[contentSecurityPolicy.ScriptSrcDirective](policy); scriptSrc != nil {
+		directives = append(directives, scriptSrc)
+	}
+	if styleSrc := seedSourceDirective[contentSecurityPolicy.StyleSrcDirective](policy); styleSrc != nil {
+		directives = append(directives, styleSrc)
+	}
+	if scriptSrcElem := policy.GetScriptSrcElem(); scriptSrcElem != nil {
+		directives = append(directives, scriptSrcElem)
+	}
+	if styleSrcElem := policy.GetStyleSrcElem(); styleSrcElem != nil {
+		directives = append(directives, styleSrcElem)
+	}
+
+	for _, directive := range directives {
+		sources := directive.GetSources()
+		if !slices.ContainsFunc(sources, func(source contentSecurityPolicy.SourceI) bool {
+			return source != nil && source.String() == nonceSource.String()
+		}) {
+			directive.SetSources(append(sources, nonceSource))
+		}
+	}
+
+	return policy.String(), nil
+}
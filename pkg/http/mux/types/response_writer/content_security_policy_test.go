@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/csp_nonce"
 	muxTypesResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
 )
 
@@ -140,3 +141,104 @@ func TestWriteResponseInlineScriptHashes(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyCspNonce(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		policy   string
+		expected string
+	}{
+		{
+			name:     "missing script-src and style-src are seeded from default-src",
+			policy:   "default-src 'self'; object-src 'none'",
+			expected: "default-src 'self'; object-src 'none'; script-src 'self' 'nonce-abc123'; style-src 'self' 'nonce-abc123'",
+		},
+		{
+			name:     "existing directives are extended, elem directives included",
+			policy:   "script-src 'strict-dynamic'; script-src-elem 'self'; style-src-elem 'self'",
+			expected: "script-src 'strict-dynamic' 'nonce-abc123'; script-src-elem 'self' 'nonce-abc123'; style-src-elem 'self' 'nonce-abc123'",
+		},
+		{
+			name:     "policy without script or style restrictions is unchanged",
+			policy:   "frame-ancestors 'none'",
+			expected: "frame-ancestors 'none'",
+		},
+		{
+			name:     "present nonce is not duplicated",
+			policy:   "script-src 'nonce-abc123'; style-src 'nonce-abc123'",
+			expected: "script-src 'nonce-abc123'; style-src 'nonce-abc123'",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := applyCspNonce(testCase.policy, "abc123")
+			if err != nil {
+				t.Fatalf("apply csp nonce: %v", err)
+			}
+			if actual != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWriteResponseCspNonce(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		generate         bool
+		wantNonce        bool
+		wantCacheControl string
+	}{
+		{name: "generated nonce is merged", generate: true, wantNonce: true, wantCacheControl: "no-store"},
+		// A nonce would make a browser disregard 'unsafe-inline', which a response that embeds none
+		// may rely on.
+		{name: "nonce not asked for is not merged", generate: false, wantNonce: false, wantCacheControl: "public, max-age=60"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			nonce := &csp_nonce.Nonce{}
+			var nonceValue string
+			if testCase.generate {
+				nonceValue = nonce.Value()
+			}
+
+			recorder := httptest.NewRecorder()
+			responseWriter := &ResponseWriter{ResponseWriter: recorder, CspNonce: nonce}
+
+			err := responseWriter.WriteResponse(
+				context.Background(),
+				&muxTypesResponse.Response{
+					StatusCode: http.StatusOK,
+					Headers: []*muxTypesResponse.HeaderEntry{
+						{Name: "Content-Type", Value: "text/html"},
+						{Name: "Cache-Control", Value: "public, max-age=60", Overwrite: true},
+					},
+					Body: []byte("<html></html>"),
+				},
+				nil,
+			)
+			if err != nil {
+				t.Fatalf("write response: %v", err)
+			}
+
+			policy := recorder.Header().Get("Content-Security-Policy")
+			if got := strings.Contains(policy, "'nonce-"); got != testCase.wantNonce {
+				t.Errorf("expected nonce %t, got policy %q", testCase.wantNonce, policy)
+			}
+			if testCase.wantNonce && !strings.Contains(policy, "script-src 'self' 'nonce-"+nonceValue+"'") {
+				t.Errorf("expected the nonce in script-src, got %q", policy)
+			}
+			if cacheControl := recorder.Header().Get("Cache-Control"); cacheControl != testCase.wantCacheControl {
+				t.Errorf("expected cache control %q, got %q", testCase.wantCacheControl, cacheControl)
+			}
+		})
+	}
+}
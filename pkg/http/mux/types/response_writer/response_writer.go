@@ -3,19 +3,25 @@ package response_writer
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
 
 	motmedelContext "github.com/Motmedel/utils_go/pkg/context"
-	motmedelGzip "github.com/Motmedel/utils_go/pkg/encoding/gzip"
 	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
 	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/csp_nonce"
 	muxTypesResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer/compression_config"
 	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
 	"github.com/Motmedel/utils_go/pkg/http/types/content_type"
+	"github.com/Motmedel/utils_go/pkg/http/types/cross_origin_policy"
+	"github.com/Motmedel/utils_go/pkg/http/types/permissions_policy"
 	motmedelHttpUtils "github.com/Motmedel/utils_go/pkg/http/utils"
 )
 
@@ -25,9 +31,9 @@ const (
 )
 
 var DefaultHeaders = map[string]string{
-	cacheControlHeaderName:         "no-store",
-	"X-Content-Type-Options":       "nosniff",
-	"Cross-Origin-Resource-Policy": sameOrigin,
+	cacheControlHeaderName:                       "no-store",
+	"X-Content-Type-Options":                     "nosniff",
+	cross_origin_policy.ResourcePolicyHeaderName: string(cross_origin_policy.ResourcePolicySameOrigin),
 }
 
 const (
@@ -42,12 +48,33 @@ const (
 	DefaultContentSecurityPolicyString = "default-src 'self'; object-src 'none'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'; upgrade-insecure-requests"
 )
 
+// DefaultPermissionsPolicy denies the features through which a document reaches the user's
+// surroundings or money. A service whose documents use one permits it by patching the policy.
+var DefaultPermissionsPolicy = &permissions_policy.PermissionsPolicy{
+	Directives: []*permissions_policy.Directive{
+		{Feature: "geolocation"},
+		{Feature: "microphone"},
+		{Feature: "camera"},
+		{Feature: "payment"},
+		{Feature: "usb"},
+		{Feature: "display-capture"},
+	},
+}
+
+// DefaultOpenerPolicy keeps a document out of the browsing context group of any window of another
+// origin, which could otherwise reach it through window.opener.
+var DefaultOpenerPolicy = &cross_origin_policy.OpenerPolicy{Value: cross_origin_policy.OpenerPolicySameOrigin}
+
+// DefaultEmbedderPolicy loads only what has agreed to be embedded, which together with
+// DefaultOpenerPolicy isolates the document across origins.
+var DefaultEmbedderPolicy = &cross_origin_policy.EmbedderPolicy{Value: cross_origin_policy.EmbedderPolicyRequireCorp}
+
 var DefaultDocumentHeaders = map[string]string{
-	"Cross-Origin-Opener-Policy":   sameOrigin,
-	"Cross-Origin-Embedder-Policy": "require-corp",
-	"Content-Security-Policy":      DefaultContentSecurityPolicyString,
-	"Permissions-Policy":           "geolocation=(), microphone=(), camera=(), payment=(), usb=(), display-capture=()",
-	"Referrer-Policy":              sameOrigin,
+	cross_origin_policy.OpenerPolicyHeaderName:   DefaultOpenerPolicy.String(),
+	cross_origin_policy.EmbedderPolicyHeaderName: DefaultEmbedderPolicy.String(),
+	"Content-Security-Policy":                    DefaultContentSecurityPolicyString,
+	permissions_policy.HeaderName:                DefaultPermissionsPolicy.String(),
+	"Referrer-Policy":                            sameOrigin,
 }
 
 type ResponseWriter struct {
@@ -59,9 +86,18 @@ type ResponseWriter struct {
 
 	WrittenStatusCode int
 	WrittenBody       []byte
+	// WrittenSize is the number of body bytes written, as sent and whether or not the body is
+	// stored.
+	WrittenSize int64
 
 	DefaultHeaders         map[string]string
 	DefaultDocumentHeaders map[string]string
+	// Compression is how bodies are compressed; the defaults of compression_config apply when it is
+	// nil.
+	Compression *compression_config.Config
+	// CspNonce is the Content Security Policy nonce of the response, merged into its policy where the
+	// handler asked for it.
+	CspNonce *csp_nonce.Nonce
 }
 
 func (responseWriter *ResponseWriter) WriteHeader(statusCode int) {
@@ -90,6 +126,7 @@ func (responseWriter *ResponseWriter) Write(data []byte) (int, error) {
 	}
 
 	n, err := responseWriter.ResponseWriter.Write(data)
+	responseWriter.WrittenSize += int64(n)
 	if err != nil {
 		return n, motmedelErrors.NewWithTrace(fmt.Errorf("http response writer write: %w", err))
 	}
@@ -213,9 +250,11 @@ func (responseWriter *ResponseWriter) WriteResponse(
 		responseWriterHeader.Add(canonicalHeaderName, headerValue)
 	}
 
+	var contentType *motmedelHttpTypes.ContentType
 	if contentTypeString != nil {
 		contentTypeData := []byte(*contentTypeString)
-		contentType, err := content_type.Parse(contentTypeData)
+		var err error
+		contentType, err = content_type.Parse(contentTypeData)
 		if err != nil {
 			return motmedelErrors.New(fmt.Errorf("parse content type: %w", err), contentTypeData)
 		}
@@ -223,6 +262,11 @@ func (responseWriter *ResponseWriter) WriteResponse(
 			return motmedelErrors.NewWithTrace(nil_error.New("content type"), contentTypeData)
 		}
 
+		// NOTE: An event stream is unbounded; its body is not kept around for logging.
+		if bodyStreamer != nil && contentType.GetFullType(true) == "text/event-stream" {
+			responseWriter.NoStoreWrittenBody = true
+		}
+
 		var useDocumentHeaders bool
 
 		effectiveContentTypeValues := []string{
@@ -266,42 +310,80 @@ func (responseWriter *ResponseWriter) WriteResponse(
 		}
 	}
 
+	// A nonce is merged after the hashes: a handler generating a document may embed both. A response
+	// that embeds a nonce is not to be stored, whatever the handler said, lest it be served again.
+	if nonce, ok := responseWriter.CspNonce.Generated(); ok {
+		responseWriterHeader.Set(cacheControlHeaderName, "no-store")
+		if policyString := responseWriterHeader.Get(contentSecurityPolicyHeaderName); policyString != "" {
+			mergedPolicyString, err := applyCspNonce(policyString, nonce)
+			if err != nil {
+				return fmt.Errorf("apply csp nonce: %w", err)
+			}
+			responseWriterHeader.Set(contentSecurityPolicyHeaderName, mergedPolicyString)
+		}
+	}
+
+	compressionConfig := responseWriter.Compression
+	if compressionConfig == nil {
+		compressionConfig = defaultCompressionConfig
+	}
+
+	_, noTransform := cacheControlSet["no-transform"]
+
+	// Compress the body if no content encoding is applied already,
+	shouldCompressBody := len(compressionConfig.Encodings) != 0 &&
+		contentEncodingString == nil &&
+		// ... the body is not sensitive (compressing could theoretically enable attacks),
+		!response.SensitiveBody &&
+		// ... transforming the response is not forbidden,
+		!noTransform &&
+		// ... the body is of a decent size, which a streamed body is assumed to be,
+		(bodyStreamer != nil || (len(body) != 0 && len(body) >= compressionConfig.MinSize)) &&
+		// ... and the body is of a type worth compressing.
+		isCompressibleContentType(contentType, compressionConfig.ContentTypes)
+
+	var encoding string
+	if shouldCompressBody {
+		// The body is encoded according to what the client accepts, even if it ends up not being.
+		if !slices.ContainsFunc(varyValues, func(value string) bool { return strings.EqualFold(value, "Accept-Encoding") }) {
+			varyValues = append(varyValues, "Accept-Encoding")
+		}
+
+		// NOTE: The case where `identity` effectively has a quality value of 0 should be handled elsewhere.
+		if acceptEncoding != nil {
+			encoding = motmedelHttpUtils.GetMatchingContentEncoding(
+				acceptEncoding.GetPriorityOrderedEncodings(),
+				compressionConfig.Encodings,
+			)
+			if encoding == motmedelHttpUtils.AcceptContentIdentity {
+				encoding = ""
+			}
+		}
+	}
+
 	_, noStore := cacheControlSet["no-store"]
 
 	if !noStore && len(varyValues) > 0 {
 		responseWriterHeader.Add("Vary", strings.Join(varyValues, ", "))
 	}
 
-	// Try to compress the body if it is of a decent size, and
-	shouldTryToCompressBody := len(body) > 1000 &&
-		// ... no content encoding is applied
-		contentEncodingString == nil &&
-		// ... the client indicates that it supports encoded content
-		acceptEncoding != nil &&
-		// ... the response body is not sensitive (compressing could theoretically enable attacks)
-		!response.SensitiveBody &&
-		// ... the response concerns a non-static resource (static resources should provide encoded values explicitly,
-		// and I don't want to add a `Vary` header like this)
-		noStore
-
-	if shouldTryToCompressBody {
-		// NOTE: The case where `identify` effectively has a quality value of 0 should be handled elsewhere.
-		switch motmedelHttpUtils.GetMatchingContentEncoding(acceptEncoding.GetPriorityOrderedEncodings(), []string{"gzip"}) {
-		case "gzip":
-			gzipBody, err := motmedelGzip.MakeGzipData(ctx, body)
+	if encoding != "" {
+		if bodyStreamer != nil {
+			responseWriterHeader.Set("Content-Encoding", encoding)
+			responseWriterHeader.Del("Content-Length")
+			weakenEtag(responseWriterHeader)
+		} else {
+			compressedBody, err := compress(body, encoding, compressionConfig)
 			if err != nil {
 				slog.WarnContext(
-					motmedelContext.WithError(
-						ctx,
-						motmedelErrors.New(fmt.Errorf("make gzip data: %w", err), body),
-					),
-					"An error occurred when making Gzip data.",
+					motmedelContext.WithError(ctx, motmedelErrors.New(fmt.Errorf("compress: %w", err), encoding)),
+					"An error occurred when compressing a response body.",
 				)
-			}
-
-			if len(gzipBody) < len(body) {
-				body = gzipBody
-				responseWriterHeader.Set("Content-Encoding", "gzip")
+			} else if len(compressedBody) < len(body) {
+				body = compressedBody
+				responseWriterHeader.Set("Content-Encoding", encoding)
+				responseWriterHeader.Set("Content-Length", strconv.Itoa(len(body)))
+				weakenEtag(responseWriterHeader)
 			}
 		}
 	}
@@ -323,14 +405,37 @@ func (responseWriter *ResponseWriter) WriteResponse(
 		// TODO: Figure out how to support HTTP/2?
 		responseWriterHeader.Set("Transfer-Encoding", "chunked")
 
+		var bodyWriter io.Writer = responseWriter
+		var bodyCompressor compressor
+		if encoding != "" {
+			var err error
+			bodyCompressor, err = newCompressor(responseWriter, encoding, compressionConfig)
+			if err != nil {
+				return fmt.Errorf("new compressor: %w", err)
+			}
+			bodyWriter = bodyCompressor
+		}
+
 		for bodyChunk, err := range bodyStreamer {
 			if err != nil {
 				return fmt.Errorf("body streamer: %w", err)
 			}
 
-			if _, err := responseWriter.Write(bodyChunk); err != nil {
+			if _, err := bodyWriter.Write(bodyChunk); err != nil {
 				return fmt.Errorf("mux response writer write: %w", err)
 			}
+			if bodyCompressor != nil {
+				if err := bodyCompressor.Flush(); err != nil {
+					return motmedelErrors.NewWithTrace(fmt.Errorf("compressor flush: %w", err), encoding)
+				}
+			}
+			flusher.Flush()
+		}
+
+		if bodyCompressor != nil {
+			if err := bodyCompressor.Close(); err != nil {
+				return motmedelErrors.NewWithTrace(fmt.Errorf("compressor close: %w", err), encoding)
+			}
 			flusher.Flush()
 		}
 
@@ -121,7 +121,7 @@ func TestWriteResponse_Compression(t *testing.T) {
 		t.Fatalf("parse accept encoding: %v", err)
 	}
 
-	// A large, compressible, non-sensitive body with a no-store default triggers gzip.
+	// A large, compressible, non-sensitive body triggers gzip.
 	body := bytes.Repeat([]byte("a"), 2000)
 
 	t.Run("compresses a large body", func(t *testing.T) {
@@ -13,11 +13,13 @@ type parsedRequestUrlContextType struct{}
 type parsedRequestHeaderContextType struct{}
 type parsedRequestBodyContextType struct{}
 type parsedRequestAuthenticationContextType struct{}
+type pathParametersContextType struct{}
 
 var ParsedRequestUrlContextKey = parsedRequestUrlContextType{}
 var ParsedRequestHeaderContextKey = parsedRequestHeaderContextType{}
 var ParsedRequestBodyContextKey = parsedRequestBodyContextType{}
 var ParsedRequestAuthenticationContextKey = parsedRequestAuthenticationContextType{}
+var PathParametersContextKey = pathParametersContextType{}
 
 func getParsed[T any](ctx context.Context, key any) (T, error) {
 	value, err := utils.GetContextValue[T](ctx, key)
@@ -113,6 +115,16 @@ func GetServerNonZeroParsedRequestAuthentication[T comparable](ctx context.Conte
 	return GetServerNonZeroContextValue[T](ctx, ParsedRequestAuthenticationContextKey)
 }
 
+// GetPathParameters returns the values of the named segments of the path pattern that routed the
+// request. They are also available through the request's PathValue method.
+func GetPathParameters(ctx context.Context) (map[string]string, error) {
+	return getParsed[map[string]string](ctx, PathParametersContextKey)
+}
+
+func GetServerPathParameters(ctx context.Context) (map[string]string, *response_error.ResponseError) {
+	return GetServerContextValue[map[string]string](ctx, PathParametersContextKey)
+}
+
 func MakeStaticContentHeaders(contentType, cacheControl, etag, lastModified string) []*response.HeaderEntry {
 	var entries []*response.HeaderEntry
 
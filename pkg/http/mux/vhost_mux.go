@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"net"
 	"net/http"
+	"strings"
 
 	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
 
@@ -22,11 +23,37 @@ type VhostMuxSpecification struct {
 	Certificate *tls.Certificate
 }
 
+// VhostMux serves each host with what its specification says. A host is looked up as it is, and
+// failing that as a wildcard: "*.example.com" is the specification of any host one label below
+// example.com that has none of its own, as a wildcard certificate would cover.
 type VhostMux struct {
 	baseMux
 	HostToSpecification map[string]*VhostMuxSpecification
 }
 
+// lookupSpecification returns the specification of host, the host's own or else its wildcard's.
+func lookupSpecification(
+	hostToSpecification map[string]*VhostMuxSpecification,
+	host string,
+) (*VhostMuxSpecification, bool) {
+	if specification, ok := hostToSpecification[host]; ok {
+		return specification, true
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if specification, ok := hostToSpecification[host]; ok {
+		return specification, true
+	}
+
+	_, parent, found := strings.Cut(host, ".")
+	if !found || parent == "" {
+		return nil, false
+	}
+
+	specification, ok := hostToSpecification["*."+parent]
+	return specification, ok
+}
+
 func (vhostMux *VhostMux) PatchHttpServer(httpServer *http.Server) {
 	if httpServer == nil {
 		return
@@ -50,7 +77,7 @@ func (vhostMux *VhostMux) PatchHttpServer(httpServer *http.Server) {
 			return nil, motmedelErrors.NewWithTrace(nil_error.New("host to mux specification"))
 		}
 
-		specification, ok := hostToSpecification[clientHello.ServerName]
+		specification, ok := lookupSpecification(hostToSpecification, clientHello.ServerName)
 		if !ok || specification == nil {
 			return nil, nil
 		}
@@ -88,7 +115,7 @@ func vhostMuxHandleRequest(
 		}
 	}
 
-	muxSpecification, ok := hostToSpecification[host]
+	muxSpecification, ok := lookupSpecification(hostToSpecification, host)
 	if !ok {
 		return nil, &muxTypesResponseError.ResponseError{
 			ProblemDetail: problem_detail.New(http.StatusMisdirectedRequest),
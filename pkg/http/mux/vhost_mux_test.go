@@ -185,3 +185,46 @@ func TestVhostMux_PatchHttpServer(t *testing.T) {
 		}
 	})
 }
+
+func TestLookupSpecification(t *testing.T) {
+	t.Parallel()
+
+	exact := &VhostMuxSpecification{RedirectTo: "https://exact.example.com"}
+	wildcard := &VhostMuxSpecification{RedirectTo: "https://wildcard.example.com"}
+
+	hostToSpecification := map[string]*VhostMuxSpecification{
+		"app.example.com": exact,
+		"*.example.com":   wildcard,
+	}
+
+	testCases := []struct {
+		name     string
+		host     string
+		expected *VhostMuxSpecification
+	}{
+		{name: "exact", host: "app.example.com", expected: exact},
+		{name: "exact, differently cased", host: "App.Example.COM", expected: exact},
+		{name: "exact, fully qualified", host: "app.example.com.", expected: exact},
+		{name: "wildcard", host: "tenant.example.com", expected: wildcard},
+		{name: "wildcard, differently cased", host: "Tenant.example.com", expected: wildcard},
+		// A wildcard covers one label, as it does in a certificate.
+		{name: "two labels below", host: "a.tenant.example.com"},
+		{name: "the parent itself", host: "example.com"},
+		{name: "unrelated", host: "example.org"},
+		{name: "empty", host: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			specification, ok := lookupSpecification(hostToSpecification, testCase.host)
+			if ok != (testCase.expected != nil) {
+				t.Fatalf("found: got %t, want %t", ok, testCase.expected != nil)
+			}
+			if specification != testCase.expected {
+				t.Errorf("specification: got %#v, want %#v", specification, testCase.expected)
+			}
+		})
+	}
+}
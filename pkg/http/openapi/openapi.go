@@ -0,0 +1,627 @@
+// Package openapi describes a mux's endpoints as an OpenAPI 3.1 document, for the API consumers the
+// TypeScript that client_code_generation writes is of no use to: what an endpoint takes and returns
+// is read from its Hint, its body loader and its parsers, as client_code_generation reads it.
+package openapi
+
+import (
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"fmt"
+	"go/ast"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	motmedelMux "github.com/Motmedel/utils_go/pkg/http/mux"
+	muxErrors "github.com/Motmedel/utils_go/pkg/http/mux/errors"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/body_loader/body_setting"
+	endpointPkg "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/path_pattern"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/client_certificate_verifier"
+	queryTag "github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/query_extractor/tag"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/token_cookie_extractor"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/token_header_extractor"
+	openapiTypes "github.com/Motmedel/utils_go/pkg/http/openapi/types"
+	"github.com/Motmedel/utils_go/pkg/http/openapi/types/document_options"
+	motmedelJsonTag "github.com/Motmedel/utils_go/pkg/json/types/tag"
+	motmedelReflect "github.com/Motmedel/utils_go/pkg/reflect"
+	jsonschemaTypes "github.com/Motmedel/utils_go/pkg/type_export/jsonschema/types"
+	typeExportTypesContext "github.com/Motmedel/utils_go/pkg/type_export/types/context"
+	"github.com/Motmedel/utils_go/pkg/utils"
+)
+
+const (
+	schemasRefPrefix   = "#/components/schemas/"
+	responsesRefPrefix = "#/components/responses/"
+
+	problemDetailName        = "ProblemDetail"
+	problemDetailContentType = "application/problem+json"
+
+	defaultContentType = "application/json"
+
+	// wildcardParameterName names the path parameter an unnamed wildcard is described as; `*` is not a
+	// name a path template can carry.
+	wildcardParameterName = "wildcard"
+)
+
+var emptyInterfaceType = reflect.TypeFor[any]()
+
+func isEmptyInterfaceType(t reflect.Type) bool {
+	if t == nil {
+		return true
+	}
+	return t == emptyInterfaceType || (t.Kind() == reflect.Interface && t.NumMethod() == 0)
+}
+
+func isJsonContentType(contentType string) bool {
+	return contentType == defaultContentType || strings.HasSuffix(contentType, "+json")
+}
+
+// problemDetailSchema describes an RFC 9457 problem detail, whose extension members are written
+// alongside the standard ones.
+var problemDetailSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"type":     map[string]any{"type": "string", "format": "uri-reference"},
+		"title":    map[string]any{"type": "string"},
+		"status":   map[string]any{"type": "integer"},
+		"detail":   map[string]any{"type": "string"},
+		"instance": map[string]any{"type": "string", "format": "uri-reference"},
+	},
+	"additionalProperties": true,
+}
+
+func problemDetailResponseRef() *openapiTypes.Response {
+	return &openapiTypes.Response{Ref: responsesRefPrefix + problemDetailName}
+}
+
+// maxUnwrapDepth bounds how many adapters and token extractors resolveSecurityScheme looks through.
+const maxUnwrapDepth = 8
+
+// resolveSecurityScheme describes the authentication parsers the library provides, looking through
+// the adapters and JWT extractors that wrap them.
+func resolveSecurityScheme(parser any) (string, *openapiTypes.SecurityScheme) {
+	isJwt := false
+
+	for range maxUnwrapDepth {
+		if utils.IsNil(parser) {
+			return "", nil
+		}
+
+		switch typedParser := parser.(type) {
+		case *client_certificate_verifier.Parser:
+			return "mutual-tls", &openapiTypes.SecurityScheme{Type: openapiTypes.SecuritySchemeTypeMutualTls}
+		case *token_cookie_extractor.Parser:
+			if typedParser.Config == nil {
+				return "", nil
+			}
+			return "cookie-" + typedParser.Name, &openapiTypes.SecurityScheme{
+				Type: openapiTypes.SecuritySchemeTypeApiKey,
+				In:   "cookie",
+				Name: typedParser.Name,
+			}
+		case *token_header_extractor.Parser:
+			headerName := typedParser.GetHeaderName()
+			prefix := strings.TrimSpace(typedParser.GetHeaderValuePrefix())
+			if strings.EqualFold(headerName, "Authorization") && strings.EqualFold(prefix, "Bearer") {
+				securityScheme := &openapiTypes.SecurityScheme{Type: openapiTypes.SecuritySchemeTypeHttp, Scheme: "bearer"}
+				if isJwt {
+					securityScheme.BearerFormat = "JWT"
+				}
+				return "bearer", securityScheme
+			}
+			return "header-" + headerName, &openapiTypes.SecurityScheme{
+				Type: openapiTypes.SecuritySchemeTypeApiKey,
+				In:   "header",
+				Name: headerName,
+			}
+		case interface {
+			GetTokenExtractor() request_parser.RequestParser[string]
+		}:
+			isJwt = true
+			parser = typedParser.GetTokenExtractor()
+		case interface{ GetParser() any }:
+			parser = typedParser.GetParser()
+		default:
+			return "", nil
+		}
+	}
+
+	return "", nil
+}
+
+// operationId makes the operation id of the method and the templated path, a path parameter being
+// marked with "By" so that "/users/{id}" and "/users/id" are told apart.
+func operationId(method string, templatedPath string) string {
+	var builder strings.Builder
+	builder.WriteString(strings.ToLower(method))
+
+	for _, segment := range strings.Split(templatedPath, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			builder.WriteString("By")
+		}
+
+		for _, part := range strings.FieldsFunc(segment, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			runes := []rune(part)
+			runes[0] = unicode.ToUpper(runes[0])
+			builder.WriteString(string(runes))
+		}
+	}
+
+	return builder.String()
+}
+
+// uniqueOperationId returns the operation id, numbered if it is taken already, and marks it taken.
+func uniqueOperationId(operationId string, taken map[string]struct{}) string {
+	uniqueId := operationId
+	for n := 2; ; n++ {
+		if _, ok := taken[uniqueId]; !ok {
+			break
+		}
+		uniqueId = operationId + strconv.Itoa(n)
+	}
+	taken[uniqueId] = struct{}{}
+
+	return uniqueId
+}
+
+// templatePath writes the endpoint path as an OpenAPI path template and returns its path
+// parameters. A wildcard, which OpenAPI has no notion of, is described as a parameter. A path that
+// is not a well-formed pattern is matched by the mux as it is, and is described so, its braces
+// percent-encoded so as not to be read as a template.
+func templatePath(path string) (string, []*openapiTypes.Parameter, error) {
+	if !strings.HasPrefix(path, "/") {
+		return "", nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: the path does not start with a slash", muxErrors.ErrMalformedPathPattern),
+			path,
+		)
+	}
+
+	pattern, err := path_pattern.Parse(path)
+	if err != nil {
+		return strings.NewReplacer("{", "%7B", "}", "%7D").Replace(path), nil, nil
+	}
+	if pattern.IsLiteral() {
+		return path, nil, nil
+	}
+
+	var parameters []*openapiTypes.Parameter
+	var segments []string
+	for _, segment := range pattern.Segments {
+		if segment.Kind == path_pattern.Literal {
+			segments = append(segments, segment.Value)
+			continue
+		}
+
+		name := segment.Value
+		if name == path_pattern.WildcardName {
+			name = wildcardParameterName
+		}
+		segments = append(segments, "{"+name+"}")
+
+		parameter := &openapiTypes.Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   map[string]any{"type": "string"},
+		}
+		if segment.Kind == path_pattern.Wildcard {
+			parameter.Description = "The remainder of the path, which may span several segments."
+		}
+		parameters = append(parameters, parameter)
+	}
+
+	return "/" + strings.Join(segments, "/"), parameters, nil
+}
+
+// queryParameters describes the fields of the URL input type the way query_extractor reads them.
+func queryParameters(
+	schemaContext *jsonschemaTypes.Context,
+	urlInputType reflect.Type,
+) ([]*openapiTypes.Parameter, error) {
+	if isEmptyInterfaceType(urlInputType) {
+		return nil, nil
+	}
+
+	structType := motmedelReflect.RemoveIndirection(urlInputType)
+	if structType.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var parameters []*openapiTypes.Parameter
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+
+		identifier := field.Name
+		if identifier == "" || !ast.IsExported(identifier) {
+			continue
+		}
+
+		optional := false
+		var format string
+
+		if tag := queryTag.New(field.Tag.Get("query")); tag != nil {
+			if tag.Skip {
+				continue
+			}
+			if tag.Name != "" {
+				identifier = tag.Name
+			}
+			optional = tag.OmitEmpty || tag.OmitZero
+			format = tag.Format
+		} else if jsonTag := motmedelJsonTag.New(field.Tag.Get("json")); jsonTag != nil {
+			if jsonTag.Skip {
+				continue
+			}
+			if jsonTag.Name != "" {
+				identifier = jsonTag.Name
+			}
+			optional = jsonTag.OmitEmpty || jsonTag.OmitZero
+		}
+
+		schema, err := schemaContext.GetJSONSchemaType(field.Type)
+		if err != nil {
+			return nil, motmedelErrors.New(fmt.Errorf("get json schema type: %w", err), field.Type)
+		}
+
+		switch format {
+		case "email", "uuid":
+			schema["format"] = format
+		case "url":
+			schema["format"] = "uri"
+		}
+
+		parameter := &openapiTypes.Parameter{
+			Name:     identifier,
+			In:       "query",
+			Required: !optional,
+			Schema:   schema,
+		}
+		if kind := field.Type.Kind(); (kind == reflect.Slice || kind == reflect.Array) && field.Type.Elem().Kind() != reflect.Uint8 {
+			explode := true
+			parameter.Style = "form"
+			parameter.Explode = &explode
+		}
+
+		parameters = append(parameters, parameter)
+	}
+
+	return parameters, nil
+}
+
+func typeSchema(schemaContext *jsonschemaTypes.Context, reflectType reflect.Type) (map[string]any, error) {
+	if isEmptyInterfaceType(reflectType) {
+		return map[string]any{}, nil
+	}
+
+	schema, err := schemaContext.GetJSONSchemaType(reflectType)
+	if err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("get json schema type: %w", err), reflectType)
+	}
+
+	return schema, nil
+}
+
+func makeRequestBody(
+	schemaContext *jsonschemaTypes.Context,
+	endpoint *endpointPkg.Endpoint,
+) (*openapiTypes.RequestBody, error) {
+	var inputType reflect.Type
+	if hint := endpoint.Hint; hint != nil {
+		inputType = hint.InputType
+	}
+
+	bodyLoader := endpoint.BodyLoader
+	if bodyLoader == nil && isEmptyInterfaceType(inputType) {
+		return nil, nil
+	}
+	if bodyLoader != nil && bodyLoader.Setting == body_setting.Forbidden {
+		return nil, nil
+	}
+
+	contentType := defaultContentType
+	required := false
+	if bodyLoader != nil {
+		if bodyLoader.ContentType != "" {
+			contentType = bodyLoader.ContentType
+		}
+		required = bodyLoader.Setting == body_setting.Required
+	}
+
+	schema := map[string]any{}
+	if isJsonContentType(contentType) {
+		var err error
+		schema, err = typeSchema(schemaContext, inputType)
+		if err != nil {
+			return nil, fmt.Errorf("type schema (input): %w", err)
+		}
+	}
+
+	return &openapiTypes.RequestBody{
+		Required: required,
+		Content:  map[string]*openapiTypes.MediaType{contentType: {Schema: schema}},
+	}, nil
+}
+
+func staticContentType(endpoint *endpointPkg.Endpoint) string {
+	for _, header := range endpoint.StaticContent.Headers {
+		if header != nil && strings.EqualFold(header.Name, "Content-Type") {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+func makeResponses(
+	schemaContext *jsonschemaTypes.Context,
+	endpoint *endpointPkg.Endpoint,
+) (map[string]*openapiTypes.Response, error) {
+	responses := map[string]*openapiTypes.Response{"default": problemDetailResponseRef()}
+
+	hint := endpoint.Hint
+	switch {
+	case endpoint.StaticContent != nil:
+		content := map[string]*openapiTypes.MediaType{}
+		if contentType := staticContentType(endpoint); contentType != "" {
+			content[contentType] = &openapiTypes.MediaType{}
+		}
+		responses[strconv.Itoa(http.StatusOK)] = &openapiTypes.Response{Description: "OK", Content: content}
+		responses[strconv.Itoa(http.StatusNotModified)] = &openapiTypes.Response{Description: "Not Modified"}
+	case hint != nil && (!isEmptyInterfaceType(hint.OutputType) || hint.OutputContentType != ""):
+		contentType := hint.OutputContentType
+		if contentType == "" {
+			contentType = defaultContentType
+		}
+
+		var schema map[string]any
+		if isJsonContentType(contentType) {
+			var err error
+			schema, err = typeSchema(schemaContext, hint.OutputType)
+			if err != nil {
+				return nil, fmt.Errorf("type schema (output): %w", err)
+			}
+		} else {
+			schema = map[string]any{"type": "string", "contentMediaType": contentType}
+		}
+
+		responses[strconv.Itoa(http.StatusOK)] = &openapiTypes.Response{
+			Description: "OK",
+			Content:     map[string]*openapiTypes.MediaType{contentType: {Schema: schema}},
+		}
+		if hint.OutputOptional {
+			responses[strconv.Itoa(http.StatusNoContent)] = &openapiTypes.Response{Description: "No Content"}
+		}
+	default:
+		responses[strconv.Itoa(http.StatusNoContent)] = &openapiTypes.Response{Description: "No Content"}
+	}
+
+	// The errors the mux itself produces before the handler is reached.
+	var errorStatusCodes []int
+	if !utils.IsNil(endpoint.UrlParser) || !utils.IsNil(endpoint.HeaderParser) || endpoint.BodyLoader != nil {
+		errorStatusCodes = append(errorStatusCodes, http.StatusBadRequest)
+	}
+	if !utils.IsNil(endpoint.AuthenticationParser) {
+		errorStatusCodes = append(errorStatusCodes, http.StatusUnauthorized)
+	}
+	if bodyLoader := endpoint.BodyLoader; bodyLoader != nil {
+		if bodyLoader.MaxBytes > 0 {
+			errorStatusCodes = append(errorStatusCodes, http.StatusRequestEntityTooLarge)
+		}
+		if bodyLoader.ContentType != "" {
+			errorStatusCodes = append(errorStatusCodes, http.StatusUnsupportedMediaType)
+		}
+	}
+	if endpoint.RateLimitingConfiguration != nil {
+		errorStatusCodes = append(errorStatusCodes, http.StatusTooManyRequests)
+	}
+	for _, statusCode := range errorStatusCodes {
+		responses[strconv.Itoa(statusCode)] = problemDetailResponseRef()
+	}
+
+	return responses, nil
+}
+
+func makeSchemaContext(endpoints []*endpointPkg.Endpoint) (*jsonschemaTypes.Context, error) {
+	var typeElements []any
+	for _, endpoint := range endpoints {
+		if endpoint == nil || endpoint.Hint == nil {
+			continue
+		}
+
+		// The URL input type is described as query parameters rather than as a schema.
+		for _, t := range []reflect.Type{endpoint.Hint.InputType, endpoint.Hint.OutputType} {
+			if !isEmptyInterfaceType(t) {
+				typeElements = append(typeElements, t)
+			}
+		}
+	}
+
+	schemaContext := &jsonschemaTypes.Context{
+		Context:              typeExportTypesContext.New(),
+		DefinitionsRefPrefix: schemasRefPrefix,
+	}
+	if err := schemaContext.Add(typeElements...); err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("jsonschema context add: %w", err), typeElements)
+	}
+
+	return schemaContext, nil
+}
+
+// Make describes the endpoints as an OpenAPI document. An endpoint whose authentication parser is
+// recognized by no resolver, and for which no default security scheme is configured, is described
+// without a security requirement.
+func Make(endpoints []*endpointPkg.Endpoint, options ...document_options.Option) (*openapiTypes.Document, error) {
+	documentOptions := document_options.New(options...)
+
+	schemaContext, err := makeSchemaContext(endpoints)
+	if err != nil {
+		return nil, fmt.Errorf("make schema context: %w", err)
+	}
+
+	securitySchemes := map[string]*openapiTypes.SecurityScheme{}
+	paths := map[string]*openapiTypes.PathItem{}
+	operationIds := map[string]struct{}{}
+
+	for _, endpoint := range endpoints {
+		if endpoint == nil {
+			continue
+		}
+
+		if endpoint.StaticContent != nil && !documentOptions.IncludeStaticContent {
+			continue
+		}
+
+		method := strings.ToUpper(endpoint.Method)
+		if method == "" {
+			return nil, motmedelErrors.NewWithTrace(empty_error.New("method"), endpoint)
+		}
+
+		path := endpoint.Path
+		if path == "" {
+			return nil, motmedelErrors.NewWithTrace(empty_error.New("path"), endpoint)
+		}
+
+		templatedPath, parameters, err := templatePath(path)
+		if err != nil {
+			return nil, fmt.Errorf("template path: %w", err)
+		}
+
+		if hint := endpoint.Hint; hint != nil {
+			urlParameters, err := queryParameters(schemaContext, hint.UrlInputType)
+			if err != nil {
+				return nil, motmedelErrors.New(fmt.Errorf("query parameters: %w", err), endpoint)
+			}
+			parameters = append(parameters, urlParameters...)
+		}
+
+		requestBody, err := makeRequestBody(schemaContext, endpoint)
+		if err != nil {
+			return nil, motmedelErrors.New(fmt.Errorf("make request body: %w", err), endpoint)
+		}
+
+		responses, err := makeResponses(schemaContext, endpoint)
+		if err != nil {
+			return nil, motmedelErrors.New(fmt.Errorf("make responses: %w", err), endpoint)
+		}
+
+		operation := &openapiTypes.Operation{
+			OperationId: uniqueOperationId(operationId(method, templatedPath), operationIds),
+			Parameters:  parameters,
+			RequestBody: requestBody,
+			Responses:   responses,
+		}
+
+		if authenticationParser := endpoint.AuthenticationParser; !endpoint.Public && !utils.IsNil(authenticationParser) {
+			var schemeName string
+			var scheme *openapiTypes.SecurityScheme
+			if resolver := documentOptions.SecuritySchemeResolver; resolver != nil {
+				schemeName, scheme = resolver(authenticationParser)
+			}
+			if schemeName == "" {
+				schemeName, scheme = resolveSecurityScheme(authenticationParser)
+			}
+			if schemeName == "" {
+				schemeName, scheme = documentOptions.DefaultSecuritySchemeName, documentOptions.DefaultSecurityScheme
+			}
+
+			if schemeName != "" && scheme != nil {
+				securitySchemes[schemeName] = scheme
+				operation.Security = []openapiTypes.SecurityRequirement{{schemeName: {}}}
+			}
+		}
+
+		pathItem, ok := paths[templatedPath]
+		if !ok {
+			pathItem = &openapiTypes.PathItem{}
+			paths[templatedPath] = pathItem
+		}
+		(*pathItem)[strings.ToLower(method)] = operation
+	}
+
+	schemas, err := schemaContext.BuildDefinitions()
+	if err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("build definitions: %w", err), schemaContext)
+	}
+	schemas[problemDetailName] = problemDetailSchema
+
+	components := &openapiTypes.Components{
+		Schemas: schemas,
+		Responses: map[string]*openapiTypes.Response{
+			problemDetailName: {
+				Description: "A problem detail (RFC 9457).",
+				Content: map[string]*openapiTypes.MediaType{
+					problemDetailContentType: {Schema: map[string]any{"$ref": schemasRefPrefix + problemDetailName}},
+				},
+			},
+		},
+	}
+	if len(securitySchemes) != 0 {
+		components.SecuritySchemes = securitySchemes
+	}
+
+	return &openapiTypes.Document{
+		OpenApi: openapiTypes.Version,
+		Info: &openapiTypes.Info{
+			Title:       documentOptions.Title,
+			Version:     documentOptions.Version,
+			Description: documentOptions.Description,
+		},
+		Servers:    documentOptions.Servers,
+		Paths:      paths,
+		Components: components,
+	}, nil
+}
+
+// MuxEndpoints returns the endpoints registered with the mux, ordered by path and method.
+func MuxEndpoints(mux *motmedelMux.Mux) []*endpointPkg.Endpoint {
+	if mux == nil {
+		return nil
+	}
+
+	var endpoints []*endpointPkg.Endpoint
+	for _, methodToEndpoint := range mux.EndpointMap {
+		for _, endpoint := range methodToEndpoint {
+			if endpoint != nil {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+
+	slices.SortFunc(endpoints, func(a *endpointPkg.Endpoint, b *endpointPkg.Endpoint) int {
+		if pathComparison := strings.Compare(a.Path, b.Path); pathComparison != 0 {
+			return pathComparison
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+
+	return endpoints
+}
+
+// Render writes the OpenAPI document describing the mux's endpoints as indented JSON, with the
+// members of its maps sorted so that the output is the same from one run to the next.
+func Render(mux *motmedelMux.Mux, options ...document_options.Option) ([]byte, error) {
+	if mux == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("mux"))
+	}
+
+	document, err := Make(MuxEndpoints(mux), options...)
+	if err != nil {
+		return nil, fmt.Errorf("make: %w", err)
+	}
+
+	data, err := json.Marshal(document, json.Deterministic(true), jsontext.WithIndent("  "))
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("json marshal: %w", err), document)
+	}
+
+	return data, nil
+}
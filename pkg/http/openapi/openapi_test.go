@@ -0,0 +1,353 @@
+package openapi
+
+import (
+	"crypto/x509"
+	"encoding/json/v2"
+	"net/http"
+	"reflect"
+	"testing"
+
+	motmedelMux "github.com/Motmedel/utils_go/pkg/http/mux"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/body_loader"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/body_loader/body_setting"
+	endpointPkg "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint/static_content"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/rate_limiting"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser"
+	requestParserAdapter "github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/adapter"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/client_certificate_verifier"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/client_certificate_verifier/client_certificate_verifier_config"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/token_cookie_extractor"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/token_header_extractor"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/token_header_extractor/token_header_extractor_config"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	openapiTypes "github.com/Motmedel/utils_go/pkg/http/openapi/types"
+	"github.com/Motmedel/utils_go/pkg/http/openapi/types/document_options"
+)
+
+type keyInput struct {
+	Name string `json:"name"`
+}
+
+type keyOutput struct {
+	Id      string    `json:"id"`
+	Created string    `json:"created,omitempty"`
+	Input   *keyInput `json:"input"`
+}
+
+type listQuery struct {
+	Limit int      `query:"limit,omitempty"`
+	Tags  []string `query:"tag"`
+	Email string   `query:"email,format=email"`
+}
+
+func TestMake(t *testing.T) {
+	t.Parallel()
+
+	endpoints := []*endpointPkg.Endpoint{
+		{
+			Path:                 "/users/{id}/keys",
+			Method:               http.MethodPost,
+			AuthenticationParser: requestParserAdapter.New(token_cookie_extractor.New()),
+			BodyLoader: &body_loader.Loader{
+				ContentType: "application/json",
+				Setting:     body_setting.Required,
+				MaxBytes:    1024,
+			},
+			RateLimitingConfiguration: &rate_limiting.RateLimitingConfiguration{NumRequests: 1, NumSecondsExpiration: 1},
+			Hint: &endpointPkg.Hint{
+				InputType:  reflect.TypeFor[keyInput](),
+				OutputType: reflect.TypeFor[keyOutput](),
+			},
+		},
+		{
+			Path:                 "/keys",
+			Method:               http.MethodGet,
+			AuthenticationParser: requestParserAdapter.New(token_header_extractor.New()),
+			UrlParser: request_parser.New(func(*http.Request) (any, *muxResponseError.ResponseError) {
+				return nil, nil
+			}),
+			Hint: &endpointPkg.Hint{
+				UrlInputType:   reflect.TypeFor[listQuery](),
+				OutputType:     reflect.TypeFor[[]*keyOutput](),
+				OutputOptional: true,
+			},
+		},
+		{
+			Path:   "/files/{path...}",
+			Method: http.MethodDelete,
+			Public: true,
+		},
+		{
+			Path:   "/index",
+			Method: http.MethodGet,
+			Public: true,
+			StaticContent: &static_content.StaticContent{
+				StaticContentData: static_content.StaticContentData{
+					Headers: []*muxResponse.HeaderEntry{{Name: "Content-Type", Value: "text/html"}},
+				},
+			},
+		},
+	}
+
+	document, err := Make(endpoints, document_options.WithTitle("Keys"))
+	if err != nil {
+		t.Fatalf("make: %v", err)
+	}
+
+	if document.OpenApi != openapiTypes.Version || document.Info.Title != "Keys" {
+		t.Fatalf("unexpected header: %q %#v", document.OpenApi, document.Info)
+	}
+
+	if _, ok := document.Paths["/index"]; ok {
+		t.Error("expected static content to be left out by default")
+	}
+
+	postKey := (*document.Paths["/users/{id}/keys"])["post"]
+	if postKey == nil {
+		t.Fatalf("expected a post operation, got paths %v", document.Paths)
+	}
+	if postKey.OperationId != "postUsersByIdKeys" {
+		t.Errorf("operation id: got %q", postKey.OperationId)
+	}
+	if len(postKey.Parameters) != 1 || postKey.Parameters[0].In != "path" || postKey.Parameters[0].Name != "id" {
+		t.Errorf("expected the id path parameter, got %#v", postKey.Parameters)
+	}
+	if postKey.RequestBody == nil || !postKey.RequestBody.Required {
+		t.Fatalf("expected a required request body, got %#v", postKey.RequestBody)
+	}
+	if ref := postKey.RequestBody.Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/KeyInput" {
+		t.Errorf("request body schema ref: got %v", ref)
+	}
+	if ref := postKey.Responses["200"].Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/KeyOutput" {
+		t.Errorf("response schema ref: got %v", ref)
+	}
+	for _, statusCode := range []string{"default", "400", "401", "413", "415", "429"} {
+		if response := postKey.Responses[statusCode]; response == nil || response.Ref != "#/components/responses/ProblemDetail" {
+			t.Errorf("expected a problem detail response for %s, got %#v", statusCode, response)
+		}
+	}
+	if len(postKey.Security) != 1 || postKey.Security[0]["cookie-session"] == nil {
+		t.Errorf("expected the session cookie security requirement, got %#v", postKey.Security)
+	}
+
+	getKeys := (*document.Paths["/keys"])["get"]
+	if getKeys == nil {
+		t.Fatal("expected a get operation")
+	}
+	if len(getKeys.Parameters) != 3 {
+		t.Fatalf("expected three query parameters, got %#v", getKeys.Parameters)
+	}
+	if limit := getKeys.Parameters[0]; limit.Name != "limit" || limit.Required {
+		t.Errorf("expected an optional limit parameter, got %#v", limit)
+	}
+	if tags := getKeys.Parameters[1]; tags.Name != "tag" || tags.Explode == nil || !*tags.Explode {
+		t.Errorf("expected an exploded tag parameter, got %#v", tags)
+	}
+	if email := getKeys.Parameters[2]; email.Schema["format"] != "email" {
+		t.Errorf("expected an email format, got %#v", email.Schema)
+	}
+	if items, _ := getKeys.Responses["200"].Content["application/json"].Schema["items"].(map[string]any); items["$ref"] != "#/components/schemas/KeyOutput" {
+		t.Errorf("expected an array of key outputs, got %#v", getKeys.Responses["200"])
+	}
+	if getKeys.Responses["204"] == nil {
+		t.Error("expected a 204 response for an optional output")
+	}
+	if len(getKeys.Security) != 1 || getKeys.Security[0]["bearer"] == nil {
+		t.Errorf("expected the bearer security requirement, got %#v", getKeys.Security)
+	}
+
+	deleteFile := (*document.Paths["/files/{path}"])["delete"]
+	if deleteFile == nil {
+		t.Fatalf("expected the wildcard path to be templated, got paths %v", document.Paths)
+	}
+	if deleteFile.Security != nil {
+		t.Errorf("expected no security requirement for a public endpoint, got %#v", deleteFile.Security)
+	}
+	if deleteFile.Responses["204"] == nil {
+		t.Error("expected a 204 response for an endpoint without output")
+	}
+
+	components := document.Components
+	if components.SecuritySchemes["bearer"].Scheme != "bearer" || components.SecuritySchemes["cookie-session"].In != "cookie" {
+		t.Errorf("unexpected security schemes: %#v", components.SecuritySchemes)
+	}
+	for _, name := range []string{"KeyInput", "KeyOutput", "ProblemDetail"} {
+		if components.Schemas[name] == nil {
+			t.Errorf("expected the %s schema", name)
+		}
+	}
+}
+
+func TestMake_SecuritySchemes(t *testing.T) {
+	t.Parallel()
+
+	unknownParser := request_parser.New(func(*http.Request) (any, *muxResponseError.ResponseError) { return nil, nil })
+
+	clientCertificateVerifier, err := client_certificate_verifier.New(
+		client_certificate_verifier_config.WithRoots(x509.NewCertPool()),
+	)
+	if err != nil {
+		t.Fatalf("client certificate verifier new: %v", err)
+	}
+
+	testCases := []struct {
+		name       string
+		parser     request_parser.RequestParser[any]
+		options    []document_options.Option
+		wantScheme string
+	}{
+		{
+			name:       "api key header",
+			parser:     requestParserAdapter.New(token_header_extractor.New(token_header_extractor_config.WithHeaderName("X-Api-Key"), token_header_extractor_config.WithHeaderValuePrefix(""))),
+			wantScheme: "header-X-Api-Key",
+		},
+		{
+			name:       "mutual tls",
+			parser:     requestParserAdapter.New(clientCertificateVerifier),
+			wantScheme: "mutual-tls",
+		},
+		{name: "unknown parser", parser: unknownParser},
+		{
+			name:       "default scheme",
+			parser:     unknownParser,
+			options:    []document_options.Option{document_options.WithDefaultSecurityScheme("custom", &openapiTypes.SecurityScheme{Type: "http", Scheme: "basic"})},
+			wantScheme: "custom",
+		},
+		{
+			name:   "resolver",
+			parser: unknownParser,
+			options: []document_options.Option{
+				document_options.WithSecuritySchemeResolver(
+					func(request_parser.RequestParser[any]) (string, *openapiTypes.SecurityScheme) {
+						return "resolved", &openapiTypes.SecurityScheme{Type: "http", Scheme: "basic"}
+					},
+				),
+			},
+			wantScheme: "resolved",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			document, err := Make(
+				[]*endpointPkg.Endpoint{{Path: "/x", Method: http.MethodGet, AuthenticationParser: testCase.parser}},
+				testCase.options...,
+			)
+			if err != nil {
+				t.Fatalf("make: %v", err)
+			}
+
+			security := (*document.Paths["/x"])["get"].Security
+			if testCase.wantScheme == "" {
+				if security != nil {
+					t.Fatalf("expected no security requirement, got %#v", security)
+				}
+				return
+			}
+			if len(security) != 1 || security[0][testCase.wantScheme] == nil {
+				t.Fatalf("expected the %q security requirement, got %#v", testCase.wantScheme, security)
+			}
+			if document.Components.SecuritySchemes[testCase.wantScheme] == nil {
+				t.Fatalf("expected the %q security scheme component", testCase.wantScheme)
+			}
+		})
+	}
+}
+
+func TestMake_Paths(t *testing.T) {
+	t.Parallel()
+
+	var endpoints []*endpointPkg.Endpoint
+	for _, path := range []string{"/static/*", "/static", "/users/{id}", "/users/id", "/users/by/id", "/a/*/b", "/a{b}"} {
+		endpoints = append(endpoints, &endpointPkg.Endpoint{Path: path, Method: http.MethodGet, Public: true})
+	}
+
+	document, err := Make(endpoints)
+	if err != nil {
+		t.Fatalf("make: %v", err)
+	}
+
+	testCases := []struct {
+		templatedPath   string
+		wantOperationId string
+	}{
+		{templatedPath: "/static/{wildcard}", wantOperationId: "getStaticByWildcard"},
+		{templatedPath: "/static", wantOperationId: "getStatic"},
+		{templatedPath: "/users/{id}", wantOperationId: "getUsersById"},
+		{templatedPath: "/users/id", wantOperationId: "getUsersId"},
+		{templatedPath: "/users/by/id", wantOperationId: "getUsersById2"},
+		// Paths that are not well-formed patterns are matched, and described, as they are.
+		{templatedPath: "/a/*/b", wantOperationId: "getAB"},
+		{templatedPath: "/a%7Bb%7D", wantOperationId: "getA7Bb7D"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.templatedPath, func(t *testing.T) {
+			t.Parallel()
+
+			pathItem := document.Paths[testCase.templatedPath]
+			if pathItem == nil || (*pathItem)["get"] == nil {
+				t.Fatalf("expected a get operation, got paths %v", document.Paths)
+			}
+			if operationId := (*pathItem)["get"].OperationId; operationId != testCase.wantOperationId {
+				t.Errorf("operation id: got %q, want %q", operationId, testCase.wantOperationId)
+			}
+		})
+	}
+}
+
+func TestMake_Errors(t *testing.T) {
+	t.Parallel()
+
+	for _, endpoint := range []*endpointPkg.Endpoint{
+		{Path: "/x"},
+		{Method: http.MethodGet},
+		{Path: "relative", Method: http.MethodGet},
+	} {
+		if _, err := Make([]*endpointPkg.Endpoint{endpoint}); err == nil {
+			t.Errorf("expected an error for %#v", endpoint)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Render(nil); err == nil {
+		t.Fatal("expected an error for a nil mux")
+	}
+
+	mux := motmedelMux.New(
+		&endpointPkg.Endpoint{Path: "/b", Method: http.MethodGet, Public: true},
+		&endpointPkg.Endpoint{Path: "/a", Method: http.MethodGet, Public: true},
+	)
+
+	data, err := Render(mux)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	again, err := Render(mux)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if string(data) != string(again) {
+		t.Error("expected the output to be deterministic")
+	}
+
+	var document map[string]any
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if document["openapi"] != openapiTypes.Version {
+		t.Errorf("openapi: got %v", document["openapi"])
+	}
+	paths, _ := document["paths"].(map[string]any)
+	if len(paths) != 2 {
+		t.Errorf("expected two paths, got %v", paths)
+	}
+}
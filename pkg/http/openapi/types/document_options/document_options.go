@@ -0,0 +1,89 @@
+package document_options
+
+import (
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser"
+	openapiTypes "github.com/Motmedel/utils_go/pkg/http/openapi/types"
+)
+
+const (
+	DefaultTitle   = "API"
+	DefaultVersion = "0.0.0"
+)
+
+// SecuritySchemeResolver names the security scheme an authentication parser corresponds to. An
+// empty name means the resolver does not recognize the parser.
+type SecuritySchemeResolver func(request_parser.RequestParser[any]) (string, *openapiTypes.SecurityScheme)
+
+type Option func(*Options)
+
+type Options struct {
+	Title       string
+	Version     string
+	Description string
+	Servers     []*openapiTypes.Server
+	// SecuritySchemeResolver is consulted before the parsers the library recognizes on its own.
+	SecuritySchemeResolver SecuritySchemeResolver
+	// DefaultSecuritySchemeName and DefaultSecurityScheme describe how an endpoint authenticates
+	// whose authentication parser is recognized by no resolver.
+	DefaultSecuritySchemeName string
+	DefaultSecurityScheme     *openapiTypes.SecurityScheme
+	// IncludeStaticContent includes the endpoints serving static content, which are left out by
+	// default: they are pages and assets rather than an API.
+	IncludeStaticContent bool
+}
+
+func New(options ...Option) *Options {
+	opts := &Options{
+		Title:   DefaultTitle,
+		Version: DefaultVersion,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(opts)
+		}
+	}
+	return opts
+}
+
+func WithTitle(title string) Option {
+	return func(opts *Options) {
+		opts.Title = title
+	}
+}
+
+func WithVersion(version string) Option {
+	return func(opts *Options) {
+		opts.Version = version
+	}
+}
+
+func WithDescription(description string) Option {
+	return func(opts *Options) {
+		opts.Description = description
+	}
+}
+
+func WithServers(servers ...*openapiTypes.Server) Option {
+	return func(opts *Options) {
+		opts.Servers = servers
+	}
+}
+
+func WithSecuritySchemeResolver(resolver SecuritySchemeResolver) Option {
+	return func(opts *Options) {
+		opts.SecuritySchemeResolver = resolver
+	}
+}
+
+func WithDefaultSecurityScheme(name string, scheme *openapiTypes.SecurityScheme) Option {
+	return func(opts *Options) {
+		opts.DefaultSecuritySchemeName = name
+		opts.DefaultSecurityScheme = scheme
+	}
+}
+
+func WithIncludeStaticContent(includeStaticContent bool) Option {
+	return func(opts *Options) {
+		opts.IncludeStaticContent = includeStaticContent
+	}
+}
@@ -0,0 +1,111 @@
+package document_options
+
+import (
+	"testing"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser"
+	openapiTypes "github.com/Motmedel/utils_go/pkg/http/openapi/types"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	options := New(nil)
+	if options == nil {
+		t.Fatalf("nil options")
+	}
+
+	if options.Title != DefaultTitle {
+		t.Errorf("title: got %q", options.Title)
+	}
+	if options.Version != DefaultVersion {
+		t.Errorf("version: got %q", options.Version)
+	}
+	if options.IncludeStaticContent {
+		t.Errorf("expected include static content to default to false")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		option Option
+		check  func(t *testing.T, options *Options)
+	}{
+		{
+			name:   "with title",
+			option: WithTitle("Keys"),
+			check: func(t *testing.T, options *Options) {
+				if options.Title != "Keys" {
+					t.Errorf("title: got %q", options.Title)
+				}
+			},
+		},
+		{
+			name:   "with version",
+			option: WithVersion("1.0.0"),
+			check: func(t *testing.T, options *Options) {
+				if options.Version != "1.0.0" {
+					t.Errorf("version: got %q", options.Version)
+				}
+			},
+		},
+		{
+			name:   "with description",
+			option: WithDescription("The key service."),
+			check: func(t *testing.T, options *Options) {
+				if options.Description != "The key service." {
+					t.Errorf("description: got %q", options.Description)
+				}
+			},
+		},
+		{
+			name:   "with servers",
+			option: WithServers(&openapiTypes.Server{Url: "https://example.com"}),
+			check: func(t *testing.T, options *Options) {
+				if len(options.Servers) != 1 || options.Servers[0].Url != "https://example.com" {
+					t.Errorf("servers: got %#v", options.Servers)
+				}
+			},
+		},
+		{
+			name: "with security scheme resolver",
+			option: WithSecuritySchemeResolver(
+				func(request_parser.RequestParser[any]) (string, *openapiTypes.SecurityScheme) { return "", nil },
+			),
+			check: func(t *testing.T, options *Options) {
+				if options.SecuritySchemeResolver == nil {
+					t.Errorf("expected a security scheme resolver")
+				}
+			},
+		},
+		{
+			name:   "with default security scheme",
+			option: WithDefaultSecurityScheme("basic", &openapiTypes.SecurityScheme{Type: "http", Scheme: "basic"}),
+			check: func(t *testing.T, options *Options) {
+				if options.DefaultSecuritySchemeName != "basic" || options.DefaultSecurityScheme == nil {
+					t.Errorf("default security scheme: got %q %#v", options.DefaultSecuritySchemeName, options.DefaultSecurityScheme)
+				}
+			},
+		},
+		{
+			name:   "with include static content",
+			option: WithIncludeStaticContent(true),
+			check: func(t *testing.T, options *Options) {
+				if !options.IncludeStaticContent {
+					t.Errorf("expected include static content")
+				}
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			testCase.check(t, New(testCase.option))
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package types
+
+// The subset of the OpenAPI 3.1 object model (https://spec.openapis.org/oas/v3.1.0) a mux's endpoints
+// can be described with. Schemas are JSON Schema 2020-12, which OpenAPI 3.1 uses as is, and are
+// held as the maps type_export/jsonschema produces.
+
+const Version = "3.1.0"
+
+type Document struct {
+	OpenApi    string                `json:"openapi"`
+	Info       *Info                 `json:"info"`
+	Servers    []*Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components *Components           `json:"components,omitempty"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	Url         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps a lower-case method, such as "get", to the operation it performs.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationId string                `json:"operationId,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Style       string         `json:"style,omitempty"`
+	Explode     *bool          `json:"explode,omitempty"`
+	Schema      map[string]any `json:"schema,omitempty"`
+}
+
+type MediaType struct {
+	Schema map[string]any `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is either a response or, with only Ref set, a reference to one among the components.
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+const (
+	SecuritySchemeTypeApiKey    = "apiKey"
+	SecuritySchemeTypeHttp      = "http"
+	SecuritySchemeTypeMutualTls = "mutualTLS"
+)
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement maps the name of a security scheme among the components to the scopes it
+// requires; schemes other than OAuth 2.0 and OpenID Connect require none.
+type SecurityRequirement map[string][]string
+
+type Components struct {
+	Schemas         map[string]any             `json:"schemas,omitempty"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
@@ -0,0 +1,342 @@
+package recorder
+
+import (
+	"encoding/base64"
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/types/har"
+	"github.com/Motmedel/utils_go/pkg/http/types/http_context_extractor"
+)
+
+// CreatorName is the name of the creator of the HTTP Archives that cassettes are exported to.
+const CreatorName = "github.com/Motmedel/utils_go/pkg/http/recorder"
+
+const bodyEncodingBase64 = "base64"
+
+type Request struct {
+	Method string      `json:"method"`
+	Url    string      `json:"url"`
+	Header http.Header `json:"header,omitzero"`
+	Body   string      `json:"body,omitzero"`
+	// BodyEncoding is "base64" when Body is the base64 encoding of a body that is not valid UTF-8.
+	BodyEncoding string `json:"body_encoding,omitzero"`
+}
+
+type Response struct {
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header,omitzero"`
+	Body         string      `json:"body,omitzero"`
+	BodyEncoding string      `json:"body_encoding,omitzero"`
+}
+
+// Interaction is a request and the response it was answered with, with their secrets masked.
+type Interaction struct {
+	Request  *Request  `json:"request"`
+	Response *Response `json:"response"`
+}
+
+// Cassette is a sequence of recorded interactions.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+
+	return base64.StdEncoding.EncodeToString(body), bodyEncodingBase64
+}
+
+func decodeBody(body string, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(body), nil
+	case bodyEncodingBase64:
+		data, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("base64 std encoding decode string: %w", err))
+		}
+		return data, nil
+	default:
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("unsupported body encoding: %q", encoding))
+	}
+}
+
+// maskHeader returns a copy of the header with its secrets masked as http_context_extractor masks
+// them, and the values of the masked headers replaced whole.
+func maskHeader(header http.Header, maskedHeaders map[string]struct{}) http.Header {
+	if header == nil {
+		return nil
+	}
+
+	masked := make(http.Header, len(header))
+	for name, values := range header {
+		maskedValues := make([]string, len(values))
+		for i, value := range values {
+			if _, ok := maskedHeaders[http.CanonicalHeaderKey(name)]; ok {
+				maskedValues[i] = http_context_extractor.MaskedValue
+			} else {
+				maskedValues[i] = http_context_extractor.MaskHeaderValue(name, value)
+			}
+		}
+		masked[name] = maskedValues
+	}
+
+	return masked
+}
+
+func makeMaskedHeaders(names []string) map[string]struct{} {
+	maskedHeaders := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		maskedHeaders[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+
+	return maskedHeaders
+}
+
+// LoadCassette reads a cassette saved with Save.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("os read file: %w", err), path)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("json unmarshal (cassette): %w", err), path)
+	}
+
+	return &cassette, nil
+}
+
+// Save writes the cassette to the file, deterministically so that re-recording the same
+// interactions leaves it unchanged.
+func (cassette *Cassette) Save(path string) error {
+	data, err := json.Marshal(cassette, json.Deterministic(true), jsontext.WithIndent("  "))
+	if err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("json marshal (cassette): %w", err))
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("os write file: %w", err), path)
+	}
+
+	return nil
+}
+
+func harHeaders(header http.Header) []*har.NameValue {
+	headers := make([]*har.NameValue, 0, len(header))
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[name] {
+			headers = append(headers, &har.NameValue{Name: name, Value: value})
+		}
+	}
+
+	return headers
+}
+
+func harCookies(cookies []*http.Cookie) []*har.Cookie {
+	harCookies := make([]*har.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		harCookie := &har.Cookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HttpOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Expires.IsZero() {
+			harCookie.Expires = &cookie.Expires
+		}
+		harCookies = append(harCookies, harCookie)
+	}
+
+	return harCookies
+}
+
+func (interaction *Interaction) harEntry() (*har.Entry, error) {
+	request := interaction.Request
+	response := interaction.Response
+	if request == nil || response == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("interaction request or response"))
+	}
+
+	requestBody, err := decodeBody(request.Body, request.BodyEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("decode body (request): %w", err)
+	}
+
+	responseBody, err := decodeBody(response.Body, response.BodyEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("decode body (response): %w", err)
+	}
+
+	harRequest := &har.Request{
+		Method:      request.Method,
+		Url:         request.Url,
+		HttpVersion: "HTTP/1.1",
+		Cookies:     harCookies((&http.Request{Header: request.Header}).Cookies()),
+		Headers:     harHeaders(request.Header),
+		QueryString: []*har.NameValue{},
+		HeadersSize: -1,
+		BodySize:    int64(len(requestBody)),
+	}
+
+	if requestUrl, err := url.Parse(request.Url); err == nil {
+		harRequest.QueryString = harHeaders(http.Header(requestUrl.Query()))
+	}
+
+	if len(requestBody) > 0 {
+		harRequest.PostData = &har.PostData{MimeType: request.Header.Get("Content-Type"), Text: string(requestBody)}
+	}
+
+	return &har.Entry{
+		Request: harRequest,
+		Response: &har.Response{
+			Status:      response.StatusCode,
+			StatusText:  http.StatusText(response.StatusCode),
+			HttpVersion: "HTTP/1.1",
+			Cookies:     harCookies((&http.Response{Header: response.Header}).Cookies()),
+			Headers:     harHeaders(response.Header),
+			Content: &har.Content{
+				Size:     int64(len(responseBody)),
+				MimeType: response.Header.Get("Content-Type"),
+				Text:     response.Body,
+				Encoding: response.BodyEncoding,
+			},
+			RedirectUrl: response.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    int64(len(responseBody)),
+		},
+		Cache:   &har.Cache{},
+		Timings: &har.Timings{},
+	}, nil
+}
+
+// Har returns the cassette as an HTTP Archive.
+func (cassette *Cassette) Har() (*har.Har, error) {
+	entries := make([]*har.Entry, 0, len(cassette.Interactions))
+	for i, interaction := range cassette.Interactions {
+		entry, err := interaction.harEntry()
+		if err != nil {
+			return nil, fmt.Errorf("har entry %d: %w", i, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return &har.Har{
+		Log: &har.Log{
+			Version: har.Version,
+			Creator: &har.Creator{Name: CreatorName},
+			Entries: entries,
+		},
+	}, nil
+}
+
+// ExportHar writes the cassette to the file as an HTTP Archive.
+func (cassette *Cassette) ExportHar(path string) error {
+	archive, err := cassette.Har()
+	if err != nil {
+		return fmt.Errorf("har: %w", err)
+	}
+
+	data, err := json.Marshal(archive, jsontext.WithIndent("  "))
+	if err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("json marshal (har): %w", err))
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("os write file: %w", err), path)
+	}
+
+	return nil
+}
+
+// headerFromHar returns the header of the HAR headers. The pseudo-headers of HTTP/2 and HTTP/3 are
+// left out, as are the headers that describe the encoding of a body that the archive holds decoded.
+func headerFromHar(headers []*har.NameValue) http.Header {
+	header := make(http.Header, len(headers))
+	for _, nameValue := range headers {
+		if nameValue == nil || strings.HasPrefix(nameValue.Name, ":") {
+			continue
+		}
+
+		switch name := http.CanonicalHeaderKey(nameValue.Name); name {
+		case "Content-Encoding", "Content-Length", "Transfer-Encoding":
+		default:
+			header.Add(name, nameValue.Value)
+		}
+	}
+
+	return header
+}
+
+// FromHar returns a cassette of the entries of the HTTP Archive, such as one captured by a browser,
+// with their secrets masked, and the values of the masked headers replaced whole.
+func FromHar(archive *har.Har, maskedHeaders ...string) (*Cassette, error) {
+	if archive == nil || archive.Log == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("har log"))
+	}
+
+	maskedHeaderSet := makeMaskedHeaders(maskedHeaders)
+
+	cassette := &Cassette{}
+	for i, entry := range archive.Log.Entries {
+		if entry == nil || entry.Request == nil || entry.Response == nil {
+			return nil, motmedelErrors.NewWithTrace(nil_error.New("har entry, request or response"), i)
+		}
+
+		request := &Request{
+			Method: entry.Request.Method,
+			Url:    entry.Request.Url,
+			Header: maskHeader(headerFromHar(entry.Request.Headers), maskedHeaderSet),
+		}
+		if postData := entry.Request.PostData; postData != nil {
+			request.Body, request.BodyEncoding = encodeBody([]byte(postData.Text))
+		}
+
+		response := &Response{
+			StatusCode: entry.Response.Status,
+			Header:     maskHeader(headerFromHar(entry.Response.Headers), maskedHeaderSet),
+		}
+		if content := entry.Response.Content; content != nil {
+			body, err := decodeBody(content.Text, content.Encoding)
+			if err != nil {
+				return nil, fmt.Errorf("decode body (har entry %d): %w", i, err)
+			}
+			response.Body, response.BodyEncoding = encodeBody(body)
+		}
+
+		cassette.Interactions = append(cassette.Interactions, &Interaction{Request: request, Response: response})
+	}
+
+	return cassette, nil
+}
+
+// ImportHar reads an HTTP Archive file into a cassette; see FromHar.
+func ImportHar(path string, maskedHeaders ...string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("os read file: %w", err), path)
+	}
+
+	var archive har.Har
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("json unmarshal (har): %w", err), path)
+	}
+
+	return FromHar(&archive, maskedHeaders...)
+}
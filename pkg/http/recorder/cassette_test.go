@@ -0,0 +1,132 @@
+package recorder
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/Motmedel/utils_go/pkg/http/types/har"
+)
+
+func TestHarRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	cassette := &Cassette{
+		Interactions: []*Interaction{
+			{
+				Request: &Request{
+					Method: http.MethodPost,
+					Url:    "https://example.com/upload?kind=image",
+					Header: http.Header{"Content-Type": {"text/plain"}, "Cookie": {"a=(MASKED)"}},
+					Body:   "hello",
+				},
+				Response: &Response{
+					StatusCode:   http.StatusOK,
+					Header:       http.Header{"Content-Type": {"application/octet-stream"}},
+					Body:         "/w==",
+					BodyEncoding: bodyEncodingBase64,
+				},
+			},
+		},
+	}
+
+	archive, err := cassette.Har()
+	if err != nil {
+		t.Fatalf("har: %v", err)
+	}
+
+	entry := archive.Log.Entries[0]
+	if archive.Log.Version != har.Version || archive.Log.Creator.Name != CreatorName {
+		t.Errorf("log: got %q, %+v", archive.Log.Version, archive.Log.Creator)
+	}
+	if len(entry.Request.QueryString) != 1 || entry.Request.QueryString[0].Value != "image" {
+		t.Errorf("query string: got %+v", entry.Request.QueryString)
+	}
+	if len(entry.Request.Cookies) != 1 || entry.Request.Cookies[0].Name != "a" {
+		t.Errorf("cookies: got %+v", entry.Request.Cookies)
+	}
+	if entry.Request.PostData == nil || entry.Request.PostData.Text != "hello" || entry.Request.BodySize != 5 {
+		t.Errorf("post data: got %+v", entry.Request.PostData)
+	}
+	if entry.Response.Content.Size != 1 || entry.Response.Content.Encoding != bodyEncodingBase64 {
+		t.Errorf("content: got %+v", entry.Response.Content)
+	}
+
+	path := filepath.Join(t.TempDir(), "cassette.har")
+	if err := cassette.ExportHar(path); err != nil {
+		t.Fatalf("export har: %v", err)
+	}
+
+	imported, err := ImportHar(path)
+	if err != nil {
+		t.Fatalf("import har: %v", err)
+	}
+
+	got := imported.Interactions[0]
+	want := cassette.Interactions[0]
+	if got.Request.Method != want.Request.Method || got.Request.Url != want.Request.Url || got.Request.Body != want.Request.Body {
+		t.Errorf("request: got %+v", got.Request)
+	}
+	if got.Response.StatusCode != want.Response.StatusCode || got.Response.Body != want.Response.Body ||
+		got.Response.BodyEncoding != want.Response.BodyEncoding {
+		t.Errorf("response: got %+v", got.Response)
+	}
+}
+
+func TestFromHar(t *testing.T) {
+	t.Parallel()
+
+	// As a browser captures it: lower-case names, pseudo-headers, and a body stored decoded.
+	archive := &har.Har{
+		Log: &har.Log{
+			Version: har.Version,
+			Entries: []*har.Entry{
+				{
+					Request: &har.Request{
+						Method: http.MethodGet,
+						Url:    "https://example.com/",
+						Headers: []*har.NameValue{
+							{Name: ":authority", Value: "example.com"},
+							{Name: "authorization", Value: "Bearer " + testJws},
+							{Name: "x-api-key", Value: "secret-key"},
+						},
+					},
+					Response: &har.Response{
+						Status: http.StatusOK,
+						Headers: []*har.NameValue{
+							{Name: "content-encoding", Value: "gzip"},
+							{Name: "content-type", Value: "text/html"},
+						},
+						Content: &har.Content{Text: "<p>hi</p>", MimeType: "text/html"},
+					},
+				},
+			},
+		},
+	}
+
+	cassette, err := FromHar(archive, "X-Api-Key")
+	if err != nil {
+		t.Fatalf("from har: %v", err)
+	}
+
+	interaction := cassette.Interactions[0]
+	if _, ok := interaction.Request.Header[":authority"]; ok {
+		t.Error("expected the pseudo-header to be left out")
+	}
+	if got := interaction.Request.Header.Get("Authorization"); got != "Bearer eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.(MASKED)" {
+		t.Errorf("authorization: got %q", got)
+	}
+	if got := interaction.Request.Header.Get("X-Api-Key"); got != "(MASKED)" {
+		t.Errorf("api key: got %q", got)
+	}
+	if got := interaction.Response.Header.Get("Content-Encoding"); got != "" {
+		t.Errorf("content-encoding: got %q", got)
+	}
+	if interaction.Response.Body != "<p>hi</p>" || interaction.Response.Header.Get("Content-Type") != "text/html" {
+		t.Errorf("response: got %+v", interaction.Response)
+	}
+
+	if _, err := FromHar(&har.Har{}); err == nil {
+		t.Error("expected an error for an archive without a log")
+	}
+}
@@ -0,0 +1,258 @@
+package recorder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/recorder/recorder_config"
+	"github.com/Motmedel/utils_go/pkg/http/types/http_context_extractor"
+)
+
+var ErrNoInteraction = errors.New("no matching interaction")
+
+// Recorder is an http.RoundTripper that records interactions to a cassette and replays them, so that
+// tests of clients can run against what a service once answered rather than against a server of
+// their own. Secrets -- in headers, and in the query parameters and bodies that the configuration
+// names -- are masked as they are recorded, and requests are compared with the recorded ones as
+// masked. A recorded interaction answers one request only, and the interactions that match
+// a request answer it in the order they were recorded, so that replay is deterministic.
+type Recorder struct {
+	config        *recorder_config.Config
+	maskedHeaders map[string]struct{}
+	mutex         sync.Mutex
+	cassette      *Cassette
+	used          map[*Interaction]struct{}
+}
+
+func readRequestBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+	defer request.Body.Close()
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("io read all (request body): %w", err))
+	}
+
+	return body, nil
+}
+
+func requestMethod(request *http.Request) string {
+	if request.Method == "" {
+		return http.MethodGet
+	}
+
+	return request.Method
+}
+
+// maskUrl returns the URL with the query parameters masked, and whether the bodies of the requests
+// to it, and of the responses, are masked.
+func (recorder *Recorder) maskUrl(urlStruct *url.URL) (string, bool, bool) {
+	config := recorder.config
+
+	return http_context_extractor.MaskUrl(urlStruct, config.MaskedUrlParams...).String(),
+		http_context_extractor.UrlMatches(urlStruct, config.MaskedRequestBodyUrls...),
+		http_context_extractor.UrlMatches(urlStruct, config.MaskedResponseBodyUrls...)
+}
+
+// maskBody returns the body, masked if it is to be, encoded for a cassette.
+func maskBody(body []byte, masked bool) (string, string) {
+	if masked && len(body) != 0 {
+		return http_context_extractor.MaskedValue, ""
+	}
+
+	return encodeBody(body)
+}
+
+// matches reports whether the interaction answers the request, which is masked. A recorded request
+// is masked again, as one imported from an HTTP Archive or recorded with other patterns may not be.
+func (recorder *Recorder) matches(interaction *Interaction, request *Request) bool {
+	recorded := interaction.Request
+	if recorded == nil || interaction.Response == nil || recorded.Method != request.Method {
+		return false
+	}
+
+	recordedUrl, err := url.Parse(recorded.Url)
+	if err != nil {
+		return false
+	}
+
+	maskedUrl, maskRequestBody, _ := recorder.maskUrl(recordedUrl)
+	if maskedUrl != request.Url {
+		return false
+	}
+
+	for _, name := range recorder.config.MatchHeaders {
+		if !slices.Equal(recorded.Header.Values(name), request.Header.Values(name)) {
+			return false
+		}
+	}
+
+	if !recorder.config.SkipMatchBody {
+		recordedBody, err := decodeBody(recorded.Body, recorded.BodyEncoding)
+		if err != nil {
+			return false
+		}
+
+		body, bodyEncoding := maskBody(recordedBody, maskRequestBody)
+		if body != request.Body || bodyEncoding != request.BodyEncoding {
+			return false
+		}
+	}
+
+	return true
+}
+
+// take returns the first unused interaction that matches the request, and marks it used.
+func (recorder *Recorder) take(request *Request) *Interaction {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	for _, interaction := range recorder.cassette.Interactions {
+		if _, ok := recorder.used[interaction]; ok {
+			continue
+		}
+
+		if recorder.matches(interaction, request) {
+			recorder.used[interaction] = struct{}{}
+			return interaction
+		}
+	}
+
+	return nil
+}
+
+func (response *Response) httpResponse(request *http.Request) (*http.Response, error) {
+	body, err := decodeBody(response.Body, response.BodyEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("decode body (response): %w", err)
+	}
+
+	header := response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.StatusCode, http.StatusText(response.StatusCode)),
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}, nil
+}
+
+func (recorder *Recorder) record(
+	request *http.Request,
+	maskedRequest *Request,
+	requestBody []byte,
+	maskResponseBody bool,
+) (*http.Response, error) {
+	outgoing := request.Clone(request.Context())
+	if requestBody != nil {
+		outgoing.Body = io.NopCloser(bytes.NewReader(requestBody))
+		outgoing.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(requestBody)), nil
+		}
+		outgoing.ContentLength = int64(len(requestBody))
+	}
+
+	response, err := recorder.config.Next.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("io read all (response body): %w", err))
+	}
+
+	maskedResponse := &Response{
+		StatusCode: response.StatusCode,
+		Header:     maskHeader(response.Header, recorder.maskedHeaders),
+	}
+	maskedResponse.Body, maskedResponse.BodyEncoding = maskBody(responseBody, maskResponseBody)
+
+	interaction := &Interaction{Request: maskedRequest, Response: maskedResponse}
+
+	recorder.mutex.Lock()
+	recorder.cassette.Interactions = append(recorder.cassette.Interactions, interaction)
+	recorder.used[interaction] = struct{}{}
+	recorder.mutex.Unlock()
+
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+	response.ContentLength = int64(len(responseBody))
+
+	return response, nil
+}
+
+func (recorder *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request == nil || request.URL == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("request or request url"))
+	}
+
+	requestBody, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedUrl, maskRequestBody, maskResponseBody := recorder.maskUrl(request.URL)
+
+	maskedRequest := &Request{
+		Method: requestMethod(request),
+		Url:    maskedUrl,
+		Header: maskHeader(request.Header, recorder.maskedHeaders),
+	}
+	maskedRequest.Body, maskedRequest.BodyEncoding = maskBody(requestBody, maskRequestBody)
+
+	if recorder.config.Mode != recorder_config.ModeRecord {
+		if interaction := recorder.take(maskedRequest); interaction != nil {
+			return interaction.Response.httpResponse(request)
+		}
+
+		if recorder.config.Mode == recorder_config.ModeReplay {
+			return nil, fmt.Errorf("%w: method=%s url=%s", ErrNoInteraction, maskedRequest.Method, maskedRequest.Url)
+		}
+	}
+
+	return recorder.record(request, maskedRequest, requestBody, maskResponseBody)
+}
+
+// Cassette returns the cassette with the interactions recorded so far, to be saved once the
+// requests have been made.
+func (recorder *Recorder) Cassette() *Cassette {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return &Cassette{Interactions: slices.Clone(recorder.cassette.Interactions)}
+}
+
+// New returns a recorder of the cassette; a nil cassette is an empty one.
+func New(cassette *Cassette, options ...recorder_config.Option) *Recorder {
+	config := recorder_config.New(options...)
+
+	if cassette == nil {
+		cassette = &Cassette{}
+	}
+
+	return &Recorder{
+		config:        config,
+		maskedHeaders: makeMaskedHeaders(config.MaskedHeaders),
+		cassette:      &Cassette{Interactions: slices.Clone(cassette.Interactions)},
+		used:          make(map[*Interaction]struct{}),
+	}
+}
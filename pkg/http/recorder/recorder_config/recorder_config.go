@@ -0,0 +1,107 @@
+package recorder_config
+
+import (
+	"net/http"
+
+	"github.com/Motmedel/utils_go/pkg/schema"
+)
+
+// Mode is what a recorder does with a request.
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette only, and fails those it has no answer for.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request and records the interaction.
+	ModeRecord
+	// ModeReplayOrRecord answers requests from the cassette, and sends and records those it has no
+	// answer for.
+	ModeReplayOrRecord
+)
+
+type Config struct {
+	Mode Mode
+	// Next sends the requests that are recorded; http.DefaultTransport is used when none is set.
+	Next http.RoundTripper
+	// MatchHeaders are the headers, besides the method, the URL and the body, a request must match a
+	// recorded one in to be answered by it. They are compared as masked.
+	MatchHeaders []string
+	// SkipMatchBody makes requests match recorded ones whatever their bodies.
+	SkipMatchBody bool
+	// MaskedHeaders are masked whole when recorded, in addition to the secrets masked by
+	// http_context_extractor.MaskHeaderValue.
+	MaskedHeaders []string
+	// MaskedUrlParams, MaskedRequestBodyUrls and MaskedResponseBodyUrls are the URL patterns of the
+	// query parameters and the bodies that are masked when recorded, as those of
+	// http_context_extractor.Extractor.
+	MaskedUrlParams        []*schema.Url
+	MaskedRequestBodyUrls  []*schema.Url
+	MaskedResponseBodyUrls []*schema.Url
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		Mode: ModeReplay,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(config)
+		}
+	}
+
+	if config.Next == nil {
+		config.Next = http.DefaultTransport
+	}
+
+	return config
+}
+
+func WithMode(mode Mode) Option {
+	return func(config *Config) {
+		config.Mode = mode
+	}
+}
+
+func WithNext(next http.RoundTripper) Option {
+	return func(config *Config) {
+		config.Next = next
+	}
+}
+
+func WithMatchHeaders(matchHeaders ...string) Option {
+	return func(config *Config) {
+		config.MatchHeaders = matchHeaders
+	}
+}
+
+func WithSkipMatchBody(skipMatchBody bool) Option {
+	return func(config *Config) {
+		config.SkipMatchBody = skipMatchBody
+	}
+}
+
+func WithMaskedHeaders(maskedHeaders ...string) Option {
+	return func(config *Config) {
+		config.MaskedHeaders = maskedHeaders
+	}
+}
+
+func WithMaskedUrlParams(urlPatterns ...*schema.Url) Option {
+	return func(config *Config) {
+		config.MaskedUrlParams = urlPatterns
+	}
+}
+
+func WithMaskedRequestBodyUrls(urlPatterns ...*schema.Url) Option {
+	return func(config *Config) {
+		config.MaskedRequestBodyUrls = urlPatterns
+	}
+}
+
+func WithMaskedResponseBodyUrls(urlPatterns ...*schema.Url) Option {
+	return func(config *Config) {
+		config.MaskedResponseBodyUrls = urlPatterns
+	}
+}
@@ -0,0 +1,56 @@
+package recorder_config
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.Mode != ModeReplay {
+		t.Errorf("mode: got %v", config.Mode)
+	}
+	if config.Next != http.DefaultTransport {
+		t.Errorf("next: got %v", config.Next)
+	}
+	if config.MatchHeaders != nil || config.MaskedHeaders != nil {
+		t.Errorf("headers: got %v, %v", config.MatchHeaders, config.MaskedHeaders)
+	}
+	if config.SkipMatchBody {
+		t.Error("expected bodies to be matched")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	next := &http.Transport{}
+
+	config := New(
+		nil,
+		WithMode(ModeReplayOrRecord),
+		WithNext(next),
+		WithMatchHeaders("Accept"),
+		WithSkipMatchBody(true),
+		WithMaskedHeaders("X-Api-Key"),
+	)
+
+	if config.Mode != ModeReplayOrRecord {
+		t.Errorf("mode: got %v", config.Mode)
+	}
+	if config.Next != next {
+		t.Errorf("next: got %v", config.Next)
+	}
+	if !slices.Equal(config.MatchHeaders, []string{"Accept"}) {
+		t.Errorf("match headers: got %v", config.MatchHeaders)
+	}
+	if !config.SkipMatchBody {
+		t.Error("expected bodies not to be matched")
+	}
+	if !slices.Equal(config.MaskedHeaders, []string{"X-Api-Key"}) {
+		t.Errorf("masked headers: got %v", config.MaskedHeaders)
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	motmedelContext "github.com/Motmedel/utils_go/pkg/context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/acme/manager"
+	"github.com/Motmedel/utils_go/pkg/http/acme/manager/manager_config"
+	motmedelMux "github.com/Motmedel/utils_go/pkg/http/mux"
+	"github.com/Motmedel/utils_go/pkg/http/service/service_config"
+)
+
+// makeAcmeManager makes the manager of the service's certificates: one for the host and for each
+// virtual host that is not a wildcard, which neither challenge type can validate.
+func makeAcmeManager(config *service_config.Config) (*manager.Manager, error) {
+	if config == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("service config"))
+	}
+
+	var hosts []string
+	if config.Host != "" {
+		hosts = append(hosts, config.Host)
+	}
+	for _, virtualHostConfig := range config.VirtualHosts {
+		if virtualHostConfig != nil && !isWildcardHost(virtualHostConfig.Host) {
+			hosts = append(hosts, virtualHostConfig.Host)
+		}
+	}
+
+	acmeManager, err := manager.New(
+		append([]manager_config.Option{manager_config.WithHosts(hosts...)}, config.AcmeOptions...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("manager new: %w", err)
+	}
+
+	return acmeManager, nil
+}
+
+// patchAcme makes the mux answer the HTTP-01 challenges of the manager.
+func patchAcme(mux *motmedelMux.Mux, acmeManager *manager.Manager) error {
+	if mux == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("mux"))
+	}
+
+	if acmeManager == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("acme manager"))
+	}
+
+	mux.Add(acmeManager.HttpChallengeEndpoint())
+
+	return nil
+}
+
+// makeAcmeTlsConfig is the TLS configuration of a service whose certificates the manager obtains. A
+// certificate the manager cannot produce is left to fallback, where the service has one: a
+// certificate reloader, or the configured files, which the server falls back on when GetCertificate
+// returns none.
+func makeAcmeTlsConfig(
+	acmeManager *manager.Manager,
+	fallback func(*tls.ClientHelloInfo) (*tls.Certificate, error),
+) (*tls.Config, error) {
+	if acmeManager == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("acme manager"))
+	}
+
+	tlsConfig := acmeManager.TlsConfig()
+	if fallback == nil {
+		return tlsConfig, nil
+	}
+
+	tlsConfig.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		certificate, err := acmeManager.GetCertificate(hello)
+		if err != nil {
+			// A host the manager is not configured for is what the fallback is for, rather than a
+			// failure.
+			if !errors.Is(err, manager.ErrHostNotAllowed) {
+				slog.WarnContext(
+					motmedelContext.WithError(hello.Context(), fmt.Errorf("acme manager get certificate: %w", err)),
+					"An ACME certificate could not be obtained; the configured certificate is served instead.",
+				)
+			}
+			return fallback(hello)
+		}
+
+		return certificate, nil
+	}
+
+	return tlsConfig, nil
+}
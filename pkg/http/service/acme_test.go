@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/acme/acme_config"
+	"github.com/Motmedel/utils_go/pkg/http/acme/cache/memory_cache"
+	"github.com/Motmedel/utils_go/pkg/http/acme/manager/manager_config"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	"github.com/Motmedel/utils_go/pkg/http/service/service_config"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
+	"github.com/Motmedel/utils_go/pkg/testing/acme_server"
+)
+
+func acmeOptions(server *acme_server.Server) []manager_config.Option {
+	return []manager_config.Option{
+		manager_config.WithCache(memory_cache.New()),
+		manager_config.WithOcspStapling(false),
+		manager_config.WithIssueTimeout(10 * time.Second),
+		manager_config.WithClientOptions(
+			acme_config.WithDirectoryUrl(server.DirectoryUrl),
+			acme_config.WithTermsOfServiceAgreed(true),
+			acme_config.WithPollInterval(10*time.Millisecond),
+			acme_config.WithFetchOptions(fetch_config.WithHttpClient(server.Client())),
+		),
+	}
+}
+
+// writeCertificateFiles writes a self-signed certificate for the host and its key, as a service
+// configured with certificate files is given.
+func writeCertificateFiles(t *testing.T, host string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fallback"},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("x509 create certificate: %v", err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("x509 marshal ec private key: %v", err)
+	}
+
+	directory := t.TempDir()
+	certificateFile := filepath.Join(directory, "certificate.pem")
+	keyFile := filepath.Join(directory, "key.pem")
+	if err := os.WriteFile(certificateFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("os write file: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatalf("os write file: %v", err)
+	}
+
+	return certificateFile, keyFile
+}
+
+func TestServeWithAcme(t *testing.T) {
+	t.Parallel()
+
+	server, err := acme_server.New()
+	if err != nil {
+		t.Fatalf("acme server new: %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	service, err := New(
+		service_config.WithHost("example.com"),
+		service_config.WithEndpoints(
+			&endpoint.Endpoint{
+				Path:   "/",
+				Method: http.MethodGet,
+				Handler: func(_ *http.Request, _ []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+					return &muxResponse.Response{Body: []byte("hello")}, nil
+				},
+				Public: true,
+			},
+		),
+		service_config.WithAcme(acmeOptions(server)...),
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	listener := listen(t)
+	address := listener.Addr().String()
+	// The TLS-ALPN-01 challenge is answered by the service's own TLS server.
+	server.SetTlsChallengeAddress(address)
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(t.Context()))
+	defer cancel()
+	go func() { _ = service.ServeListener(ctx, listener) }()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: server.Roots(), ServerName: "example.com"},
+		},
+	}
+	t.Cleanup(client.CloseIdleConnections)
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "https://"+address+"/", nil)
+	if err != nil {
+		t.Fatalf("http new request with context: %v", err)
+	}
+	request.Host = "example.com"
+
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("client do: %v", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("io read all: %v", err)
+	}
+	if string(body) != "hello" {
+		t.Errorf("body: got %q", body)
+	}
+	if issued := server.Issued(); issued != 1 {
+		t.Errorf("expected one certificate to be issued, got %d", issued)
+	}
+}
+
+func TestServeWithAcmeFallsBackToCertificateFiles(t *testing.T) {
+	t.Parallel()
+
+	server, err := acme_server.New()
+	if err != nil {
+		t.Fatalf("acme server new: %v", err)
+	}
+	t.Cleanup(server.Close)
+	// Nothing answers the challenges, so no certificate is obtained.
+	server.SetTlsChallengeAddress("127.0.0.1:1")
+	server.SetHttpChallengeAddress("127.0.0.1:1")
+
+	certificateFile, keyFile := writeCertificateFiles(t, "example.com")
+
+	service, err := New(
+		service_config.WithHost("example.com"),
+		service_config.WithCertificateFiles(certificateFile, keyFile),
+		service_config.WithAcme(acmeOptions(server)...),
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	listener := listen(t)
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(t.Context()))
+	defer cancel()
+	go func() { _ = service.ServeListener(ctx, listener) }()
+
+	for _, serverName := range []string{"example.com", "other.example.com"} {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: serverName, InsecureSkipVerify: true}}
+		connection, err := dialer.DialContext(t.Context(), "tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("tls dialer dial context (%s): %v", serverName, err)
+		}
+
+		peerCertificates := connection.(*tls.Conn).ConnectionState().PeerCertificates
+		_ = connection.Close()
+
+		if len(peerCertificates) == 0 || peerCertificates[0].Subject.CommonName != "fallback" {
+			t.Errorf("expected the configured certificate for %s, got %v", serverName, peerCertificates)
+		}
+	}
+}
+
+func TestNewWithAcmeWithoutHosts(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(service_config.WithAcme(manager_config.WithCache(memory_cache.New()))); err == nil {
+		t.Fatal("expected an error for acme without a host")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	motmedelMux "github.com/Motmedel/utils_go/pkg/http/mux"
 	endpointPkg "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
 	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint/static_content"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/path_pattern"
 	muxUtils "github.com/Motmedel/utils_go/pkg/http/mux/utils"
 	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
 	contentTypeParsing "github.com/Motmedel/utils_go/pkg/http/types/content_type"
@@ -111,6 +112,11 @@ func patchSitemap(mux *motmedelMux.Mux, baseUrl *url.URL) (string, error) {
 				continue
 			}
 
+			// A path with named segments or a wildcard is not a location a crawler can visit.
+			if pattern, err := path_pattern.Parse(endpoint.Path); err == nil && !pattern.IsLiteral() {
+				continue
+			}
+
 			pathUrl := baseUrl.JoinPath(endpoint.Path)
 			if pathUrl == nil {
 				return "", motmedelErrors.NewWithTrace(nil_error.New("path url"), endpoint.Path)
@@ -3,24 +3,31 @@ package service
 import (
 	"fmt"
 	"net/url"
-	"strings"
+	"slices"
 
 	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
 	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
 	motmedelMux "github.com/Motmedel/utils_go/pkg/http/mux"
 	csp "github.com/Motmedel/utils_go/pkg/http/types/content_security_policy"
+	"github.com/Motmedel/utils_go/pkg/http/types/permissions_policy"
 	cspUtils "github.com/Motmedel/utils_go/pkg/http/utils/content_security_policy"
+	ppUtils "github.com/Motmedel/utils_go/pkg/http/utils/permissions_policy"
 )
 
-const permissionsPolicyHeaderName = "Permissions-Policy"
+// identityCredentialsGetFeature is the feature without which the browser refuses a document's call
+// for the user's identity, whatever the content security policy says.
+const identityCredentialsGetFeature = "identity-credentials-get"
 
 // patchFedCm lets the documents ask the identity providers who the user is, through the browser's
 // federated credential management: the providers are permitted as connect-src, which is what the
 // browser fetches their configuration and accounts over, and identity-credentials-get is permitted
-// for each of them, without which the browser refuses the call whatever the policy says.
+// for their origins, in the one directive that a permissions policy has per feature.
 //
 // The providers are whichever the service federates to; nothing here is particular to any of them.
 func patchFedCm(mux *motmedelMux.Mux, providerUrls ...*url.URL) error {
+	providerUrls = slices.DeleteFunc(slices.Clone(providerUrls), func(providerUrl *url.URL) bool {
+		return providerUrl == nil
+	})
 	if len(providerUrls) == 0 {
 		return nil
 	}
@@ -29,27 +36,6 @@ func patchFedCm(mux *motmedelMux.Mux, providerUrls ...*url.URL) error {
 		return motmedelErrors.NewWithTrace(nil_error.New("mux"))
 	}
 
-	defaultDocumentHeaders := mux.DefaultDocumentHeaders
-	if defaultDocumentHeaders == nil {
-		return motmedelErrors.NewWithTrace(nil_error.NewWithInstance("map", "default document headers"))
-	}
-
-	var permissionsPolicyEntries []string
-	for _, providerUrl := range providerUrls {
-		if providerUrl == nil {
-			continue
-		}
-
-		permissionsPolicyEntries = append(
-			permissionsPolicyEntries,
-			fmt.Sprintf("identity-credentials-get=(self %q)", providerUrl.String()),
-		)
-	}
-
-	if len(permissionsPolicyEntries) == 0 {
-		return nil
-	}
-
 	err := patchContentSecurityPolicy(
 		mux,
 		func(contentSecurityPolicy *csp.ContentSecurityPolicy) error {
@@ -61,13 +47,16 @@ func patchFedCm(mux *motmedelMux.Mux, providerUrls ...*url.URL) error {
 		return fmt.Errorf("patch content security policy: %w", err)
 	}
 
-	permissionsPolicy := defaultDocumentHeaders[permissionsPolicyHeaderName]
-	if permissionsPolicy != "" {
-		permissionsPolicy += ", "
+	err = patchPermissionsPolicy(
+		mux,
+		func(permissionsPolicy *permissions_policy.PermissionsPolicy) error {
+			ppUtils.PatchPermissionsPolicyWithOrigins(permissionsPolicy, identityCredentialsGetFeature, providerUrls...)
+			return nil
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("patch permissions policy: %w", err)
 	}
-	permissionsPolicy += strings.Join(permissionsPolicyEntries, ", ")
-
-	defaultDocumentHeaders[permissionsPolicyHeaderName] = permissionsPolicy
 
 	return nil
 }
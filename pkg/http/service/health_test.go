@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/health/health_config"
+	"github.com/Motmedel/utils_go/pkg/http/service/service_config"
+)
+
+// getStatusCode performs a request and returns the status code it was answered with.
+func getStatusCode(t *testing.T, url string) int {
+	t.Helper()
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("http new request with context: %v", err)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("client do: %v", err)
+	}
+	_ = response.Body.Close()
+
+	return response.StatusCode
+}
+
+func TestServeListenerDrains(t *testing.T) {
+	t.Parallel()
+
+	drainDelay := 500 * time.Millisecond
+
+	service, err := New(
+		service_config.WithHost("example.com"),
+		service_config.WithEndpoints(noContentEndpoint()),
+		service_config.WithHealth(health_config.WithPaths("/healthz", "/readyz")),
+		service_config.WithDrainDelay(drainDelay),
+		service_config.WithShutdownTimeout(time.Second),
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if service.HealthChecker == nil {
+		t.Fatal("expected a health checker")
+	}
+
+	listener := listen(t)
+	baseUrl := "http://" + listener.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(t.Context()))
+	defer cancel()
+
+	served := make(chan error, 1)
+	go func() { served <- service.ServeListener(ctx, listener) }()
+
+	waitUntilServing(t, listener.Addr().String())
+
+	// A probe addressing the instance is answered, though the service answers for its host only.
+	if statusCode := getStatusCode(t, baseUrl+"/"); statusCode != http.StatusMisdirectedRequest {
+		t.Errorf("the service's own endpoint: got %d", statusCode)
+	}
+	for _, path := range []string{"/healthz", "/readyz"} {
+		if statusCode := getStatusCode(t, baseUrl+path); statusCode != http.StatusOK {
+			t.Errorf("%s: got %d", path, statusCode)
+		}
+	}
+
+	stopped := time.Now()
+	cancel()
+
+	// While draining, the service is still served but no longer ready.
+	if statusCode := getStatusCode(t, baseUrl+"/readyz"); statusCode != http.StatusServiceUnavailable {
+		t.Errorf("readyz while draining: got %d", statusCode)
+	}
+	if statusCode := getStatusCode(t, baseUrl+"/healthz"); statusCode != http.StatusOK {
+		t.Errorf("healthz while draining: got %d", statusCode)
+	}
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("serve listener: %v", err)
+		}
+		if elapsed := time.Since(stopped); elapsed < drainDelay {
+			t.Errorf("serving stopped after %v, before the drain delay", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serving never stopped")
+	}
+}
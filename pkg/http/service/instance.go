@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	motmedelMux "github.com/Motmedel/utils_go/pkg/http/mux"
+	endpointPkg "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+)
+
+// instanceHandler serves what concerns the instance rather than a host -- its health, its metrics
+// -- ahead of the handler it wraps, which may answer for particular hosts only: a probe or a scraper
+// commonly addresses the instance, by its address, rather than the host.
+type instanceHandler struct {
+	instanceMux *motmedelMux.Mux
+	paths       []string
+	next        http.Handler
+}
+
+func (handler *instanceHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	if request != nil && request.URL != nil && slices.Contains(handler.paths, request.URL.Path) {
+		handler.instanceMux.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	handler.next.ServeHTTP(responseWriter, request)
+}
+
+// makeInstanceHandler makes the handler that serves the endpoints ahead of next.
+func makeInstanceHandler(next http.Handler, endpoints ...*endpointPkg.Endpoint) (http.Handler, error) {
+	if next == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("handler"))
+	}
+
+	var paths []string
+	for _, endpoint := range endpoints {
+		if endpoint != nil && !slices.Contains(paths, endpoint.Path) {
+			paths = append(paths, endpoint.Path)
+		}
+	}
+
+	return &instanceHandler{
+		instanceMux: motmedelMux.New(endpoints...),
+		paths:       paths,
+		next:        next,
+	}, nil
+}
+
+// checkInstanceEndpoints makes sure that no endpoint of the muxes is at the path of one of the
+// endpoints, which answer for every host and would shadow it.
+func checkInstanceEndpoints(
+	endpoints []*endpointPkg.Endpoint,
+	serviceMux *motmedelMux.Mux,
+	virtualHostMuxes map[string]*motmedelMux.Mux,
+) error {
+	for _, endpoint := range endpoints {
+		if endpoint == nil {
+			continue
+		}
+
+		if serviceMux != nil && len(serviceMux.EndpointMap[endpoint.Path]) != 0 {
+			return motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: an endpoint at the path of an instance endpoint", motmedelErrors.ErrValidationError),
+				endpoint.Path,
+			)
+		}
+
+		for virtualHost, virtualHostMux := range virtualHostMuxes {
+			if virtualHostMux != nil && len(virtualHostMux.EndpointMap[endpoint.Path]) != 0 {
+				return motmedelErrors.NewWithTrace(
+					fmt.Errorf(
+						"%w: an endpoint at the path of an instance endpoint (virtual host)",
+						motmedelErrors.ErrValidationError,
+					),
+					endpoint.Path, virtualHost,
+				)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/mux_metrics"
+	"github.com/Motmedel/utils_go/pkg/http/service/service_config"
+)
+
+func endpointAt(path string) *endpoint.Endpoint {
+	pathEndpoint := noContentEndpoint()
+	pathEndpoint.Path = path
+
+	return pathEndpoint
+}
+
+func TestNewWithShadowedInstanceEndpoints(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		options []service_config.Option
+		wantErr bool
+	}{
+		{
+			name: "metrics",
+			options: []service_config.Option{
+				service_config.WithEndpoints(endpointAt(mux_metrics.DefaultPath)),
+				service_config.WithMetrics(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "health",
+			options: []service_config.Option{
+				service_config.WithEndpoints(endpointAt("/readyz")),
+				service_config.WithHealth(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "virtual host",
+			options: []service_config.Option{
+				service_config.WithVirtualHost("api.example.com", service_config.WithEndpoints(endpointAt("/healthz"))),
+				service_config.WithHealth(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "metrics served by the caller",
+			options: []service_config.Option{
+				service_config.WithEndpoints(endpointAt(mux_metrics.DefaultPath)),
+				service_config.WithMetrics(),
+				service_config.WithMetricsPath(""),
+			},
+		},
+		{
+			name: "other paths",
+			options: []service_config.Option{
+				service_config.WithEndpoints(endpointAt("/status")),
+				service_config.WithHealth(),
+				service_config.WithMetrics(),
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := New(append([]service_config.Option{service_config.WithHost("example.com")}, testCase.options...)...)
+			if testCase.wantErr {
+				if !errors.Is(err, motmedelErrors.ErrValidationError) {
+					t.Errorf("new: got %v, want a validation error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("new: %v", err)
+			}
+		})
+	}
+}
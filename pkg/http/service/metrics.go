@@ -0,0 +1,24 @@
+package service
+
+import (
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	motmedelMux "github.com/Motmedel/utils_go/pkg/http/mux"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/mux_metrics"
+)
+
+// patchMetrics makes the mux record the metrics of the requests it serves. The muxes of the service
+// share the metrics, their endpoints being told apart by path.
+func patchMetrics(mux *motmedelMux.Mux, muxMetrics *mux_metrics.Metrics) error {
+	if mux == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("mux"))
+	}
+
+	if muxMetrics == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("mux metrics"))
+	}
+
+	mux.Metrics = muxMetrics
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/mux_metrics"
+	"github.com/Motmedel/utils_go/pkg/http/service/service_config"
+	"github.com/Motmedel/utils_go/pkg/metrics"
+)
+
+func TestServeListenerMetrics(t *testing.T) {
+	t.Parallel()
+
+	service, err := New(
+		service_config.WithHost("example.com"),
+		service_config.WithEndpoints(noContentEndpoint()),
+		service_config.WithVirtualHost("other.example.com", service_config.WithEndpoints(noContentEndpoint())),
+		service_config.WithVirtualHost("third.example.com", service_config.WithEndpoints(noContentEndpoint())),
+		service_config.WithMetrics(),
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if service.Metrics == nil {
+		t.Fatal("expected metrics")
+	}
+	for virtualHost, virtualHostMux := range service.VirtualHostMuxes {
+		if virtualHostMux.Metrics == nil || virtualHostMux.Metrics.Registry != service.Metrics.Registry {
+			t.Errorf("expected the metrics of virtual host %q to record in the service's registry", virtualHost)
+		}
+	}
+
+	baseUrl := "http://" + serveListener(t, service)
+
+	for _, host := range []string{"example.com", "example.com", "other.example.com", "third.example.com"} {
+		request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, baseUrl+"/", nil)
+		if err != nil {
+			t.Fatalf("http new request with context: %v", err)
+		}
+		request.Host = host
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("client do: %v", err)
+		}
+		_ = response.Body.Close()
+		if response.StatusCode != http.StatusNoContent {
+			t.Errorf("%s: got %d", host, response.StatusCode)
+		}
+	}
+
+	// The metrics are served for whichever host a scraper asks for.
+	response, err := http.Get(baseUrl + mux_metrics.DefaultPath)
+	if err != nil {
+		t.Fatalf("http get: %v", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("metrics: got %d", response.StatusCode)
+	}
+	if contentType := response.Header.Get("Content-Type"); contentType != metrics.OpenMetricsContentType {
+		t.Errorf("content type: got %q", contentType)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("io read all: %v", err)
+	}
+	// The same path of each host is a series of its own.
+	for _, line := range []string{
+		`http_server_requests_total{host="",method="GET",endpoint="/",status_class="2xx"} 2`,
+		`http_server_requests_total{host="other.example.com",method="GET",endpoint="/",status_class="2xx"} 1`,
+		`http_server_requests_total{host="third.example.com",method="GET",endpoint="/",status_class="2xx"} 1`,
+	} {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("expected %s in the exposition:\n%s", line, body)
+		}
+	}
+}
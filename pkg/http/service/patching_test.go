@@ -18,6 +18,7 @@ import (
 	"github.com/Motmedel/utils_go/pkg/http/service/service_config"
 	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
 	"github.com/Motmedel/utils_go/pkg/http/types/content_security_policy"
+	"github.com/Motmedel/utils_go/pkg/http/types/permissions_policy"
 	cspUtils "github.com/Motmedel/utils_go/pkg/http/utils/content_security_policy"
 )
 
@@ -345,11 +346,12 @@ func TestFedCm(t *testing.T) {
 	t.Parallel()
 
 	provider := &url.URL{Scheme: "https", Host: "accounts.example.com"}
+	otherProvider := &url.URL{Scheme: "https", Host: "id.example.org", Path: "/fedcm.json"}
 
 	service, err := New(
 		service_config.WithHost("example.com"),
 		service_config.WithProfile(service_config.ProfilePublicWeb),
-		service_config.WithFedCm(provider),
+		service_config.WithFedCm(provider, otherProvider),
 	)
 	if err != nil {
 		t.Fatalf("new: %v", err)
@@ -363,11 +365,16 @@ func TestFedCm(t *testing.T) {
 		t.Errorf("the policy does not permit the provider:\n%s", policy)
 	}
 
-	// Without the permission the browser refuses the call whatever the policy permits.
-	permissionsPolicy := mux.DefaultDocumentHeaders[permissionsPolicyHeaderName]
-	if expected := `identity-credentials-get=(self "https://accounts.example.com")`; !strings.Contains(permissionsPolicy, expected) {
+	// Without the permission the browser refuses the call whatever the policy permits. The
+	// providers are permitted in the one directive, a browser taking only the last of a feature's.
+	permissionsPolicy := mux.DefaultDocumentHeaders[permissions_policy.HeaderName]
+	expected := `identity-credentials-get=(self "https://accounts.example.com" "https://id.example.org")`
+	if !strings.Contains(permissionsPolicy, expected) {
 		t.Errorf("the permissions policy lacks %q:\n%s", expected, permissionsPolicy)
 	}
+	if count := strings.Count(permissionsPolicy, "identity-credentials-get"); count != 1 {
+		t.Errorf("identity-credentials-get: got %d directives, want one:\n%s", count, permissionsPolicy)
+	}
 
 	// What the permissions policy said before is kept.
 	if !strings.Contains(permissionsPolicy, "geolocation=()") {
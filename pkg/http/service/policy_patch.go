@@ -0,0 +1,333 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	motmedelMux "github.com/Motmedel/utils_go/pkg/http/mux"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/middleware"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+	"github.com/Motmedel/utils_go/pkg/http/service/service_config"
+	"github.com/Motmedel/utils_go/pkg/http/types/cross_origin_policy"
+	"github.com/Motmedel/utils_go/pkg/http/types/document_policy"
+	"github.com/Motmedel/utils_go/pkg/http/types/permissions_policy"
+)
+
+// patchPermissionsPolicyHeader hands the permissions policy in the headers to patch, and writes
+// back what it made of it. Headers that say none say the default, which is what is patched then.
+func patchPermissionsPolicyHeader(
+	headers map[string]string,
+	patch func(*permissions_policy.PermissionsPolicy) error,
+) error {
+	if headers == nil {
+		return motmedelErrors.NewWithTrace(nil_error.NewWithInstance("map", "headers"))
+	}
+
+	if patch == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("patch"))
+	}
+
+	permissionsPolicyString, ok := headers[permissions_policy.HeaderName]
+	if !ok {
+		permissionsPolicyString = response_writer.DefaultPermissionsPolicy.String()
+	}
+
+	permissionsPolicy, err := permissions_policy.Parse([]byte(permissionsPolicyString))
+	if err != nil {
+		return motmedelErrors.New(
+			fmt.Errorf("permissions policy parse: %w", err),
+			permissionsPolicyString,
+		)
+	}
+	if permissionsPolicy == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("permissions policy"))
+	}
+
+	if err := patch(permissionsPolicy); err != nil {
+		return fmt.Errorf("patch: %w", err)
+	}
+
+	setOrDeleteHeader(headers, permissions_policy.HeaderName, permissionsPolicy.String())
+
+	return nil
+}
+
+// patchDocumentPolicyHeader hands the document policy in the headers to patch, and writes back
+// what it made of it. There is no default document policy; headers that say none are patched from
+// an empty one.
+func patchDocumentPolicyHeader(
+	headers map[string]string,
+	patch func(*document_policy.DocumentPolicy) error,
+) error {
+	if headers == nil {
+		return motmedelErrors.NewWithTrace(nil_error.NewWithInstance("map", "headers"))
+	}
+
+	if patch == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("patch"))
+	}
+
+	documentPolicyString := headers[document_policy.HeaderName]
+
+	documentPolicy, err := document_policy.Parse([]byte(documentPolicyString))
+	if err != nil {
+		return motmedelErrors.New(
+			fmt.Errorf("document policy parse: %w", err),
+			documentPolicyString,
+		)
+	}
+	if documentPolicy == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("document policy"))
+	}
+
+	if err := patch(documentPolicy); err != nil {
+		return fmt.Errorf("patch: %w", err)
+	}
+
+	setOrDeleteHeader(headers, document_policy.HeaderName, documentPolicy.String())
+
+	return nil
+}
+
+// setOrDeleteHeader sets the header, or deletes it where there is nothing to say: a policy with
+// nothing in it is better not sent than sent empty.
+func setOrDeleteHeader(headers map[string]string, name string, value string) {
+	if value == "" {
+		delete(headers, name)
+		return
+	}
+
+	headers[name] = value
+}
+
+// patchPolicyHeaders patches the policies in the headers that are answered with in general and on
+// documents, as the patch says.
+func patchPolicyHeaders(
+	defaultHeaders map[string]string,
+	documentHeaders map[string]string,
+	policyPatch *service_config.PolicyPatch,
+) error {
+	if policyPatch == nil {
+		return nil
+	}
+
+	if defaultHeaders == nil {
+		return motmedelErrors.NewWithTrace(nil_error.NewWithInstance("map", "default headers"))
+	}
+
+	if documentHeaders == nil {
+		return motmedelErrors.NewWithTrace(nil_error.NewWithInstance("map", "default document headers"))
+	}
+
+	if patch := policyPatch.PermissionsPolicy; patch != nil {
+		if err := patchPermissionsPolicyHeader(documentHeaders, patch); err != nil {
+			return fmt.Errorf("patch permissions policy header: %w", err)
+		}
+	}
+
+	if patch := policyPatch.DocumentPolicy; patch != nil {
+		if err := patchDocumentPolicyHeader(documentHeaders, patch); err != nil {
+			return fmt.Errorf("patch document policy header: %w", err)
+		}
+	}
+
+	if openerPolicy := policyPatch.OpenerPolicy; openerPolicy != nil {
+		value := openerPolicy.String()
+		if value == "" {
+			return motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: cross-origin opener policy", motmedelErrors.ErrValidationError),
+				openerPolicy,
+			)
+		}
+		documentHeaders[cross_origin_policy.OpenerPolicyHeaderName] = value
+	}
+
+	if embedderPolicy := policyPatch.EmbedderPolicy; embedderPolicy != nil {
+		value := embedderPolicy.String()
+		if value == "" {
+			return motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: cross-origin embedder policy", motmedelErrors.ErrValidationError),
+				embedderPolicy,
+			)
+		}
+		documentHeaders[cross_origin_policy.EmbedderPolicyHeaderName] = value
+	}
+
+	if resourcePolicy := policyPatch.ResourcePolicy; resourcePolicy != "" {
+		defaultHeaders[cross_origin_policy.ResourcePolicyHeaderName] = string(resourcePolicy)
+	}
+
+	return nil
+}
+
+// patchPermissionsPolicy hands the permissions policy a document is answered with to patch, and
+// writes back what it made of it.
+func patchPermissionsPolicy(
+	mux *motmedelMux.Mux,
+	patch func(*permissions_policy.PermissionsPolicy) error,
+) error {
+	if mux == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("mux"))
+	}
+
+	if err := patchPermissionsPolicyHeader(mux.DefaultDocumentHeaders, patch); err != nil {
+		return fmt.Errorf("patch permissions policy header: %w", err)
+	}
+
+	return nil
+}
+
+// changedHeaderEntries returns what the patched headers say differently from the headers they were
+// patched from, as entries that replace what the latter would have said. A header the patch
+// removed is replaced with nothing.
+func changedHeaderEntries(headers map[string]string, patchedHeaders map[string]string) []*response.HeaderEntry {
+	var headerEntries []*response.HeaderEntry
+	for _, name := range slices.Sorted(maps.Keys(patchedHeaders)) {
+		if value, ok := headers[name]; ok && value == patchedHeaders[name] {
+			continue
+		}
+		headerEntries = append(
+			headerEntries,
+			&response.HeaderEntry{Name: name, Value: patchedHeaders[name], Overwrite: true},
+		)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		if _, ok := patchedHeaders[name]; !ok {
+			headerEntries = append(headerEntries, &response.HeaderEntry{Name: name, Overwrite: true})
+		}
+	}
+
+	return headerEntries
+}
+
+// headerEntriesMiddleware adds the header entries to the responses of the handler it wraps. A
+// handler that wrote its response itself has sent its headers already, and is left as it is.
+func headerEntriesMiddleware(headerEntries []*response.HeaderEntry) middleware.HandlerMiddleware {
+	return func(next middleware.Handler) middleware.Handler {
+		return func(
+			request *http.Request,
+			responseWriter *response_writer.ResponseWriter,
+		) (*response.Response, *response_error.ResponseError) {
+			handlerResponse, responseError := next(request, responseWriter)
+			if responseError != nil {
+				return handlerResponse, responseError
+			}
+
+			if responseWriter != nil && responseWriter.WriteHeaderCalled {
+				return handlerResponse, nil
+			}
+
+			if handlerResponse == nil {
+				handlerResponse = &response.Response{}
+			}
+			handlerResponse.Headers = append(handlerResponse.Headers, headerEntries...)
+
+			return handlerResponse, nil
+		}
+	}
+}
+
+// patchEndpointPolicies makes the endpoints at the path answer with the policies the rest of the
+// mux answers with, patched as the patches say. The mux is patched for the rest first, so that what
+// the endpoints start from is what they would have answered with otherwise.
+func patchEndpointPolicies(
+	mux *motmedelMux.Mux,
+	path string,
+	policyPatches ...*service_config.PolicyPatch,
+) error {
+	if mux == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("mux"))
+	}
+
+	methodToEndpoint := mux.EndpointMap[path]
+	if len(methodToEndpoint) == 0 {
+		return motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: no endpoint at the path of a policy patch", motmedelErrors.ErrValidationError),
+			path,
+		)
+	}
+
+	defaultHeaders := maps.Clone(mux.DefaultHeaders)
+	documentHeaders := maps.Clone(mux.DefaultDocumentHeaders)
+	if defaultHeaders == nil || documentHeaders == nil {
+		return motmedelErrors.NewWithTrace(nil_error.NewWithInstance("map", "default headers"))
+	}
+
+	for _, policyPatch := range policyPatches {
+		if err := patchPolicyHeaders(defaultHeaders, documentHeaders, policyPatch); err != nil {
+			return motmedelErrors.New(fmt.Errorf("patch policy headers: %w", err), path)
+		}
+	}
+
+	headerEntries := slices.Concat(
+		changedHeaderEntries(mux.DefaultHeaders, defaultHeaders),
+		changedHeaderEntries(mux.DefaultDocumentHeaders, documentHeaders),
+	)
+	if len(headerEntries) == 0 {
+		return nil
+	}
+
+	for _, endpoint := range methodToEndpoint {
+		if endpoint == nil {
+			continue
+		}
+
+		// Outermost, so that the entries are added to what the endpoint's own middleware made of
+		// the response. The slice is made anew, the endpoint's being shared with its duplicates.
+		endpoint.Middleware = slices.Concat(
+			[]middleware.HandlerMiddleware{headerEntriesMiddleware(headerEntries)},
+			endpoint.Middleware,
+		)
+	}
+
+	return nil
+}
+
+// patchPolicies patches the policies the mux answers with, as the patches say: those without a
+// path for everything it answers with, and then those with one for the endpoints there, each path's
+// in the order given.
+func patchPolicies(mux *motmedelMux.Mux, policyPatches ...*service_config.PolicyPatch) error {
+	if len(policyPatches) == 0 {
+		return nil
+	}
+
+	if mux == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("mux"))
+	}
+
+	var paths []string
+	pathToPolicyPatches := make(map[string][]*service_config.PolicyPatch)
+	for _, policyPatch := range policyPatches {
+		if policyPatch == nil {
+			continue
+		}
+
+		path := policyPatch.Path
+		if path == "" {
+			if err := patchPolicyHeaders(mux.DefaultHeaders, mux.DefaultDocumentHeaders, policyPatch); err != nil {
+				return fmt.Errorf("patch policy headers: %w", err)
+			}
+			continue
+		}
+
+		if _, ok := pathToPolicyPatches[path]; !ok {
+			paths = append(paths, path)
+		}
+		pathToPolicyPatches[path] = append(pathToPolicyPatches[path], policyPatch)
+	}
+
+	for _, path := range paths {
+		if err := patchEndpointPolicies(mux, path, pathToPolicyPatches[path]...); err != nil {
+			return fmt.Errorf("patch endpoint policies: %w", err)
+		}
+	}
+
+	return nil
+}
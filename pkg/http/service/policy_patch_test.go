@@ -0,0 +1,207 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/http/service/service_config"
+	"github.com/Motmedel/utils_go/pkg/http/types/cross_origin_policy"
+	"github.com/Motmedel/utils_go/pkg/http/types/document_policy"
+	"github.com/Motmedel/utils_go/pkg/http/types/permissions_policy"
+	ppUtils "github.com/Motmedel/utils_go/pkg/http/utils/permissions_policy"
+)
+
+func TestPolicyPatches(t *testing.T) {
+	t.Parallel()
+
+	service, err := New(
+		service_config.WithHost("example.com"),
+		service_config.WithPermissionsPolicy(func(policy *permissions_policy.PermissionsPolicy) error {
+			ppUtils.PatchPermissionsPolicyWithSelf(policy, "fullscreen")
+			return nil
+		}),
+		service_config.WithPermissionsPolicy(func(policy *permissions_policy.PermissionsPolicy) error {
+			ppUtils.PatchPermissionsPolicyDeny(policy, "usb", "bluetooth")
+			return nil
+		}),
+		service_config.WithDocumentPolicy(func(policy *document_policy.DocumentPolicy) error {
+			policy.Set(&document_policy.Directive{Feature: "sync-xhr", Value: false})
+			return nil
+		}),
+		service_config.WithCrossOriginOpenerPolicy(
+			&cross_origin_policy.OpenerPolicy{Value: cross_origin_policy.OpenerPolicySameOriginAllowPopups},
+		),
+		service_config.WithCrossOriginEmbedderPolicy(
+			&cross_origin_policy.EmbedderPolicy{Value: cross_origin_policy.EmbedderPolicyCredentialless, ReportTo: "coep"},
+		),
+		service_config.WithCrossOriginResourcePolicy(cross_origin_policy.ResourcePolicySameSite),
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	mux := service.Mux
+
+	testCases := []struct {
+		name     string
+		headers  map[string]string
+		header   string
+		expected string
+	}{
+		{
+			// The patches apply in order, to what the previous ones made of the default.
+			name:     "permissions policy",
+			headers:  mux.DefaultDocumentHeaders,
+			header:   permissions_policy.HeaderName,
+			expected: "geolocation=(), microphone=(), camera=(), payment=(), usb=(), display-capture=(), fullscreen=(self), bluetooth=()",
+		},
+		{
+			name:     "document policy",
+			headers:  mux.DefaultDocumentHeaders,
+			header:   document_policy.HeaderName,
+			expected: "sync-xhr=?0",
+		},
+		{
+			name:     "opener policy",
+			headers:  mux.DefaultDocumentHeaders,
+			header:   cross_origin_policy.OpenerPolicyHeaderName,
+			expected: "same-origin-allow-popups",
+		},
+		{
+			name:     "embedder policy",
+			headers:  mux.DefaultDocumentHeaders,
+			header:   cross_origin_policy.EmbedderPolicyHeaderName,
+			expected: `credentialless;report-to="coep"`,
+		},
+		{
+			name:     "resource policy",
+			headers:  mux.DefaultHeaders,
+			header:   cross_origin_policy.ResourcePolicyHeaderName,
+			expected: "same-site",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testCase.headers[testCase.header]; got != testCase.expected {
+				t.Errorf("%s: got %q, want %q", testCase.header, got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestPolicyPatchesLeaveTheDefaults(t *testing.T) {
+	t.Parallel()
+
+	service, err := New(service_config.WithHost("example.com"))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	// A service that patches nothing says no document policy, rather than an empty one.
+	if value, ok := service.Mux.DefaultDocumentHeaders[document_policy.HeaderName]; ok {
+		t.Errorf("expected no document policy, got %q", value)
+	}
+}
+
+func TestEndpointPolicies(t *testing.T) {
+	t.Parallel()
+
+	service, err := New(
+		service_config.WithHost("example.com"),
+		service_config.WithEndpoints(
+			staticContentEndpoint("/", "text/html"),
+			staticContentEndpoint("/map", "text/html"),
+		),
+		service_config.WithPermissionsPolicy(func(policy *permissions_policy.PermissionsPolicy) error {
+			ppUtils.PatchPermissionsPolicyDeny(policy, "usb")
+			return nil
+		}),
+		service_config.WithEndpointPolicies(
+			"/map",
+			service_config.WithPermissionsPolicy(func(policy *permissions_policy.PermissionsPolicy) error {
+				ppUtils.PatchPermissionsPolicyWithSelf(policy, "geolocation")
+				return nil
+			}),
+			service_config.WithCrossOriginOpenerPolicy(
+				&cross_origin_policy.OpenerPolicy{Value: cross_origin_policy.OpenerPolicySameOriginAllowPopups},
+			),
+		),
+		service_config.WithEndpointPolicies(
+			"/map",
+			service_config.WithCrossOriginResourcePolicy(cross_origin_policy.ResourcePolicyCrossOrigin),
+		),
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	address := serveListener(t, service)
+	client := &http.Client{Transport: &http.Transport{}}
+
+	testCases := []struct {
+		name                      string
+		path                      string
+		expectedPermissionsPolicy string
+		expectedOpenerPolicy      string
+		expectedResourcePolicy    string
+	}{
+		{
+			name:                      "the rest of the service",
+			path:                      "/",
+			expectedPermissionsPolicy: "geolocation=(), microphone=(), camera=(), payment=(), usb=(), display-capture=()",
+			expectedOpenerPolicy:      "same-origin",
+			expectedResourcePolicy:    "same-origin",
+		},
+		{
+			// The endpoint starts from what the service patched, and its patches apply in order.
+			name:                      "the endpoint",
+			path:                      "/map",
+			expectedPermissionsPolicy: "geolocation=(self), microphone=(), camera=(), payment=(), usb=(), display-capture=()",
+			expectedOpenerPolicy:      "same-origin-allow-popups",
+			expectedResourcePolicy:    "cross-origin",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			response := doRequest(t, client, "http://"+address+testCase.path, "example.com")
+
+			expectations := []struct {
+				header   string
+				expected string
+			}{
+				{header: permissions_policy.HeaderName, expected: testCase.expectedPermissionsPolicy},
+				{header: cross_origin_policy.OpenerPolicyHeaderName, expected: testCase.expectedOpenerPolicy},
+				{header: cross_origin_policy.ResourcePolicyHeaderName, expected: testCase.expectedResourcePolicy},
+			}
+			for _, expectation := range expectations {
+				values := response.headers.Values(expectation.header)
+				if len(values) != 1 || values[0] != expectation.expected {
+					t.Errorf("%s: got %q, want %q", expectation.header, values, expectation.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestEndpointPoliciesWithoutAnEndpoint(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(
+		service_config.WithHost("example.com"),
+		service_config.WithEndpointPolicies(
+			"/missing",
+			service_config.WithCrossOriginResourcePolicy(cross_origin_policy.ResourcePolicyCrossOrigin),
+		),
+	)
+	if !errors.Is(err, motmedelErrors.ErrValidationError) {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+}
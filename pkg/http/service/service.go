@@ -10,18 +10,23 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"time"
 
 	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
 	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
 	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/acme/manager"
+	"github.com/Motmedel/utils_go/pkg/http/health"
 	motmedelMux "github.com/Motmedel/utils_go/pkg/http/mux"
 	endpointPkg "github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/mux_metrics"
 	"github.com/Motmedel/utils_go/pkg/http/service/service_config"
 	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
 	motmedelNet "github.com/Motmedel/utils_go/pkg/net"
 	"github.com/Motmedel/utils_go/pkg/net/types/domain_parts"
+	"github.com/Motmedel/utils_go/pkg/tls/certificate_reloader"
 )
 
 // Service is a mux and the server that serves it. The server is made for being stopped: asking it
@@ -29,11 +34,23 @@ import (
 type Service struct {
 	Server *http.Server
 	Mux    *motmedelMux.Mux
-
-	shutdownTimeout time.Duration
-	signals         []os.Signal
-	certificateFile string
-	keyFile         string
+	// VirtualHostMuxes are the muxes of the virtual hosts, by host as configured.
+	VirtualHostMuxes map[string]*motmedelMux.Mux
+	// AcmeManager obtains the service's certificates, where it was configured to with WithAcme.
+	AcmeManager *manager.Manager
+	// HealthChecker checks the service's liveness and readiness, where it was configured to with
+	// WithHealth.
+	HealthChecker *health.Checker
+	// Metrics record the requests the service's muxes serve, where it was configured to with
+	// WithMetrics.
+	Metrics *mux_metrics.Metrics
+
+	shutdownTimeout     time.Duration
+	drainDelay          time.Duration
+	signals             []os.Signal
+	certificateFile     string
+	keyFile             string
+	certificateReloader *certificate_reloader.Reloader
 }
 
 // Serve serves until the process is asked to stop, and then lets the requests it is handling
@@ -109,6 +126,10 @@ func (service *Service) serve(ctx context.Context, listener net.Listener) error
 
 	serveTls := server.TLSConfig != nil || service.certificateFile != "" || service.keyFile != ""
 
+	if reloader := service.certificateReloader; reloader != nil {
+		go reloader.Watch(ctx)
+	}
+
 	served := make(chan error, 1)
 	go func() {
 		var err error
@@ -132,6 +153,21 @@ func (service *Service) serve(ctx context.Context, listener net.Listener) error
 	case <-ctx.Done():
 	}
 
+	// The service keeps serving while it drains, failing its readiness, so that a load balancer
+	// stops sending it requests before it stops taking them.
+	if checker := service.HealthChecker; checker != nil {
+		checker.Drain()
+	}
+	if drainDelay := service.drainDelay; drainDelay > 0 {
+		timer := time.NewTimer(drainDelay)
+		select {
+		case err := <-served:
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+
 	shutdownTimeout := service.shutdownTimeout
 	if shutdownTimeout <= 0 {
 		shutdownTimeout = service_config.DefaultShutdownTimeout
@@ -196,6 +232,12 @@ func withDuplicatedEndpoints(
 	return endpoints, nil
 }
 
+// isWildcardHost reports whether the host is a wildcard, answering for any host one label below the
+// rest of it.
+func isWildcardHost(host string) bool {
+	return strings.HasPrefix(host, "*.")
+}
+
 // makeBaseUrl is where the service is reached, derived from the host it answers for: a host that
 // resolves on the machine itself is reached over HTTP, anything else over HTTPS.
 func makeBaseUrl(host string) *url.URL {
@@ -365,6 +407,10 @@ func patchMux(mux *motmedelMux.Mux, config *service_config.Config) error {
 		}
 	}
 
+	if err := patchPolicies(mux, config.PolicyPatches...); err != nil {
+		return fmt.Errorf("patch policies: %w", err)
+	}
+
 	// A browser told to reach localhost over HTTPS only cannot reach a development server at all,
 	// and remembers so for as long as the max-age says.
 	if config.StrictTransportSecurity && !motmedelNet.IsLocalhost(host) {
@@ -379,6 +425,14 @@ func patchMux(mux *motmedelMux.Mux, config *service_config.Config) error {
 			return motmedelErrors.NewWithTrace(empty_error.NewWithInstance("host", "sitemap"))
 		}
 
+		// The sitemap protocol wants absolute locations, which a wildcard has none of.
+		if isWildcardHost(host) {
+			return motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: a sitemap for a wildcard host", motmedelErrors.ErrValidationError),
+				host,
+			)
+		}
+
 		var err error
 		sitemapUrl, err = patchSitemap(mux, baseUrl)
 		if err != nil {
@@ -399,27 +453,114 @@ func patchMux(mux *motmedelMux.Mux, config *service_config.Config) error {
 	return nil
 }
 
+// makeMux makes the mux a host is served with, from the host's configuration: its endpoints, and
+// what it was configured to answer with beyond them.
+func makeMux(config *service_config.Config) (*motmedelMux.Mux, error) {
+	if config == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("service config"))
+	}
+
+	if profile := config.Profile; profile != "" && !profile.IsValid() {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: service profile", motmedelErrors.ErrValidationError),
+			profile,
+		)
+	}
+
+	// A wildcard directly below a public suffix would answer for registered domains of others.
+	if host := config.Host; isWildcardHost(host) && !motmedelNet.IsLocalhost(host) {
+		domainParts := domain_parts.New(host)
+		if domainParts == nil || isWildcardHost(domainParts.RegisteredDomain) {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: a wildcard host below a public suffix", motmedelErrors.ErrValidationError),
+				host,
+			)
+		}
+	}
+
+	endpoints, err := withDuplicatedEndpoints(config.Endpoints, config.DuplicatedEndpoints)
+	if err != nil {
+		return nil, fmt.Errorf("with duplicated endpoints: %w", err)
+	}
+
+	mux := motmedelMux.New(endpoints...)
+
+	if err := patchMux(mux, config); err != nil {
+		return nil, fmt.Errorf("patch mux: %w", err)
+	}
+
+	return mux, nil
+}
+
 // makeHandler makes what the server serves with: the mux itself, or -- where the service answers
-// for a host of its own -- a vhost mux that tells that host apart from the ones redirected away.
+// for a host of its own -- a vhost mux that tells that host apart from its virtual hosts and from
+// the hosts redirected away.
 func makeHandler(
 	serviceMux *motmedelMux.Mux,
-	host string,
-	redirects []*service_config.Redirect,
+	config *service_config.Config,
+	virtualHostMuxes map[string]*motmedelMux.Mux,
 ) (http.Handler, error) {
 	if serviceMux == nil {
 		return nil, motmedelErrors.NewWithTrace(nil_error.New("mux"))
 	}
 
+	if config == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("service config"))
+	}
+
+	host := config.Host
 	if host == "" {
-		if len(redirects) != 0 {
-			// Without a host of its own, the service has nothing to tell the redirected hosts from.
+		// Without a host of its own, the service has nothing to tell the other hosts from.
+		if len(config.Redirects) != 0 {
 			return nil, motmedelErrors.NewWithTrace(empty_error.NewWithInstance("host", "redirects"))
 		}
+		if len(virtualHostMuxes) != 0 {
+			return nil, motmedelErrors.NewWithTrace(empty_error.NewWithInstance("host", "virtual hosts"))
+		}
 
 		return serviceMux, nil
 	}
 
-	hostToSpecification := map[string]*motmedelMux.VhostMuxSpecification{host: {Mux: serviceMux}}
+	hostToSpecification := make(map[string]*motmedelMux.VhostMuxSpecification)
+
+	// Hosts are told apart case-insensitively, so that two that differ in case only are reported
+	// rather than one silently answering for the other.
+	addSpecification := func(host string, specification *motmedelMux.VhostMuxSpecification) error {
+		host = strings.ToLower(host)
+		if _, found := hostToSpecification[host]; found {
+			return motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: a host answered for more than once", motmedelErrors.ErrValidationError),
+				host,
+			)
+		}
+
+		hostToSpecification[host] = specification
+		return nil
+	}
+
+	if err := addSpecification(host, &motmedelMux.VhostMuxSpecification{Mux: serviceMux}); err != nil {
+		return nil, err
+	}
+
+	redirects := config.Redirects
+
+	for _, virtualHostConfig := range config.VirtualHosts {
+		if virtualHostConfig == nil {
+			continue
+		}
+
+		virtualHost := virtualHostConfig.Host
+		virtualHostMux, found := virtualHostMuxes[virtualHost]
+		if !found || virtualHostMux == nil {
+			return nil, motmedelErrors.NewWithTrace(nil_error.New("virtual host mux"), virtualHost)
+		}
+
+		if err := addSpecification(virtualHost, &motmedelMux.VhostMuxSpecification{Mux: virtualHostMux}); err != nil {
+			return nil, err
+		}
+
+		redirects = append(slices.Clip(redirects), virtualHostConfig.Redirects...)
+	}
 
 	for _, redirect := range redirects {
 		if redirect == nil {
@@ -437,7 +578,9 @@ func makeHandler(
 			)
 		}
 
-		hostToSpecification[redirect.Host] = &motmedelMux.VhostMuxSpecification{RedirectTo: redirect.To}
+		if err := addSpecification(redirect.Host, &motmedelMux.VhostMuxSpecification{RedirectTo: redirect.To}); err != nil {
+			return nil, err
+		}
 	}
 
 	vhostMux := &motmedelMux.VhostMux{HostToSpecification: hostToSpecification}
@@ -454,29 +597,105 @@ func New(options ...service_config.Option) (*Service, error) {
 		return nil, motmedelErrors.NewWithTrace(nil_error.New("service config"))
 	}
 
-	if profile := config.Profile; profile != "" && !profile.IsValid() {
-		return nil, motmedelErrors.NewWithTrace(
-			fmt.Errorf("%w: service profile", motmedelErrors.ErrValidationError),
-			profile,
-		)
+	serviceMux, err := makeMux(config)
+	if err != nil {
+		return nil, fmt.Errorf("make mux: %w", err)
 	}
 
-	endpoints, err := withDuplicatedEndpoints(config.Endpoints, config.DuplicatedEndpoints)
-	if err != nil {
-		return nil, fmt.Errorf("with duplicated endpoints: %w", err)
+	var virtualHostMuxes map[string]*motmedelMux.Mux
+	for _, virtualHostConfig := range config.VirtualHosts {
+		if virtualHostConfig == nil {
+			continue
+		}
+
+		virtualHost := virtualHostConfig.Host
+		if virtualHost == "" {
+			return nil, motmedelErrors.NewWithTrace(empty_error.NewWithInstance("host", "virtual host"))
+		}
+
+		if len(virtualHostConfig.VirtualHosts) != 0 {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: virtual hosts of a virtual host", motmedelErrors.ErrValidationError),
+				virtualHost,
+			)
+		}
+
+		virtualHostMux, err := makeMux(virtualHostConfig)
+		if err != nil {
+			return nil, motmedelErrors.New(fmt.Errorf("make mux (virtual host): %w", err), virtualHost)
+		}
+
+		if virtualHostMuxes == nil {
+			virtualHostMuxes = make(map[string]*motmedelMux.Mux)
+		}
+		virtualHostMuxes[virtualHost] = virtualHostMux
+	}
+
+	var acmeManager *manager.Manager
+	if config.Acme {
+		acmeManager, err = makeAcmeManager(config)
+		if err != nil {
+			return nil, fmt.Errorf("make acme manager: %w", err)
+		}
+
+		if err := patchAcme(serviceMux, acmeManager); err != nil {
+			return nil, fmt.Errorf("patch acme: %w", err)
+		}
+		for virtualHost, virtualHostMux := range virtualHostMuxes {
+			if err := patchAcme(virtualHostMux, acmeManager); err != nil {
+				return nil, motmedelErrors.New(fmt.Errorf("patch acme (virtual host): %w", err), virtualHost)
+			}
+		}
 	}
 
-	serviceMux := motmedelMux.New(endpoints...)
+	var muxMetrics *mux_metrics.Metrics
+	if config.Metrics {
+		muxMetrics, err = mux_metrics.New(config.MetricsOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("mux metrics new: %w", err)
+		}
 
-	if err := patchMux(serviceMux, config); err != nil {
-		return nil, fmt.Errorf("patch mux: %w", err)
+		if err := patchMetrics(serviceMux, muxMetrics); err != nil {
+			return nil, fmt.Errorf("patch metrics: %w", err)
+		}
+		for virtualHost, virtualHostMux := range virtualHostMuxes {
+			if err := patchMetrics(virtualHostMux, muxMetrics.WithHost(virtualHost)); err != nil {
+				return nil, motmedelErrors.New(fmt.Errorf("patch metrics (virtual host): %w", err), virtualHost)
+			}
+		}
 	}
 
-	handler, err := makeHandler(serviceMux, config.Host, config.Redirects)
+	handler, err := makeHandler(serviceMux, config, virtualHostMuxes)
 	if err != nil {
 		return nil, fmt.Errorf("make handler: %w", err)
 	}
 
+	var instanceEndpoints []*endpointPkg.Endpoint
+
+	var healthChecker *health.Checker
+	if config.Health {
+		healthChecker, err = health.New(config.HealthOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("health new: %w", err)
+		}
+		instanceEndpoints = append(instanceEndpoints, healthChecker.Endpoints()...)
+	}
+
+	if muxMetrics != nil && config.MetricsPath != "" {
+		instanceEndpoints = append(instanceEndpoints, mux_metrics.Endpoint(muxMetrics.Registry, config.MetricsPath))
+	}
+
+	if len(instanceEndpoints) != 0 {
+		if err := checkInstanceEndpoints(instanceEndpoints, serviceMux, virtualHostMuxes); err != nil {
+			return nil, fmt.Errorf("check instance endpoints: %w", err)
+		}
+
+		handler, err = makeInstanceHandler(handler, instanceEndpoints...)
+		if err != nil {
+			return nil, fmt.Errorf("make instance handler: %w", err)
+		}
+	}
+
 	protocols := config.Protocols
 	if config.UnencryptedHttp2 {
 		if protocols == nil {
@@ -505,12 +724,30 @@ func New(options ...service_config.Option) (*Service, error) {
 		ErrorLog:                     errorLog,
 	}
 
+	server.TLSConfig, err = makeTlsConfig(config, acmeManager)
+	if err != nil {
+		return nil, fmt.Errorf("make tls config: %w", err)
+	}
+
+	// The files are not loaded by the server where the reloader serves a certificate: loaded, they
+	// would be served to a client that sends no server name, whatever the reloader has loaded since.
+	certificateFile, keyFile := config.CertificateFile, config.KeyFile
+	if reloader := config.CertificateReloader; reloader != nil && reloader.TlsConfig().GetCertificate != nil {
+		certificateFile, keyFile = "", ""
+	}
+
 	return &Service{
-		Server:          server,
-		Mux:             serviceMux,
-		shutdownTimeout: config.ShutdownTimeout,
-		signals:         config.Signals,
-		certificateFile: config.CertificateFile,
-		keyFile:         config.KeyFile,
+		Server:              server,
+		Mux:                 serviceMux,
+		VirtualHostMuxes:    virtualHostMuxes,
+		AcmeManager:         acmeManager,
+		HealthChecker:       healthChecker,
+		Metrics:             muxMetrics,
+		shutdownTimeout:     config.ShutdownTimeout,
+		drainDelay:          config.DrainDelay,
+		signals:             config.Signals,
+		certificateFile:     certificateFile,
+		keyFile:             keyFile,
+		certificateReloader: config.CertificateReloader,
 	}, nil
 }
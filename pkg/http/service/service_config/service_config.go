@@ -8,8 +8,16 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Motmedel/utils_go/pkg/http/acme/manager/manager_config"
+	"github.com/Motmedel/utils_go/pkg/http/health/health_config"
 	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/mux_metrics"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/mux_metrics/mux_metrics_config"
 	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
+	"github.com/Motmedel/utils_go/pkg/http/types/cross_origin_policy"
+	"github.com/Motmedel/utils_go/pkg/http/types/document_policy"
+	"github.com/Motmedel/utils_go/pkg/http/types/permissions_policy"
+	"github.com/Motmedel/utils_go/pkg/tls/certificate_reloader"
 )
 
 type Option func(*Config)
@@ -31,6 +39,21 @@ type DuplicatedEndpoint struct {
 	To []string
 }
 
+// PolicyPatch patches the policies besides the content security policy that the service answers
+// with: the permissions and document policies a document is answered with, and the cross-origin
+// policies. What is left unset is left as it is.
+type PolicyPatch struct {
+	// Path is the endpoint whose responses the patch applies to, in place of what the service
+	// answers with otherwise. Where it is empty, the patch applies to everything the service
+	// answers with.
+	Path              string
+	PermissionsPolicy func(*permissions_policy.PermissionsPolicy) error
+	DocumentPolicy    func(*document_policy.DocumentPolicy) error
+	OpenerPolicy      *cross_origin_policy.OpenerPolicy
+	EmbedderPolicy    *cross_origin_policy.EmbedderPolicy
+	ResourcePolicy    cross_origin_policy.ResourcePolicy
+}
+
 // DefaultSecurityTxtValidity is how long a security.txt says its information is to be considered
 // valid, where it does not say itself. RFC 9116 requires the field and recommends less than a year.
 const DefaultSecurityTxtValidity = 365 * 24 * time.Hour
@@ -62,6 +85,12 @@ type Config struct {
 	// "421 Misdirected Request", rather than by the mux.
 	Host      string
 	Redirects []*Redirect
+	// VirtualHosts are the other hosts the service answers for, each with a mux of its own made
+	// from its own configuration, and patched as the service's own is. A host of "*.example.com"
+	// is any host one label below example.com that is answered for by nothing else. What concerns
+	// the server rather than a host -- the address, timeouts, protocols, TLS -- is taken from the
+	// service's configuration, and ignored in a virtual host's.
+	VirtualHosts []*Config
 	// Profile is what the service was set up as, for the record. What it decided is in the fields
 	// below, which an option applied after it may have overridden.
 	Profile Profile
@@ -98,6 +127,10 @@ type Config struct {
 	// FedCmProviders are the identity providers the service's documents may ask who the user is
 	// through, using the browser's federated credential management.
 	FedCmProviders []*url.URL
+	// PolicyPatches patch the policies besides the content security policy that the service answers
+	// with, in order, once everything else the service was configured with has patched them. Those
+	// with a path patch what the endpoint there answers with alone.
+	PolicyPatches []*PolicyPatch
 	// Reporting makes the service ask browsers to report what they block on its documents --
 	// content security policy violations and integrity violations -- and serve the endpoints the
 	// reports go to, along with the ones a page's own JavaScript reports its errors to.
@@ -120,9 +153,13 @@ type Config struct {
 	// SecurityTxtUrl is where the service's security.txt is served instead of by the service
 	// itself: both /security.txt and /.well-known/security.txt redirect there. It takes precedence
 	// over SecurityTxtContent and over what the host would otherwise decide.
-	SecurityTxtUrl    *url.URL
-	Address           string
-	ShutdownTimeout   time.Duration
+	SecurityTxtUrl  *url.URL
+	Address         string
+	ShutdownTimeout time.Duration
+	// DrainDelay is how long the service keeps serving once asked to stop, before it stops taking
+	// new connections and gives those it has ShutdownTimeout to finish. It is spent failing the
+	// readiness, where Health is on, for a load balancer to stop sending it requests.
+	DrainDelay        time.Duration
 	Signals           []os.Signal
 	ReadHeaderTimeout time.Duration
 	Protocols         *http.Protocols
@@ -134,6 +171,25 @@ type Config struct {
 	ErrorLog              *log.Logger
 	CertificateFile       string
 	KeyFile               string
+	// CertificateReloader serves the certificate in the files it watches, replacing it as they
+	// change, and verifies client certificates against the certificate authorities it watches as
+	// well. Its certificate takes precedence over CertificateFile and KeyFile.
+	CertificateReloader *certificate_reloader.Reloader
+	// Acme makes the service obtain its certificates from an ACME server, configured by
+	// AcmeOptions. The certificate and key files, where configured as well, are served whenever a
+	// certificate cannot be obtained.
+	Acme        bool
+	AcmeOptions []manager_config.Option
+	// Health makes the service serve its liveness and readiness, checked as HealthOptions say, for
+	// whichever host a probe asks for.
+	Health        bool
+	HealthOptions []health_config.Option
+	// Metrics makes the service's muxes record the requests they serve, as MetricsOptions say, and
+	// the service serve the metrics at MetricsPath, unless it is empty, for whichever host a scraper
+	// asks for.
+	Metrics        bool
+	MetricsOptions []mux_metrics_config.Option
+	MetricsPath    string
 }
 
 func New(options ...Option) *Config {
@@ -145,6 +201,7 @@ func New(options ...Option) *Config {
 		ShutdownTimeout:          DefaultShutdownTimeout,
 		Signals:                  DefaultSignals,
 		ReadHeaderTimeout:        DefaultReadHeaderTimeout,
+		MetricsPath:              mux_metrics.DefaultPath,
 	}
 
 	for _, option := range options {
@@ -194,6 +251,22 @@ func WithRedirects(redirects ...*Redirect) Option {
 	}
 }
 
+// WithVirtualHost makes the service answer for another host, with what the options configure: its
+// endpoints, its profile, its security.txt, its redirects. Nothing is inherited from the service's
+// own configuration; a virtual host starts from the defaults, as the service does. The service is
+// required to have a host of its own to tell the virtual hosts apart from.
+//
+// A host of "*.example.com" answers for any host one label below example.com that is answered for
+// by nothing else. It serves no sitemap, having no one location to give.
+func WithVirtualHost(host string, options ...Option) Option {
+	return func(config *Config) {
+		config.VirtualHosts = append(
+			config.VirtualHosts,
+			New(append(options, WithHost(host))...),
+		)
+	}
+}
+
 // WithStrictTransportSecurity makes the service tell browsers to reach it over HTTPS only. It is
 // not answered with on localhost, whatever is configured here.
 func WithStrictTransportSecurity(strictTransportSecurity bool) Option {
@@ -254,6 +327,73 @@ func WithFedCm(providerUrls ...*url.URL) Option {
 	}
 }
 
+// WithPermissionsPolicy patches the permissions policy the service's documents are answered with,
+// which denies the features that reach the user's surroundings or money unless patched otherwise.
+func WithPermissionsPolicy(patch func(*permissions_policy.PermissionsPolicy) error) Option {
+	return func(config *Config) {
+		config.PolicyPatches = append(config.PolicyPatches, &PolicyPatch{PermissionsPolicy: patch})
+	}
+}
+
+// WithDocumentPolicy patches the document policy the service's documents are answered with, which
+// is none unless patched.
+func WithDocumentPolicy(patch func(*document_policy.DocumentPolicy) error) Option {
+	return func(config *Config) {
+		config.PolicyPatches = append(config.PolicyPatches, &PolicyPatch{DocumentPolicy: patch})
+	}
+}
+
+// WithCrossOriginOpenerPolicy replaces the cross-origin opener policy the service's documents are
+// answered with. A document that opens a window of another origin and expects to reach it, as a
+// popup sign-in does, needs "same-origin-allow-popups" rather than the default "same-origin".
+func WithCrossOriginOpenerPolicy(policy *cross_origin_policy.OpenerPolicy) Option {
+	return func(config *Config) {
+		config.PolicyPatches = append(config.PolicyPatches, &PolicyPatch{OpenerPolicy: policy})
+	}
+}
+
+// WithCrossOriginEmbedderPolicy replaces the cross-origin embedder policy the service's documents
+// are answered with. "credentialless" loads what has not agreed to be embedded, without credentials,
+// where the default "require-corp" refuses it.
+func WithCrossOriginEmbedderPolicy(policy *cross_origin_policy.EmbedderPolicy) Option {
+	return func(config *Config) {
+		config.PolicyPatches = append(config.PolicyPatches, &PolicyPatch{EmbedderPolicy: policy})
+	}
+}
+
+// WithCrossOriginResourcePolicy replaces the cross-origin resource policy the service answers with,
+// for a service whose responses are loaded by documents of other origins.
+func WithCrossOriginResourcePolicy(policy cross_origin_policy.ResourcePolicy) Option {
+	return func(config *Config) {
+		config.PolicyPatches = append(config.PolicyPatches, &PolicyPatch{ResourcePolicy: policy})
+	}
+}
+
+// WithEndpointPolicies applies the policy options -- WithPermissionsPolicy, WithDocumentPolicy and
+// the cross-origin ones -- to what the endpoint at the path answers with alone. The endpoint starts
+// from what the rest of the service answers with, patched as the options say. Options other than
+// those are ignored.
+func WithEndpointPolicies(path string, options ...Option) Option {
+	return func(config *Config) {
+		endpointConfig := &Config{}
+		for _, option := range options {
+			if option != nil {
+				option(endpointConfig)
+			}
+		}
+
+		for _, policyPatch := range endpointConfig.PolicyPatches {
+			if policyPatch == nil {
+				continue
+			}
+
+			endpointPolicyPatch := *policyPatch
+			endpointPolicyPatch.Path = path
+			config.PolicyPatches = append(config.PolicyPatches, &endpointPolicyPatch)
+		}
+	}
+}
+
 // WithRobotsTxt makes the service serve a robots.txt telling crawlers to keep out -- except where
 // WithSitemap says otherwise, in which case the crawlers that honour sitemaps are invited.
 func WithRobotsTxt(robotsTxt bool) Option {
@@ -343,6 +483,17 @@ func WithShutdownTimeout(shutdownTimeout time.Duration) Option {
 	}
 }
 
+// WithDrainDelay makes the service keep serving for the delay once asked to stop, failing its
+// readiness meanwhile where WithHealth is configured, so that a load balancer has stopped sending it
+// requests by the time it stops taking them. The delay comes before the shutdown timeout; the two
+// together are to fit within what the platform allows between asking the process to stop and
+// killing it.
+func WithDrainDelay(drainDelay time.Duration) Option {
+	return func(config *Config) {
+		config.DrainDelay = drainDelay
+	}
+}
+
 // WithSignals sets the signals whose delivery makes Serve stop serving.
 func WithSignals(signals ...os.Signal) Option {
 	return func(config *Config) {
@@ -398,3 +549,60 @@ func WithCertificateFiles(certificateFile string, keyFile string) Option {
 		config.KeyFile = keyFile
 	}
 }
+
+// WithCertificateReloader makes the service serve TLS with the certificate the reloader serves, and
+// ask for and verify client certificates where the reloader is configured with client certificate
+// authorities. The service watches the reloader's files for as long as it serves.
+//
+// The reloader is made by the caller, so that an endpoint authenticating clients by their
+// certificate can verify them against what it has loaded; see client_certificate_verifier.
+func WithCertificateReloader(certificateReloader *certificate_reloader.Reloader) Option {
+	return func(config *Config) {
+		config.CertificateReloader = certificateReloader
+	}
+}
+
+// WithAcme makes the service serve TLS with certificates obtained from an ACME server -- Let's
+// Encrypt, unless the options say otherwise -- and kept renewed. They are obtained for the host and
+// the virtual hosts that are not wildcards, besides those the options name.
+//
+// The challenges are answered by the service itself: TLS-ALPN-01 by the TLS server, and HTTP-01 by
+// an endpoint added to every host's mux, which is to be reachable over plain HTTP on port 80 for
+// it. Certificate files configured as well are served whenever a certificate cannot be obtained.
+func WithAcme(options ...manager_config.Option) Option {
+	return func(config *Config) {
+		config.Acme = true
+		config.AcmeOptions = append(config.AcmeOptions, options...)
+	}
+}
+
+// WithHealth makes the service serve its liveness at /healthz and its readiness at /readyz, unless
+// the options say otherwise, running the checks the options add. They are served for whichever host
+// a probe asks for, a probe commonly addressing the instance rather than the host, and an endpoint
+// of the service or a virtual host at one of their paths is an error.
+func WithHealth(options ...health_config.Option) Option {
+	return func(config *Config) {
+		config.Health = true
+		config.HealthOptions = append(config.HealthOptions, options...)
+	}
+}
+
+// WithMetrics makes the service's muxes record the requests they serve -- their endpoints labelled
+// by their paths as registered -- and the service serve the metrics at /metrics, in the OpenMetrics
+// text format, for whichever host a scraper asks for; an endpoint of the service or a virtual host at
+// the path is an error. A registry among the options holds the metrics, for them to be served with
+// others.
+func WithMetrics(options ...mux_metrics_config.Option) Option {
+	return func(config *Config) {
+		config.Metrics = true
+		config.MetricsOptions = append(config.MetricsOptions, options...)
+	}
+}
+
+// WithMetricsPath sets the path the metrics are served at; the empty path leaves them to be served
+// by the caller, from the registry.
+func WithMetricsPath(path string) Option {
+	return func(config *Config) {
+		config.MetricsPath = path
+	}
+}
@@ -10,13 +10,22 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Motmedel/utils_go/pkg/http/acme/manager/manager_config"
+	"github.com/Motmedel/utils_go/pkg/http/health/health_config"
 	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/mux_metrics/mux_metrics_config"
 	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
+	"github.com/Motmedel/utils_go/pkg/http/types/cross_origin_policy"
+	"github.com/Motmedel/utils_go/pkg/http/types/document_policy"
+	"github.com/Motmedel/utils_go/pkg/http/types/permissions_policy"
+	"github.com/Motmedel/utils_go/pkg/tls/certificate_reloader"
 )
 
 func TestNew(t *testing.T) {
 	t.Parallel()
 
+	reloader := &certificate_reloader.Reloader{}
+
 	testCases := []struct {
 		name    string
 		options []Option
@@ -119,6 +128,32 @@ func TestNew(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "with virtual hosts",
+			options: []Option{
+				WithHost("app.example.com"),
+				WithReporting(true),
+				WithVirtualHost("api.example.com", WithProfile(ProfilePublicApi), WithHost("ignored.example.com")),
+				WithVirtualHost("*.example.com"),
+			},
+			check: func(t *testing.T, config *Config) {
+				if len(config.VirtualHosts) != 2 {
+					t.Fatalf("virtual hosts: got %+v, want two", config.VirtualHosts)
+				}
+
+				// The host given is the virtual host's, whatever its options say.
+				api := config.VirtualHosts[0]
+				if api.Host != "api.example.com" || api.Profile != ProfilePublicApi || !api.RobotsTxt {
+					t.Errorf("virtual host: got %+v", api)
+				}
+
+				// Nothing is inherited from the service's own configuration.
+				wildcard := config.VirtualHosts[1]
+				if wildcard.Host != "*.example.com" || wildcard.Reporting || !wildcard.SecurityTxt {
+					t.Errorf("virtual host: got %+v", wildcard)
+				}
+			},
+		},
 		{
 			// A service says how a vulnerability in it is reported unless it is told not to.
 			name:    "without security txt",
@@ -270,6 +305,103 @@ func TestNew(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "with acme",
+			options: []Option{
+				WithAcme(manager_config.WithHosts("example.com")),
+				WithAcme(manager_config.WithOcspStapling(false)),
+			},
+			check: func(t *testing.T, config *Config) {
+				if !config.Acme {
+					t.Error("acme is disabled")
+				}
+				if len(config.AcmeOptions) != 2 {
+					t.Errorf("expected the acme options to accumulate, got %d", len(config.AcmeOptions))
+				}
+			},
+		},
+		{
+			name: "with health",
+			options: []Option{
+				WithHealth(health_config.WithTimeout(time.Second)),
+				WithHealth(health_config.WithPaths("/live", "/ready")),
+				WithDrainDelay(5 * time.Second),
+			},
+			check: func(t *testing.T, config *Config) {
+				if !config.Health {
+					t.Error("health is disabled")
+				}
+				if len(config.HealthOptions) != 2 {
+					t.Errorf("expected the health options to accumulate, got %d", len(config.HealthOptions))
+				}
+				if config.DrainDelay != 5*time.Second {
+					t.Errorf("drain delay: got %v", config.DrainDelay)
+				}
+			},
+		},
+		{
+			name: "with metrics",
+			options: []Option{
+				WithMetrics(mux_metrics_config.WithDurationBuckets(1, 2)),
+				WithMetrics(),
+				WithMetricsPath("/internal/metrics"),
+			},
+			check: func(t *testing.T, config *Config) {
+				if !config.Metrics {
+					t.Error("metrics are disabled")
+				}
+				if len(config.MetricsOptions) != 1 {
+					t.Errorf("expected the metrics options to accumulate, got %d", len(config.MetricsOptions))
+				}
+				if config.MetricsPath != "/internal/metrics" {
+					t.Errorf("metrics path: got %q", config.MetricsPath)
+				}
+			},
+		},
+		{
+			name: "with policies",
+			options: []Option{
+				WithPermissionsPolicy(func(*permissions_policy.PermissionsPolicy) error { return nil }),
+				WithCrossOriginResourcePolicy(cross_origin_policy.ResourcePolicyCrossOrigin),
+				WithEndpointPolicies(
+					"/map",
+					WithCrossOriginOpenerPolicy(
+						&cross_origin_policy.OpenerPolicy{Value: cross_origin_policy.OpenerPolicySameOriginAllowPopups},
+					),
+					WithDocumentPolicy(func(*document_policy.DocumentPolicy) error { return nil }),
+					WithHost("ignored.example.com"),
+				),
+			},
+			check: func(t *testing.T, config *Config) {
+				policyPatches := config.PolicyPatches
+				if len(policyPatches) != 4 {
+					t.Fatalf("expected the policy patches to accumulate, got %d", len(policyPatches))
+				}
+				if policyPatches[0].PermissionsPolicy == nil || policyPatches[0].Path != "" {
+					t.Errorf("permissions policy patch: got %+v", policyPatches[0])
+				}
+				if policyPatches[1].ResourcePolicy != cross_origin_policy.ResourcePolicyCrossOrigin {
+					t.Errorf("resource policy patch: got %+v", policyPatches[1])
+				}
+				for _, policyPatch := range policyPatches[2:] {
+					if policyPatch.Path != "/map" {
+						t.Errorf("expected the endpoint's path, got %q", policyPatch.Path)
+					}
+				}
+				if config.Host != "" {
+					t.Errorf("expected what is not a policy option to be ignored, got host %q", config.Host)
+				}
+			},
+		},
+		{
+			name:    "with certificate reloader",
+			options: []Option{WithCertificateReloader(reloader)},
+			check: func(t *testing.T, config *Config) {
+				if config.CertificateReloader != reloader {
+					t.Error("expected the certificate reloader")
+				}
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -427,6 +427,187 @@ func TestServesTheHostItAnswersFor(t *testing.T) {
 	}
 }
 
+func statusCodeEndpoint(statusCode int) *endpoint.Endpoint {
+	return &endpoint.Endpoint{
+		Path:   "/",
+		Method: http.MethodGet,
+		Handler: func(_ *http.Request, _ []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			return &muxResponse.Response{StatusCode: statusCode}, nil
+		},
+	}
+}
+
+// TestServesVirtualHosts verifies that each virtual host is served by a mux of its own, that a
+// wildcard answers for what no host of its own does, and that a virtual host's redirects are the
+// service's.
+func TestServesVirtualHosts(t *testing.T) {
+	t.Parallel()
+
+	service, err := New(
+		service_config.WithEndpoints(noContentEndpoint()),
+		service_config.WithHost("app.example.com"),
+		service_config.WithVirtualHost(
+			"api.example.com",
+			service_config.WithEndpoints(statusCodeEndpoint(http.StatusOK)),
+			service_config.WithProfile(service_config.ProfilePublicApi),
+		),
+		service_config.WithVirtualHost(
+			"example.com",
+			service_config.WithEndpoints(statusCodeEndpoint(http.StatusResetContent)),
+			service_config.WithRedirects(&service_config.Redirect{Host: "www.example.com", To: "https://example.com"}),
+		),
+		service_config.WithVirtualHost(
+			"*.example.com",
+			service_config.WithEndpoints(statusCodeEndpoint(http.StatusAccepted)),
+		),
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	if len(service.VirtualHostMuxes) != 3 {
+		t.Fatalf("virtual host muxes: got %v, want three", service.VirtualHostMuxes)
+	}
+
+	// Each host is patched as what it was configured as.
+	if service.Mux.Get("/robots.txt", http.MethodGet) != nil {
+		t.Error("the service's own host serves the robots.txt of the api")
+	}
+	if service.VirtualHostMuxes["api.example.com"].Get("/robots.txt", http.MethodGet) == nil {
+		t.Error("the api serves no robots.txt")
+	}
+	if endpoint := service.VirtualHostMuxes["example.com"].Get(wellKnownSecurityTxtPath, http.MethodGet); endpoint == nil || endpoint.StaticContent == nil {
+		t.Error("the registered domain serves no security.txt of its own")
+	}
+	if endpoint := service.Mux.Get(wellKnownSecurityTxtPath, http.MethodGet); endpoint == nil || endpoint.StaticContent != nil {
+		t.Error("the subdomain does not point at the registered domain's security.txt")
+	}
+
+	address := serveListener(t, service)
+
+	client := &http.Client{
+		Transport: &http.Transport{},
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		host               string
+		expectedStatusCode int
+		expectedLocation   string
+	}{
+		{name: "the service's own host", host: "app.example.com", expectedStatusCode: http.StatusNoContent},
+		{name: "a virtual host", host: "api.example.com", expectedStatusCode: http.StatusOK},
+		{name: "a virtual host, differently cased", host: "API.example.com", expectedStatusCode: http.StatusOK},
+		{name: "another virtual host", host: "example.com", expectedStatusCode: http.StatusResetContent},
+		{name: "a wildcard", host: "tenant.example.com", expectedStatusCode: http.StatusAccepted},
+		{
+			name:               "a virtual host's redirect",
+			host:               "www.example.com",
+			expectedStatusCode: http.StatusMovedPermanently,
+			expectedLocation:   "https://example.com/",
+		},
+		{
+			name:               "below the wildcard",
+			host:               "a.tenant.example.com",
+			expectedStatusCode: http.StatusMisdirectedRequest,
+		},
+		{name: "elsewhere", host: "example.org", expectedStatusCode: http.StatusMisdirectedRequest},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			response := doRequest(t, client, "http://"+address+"/", testCase.host)
+
+			if response.statusCode != testCase.expectedStatusCode {
+				t.Errorf("status code: got %d, want %d", response.statusCode, testCase.expectedStatusCode)
+			}
+
+			if location := response.headers.Get("Location"); location != testCase.expectedLocation {
+				t.Errorf("location: got %q, want %q", location, testCase.expectedLocation)
+			}
+		})
+	}
+}
+
+func TestNewWithUnusableVirtualHosts(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		options []service_config.Option
+	}{
+		{
+			name:    "without a host of its own",
+			options: []service_config.Option{service_config.WithVirtualHost("api.example.com")},
+		},
+		{
+			name: "without a host",
+			options: []service_config.Option{
+				service_config.WithHost("example.com"),
+				service_config.WithVirtualHost(""),
+			},
+		},
+		{
+			name: "the service's own host",
+			options: []service_config.Option{
+				service_config.WithHost("example.com"),
+				service_config.WithVirtualHost("Example.com"),
+			},
+		},
+		{
+			name: "a host redirected",
+			options: []service_config.Option{
+				service_config.WithHost("example.com"),
+				service_config.WithRedirects(&service_config.Redirect{Host: "api.example.com", To: "https://example.com"}),
+				service_config.WithVirtualHost("api.example.com"),
+			},
+		},
+		{
+			name: "virtual hosts of its own",
+			options: []service_config.Option{
+				service_config.WithHost("example.com"),
+				service_config.WithVirtualHost("api.example.com", service_config.WithVirtualHost("v1.example.com")),
+			},
+		},
+		{
+			name: "an unknown profile",
+			options: []service_config.Option{
+				service_config.WithHost("example.com"),
+				service_config.WithVirtualHost("api.example.com", service_config.WithProfile("something else")),
+			},
+		},
+		{
+			name: "a wildcard with a sitemap",
+			options: []service_config.Option{
+				service_config.WithHost("example.com"),
+				service_config.WithVirtualHost("*.example.com", service_config.WithSitemap(true)),
+			},
+		},
+		{
+			name: "a wildcard below a public suffix",
+			options: []service_config.Option{
+				service_config.WithHost("example.co.uk"),
+				service_config.WithVirtualHost("*.co.uk"),
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := New(testCase.options...); err == nil {
+				t.Error("new: got no error, want one")
+			}
+		})
+	}
+}
+
 // TestServesUnencryptedHttp2 verifies that a service that speaks unencrypted HTTP/2 serves both it,
 // to a client that begins with prior knowledge, and HTTP/1.1, on the same port.
 func TestServesUnencryptedHttp2(t *testing.T) {
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/acme"
+	"github.com/Motmedel/utils_go/pkg/http/acme/manager"
+	"github.com/Motmedel/utils_go/pkg/http/service/service_config"
+)
+
+// makeTlsConfig is the TLS configuration of the service's server where its certificates are not
+// only the configured files loaded once: obtained by the ACME manager, or served by the certificate
+// reloader, which verifies client certificates as well where it is configured to. It is nil where
+// they are.
+func makeTlsConfig(config *service_config.Config, acmeManager *manager.Manager) (*tls.Config, error) {
+	if config == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("service config"))
+	}
+
+	reloader := config.CertificateReloader
+	if acmeManager == nil {
+		if reloader == nil {
+			return nil, nil
+		}
+		return reloader.TlsConfig(), nil
+	}
+
+	var fallback func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	var reloaderTlsConfig *tls.Config
+	if reloader != nil {
+		reloaderTlsConfig = reloader.TlsConfig()
+		fallback = reloaderTlsConfig.GetCertificate
+	}
+	if fallback == nil && (config.CertificateFile != "" || config.KeyFile != "") {
+		fallback = func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return nil, nil }
+	}
+
+	tlsConfig, err := makeAcmeTlsConfig(acmeManager, fallback)
+	if err != nil {
+		return nil, fmt.Errorf("make acme tls config: %w", err)
+	}
+
+	if reloaderTlsConfig != nil && reloaderTlsConfig.VerifyConnection != nil {
+		verifyConnection := reloaderTlsConfig.VerifyConnection
+		tlsConfig.ClientAuth = reloaderTlsConfig.ClientAuth
+		tlsConfig.VerifyConnection = func(connectionState tls.ConnectionState) error {
+			// An ACME server validating a TLS-ALPN-01 challenge presents no certificate of its own.
+			if connectionState.NegotiatedProtocol == acme.TlsAlpnProtocol {
+				return nil
+			}
+			return verifyConnection(connectionState)
+		}
+	}
+
+	return tlsConfig, nil
+}
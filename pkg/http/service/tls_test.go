@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	requestParserAdapter "github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/adapter"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/client_certificate_verifier"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/client_certificate_verifier/client_certificate_verifier_config"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	"github.com/Motmedel/utils_go/pkg/http/service/service_config"
+	"github.com/Motmedel/utils_go/pkg/tls/certificate_reloader"
+	"github.com/Motmedel/utils_go/pkg/tls/certificate_reloader/certificate_reloader_config"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+func (testCertificate *testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{testCertificate.certificate.Raw}, PrivateKey: testCertificate.key}
+}
+
+// makeTestCertificate makes a certificate of the template, signed by signer or self-signed if it is
+// nil.
+func makeTestCertificate(t *testing.T, signer *testCertificate, template *x509.Certificate) *testCertificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa generate key: %v", err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	parent, parentKey := template, key
+	if signer != nil {
+		parent, parentKey = signer.certificate, signer.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("x509 create certificate: %v", err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509 parse certificate: %v", err)
+	}
+
+	return &testCertificate{certificate: certificate, key: key}
+}
+
+func writeTestCertificate(t *testing.T, testCertificate *testCertificate, certificateFile string, keyFile string) {
+	t.Helper()
+
+	keyDer, err := x509.MarshalECPrivateKey(testCertificate.key)
+	if err != nil {
+		t.Fatalf("x509 marshal ec private key: %v", err)
+	}
+
+	certificatePem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testCertificate.certificate.Raw})
+	if err := os.WriteFile(certificateFile, certificatePem, 0600); err != nil {
+		t.Fatalf("os write file: %v", err)
+	}
+	if keyFile == "" {
+		return
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatalf("os write file: %v", err)
+	}
+}
+
+func TestServeWithCertificateReloader(t *testing.T) {
+	t.Parallel()
+
+	authority := makeTestCertificate(t, nil, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "authority"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+	newServerCertificate := func(name string) *testCertificate {
+		return makeTestCertificate(t, authority, &x509.Certificate{
+			Subject:     pkix.Name{CommonName: name},
+			IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		})
+	}
+	client := makeTestCertificate(t, authority, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	directory := t.TempDir()
+	certificateFile := filepath.Join(directory, "certificate.pem")
+	keyFile := filepath.Join(directory, "key.pem")
+	clientCaFile := filepath.Join(directory, "ca.pem")
+	writeTestCertificate(t, newServerCertificate("first"), certificateFile, keyFile)
+	writeTestCertificate(t, authority, clientCaFile, "")
+
+	reloader, err := certificate_reloader.New(
+		certificate_reloader_config.WithCertificateFiles(certificateFile, keyFile),
+		certificate_reloader_config.WithClientCaFile(clientCaFile),
+		certificate_reloader_config.WithInterval(10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("certificate reloader new: %v", err)
+	}
+
+	parser, err := client_certificate_verifier.New(client_certificate_verifier_config.WithRootsFunction(reloader.ClientCas))
+	if err != nil {
+		t.Fatalf("client certificate verifier new: %v", err)
+	}
+
+	service, err := New(
+		service_config.WithEndpoints(
+			&endpoint.Endpoint{
+				Path:                 "/",
+				Method:               http.MethodGet,
+				AuthenticationParser: requestParserAdapter.New(parser),
+				Handler: func(request *http.Request, _ []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+					return &muxResponse.Response{Body: []byte("hello")}, nil
+				},
+			},
+		),
+		service_config.WithCertificateReloader(reloader),
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	listener := listen(t)
+	address := listener.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(t.Context()))
+	defer cancel()
+	go func() { _ = service.ServeListener(ctx, listener) }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(authority.certificate)
+
+	// request performs a request on a connection of its own, returning the status and the name of
+	// the certificate the service presented.
+	request := func(certificates ...tls.Certificate) (int, string) {
+		t.Helper()
+
+		httpClient := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certificates},
+				DisableKeepAlives: true,
+			},
+		}
+
+		request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "https://"+address+"/", nil)
+		if err != nil {
+			t.Fatalf("http new request with context: %v", err)
+		}
+
+		response, err := httpClient.Do(request)
+		if err != nil {
+			t.Fatalf("client do: %v", err)
+		}
+		_ = response.Body.Close()
+
+		return response.StatusCode, response.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	if statusCode, name := request(client.tlsCertificate()); statusCode != http.StatusOK || name != "first" {
+		t.Errorf("with a client certificate: got %d from %q", statusCode, name)
+	}
+	if statusCode, _ := request(); statusCode != http.StatusUnauthorized {
+		t.Errorf("without a client certificate: got %d", statusCode)
+	}
+
+	writeTestCertificate(t, newServerCertificate("second"), certificateFile, keyFile)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, name := request(client.tlsCertificate()); name == "second" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the new certificate was never served")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
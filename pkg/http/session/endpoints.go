@@ -0,0 +1,190 @@
+package session
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/middleware"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/request_parser/adapter"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	muxResponseWriter "github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+	muxUtils "github.com/Motmedel/utils_go/pkg/http/mux/utils"
+)
+
+// Login authenticates the user making a login request, with what the request carries, returning
+// what the session is to say of the user: the subject, and the authentication methods and roles.
+type Login func(request *http.Request, body []byte) (*Session, *muxResponseError.ResponseError)
+
+// makeCookieHeaderEntries makes the entries that set the cookies.
+func makeCookieHeaderEntries(cookies []*http.Cookie) []*muxResponse.HeaderEntry {
+	var headerEntries []*muxResponse.HeaderEntry
+	for _, cookie := range cookies {
+		if cookie == nil {
+			continue
+		}
+		headerEntries = append(headerEntries, &muxResponse.HeaderEntry{Name: "Set-Cookie", Value: cookie.String()})
+	}
+
+	return headerEntries
+}
+
+// makeSessionResponse makes the response that hands the client the cookies. A response that sets a
+// session is not to be stored by anyone.
+func makeSessionResponse(cookies []*http.Cookie, headerEntries ...*muxResponse.HeaderEntry) *muxResponse.Response {
+	return &muxResponse.Response{
+		StatusCode: http.StatusNoContent,
+		Headers: slices.Concat(
+			[]*muxResponse.HeaderEntry{{Name: "Cache-Control", Value: "no-store"}},
+			makeCookieHeaderEntries(cookies),
+			headerEntries,
+		),
+	}
+}
+
+// LoginEndpoint serves the logins: a session is issued to the user that login authenticates. The
+// CSRF token of the session is answered with in the CSRF header as well as in its cookie. A login
+// from another origin than a trusted one is refused, lest another site log the user in to an
+// account of its choosing.
+//
+// The endpoint is to be given a body loader if login reads the body.
+func (manager *Manager) LoginEndpoint(login Login) *endpoint.Endpoint {
+	return &endpoint.Endpoint{
+		Path:   manager.config.LoginPath,
+		Method: http.MethodPost,
+		Handler: func(request *http.Request, body []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			if login == nil {
+				return nil, &muxResponseError.ResponseError{
+					ServerError: motmedelErrors.NewWithTrace(nil_error.New("login")),
+				}
+			}
+
+			if err := manager.CheckOrigin(request); err != nil {
+				return nil, makeResponseError(fmt.Errorf("check origin: %w", err))
+			}
+
+			session, responseError := login(request, body)
+			if responseError != nil {
+				return nil, responseError
+			}
+
+			issued, cookies, err := manager.Issue(session)
+			if err != nil {
+				return nil, &muxResponseError.ResponseError{ServerError: fmt.Errorf("issue: %w", err)}
+			}
+
+			csrfToken, err := manager.CsrfToken(issued)
+			if err != nil {
+				return nil, &muxResponseError.ResponseError{ServerError: fmt.Errorf("csrf token: %w", err)}
+			}
+
+			return makeSessionResponse(
+				cookies,
+				&muxResponse.HeaderEntry{Name: manager.config.CsrfHeaderName, Value: csrfToken},
+			), nil
+		},
+		Public: true,
+	}
+}
+
+// RefreshEndpoint serves the refreshes of sessions whose session tokens expired. A refresh that
+// fails has the client forget the session. A refresh from another origin than a trusted one is
+// refused, as a login is.
+func (manager *Manager) RefreshEndpoint() *endpoint.Endpoint {
+	return &endpoint.Endpoint{
+		Path:   manager.config.RefreshPath,
+		Method: http.MethodPost,
+		Handler: func(request *http.Request, _ []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			if err := manager.CheckOrigin(request); err != nil {
+				return nil, makeResponseError(fmt.Errorf("check origin: %w", err))
+			}
+
+			_, cookies, err := manager.Refresh(request)
+			if err != nil {
+				responseError := makeResponseError(fmt.Errorf("refresh: %w", err))
+				if responseError.ServerError == nil {
+					responseError.Headers = makeCookieHeaderEntries(manager.ClearingCookies())
+				}
+				return nil, responseError
+			}
+
+			return makeSessionResponse(cookies), nil
+		},
+		Public: true,
+	}
+}
+
+// LogoutEndpoint serves the logouts: the session is revoked, and the client made to forget it.
+func (manager *Manager) LogoutEndpoint() *endpoint.Endpoint {
+	return &endpoint.Endpoint{
+		Path:   manager.config.LogoutPath,
+		Method: http.MethodPost,
+		Handler: func(request *http.Request, _ []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			cookies, err := manager.Logout(request)
+			if err != nil {
+				return nil, makeResponseError(fmt.Errorf("logout: %w", err))
+			}
+
+			return makeSessionResponse(cookies), nil
+		},
+		// Public, so that a session that has expired can be logged out of as well.
+		Public: true,
+	}
+}
+
+func (manager *Manager) Endpoints(login Login) []*endpoint.Endpoint {
+	return []*endpoint.Endpoint{manager.LoginEndpoint(login), manager.RefreshEndpoint(), manager.LogoutEndpoint()}
+}
+
+// renewalMiddleware sets the cookie holding the renewed session token on the response, where the
+// session token was renewed as the request was authenticated. A handler that wrote its response
+// itself has sent its headers already, and is left as it is.
+func renewalMiddleware(next middleware.Handler) middleware.Handler {
+	return func(
+		request *http.Request,
+		responseWriter *muxResponseWriter.ResponseWriter,
+	) (*muxResponse.Response, *muxResponseError.ResponseError) {
+		response, responseError := next(request, responseWriter)
+
+		session, _ := request.Context().Value(muxUtils.ParsedRequestAuthenticationContextKey).(*Session)
+		renewedCookie := session.RenewedCookie()
+		if renewedCookie == nil || (responseWriter != nil && responseWriter.WriteHeaderCalled) {
+			return response, responseError
+		}
+
+		headerEntries := makeCookieHeaderEntries([]*http.Cookie{renewedCookie})
+		if responseError != nil {
+			responseError.Headers = append(responseError.Headers, headerEntries...)
+			return response, responseError
+		}
+
+		if response == nil {
+			response = &muxResponse.Response{}
+		}
+		response.Headers = append(response.Headers, headerEntries...)
+
+		return response, nil
+	}
+}
+
+// Protect makes the endpoints require a session: their requests are authenticated by the session
+// they are made in, those that change something by its CSRF token too, and a session token renewed
+// as a request is authenticated is set by the response.
+func (manager *Manager) Protect(endpoints ...*endpoint.Endpoint) {
+	for _, protectedEndpoint := range endpoints {
+		if protectedEndpoint == nil {
+			continue
+		}
+
+		protectedEndpoint.AuthenticationParser = adapter.New[*Session](manager)
+		protectedEndpoint.Public = false
+		protectedEndpoint.Middleware = slices.Concat(
+			[]middleware.HandlerMiddleware{renewalMiddleware},
+			protectedEndpoint.Middleware,
+		)
+	}
+}
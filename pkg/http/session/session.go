@@ -0,0 +1,695 @@
+// Package session issues, renews, refreshes and revokes the sessions of the users of a service,
+// which are kept in cookies holding signed -- and, optionally, encrypted -- JWTs.
+//
+// A session is two tokens: a session token, short-lived and renewed while it is used, which
+// authenticates the requests; and a refresh token, with which a new session token is issued once
+// the session has been idle for too long. A refresh token is used once, and replaced with a new one
+// as it is; a refresh token used twice has been stolen, and the session is revoked. Revoked
+// sessions are kept in a store that the service checks each request against.
+//
+// The requests that change something are to carry a CSRF token, which is bound to the session.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	motmedelCryptoInterfaces "github.com/Motmedel/utils_go/pkg/crypto/interfaces"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+	"github.com/Motmedel/utils_go/pkg/errors/types/mismatch_error"
+	"github.com/Motmedel/utils_go/pkg/errors/types/missing_error"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	"github.com/Motmedel/utils_go/pkg/http/session/session_config"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail"
+	"github.com/Motmedel/utils_go/pkg/http/types/problem_detail/problem_detail_config"
+	"github.com/Motmedel/utils_go/pkg/json/jose/jwe"
+	"github.com/Motmedel/utils_go/pkg/json/jose/jwt/types/claims/session_claims"
+	motmedelJwtToken "github.com/Motmedel/utils_go/pkg/json/jose/jwt/types/token"
+	"github.com/Motmedel/utils_go/pkg/json/jose/jwt/types/token/authenticated_token"
+	"github.com/Motmedel/utils_go/pkg/json/jose/jwt/types/token/authenticated_token/authenticated_token_config"
+	jwtValidator "github.com/Motmedel/utils_go/pkg/json/jose/jwt/types/validator"
+	"github.com/Motmedel/utils_go/pkg/json/jose/jwt/types/validator/registered_claims_validator"
+	"github.com/Motmedel/utils_go/pkg/json/jose/jwt/types/validator/setting"
+	"github.com/Motmedel/utils_go/pkg/schema"
+	"github.com/Motmedel/utils_go/pkg/utils"
+)
+
+var (
+	ErrNoSession         = errors.New("no session")
+	ErrExpired           = errors.New("expired session")
+	ErrRevoked           = errors.New("revoked session")
+	ErrRefreshTokenReuse = errors.New("refresh token reuse")
+	ErrCsrfTokenMismatch = errors.New("csrf token mismatch")
+	ErrCrossOrigin       = errors.New("cross-origin request")
+)
+
+const (
+	sessionIdClaim = "sid"
+	// tokenUseClaim tells the session tokens and the refresh tokens apart, so that neither can be
+	// used as the other.
+	tokenUseClaim    = "token_use"
+	tokenUseSession  = "session"
+	tokenUseRefresh  = "refresh"
+	csrfTokenContext = "csrf\n"
+)
+
+// tokenValidator validates the tokens as the default one does, but for the times, which are
+// validated against the manager's clock instead.
+var tokenValidator = &jwtValidator.Validator{
+	PayloadValidator: &registered_claims_validator.Validator{
+		Settings: map[string]setting.Setting{"exp": setting.Skip, "nbf": setting.Skip, "iat": setting.Skip},
+	},
+}
+
+// Session is a user's session: who the user authenticated as, and how, and for how long the session
+// lasts.
+type Session struct {
+	Id                    string
+	Subject               string
+	AuthenticationMethods []string
+	Roles                 []string
+	AuthenticatedAt       time.Time
+	// ExpiresAt is when the session token expires, unless it is renewed before.
+	ExpiresAt time.Time
+
+	// renewedCookie is a session cookie that expires later, which the response is to set.
+	renewedCookie *http.Cookie
+}
+
+// GetUser returns the user of the session. A subject of the form "<id>:<email>" is the user's id
+// and email address, as in the session claims of other tokens.
+func (session *Session) GetUser() *schema.User {
+	if session == nil || session.Subject == "" {
+		return nil
+	}
+
+	user := &schema.User{Id: session.Subject, Roles: session.Roles}
+	if id, email, found := strings.Cut(session.Subject, ":"); found {
+		user.Id = id
+		user.Email = email
+	}
+
+	return user
+}
+
+// RenewedCookie returns the cookie holding the renewed session token, if the session token was
+// renewed as the session was authenticated.
+func (session *Session) RenewedCookie() *http.Cookie {
+	if session == nil {
+		return nil
+	}
+
+	return session.renewedCookie
+}
+
+// Manager manages the sessions of a service.
+type Manager struct {
+	SigningMethod         motmedelCryptoInterfaces.Method
+	config                *session_config.Config
+	crossOriginProtection *http.CrossOriginProtection
+}
+
+func validationError(err error) error {
+	return fmt.Errorf("%w: %w", motmedelErrors.ErrValidationError, err)
+}
+
+func newId() string {
+	return rand.Text()
+}
+
+func earliest(times ...time.Time) time.Time {
+	first := times[0]
+	for _, t := range times[1:] {
+		if t.Before(first) {
+			first = t
+		}
+	}
+
+	return first
+}
+
+func (manager *Manager) now() time.Time {
+	return manager.config.Now().Truncate(time.Second)
+}
+
+func (manager *Manager) absoluteExpiry(session *Session) time.Time {
+	return session.AuthenticatedAt.Add(manager.config.AbsoluteTimeout)
+}
+
+// encode signs the claims as a JWT, and encrypts the JWT where the manager is to.
+func (manager *Manager) encode(claims map[string]any) (string, error) {
+	tokenString, err := (&motmedelJwtToken.Token{Payload: claims}).Encode(manager.SigningMethod)
+	if err != nil {
+		return "", fmt.Errorf("token encode: %w", err)
+	}
+
+	encrypter := manager.config.Encrypter
+	if encrypter == nil {
+		return tokenString, nil
+	}
+
+	encrypted, err := encrypter.Encrypt([]byte(tokenString))
+	if err != nil {
+		return "", fmt.Errorf("encrypter encrypt: %w", err)
+	}
+
+	return encrypted, nil
+}
+
+// decode decrypts the value where the manager encrypts, and verifies the JWT in it. What cannot be
+// decrypted or verified is a validation error, it being what the client sent.
+func (manager *Manager) decode(value string) (map[string]any, error) {
+	tokenString := value
+	if encrypter := manager.config.Encrypter; encrypter != nil {
+		encryption, err := jwe.ParseCompact(
+			value,
+			[]jwe.KeyAlgorithm{encrypter.KeyAlgorithm},
+			[]jwe.ContentEncryption{encrypter.ContentEncryption},
+		)
+		if err != nil {
+			return nil, validationError(fmt.Errorf("jwe parse compact: %w", err))
+		}
+
+		plaintext, err := encryption.Decrypt(manager.config.DecryptionKey)
+		if err != nil {
+			return nil, validationError(fmt.Errorf("encryption decrypt: %w", err))
+		}
+		tokenString = string(plaintext)
+	}
+
+	token, err := authenticated_token.New(
+		tokenString,
+		authenticated_token_config.WithSignatureVerifier(manager.SigningMethod),
+		authenticated_token_config.WithTokenValidator(tokenValidator),
+	)
+	if err != nil {
+		return nil, validationError(fmt.Errorf("authenticated token new: %w", err))
+	}
+	if token == nil || token.Token == nil {
+		return nil, validationError(nil_error.New("authenticated jwt token"))
+	}
+
+	return token.Payload, nil
+}
+
+func (manager *Manager) makeClaims(session *Session, use string, issuedAt time.Time, expiresAt time.Time) map[string]any {
+	claims := map[string]any{
+		"sub":          session.Subject,
+		"jti":          newId(),
+		"iat":          issuedAt.Unix(),
+		"exp":          expiresAt.Unix(),
+		"auth_time":    session.AuthenticatedAt.Unix(),
+		sessionIdClaim: session.Id,
+		tokenUseClaim:  use,
+	}
+	if issuer := manager.config.Issuer; issuer != "" {
+		claims["iss"] = issuer
+	}
+	if len(session.AuthenticationMethods) != 0 {
+		claims["amr"] = session.AuthenticationMethods
+	}
+	if len(session.Roles) != 0 {
+		claims["roles"] = session.Roles
+	}
+
+	return claims
+}
+
+// parse decodes a token of the use, returning the session it is of, with the expiry of the token,
+// and the token's id.
+func (manager *Manager) parse(value string, use string, now time.Time) (*Session, string, error) {
+	payload, err := manager.decode(value)
+	if err != nil {
+		return nil, "", fmt.Errorf("decode: %w", err)
+	}
+
+	claims, err := session_claims.New(payload)
+	if err != nil {
+		return nil, "", validationError(fmt.Errorf("session claims new: %w", err))
+	}
+	if claims == nil {
+		return nil, "", validationError(nil_error.New("session claims"))
+	}
+
+	if tokenUse, _ := utils.MapGetConvert[string](payload, tokenUseClaim); tokenUse != use {
+		return nil, "", validationError(mismatch_error.New(tokenUseClaim, tokenUse, use))
+	}
+
+	if issuer := manager.config.Issuer; issuer != "" && claims.Issuer != issuer {
+		return nil, "", validationError(mismatch_error.New("iss", claims.Issuer, issuer))
+	}
+
+	sessionId, _ := utils.MapGetConvert[string](payload, sessionIdClaim)
+	if sessionId == "" {
+		return nil, "", validationError(missing_error.New(sessionIdClaim))
+	}
+	if claims.Subject == "" {
+		return nil, "", validationError(missing_error.New("sub"))
+	}
+	if claims.ExpiresAt == nil {
+		return nil, "", validationError(missing_error.New("exp"))
+	}
+	if claims.AuthenticatedAt == nil {
+		return nil, "", validationError(missing_error.New("auth_time"))
+	}
+
+	session := &Session{
+		Id:                    sessionId,
+		Subject:               claims.Subject,
+		AuthenticationMethods: claims.AuthenticationMethods,
+		Roles:                 claims.Roles,
+		AuthenticatedAt:       claims.AuthenticatedAt.Time,
+		ExpiresAt:             claims.ExpiresAt.Time,
+	}
+
+	if !now.Before(session.ExpiresAt) || !now.Before(manager.absoluteExpiry(session)) {
+		return nil, "", validationError(ErrExpired)
+	}
+
+	return session, claims.Id, nil
+}
+
+func (manager *Manager) makeCookie(name string, value string, expiresAt time.Time, httpOnly bool) *http.Cookie {
+	config := manager.config
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     config.CookiePath,
+		Domain:   config.CookieDomain,
+		Expires:  expiresAt,
+		Secure:   !config.Insecure,
+		HttpOnly: httpOnly,
+		// Not sent along with cross-site requests at all, so that only the site itself makes use of
+		// the session.
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+func (manager *Manager) makeSessionCookie(session *Session, now time.Time) (*http.Cookie, error) {
+	value, err := manager.encode(manager.makeClaims(session, tokenUseSession, now, session.ExpiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("encode (session): %w", err)
+	}
+
+	return manager.makeCookie(manager.config.CookieName, value, session.ExpiresAt, true), nil
+}
+
+func (manager *Manager) makeRefreshCookie(session *Session, now time.Time) (*http.Cookie, error) {
+	expiresAt := earliest(now.Add(manager.config.RefreshTimeout), manager.absoluteExpiry(session))
+
+	value, err := manager.encode(manager.makeClaims(session, tokenUseRefresh, now, expiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("encode (refresh): %w", err)
+	}
+
+	return manager.makeCookie(manager.config.RefreshCookieName, value, expiresAt, true), nil
+}
+
+// makeCsrfCookie makes the cookie the CSRF token is handed to the client in, which the client is to
+// read, unlike the others.
+func (manager *Manager) makeCsrfCookie(session *Session) (*http.Cookie, error) {
+	csrfToken, err := manager.CsrfToken(session)
+	if err != nil {
+		return nil, fmt.Errorf("csrf token: %w", err)
+	}
+
+	return manager.makeCookie(manager.config.CsrfCookieName, csrfToken, manager.absoluteExpiry(session), false), nil
+}
+
+// makeCookies makes the cookies of a session that is issued or refreshed.
+func (manager *Manager) makeCookies(session *Session, now time.Time) ([]*http.Cookie, error) {
+	sessionCookie, err := manager.makeSessionCookie(session, now)
+	if err != nil {
+		return nil, fmt.Errorf("make session cookie: %w", err)
+	}
+
+	refreshCookie, err := manager.makeRefreshCookie(session, now)
+	if err != nil {
+		return nil, fmt.Errorf("make refresh cookie: %w", err)
+	}
+
+	csrfCookie, err := manager.makeCsrfCookie(session)
+	if err != nil {
+		return nil, fmt.Errorf("make csrf cookie: %w", err)
+	}
+
+	return []*http.Cookie{sessionCookie, refreshCookie, csrfCookie}, nil
+}
+
+// ClearingCookies returns the cookies that make the client forget the session.
+func (manager *Manager) ClearingCookies() []*http.Cookie {
+	config := manager.config
+
+	var cookies []*http.Cookie
+	for _, name := range []string{config.CookieName, config.RefreshCookieName, config.CsrfCookieName} {
+		cookie := manager.makeCookie(name, "", time.Unix(0, 0), name != config.CsrfCookieName)
+		cookie.MaxAge = -1
+		cookies = append(cookies, cookie)
+	}
+
+	return cookies
+}
+
+// Issue issues a session for the user the session says, who authenticated just now, returning it
+// and the cookies that hold it.
+func (manager *Manager) Issue(session *Session) (*Session, []*http.Cookie, error) {
+	if session == nil {
+		return nil, nil, motmedelErrors.NewWithTrace(nil_error.New("session"))
+	}
+	if session.Subject == "" {
+		return nil, nil, motmedelErrors.NewWithTrace(empty_error.New("subject"))
+	}
+
+	now := manager.now()
+	issued := &Session{
+		Id:                    newId(),
+		Subject:               session.Subject,
+		AuthenticationMethods: session.AuthenticationMethods,
+		Roles:                 session.Roles,
+		AuthenticatedAt:       now,
+	}
+	issued.ExpiresAt = earliest(now.Add(manager.config.IdleTimeout), manager.absoluteExpiry(issued))
+
+	cookies, err := manager.makeCookies(issued, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("make cookies: %w", err)
+	}
+
+	return issued, cookies, nil
+}
+
+func (manager *Manager) checkRevoked(ctx context.Context, session *Session) error {
+	revoked, err := manager.config.Store.Revoked(ctx, session.Id)
+	if err != nil {
+		return motmedelErrors.New(fmt.Errorf("store revoked: %w", err), session.Id)
+	}
+	if revoked {
+		return validationError(ErrRevoked)
+	}
+
+	return nil
+}
+
+func getCookieValue(request *http.Request, name string) (string, error) {
+	cookie, err := request.Cookie(name)
+	if err != nil || cookie.Value == "" {
+		return "", validationError(ErrNoSession)
+	}
+
+	return cookie.Value, nil
+}
+
+// Authenticate returns the session the request is made in. A session token close to its expiry is
+// renewed, the cookie holding the renewed one being for the response to the request to set.
+func (manager *Manager) Authenticate(request *http.Request) (*Session, error) {
+	if request == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("request"))
+	}
+
+	value, err := getCookieValue(request, manager.config.CookieName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := manager.now()
+	session, _, err := manager.parse(value, tokenUseSession, now)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+
+	if err := manager.checkRevoked(request.Context(), session); err != nil {
+		return nil, fmt.Errorf("check revoked: %w", err)
+	}
+
+	if session.ExpiresAt.Sub(now) < manager.config.RenewBefore {
+		expiresAt := earliest(now.Add(manager.config.IdleTimeout), manager.absoluteExpiry(session))
+		if expiresAt.After(session.ExpiresAt) {
+			session.ExpiresAt = expiresAt
+			renewedCookie, err := manager.makeSessionCookie(session, now)
+			if err != nil {
+				return nil, fmt.Errorf("make session cookie: %w", err)
+			}
+			session.renewedCookie = renewedCookie
+		}
+	}
+
+	return session, nil
+}
+
+// Refresh issues a new session token with the refresh token the request carries, and a new refresh
+// token in place of that one. A refresh token that was used already has the session revoked.
+func (manager *Manager) Refresh(request *http.Request) (*Session, []*http.Cookie, error) {
+	if request == nil {
+		return nil, nil, motmedelErrors.NewWithTrace(nil_error.New("request"))
+	}
+
+	value, err := getCookieValue(request, manager.config.RefreshCookieName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := manager.now()
+	session, tokenId, err := manager.parse(value, tokenUseRefresh, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse: %w", err)
+	}
+	if tokenId == "" {
+		return nil, nil, validationError(missing_error.New("jti"))
+	}
+
+	ctx := request.Context()
+	if err := manager.checkRevoked(ctx, session); err != nil {
+		return nil, nil, fmt.Errorf("check revoked: %w", err)
+	}
+
+	used, err := manager.config.Store.ConsumeRefreshToken(ctx, tokenId, session.ExpiresAt.Sub(now))
+	if err != nil {
+		return nil, nil, motmedelErrors.New(fmt.Errorf("store consume refresh token: %w", err), tokenId)
+	}
+	if used {
+		// Either the client or whoever stole the token from it used it first; which one is not known,
+		// so neither is let on.
+		if err := manager.Revoke(ctx, session.Id); err != nil {
+			return nil, nil, fmt.Errorf("revoke: %w", err)
+		}
+		return nil, nil, validationError(ErrRefreshTokenReuse)
+	}
+
+	session.ExpiresAt = earliest(now.Add(manager.config.IdleTimeout), manager.absoluteExpiry(session))
+
+	cookies, err := manager.makeCookies(session, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("make cookies: %w", err)
+	}
+
+	return session, cookies, nil
+}
+
+// Revoke revokes the session, so that none of its tokens is valid anymore.
+func (manager *Manager) Revoke(ctx context.Context, sessionId string) error {
+	if sessionId == "" {
+		return motmedelErrors.NewWithTrace(empty_error.New("session id"))
+	}
+
+	// No token of the session is valid after the absolute timeout anyway.
+	if err := manager.config.Store.Revoke(ctx, sessionId, manager.config.AbsoluteTimeout); err != nil {
+		return motmedelErrors.New(fmt.Errorf("store revoke: %w", err), sessionId)
+	}
+
+	return nil
+}
+
+// Logout revokes the session the request is made in, if it is still valid, returning the cookies
+// that make the client forget it. The request is to carry the CSRF token of the session, lest
+// another site log the user out.
+func (manager *Manager) Logout(request *http.Request) ([]*http.Cookie, error) {
+	if request == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("request"))
+	}
+
+	value, err := getCookieValue(request, manager.config.CookieName)
+	if err != nil {
+		return manager.ClearingCookies(), nil
+	}
+
+	session, _, err := manager.parse(value, tokenUseSession, manager.now())
+	if err != nil {
+		if errors.Is(err, motmedelErrors.ErrValidationError) {
+			return manager.ClearingCookies(), nil
+		}
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+
+	if err := manager.checkRevoked(request.Context(), session); err != nil {
+		if errors.Is(err, ErrRevoked) {
+			return manager.ClearingCookies(), nil
+		}
+		return nil, fmt.Errorf("check revoked: %w", err)
+	}
+
+	if err := manager.VerifyCsrfToken(session, request.Header.Get(manager.config.CsrfHeaderName)); err != nil {
+		return nil, fmt.Errorf("verify csrf token: %w", err)
+	}
+
+	if err := manager.Revoke(request.Context(), session.Id); err != nil {
+		return nil, fmt.Errorf("revoke: %w", err)
+	}
+
+	return manager.ClearingCookies(), nil
+}
+
+func csrfTokenMessage(session *Session) []byte {
+	return []byte(csrfTokenContext + session.Id)
+}
+
+// CsrfToken returns the CSRF token of the session: the signature of its id, which only the service
+// can make, and which is of that session alone.
+func (manager *Manager) CsrfToken(session *Session) (string, error) {
+	if session == nil {
+		return "", motmedelErrors.NewWithTrace(nil_error.New("session"))
+	}
+
+	signature, err := manager.SigningMethod.Sign(csrfTokenMessage(session))
+	if err != nil {
+		return "", motmedelErrors.NewWithTrace(fmt.Errorf("signing method sign: %w", err))
+	}
+
+	return base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyCsrfToken verifies that the CSRF token is the session's.
+func (manager *Manager) VerifyCsrfToken(session *Session, csrfToken string) error {
+	if session == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("session"))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(csrfToken)
+	if err != nil || len(signature) == 0 {
+		return validationError(ErrCsrfTokenMismatch)
+	}
+
+	if err := manager.SigningMethod.Verify(csrfTokenMessage(session), signature); err != nil {
+		return validationError(fmt.Errorf("%w: signing method verify: %w", ErrCsrfTokenMismatch, err))
+	}
+
+	return nil
+}
+
+// CheckOrigin checks that the request is made from the origin of the service, or a trusted one, by
+// its Sec-Fetch-Site or Origin header. The logins and refreshes are checked so, as they are made
+// without a CSRF token: the client has no session to have one of, or has one that expired.
+func (manager *Manager) CheckOrigin(request *http.Request) error {
+	if request == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("request"))
+	}
+
+	crossOriginProtection := manager.crossOriginProtection
+	if crossOriginProtection == nil {
+		crossOriginProtection = http.NewCrossOriginProtection()
+	}
+
+	if err := crossOriginProtection.Check(request); err != nil {
+		return motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: %w", ErrCrossOrigin, err),
+			request.Header.Get("Origin"), request.Header.Get("Sec-Fetch-Site"),
+		)
+	}
+
+	return nil
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// makeResponseError makes the response to a request whose session could not be had, or could not
+// be refreshed.
+func makeResponseError(err error) *muxResponseError.ResponseError {
+	var statusCode int
+	var detail string
+	switch {
+	case errors.Is(err, ErrCsrfTokenMismatch):
+		statusCode, detail = http.StatusForbidden, "Invalid CSRF token."
+	case errors.Is(err, ErrCrossOrigin):
+		statusCode, detail = http.StatusForbidden, "Cross-origin request."
+	case errors.Is(err, ErrNoSession):
+		statusCode, detail = http.StatusUnauthorized, "Missing session."
+	case errors.Is(err, ErrExpired):
+		statusCode, detail = http.StatusUnauthorized, "Expired session."
+	case errors.Is(err, motmedelErrors.ErrValidationError):
+		statusCode, detail = http.StatusUnauthorized, "Invalid session."
+	default:
+		return &muxResponseError.ResponseError{ServerError: err}
+	}
+
+	return &muxResponseError.ResponseError{
+		ClientError:   err,
+		ProblemDetail: problem_detail.New(statusCode, problem_detail_config.WithDetail(detail)),
+	}
+}
+
+// Parse authenticates the session the request is made in, as the authentication parser of an
+// endpoint. A request that changes something is to carry the session's CSRF token.
+func (manager *Manager) Parse(request *http.Request) (*Session, *muxResponseError.ResponseError) {
+	session, err := manager.Authenticate(request)
+	if err != nil {
+		return nil, makeResponseError(fmt.Errorf("authenticate: %w", err))
+	}
+
+	if !isSafeMethod(request.Method) {
+		if err := manager.VerifyCsrfToken(session, request.Header.Get(manager.config.CsrfHeaderName)); err != nil {
+			return nil, makeResponseError(fmt.Errorf("verify csrf token: %w", err))
+		}
+	}
+
+	return session, nil
+}
+
+func New(signingMethod motmedelCryptoInterfaces.Method, options ...session_config.Option) (*Manager, error) {
+	if utils.IsNil(signingMethod) {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("signing method"))
+	}
+
+	config := session_config.New(options...)
+
+	if config.IdleTimeout <= 0 || config.RefreshTimeout <= 0 || config.AbsoluteTimeout <= 0 {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: the timeouts are to be positive", motmedelErrors.ErrValidationError),
+		)
+	}
+
+	if config.CookieName == "" || config.RefreshCookieName == "" || config.CsrfCookieName == "" {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("cookie name"))
+	}
+
+	if (config.Encrypter == nil) != utils.IsNil(config.DecryptionKey) {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: an encrypter requires a decryption key, and the other way around", motmedelErrors.ErrValidationError),
+		)
+	}
+
+	crossOriginProtection := http.NewCrossOriginProtection()
+	for _, origin := range config.TrustedOrigins {
+		if err := crossOriginProtection.AddTrustedOrigin(origin); err != nil {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: cross origin protection add trusted origin: %w", motmedelErrors.ErrValidationError, err),
+				origin,
+			)
+		}
+	}
+
+	return &Manager{SigningMethod: signingMethod, config: config, crossOriginProtection: crossOriginProtection}, nil
+}
@@ -0,0 +1,215 @@
+package session_config
+
+import (
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/session/store"
+	"github.com/Motmedel/utils_go/pkg/http/session/store/memory_store"
+	"github.com/Motmedel/utils_go/pkg/json/jose/jwe"
+)
+
+const (
+	DefaultCookieName        = "session"
+	DefaultRefreshCookieName = "session_refresh"
+	DefaultCsrfCookieName    = "csrf"
+	DefaultCsrfHeaderName    = "X-Csrf-Token"
+	DefaultCookiePath        = "/"
+	DefaultIdleTimeout       = 15 * time.Minute
+	DefaultRefreshTimeout    = 24 * time.Hour
+	DefaultAbsoluteTimeout   = 7 * 24 * time.Hour
+	DefaultLoginPath         = "/login"
+	DefaultLogoutPath        = "/logout"
+	DefaultRefreshPath       = "/refresh"
+)
+
+type Config struct {
+	CookieName        string
+	RefreshCookieName string
+	// CsrfCookieName is the name of the cookie the CSRF token is handed to the client in, for it to
+	// send back in the CsrfHeaderName header.
+	CsrfCookieName string
+	CsrfHeaderName string
+	CookieDomain   string
+	CookiePath     string
+	// Insecure makes the cookies be sent over plain HTTP as well, for development.
+	Insecure bool
+
+	// IdleTimeout is for how long a session cookie is valid. The cookie is renewed while used, so
+	// that it is an idle session that expires.
+	IdleTimeout time.Duration
+	// RenewBefore is how close to its expiry a session cookie is renewed; half the idle timeout when
+	// not set.
+	RenewBefore time.Duration
+	// RefreshTimeout is for how long a refresh token is valid. Each is used once, being replaced
+	// with a new one as a new session cookie is issued with it.
+	RefreshTimeout time.Duration
+	// AbsoluteTimeout is for how long after the user authenticated a session lasts, however it is
+	// renewed and refreshed.
+	AbsoluteTimeout time.Duration
+
+	// Issuer is the issuer of the tokens, which the tokens are required to be from.
+	Issuer string
+	// Encrypter encrypts the tokens, which are then decrypted with DecryptionKey; the tokens are
+	// signed only when it is not set.
+	Encrypter     *jwe.Encrypter
+	DecryptionKey any
+	// Store holds the revoked sessions and the used refresh tokens; a new in-memory store is used
+	// when none is set.
+	Store store.Store
+
+	LoginPath   string
+	LogoutPath  string
+	RefreshPath string
+	// TrustedOrigins are the origins, e.g. "https://app.example.com", besides that of the service
+	// that the logins and refreshes may be made from.
+	TrustedOrigins []string
+
+	Now func() time.Time
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		CookieName:        DefaultCookieName,
+		RefreshCookieName: DefaultRefreshCookieName,
+		CsrfCookieName:    DefaultCsrfCookieName,
+		CsrfHeaderName:    DefaultCsrfHeaderName,
+		CookiePath:        DefaultCookiePath,
+		IdleTimeout:       DefaultIdleTimeout,
+		RefreshTimeout:    DefaultRefreshTimeout,
+		AbsoluteTimeout:   DefaultAbsoluteTimeout,
+		LoginPath:         DefaultLoginPath,
+		LogoutPath:        DefaultLogoutPath,
+		RefreshPath:       DefaultRefreshPath,
+		Now:               time.Now,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	if config.RenewBefore == 0 {
+		config.RenewBefore = config.IdleTimeout / 2
+	}
+
+	if config.Store == nil {
+		config.Store = memory_store.New()
+	}
+
+	return config
+}
+
+func WithCookieName(cookieName string) Option {
+	return func(config *Config) {
+		config.CookieName = cookieName
+	}
+}
+
+func WithRefreshCookieName(refreshCookieName string) Option {
+	return func(config *Config) {
+		config.RefreshCookieName = refreshCookieName
+	}
+}
+
+func WithCsrfCookieName(csrfCookieName string) Option {
+	return func(config *Config) {
+		config.CsrfCookieName = csrfCookieName
+	}
+}
+
+func WithCsrfHeaderName(csrfHeaderName string) Option {
+	return func(config *Config) {
+		config.CsrfHeaderName = csrfHeaderName
+	}
+}
+
+func WithCookieDomain(cookieDomain string) Option {
+	return func(config *Config) {
+		config.CookieDomain = cookieDomain
+	}
+}
+
+func WithCookiePath(cookiePath string) Option {
+	return func(config *Config) {
+		config.CookiePath = cookiePath
+	}
+}
+
+func WithInsecure(insecure bool) Option {
+	return func(config *Config) {
+		config.Insecure = insecure
+	}
+}
+
+func WithIdleTimeout(idleTimeout time.Duration) Option {
+	return func(config *Config) {
+		config.IdleTimeout = idleTimeout
+	}
+}
+
+func WithRenewBefore(renewBefore time.Duration) Option {
+	return func(config *Config) {
+		config.RenewBefore = renewBefore
+	}
+}
+
+func WithRefreshTimeout(refreshTimeout time.Duration) Option {
+	return func(config *Config) {
+		config.RefreshTimeout = refreshTimeout
+	}
+}
+
+func WithAbsoluteTimeout(absoluteTimeout time.Duration) Option {
+	return func(config *Config) {
+		config.AbsoluteTimeout = absoluteTimeout
+	}
+}
+
+func WithIssuer(issuer string) Option {
+	return func(config *Config) {
+		config.Issuer = issuer
+	}
+}
+
+func WithEncryption(encrypter *jwe.Encrypter, decryptionKey any) Option {
+	return func(config *Config) {
+		config.Encrypter = encrypter
+		config.DecryptionKey = decryptionKey
+	}
+}
+
+func WithStore(store store.Store) Option {
+	return func(config *Config) {
+		config.Store = store
+	}
+}
+
+func WithLoginPath(loginPath string) Option {
+	return func(config *Config) {
+		config.LoginPath = loginPath
+	}
+}
+
+func WithLogoutPath(logoutPath string) Option {
+	return func(config *Config) {
+		config.LogoutPath = logoutPath
+	}
+}
+
+func WithTrustedOrigins(trustedOrigins ...string) Option {
+	return func(config *Config) {
+		config.TrustedOrigins = trustedOrigins
+	}
+}
+
+func WithRefreshPath(refreshPath string) Option {
+	return func(config *Config) {
+		config.RefreshPath = refreshPath
+	}
+}
+
+func WithNow(now func() time.Time) Option {
+	return func(config *Config) {
+		config.Now = now
+	}
+}
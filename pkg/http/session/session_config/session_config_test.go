@@ -0,0 +1,116 @@
+package session_config
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/session/store/memory_store"
+	"github.com/Motmedel/utils_go/pkg/json/jose/jwe"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.CookieName != DefaultCookieName {
+		t.Errorf("cookie name: got %q", config.CookieName)
+	}
+	if config.RefreshCookieName != DefaultRefreshCookieName {
+		t.Errorf("refresh cookie name: got %q", config.RefreshCookieName)
+	}
+	if config.CsrfCookieName != DefaultCsrfCookieName || config.CsrfHeaderName != DefaultCsrfHeaderName {
+		t.Errorf("csrf: got %q, %q", config.CsrfCookieName, config.CsrfHeaderName)
+	}
+	if config.CookiePath != DefaultCookiePath {
+		t.Errorf("cookie path: got %q", config.CookiePath)
+	}
+	if config.Insecure {
+		t.Error("expected the cookies to be secure by default")
+	}
+	if config.IdleTimeout != DefaultIdleTimeout || config.RenewBefore != DefaultIdleTimeout/2 {
+		t.Errorf("idle timeout: got %v, renew before %v", config.IdleTimeout, config.RenewBefore)
+	}
+	if config.RefreshTimeout != DefaultRefreshTimeout || config.AbsoluteTimeout != DefaultAbsoluteTimeout {
+		t.Errorf("timeouts: got %v, %v", config.RefreshTimeout, config.AbsoluteTimeout)
+	}
+	if config.LoginPath != DefaultLoginPath || config.LogoutPath != DefaultLogoutPath || config.RefreshPath != DefaultRefreshPath {
+		t.Errorf("paths: got %q, %q, %q", config.LoginPath, config.LogoutPath, config.RefreshPath)
+	}
+	if config.TrustedOrigins != nil {
+		t.Errorf("expected no trusted origins, got %v", config.TrustedOrigins)
+	}
+	if config.Now == nil {
+		t.Error("expected a default now function")
+	}
+	if _, ok := config.Store.(*memory_store.Store); !ok {
+		t.Errorf("expected a default memory store, got %T", config.Store)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	store := memory_store.New()
+	encrypter := &jwe.Encrypter{}
+	now := time.Unix(1, 0)
+
+	config := New(
+		WithCookieName("__Host-session"),
+		WithRefreshCookieName("__Host-refresh"),
+		WithCsrfCookieName("__Host-csrf"),
+		WithCsrfHeaderName("X-Xsrf-Token"),
+		WithCookieDomain("example.com"),
+		WithCookiePath("/app"),
+		WithInsecure(true),
+		WithIdleTimeout(time.Hour),
+		WithRefreshTimeout(2*time.Hour),
+		WithAbsoluteTimeout(3*time.Hour),
+		WithIssuer("https://example.com"),
+		WithEncryption(encrypter, "key"),
+		WithStore(store),
+		WithLoginPath("/session/login"),
+		WithLogoutPath("/session/logout"),
+		WithRefreshPath("/session/refresh"),
+		WithTrustedOrigins("https://app.example.com"),
+		WithNow(func() time.Time { return now }),
+	)
+
+	if config.CookieName != "__Host-session" || config.RefreshCookieName != "__Host-refresh" {
+		t.Errorf("cookie names: got %q, %q", config.CookieName, config.RefreshCookieName)
+	}
+	if config.CsrfCookieName != "__Host-csrf" || config.CsrfHeaderName != "X-Xsrf-Token" {
+		t.Errorf("csrf: got %q, %q", config.CsrfCookieName, config.CsrfHeaderName)
+	}
+	if config.CookieDomain != "example.com" || config.CookiePath != "/app" || !config.Insecure {
+		t.Errorf("cookies: got %q, %q, %v", config.CookieDomain, config.CookiePath, config.Insecure)
+	}
+	if config.IdleTimeout != time.Hour || config.RenewBefore != 30*time.Minute {
+		t.Errorf("idle timeout: got %v, renew before %v", config.IdleTimeout, config.RenewBefore)
+	}
+	if config.RefreshTimeout != 2*time.Hour || config.AbsoluteTimeout != 3*time.Hour {
+		t.Errorf("timeouts: got %v, %v", config.RefreshTimeout, config.AbsoluteTimeout)
+	}
+	if config.Issuer != "https://example.com" {
+		t.Errorf("issuer: got %q", config.Issuer)
+	}
+	if config.Encrypter != encrypter || config.DecryptionKey != "key" {
+		t.Errorf("encryption: got %v, %v", config.Encrypter, config.DecryptionKey)
+	}
+	if config.Store != store {
+		t.Errorf("store: got %v", config.Store)
+	}
+	if config.LoginPath != "/session/login" || config.LogoutPath != "/session/logout" || config.RefreshPath != "/session/refresh" {
+		t.Errorf("paths: got %q, %q, %q", config.LoginPath, config.LogoutPath, config.RefreshPath)
+	}
+	if !slices.Equal(config.TrustedOrigins, []string{"https://app.example.com"}) {
+		t.Errorf("trusted origins: got %v", config.TrustedOrigins)
+	}
+	if !config.Now().Equal(now) {
+		t.Errorf("now: got %v", config.Now())
+	}
+
+	if config := New(WithRenewBefore(time.Minute)); config.RenewBefore != time.Minute {
+		t.Errorf("renew before: got %v", config.RenewBefore)
+	}
+}
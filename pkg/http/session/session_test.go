@@ -0,0 +1,539 @@
+package session
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	motmedelHmac "github.com/Motmedel/utils_go/pkg/crypto/hmac"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelMux "github.com/Motmedel/utils_go/pkg/http/mux"
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/endpoint"
+	muxResponse "github.com/Motmedel/utils_go/pkg/http/mux/types/response"
+	muxResponseError "github.com/Motmedel/utils_go/pkg/http/mux/types/response_error"
+	muxUtils "github.com/Motmedel/utils_go/pkg/http/mux/utils"
+	"github.com/Motmedel/utils_go/pkg/http/session/session_config"
+	"github.com/Motmedel/utils_go/pkg/json/jose/jwe"
+)
+
+var testStart = time.Unix(1700000000, 0)
+
+// newTestManager makes a manager whose clock is advanced by the returned function.
+func newTestManager(t *testing.T, options ...session_config.Option) (*Manager, func(time.Duration)) {
+	t.Helper()
+
+	method, err := motmedelHmac.New("HS256", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("hmac new: %v", err)
+	}
+
+	now := testStart
+	options = append(
+		[]session_config.Option{
+			session_config.WithNow(func() time.Time { return now }),
+			session_config.WithIdleTimeout(10 * time.Minute),
+			session_config.WithRefreshTimeout(time.Hour),
+			session_config.WithAbsoluteTimeout(2 * time.Hour),
+		},
+		options...,
+	)
+
+	manager, err := New(method, options...)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	return manager, func(duration time.Duration) { now = now.Add(duration) }
+}
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, cookie := range cookies {
+		if cookie != nil && cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func makeRequest(method string, target string, cookies ...*http.Cookie) *http.Request {
+	request := httptest.NewRequest(method, target, nil)
+	for _, cookie := range cookies {
+		if cookie != nil {
+			request.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+	return request
+}
+
+func issue(t *testing.T, manager *Manager) (*Session, []*http.Cookie) {
+	t.Helper()
+
+	session, cookies, err := manager.Issue(&Session{Subject: "1:user@example.com", Roles: []string{"admin"}})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if len(cookies) != 3 {
+		t.Fatalf("expected three cookies, got %d", len(cookies))
+	}
+
+	return session, cookies
+}
+
+func TestManager_Issue(t *testing.T) {
+	t.Parallel()
+
+	manager, _ := newTestManager(t)
+	session, cookies := issue(t, manager)
+
+	if session.Id == "" || !session.AuthenticatedAt.Equal(testStart) {
+		t.Errorf("unexpected session: %+v", session)
+	}
+	if !session.ExpiresAt.Equal(testStart.Add(10 * time.Minute)) {
+		t.Errorf("expires at: got %v", session.ExpiresAt)
+	}
+
+	sessionCookie := findCookie(cookies, session_config.DefaultCookieName)
+	refreshCookie := findCookie(cookies, session_config.DefaultRefreshCookieName)
+	csrfCookie := findCookie(cookies, session_config.DefaultCsrfCookieName)
+	if sessionCookie == nil || refreshCookie == nil || csrfCookie == nil {
+		t.Fatalf("missing cookies: %v", cookies)
+	}
+	if !sessionCookie.HttpOnly || !sessionCookie.Secure || sessionCookie.SameSite != http.SameSiteStrictMode {
+		t.Errorf("unexpected session cookie attributes: %s", sessionCookie)
+	}
+	if csrfCookie.HttpOnly {
+		t.Error("expected the csrf cookie to be readable by the client")
+	}
+	if !refreshCookie.Expires.Equal(testStart.Add(time.Hour)) {
+		t.Errorf("refresh cookie expires: got %v", refreshCookie.Expires)
+	}
+
+	user := session.GetUser()
+	if user == nil || user.Id != "1" || user.Email != "user@example.com" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+
+	if _, _, err := manager.Issue(&Session{}); err == nil {
+		t.Error("expected an error for a session without a subject")
+	}
+}
+
+func TestManager_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		advance     time.Duration
+		cookie      func(cookies []*http.Cookie) *http.Cookie
+		wantErr     error
+		wantRenewed bool
+	}{
+		{name: "fresh", advance: time.Minute},
+		{name: "renewed", advance: 6 * time.Minute, wantRenewed: true},
+		{name: "expired", advance: 10 * time.Minute, wantErr: ErrExpired},
+		{name: "no cookie", cookie: func([]*http.Cookie) *http.Cookie { return nil }, wantErr: ErrNoSession},
+		{
+			name: "refresh token as session token",
+			cookie: func(cookies []*http.Cookie) *http.Cookie {
+				return &http.Cookie{
+					Name:  session_config.DefaultCookieName,
+					Value: findCookie(cookies, session_config.DefaultRefreshCookieName).Value,
+				}
+			},
+			wantErr: motmedelErrors.ErrValidationError,
+		},
+		{
+			name: "tampered",
+			cookie: func(cookies []*http.Cookie) *http.Cookie {
+				value := findCookie(cookies, session_config.DefaultCookieName).Value
+				return &http.Cookie{Name: session_config.DefaultCookieName, Value: value[:len(value)-2] + "AA"}
+			},
+			wantErr: motmedelErrors.ErrValidationError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			manager, advance := newTestManager(t)
+			issued, cookies := issue(t, manager)
+			advance(testCase.advance)
+
+			cookie := findCookie(cookies, session_config.DefaultCookieName)
+			if testCase.cookie != nil {
+				cookie = testCase.cookie(cookies)
+			}
+
+			session, err := manager.Authenticate(makeRequest(http.MethodGet, "/", cookie))
+			if testCase.wantErr != nil {
+				if !errors.Is(err, testCase.wantErr) {
+					t.Fatalf("expected %v, got %v", testCase.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("authenticate: %v", err)
+			}
+
+			if session.Id != issued.Id || session.Subject != issued.Subject {
+				t.Errorf("unexpected session: %+v", session)
+			}
+
+			renewedCookie := session.RenewedCookie()
+			if (renewedCookie != nil) != testCase.wantRenewed {
+				t.Fatalf("renewed cookie: got %v", renewedCookie)
+			}
+			if renewedCookie != nil {
+				wantExpiresAt := testStart.Add(testCase.advance + 10*time.Minute)
+				if !session.ExpiresAt.Equal(wantExpiresAt) || !renewedCookie.Expires.Equal(wantExpiresAt) {
+					t.Errorf("renewed expiry: got %v, %v", session.ExpiresAt, renewedCookie.Expires)
+				}
+			}
+		})
+	}
+}
+
+func TestManager_Authenticate_absoluteTimeout(t *testing.T) {
+	t.Parallel()
+
+	manager, advance := newTestManager(t)
+	_, cookies := issue(t, manager)
+	sessionCookie := findCookie(cookies, session_config.DefaultCookieName)
+
+	// Renewing every few minutes keeps the session until the absolute timeout, and not any longer.
+	for elapsed := time.Duration(0); elapsed < 2*time.Hour; elapsed += 6 * time.Minute {
+		session, err := manager.Authenticate(makeRequest(http.MethodGet, "/", sessionCookie))
+		if err != nil {
+			t.Fatalf("authenticate after %v: %v", elapsed, err)
+		}
+		if session.ExpiresAt.After(testStart.Add(2 * time.Hour)) {
+			t.Fatalf("renewed past the absolute timeout: %v", session.ExpiresAt)
+		}
+		if renewedCookie := session.RenewedCookie(); renewedCookie != nil {
+			sessionCookie = renewedCookie
+		}
+		advance(6 * time.Minute)
+	}
+
+	if _, err := manager.Authenticate(makeRequest(http.MethodGet, "/", sessionCookie)); !errors.Is(err, ErrExpired) {
+		t.Errorf("expected an expired session, got %v", err)
+	}
+}
+
+func TestManager_Refresh(t *testing.T) {
+	t.Parallel()
+
+	manager, advance := newTestManager(t)
+	issued, cookies := issue(t, manager)
+	refreshCookie := findCookie(cookies, session_config.DefaultRefreshCookieName)
+
+	advance(20 * time.Minute)
+
+	session, refreshedCookies, err := manager.Refresh(
+		makeRequest(http.MethodPost, "/refresh", refreshCookie),
+	)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if session.Id != issued.Id || !session.AuthenticatedAt.Equal(issued.AuthenticatedAt) {
+		t.Errorf("unexpected session: %+v", session)
+	}
+
+	newSessionCookie := findCookie(refreshedCookies, session_config.DefaultCookieName)
+	newRefreshCookie := findCookie(refreshedCookies, session_config.DefaultRefreshCookieName)
+	if newRefreshCookie == nil || newRefreshCookie.Value == refreshCookie.Value {
+		t.Fatal("expected the refresh token to be rotated")
+	}
+	if _, err := manager.Authenticate(makeRequest(http.MethodGet, "/", newSessionCookie)); err != nil {
+		t.Fatalf("authenticate with the refreshed session token: %v", err)
+	}
+
+	// The old refresh token being used again has the whole session revoked.
+	_, _, err = manager.Refresh(makeRequest(http.MethodPost, "/refresh", refreshCookie))
+	if !errors.Is(err, ErrRefreshTokenReuse) {
+		t.Fatalf("expected refresh token reuse, got %v", err)
+	}
+	if _, err := manager.Authenticate(makeRequest(http.MethodGet, "/", newSessionCookie)); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected a revoked session, got %v", err)
+	}
+	if _, _, err := manager.Refresh(makeRequest(http.MethodPost, "/refresh", newRefreshCookie)); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected a revoked session, got %v", err)
+	}
+}
+
+func TestManager_Logout(t *testing.T) {
+	t.Parallel()
+
+	manager, _ := newTestManager(t)
+	session, cookies := issue(t, manager)
+	sessionCookie := findCookie(cookies, session_config.DefaultCookieName)
+
+	if _, err := manager.Logout(makeRequest(http.MethodPost, "/logout", sessionCookie)); !errors.Is(err, ErrCsrfTokenMismatch) {
+		t.Fatalf("expected a csrf token mismatch, got %v", err)
+	}
+
+	csrfToken, err := manager.CsrfToken(session)
+	if err != nil {
+		t.Fatalf("csrf token: %v", err)
+	}
+
+	request := makeRequest(http.MethodPost, "/logout", sessionCookie)
+	request.Header.Set(session_config.DefaultCsrfHeaderName, csrfToken)
+	clearingCookies, err := manager.Logout(request)
+	if err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	if len(clearingCookies) != 3 || clearingCookies[0].MaxAge != -1 {
+		t.Errorf("unexpected clearing cookies: %v", clearingCookies)
+	}
+
+	if _, err := manager.Authenticate(makeRequest(http.MethodGet, "/", sessionCookie)); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected a revoked session, got %v", err)
+	}
+
+	if _, err := manager.Logout(makeRequest(http.MethodPost, "/logout")); err != nil {
+		t.Errorf("expected a logout without a session to succeed, got %v", err)
+	}
+}
+
+func TestManager_VerifyCsrfToken(t *testing.T) {
+	t.Parallel()
+
+	manager, _ := newTestManager(t)
+	session, _ := issue(t, manager)
+	other, _ := issue(t, manager)
+
+	csrfToken, err := manager.CsrfToken(session)
+	if err != nil {
+		t.Fatalf("csrf token: %v", err)
+	}
+	otherCsrfToken, err := manager.CsrfToken(other)
+	if err != nil {
+		t.Fatalf("csrf token: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		csrfToken string
+		wantErr   bool
+	}{
+		{name: "valid", csrfToken: csrfToken},
+		{name: "other session", csrfToken: otherCsrfToken, wantErr: true},
+		{name: "empty", wantErr: true},
+		{name: "not base64", csrfToken: "!", wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := manager.VerifyCsrfToken(session, testCase.csrfToken)
+			if testCase.wantErr != errors.Is(err, ErrCsrfTokenMismatch) {
+				t.Errorf("got %v", err)
+			}
+		})
+	}
+}
+
+func TestManager_CheckOrigin(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		header  http.Header
+		options []session_config.Option
+		wantErr bool
+	}{
+		{name: "no origin", header: http.Header{}},
+		{name: "same origin fetch", header: http.Header{"Sec-Fetch-Site": {"same-origin"}}},
+		{name: "same site fetch", header: http.Header{"Sec-Fetch-Site": {"same-site"}}, wantErr: true},
+		{name: "cross site fetch", header: http.Header{"Sec-Fetch-Site": {"cross-site"}}, wantErr: true},
+		{name: "same origin", header: http.Header{"Origin": {"http://example.com"}}},
+		{name: "other origin", header: http.Header{"Origin": {"https://evil.example"}}, wantErr: true},
+		{
+			name:    "trusted origin",
+			header:  http.Header{"Origin": {"https://app.example"}, "Sec-Fetch-Site": {"cross-site"}},
+			options: []session_config.Option{session_config.WithTrustedOrigins("https://app.example")},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			manager, _ := newTestManager(t, testCase.options...)
+
+			request := makeRequest(http.MethodPost, "/login")
+			for name, values := range testCase.header {
+				request.Header[name] = values
+			}
+
+			err := manager.CheckOrigin(request)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("got %v, want error %t", err, testCase.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrCrossOrigin) {
+				t.Fatalf("expected ErrCrossOrigin, got %v", err)
+			}
+		})
+	}
+
+	method, err := motmedelHmac.New("HS256", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("hmac new: %v", err)
+	}
+	if _, err := New(method, session_config.WithTrustedOrigins("app.example")); !errors.Is(err, motmedelErrors.ErrValidationError) {
+		t.Fatalf("expected a validation error for a malformed trusted origin, got %v", err)
+	}
+}
+
+func TestManager_encryption(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	encrypter, err := jwe.NewEncrypter(jwe.KeyAlgorithmEcdhEs, jwe.ContentEncryptionA256Gcm, &key.PublicKey)
+	if err != nil {
+		t.Fatalf("new encrypter: %v", err)
+	}
+
+	manager, _ := newTestManager(t, session_config.WithEncryption(encrypter, key))
+	issued, cookies := issue(t, manager)
+
+	sessionCookie := findCookie(cookies, session_config.DefaultCookieName)
+	if strings.Count(sessionCookie.Value, ".") != 4 {
+		t.Fatalf("expected a jwe compact serialization, got %q", sessionCookie.Value)
+	}
+
+	session, err := manager.Authenticate(makeRequest(http.MethodGet, "/", sessionCookie))
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if session.Id != issued.Id {
+		t.Errorf("session id: got %q, want %q", session.Id, issued.Id)
+	}
+
+	if _, err := New(manager.SigningMethod, session_config.WithEncryption(encrypter, nil)); err == nil {
+		t.Error("expected an error for an encrypter without a decryption key")
+	}
+}
+
+func TestManager_Endpoints(t *testing.T) {
+	t.Parallel()
+
+	manager, advance := newTestManager(t)
+
+	login := func(request *http.Request, _ []byte) (*Session, *muxResponseError.ResponseError) {
+		return &Session{Subject: request.URL.Query().Get("user")}, nil
+	}
+
+	protected := &endpoint.Endpoint{
+		Path:   "/me",
+		Method: http.MethodPost,
+		Handler: func(request *http.Request, _ []byte) (*muxResponse.Response, *muxResponseError.ResponseError) {
+			session, _ := request.Context().Value(muxUtils.ParsedRequestAuthenticationContextKey).(*Session)
+			return &muxResponse.Response{Body: []byte(session.Subject)}, nil
+		},
+	}
+	manager.Protect(protected)
+
+	mux := motmedelMux.New(append(manager.Endpoints(login), protected)...)
+	serve := func(request *http.Request) *http.Response {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, request)
+		return recorder.Result()
+	}
+
+	response := serve(makeRequest(http.MethodPost, "/login?user=alice"))
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("login: got status %d", response.StatusCode)
+	}
+	if response.Header.Get("Cache-Control") != "no-store" {
+		t.Errorf("login: got cache control %q", response.Header.Get("Cache-Control"))
+	}
+	csrfToken := response.Header.Get(session_config.DefaultCsrfHeaderName)
+	cookies := response.Cookies()
+	if csrfToken == "" || findCookie(cookies, session_config.DefaultCsrfCookieName).Value != csrfToken {
+		t.Fatalf("login: unexpected csrf token %q", csrfToken)
+	}
+
+	crossOriginLogin := makeRequest(http.MethodPost, "/login?user=mallory")
+	crossOriginLogin.Header.Set("Origin", "https://evil.example")
+	if response := serve(crossOriginLogin); response.StatusCode != http.StatusForbidden || len(response.Cookies()) != 0 {
+		t.Errorf("login, cross-origin: got status %d and cookies %v", response.StatusCode, response.Cookies())
+	}
+
+	makeProtectedRequest := func(csrfToken string, cookies ...*http.Cookie) *http.Request {
+		request := makeRequest(http.MethodPost, "/me", cookies...)
+		request.Header.Set(session_config.DefaultCsrfHeaderName, csrfToken)
+		return request
+	}
+	sessionCookie := findCookie(cookies, session_config.DefaultCookieName)
+
+	if response := serve(makeProtectedRequest(csrfToken, sessionCookie)); response.StatusCode != http.StatusOK {
+		t.Fatalf("protected: got status %d", response.StatusCode)
+	} else if len(response.Cookies()) != 0 {
+		t.Errorf("protected: expected no renewed cookie, got %v", response.Cookies())
+	}
+	if response := serve(makeProtectedRequest("", sessionCookie)); response.StatusCode != http.StatusForbidden {
+		t.Errorf("protected without a csrf token: got status %d", response.StatusCode)
+	}
+	if response := serve(makeProtectedRequest(csrfToken)); response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("protected without a session: got status %d", response.StatusCode)
+	}
+
+	advance(6 * time.Minute)
+	response = serve(makeProtectedRequest(csrfToken, sessionCookie))
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("protected, renewed: got status %d", response.StatusCode)
+	}
+	if findCookie(response.Cookies(), session_config.DefaultCookieName) == nil {
+		t.Error("protected, renewed: expected a renewed session cookie")
+	}
+
+	advance(10 * time.Minute)
+	if response := serve(makeProtectedRequest(csrfToken, sessionCookie)); response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("protected, expired: got status %d", response.StatusCode)
+	}
+
+	refreshCookie := findCookie(cookies, session_config.DefaultRefreshCookieName)
+	response = serve(makeRequest(http.MethodPost, "/refresh", refreshCookie))
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("refresh: got status %d", response.StatusCode)
+	}
+	sessionCookie = findCookie(response.Cookies(), session_config.DefaultCookieName)
+
+	crossOriginRefresh := makeRequest(http.MethodPost, "/refresh", refreshCookie)
+	crossOriginRefresh.Header.Set("Origin", "https://evil.example")
+	if response := serve(crossOriginRefresh); response.StatusCode != http.StatusForbidden {
+		t.Errorf("refresh, cross-origin: got status %d", response.StatusCode)
+	}
+
+	response = serve(makeRequest(http.MethodPost, "/refresh", refreshCookie))
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("refresh, reused: got status %d", response.StatusCode)
+	}
+	if cookie := findCookie(response.Cookies(), session_config.DefaultCookieName); cookie == nil || cookie.MaxAge != -1 {
+		t.Errorf("refresh, reused: expected the session cookie to be cleared, got %v", cookie)
+	}
+	if response := serve(makeProtectedRequest(csrfToken, sessionCookie)); response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("protected, revoked: got status %d", response.StatusCode)
+	}
+
+	response = serve(makeRequest(http.MethodPost, "/logout", sessionCookie))
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("logout: got status %d", response.StatusCode)
+	}
+	if cookie := findCookie(response.Cookies(), session_config.DefaultCookieName); cookie == nil || cookie.MaxAge != -1 {
+		t.Errorf("logout: expected the session cookie to be cleared, got %v", cookie)
+	}
+}
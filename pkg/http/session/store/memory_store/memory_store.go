@@ -0,0 +1,91 @@
+package memory_store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the number of writes after which expired entries are evicted.
+const sweepInterval = 1024
+
+// Store is an in-process store. Expired entries are evicted when read and periodically when
+// writing.
+type Store struct {
+	mutex          sync.Mutex
+	revoked        map[string]time.Time
+	consumedTokens map[string]time.Time
+	writes         int
+}
+
+func sweep(expiries map[string]time.Time, now time.Time) {
+	for key, expiresAt := range expiries {
+		if !now.Before(expiresAt) {
+			delete(expiries, key)
+		}
+	}
+}
+
+func (memoryStore *Store) write(now time.Time) {
+	memoryStore.writes++
+	if memoryStore.writes%sweepInterval == 0 {
+		sweep(memoryStore.revoked, now)
+		sweep(memoryStore.consumedTokens, now)
+	}
+}
+
+func (memoryStore *Store) Revoke(_ context.Context, sessionId string, ttl time.Duration) error {
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	now := time.Now()
+	memoryStore.write(now)
+
+	memoryStore.revoked[sessionId] = now.Add(ttl)
+
+	return nil
+}
+
+func (memoryStore *Store) Revoked(_ context.Context, sessionId string) (bool, error) {
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	expiresAt, ok := memoryStore.revoked[sessionId]
+	if !ok {
+		return false, nil
+	}
+	if !time.Now().Before(expiresAt) {
+		delete(memoryStore.revoked, sessionId)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (memoryStore *Store) ConsumeRefreshToken(_ context.Context, tokenId string, ttl time.Duration) (bool, error) {
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	now := time.Now()
+	memoryStore.write(now)
+
+	if expiresAt, ok := memoryStore.consumedTokens[tokenId]; ok && now.Before(expiresAt) {
+		return true, nil
+	}
+
+	memoryStore.consumedTokens[tokenId] = now.Add(ttl)
+
+	return false, nil
+}
+
+// Len returns the number of revoked sessions and used refresh tokens held, expired ones among them.
+func (memoryStore *Store) Len() int {
+	memoryStore.mutex.Lock()
+	defer memoryStore.mutex.Unlock()
+
+	return len(memoryStore.revoked) + len(memoryStore.consumedTokens)
+}
+
+func New() *Store {
+	return &Store{revoked: make(map[string]time.Time), consumedTokens: make(map[string]time.Time)}
+}
@@ -0,0 +1,61 @@
+package memory_store
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestStore_Revoke(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		memoryStore := New()
+		ctx := t.Context()
+
+		if revoked, err := memoryStore.Revoked(ctx, "session"); err != nil || revoked {
+			t.Fatalf("revoked: got %v, %v", revoked, err)
+		}
+
+		if err := memoryStore.Revoke(ctx, "session", time.Minute); err != nil {
+			t.Fatalf("revoke: %v", err)
+		}
+		if revoked, _ := memoryStore.Revoked(ctx, "session"); !revoked {
+			t.Fatal("expected the session to be revoked")
+		}
+		if revoked, _ := memoryStore.Revoked(ctx, "other"); revoked {
+			t.Fatal("expected another session not to be revoked")
+		}
+
+		time.Sleep(time.Minute)
+
+		if revoked, _ := memoryStore.Revoked(ctx, "session"); revoked {
+			t.Fatal("expected the revocation to have expired")
+		}
+		if memoryStore.Len() != 0 {
+			t.Errorf("len: got %d", memoryStore.Len())
+		}
+	})
+}
+
+func TestStore_ConsumeRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		memoryStore := New()
+		ctx := t.Context()
+
+		if used, err := memoryStore.ConsumeRefreshToken(ctx, "token", time.Minute); err != nil || used {
+			t.Fatalf("first consumption: got %v, %v", used, err)
+		}
+		if used, _ := memoryStore.ConsumeRefreshToken(ctx, "token", time.Minute); !used {
+			t.Fatal("expected the second consumption to find the token used")
+		}
+
+		time.Sleep(time.Minute)
+
+		if used, _ := memoryStore.ConsumeRefreshToken(ctx, "token", time.Minute); used {
+			t.Fatal("expected the mark to have expired")
+		}
+	})
+}
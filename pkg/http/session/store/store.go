@@ -0,0 +1,20 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Store holds what the sessions are checked against on the server: the sessions that are revoked,
+// and the refresh tokens that are used. A store that several replicas share makes them share both;
+// its ConsumeRefreshToken must then be atomic across them.
+type Store interface {
+	// Revoke adds the session to the revocation list. It is remembered for ttl, after which no token
+	// of the session is valid anyway.
+	Revoke(ctx context.Context, sessionId string, ttl time.Duration) error
+	// Revoked reports whether the session is in the revocation list.
+	Revoked(ctx context.Context, sessionId string) (bool, error)
+	// ConsumeRefreshToken marks the refresh token as used, and reports whether it was used already.
+	// The mark is remembered for ttl, after which the token is expired anyway.
+	ConsumeRefreshToken(ctx context.Context, tokenId string, ttl time.Duration) (bool, error)
+}
@@ -0,0 +1,624 @@
+package structured_field
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+)
+
+// The mapping of Go values to structured field values, and back, goes as follows:
+//
+//   - bool, the integers, the floats, string, Token, DisplayString, []byte and time.Time are bare
+//     items, as is said of Item.
+//   - A slice of anything else is an inner list, or a list where it is the value as a whole.
+//   - A struct is a dictionary, its fields being the members. A struct with a field tagged as the
+//     value is an item or an inner list instead, the rest of its fields being the parameters.
+//   - *Item, *InnerList and any are the member as it is.
+//   - Pointers are followed, and made where there is nothing to follow when unmarshalling.
+//
+// Fields are keyed by the name in their `sf` tag, or by their name in lowercase. The tag's options
+// are "omitempty", which leaves out the field where it is the zero value, and "value", which makes
+// the field the value. Fields tagged "-" and fields that are nil are left out.
+
+const tagKey = "sf"
+
+var ErrMismatchedType = errors.New("mismatched type")
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	tokenType         = reflect.TypeFor[Token]()
+	displayStringType = reflect.TypeFor[DisplayString]()
+	itemType          = reflect.TypeFor[*Item]()
+	innerListType     = reflect.TypeFor[*InnerList]()
+)
+
+type structField struct {
+	index     int
+	key       string
+	omitEmpty bool
+}
+
+// structFields returns the fields of the struct type that are members or parameters, and the index
+// of the field that is the value, or -1 where the struct is a dictionary.
+func structFields(structType reflect.Type) ([]*structField, int, error) {
+	valueIndex := -1
+
+	var fields []*structField
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get(tagKey)
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		isValue := false
+		omitEmpty := false
+		for option := range strings.SplitSeq(options, ",") {
+			switch option {
+			case "value":
+				isValue = true
+			case "omitempty":
+				omitEmpty = true
+			}
+		}
+
+		if isValue {
+			if valueIndex >= 0 {
+				return nil, 0, semanticError(
+					fmt.Errorf("%w: more than one value field", ErrUnsupportedType),
+					structType.String(),
+				)
+			}
+			valueIndex = i
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if !isValidKey(name) {
+			return nil, 0, semanticError(fmt.Errorf("%w: key", ErrUnexpectedCharacter), name)
+		}
+
+		fields = append(fields, &structField{index: i, key: name, omitEmpty: omitEmpty})
+	}
+
+	return fields, valueIndex, nil
+}
+
+func isBareItemType(valueType reflect.Type) bool {
+	switch valueType {
+	case timeType, tokenType, displayStringType:
+		return true
+	}
+
+	switch valueType.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Slice:
+		return valueType.Elem().Kind() == reflect.Uint8
+	default:
+		return false
+	}
+}
+
+func isListType(valueType reflect.Type) bool {
+	return valueType.Kind() == reflect.Slice && !isBareItemType(valueType)
+}
+
+// isDictionaryType reports whether the type is a struct without a value field.
+func isDictionaryType(valueType reflect.Type) (bool, error) {
+	if valueType.Kind() != reflect.Struct || valueType == timeType {
+		return false, nil
+	}
+
+	_, valueIndex, err := structFields(valueType)
+	if err != nil {
+		return false, err
+	}
+
+	return valueIndex < 0, nil
+}
+
+// indirect follows the pointers and interfaces of the value to what they point to, stopping at an
+// *Item or an *InnerList. It reports whether there was anything there.
+func indirect(value reflect.Value) (reflect.Value, bool) {
+	for value.IsValid() && (value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return value, false
+		}
+		if value.Type() == itemType || value.Type() == innerListType {
+			return value, true
+		}
+		value = value.Elem()
+	}
+
+	return value, value.IsValid()
+}
+
+// isLeftOut reports whether the field is left out of the dictionary or parameters it is in.
+func isLeftOut(value reflect.Value, field *structField) bool {
+	if _, ok := indirect(value); !ok {
+		return true
+	}
+
+	return field.omitEmpty && value.IsZero()
+}
+
+func marshalBareItem(value reflect.Value) (any, error) {
+	value, ok := indirect(value)
+	if !ok {
+		return nil, semanticError(fmt.Errorf("%w: nil bare item", ErrUnsupportedType), nil)
+	}
+
+	switch value.Type() {
+	case timeType:
+		return value.Interface(), nil
+	case tokenType:
+		return Token(value.String()), nil
+	case displayStringType:
+		return DisplayString(value.String()), nil
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return value.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		unsigned := value.Uint()
+		if unsigned > maxInteger {
+			return nil, semanticError(fmt.Errorf("%w: integer", ErrOutOfRange), unsigned)
+		}
+		return int64(unsigned), nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), nil
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return value.Bytes(), nil
+		}
+	}
+
+	return nil, semanticError(fmt.Errorf("%w: %s", ErrUnsupportedType, value.Type()), value.Type().String())
+}
+
+func marshalParameters(value reflect.Value, fields []*structField) (Parameters, error) {
+	var parameters Parameters
+	for _, field := range fields {
+		fieldValue := value.Field(field.index)
+		if isLeftOut(fieldValue, field) {
+			continue
+		}
+
+		bareItem, err := marshalBareItem(fieldValue)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", field.key, err)
+		}
+
+		parameters = append(parameters, &Parameter{Key: field.key, Value: bareItem})
+	}
+
+	return parameters, nil
+}
+
+// marshalMember returns the value as an *Item or an *InnerList.
+func marshalMember(value reflect.Value) (any, error) {
+	value, ok := indirect(value)
+	if !ok {
+		return nil, semanticError(fmt.Errorf("%w: nil member", ErrUnsupportedType), nil)
+	}
+
+	valueType := value.Type()
+	switch {
+	case valueType == itemType || valueType == innerListType:
+		return value.Interface(), nil
+	case isBareItemType(valueType):
+		bareItem, err := marshalBareItem(value)
+		if err != nil {
+			return nil, err
+		}
+		return &Item{Value: bareItem}, nil
+	case isListType(valueType):
+		innerList := &InnerList{}
+		for i := range value.Len() {
+			member, err := marshalMember(value.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("inner list item %d: %w", i, err)
+			}
+			item, ok := member.(*Item)
+			if !ok {
+				return nil, semanticError(fmt.Errorf("%w: inner list in an inner list", ErrUnsupportedType), member)
+			}
+			innerList.Items = append(innerList.Items, item)
+		}
+		return innerList, nil
+	case valueType.Kind() == reflect.Struct:
+		fields, valueIndex, err := structFields(valueType)
+		if err != nil {
+			return nil, err
+		}
+		if valueIndex < 0 {
+			return nil, semanticError(
+				fmt.Errorf("%w: a dictionary as a member", ErrUnsupportedType),
+				valueType.String(),
+			)
+		}
+
+		parameters, err := marshalParameters(value, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		member, err := marshalMember(value.Field(valueIndex))
+		if err != nil {
+			return nil, err
+		}
+
+		// A copy, so as to not add the parameters to an *Item or *InnerList of the value's own.
+		switch typedMember := member.(type) {
+		case *Item:
+			return &Item{Value: typedMember.Value, Parameters: slices.Concat(typedMember.Parameters, parameters)}, nil
+		case *InnerList:
+			return &InnerList{
+				Items:      typedMember.Items,
+				Parameters: slices.Concat(typedMember.Parameters, parameters),
+			}, nil
+		}
+	}
+
+	return nil, semanticError(fmt.Errorf("%w: %s", ErrUnsupportedType, valueType), valueType.String())
+}
+
+func marshalDictionary(value reflect.Value) (Dictionary, error) {
+	fields, _, err := structFields(value.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var dictionary Dictionary
+	for _, field := range fields {
+		fieldValue := value.Field(field.index)
+		if isLeftOut(fieldValue, field) {
+			continue
+		}
+
+		member, err := marshalMember(fieldValue)
+		if err != nil {
+			return nil, fmt.Errorf("member %s: %w", field.key, err)
+		}
+
+		dictionary = append(dictionary, &DictionaryMember{Key: field.key, Value: member})
+	}
+
+	return dictionary, nil
+}
+
+func marshalList(value reflect.Value) (List, error) {
+	var list List
+	for i := range value.Len() {
+		member, err := marshalMember(value.Index(i))
+		if err != nil {
+			return nil, fmt.Errorf("list member %d: %w", i, err)
+		}
+		list = append(list, member)
+	}
+
+	return list, nil
+}
+
+// Marshal serializes the value as a structured field value: a dictionary where it is a struct
+// without a value field, a list where it is a slice that is not a byte sequence, and an item
+// otherwise.
+func Marshal(value any) (string, error) {
+	switch typedValue := value.(type) {
+	case Dictionary:
+		return SerializeDictionary(typedValue)
+	case List:
+		return SerializeList(typedValue)
+	case *Item:
+		return SerializeItem(typedValue)
+	}
+
+	reflectValue, ok := indirect(reflect.ValueOf(value))
+	if !ok {
+		return "", motmedelErrors.NewWithTrace(nil_error.New("value"))
+	}
+
+	valueType := reflectValue.Type()
+	isDictionary, err := isDictionaryType(valueType)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case isDictionary:
+		dictionary, err := marshalDictionary(reflectValue)
+		if err != nil {
+			return "", err
+		}
+		return SerializeDictionary(dictionary)
+	case isListType(valueType):
+		list, err := marshalList(reflectValue)
+		if err != nil {
+			return "", err
+		}
+		return SerializeList(list)
+	}
+
+	member, err := marshalMember(reflectValue)
+	if err != nil {
+		return "", err
+	}
+	item, ok := member.(*Item)
+	if !ok {
+		return "", semanticError(fmt.Errorf("%w: an inner list as a whole", ErrUnsupportedType), member)
+	}
+
+	return SerializeItem(item)
+}
+
+// allocate follows the pointers of the value to what they point to, making what there is not, and
+// stopping at an *Item or an *InnerList.
+func allocate(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Pointer && value.Type() != itemType && value.Type() != innerListType {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+
+	return value
+}
+
+func isAny(value reflect.Value) bool {
+	return value.Kind() == reflect.Interface && value.NumMethod() == 0
+}
+
+func mismatchError(value any, target reflect.Value) error {
+	return semanticError(fmt.Errorf("%w: %T into %s", ErrMismatchedType, value, target.Type()), value)
+}
+
+func unmarshalBareItem(value any, target reflect.Value) error {
+	target = allocate(target)
+	if isAny(target) {
+		target.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	targetType := target.Type()
+	switch typedValue := value.(type) {
+	case bool:
+		if target.Kind() == reflect.Bool {
+			target.SetBool(typedValue)
+			return nil
+		}
+	case int64:
+		switch target.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if target.OverflowInt(typedValue) {
+				return semanticError(fmt.Errorf("%w: integer into %s", ErrOutOfRange, targetType), typedValue)
+			}
+			target.SetInt(typedValue)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if typedValue < 0 || target.OverflowUint(uint64(typedValue)) {
+				return semanticError(fmt.Errorf("%w: integer into %s", ErrOutOfRange, targetType), typedValue)
+			}
+			target.SetUint(uint64(typedValue))
+			return nil
+		}
+	case float64:
+		if target.Kind() == reflect.Float32 || target.Kind() == reflect.Float64 {
+			target.SetFloat(typedValue)
+			return nil
+		}
+	case string:
+		if target.Kind() == reflect.String && targetType != tokenType && targetType != displayStringType {
+			target.SetString(typedValue)
+			return nil
+		}
+	case Token:
+		if targetType == tokenType {
+			target.SetString(string(typedValue))
+			return nil
+		}
+	case DisplayString:
+		if targetType == displayStringType {
+			target.SetString(string(typedValue))
+			return nil
+		}
+	case []byte:
+		if target.Kind() == reflect.Slice && targetType.Elem().Kind() == reflect.Uint8 {
+			target.SetBytes(typedValue)
+			return nil
+		}
+	case time.Time:
+		if targetType == timeType {
+			target.Set(reflect.ValueOf(typedValue))
+			return nil
+		}
+	}
+
+	return mismatchError(value, target)
+}
+
+func unmarshalParameters(parameters Parameters, target reflect.Value, fields []*structField) error {
+	for _, field := range fields {
+		value, ok := parameters.Get(field.key)
+		if !ok {
+			continue
+		}
+
+		if err := unmarshalBareItem(value, target.Field(field.index)); err != nil {
+			return fmt.Errorf("parameter %s: %w", field.key, err)
+		}
+	}
+
+	return nil
+}
+
+func unmarshalMember(member any, target reflect.Value) error {
+	target = allocate(target)
+
+	targetType := target.Type()
+	switch {
+	case isAny(target), targetType == itemType, targetType == innerListType:
+		memberValue := reflect.ValueOf(member)
+		if !memberValue.Type().AssignableTo(targetType) {
+			return mismatchError(member, target)
+		}
+		target.Set(memberValue)
+		return nil
+	case isBareItemType(targetType):
+		item, ok := member.(*Item)
+		if !ok || item == nil {
+			return mismatchError(member, target)
+		}
+		return unmarshalBareItem(item.Value, target)
+	case isListType(targetType):
+		innerList, ok := member.(*InnerList)
+		if !ok || innerList == nil {
+			return mismatchError(member, target)
+		}
+		slice := reflect.MakeSlice(targetType, len(innerList.Items), len(innerList.Items))
+		for i, item := range innerList.Items {
+			if err := unmarshalMember(item, slice.Index(i)); err != nil {
+				return fmt.Errorf("inner list item %d: %w", i, err)
+			}
+		}
+		target.Set(slice)
+		return nil
+	case targetType.Kind() == reflect.Struct:
+		fields, valueIndex, err := structFields(targetType)
+		if err != nil {
+			return err
+		}
+		if valueIndex < 0 {
+			return semanticError(
+				fmt.Errorf("%w: a dictionary as a member", ErrUnsupportedType),
+				targetType.String(),
+			)
+		}
+
+		var value any
+		var parameters Parameters
+		switch typedMember := member.(type) {
+		case *Item:
+			if typedMember == nil {
+				return mismatchError(member, target)
+			}
+			value = &Item{Value: typedMember.Value}
+			parameters = typedMember.Parameters
+		case *InnerList:
+			if typedMember == nil {
+				return mismatchError(member, target)
+			}
+			value = &InnerList{Items: typedMember.Items}
+			parameters = typedMember.Parameters
+		default:
+			return mismatchError(member, target)
+		}
+
+		if err := unmarshalMember(value, target.Field(valueIndex)); err != nil {
+			return err
+		}
+		return unmarshalParameters(parameters, target, fields)
+	}
+
+	return semanticError(fmt.Errorf("%w: %s", ErrUnsupportedType, targetType), targetType.String())
+}
+
+func unmarshalDictionary(dictionary Dictionary, target reflect.Value) error {
+	fields, _, err := structFields(target.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		member, ok := dictionary.Get(field.key)
+		if !ok {
+			continue
+		}
+
+		if err := unmarshalMember(member, target.Field(field.index)); err != nil {
+			return fmt.Errorf("member %s: %w", field.key, err)
+		}
+	}
+
+	return nil
+}
+
+func unmarshalList(list List, target reflect.Value) error {
+	slice := reflect.MakeSlice(target.Type(), len(list), len(list))
+	for i, member := range list {
+		if err := unmarshalMember(member, slice.Index(i)); err != nil {
+			return fmt.Errorf("list member %d: %w", i, err)
+		}
+	}
+	target.Set(slice)
+
+	return nil
+}
+
+// Unmarshal parses the data as a structured field value into what the value points to, which is
+// parsed as a dictionary where it is a struct without a value field, a list where it is a slice that
+// is not a byte sequence, and an item otherwise. Members and parameters the value has no field for
+// are ignored, and fields there are no members or parameters for are left as they are.
+func Unmarshal(data []byte, value any) error {
+	reflectValue := reflect.ValueOf(value)
+	if reflectValue.Kind() != reflect.Pointer {
+		return semanticError(fmt.Errorf("%w: not a pointer", ErrUnsupportedType), fmt.Sprintf("%T", value))
+	}
+	if reflectValue.IsNil() {
+		return motmedelErrors.NewWithTrace(nil_error.New("value"))
+	}
+
+	target := allocate(reflectValue.Elem())
+	targetType := target.Type()
+
+	isDictionary, err := isDictionaryType(targetType)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case targetType == reflect.TypeFor[Dictionary]() || isDictionary:
+		dictionary, err := ParseDictionary(data)
+		if err != nil {
+			return fmt.Errorf("parse dictionary: %w", err)
+		}
+		if !isDictionary {
+			target.Set(reflect.ValueOf(dictionary))
+			return nil
+		}
+		return unmarshalDictionary(dictionary, target)
+	case isListType(targetType):
+		list, err := ParseList(data)
+		if err != nil {
+			return fmt.Errorf("parse list: %w", err)
+		}
+		return unmarshalList(list, target)
+	}
+
+	item, err := ParseItem(data)
+	if err != nil {
+		return fmt.Errorf("parse item: %w", err)
+	}
+
+	return unmarshalMember(item, target)
+}
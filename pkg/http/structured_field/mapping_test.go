@@ -0,0 +1,202 @@
+package structured_field
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+)
+
+type testDirective struct {
+	Value    Token  `sf:",value"`
+	ReportTo string `sf:"report-to,omitempty"`
+}
+
+type testOrigins struct {
+	Origins []string `sf:",value"`
+	Self    bool     `sf:",omitempty"`
+}
+
+type testDictionary struct {
+	Policy  *testDirective `sf:"policy"`
+	Origins testOrigins    `sf:"origins"`
+	MaxAge  uint32         `sf:"max-age,omitempty"`
+	Expires time.Time      `sf:",omitempty"`
+	Title   DisplayString  `sf:"title,omitempty"`
+	Digest  []byte         `sf:",omitempty"`
+	Raw     *Item          `sf:"raw"`
+	Ignored string         `sf:"-"`
+	hidden  string
+}
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		value   any
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "dictionary",
+			value: &testDictionary{
+				Policy:  &testDirective{Value: "same-origin", ReportTo: "main"},
+				Origins: testOrigins{Origins: []string{"https://a.example"}, Self: true},
+				MaxAge:  60,
+				Expires: time.Unix(1659578233, 0),
+				Title:   "füü",
+				Raw:     &Item{Value: int64(1), Parameters: Parameters{{Key: "a", Value: true}}},
+				Ignored: "x",
+				hidden:  "y",
+			},
+			want: `policy=same-origin;report-to="main", origins=("https://a.example");self, max-age=60, expires=@1659578233, title=%"f%c3%bc%c3%bc", raw=1;a`,
+		},
+		{
+			name:  "dictionary, left out",
+			value: testDictionary{Origins: testOrigins{}},
+			want:  "origins=()",
+		},
+		{name: "item", value: testDirective{Value: "require-corp"}, want: "require-corp"},
+		{name: "bare item", value: int8(-5), want: "-5"},
+		{name: "list", value: []testDirective{{Value: "a"}, {Value: "b", ReportTo: "c"}}, want: `a, b;report-to="c"`},
+		{name: "list of inner lists", value: [][]bool{{true}, {}}, want: "(?1), ()"},
+		{name: "byte sequence", value: []byte("hi"), want: ":aGk=:"},
+		{name: "dictionary as it is", value: Dictionary{{Key: "a", Value: &Item{Value: true}}}, want: "a"},
+		{name: "inner list as a whole", value: testOrigins{}, wantErr: true},
+		{name: "inner list in an inner list", value: [][][]bool{{{true}}}, wantErr: true},
+		{name: "unsupported type", value: map[string]string{}, wantErr: true},
+		{name: "out of range", value: uint64(1e16), wantErr: true},
+		{
+			name: "bad key",
+			value: struct {
+				A int `sf:"A"`
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "more than one value field",
+			value: struct {
+				A int `sf:",value"`
+				B int `sf:",value"`
+			}{},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			serialized, err := Marshal(testCase.value)
+			if testCase.wantErr {
+				if !errors.Is(err, motmedelErrors.ErrSemanticError) {
+					t.Fatalf("expected a semantic error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if serialized != testCase.want {
+				t.Errorf("got %q, want %q", serialized, testCase.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("dictionary", func(t *testing.T) {
+		t.Parallel()
+
+		input := `policy=same-origin;report-to="main";unknown, origins=("https://a.example");self, ` +
+			`max-age=60, expires=@1659578233, title=%"f%c3%bc%c3%bc", digest=:aGk=:, raw=1;a, other=?0`
+
+		var dictionary testDictionary
+		if err := Unmarshal([]byte(input), &dictionary); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+
+		want := testDictionary{
+			Policy:  &testDirective{Value: "same-origin", ReportTo: "main"},
+			Origins: testOrigins{Origins: []string{"https://a.example"}, Self: true},
+			MaxAge:  60,
+			Expires: time.Unix(1659578233, 0).UTC(),
+			Title:   "füü",
+			Digest:  []byte("hi"),
+			Raw:     &Item{Value: int64(1), Parameters: Parameters{{Key: "a", Value: true}}},
+		}
+		if !reflect.DeepEqual(dictionary, want) {
+			t.Errorf("got %#v, want %#v", dictionary, want)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		t.Parallel()
+
+		var list []*testDirective
+		if err := Unmarshal([]byte(`a, b;report-to="c"`), &list); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+
+		want := []*testDirective{{Value: "a"}, {Value: "b", ReportTo: "c"}}
+		if !reflect.DeepEqual(list, want) {
+			t.Errorf("got %#v, want %#v", list, want)
+		}
+	})
+
+	t.Run("item", func(t *testing.T) {
+		t.Parallel()
+
+		var value *float64
+		if err := Unmarshal([]byte("1.5"), &value); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if value == nil || *value != 1.5 {
+			t.Errorf("got %v, want 1.5", value)
+		}
+	})
+
+	t.Run("any", func(t *testing.T) {
+		t.Parallel()
+
+		var value any
+		if err := Unmarshal([]byte("a;b"), &value); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+
+		want := &Item{Value: Token("a"), Parameters: Parameters{{Key: "b", Value: true}}}
+		if !reflect.DeepEqual(value, want) {
+			t.Errorf("got %#v, want %#v", value, want)
+		}
+	})
+
+	errorTestCases := []struct {
+		name   string
+		input  string
+		target any
+		want   error
+	}{
+		{name: "string into token", input: `policy="same-origin"`, target: &testDictionary{}, want: ErrMismatchedType},
+		{name: "token into string", input: "a", target: new(string), want: ErrMismatchedType},
+		{name: "inner list into item", input: "policy=(a)", target: &testDictionary{}, want: ErrMismatchedType},
+		{name: "negative into unsigned", input: "max-age=-1", target: &testDictionary{}, want: ErrOutOfRange},
+		{name: "too large", input: "300", target: new(uint8), want: ErrOutOfRange},
+		{name: "syntax", input: "a=", target: &testDictionary{}, want: motmedelErrors.ErrSyntaxError},
+		{name: "not a pointer", input: "1", target: 1, want: ErrUnsupportedType},
+	}
+
+	for _, testCase := range errorTestCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := Unmarshal([]byte(testCase.input), testCase.target); !errors.Is(err, testCase.want) {
+				t.Errorf("expected %v, got %v", testCase.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,834 @@
+// Package structured_field parses and serializes the structured field values of RFC 9651 that the
+// headers of the http packages are made of, and maps them to and from Go values.
+package structured_field
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+)
+
+var (
+	ErrUnexpectedEnd       = errors.New("unexpected end")
+	ErrUnexpectedCharacter = errors.New("unexpected character")
+	ErrOutOfRange          = errors.New("out of range")
+	ErrUnsupportedType     = errors.New("unsupported type")
+)
+
+const (
+	maxInteger          = 999_999_999_999_999
+	maxIntegerDigits    = 15
+	maxDecimalDigits    = 12
+	maxFractionDigits   = 3
+	maxDecimalLength    = 16
+	maxDecimalMagnitude = 999_999_999_999.999
+)
+
+// Token is a bare item that is a token rather than a string.
+type Token string
+
+// DisplayString is a bare item that is a display string: Unicode text, rather than the ASCII of a
+// string.
+type DisplayString string
+
+// Item is a bare item -- an int64, a float64 (a decimal), a string, a Token, a []byte (a byte
+// sequence), a bool, a time.Time (a date) or a DisplayString -- with parameters.
+type Item struct {
+	Value      any
+	Parameters Parameters
+}
+
+type InnerList struct {
+	Items      []*Item
+	Parameters Parameters
+}
+
+type Parameter struct {
+	Key   string
+	Value any
+}
+
+type Parameters []*Parameter
+
+// Get returns the value of the parameter with the key.
+func (parameters Parameters) Get(key string) (any, bool) {
+	for _, parameter := range parameters {
+		if parameter != nil && parameter.Key == key {
+			return parameter.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// Set sets the value of the parameter with the key, which is added last if there is none, and
+// returns the parameters.
+func (parameters Parameters) Set(key string, value any) Parameters {
+	for _, parameter := range parameters {
+		if parameter != nil && parameter.Key == key {
+			parameter.Value = value
+			return parameters
+		}
+	}
+
+	return append(parameters, &Parameter{Key: key, Value: value})
+}
+
+// A member of a list or dictionary is an *Item or an *InnerList.
+
+type List []any
+
+type DictionaryMember struct {
+	Key   string
+	Value any
+}
+
+type Dictionary []*DictionaryMember
+
+// Get returns the member with the key.
+func (dictionary Dictionary) Get(key string) (any, bool) {
+	for _, member := range dictionary {
+		if member != nil && member.Key == key {
+			return member.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// Set sets the member with the key, which is added last if there is none, and returns the
+// dictionary.
+func (dictionary Dictionary) Set(key string, value any) Dictionary {
+	for _, member := range dictionary {
+		if member != nil && member.Key == key {
+			member.Value = value
+			return dictionary
+		}
+	}
+
+	return append(dictionary, &DictionaryMember{Key: key, Value: value})
+}
+
+type parser struct {
+	data []byte
+	i    int
+}
+
+func (p *parser) syntaxError(err error) error {
+	return motmedelErrors.NewWithTrace(
+		fmt.Errorf("%w: %w at %d", motmedelErrors.ErrSyntaxError, err, p.i),
+		p.data,
+	)
+}
+
+func (p *parser) done() bool {
+	return p.i >= len(p.data)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.data[p.i]
+}
+
+func (p *parser) skipSpaces() {
+	for !p.done() && p.data[p.i] == ' ' {
+		p.i++
+	}
+}
+
+func (p *parser) skipOws() {
+	for !p.done() && (p.data[p.i] == ' ' || p.data[p.i] == '\t') {
+		p.i++
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLcAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+func isAlpha(c byte) bool {
+	return isLcAlpha(c) || (c >= 'A' && c <= 'Z')
+}
+
+func isTchar(c byte) bool {
+	return isAlpha(c) || isDigit(c) || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+func isKeyCharacter(c byte) bool {
+	return isLcAlpha(c) || isDigit(c) || c == '_' || c == '-' || c == '.' || c == '*'
+}
+
+func (p *parser) parseKey() (string, error) {
+	if c := p.peek(); !isLcAlpha(c) && c != '*' {
+		return "", p.syntaxError(fmt.Errorf("%w: key", ErrUnexpectedCharacter))
+	}
+
+	start := p.i
+	for !p.done() && isKeyCharacter(p.data[p.i]) {
+		p.i++
+	}
+
+	return string(p.data[start:p.i]), nil
+}
+
+func (p *parser) parseNumber() (any, error) {
+	negative := false
+	if p.peek() == '-' {
+		negative = true
+		p.i++
+	}
+	if !isDigit(p.peek()) {
+		return nil, p.syntaxError(fmt.Errorf("%w: number", ErrUnexpectedCharacter))
+	}
+
+	start := p.i
+	isDecimal := false
+	for !p.done() {
+		c := p.data[p.i]
+		if isDigit(c) {
+			p.i++
+		} else if c == '.' && !isDecimal {
+			if p.i-start > maxDecimalDigits {
+				return nil, p.syntaxError(fmt.Errorf("%w: decimal", ErrOutOfRange))
+			}
+			isDecimal = true
+			p.i++
+		} else {
+			break
+		}
+
+		if !isDecimal && p.i-start > maxIntegerDigits {
+			return nil, p.syntaxError(fmt.Errorf("%w: integer", ErrOutOfRange))
+		}
+		if isDecimal && p.i-start > maxDecimalLength {
+			return nil, p.syntaxError(fmt.Errorf("%w: decimal", ErrOutOfRange))
+		}
+	}
+
+	number := string(p.data[start:p.i])
+	if !isDecimal {
+		value, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			return nil, p.syntaxError(fmt.Errorf("strconv parse int: %w", err))
+		}
+		if negative {
+			value = -value
+		}
+		return value, nil
+	}
+
+	if strings.HasSuffix(number, ".") {
+		return nil, p.syntaxError(fmt.Errorf("%w: decimal", ErrUnexpectedEnd))
+	}
+	if fraction := number[strings.IndexByte(number, '.')+1:]; len(fraction) > maxFractionDigits {
+		return nil, p.syntaxError(fmt.Errorf("%w: decimal", ErrOutOfRange))
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return nil, p.syntaxError(fmt.Errorf("strconv parse float: %w", err))
+	}
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+func (p *parser) parseString() (string, error) {
+	// The opening quote.
+	p.i++
+
+	var builder strings.Builder
+	for !p.done() {
+		c := p.data[p.i]
+		p.i++
+
+		switch {
+		case c == '\\':
+			if p.done() {
+				return "", p.syntaxError(fmt.Errorf("%w: string", ErrUnexpectedEnd))
+			}
+			escaped := p.data[p.i]
+			if escaped != '"' && escaped != '\\' {
+				return "", p.syntaxError(fmt.Errorf("%w: string escape", ErrUnexpectedCharacter))
+			}
+			p.i++
+			builder.WriteByte(escaped)
+		case c == '"':
+			return builder.String(), nil
+		case c < 0x20 || c > 0x7e:
+			return "", p.syntaxError(fmt.Errorf("%w: string", ErrUnexpectedCharacter))
+		default:
+			builder.WriteByte(c)
+		}
+	}
+
+	return "", p.syntaxError(fmt.Errorf("%w: string", ErrUnexpectedEnd))
+}
+
+func (p *parser) parseToken() Token {
+	start := p.i
+	p.i++
+	for !p.done() {
+		c := p.data[p.i]
+		if !isTchar(c) && c != ':' && c != '/' {
+			break
+		}
+		p.i++
+	}
+
+	return Token(p.data[start:p.i])
+}
+
+func (p *parser) parseByteSequence() ([]byte, error) {
+	// The opening colon.
+	p.i++
+
+	end := strings.IndexByte(string(p.data[p.i:]), ':')
+	if end < 0 {
+		return nil, p.syntaxError(fmt.Errorf("%w: byte sequence", ErrUnexpectedEnd))
+	}
+
+	encoded := string(p.data[p.i : p.i+end])
+	p.i += end + 1
+
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, p.syntaxError(fmt.Errorf("base64 decode string: %w", err))
+	}
+
+	return value, nil
+}
+
+func (p *parser) parseBoolean() (bool, error) {
+	// The question mark.
+	p.i++
+
+	switch p.peek() {
+	case '1':
+		p.i++
+		return true, nil
+	case '0':
+		p.i++
+		return false, nil
+	default:
+		return false, p.syntaxError(fmt.Errorf("%w: boolean", ErrUnexpectedCharacter))
+	}
+}
+
+func (p *parser) parseDate() (time.Time, error) {
+	// The at sign.
+	p.i++
+
+	value, err := p.parseNumber()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	seconds, ok := value.(int64)
+	if !ok {
+		return time.Time{}, p.syntaxError(fmt.Errorf("%w: date", ErrUnexpectedCharacter))
+	}
+
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+func isLcHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f')
+}
+
+func (p *parser) parseDisplayString() (DisplayString, error) {
+	// The percent sign, which is to be followed by the opening quote.
+	p.i++
+	if p.peek() != '"' {
+		return "", p.syntaxError(fmt.Errorf("%w: display string", ErrUnexpectedCharacter))
+	}
+	p.i++
+
+	var value []byte
+	for !p.done() {
+		c := p.data[p.i]
+		p.i++
+
+		switch {
+		case c == '%':
+			if p.i+2 > len(p.data) {
+				return "", p.syntaxError(fmt.Errorf("%w: display string", ErrUnexpectedEnd))
+			}
+			high, low := p.data[p.i], p.data[p.i+1]
+			// Only lowercase hex digits are canonical, and only what is canonical is accepted.
+			if !isLcHexDigit(high) || !isLcHexDigit(low) {
+				return "", p.syntaxError(fmt.Errorf("%w: display string escape", ErrUnexpectedCharacter))
+			}
+			decoded, err := strconv.ParseUint(string(p.data[p.i:p.i+2]), 16, 8)
+			if err != nil {
+				return "", p.syntaxError(fmt.Errorf("strconv parse uint: %w", err))
+			}
+			p.i += 2
+			value = append(value, byte(decoded))
+		case c == '"':
+			if !utf8.Valid(value) {
+				return "", p.syntaxError(fmt.Errorf("%w: display string utf-8", ErrUnexpectedCharacter))
+			}
+			return DisplayString(value), nil
+		case c < 0x20 || c > 0x7e:
+			return "", p.syntaxError(fmt.Errorf("%w: display string", ErrUnexpectedCharacter))
+		default:
+			value = append(value, c)
+		}
+	}
+
+	return "", p.syntaxError(fmt.Errorf("%w: display string", ErrUnexpectedEnd))
+}
+
+func (p *parser) parseBareItem() (any, error) {
+	switch c := p.peek(); {
+	case c == '-' || isDigit(c):
+		return p.parseNumber()
+	case c == '"':
+		return p.parseString()
+	case c == '*' || isAlpha(c):
+		return p.parseToken(), nil
+	case c == ':':
+		return p.parseByteSequence()
+	case c == '?':
+		return p.parseBoolean()
+	case c == '@':
+		return p.parseDate()
+	case c == '%':
+		return p.parseDisplayString()
+	case p.done():
+		return nil, p.syntaxError(fmt.Errorf("%w: bare item", ErrUnexpectedEnd))
+	default:
+		return nil, p.syntaxError(fmt.Errorf("%w: bare item", ErrUnexpectedCharacter))
+	}
+}
+
+func (p *parser) parseParameters() (Parameters, error) {
+	var parameters Parameters
+
+	for p.peek() == ';' {
+		p.i++
+		p.skipSpaces()
+
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+
+		var value any = true
+		if p.peek() == '=' {
+			p.i++
+			value, err = p.parseBareItem()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		parameters = parameters.Set(key, value)
+	}
+
+	return parameters, nil
+}
+
+func (p *parser) parseItem() (*Item, error) {
+	value, err := p.parseBareItem()
+	if err != nil {
+		return nil, err
+	}
+
+	parameters, err := p.parseParameters()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Item{Value: value, Parameters: parameters}, nil
+}
+
+func (p *parser) parseInnerList() (*InnerList, error) {
+	// The opening parenthesis.
+	p.i++
+
+	innerList := &InnerList{}
+	for !p.done() {
+		p.skipSpaces()
+
+		if p.peek() == ')' {
+			p.i++
+			parameters, err := p.parseParameters()
+			if err != nil {
+				return nil, err
+			}
+			innerList.Parameters = parameters
+			return innerList, nil
+		}
+
+		item, err := p.parseItem()
+		if err != nil {
+			return nil, err
+		}
+		innerList.Items = append(innerList.Items, item)
+
+		if c := p.peek(); c != ' ' && c != ')' {
+			return nil, p.syntaxError(fmt.Errorf("%w: inner list", ErrUnexpectedCharacter))
+		}
+	}
+
+	return nil, p.syntaxError(fmt.Errorf("%w: inner list", ErrUnexpectedEnd))
+}
+
+func (p *parser) parseItemOrInnerList() (any, error) {
+	if p.peek() == '(' {
+		return p.parseInnerList()
+	}
+	return p.parseItem()
+}
+
+// parseMembers parses the comma-separated members of a list or dictionary.
+func (p *parser) parseMembers(parseMember func() error) error {
+	for !p.done() {
+		if err := parseMember(); err != nil {
+			return err
+		}
+
+		p.skipOws()
+		if p.done() {
+			return nil
+		}
+		if p.data[p.i] != ',' {
+			return p.syntaxError(fmt.Errorf("%w: member separator", ErrUnexpectedCharacter))
+		}
+		p.i++
+		p.skipOws()
+		if p.done() {
+			return p.syntaxError(fmt.Errorf("%w: trailing comma", ErrUnexpectedEnd))
+		}
+	}
+
+	return nil
+}
+
+func newParser(data []byte) *parser {
+	p := &parser{data: data}
+	p.skipSpaces()
+	// Trailing spaces are not significant either.
+	for len(p.data) > p.i && p.data[len(p.data)-1] == ' ' {
+		p.data = p.data[:len(p.data)-1]
+	}
+
+	return p
+}
+
+func ParseList(data []byte) (List, error) {
+	p := newParser(data)
+
+	var list List
+	err := p.parseMembers(
+		func() error {
+			member, err := p.parseItemOrInnerList()
+			if err != nil {
+				return err
+			}
+			list = append(list, member)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func ParseDictionary(data []byte) (Dictionary, error) {
+	p := newParser(data)
+
+	var dictionary Dictionary
+	err := p.parseMembers(
+		func() error {
+			key, err := p.parseKey()
+			if err != nil {
+				return err
+			}
+
+			var member any
+			if p.peek() == '=' {
+				p.i++
+				member, err = p.parseItemOrInnerList()
+			} else {
+				var parameters Parameters
+				parameters, err = p.parseParameters()
+				member = &Item{Value: true, Parameters: parameters}
+			}
+			if err != nil {
+				return err
+			}
+
+			dictionary = dictionary.Set(key, member)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return dictionary, nil
+}
+
+func ParseItem(data []byte) (*Item, error) {
+	p := newParser(data)
+
+	item, err := p.parseItem()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.syntaxError(fmt.Errorf("%w: item", ErrUnexpectedCharacter))
+	}
+
+	return item, nil
+}
+
+func semanticError(err error, value any) error {
+	return motmedelErrors.NewWithTrace(fmt.Errorf("%w: %w", motmedelErrors.ErrSemanticError, err), value)
+}
+
+func isValidKey(key string) bool {
+	if key == "" || (!isLcAlpha(key[0]) && key[0] != '*') {
+		return false
+	}
+	for i := 1; i < len(key); i++ {
+		if !isKeyCharacter(key[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeKey(builder *strings.Builder, key string) error {
+	if !isValidKey(key) {
+		return semanticError(fmt.Errorf("%w: key", ErrUnexpectedCharacter), key)
+	}
+	builder.WriteString(key)
+	return nil
+}
+
+func writeBareItem(builder *strings.Builder, value any) error {
+	switch typedValue := value.(type) {
+	case int:
+		return writeBareItem(builder, int64(typedValue))
+	case int64:
+		if typedValue > maxInteger || typedValue < -maxInteger {
+			return semanticError(fmt.Errorf("%w: integer", ErrOutOfRange), typedValue)
+		}
+		builder.WriteString(strconv.FormatInt(typedValue, 10))
+	case float64:
+		rounded := math.RoundToEven(typedValue*1000) / 1000
+		if math.IsNaN(rounded) || math.Abs(rounded) > maxDecimalMagnitude {
+			return semanticError(fmt.Errorf("%w: decimal", ErrOutOfRange), typedValue)
+		}
+		decimal := strings.TrimRight(strconv.FormatFloat(rounded, 'f', maxFractionDigits, 64), "0")
+		if strings.HasSuffix(decimal, ".") {
+			decimal += "0"
+		}
+		builder.WriteString(decimal)
+	case string:
+		builder.WriteByte('"')
+		for i := 0; i < len(typedValue); i++ {
+			c := typedValue[i]
+			if c < 0x20 || c > 0x7e {
+				return semanticError(fmt.Errorf("%w: string", ErrUnexpectedCharacter), typedValue)
+			}
+			if c == '"' || c == '\\' {
+				builder.WriteByte('\\')
+			}
+			builder.WriteByte(c)
+		}
+		builder.WriteByte('"')
+	case Token:
+		if typedValue == "" || (!isAlpha(typedValue[0]) && typedValue[0] != '*') {
+			return semanticError(fmt.Errorf("%w: token", ErrUnexpectedCharacter), typedValue)
+		}
+		for i := 1; i < len(typedValue); i++ {
+			if c := typedValue[i]; !isTchar(c) && c != ':' && c != '/' {
+				return semanticError(fmt.Errorf("%w: token", ErrUnexpectedCharacter), typedValue)
+			}
+		}
+		builder.WriteString(string(typedValue))
+	case []byte:
+		builder.WriteByte(':')
+		builder.WriteString(base64.StdEncoding.EncodeToString(typedValue))
+		builder.WriteByte(':')
+	case bool:
+		if typedValue {
+			builder.WriteString("?1")
+		} else {
+			builder.WriteString("?0")
+		}
+	case time.Time:
+		if typedValue.Nanosecond() != 0 {
+			return semanticError(fmt.Errorf("%w: date with a fraction of a second", ErrOutOfRange), typedValue)
+		}
+		seconds := typedValue.Unix()
+		if seconds > maxInteger || seconds < -maxInteger {
+			return semanticError(fmt.Errorf("%w: date", ErrOutOfRange), typedValue)
+		}
+		builder.WriteByte('@')
+		builder.WriteString(strconv.FormatInt(seconds, 10))
+	case DisplayString:
+		if !utf8.ValidString(string(typedValue)) {
+			return semanticError(fmt.Errorf("%w: display string utf-8", ErrUnexpectedCharacter), typedValue)
+		}
+		builder.WriteString(`%"`)
+		for i := 0; i < len(typedValue); i++ {
+			c := typedValue[i]
+			if c == '%' || c == '"' || c < 0x20 || c > 0x7e {
+				builder.WriteByte('%')
+				builder.WriteString(hex.EncodeToString([]byte{c}))
+				continue
+			}
+			builder.WriteByte(c)
+		}
+		builder.WriteByte('"')
+	default:
+		return semanticError(fmt.Errorf("%w: %T", ErrUnsupportedType, value), value)
+	}
+
+	return nil
+}
+
+func writeParameters(builder *strings.Builder, parameters Parameters) error {
+	for _, parameter := range parameters {
+		if parameter == nil {
+			continue
+		}
+
+		builder.WriteByte(';')
+		if err := writeKey(builder, parameter.Key); err != nil {
+			return err
+		}
+		if value, ok := parameter.Value.(bool); ok && value {
+			continue
+		}
+		builder.WriteByte('=')
+		if err := writeBareItem(builder, parameter.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeItem(builder *strings.Builder, item *Item) error {
+	if item == nil {
+		return semanticError(fmt.Errorf("%w: nil item", ErrUnsupportedType), item)
+	}
+	if err := writeBareItem(builder, item.Value); err != nil {
+		return err
+	}
+	return writeParameters(builder, item.Parameters)
+}
+
+func writeInnerList(builder *strings.Builder, innerList *InnerList) error {
+	if innerList == nil {
+		return semanticError(fmt.Errorf("%w: nil inner list", ErrUnsupportedType), innerList)
+	}
+
+	builder.WriteByte('(')
+	for i, item := range innerList.Items {
+		if i != 0 {
+			builder.WriteByte(' ')
+		}
+		if err := writeItem(builder, item); err != nil {
+			return err
+		}
+	}
+	builder.WriteByte(')')
+
+	return writeParameters(builder, innerList.Parameters)
+}
+
+func writeMember(builder *strings.Builder, member any) error {
+	switch typedMember := member.(type) {
+	case *Item:
+		return writeItem(builder, typedMember)
+	case *InnerList:
+		return writeInnerList(builder, typedMember)
+	default:
+		return semanticError(fmt.Errorf("%w: member %T", ErrUnsupportedType, member), member)
+	}
+}
+
+func SerializeItem(item *Item) (string, error) {
+	var builder strings.Builder
+	if err := writeItem(&builder, item); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+func SerializeInnerList(innerList *InnerList) (string, error) {
+	var builder strings.Builder
+	if err := writeInnerList(&builder, innerList); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+func SerializeList(list List) (string, error) {
+	var builder strings.Builder
+	for i, member := range list {
+		if i != 0 {
+			builder.WriteString(", ")
+		}
+		if err := writeMember(&builder, member); err != nil {
+			return "", err
+		}
+	}
+	return builder.String(), nil
+}
+
+func SerializeDictionary(dictionary Dictionary) (string, error) {
+	var builder strings.Builder
+	for i, member := range dictionary {
+		if member == nil {
+			continue
+		}
+		if i != 0 {
+			builder.WriteString(", ")
+		}
+		if err := writeKey(&builder, member.Key); err != nil {
+			return "", err
+		}
+
+		// A member that is true is serialized as its key and parameters alone.
+		if item, ok := member.Value.(*Item); ok && item != nil {
+			if value, ok := item.Value.(bool); ok && value {
+				if err := writeParameters(&builder, item.Parameters); err != nil {
+					return "", err
+				}
+				continue
+			}
+		}
+
+		builder.WriteByte('=')
+		if err := writeMember(&builder, member.Value); err != nil {
+			return "", err
+		}
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,195 @@
+package structured_field
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+)
+
+func TestParseItem(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		input   string
+		want    *Item
+		wantErr bool
+	}{
+		{name: "integer", input: "42", want: &Item{Value: int64(42)}},
+		{name: "negative integer", input: "-42", want: &Item{Value: int64(-42)}},
+		{name: "decimal", input: "4.5", want: &Item{Value: 4.5}},
+		{name: "string", input: `"a \"b\" \\"`, want: &Item{Value: `a "b" \`}},
+		{name: "token", input: "*foo/bar:baz", want: &Item{Value: Token("*foo/bar:baz")}},
+		{name: "byte sequence", input: ":aGVsbG8=:", want: &Item{Value: []byte("hello")}},
+		{name: "boolean", input: "?0", want: &Item{Value: false}},
+		{name: "date", input: "@1659578233", want: &Item{Value: time.Unix(1659578233, 0).UTC()}},
+		{name: "negative date", input: "@-1", want: &Item{Value: time.Unix(-1, 0).UTC()}},
+		{name: "display string", input: `%"f%c3%bc%c3%bc %22%25"`, want: &Item{Value: DisplayString(`füü "%`)}},
+		{
+			name:  "parameters",
+			input: `1;a;b=?0;c="x"`,
+			want: &Item{
+				Value:      int64(1),
+				Parameters: Parameters{{Key: "a", Value: true}, {Key: "b", Value: false}, {Key: "c", Value: "x"}},
+			},
+		},
+		{name: "surrounding spaces", input: "  1  ", want: &Item{Value: int64(1)}},
+		{name: "integer too long", input: "1234567890123456", wantErr: true},
+		{name: "decimal too precise", input: "1.1234", wantErr: true},
+		{name: "decimal without fraction", input: "1.", wantErr: true},
+		{name: "bad escape", input: `"\a"`, wantErr: true},
+		{name: "unterminated string", input: `"a`, wantErr: true},
+		{name: "bad boolean", input: "?2", wantErr: true},
+		{name: "bad base64", input: ":a:", wantErr: true},
+		{name: "uppercase key", input: "1;A", wantErr: true},
+		{name: "trailing data", input: "1 2", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+		{name: "decimal date", input: "@1.5", wantErr: true},
+		{name: "display string without quote", input: "%a", wantErr: true},
+		{name: "uppercase display string escape", input: `%"%C3%BC"`, wantErr: true},
+		{name: "display string not utf-8", input: `%"%c3"`, wantErr: true},
+		{name: "truncated display string escape", input: `%"%c"`, wantErr: true},
+		{name: "unterminated display string", input: `%"a`, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			item, err := ParseItem([]byte(testCase.input))
+			if testCase.wantErr {
+				if !errors.Is(err, motmedelErrors.ErrSyntaxError) {
+					t.Fatalf("expected a syntax error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse item: %v", err)
+			}
+			if !reflect.DeepEqual(item, testCase.want) {
+				t.Errorf("got %#v, want %#v", item, testCase.want)
+			}
+		})
+	}
+}
+
+func TestParseDictionary(t *testing.T) {
+	t.Parallel()
+
+	dictionary, err := ParseDictionary([]byte(`sig1=("@method" "@path");created=1618884473;keyid="k", sig2=:aGk=:, flag;x=1, sig1=2`))
+	if err != nil {
+		t.Fatalf("parse dictionary: %v", err)
+	}
+
+	if len(dictionary) != 3 {
+		t.Fatalf("expected three members, got %d", len(dictionary))
+	}
+
+	// A duplicate key replaces the value, keeping the position.
+	if member, _ := dictionary.Get("sig1"); !reflect.DeepEqual(member, &Item{Value: int64(2)}) {
+		t.Errorf("sig1: got %#v", member)
+	}
+	if member, _ := dictionary.Get("sig2"); !reflect.DeepEqual(member, &Item{Value: []byte("hi")}) {
+		t.Errorf("sig2: got %#v", member)
+	}
+	if member, _ := dictionary.Get("flag"); !reflect.DeepEqual(member, &Item{Value: true, Parameters: Parameters{{Key: "x", Value: int64(1)}}}) {
+		t.Errorf("flag: got %#v", member)
+	}
+
+	for _, input := range []string{"a=1,", "a=(1", "A=1", "a=1;", "a=(1,2)"} {
+		if _, err := ParseDictionary([]byte(input)); !errors.Is(err, motmedelErrors.ErrSyntaxError) {
+			t.Errorf("expected a syntax error for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestParseList(t *testing.T) {
+	t.Parallel()
+
+	list, err := ParseList([]byte("a,\t(b c);d, 1"))
+	if err != nil {
+		t.Fatalf("parse list: %v", err)
+	}
+
+	want := List{
+		&Item{Value: Token("a")},
+		&InnerList{
+			Items:      []*Item{{Value: Token("b")}, {Value: Token("c")}},
+			Parameters: Parameters{{Key: "d", Value: true}},
+		},
+		&Item{Value: int64(1)},
+	}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("got %#v, want %#v", list, want)
+	}
+
+	if list, err := ParseList(nil); err != nil || len(list) != 0 {
+		t.Errorf("expected an empty list, got %v, %v", list, err)
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		value   any
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "dictionary",
+			value: Dictionary{
+				{Key: "a", Value: &Item{Value: true, Parameters: Parameters{{Key: "p", Value: int64(1)}}}},
+				{Key: "b", Value: &Item{Value: false}},
+				{Key: "c", Value: &InnerList{Items: []*Item{{Value: "x"}, {Value: Token("y")}}, Parameters: Parameters{{Key: "q", Value: 1.5}}}},
+				{Key: "d", Value: &Item{Value: []byte("hi")}},
+			},
+			want: `a;p=1, b=?0, c=("x" y);q=1.5, d=:aGk=:`,
+		},
+		{name: "list", value: List{&Item{Value: 2.0}, &Item{Value: 0.1235}}, want: "2.0, 0.124"},
+		{name: "item", value: &Item{Value: "a\"b", Parameters: Parameters{{Key: "t", Value: true}}}, want: `"a\"b";t`},
+		{name: "date", value: &Item{Value: time.Unix(1659578233, 0)}, want: "@1659578233"},
+		{name: "display string", value: &Item{Value: DisplayString("füü \"%\n")}, want: `%"f%c3%bc%c3%bc %22%25%0a"`},
+		{name: "integer out of range", value: &Item{Value: int64(1e15)}, wantErr: true},
+		{name: "date with a fraction of a second", value: &Item{Value: time.Unix(1, 5)}, wantErr: true},
+		{name: "display string not utf-8", value: &Item{Value: DisplayString("\xff")}, wantErr: true},
+		{name: "bad string", value: &Item{Value: "\n"}, wantErr: true},
+		{name: "bad token", value: &Item{Value: Token("1a")}, wantErr: true},
+		{name: "unsupported type", value: &Item{Value: uint8(1)}, wantErr: true},
+		{name: "bad key", value: Dictionary{{Key: "A", Value: &Item{Value: int64(1)}}}, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var serialized string
+			var err error
+			switch value := testCase.value.(type) {
+			case Dictionary:
+				serialized, err = SerializeDictionary(value)
+			case List:
+				serialized, err = SerializeList(value)
+			case *Item:
+				serialized, err = SerializeItem(value)
+			}
+
+			if testCase.wantErr {
+				if !errors.Is(err, motmedelErrors.ErrSemanticError) {
+					t.Fatalf("expected a semantic error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("serialize: %v", err)
+			}
+			if serialized != testCase.want {
+				t.Errorf("got %q, want %q", serialized, testCase.want)
+			}
+		})
+	}
+}
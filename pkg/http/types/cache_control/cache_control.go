@@ -25,6 +25,9 @@ var deltaSecondsDirectives = map[string]bool{
 	"max-stale": true,
 	"min-fresh": true,
 	"s-maxage":  true,
+
+	"stale-while-revalidate": true,
+	"stale-if-error":         true,
 }
 
 var (
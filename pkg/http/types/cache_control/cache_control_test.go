@@ -255,6 +255,10 @@ func TestParseCacheControlInvalid(t *testing.T) {
 			name:   "min-fresh non-numeric",
 			header: "min-fresh=bar",
 		},
+		{
+			name:   "stale-if-error non-numeric",
+			header: "stale-if-error=qux",
+		},
 		{
 			name:   "max-stale non-numeric",
 			header: "max-stale=baz",
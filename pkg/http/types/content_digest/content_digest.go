@@ -0,0 +1,141 @@
+// Package content_digest makes and verifies the Content-Digest header of RFC 9530.
+package content_digest
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelHttpErrors "github.com/Motmedel/utils_go/pkg/http/errors"
+	"github.com/Motmedel/utils_go/pkg/http/structured_field"
+)
+
+const (
+	HeaderName = "Content-Digest"
+
+	AlgorithmSha256 = "sha-256"
+	AlgorithmSha512 = "sha-512"
+)
+
+// DefaultAlgorithm is the algorithm that digests are made with when none is specified.
+const DefaultAlgorithm = AlgorithmSha256
+
+var algorithmToHashFunc = map[string]func() hash.Hash{
+	AlgorithmSha256: sha256.New,
+	AlgorithmSha512: sha512.New,
+}
+
+type Digest struct {
+	Algorithm string
+	Value     []byte
+}
+
+// Make returns the digest of the content with the algorithm.
+func Make(content []byte, algorithm string) (*Digest, error) {
+	hashFunc, ok := algorithmToHashFunc[algorithm]
+	if !ok {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: %q", motmedelHttpErrors.ErrUnsupportedDigestAlgorithm, algorithm),
+			algorithm,
+		)
+	}
+
+	digestHash := hashFunc()
+	digestHash.Write(content)
+
+	return &Digest{Algorithm: algorithm, Value: digestHash.Sum(nil)}, nil
+}
+
+// MakeHeaderValue returns the Content-Digest header value stating the digests of the content with the
+// algorithms, or with DefaultAlgorithm if none are specified.
+func MakeHeaderValue(content []byte, algorithms ...string) (string, error) {
+	if len(algorithms) == 0 {
+		algorithms = []string{DefaultAlgorithm}
+	}
+
+	dictionary := make(structured_field.Dictionary, 0, len(algorithms))
+	for _, algorithm := range algorithms {
+		digest, err := Make(content, algorithm)
+		if err != nil {
+			return "", fmt.Errorf("make: %w", err)
+		}
+		dictionary = append(
+			dictionary,
+			&structured_field.DictionaryMember{Key: digest.Algorithm, Value: &structured_field.Item{Value: digest.Value}},
+		)
+	}
+
+	value, err := structured_field.SerializeDictionary(dictionary)
+	if err != nil {
+		return "", fmt.Errorf("serialize dictionary: %w", err)
+	}
+
+	return value, nil
+}
+
+// Parse parses a Content-Digest header value. Members that are not byte sequences are malformed.
+func Parse(data []byte) ([]*Digest, error) {
+	dictionary, err := structured_field.ParseDictionary(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse dictionary: %w", err)
+	}
+
+	digests := make([]*Digest, 0, len(dictionary))
+	for _, member := range dictionary {
+		item, ok := member.Value.(*structured_field.Item)
+		if !ok {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: not an item: %s", motmedelErrors.ErrSemanticError, member.Key),
+				data,
+			)
+		}
+		value, ok := item.Value.([]byte)
+		if !ok {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: not a byte sequence: %s", motmedelErrors.ErrSemanticError, member.Key),
+				data,
+			)
+		}
+
+		digests = append(digests, &Digest{Algorithm: member.Key, Value: value})
+	}
+
+	return digests, nil
+}
+
+// Verify verifies the content against a Content-Digest header value. Digests with unsupported
+// algorithms are disregarded; every other digest must match, and at least one must be present.
+func Verify(headerValue string, content []byte) error {
+	digests, err := Parse([]byte(headerValue))
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	verified := false
+	for _, digest := range digests {
+		if _, ok := algorithmToHashFunc[digest.Algorithm]; !ok {
+			continue
+		}
+
+		contentDigest, err := Make(content, digest.Algorithm)
+		if err != nil {
+			return fmt.Errorf("make: %w", err)
+		}
+		if subtle.ConstantTimeCompare(contentDigest.Value, digest.Value) != 1 {
+			return motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: %s", motmedelHttpErrors.ErrDigestMismatch, digest.Algorithm),
+				headerValue,
+			)
+		}
+		verified = true
+	}
+
+	if !verified {
+		return motmedelErrors.NewWithTrace(motmedelHttpErrors.ErrUnsupportedDigestAlgorithm, headerValue)
+	}
+
+	return nil
+}
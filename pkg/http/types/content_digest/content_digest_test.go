@@ -0,0 +1,81 @@
+package content_digest
+
+import (
+	"errors"
+	"testing"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelHttpErrors "github.com/Motmedel/utils_go/pkg/http/errors"
+)
+
+// The examples of RFC 9530, Appendix B.
+const (
+	exampleContent      = "{\"hello\": \"world\"}\n"
+	exampleSha256Digest = "sha-256=:RK/0qy18MlBSVnWgjwz6lZEWjP/lF5HF9bvEF8FabDg=:"
+	exampleSha512Digest = "sha-512=:YMAam51Jz/jOATT6/zvHrLVgOYTGFy1d6GJiOHTohq4yP+pgk4vf2aCsyRZOtw8MjkM7iw7yZ/WkppmM44T3qg==:"
+)
+
+func TestMakeHeaderValue(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		algorithms []string
+		want       string
+		wantErr    error
+	}{
+		{name: "default", want: exampleSha256Digest},
+		{name: "sha-512", algorithms: []string{AlgorithmSha512}, want: exampleSha512Digest},
+		{name: "both", algorithms: []string{AlgorithmSha256, AlgorithmSha512}, want: exampleSha256Digest + ", " + exampleSha512Digest},
+		{name: "unsupported", algorithms: []string{"md5"}, wantErr: motmedelHttpErrors.ErrUnsupportedDigestAlgorithm},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := MakeHeaderValue([]byte(exampleContent), testCase.algorithms...)
+			if !errors.Is(err, testCase.wantErr) {
+				t.Fatalf("got error %v, want %v", err, testCase.wantErr)
+			}
+			if value != testCase.want {
+				t.Errorf("got %q, want %q", value, testCase.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		headerValue string
+		content     string
+		wantErr     error
+	}{
+		{name: "sha-256", headerValue: exampleSha256Digest, content: exampleContent},
+		{name: "sha-512", headerValue: exampleSha512Digest, content: exampleContent},
+		{name: "unknown algorithm disregarded", headerValue: "md5=:AAAA:, " + exampleSha256Digest, content: exampleContent},
+		{name: "mismatch", headerValue: exampleSha256Digest, content: "{}", wantErr: motmedelHttpErrors.ErrDigestMismatch},
+		{
+			name:        "one mismatch",
+			headerValue: exampleSha256Digest + ", sha-512=:AAAA:",
+			content:     exampleContent,
+			wantErr:     motmedelHttpErrors.ErrDigestMismatch,
+		},
+		{name: "only unknown", headerValue: "md5=:AAAA:", content: exampleContent, wantErr: motmedelHttpErrors.ErrUnsupportedDigestAlgorithm},
+		{name: "not a byte sequence", headerValue: `sha-256="x"`, content: exampleContent, wantErr: motmedelErrors.ErrSemanticError},
+		{name: "malformed", headerValue: "sha-256=:", content: exampleContent, wantErr: motmedelErrors.ErrSyntaxError},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := Verify(testCase.headerValue, []byte(testCase.content)); !errors.Is(err, testCase.wantErr) {
+				t.Errorf("got %v, want %v", err, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package content_security_policy
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"hash"
+	"net/url"
+	"strings"
+)
+
+const (
+	keywordSelf           = "self"
+	keywordUnsafeInline   = "unsafe-inline"
+	keywordUnsafeEval     = "unsafe-eval"
+	keywordUnsafeHashes   = "unsafe-hashes"
+	keywordWasmUnsafeEval = "wasm-unsafe-eval"
+	keywordStrictDynamic  = "strict-dynamic"
+)
+
+// fallbackLists are the directives consulted for what a directive governs, in order, the first
+// present one being the one in effect (CSP Level 3, Section 6.8.3). A directive not listed governs
+// on its own, and falls back on nothing.
+var fallbackLists = map[string][]string{
+	DirectiveNameScriptSrcElem: {DirectiveNameScriptSrcElem, DirectiveNameScriptSrc, DirectiveNameDefaultSrc},
+	DirectiveNameScriptSrcAttr: {DirectiveNameScriptSrcAttr, DirectiveNameScriptSrc, DirectiveNameDefaultSrc},
+	DirectiveNameScriptSrc:     {DirectiveNameScriptSrc, DirectiveNameDefaultSrc},
+	DirectiveNameStyleSrcElem:  {DirectiveNameStyleSrcElem, DirectiveNameStyleSrc, DirectiveNameDefaultSrc},
+	DirectiveNameStyleSrcAttr:  {DirectiveNameStyleSrcAttr, DirectiveNameStyleSrc, DirectiveNameDefaultSrc},
+	DirectiveNameStyleSrc:      {DirectiveNameStyleSrc, DirectiveNameDefaultSrc},
+	DirectiveNameWorkerSrc: {
+		DirectiveNameWorkerSrc,
+		DirectiveNameChildSrc,
+		DirectiveNameScriptSrc,
+		DirectiveNameDefaultSrc,
+	},
+	DirectiveNameFrameSrc:    {DirectiveNameFrameSrc, DirectiveNameChildSrc, DirectiveNameDefaultSrc},
+	DirectiveNameChildSrc:    {DirectiveNameChildSrc, DirectiveNameDefaultSrc},
+	DirectiveNameConnectSrc:  {DirectiveNameConnectSrc, DirectiveNameDefaultSrc},
+	DirectiveNameFontSrc:     {DirectiveNameFontSrc, DirectiveNameDefaultSrc},
+	DirectiveNameImgSrc:      {DirectiveNameImgSrc, DirectiveNameDefaultSrc},
+	DirectiveNameManifestSrc: {DirectiveNameManifestSrc, DirectiveNameDefaultSrc},
+	DirectiveNameMediaSrc:    {DirectiveNameMediaSrc, DirectiveNameDefaultSrc},
+	DirectiveNameObjectSrc:   {DirectiveNameObjectSrc, DirectiveNameDefaultSrc},
+}
+
+// defaultPorts are the ports a URL of the scheme has when it states none.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+// FallbackList returns the directives consulted for what the directive governs, in order.
+func FallbackList(directiveName string) []string {
+	if fallbackList, ok := fallbackLists[directiveName]; ok {
+		return fallbackList
+	}
+
+	return []string{directiveName}
+}
+
+// EffectiveDirective returns the source directive that governs what the named directive does --
+// the directive itself, or the first present one it falls back on. It is not found where none of
+// them is present, and what the directive governs is not restricted.
+func (csp *ContentSecurityPolicy) EffectiveDirective(directiveName string) (SourceDirectiveI, bool) {
+	if csp == nil {
+		return nil, false
+	}
+
+	for _, name := range FallbackList(directiveName) {
+		directive, found := csp.GetDirective(name)
+		if !found {
+			continue
+		}
+
+		if sourceDirective, ok := directive.(SourceDirectiveI); ok {
+			return sourceDirective, true
+		}
+	}
+
+	return nil, false
+}
+
+// AllowsUrl reports whether the policy permits the URL to be fetched for what the named directive
+// governs (e.g. img-src for an image), by a document of the origin self. The nonce is that of the
+// element fetching the URL, if any, which permits it where the directive is a script or style one.
+func (csp *ContentSecurityPolicy) AllowsUrl(directiveName string, target *url.URL, self *url.URL, nonce string) bool {
+	directive, found := csp.EffectiveDirective(directiveName)
+	if !found {
+		return true
+	}
+
+	if target == nil {
+		return false
+	}
+
+	sources := directive.GetSources()
+
+	if nonce != "" && isScriptOrStyle(directiveName) && containsNonce(sources, nonce) {
+		return true
+	}
+
+	// Where the scripts are trusted by 'strict-dynamic', those that nothing but their URL vouches
+	// for are not: the expressions matching URLs are disregarded.
+	if isScript(directiveName) && containsKeyword(sources, keywordStrictDynamic) {
+		return false
+	}
+
+	for _, source := range sources {
+		if sourceMatchesUrl(source, target, self) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowsInline reports whether the policy permits the inline content -- the body of a script or
+// style element, or the value of an event handler or style attribute -- under the named directive,
+// which is script-src-elem, script-src-attr, style-src-elem or style-src-attr. The nonce is that of
+// the element, if any; an attribute has none.
+func (csp *ContentSecurityPolicy) AllowsInline(directiveName string, nonce string, content []byte) bool {
+	directive, found := csp.EffectiveDirective(directiveName)
+	if !found {
+		return true
+	}
+
+	sources := directive.GetSources()
+
+	if AllowsAllInline(sources, isScript(directiveName)) {
+		return true
+	}
+
+	isAttribute := directiveName == DirectiveNameScriptSrcAttr || directiveName == DirectiveNameStyleSrcAttr
+
+	if !isAttribute && nonce != "" && containsNonce(sources, nonce) {
+		return true
+	}
+
+	// A hash permits an attribute only where 'unsafe-hashes' says that it may.
+	if isAttribute && !containsKeyword(sources, keywordUnsafeHashes) {
+		return false
+	}
+
+	for _, source := range sources {
+		hashSource, ok := source.(*HashSource)
+		if ok && hashSourceMatches(hashSource, content) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowsEval reports whether the policy permits strings to be evaluated as script, as eval() and
+// the like do.
+func (csp *ContentSecurityPolicy) AllowsEval() bool {
+	directive, found := csp.EffectiveDirective(DirectiveNameScriptSrc)
+	if !found {
+		return true
+	}
+
+	return containsKeyword(directive.GetSources(), keywordUnsafeEval)
+}
+
+// AllowsWasmEval reports whether the policy permits WebAssembly to be compiled and instantiated.
+func (csp *ContentSecurityPolicy) AllowsWasmEval() bool {
+	directive, found := csp.EffectiveDirective(DirectiveNameScriptSrc)
+	if !found {
+		return true
+	}
+
+	sources := directive.GetSources()
+	return containsKeyword(sources, keywordUnsafeEval) || containsKeyword(sources, keywordWasmUnsafeEval)
+}
+
+// AllowsAllInline reports whether the sources permit all inline content: they do with
+// 'unsafe-inline', unless a nonce or hash is among them -- or, for scripts, 'strict-dynamic' -- for
+// which a browser disregards it.
+func AllowsAllInline(sources []SourceI, script bool) bool {
+	if !containsKeyword(sources, keywordUnsafeInline) {
+		return false
+	}
+
+	for _, source := range sources {
+		switch typedSource := source.(type) {
+		case *NonceSource, *HashSource:
+			return false
+		case *KeywordSource:
+			if script && strings.EqualFold(typedSource.Keyword, keywordStrictDynamic) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func isScript(directiveName string) bool {
+	return strings.HasPrefix(directiveName, "script-src") || directiveName == DirectiveNameWorkerSrc
+}
+
+func isScriptOrStyle(directiveName string) bool {
+	return strings.HasPrefix(directiveName, "script-src") || strings.HasPrefix(directiveName, "style-src")
+}
+
+func containsKeyword(sources []SourceI, keyword string) bool {
+	for _, source := range sources {
+		if keywordSource, ok := source.(*KeywordSource); ok && strings.EqualFold(keywordSource.Keyword, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsNonce(sources []SourceI, nonce string) bool {
+	for _, source := range sources {
+		if nonceSource, ok := source.(*NonceSource); ok && nonceSource.Base64Value == nonce {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hashSourceMatches reports whether the hash source is of the content. Its value may be in either
+// base64 alphabet, the URL-safe one included (CSP Level 3, Section 2.3.1).
+func hashSourceMatches(hashSource *HashSource, content []byte) bool {
+	var hasher hash.Hash
+	switch strings.ToLower(hashSource.HashAlgorithm) {
+	case "sha256":
+		hasher = sha256.New()
+	case "sha384":
+		hasher = sha512.New384()
+	case "sha512":
+		hasher = sha512.New()
+	default:
+		return false
+	}
+
+	hasher.Write(content)
+	expected := base64.StdEncoding.EncodeToString(hasher.Sum(nil))
+
+	actual := strings.NewReplacer("-", "+", "_", "/").Replace(hashSource.Base64Value)
+	return actual == expected
+}
+
+// schemePartMatches reports whether a URL of scheme b is matched by an expression of scheme a,
+// which permits its secure counterparts as well (CSP Level 3, Section 6.7.2.8).
+func schemePartMatches(a string, b string) bool {
+	a = strings.ToLower(a)
+	b = strings.ToLower(b)
+
+	switch {
+	case a == b:
+		return true
+	case a == "http":
+		return b == "https"
+	case a == "ws":
+		return b == "wss" || b == "http" || b == "https"
+	case a == "wss":
+		return b == "https"
+	}
+
+	return false
+}
+
+func effectivePort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+
+	return defaultPorts[strings.ToLower(u.Scheme)]
+}
+
+func sourceMatchesUrl(source SourceI, target *url.URL, self *url.URL) bool {
+	switch typedSource := source.(type) {
+	case *SchemeSource:
+		return schemePartMatches(typedSource.Scheme, target.Scheme)
+	case *HostSource:
+		return hostSourceMatchesUrl(typedSource, target, self)
+	case *KeywordSource:
+		return strings.EqualFold(typedSource.Keyword, keywordSelf) && selfMatchesUrl(self, target)
+	}
+
+	return false
+}
+
+// hostSourceMatchesUrl matches a host source against a URL (CSP Level 3, Section 6.7.2.7). The
+// path is matched as given, a redirected fetch -- for which it is disregarded -- not being told
+// apart.
+func hostSourceMatchesUrl(hostSource *HostSource, target *url.URL, self *url.URL) bool {
+	host := strings.ToLower(hostSource.Host)
+	targetHost := strings.ToLower(target.Hostname())
+	if targetHost == "" {
+		return false
+	}
+
+	// A lone "*" permits any URL of a network scheme, or of the scheme of the document itself.
+	if host == "*" && hostSource.Scheme == "" && hostSource.PortString == "" && hostSource.Path == "" {
+		switch strings.ToLower(target.Scheme) {
+		case "http", "https", "ws", "wss":
+			return true
+		}
+		return self != nil && strings.EqualFold(self.Scheme, target.Scheme)
+	}
+
+	if scheme := hostSource.Scheme; scheme != "" {
+		if !schemePartMatches(scheme, target.Scheme) {
+			return false
+		}
+	} else if self == nil || !schemePartMatches(self.Scheme, target.Scheme) {
+		return false
+	}
+
+	// A wildcard host permits the subdomains of the host that follows it, but not that host itself.
+	if wildcardHost, ok := strings.CutPrefix(host, "*"); ok {
+		if !strings.HasSuffix(targetHost, wildcardHost) {
+			return false
+		}
+	} else if host != targetHost {
+		return false
+	}
+
+	switch port := hostSource.PortString; port {
+	case "*":
+	case "":
+		if effectivePort(target) != defaultPorts[strings.ToLower(target.Scheme)] {
+			return false
+		}
+	default:
+		if port != effectivePort(target) {
+			return false
+		}
+	}
+
+	if path := hostSource.Path; path != "" && path != "/" {
+		targetPath := target.EscapedPath()
+		if strings.HasSuffix(path, "/") {
+			return strings.HasPrefix(targetPath, path)
+		}
+		return targetPath == path
+	}
+
+	return true
+}
+
+// selfMatchesUrl reports whether 'self' permits the URL: one of the document's own origin, or of
+// its secure counterpart.
+func selfMatchesUrl(self *url.URL, target *url.URL) bool {
+	if self == nil || !strings.EqualFold(self.Hostname(), target.Hostname()) {
+		return false
+	}
+
+	selfScheme := strings.ToLower(self.Scheme)
+	targetScheme := strings.ToLower(target.Scheme)
+
+	if selfScheme == targetScheme {
+		return effectivePort(self) == effectivePort(target)
+	}
+
+	upgraded := (selfScheme == "http" && (targetScheme == "https" || targetScheme == "ws" || targetScheme == "wss")) ||
+		(selfScheme == "https" && targetScheme == "wss")
+	if !upgraded {
+		return false
+	}
+
+	// An upgraded URL is of the same origin where both are on their scheme's default port.
+	return effectivePort(self) == defaultPorts[selfScheme] && effectivePort(target) == defaultPorts[targetScheme]
+}
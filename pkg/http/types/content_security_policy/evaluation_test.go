@@ -0,0 +1,208 @@
+package content_security_policy
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func mustParse(t *testing.T, policy string) *ContentSecurityPolicy {
+	t.Helper()
+
+	csp, err := Parse([]byte(policy))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	return csp
+}
+
+func mustParseUrl(t *testing.T, rawUrl string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		t.Fatalf("url parse: %v", err)
+	}
+
+	return u
+}
+
+func TestEffectiveDirective(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		policy        string
+		directiveName string
+		want          string
+	}{
+		{name: "the directive itself", policy: "default-src 'none'; img-src 'self'", directiveName: DirectiveNameImgSrc, want: DirectiveNameImgSrc},
+		{name: "default-src", policy: "default-src 'none'", directiveName: DirectiveNameImgSrc, want: DirectiveNameDefaultSrc},
+		{name: "script-src for elements", policy: "default-src 'none'; script-src 'self'", directiveName: DirectiveNameScriptSrcElem, want: DirectiveNameScriptSrc},
+		{name: "child-src for workers", policy: "script-src 'self'; child-src 'self'", directiveName: DirectiveNameWorkerSrc, want: DirectiveNameChildSrc},
+		{name: "script-src for workers", policy: "default-src 'none'; script-src 'self'", directiveName: DirectiveNameWorkerSrc, want: DirectiveNameScriptSrc},
+		{name: "no fallback", policy: "default-src 'none'", directiveName: DirectiveNameBaseUri, want: ""},
+		{name: "nothing present", policy: "frame-ancestors 'none'", directiveName: DirectiveNameScriptSrc, want: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			directive, found := mustParse(t, testCase.policy).EffectiveDirective(testCase.directiveName)
+			if testCase.want == "" {
+				if found {
+					t.Fatalf("expected no effective directive, got %v", directive)
+				}
+				return
+			}
+			if !found {
+				t.Fatal("expected an effective directive")
+			}
+			if name := directive.(DirectiveI).GetName(); name != testCase.want {
+				t.Errorf("got %q, want %q", name, testCase.want)
+			}
+		})
+	}
+}
+
+func TestAllowsUrl(t *testing.T) {
+	t.Parallel()
+
+	self := mustParseUrl(t, "https://example.com")
+
+	testCases := []struct {
+		name          string
+		policy        string
+		directiveName string
+		target        string
+		nonce         string
+		want          bool
+	}{
+		{name: "unrestricted", policy: "frame-ancestors 'none'", directiveName: DirectiveNameImgSrc, target: "https://other.test/a.png", want: true},
+		{name: "none", policy: "img-src 'none'", directiveName: DirectiveNameImgSrc, target: "https://example.com/a.png"},
+		{name: "self", policy: "img-src 'self'", directiveName: DirectiveNameImgSrc, target: "https://example.com/a.png", want: true},
+		{name: "self, other port", policy: "img-src 'self'", directiveName: DirectiveNameImgSrc, target: "https://example.com:8443/a.png"},
+		{name: "self, upgraded socket", policy: "connect-src 'self'", directiveName: DirectiveNameConnectSrc, target: "wss://example.com/socket", want: true},
+		{name: "self, other host", policy: "img-src 'self'", directiveName: DirectiveNameImgSrc, target: "https://other.test/a.png"},
+		{name: "falls back on default-src", policy: "default-src 'self'", directiveName: DirectiveNameImgSrc, target: "https://other.test/a.png"},
+		{name: "scheme", policy: "img-src data:", directiveName: DirectiveNameImgSrc, target: "data:image/png;base64,AA==", want: true},
+		{name: "scheme, upgraded", policy: "img-src http:", directiveName: DirectiveNameImgSrc, target: "https://other.test/a.png", want: true},
+		{name: "scheme, not downgraded", policy: "img-src https:", directiveName: DirectiveNameImgSrc, target: "http://other.test/a.png"},
+		{name: "wildcard", policy: "img-src *", directiveName: DirectiveNameImgSrc, target: "https://other.test/a.png", want: true},
+		{name: "wildcard, not data", policy: "img-src *", directiveName: DirectiveNameImgSrc, target: "data:image/png;base64,AA=="},
+		{name: "host", policy: "img-src cdn.test", directiveName: DirectiveNameImgSrc, target: "https://cdn.test/a.png", want: true},
+		{name: "host, scheme of the document", policy: "img-src cdn.test", directiveName: DirectiveNameImgSrc, target: "http://cdn.test/a.png"},
+		{name: "host, other port", policy: "img-src https://cdn.test", directiveName: DirectiveNameImgSrc, target: "https://cdn.test:8443/a.png"},
+		{name: "host, any port", policy: "img-src https://cdn.test:*", directiveName: DirectiveNameImgSrc, target: "https://cdn.test:8443/a.png", want: true},
+		{name: "wildcard host", policy: "img-src *.cdn.test", directiveName: DirectiveNameImgSrc, target: "https://a.b.cdn.test/a.png", want: true},
+		{name: "wildcard host, not the host itself", policy: "img-src *.cdn.test", directiveName: DirectiveNameImgSrc, target: "https://cdn.test/a.png"},
+		{name: "directory path", policy: "img-src https://cdn.test/images/", directiveName: DirectiveNameImgSrc, target: "https://cdn.test/images/a.png", want: true},
+		{name: "directory path, outside", policy: "img-src https://cdn.test/images/", directiveName: DirectiveNameImgSrc, target: "https://cdn.test/other/a.png"},
+		{name: "exact path", policy: "img-src https://cdn.test/a.png", directiveName: DirectiveNameImgSrc, target: "https://cdn.test/a.png", want: true},
+		{name: "exact path, other", policy: "img-src https://cdn.test/a.png", directiveName: DirectiveNameImgSrc, target: "https://cdn.test/b.png"},
+		{name: "nonce", policy: "script-src 'nonce-abc'", directiveName: DirectiveNameScriptSrcElem, target: "https://other.test/a.js", nonce: "abc", want: true},
+		{name: "nonce, wrong", policy: "script-src 'nonce-abc'", directiveName: DirectiveNameScriptSrcElem, target: "https://other.test/a.js", nonce: "def"},
+		{name: "nonce, not for images", policy: "img-src 'nonce-abc'", directiveName: DirectiveNameImgSrc, target: "https://other.test/a.png", nonce: "abc"},
+		{name: "strict-dynamic disregards hosts", policy: "script-src 'self' 'nonce-abc' 'strict-dynamic'", directiveName: DirectiveNameScriptSrcElem, target: "https://example.com/a.js"},
+		{name: "strict-dynamic, nonce", policy: "script-src 'self' 'nonce-abc' 'strict-dynamic'", directiveName: DirectiveNameScriptSrcElem, target: "https://example.com/a.js", nonce: "abc", want: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			csp := mustParse(t, testCase.policy)
+			got := csp.AllowsUrl(testCase.directiveName, mustParseUrl(t, testCase.target), self, testCase.nonce)
+			if got != testCase.want {
+				t.Errorf("got %t, want %t", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestAllowsInline(t *testing.T) {
+	t.Parallel()
+
+	content := []byte("alert(1)")
+	digest := sha256.Sum256(content)
+	hashSource := "'sha256-" + base64.StdEncoding.EncodeToString(digest[:]) + "'"
+	urlSafeHashSource := "'sha256-" + base64.URLEncoding.EncodeToString(digest[:]) + "'"
+
+	testCases := []struct {
+		name          string
+		policy        string
+		directiveName string
+		nonce         string
+		want          bool
+	}{
+		{name: "unrestricted", policy: "img-src 'none'", directiveName: DirectiveNameScriptSrcElem, want: true},
+		{name: "restricted", policy: "default-src 'self'", directiveName: DirectiveNameScriptSrcElem},
+		{name: "unsafe-inline", policy: "script-src 'unsafe-inline'", directiveName: DirectiveNameScriptSrcElem, want: true},
+		{name: "unsafe-inline, disregarded for a nonce", policy: "script-src 'unsafe-inline' 'nonce-abc'", directiveName: DirectiveNameScriptSrcElem},
+		{name: "unsafe-inline, disregarded for strict-dynamic", policy: "script-src 'unsafe-inline' 'strict-dynamic'", directiveName: DirectiveNameScriptSrcElem},
+		{name: "unsafe-inline with strict-dynamic, styles", policy: "style-src 'unsafe-inline' 'strict-dynamic'", directiveName: DirectiveNameStyleSrcElem, want: true},
+		{name: "nonce", policy: "script-src 'nonce-abc'", directiveName: DirectiveNameScriptSrcElem, nonce: "abc", want: true},
+		{name: "nonce, not for attributes", policy: "script-src 'nonce-abc'", directiveName: DirectiveNameScriptSrcAttr, nonce: "abc"},
+		{name: "hash", policy: "script-src " + hashSource, directiveName: DirectiveNameScriptSrcElem, want: true},
+		{name: "hash, url-safe", policy: "script-src " + urlSafeHashSource, directiveName: DirectiveNameScriptSrcElem, want: true},
+		{name: "hash, other content", policy: "script-src 'sha256-AAAA'", directiveName: DirectiveNameScriptSrcElem},
+		{name: "hash, attribute", policy: "script-src " + hashSource, directiveName: DirectiveNameScriptSrcAttr},
+		{name: "hash, attribute with unsafe-hashes", policy: "script-src 'unsafe-hashes' " + hashSource, directiveName: DirectiveNameScriptSrcAttr, want: true},
+		{name: "script-src-elem takes precedence", policy: "script-src 'unsafe-inline'; script-src-elem 'self'", directiveName: DirectiveNameScriptSrcElem},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := mustParse(t, testCase.policy).AllowsInline(testCase.directiveName, testCase.nonce, content)
+			if got != testCase.want {
+				t.Errorf("got %t, want %t", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestAllowsEval(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		policy   string
+		want     bool
+		wantWasm bool
+	}{
+		{policy: "img-src 'none'", want: true, wantWasm: true},
+		{policy: "default-src 'self'"},
+		{policy: "default-src 'self' 'unsafe-eval'", want: true, wantWasm: true},
+		{policy: "script-src 'self' 'wasm-unsafe-eval'", wantWasm: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.policy, func(t *testing.T) {
+			t.Parallel()
+
+			csp := mustParse(t, testCase.policy)
+			if got := csp.AllowsEval(); got != testCase.want {
+				t.Errorf("eval: got %t, want %t", got, testCase.want)
+			}
+			if got := csp.AllowsWasmEval(); got != testCase.wantWasm {
+				t.Errorf("wasm eval: got %t, want %t", got, testCase.wantWasm)
+			}
+		})
+	}
+}
+
+func TestFallbackList(t *testing.T) {
+	t.Parallel()
+
+	if got := FallbackList(DirectiveNameBaseUri); !slices.Equal(got, []string{DirectiveNameBaseUri}) {
+		t.Errorf("base-uri: got %v", got)
+	}
+	if got := FallbackList(DirectiveNameFrameSrc); !slices.Equal(got, []string{DirectiveNameFrameSrc, DirectiveNameChildSrc, DirectiveNameDefaultSrc}) {
+		t.Errorf("frame-src: got %v", got)
+	}
+}
@@ -0,0 +1,141 @@
+package lint
+
+import (
+	"github.com/Motmedel/utils_go/pkg/sarif"
+)
+
+// RuleId identifies a check the linter performs.
+type RuleId string
+
+const (
+	// RuleIdMissingScriptSrc marks a policy that restricts scripts neither through script-src nor
+	// through default-src, and so does nothing against the injection of one.
+	RuleIdMissingScriptSrc RuleId = "missing-script-src"
+	// RuleIdUnsafeInline marks an 'unsafe-inline' that takes effect for scripts: one that no nonce,
+	// hash or 'strict-dynamic' alongside it makes a browser disregard.
+	RuleIdUnsafeInline RuleId = "unsafe-inline"
+	// RuleIdUnsafeEval marks an 'unsafe-eval', with which strings are evaluated as script.
+	RuleIdUnsafeEval RuleId = "unsafe-eval"
+	// RuleIdWildcardSource marks a lone "*" where scripts, plugins or the base URL are governed,
+	// which permits nearly every host.
+	RuleIdWildcardSource RuleId = "wildcard-source"
+	// RuleIdPermissiveSchemeSource marks a scheme source where scripts, plugins or the base URL are
+	// governed: "https:" permits every host served over it, and "data:" and "blob:" content that the
+	// page itself may have been made to create.
+	RuleIdPermissiveSchemeSource RuleId = "permissive-scheme-source"
+	// RuleIdJsonpCapableHost marks an allowlisted script host known to serve JSONP endpoints, or
+	// libraries such as AngularJS that evaluate what the page contains, through either of which an
+	// allowlist is bypassed.
+	RuleIdJsonpCapableHost RuleId = "jsonp-capable-host"
+	// RuleIdMissingObjectSrc marks a policy that does not restrict plugins to 'none', through
+	// object-src or default-src.
+	RuleIdMissingObjectSrc RuleId = "missing-object-src"
+	// RuleIdMissingBaseUri marks a policy that restricts scripts but not the base URL, which an
+	// injected base element can then point the relative URLs of the page's scripts elsewhere with.
+	RuleIdMissingBaseUri RuleId = "missing-base-uri"
+	// RuleIdWeakNonce marks a nonce too short to carry the 128 bits that make it unguessable.
+	RuleIdWeakNonce RuleId = "weak-nonce"
+	// RuleIdDuplicateDirective marks a directive given again, which a browser ignores.
+	RuleIdDuplicateDirective RuleId = "duplicate-directive"
+)
+
+// Rule describes a check the linter performs.
+type Rule struct {
+	Id          RuleId
+	Level       sarif.Level
+	Description string
+}
+
+// rules describes every check, in reporting order.
+var rules = []*Rule{
+	{
+		Id:          RuleIdMissingScriptSrc,
+		Level:       sarif.LevelError,
+		Description: "A policy that restricts scripts neither through script-src nor through default-src.",
+	},
+	{
+		Id:          RuleIdUnsafeInline,
+		Level:       sarif.LevelError,
+		Description: "An 'unsafe-inline' that permits every inline script.",
+	},
+	{
+		Id:          RuleIdUnsafeEval,
+		Level:       sarif.LevelWarning,
+		Description: "An 'unsafe-eval' that permits strings to be evaluated as script.",
+	},
+	{
+		Id:          RuleIdWildcardSource,
+		Level:       sarif.LevelError,
+		Description: "A lone \"*\" where scripts, plugins or the base URL are governed.",
+	},
+	{
+		Id:          RuleIdPermissiveSchemeSource,
+		Level:       sarif.LevelWarning,
+		Description: "A scheme source where scripts, plugins or the base URL are governed.",
+	},
+	{
+		Id:          RuleIdJsonpCapableHost,
+		Level:       sarif.LevelWarning,
+		Description: "An allowlisted script host through which an allowlist is known to be bypassed.",
+	},
+	{
+		Id:          RuleIdMissingObjectSrc,
+		Level:       sarif.LevelWarning,
+		Description: "A policy that does not restrict plugins to 'none'.",
+	},
+	{
+		Id:          RuleIdMissingBaseUri,
+		Level:       sarif.LevelWarning,
+		Description: "A policy that restricts scripts but not the base URL.",
+	},
+	{
+		Id:          RuleIdWeakNonce,
+		Level:       sarif.LevelWarning,
+		Description: "A nonce too short to carry 128 bits.",
+	},
+	{
+		Id:          RuleIdDuplicateDirective,
+		Level:       sarif.LevelNote,
+		Description: "A directive given again, which a browser ignores.",
+	},
+}
+
+// Rules returns every check the linter performs, in reporting order.
+func Rules() []*Rule {
+	return rules
+}
+
+// ruleById indexes the checks by identifier.
+var ruleById = func() map[RuleId]*Rule {
+	byId := make(map[RuleId]*Rule, len(rules))
+	for _, rule := range rules {
+		byId[rule.Id] = rule
+	}
+	return byId
+}()
+
+// Position is a location in a policy, counted over its bytes.
+type Position struct {
+	// Offset is the zero-based byte offset.
+	Offset int
+	// Line and Column are one-based, as editors and SARIF count them. A policy is a header value,
+	// and on one line.
+	Line, Column int
+}
+
+// Finding is one reported occurrence of a check.
+type Finding struct {
+	RuleId RuleId
+	// Directive names the directive the finding is about, where it is about one.
+	Directive string
+	// Start and End delimit the bytes the finding covers; Start <= End. A finding about what the
+	// policy lacks covers the policy as a whole.
+	Start, End *Position
+	// Message says what is wrong, in one sentence.
+	Message string
+}
+
+// Rule returns the check the finding reports on.
+func (finding *Finding) Rule() *Rule {
+	return ruleById[finding.RuleId]
+}
@@ -0,0 +1,353 @@
+// Package lint reports on Content Security Policies: on what makes one weaker than it looks, such
+// as an 'unsafe-inline' that takes effect, an allowlisted host through which the allowlist is
+// bypassed, or a directive that should be there and is not.
+//
+// Findings carry the bytes of the policy they cover, so that they can be reported against it.
+// Sarif renders them as a SARIF 2.1.0 log.
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	csp "github.com/Motmedel/utils_go/pkg/http/types/content_security_policy"
+)
+
+// minimumNonceLength is the number of base64 characters that carry the 128 bits a nonce is to be
+// made of, at six bits a character.
+const minimumNonceLength = 22
+
+// jsonpCapableHosts are script hosts known to serve JSONP endpoints, or libraries such as AngularJS
+// that evaluate what the page contains, either of which a script permitted from them can be made to
+// run what an attacker says.
+var jsonpCapableHosts = []string{
+	"accounts.google.com",
+	"ajax.googleapis.com",
+	"cdn.jsdelivr.net",
+	"cdnjs.cloudflare.com",
+	"code.angularjs.org",
+	"graph.facebook.com",
+	"maps.googleapis.com",
+	"unpkg.com",
+	"www.google.com",
+	"www.googleapis.com",
+	"www.gstatic.com",
+}
+
+// permissiveSchemes are the schemes a scheme source permits too much with, where scripts, plugins
+// or the base URL are governed.
+var permissiveSchemes = []string{"http", "https", "data", "blob"}
+
+// linter accumulates the findings of one run over one policy.
+type linter struct {
+	locator *locator
+	policy  *csp.ContentSecurityPolicy
+	// scriptDirectives are the directives in effect for scripts, which are reported on as such.
+	scriptDirectives []csp.SourceDirectiveI
+	findings         []*Finding
+}
+
+func (linter *linter) add(ruleId RuleId, directive string, span span, message string) {
+	linter.findings = append(linter.findings, &Finding{
+		RuleId:    ruleId,
+		Directive: directive,
+		Start:     linter.locator.position(span.start),
+		End:       linter.locator.position(span.end),
+		Message:   message,
+	})
+}
+
+// Lint reports on a Content Security Policy, as serialized in a header value.
+func Lint(input []byte) ([]*Finding, error) {
+	policy, err := csp.Parse(input)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: content security policy parse: %w", motmedelErrors.ErrParseError, err),
+			input,
+		)
+	}
+
+	linter := &linter{locator: newLocator(input), policy: policy}
+
+	linter.scriptDirectives = effectiveScriptDirectives(policy)
+	if len(linter.scriptDirectives) == 0 {
+		linter.add(
+			RuleIdMissingScriptSrc,
+			"",
+			linter.locator.whole(),
+			"neither script-src nor default-src restricts scripts, so any script injected runs",
+		)
+	}
+	for _, directive := range linter.scriptDirectives {
+		linter.scriptFindings(directive)
+	}
+
+	linter.restrictingFindings(csp.DirectiveNameObjectSrc)
+	linter.restrictingFindings(csp.DirectiveNameBaseUri)
+	linter.objectSrcFindings()
+	if len(linter.scriptDirectives) != 0 {
+		linter.baseUriFindings()
+	}
+	linter.nonceFindings()
+	linter.duplicateFindings()
+
+	return sortFindings(linter.findings), nil
+}
+
+// effectiveScriptDirectives returns the directives in effect for script elements and attributes,
+// each once.
+func effectiveScriptDirectives(policy *csp.ContentSecurityPolicy) []csp.SourceDirectiveI {
+	var directives []csp.SourceDirectiveI
+	for _, name := range []string{csp.DirectiveNameScriptSrcElem, csp.DirectiveNameScriptSrcAttr} {
+		directive, found := policy.EffectiveDirective(name)
+		if found && !slices.Contains(directives, directive) {
+			directives = append(directives, directive)
+		}
+	}
+
+	return directives
+}
+
+func directiveName(directive csp.SourceDirectiveI) string {
+	if named, ok := directive.(csp.DirectiveI); ok {
+		return named.GetName()
+	}
+
+	return ""
+}
+
+func keywordSources(directive csp.SourceDirectiveI, keyword string) []csp.SourceI {
+	var sources []csp.SourceI
+	for _, source := range directive.GetSources() {
+		if keywordSource, ok := source.(*csp.KeywordSource); ok && strings.EqualFold(keywordSource.Keyword, keyword) {
+			sources = append(sources, source)
+		}
+	}
+
+	return sources
+}
+
+// scriptFindings reports on a directive in effect for scripts.
+func (linter *linter) scriptFindings(directive csp.SourceDirectiveI) {
+	name := directiveName(directive)
+	sources := directive.GetSources()
+
+	if csp.AllowsAllInline(sources, true) {
+		for _, source := range keywordSources(directive, "unsafe-inline") {
+			linter.add(
+				RuleIdUnsafeInline,
+				name,
+				linter.locator.source(name, source),
+				fmt.Sprintf("%s permits every inline script, injected ones included", name),
+			)
+		}
+	}
+
+	for _, source := range keywordSources(directive, "unsafe-eval") {
+		linter.add(
+			RuleIdUnsafeEval,
+			name,
+			linter.locator.source(name, source),
+			fmt.Sprintf("%s permits strings to be evaluated as script", name),
+		)
+	}
+
+	// A browser that supports 'strict-dynamic' disregards the hosts and schemes alongside it.
+	if len(keywordSources(directive, "strict-dynamic")) != 0 {
+		return
+	}
+
+	linter.permissiveSourceFindings(name, sources)
+
+	for _, source := range sources {
+		hostSource, ok := source.(*csp.HostSource)
+		if !ok {
+			continue
+		}
+
+		if host := jsonpCapableHost(hostSource.Host); host != "" {
+			linter.add(
+				RuleIdJsonpCapableHost,
+				name,
+				linter.locator.source(name, source),
+				fmt.Sprintf("%s permits scripts from %s, through which the allowlist is known to be bypassed", name, host),
+			)
+		}
+	}
+}
+
+// restrictingFindings reports on the permissive sources of the directive in effect for what the
+// named directive governs, unless it is in effect for scripts too, and reported on as such.
+func (linter *linter) restrictingFindings(name string) {
+	directive, found := linter.policy.EffectiveDirective(name)
+	if !found || slices.Contains(linter.scriptDirectives, directive) {
+		return
+	}
+
+	linter.permissiveSourceFindings(directiveName(directive), directive.GetSources())
+}
+
+func (linter *linter) permissiveSourceFindings(name string, sources []csp.SourceI) {
+	for _, source := range sources {
+		switch typedSource := source.(type) {
+		case *csp.HostSource:
+			if typedSource.Host == "*" && typedSource.Scheme == "" && typedSource.PortString == "" && typedSource.Path == "" {
+				linter.add(
+					RuleIdWildcardSource,
+					name,
+					linter.locator.source(name, source),
+					fmt.Sprintf("%s permits nearly every host with \"*\"", name),
+				)
+			}
+		case *csp.SchemeSource:
+			scheme := strings.ToLower(typedSource.Scheme)
+			if slices.Contains(permissiveSchemes, scheme) {
+				linter.add(
+					RuleIdPermissiveSchemeSource,
+					name,
+					linter.locator.source(name, source),
+					fmt.Sprintf("%s permits any %s: URL", name, scheme),
+				)
+			}
+		}
+	}
+}
+
+func jsonpCapableHost(host string) string {
+	host = strings.ToLower(host)
+
+	// A wildcard host permits the subdomains of the host that follows it.
+	if suffix, ok := strings.CutPrefix(host, "*."); ok {
+		for _, jsonpHost := range jsonpCapableHosts {
+			if strings.HasSuffix(jsonpHost, "."+suffix) {
+				return jsonpHost
+			}
+		}
+		return ""
+	}
+
+	if slices.Contains(jsonpCapableHosts, host) {
+		return host
+	}
+
+	return ""
+}
+
+func onlyNone(directive csp.SourceDirectiveI) bool {
+	sources := directive.GetSources()
+	if len(sources) == 0 {
+		return false
+	}
+
+	for _, source := range sources {
+		if _, ok := source.(*csp.NoneSource); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (linter *linter) objectSrcFindings() {
+	directive, found := linter.policy.EffectiveDirective(csp.DirectiveNameObjectSrc)
+	if !found {
+		linter.add(
+			RuleIdMissingObjectSrc,
+			"",
+			linter.locator.whole(),
+			"neither object-src nor default-src restricts plugins, which can run script",
+		)
+		return
+	}
+
+	if onlyNone(directive) {
+		return
+	}
+
+	name := directiveName(directive)
+	linter.add(
+		RuleIdMissingObjectSrc,
+		name,
+		linter.locator.directive(name, 0),
+		fmt.Sprintf("%s permits plugins, which can run script, where object-src 'none' permits none", name),
+	)
+}
+
+func (linter *linter) baseUriFindings() {
+	if _, found := linter.policy.GetDirective(csp.DirectiveNameBaseUri); found {
+		return
+	}
+
+	linter.add(
+		RuleIdMissingBaseUri,
+		"",
+		linter.locator.whole(),
+		"base-uri is missing, so an injected base element can make the page's relative script URLs load from elsewhere",
+	)
+}
+
+func (linter *linter) nonceFindings() {
+	for _, directive := range linter.policy.Directives {
+		sourceDirective, ok := directive.(csp.SourceDirectiveI)
+		if !ok {
+			continue
+		}
+
+		name := directive.GetName()
+		for _, source := range sourceDirective.GetSources() {
+			nonceSource, ok := source.(*csp.NonceSource)
+			if !ok || len(strings.TrimRight(nonceSource.Base64Value, "=")) >= minimumNonceLength {
+				continue
+			}
+
+			linter.add(
+				RuleIdWeakNonce,
+				name,
+				linter.locator.source(name, source),
+				fmt.Sprintf(
+					"the nonce of %s carries at most %d bits, where 128 make it unguessable",
+					name,
+					len(strings.TrimRight(nonceSource.Base64Value, "="))*6,
+				),
+			)
+		}
+	}
+}
+
+func (linter *linter) duplicateFindings() {
+	occurrences := make(map[string]int)
+	for _, directive := range linter.policy.IneffectiveDirectives {
+		if directive == nil {
+			continue
+		}
+
+		name := directive.GetName()
+		occurrences[name]++
+		linter.add(
+			RuleIdDuplicateDirective,
+			name,
+			linter.locator.directive(name, occurrences[name]),
+			fmt.Sprintf("%s is given again, and this occurrence is ignored", name),
+		)
+	}
+}
+
+// sortFindings orders findings by where they are, and then by the order the checks are declared
+// in, so that a run reports the same thing every time.
+func sortFindings(findings []*Finding) []*Finding {
+	ruleOrder := make(map[RuleId]int, len(rules))
+	for i, rule := range rules {
+		ruleOrder[rule.Id] = i
+	}
+
+	slices.SortStableFunc(findings, func(a *Finding, b *Finding) int {
+		if a.Start.Offset != b.Start.Offset {
+			return a.Start.Offset - b.Start.Offset
+		}
+		return ruleOrder[a.RuleId] - ruleOrder[b.RuleId]
+	})
+
+	return findings
+}
@@ -0,0 +1,197 @@
+package lint
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Motmedel/utils_go/pkg/http/mux/types/response_writer"
+	"github.com/Motmedel/utils_go/pkg/sarif"
+)
+
+// ruleIds returns the checks the findings answer to, in order.
+func ruleIds(findings []*Finding) []RuleId {
+	ids := make([]RuleId, 0, len(findings))
+	for _, finding := range findings {
+		ids = append(ids, finding.RuleId)
+	}
+	return ids
+}
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected []RuleId
+	}{
+		{
+			name:     "strict policy is clean",
+			input:    "script-src 'nonce-0123456789abcdefghijkl' 'strict-dynamic'; object-src 'none'; base-uri 'none'",
+			expected: []RuleId{},
+		},
+		{
+			name:     "no script restriction",
+			input:    "img-src 'self'",
+			expected: []RuleId{RuleIdMissingScriptSrc, RuleIdMissingObjectSrc},
+		},
+		{
+			name:     "unsafe-inline and unsafe-eval",
+			input:    "script-src 'self' 'unsafe-inline' 'unsafe-eval'; object-src 'none'; base-uri 'self'",
+			expected: []RuleId{RuleIdUnsafeInline, RuleIdUnsafeEval},
+		},
+		{
+			// A browser disregards 'unsafe-inline' alongside a nonce; it is there for older ones.
+			name:     "unsafe-inline disregarded",
+			input:    "script-src 'unsafe-inline' 'nonce-0123456789abcdefghijkl'; object-src 'none'; base-uri 'none'",
+			expected: []RuleId{},
+		},
+		{
+			name:     "wildcard and scheme sources",
+			input:    "script-src * https: data:; object-src 'none'; base-uri 'none'",
+			expected: []RuleId{RuleIdWildcardSource, RuleIdPermissiveSchemeSource, RuleIdPermissiveSchemeSource},
+		},
+		{
+			name:     "jsonp-capable hosts",
+			input:    "script-src 'self' https://www.google.com *.googleapis.com; object-src 'none'; base-uri 'none'",
+			expected: []RuleId{RuleIdJsonpCapableHost, RuleIdJsonpCapableHost},
+		},
+		{
+			name:     "hosts disregarded for strict-dynamic",
+			input:    "script-src https://www.google.com https: 'nonce-0123456789abcdefghijkl' 'strict-dynamic'; object-src 'none'; base-uri 'none'",
+			expected: []RuleId{},
+		},
+		{
+			name:     "plugins and base url unrestricted",
+			input:    "default-src 'self'",
+			expected: []RuleId{RuleIdMissingObjectSrc, RuleIdMissingBaseUri},
+		},
+		{
+			name:     "permissive object-src",
+			input:    "default-src 'self'; object-src data:; base-uri 'none'",
+			expected: []RuleId{RuleIdMissingObjectSrc, RuleIdPermissiveSchemeSource},
+		},
+		{
+			name:     "weak nonce",
+			input:    "script-src 'nonce-abc'; object-src 'none'; base-uri 'none'",
+			expected: []RuleId{RuleIdWeakNonce},
+		},
+		{
+			name:     "duplicate directive",
+			input:    "default-src 'none'; base-uri 'none'; default-src *",
+			expected: []RuleId{RuleIdDuplicateDirective},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			findings, err := Lint([]byte(testCase.input))
+			if err != nil {
+				t.Fatalf("lint: %v", err)
+			}
+
+			if got := ruleIds(findings); !slices.Equal(got, testCase.expected) {
+				t.Errorf("got %v, want %v", got, testCase.expected)
+				for _, finding := range findings {
+					t.Logf("%s: %s", finding.RuleId, finding.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	t.Parallel()
+
+	// The policy the mux serves documents with by default holds up to its own linter.
+	findings, err := Lint([]byte(response_writer.DefaultContentSecurityPolicyString))
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+
+	if len(findings) != 0 {
+		t.Errorf("expected the default policy to be clean, got %v", ruleIds(findings))
+	}
+}
+
+func TestPositions(t *testing.T) {
+	t.Parallel()
+
+	input := "default-src 'none'; script-src 'self'  'unsafe-eval'; base-uri 'none'; default-src *"
+
+	findings, err := Lint([]byte(input))
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+
+	testCases := []struct {
+		ruleId RuleId
+		quoted string
+	}{
+		{ruleId: RuleIdUnsafeEval, quoted: "'unsafe-eval'"},
+		{ruleId: RuleIdDuplicateDirective, quoted: "default-src *"},
+	}
+
+	for _, testCase := range testCases {
+		index := slices.IndexFunc(findings, func(finding *Finding) bool { return finding.RuleId == testCase.ruleId })
+		if index == -1 {
+			t.Fatalf("expected a %s finding", testCase.ruleId)
+		}
+
+		finding := findings[index]
+		if quoted := input[finding.Start.Offset:finding.End.Offset]; quoted != testCase.quoted {
+			t.Errorf("%s: got %q, want %q", testCase.ruleId, quoted, testCase.quoted)
+		}
+		if finding.Start.Line != 1 || finding.Start.Column != finding.Start.Offset+1 {
+			t.Errorf("%s: got %d:%d", testCase.ruleId, finding.Start.Line, finding.Start.Column)
+		}
+	}
+}
+
+func TestSarif(t *testing.T) {
+	t.Parallel()
+
+	findings, err := Lint([]byte("script-src 'unsafe-inline'; object-src 'none'; base-uri 'none'"))
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+
+	log := Sarif([]*Report{{Uri: "https://example.com/", Findings: findings}})
+	if len(log.Runs) != 1 {
+		t.Fatalf("expected one run, got %d", len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(Rules()) {
+		t.Errorf("expected every rule to be declared, got %d", len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("expected one result, got %d", len(run.Results))
+	}
+
+	result := run.Results[0]
+	if result.RuleId != string(RuleIdUnsafeInline) || result.Level != sarif.LevelError {
+		t.Errorf("result: got %s at %s", result.RuleId, result.Level)
+	}
+	if index := *result.RuleIndex; run.Tool.Driver.Rules[index].Id != result.RuleId {
+		t.Errorf("rule index %d is of %s", index, run.Tool.Driver.Rules[index].Id)
+	}
+
+	region := result.Locations[0].PhysicalLocation.Region
+	if region.ByteOffset != 11 || region.ByteLength != len("'unsafe-inline'") {
+		t.Errorf("region: got %d+%d", region.ByteOffset, region.ByteLength)
+	}
+	if result.Properties["directive"] != "script-src" {
+		t.Errorf("properties: got %v", result.Properties)
+	}
+}
+
+func TestLintErrors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Lint([]byte("script-src 'self\x00'")); err == nil {
+		t.Error("expected an error for a policy that does not parse")
+	}
+}
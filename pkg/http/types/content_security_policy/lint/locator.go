@@ -0,0 +1,121 @@
+package lint
+
+import (
+	"strings"
+
+	csp "github.com/Motmedel/utils_go/pkg/http/types/content_security_policy"
+)
+
+// span delimits bytes of a policy.
+type span struct {
+	start, end int
+}
+
+// located is a directive as it occurs in a policy.
+type located struct {
+	// directive covers the directive from its name to its last token.
+	directive span
+	tokens    []string
+	// tokenSpans are those of the tokens of the directive's value.
+	tokenSpans []span
+}
+
+// locator finds where the directives of a policy, and their sources, are in it, the parsed policy
+// keeping no offsets. A policy is a header value, which RFC 9110 makes visible US-ASCII and
+// whitespace, so a byte offset is a character offset as well.
+type locator struct {
+	input []byte
+	// directives holds the occurrences of each directive, in order, by lowercase name.
+	directives map[string][]*located
+}
+
+func isWhitespace(b byte) bool {
+	return b == ' ' || b == '\t'
+}
+
+func newLocator(input []byte) *locator {
+	locator := &locator{input: input, directives: make(map[string][]*located)}
+
+	start := 0
+	for i := 0; i <= len(input); i++ {
+		if i != len(input) && input[i] != ';' {
+			continue
+		}
+
+		var tokens []string
+		var tokenSpans []span
+		for j := start; j < i; {
+			if isWhitespace(input[j]) {
+				j++
+				continue
+			}
+
+			k := j
+			for k < i && !isWhitespace(input[k]) {
+				k++
+			}
+			tokens = append(tokens, string(input[j:k]))
+			tokenSpans = append(tokenSpans, span{start: j, end: k})
+			j = k
+		}
+
+		if len(tokens) != 0 {
+			name := strings.ToLower(tokens[0])
+			locator.directives[name] = append(locator.directives[name], &located{
+				directive:  span{start: tokenSpans[0].start, end: tokenSpans[len(tokenSpans)-1].end},
+				tokens:     tokens[1:],
+				tokenSpans: tokenSpans[1:],
+			})
+		}
+
+		start = i + 1
+	}
+
+	return locator
+}
+
+// whole covers the policy, without the whitespace around it.
+func (locator *locator) whole() span {
+	start, end := 0, len(locator.input)
+	for start < end && isWhitespace(locator.input[start]) {
+		start++
+	}
+	for end > start && isWhitespace(locator.input[end-1]) {
+		end--
+	}
+
+	return span{start: start, end: end}
+}
+
+// directive covers the nth occurrence of the named directive, counting from zero, or the policy
+// where there is no such occurrence.
+func (locator *locator) directive(name string, occurrence int) span {
+	occurrences := locator.directives[strings.ToLower(name)]
+	if occurrence < 0 || occurrence >= len(occurrences) {
+		return locator.whole()
+	}
+
+	return occurrences[occurrence].directive
+}
+
+// source covers the source in the named directive as in effect, which is its first occurrence, or
+// the directive where the source is not found in it.
+func (locator *locator) source(name string, source csp.SourceI) span {
+	occurrences := locator.directives[strings.ToLower(name)]
+	if len(occurrences) == 0 {
+		return locator.whole()
+	}
+
+	occurrence := occurrences[0]
+	for i, token := range occurrence.tokens {
+		if source != nil && (token == source.GetRaw() || strings.EqualFold(token, source.String())) {
+			return occurrence.tokenSpans[i]
+		}
+	}
+
+	return occurrence.directive
+}
+
+func (locator *locator) position(offset int) *Position {
+	return &Position{Offset: offset, Line: 1, Column: offset + 1}
+}
@@ -0,0 +1,168 @@
+// Package cross_origin_policy parses and serializes the headers that isolate a document from other
+// origins: Cross-Origin-Opener-Policy, which says which documents may share a browsing context group
+// with it; Cross-Origin-Embedder-Policy, which says what it may load that has not agreed to it; and
+// Cross-Origin-Resource-Policy, which says who may load a response at all.
+package cross_origin_policy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/http/structured_field"
+)
+
+const (
+	OpenerPolicyHeaderName             = "Cross-Origin-Opener-Policy"
+	OpenerPolicyReportOnlyHeaderName   = "Cross-Origin-Opener-Policy-Report-Only"
+	EmbedderPolicyHeaderName           = "Cross-Origin-Embedder-Policy"
+	EmbedderPolicyReportOnlyHeaderName = "Cross-Origin-Embedder-Policy-Report-Only"
+	ResourcePolicyHeaderName           = "Cross-Origin-Resource-Policy"
+
+	reportToParameterKey = "report-to"
+)
+
+const (
+	OpenerPolicyUnsafeNone            = "unsafe-none"
+	OpenerPolicySameOriginAllowPopups = "same-origin-allow-popups"
+	OpenerPolicySameOrigin            = "same-origin"
+	OpenerPolicyNoopenerAllowPopups   = "noopener-allow-popups"
+)
+
+var openerPolicyValues = []string{
+	OpenerPolicyUnsafeNone,
+	OpenerPolicySameOriginAllowPopups,
+	OpenerPolicySameOrigin,
+	OpenerPolicyNoopenerAllowPopups,
+}
+
+const (
+	EmbedderPolicyUnsafeNone     = "unsafe-none"
+	EmbedderPolicyRequireCorp    = "require-corp"
+	EmbedderPolicyCredentialless = "credentialless"
+)
+
+var embedderPolicyValues = []string{
+	EmbedderPolicyUnsafeNone,
+	EmbedderPolicyRequireCorp,
+	EmbedderPolicyCredentialless,
+}
+
+// policy is what Cross-Origin-Opener-Policy and Cross-Origin-Embedder-Policy are both made of: a
+// token, and the reporting endpoint the violations of it are reported to.
+type policy struct {
+	Value string
+	// ReportTo names the reporting endpoint the violations of the policy are reported to.
+	ReportTo string
+}
+
+func (policy *policy) string() string {
+	item := &structured_field.Item{Value: structured_field.Token(policy.Value)}
+	if reportTo := policy.ReportTo; reportTo != "" {
+		item.Parameters = structured_field.Parameters{{Key: reportToParameterKey, Value: reportTo}}
+	}
+
+	value, err := structured_field.SerializeItem(item)
+	if err != nil {
+		return ""
+	}
+
+	return value
+}
+
+func parsePolicy(data []byte, values []string) (*policy, error) {
+	item, err := structured_field.ParseItem(data)
+	if err != nil {
+		return nil, motmedelErrors.New(
+			fmt.Errorf("%w: parse item: %w", motmedelErrors.ErrParseError, err),
+			data,
+		)
+	}
+
+	token, ok := item.Value.(structured_field.Token)
+	if !ok || !slices.Contains(values, string(token)) {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: unknown value: %v", motmedelErrors.ErrSemanticError, item.Value),
+			data,
+		)
+	}
+
+	parsedPolicy := &policy{Value: string(token)}
+	if reportTo, ok := item.Parameters.Get(reportToParameterKey); ok {
+		// The endpoint is specified as a string; a token is taken as well, being what is meant.
+		switch typedReportTo := reportTo.(type) {
+		case string:
+			parsedPolicy.ReportTo = typedReportTo
+		case structured_field.Token:
+			parsedPolicy.ReportTo = string(typedReportTo)
+		}
+	}
+
+	return parsedPolicy, nil
+}
+
+// OpenerPolicy is the value of Cross-Origin-Opener-Policy.
+type OpenerPolicy policy
+
+// String serializes the policy as a header value, or returns an empty string where its value is
+// not a token.
+func (openerPolicy *OpenerPolicy) String() string {
+	return (*policy)(openerPolicy).string()
+}
+
+// ParseOpenerPolicy parses a Cross-Origin-Opener-Policy header value. A value that is not one of
+// those specified is an error; a browser takes it as "unsafe-none".
+func ParseOpenerPolicy(data []byte) (*OpenerPolicy, error) {
+	parsedPolicy, err := parsePolicy(data, openerPolicyValues)
+	if err != nil {
+		return nil, fmt.Errorf("parse policy: %w", err)
+	}
+
+	return (*OpenerPolicy)(parsedPolicy), nil
+}
+
+// EmbedderPolicy is the value of Cross-Origin-Embedder-Policy.
+type EmbedderPolicy policy
+
+// String serializes the policy as a header value, or returns an empty string where its value is
+// not a token.
+func (embedderPolicy *EmbedderPolicy) String() string {
+	return (*policy)(embedderPolicy).string()
+}
+
+// ParseEmbedderPolicy parses a Cross-Origin-Embedder-Policy header value. A value that is not one
+// of those specified is an error; a browser takes it as "unsafe-none".
+func ParseEmbedderPolicy(data []byte) (*EmbedderPolicy, error) {
+	parsedPolicy, err := parsePolicy(data, embedderPolicyValues)
+	if err != nil {
+		return nil, fmt.Errorf("parse policy: %w", err)
+	}
+
+	return (*EmbedderPolicy)(parsedPolicy), nil
+}
+
+// ResourcePolicy is the value of Cross-Origin-Resource-Policy. Unlike the other two, it is not a
+// structured field, and takes no parameters.
+type ResourcePolicy string
+
+const (
+	ResourcePolicySameSite    ResourcePolicy = "same-site"
+	ResourcePolicySameOrigin  ResourcePolicy = "same-origin"
+	ResourcePolicyCrossOrigin ResourcePolicy = "cross-origin"
+)
+
+// ParseResourcePolicy parses a Cross-Origin-Resource-Policy header value. Fetch compares the value
+// as it is, so a value in another case is not the one it looks like, and an error.
+func ParseResourcePolicy(data []byte) (ResourcePolicy, error) {
+	resourcePolicy := ResourcePolicy(strings.Trim(string(data), " \t"))
+	switch resourcePolicy {
+	case ResourcePolicySameSite, ResourcePolicySameOrigin, ResourcePolicyCrossOrigin:
+		return resourcePolicy, nil
+	}
+
+	return "", motmedelErrors.NewWithTrace(
+		fmt.Errorf("%w: unknown value", motmedelErrors.ErrSemanticError),
+		data,
+	)
+}
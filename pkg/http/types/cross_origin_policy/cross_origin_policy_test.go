@@ -0,0 +1,189 @@
+package cross_origin_policy
+
+import (
+	"testing"
+)
+
+func TestParseOpenerPolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input    string
+		expected *OpenerPolicy
+	}{
+		{input: "same-origin", expected: &OpenerPolicy{Value: OpenerPolicySameOrigin}},
+		{input: "same-origin-allow-popups", expected: &OpenerPolicy{Value: OpenerPolicySameOriginAllowPopups}},
+		{input: `same-origin; report-to="coop"`, expected: &OpenerPolicy{Value: OpenerPolicySameOrigin, ReportTo: "coop"}},
+		{input: "unsafe-none;report-to=coop", expected: &OpenerPolicy{Value: OpenerPolicyUnsafeNone, ReportTo: "coop"}},
+		{input: "same-site"},
+		{input: `"same-origin"`},
+		{input: "same-origin,"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			t.Parallel()
+
+			openerPolicy, err := ParseOpenerPolicy([]byte(testCase.input))
+			if testCase.expected == nil {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", openerPolicy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse opener policy: %v", err)
+			}
+			if *openerPolicy != *testCase.expected {
+				t.Errorf("got %v, want %v", openerPolicy, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestParseEmbedderPolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input    string
+		expected *EmbedderPolicy
+	}{
+		{input: "require-corp", expected: &EmbedderPolicy{Value: EmbedderPolicyRequireCorp}},
+		{input: `credentialless; report-to="coep"`, expected: &EmbedderPolicy{Value: EmbedderPolicyCredentialless, ReportTo: "coep"}},
+		{input: "same-origin"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			t.Parallel()
+
+			embedderPolicy, err := ParseEmbedderPolicy([]byte(testCase.input))
+			if testCase.expected == nil {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", embedderPolicy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse embedder policy: %v", err)
+			}
+			if *embedderPolicy != *testCase.expected {
+				t.Errorf("got %v, want %v", embedderPolicy, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		value    interface{ String() string }
+		expected string
+	}{
+		{name: "opener policy", value: &OpenerPolicy{Value: OpenerPolicySameOrigin}, expected: "same-origin"},
+		{
+			name:     "opener policy with report-to",
+			value:    &OpenerPolicy{Value: OpenerPolicySameOriginAllowPopups, ReportTo: "coop"},
+			expected: `same-origin-allow-popups;report-to="coop"`,
+		},
+		{name: "embedder policy", value: &EmbedderPolicy{Value: EmbedderPolicyRequireCorp}, expected: "require-corp"},
+		{name: "not a token", value: &EmbedderPolicy{Value: "require corp"}, expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testCase.value.String(); got != testCase.expected {
+				t.Errorf("got %q, want %q", got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestParseResourcePolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input    string
+		expected ResourcePolicy
+	}{
+		{input: "same-origin", expected: ResourcePolicySameOrigin},
+		{input: " same-site ", expected: ResourcePolicySameSite},
+		{input: "cross-origin", expected: ResourcePolicyCrossOrigin},
+		{input: "Same-Origin"},
+		{input: "same-origin, cross-origin"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			t.Parallel()
+
+			resourcePolicy, err := ParseResourcePolicy([]byte(testCase.input))
+			if testCase.expected == "" {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", resourcePolicy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse resource policy: %v", err)
+			}
+			if resourcePolicy != testCase.expected {
+				t.Errorf("got %q, want %q", resourcePolicy, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestReportBodyMessages(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		body     interface{ Message() string }
+		expected string
+	}{
+		{
+			name: "opener policy, access",
+			body: &OpenerPolicyViolationReportBody{
+				Type:            "access-from-coop-page-to-openee",
+				Disposition:     "reporting",
+				EffectivePolicy: OpenerPolicySameOrigin,
+				Property:        "postMessage",
+			},
+			expected: "The page's cross-origin opener policy (same-origin) would have severed access to the postMessage property of another window.",
+		},
+		{
+			name: "opener policy, navigation",
+			body: &OpenerPolicyViolationReportBody{
+				Type:            "navigation-to-response",
+				Disposition:     "enforce",
+				EffectivePolicy: OpenerPolicySameOrigin,
+			},
+			expected: "The page's cross-origin opener policy (same-origin) severed the page from the window it shared a browsing context group with, on a navigation.",
+		},
+		{
+			name: "embedder policy",
+			body: &EmbedderPolicyViolationReportBody{
+				Type:        "corp",
+				BlockedUrl:  "https://other.example/image.png",
+				Destination: "image",
+				Disposition: "enforce",
+			},
+			expected: "The page's cross-origin embedder policy blocked a image at https://other.example/image.png from being loaded because it does not permit being embedded.",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testCase.body.Message(); got != testCase.expected {
+				t.Errorf("Message() = %q, want %q", got, testCase.expected)
+			}
+		})
+	}
+}
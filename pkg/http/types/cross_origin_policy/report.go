@@ -0,0 +1,90 @@
+package cross_origin_policy
+
+import "fmt"
+
+const (
+	// OpenerPolicyReportType is the type of the reports a browser sends about what a
+	// Cross-Origin-Opener-Policy severed or would have severed.
+	OpenerPolicyReportType = "coop"
+	// EmbedderPolicyReportType is the type of the reports a browser sends about what a
+	// Cross-Origin-Embedder-Policy blocked or would have blocked.
+	EmbedderPolicyReportType = "coep"
+)
+
+// OpenerPolicyViolationReportBody is the report body for "coop" reports sent via the Reporting API.
+// Defined in the HTML standard, section 7.1.3.4. Which members are present depends on Type: a
+// navigation is reported with the URLs either side of it, an access between the two documents
+// with the property accessed and where from.
+type OpenerPolicyViolationReportBody struct {
+	// The body is written by the browser, which is free to say more about what it blocked than
+	// what is specified today. What it adds is not worth refusing the report over.
+	//nolint:revive // The blank field is what carries what holds for the object; it is read from the type.
+	_ struct{} `jsonschema:",additionalProperties:true"`
+
+	Type                string  `json:"type"`
+	Disposition         string  `json:"disposition,omitempty"`
+	EffectivePolicy     string  `json:"effectivePolicy,omitempty"`
+	PreviousResponseUrl *string `json:"previousResponseURL,omitempty" jsonschema:"previousResponseURL,optional,minlength:0"`
+	NextResponseUrl     *string `json:"nextResponseURL,omitempty" jsonschema:"nextResponseURL,optional,minlength:0"`
+	Referrer            *string `json:"referrer,omitempty" jsonschema:"referrer,optional,minlength:0"`
+	Property            string  `json:"property,omitempty"`
+	OpenerUrl           *string `json:"openerURL,omitempty" jsonschema:"openerURL,optional,minlength:0"`
+	OpeneeUrl           *string `json:"openeeURL,omitempty" jsonschema:"openeeURL,optional,minlength:0"`
+	OtherDocumentUrl    *string `json:"otherDocumentURL,omitempty" jsonschema:"otherDocumentURL,optional,minlength:0"`
+	InitialPopupUrl     *string `json:"initialPopupURL,omitempty" jsonschema:"initialPopupURL,optional,minlength:0"`
+	SourceFile          *string `json:"sourceFile,omitempty" jsonschema:"sourceFile,optional,minlength:0"`
+	LineNumber          *int    `json:"lineNumber,omitempty"`
+	ColumnNumber        *int    `json:"columnNumber,omitempty"`
+}
+
+func (body *OpenerPolicyViolationReportBody) Message() string {
+	verb := "severed"
+	if body.Disposition == "reporting" {
+		verb = "would have severed"
+	}
+
+	if body.Property != "" {
+		return fmt.Sprintf(
+			"The page's cross-origin opener policy (%s) %s access to the %s property of another window.",
+			body.EffectivePolicy,
+			verb,
+			body.Property,
+		)
+	}
+
+	return fmt.Sprintf(
+		"The page's cross-origin opener policy (%s) %s the page from the window it shared a browsing context group with, on a navigation.",
+		body.EffectivePolicy,
+		verb,
+	)
+}
+
+// EmbedderPolicyViolationReportBody is the report body for "coep" reports sent via the Reporting
+// API. Defined in the HTML standard, section 7.1.4.3.
+type EmbedderPolicyViolationReportBody struct {
+	// The body is written by the browser, which is free to say more about what it blocked than
+	// what is specified today. What it adds is not worth refusing the report over.
+	//nolint:revive // The blank field is what carries what holds for the object; it is read from the type.
+	_ struct{} `jsonschema:",additionalProperties:true"`
+
+	// Type is "corp" for a subresource, "navigation" for a frame, and "worker initialization" for a
+	// worker.
+	Type        string `json:"type"`
+	BlockedUrl  string `json:"blockedURL"`
+	Destination string `json:"destination,omitempty"`
+	Disposition string `json:"disposition,omitempty"`
+}
+
+func (body *EmbedderPolicyViolationReportBody) Message() string {
+	verb := "blocked"
+	if body.Disposition == "reporting" {
+		verb = "would have blocked"
+	}
+
+	return fmt.Sprintf(
+		"The page's cross-origin embedder policy %s a %s at %s from being loaded because it does not permit being embedded.",
+		verb,
+		body.Destination,
+		body.BlockedUrl,
+	)
+}
@@ -0,0 +1,195 @@
+// Package document_policy parses and serializes the Document-Policy header, a structured field
+// dictionary that configures, per feature, how a document behaves -- whether it may use something
+// at all, or within what bounds.
+package document_policy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/http/structured_field"
+)
+
+const (
+	HeaderName           = "Document-Policy"
+	ReportOnlyHeaderName = "Document-Policy-Report-Only"
+	// RequireHeaderName is what a document says of the policies the documents it frames are to
+	// have.
+	RequireHeaderName = "Require-Document-Policy"
+
+	reportToParameterKey = "report-to"
+)
+
+// Token is a value that is a token rather than a string, as the values of enumerated features are.
+type Token string
+
+type Directive struct {
+	Feature string
+	// Value configures the feature: a bool, an int64, a float64 or a Token, depending on the
+	// feature.
+	Value any
+	// ReportTo names the reporting endpoint the violations of the directive are reported to.
+	ReportTo string
+}
+
+func (directive *Directive) member() *structured_field.DictionaryMember {
+	value := directive.Value
+	if token, ok := value.(Token); ok {
+		value = structured_field.Token(token)
+	}
+
+	var parameters structured_field.Parameters
+	if reportTo := directive.ReportTo; reportTo != "" {
+		parameters = structured_field.Parameters{
+			{Key: reportToParameterKey, Value: structured_field.Token(reportTo)},
+		}
+	}
+
+	return &structured_field.DictionaryMember{
+		Key:   directive.Feature,
+		Value: &structured_field.Item{Value: value, Parameters: parameters},
+	}
+}
+
+type DocumentPolicy struct {
+	Directives []*Directive
+	// ReportTo names the reporting endpoint the violations of directives that name none are
+	// reported to. It is said with the "*" member.
+	ReportTo string
+}
+
+// Get returns the directive of the feature.
+func (policy *DocumentPolicy) Get(feature string) *Directive {
+	for _, directive := range policy.Directives {
+		if directive != nil && directive.Feature == feature {
+			return directive
+		}
+	}
+
+	return nil
+}
+
+// Set replaces the directive of the directive's feature, or adds it where there is none.
+func (policy *DocumentPolicy) Set(directive *Directive) {
+	if directive == nil {
+		return
+	}
+
+	for i, existingDirective := range policy.Directives {
+		if existingDirective != nil && existingDirective.Feature == directive.Feature {
+			policy.Directives[i] = directive
+			return
+		}
+	}
+
+	policy.Directives = append(policy.Directives, directive)
+}
+
+// Delete removes the directive of the feature, which leaves the feature to its default.
+func (policy *DocumentPolicy) Delete(feature string) {
+	policy.Directives = slices.DeleteFunc(policy.Directives, func(directive *Directive) bool {
+		return directive != nil && directive.Feature == feature
+	})
+}
+
+// String serializes the policy as a header value. A directive that cannot be serialized -- its
+// feature not being a structured field key, or its value not being of a type a feature takes -- is
+// left out, as a browser would have ignored it.
+func (policy *DocumentPolicy) String() string {
+	var members []string
+	for _, directive := range policy.Directives {
+		if directive == nil {
+			continue
+		}
+
+		value, err := structured_field.SerializeDictionary(structured_field.Dictionary{directive.member()})
+		if err != nil {
+			continue
+		}
+
+		members = append(members, value)
+	}
+
+	if reportTo := policy.ReportTo; reportTo != "" {
+		value, err := structured_field.SerializeDictionary(
+			structured_field.Dictionary{
+				{
+					Key: "*",
+					Value: &structured_field.Item{
+						Value: true,
+						Parameters: structured_field.Parameters{
+							{Key: reportToParameterKey, Value: structured_field.Token(reportTo)},
+						},
+					},
+				},
+			},
+		)
+		if err == nil {
+			members = append(members, value)
+		}
+	}
+
+	return strings.Join(members, ", ")
+}
+
+func reportToFromParameters(parameters structured_field.Parameters) string {
+	reportTo, ok := parameters.Get(reportToParameterKey)
+	if !ok {
+		return ""
+	}
+
+	switch typedReportTo := reportTo.(type) {
+	case structured_field.Token:
+		return string(typedReportTo)
+	case string:
+		return typedReportTo
+	}
+
+	return ""
+}
+
+// Parse parses a Document-Policy header value. A member that is an inner list configures no
+// feature, and is disregarded as a browser disregards it.
+func Parse(data []byte) (*DocumentPolicy, error) {
+	dictionary, err := structured_field.ParseDictionary(data)
+	if err != nil {
+		return nil, motmedelErrors.New(
+			fmt.Errorf("%w: parse dictionary: %w", motmedelErrors.ErrParseError, err),
+			data,
+		)
+	}
+
+	policy := &DocumentPolicy{Directives: make([]*Directive, 0, len(dictionary))}
+	for _, member := range dictionary {
+		if member == nil {
+			continue
+		}
+
+		item, ok := member.Value.(*structured_field.Item)
+		if !ok || item == nil {
+			continue
+		}
+
+		if member.Key == "*" {
+			policy.ReportTo = reportToFromParameters(item.Parameters)
+			continue
+		}
+
+		value := item.Value
+		switch typedValue := value.(type) {
+		case structured_field.Token:
+			value = Token(typedValue)
+		case string, []byte:
+			continue
+		}
+
+		policy.Directives = append(
+			policy.Directives,
+			&Directive{Feature: member.Key, Value: value, ReportTo: reportToFromParameters(item.Parameters)},
+		)
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,171 @@
+package document_policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected *DocumentPolicy
+	}{
+		{
+			name:  "boolean features",
+			input: "force-load-at-top, sync-xhr=?0",
+			expected: &DocumentPolicy{Directives: []*Directive{
+				{Feature: "force-load-at-top", Value: true},
+				{Feature: "sync-xhr", Value: false},
+			}},
+		},
+		{
+			name:  "numbers and tokens",
+			input: "oversized-images=2.0, max-frames=4, image-format=webp",
+			expected: &DocumentPolicy{Directives: []*Directive{
+				{Feature: "oversized-images", Value: 2.0},
+				{Feature: "max-frames", Value: int64(4)},
+				{Feature: "image-format", Value: Token("webp")},
+			}},
+		},
+		{
+			name:  "report-to",
+			input: "sync-xhr=?0;report-to=main, *;report-to=fallback",
+			expected: &DocumentPolicy{
+				Directives: []*Directive{{Feature: "sync-xhr", Value: false, ReportTo: "main"}},
+				ReportTo:   "fallback",
+			},
+		},
+		{
+			name:  "members configuring nothing",
+			input: `sync-xhr=?0, listed=(a b), quoted="a"`,
+			expected: &DocumentPolicy{Directives: []*Directive{
+				{Feature: "sync-xhr", Value: false},
+			}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := Parse([]byte(testCase.input))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !reflect.DeepEqual(policy, testCase.expected) {
+				t.Errorf("got %q, want %q", policy.String(), testCase.expected.String())
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Parse([]byte("sync-xhr=?2")); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		policy   *DocumentPolicy
+		expected string
+	}{
+		{
+			name:     "empty",
+			policy:   &DocumentPolicy{},
+			expected: "",
+		},
+		{
+			name: "directives",
+			policy: &DocumentPolicy{
+				Directives: []*Directive{
+					{Feature: "force-load-at-top", Value: true},
+					{Feature: "sync-xhr", Value: false, ReportTo: "main"},
+					{Feature: "oversized-images", Value: 2.0},
+					{Feature: "image-format", Value: Token("webp")},
+				},
+				ReportTo: "fallback",
+			},
+			expected: "force-load-at-top, sync-xhr=?0;report-to=main, oversized-images=2.0, image-format=webp, *;report-to=fallback",
+		},
+		{
+			name: "a directive that cannot be serialized",
+			policy: &DocumentPolicy{Directives: []*Directive{
+				{Feature: "sync-xhr", Value: struct{}{}},
+				{Feature: "force-load-at-top", Value: true},
+			}},
+			expected: "force-load-at-top",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testCase.policy.String(); got != testCase.expected {
+				t.Errorf("got %q, want %q", got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestSetAndDelete(t *testing.T) {
+	t.Parallel()
+
+	policy := &DocumentPolicy{}
+	policy.Set(&Directive{Feature: "sync-xhr", Value: true})
+	policy.Set(&Directive{Feature: "sync-xhr", Value: false})
+	policy.Set(&Directive{Feature: "force-load-at-top", Value: true})
+	if got, expected := policy.String(), "sync-xhr=?0, force-load-at-top"; got != expected {
+		t.Errorf("set: got %q, want %q", got, expected)
+	}
+
+	policy.Delete("sync-xhr")
+	if policy.Get("sync-xhr") != nil {
+		t.Error("expected the deleted directive to be gone")
+	}
+}
+
+func TestDocumentPolicyViolationReportBodyMessage(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		body     *DocumentPolicyViolationReportBody
+		expected string
+	}{
+		{
+			name:     "enforced",
+			body:     &DocumentPolicyViolationReportBody{FeatureId: "sync-xhr", Disposition: "enforce"},
+			expected: "The page's document policy blocked the use of sync-xhr.",
+		},
+		{
+			name:     "report only",
+			body:     &DocumentPolicyViolationReportBody{FeatureId: "sync-xhr", Disposition: "report"},
+			expected: "The page's document policy would have blocked the use of sync-xhr.",
+		},
+		{
+			name:     "console message",
+			body:     &DocumentPolicyViolationReportBody{FeatureId: "sync-xhr", ConsoleMessage: "Synchronous requests are disallowed."},
+			expected: "Synchronous requests are disallowed.",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testCase.body.Message(); got != testCase.expected {
+				t.Errorf("Message() = %q, want %q", got, testCase.expected)
+			}
+		})
+	}
+}
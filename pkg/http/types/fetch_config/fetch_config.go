@@ -1,6 +1,7 @@
 package fetch_config
 
 import (
+	"io"
 	"maps"
 	"net/http"
 
@@ -14,9 +15,17 @@ const (
 )
 
 type Config struct {
-	Method               string
-	Headers              map[string]string
-	Body                 []byte
+	Method  string
+	Headers map[string]string
+	Body    []byte
+	// BodyReader, when set, is streamed as the request body in place of Body. GetBody returns a new
+	// reader of the same body for a retry; without it, a request with a reader http.NewRequest cannot
+	// replay is not retried.
+	BodyReader io.Reader
+	GetBody    func() (io.ReadCloser, error)
+	// MaxResponseBytes, when positive, is the size of the largest response body that is read; a
+	// larger one fails the fetch with a *ResponseTooLargeError.
+	MaxResponseBytes     int64
 	SkipReadResponseBody bool
 	SkipErrorOnStatus    bool
 	RetryConfig          *retry_config.Config
@@ -63,6 +72,21 @@ func WithBody(body []byte) Option {
 	}
 }
 
+// WithBodyReader streams the reader as the request body; getBody, which may be nil, replays it for
+// retries.
+func WithBodyReader(bodyReader io.Reader, getBody func() (io.ReadCloser, error)) Option {
+	return func(configuration *Config) {
+		configuration.BodyReader = bodyReader
+		configuration.GetBody = getBody
+	}
+}
+
+func WithMaxResponseBytes(maxResponseBytes int64) Option {
+	return func(configuration *Config) {
+		configuration.MaxResponseBytes = maxResponseBytes
+	}
+}
+
 func WithSkipReadResponseBody(skipReadResponseBody bool) Option {
 	return func(configuration *Config) {
 		configuration.SkipReadResponseBody = skipReadResponseBody
@@ -86,3 +110,17 @@ func WithHttpClient(httpClient *http.Client) Option {
 		configuration.HttpClient = httpClient
 	}
 }
+
+// WithTransport makes the requests be sent with the transport, by a copy of the configuration's
+// client as it is when the option is applied. A client_cache.Transport, for one, has the responses
+// cached.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(configuration *Config) {
+		var httpClient http.Client
+		if configuration.HttpClient != nil {
+			httpClient = *configuration.HttpClient
+		}
+		httpClient.Transport = transport
+		configuration.HttpClient = &httpClient
+	}
+}
@@ -2,9 +2,11 @@ package fetch_config
 
 import (
 	"bytes"
+	"io"
 	"maps"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config"
 )
@@ -87,6 +89,25 @@ func TestNewOptions(t *testing.T) {
 	}
 }
 
+func TestNewStreamingOptions(t *testing.T) {
+	t.Parallel()
+
+	bodyReader := bytes.NewReader([]byte("payload"))
+	getBody := func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader([]byte("payload"))), nil }
+
+	config := New(WithBodyReader(bodyReader, getBody), WithMaxResponseBytes(1024))
+
+	if config.BodyReader != bodyReader {
+		t.Errorf("BodyReader = %v, want %v", config.BodyReader, bodyReader)
+	}
+	if config.GetBody == nil {
+		t.Error("GetBody is nil, want the provided func")
+	}
+	if config.MaxResponseBytes != 1024 {
+		t.Errorf("MaxResponseBytes = %d, want 1024", config.MaxResponseBytes)
+	}
+}
+
 func TestWithHeadersMerges(t *testing.T) {
 	t.Parallel()
 
@@ -151,3 +172,24 @@ func TestWithHeadersDoesNotAliasInput(t *testing.T) {
 		t.Errorf("Headers = %v, want 2 entries", config.Headers)
 	}
 }
+
+func TestWithTransport(t *testing.T) {
+	t.Parallel()
+
+	transport := &http.Transport{}
+	httpClient := &http.Client{Timeout: time.Second}
+
+	config := New(WithHttpClient(httpClient), WithTransport(transport))
+	if config.HttpClient == httpClient {
+		t.Fatal("expected the client to be copied")
+	}
+	if config.HttpClient.Transport != transport {
+		t.Errorf("Transport = %v, want %v", config.HttpClient.Transport, transport)
+	}
+	if config.HttpClient.Timeout != time.Second {
+		t.Errorf("Timeout = %v, want %v", config.HttpClient.Timeout, time.Second)
+	}
+	if httpClient.Transport != nil {
+		t.Errorf("expected the original client to be left as it is, got %v", httpClient.Transport)
+	}
+}
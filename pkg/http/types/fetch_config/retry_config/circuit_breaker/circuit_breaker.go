@@ -0,0 +1,234 @@
+package circuit_breaker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	motmedelHttpErrors "github.com/Motmedel/utils_go/pkg/http/errors"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/circuit_breaker/circuit_breaker_config"
+)
+
+// StateChangedMessage is the message a change of the state of a circuit is logged under.
+const StateChangedMessage = "The state of a circuit changed."
+
+// DoneFunc records the outcome of a request that a breaker let through.
+type DoneFunc func(failed bool)
+
+type bucket struct {
+	slot      int64
+	successes int
+	failures  int
+}
+
+type circuit struct {
+	state    circuit_breaker_config.State
+	openedAt time.Time
+	// generation changes with every change of state, so that the outcome of a request let through
+	// in an earlier state is not counted in a later one.
+	generation        uint64
+	buckets           []bucket
+	halfOpenInFlight  int
+	halfOpenSuccesses int
+}
+
+type stateChange struct {
+	host string
+	from circuit_breaker_config.State
+	to   circuit_breaker_config.State
+}
+
+// Breaker keeps a circuit per host: a closed circuit opens when the rate of failures in a sliding
+// window reaches a threshold, an open one fails requests fast with ErrCircuitOpen, and after a
+// while it is half-open and lets a few probes through, closing if they succeed and opening again
+// if one fails. A breaker is meant to be shared by all calls to the hosts it guards.
+type Breaker struct {
+	config   *circuit_breaker_config.Config
+	mutex    sync.Mutex
+	circuits map[string]*circuit
+}
+
+func (breaker *Breaker) bucketWidth() time.Duration {
+	width := breaker.config.Window / time.Duration(breaker.config.Buckets)
+	if width <= 0 {
+		width = 1
+	}
+
+	return width
+}
+
+// setState must be called with the lock held.
+func (breaker *Breaker) setState(
+	host string,
+	c *circuit,
+	state circuit_breaker_config.State,
+	now time.Time,
+	changes []stateChange,
+) []stateChange {
+	changes = append(changes, stateChange{host: host, from: c.state, to: state})
+
+	c.state = state
+	c.generation++
+	c.halfOpenInFlight = 0
+	c.halfOpenSuccesses = 0
+	if state == circuit_breaker_config.StateOpen {
+		c.openedAt = now
+	}
+	if state == circuit_breaker_config.StateClosed {
+		clear(c.buckets)
+	}
+
+	return changes
+}
+
+func (breaker *Breaker) notify(ctx context.Context, changes []stateChange) {
+	for _, change := range changes {
+		level := slog.LevelInfo
+		if change.to == circuit_breaker_config.StateOpen {
+			level = slog.LevelWarn
+		}
+
+		slog.Log(
+			ctx,
+			level,
+			StateChangedMessage,
+			slog.Group(
+				"event",
+				slog.String("reason", StateChangedMessage),
+			),
+			slog.Group(
+				"circuit_breaker",
+				slog.String("host", change.host),
+				slog.String("from", change.from.String()),
+				slog.String("to", change.to.String()),
+			),
+		)
+
+		if onStateChange := breaker.config.OnStateChange; onStateChange != nil {
+			onStateChange(change.host, change.from, change.to)
+		}
+	}
+}
+
+// record must be called with the lock held.
+func (breaker *Breaker) record(
+	host string,
+	c *circuit,
+	generation uint64,
+	failed bool,
+	now time.Time,
+) []stateChange {
+	if generation != c.generation {
+		return nil
+	}
+
+	switch c.state {
+	case circuit_breaker_config.StateHalfOpen:
+		c.halfOpenInFlight--
+		if failed {
+			return breaker.setState(host, c, circuit_breaker_config.StateOpen, now, nil)
+		}
+
+		c.halfOpenSuccesses++
+		if c.halfOpenSuccesses >= breaker.config.HalfOpenProbes {
+			return breaker.setState(host, c, circuit_breaker_config.StateClosed, now, nil)
+		}
+	case circuit_breaker_config.StateClosed:
+		slot := now.UnixNano() / int64(breaker.bucketWidth())
+		b := &c.buckets[int(slot%int64(len(c.buckets)))]
+		if b.slot != slot {
+			*b = bucket{slot: slot}
+		}
+		if failed {
+			b.failures++
+		} else {
+			b.successes++
+		}
+
+		var successes, failures int
+		oldestSlot := slot - int64(len(c.buckets)) + 1
+		for _, windowBucket := range c.buckets {
+			if windowBucket.slot >= oldestSlot {
+				successes += windowBucket.successes
+				failures += windowBucket.failures
+			}
+		}
+
+		total := successes + failures
+		if total > 0 && total >= breaker.config.MinimumRequests &&
+			float64(failures)/float64(total) >= breaker.config.FailureRateThreshold {
+			return breaker.setState(host, c, circuit_breaker_config.StateOpen, now, nil)
+		}
+	}
+
+	return nil
+}
+
+// Allow returns whether a request to the host may be made. If it may, the returned function must be
+// called with the outcome of the request; if it may not, the error wraps ErrCircuitOpen.
+func (breaker *Breaker) Allow(ctx context.Context, host string) (DoneFunc, error) {
+	var changes []stateChange
+	defer func() {
+		breaker.notify(ctx, changes)
+	}()
+
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	now := breaker.config.Now()
+
+	c, ok := breaker.circuits[host]
+	if !ok {
+		c = &circuit{buckets: make([]bucket, breaker.config.Buckets)}
+		breaker.circuits[host] = c
+	}
+
+	if c.state == circuit_breaker_config.StateOpen && now.Sub(c.openedAt) >= breaker.config.OpenDuration {
+		changes = breaker.setState(host, c, circuit_breaker_config.StateHalfOpen, now, changes)
+	}
+
+	switch c.state {
+	case circuit_breaker_config.StateOpen:
+		return nil, fmt.Errorf("%w: host=%q", motmedelHttpErrors.ErrCircuitOpen, host)
+	case circuit_breaker_config.StateHalfOpen:
+		if c.halfOpenInFlight+c.halfOpenSuccesses >= breaker.config.HalfOpenProbes {
+			return nil, fmt.Errorf("%w: host=%q state=%s", motmedelHttpErrors.ErrCircuitOpen, host, c.state)
+		}
+		c.halfOpenInFlight++
+	}
+
+	generation := c.generation
+	var once sync.Once
+
+	return func(failed bool) {
+		once.Do(func() {
+			breaker.mutex.Lock()
+			doneChanges := breaker.record(host, c, generation, failed, breaker.config.Now())
+			breaker.mutex.Unlock()
+
+			breaker.notify(ctx, doneChanges)
+		})
+	}, nil
+}
+
+// State returns the state of the circuit of the host.
+func (breaker *Breaker) State(host string) circuit_breaker_config.State {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	c, ok := breaker.circuits[host]
+	if !ok {
+		return circuit_breaker_config.StateClosed
+	}
+
+	return c.state
+}
+
+func New(options ...circuit_breaker_config.Option) *Breaker {
+	return &Breaker{
+		config:   circuit_breaker_config.New(options...),
+		circuits: make(map[string]*circuit),
+	}
+}
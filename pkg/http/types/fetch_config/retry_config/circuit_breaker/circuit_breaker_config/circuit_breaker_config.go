@@ -0,0 +1,140 @@
+package circuit_breaker_config
+
+import (
+	"time"
+)
+
+// State is the state of the circuit of a host.
+type State int
+
+const (
+	// StateClosed lets requests through while the failure rate is below the threshold.
+	StateClosed State = iota
+	// StateOpen fails requests fast until the open duration has passed.
+	StateOpen
+	// StateHalfOpen lets a limited number of probes through to learn whether the host has recovered.
+	StateHalfOpen
+)
+
+func (state State) String() string {
+	switch state {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// StateChangeFunc is called, without the breaker's lock held, when the circuit of a host changes
+// state.
+type StateChangeFunc func(host string, from State, to State)
+
+const (
+	DefaultWindow               = 30 * time.Second
+	DefaultBuckets              = 10
+	DefaultMinimumRequests      = 10
+	DefaultFailureRateThreshold = 0.5
+	DefaultOpenDuration         = 30 * time.Second
+	DefaultHalfOpenProbes       = 1
+)
+
+type Config struct {
+	// Window is the period the failure rate is computed over; it is divided into Buckets that
+	// expire one at a time.
+	Window  time.Duration
+	Buckets int
+	// MinimumRequests is the number of outcomes in the window below which the circuit does not open,
+	// however high the failure rate.
+	MinimumRequests int
+	// FailureRateThreshold is the fraction of failed outcomes in the window at which the circuit
+	// opens.
+	FailureRateThreshold float64
+	// OpenDuration is for how long an open circuit fails requests before it lets probes through.
+	OpenDuration time.Duration
+	// HalfOpenProbes is the number of probes let through at once when half-open, and the number of
+	// them that must succeed for the circuit to close.
+	HalfOpenProbes int
+	OnStateChange  StateChangeFunc
+	Now            func() time.Time
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		Window:               DefaultWindow,
+		Buckets:              DefaultBuckets,
+		MinimumRequests:      DefaultMinimumRequests,
+		FailureRateThreshold: DefaultFailureRateThreshold,
+		OpenDuration:         DefaultOpenDuration,
+		HalfOpenProbes:       DefaultHalfOpenProbes,
+		Now:                  time.Now,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(config)
+		}
+	}
+
+	if config.Buckets < 1 {
+		config.Buckets = 1
+	}
+
+	if config.HalfOpenProbes < 1 {
+		config.HalfOpenProbes = 1
+	}
+
+	return config
+}
+
+func WithWindow(window time.Duration) Option {
+	return func(config *Config) {
+		config.Window = window
+	}
+}
+
+func WithBuckets(buckets int) Option {
+	return func(config *Config) {
+		config.Buckets = buckets
+	}
+}
+
+func WithMinimumRequests(minimumRequests int) Option {
+	return func(config *Config) {
+		config.MinimumRequests = minimumRequests
+	}
+}
+
+func WithFailureRateThreshold(failureRateThreshold float64) Option {
+	return func(config *Config) {
+		config.FailureRateThreshold = failureRateThreshold
+	}
+}
+
+func WithOpenDuration(openDuration time.Duration) Option {
+	return func(config *Config) {
+		config.OpenDuration = openDuration
+	}
+}
+
+func WithHalfOpenProbes(halfOpenProbes int) Option {
+	return func(config *Config) {
+		config.HalfOpenProbes = halfOpenProbes
+	}
+}
+
+func WithOnStateChange(onStateChange StateChangeFunc) Option {
+	return func(config *Config) {
+		config.OnStateChange = onStateChange
+	}
+}
+
+func WithNow(now func() time.Time) Option {
+	return func(config *Config) {
+		config.Now = now
+	}
+}
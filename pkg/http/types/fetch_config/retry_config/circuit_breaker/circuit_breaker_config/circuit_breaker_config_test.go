@@ -0,0 +1,107 @@
+package circuit_breaker_config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.Window != DefaultWindow || config.Buckets != DefaultBuckets {
+		t.Errorf("window: got %v, %d", config.Window, config.Buckets)
+	}
+	if config.MinimumRequests != DefaultMinimumRequests {
+		t.Errorf("minimum requests: got %d", config.MinimumRequests)
+	}
+	if config.FailureRateThreshold != DefaultFailureRateThreshold {
+		t.Errorf("failure rate threshold: got %v", config.FailureRateThreshold)
+	}
+	if config.OpenDuration != DefaultOpenDuration {
+		t.Errorf("open duration: got %v", config.OpenDuration)
+	}
+	if config.HalfOpenProbes != DefaultHalfOpenProbes {
+		t.Errorf("half-open probes: got %d", config.HalfOpenProbes)
+	}
+	if config.OnStateChange != nil {
+		t.Error("expected no state change function")
+	}
+	if config.Now == nil {
+		t.Error("expected a default now function")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1, 0)
+	var called bool
+
+	config := New(
+		nil,
+		WithWindow(time.Minute),
+		WithBuckets(6),
+		WithMinimumRequests(3),
+		WithFailureRateThreshold(0.25),
+		WithOpenDuration(time.Second),
+		WithHalfOpenProbes(2),
+		WithOnStateChange(func(string, State, State) { called = true }),
+		WithNow(func() time.Time { return now }),
+	)
+
+	if config.Window != time.Minute || config.Buckets != 6 {
+		t.Errorf("window: got %v, %d", config.Window, config.Buckets)
+	}
+	if config.MinimumRequests != 3 {
+		t.Errorf("minimum requests: got %d", config.MinimumRequests)
+	}
+	if config.FailureRateThreshold != 0.25 {
+		t.Errorf("failure rate threshold: got %v", config.FailureRateThreshold)
+	}
+	if config.OpenDuration != time.Second {
+		t.Errorf("open duration: got %v", config.OpenDuration)
+	}
+	if config.HalfOpenProbes != 2 {
+		t.Errorf("half-open probes: got %d", config.HalfOpenProbes)
+	}
+	if config.OnStateChange("", StateClosed, StateOpen); !called {
+		t.Error("expected the provided state change function")
+	}
+	if !config.Now().Equal(now) {
+		t.Errorf("now: got %v", config.Now())
+	}
+}
+
+func TestNewClampsCounts(t *testing.T) {
+	t.Parallel()
+
+	config := New(WithBuckets(0), WithHalfOpenProbes(-1))
+	if config.Buckets != 1 || config.HalfOpenProbes != 1 {
+		t.Errorf("got %d buckets and %d probes, want 1 and 1", config.Buckets, config.HalfOpenProbes)
+	}
+}
+
+func TestStateString(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		state State
+		want  string
+	}{
+		{state: StateClosed, want: "closed"},
+		{state: StateOpen, want: "open"},
+		{state: StateHalfOpen, want: "half-open"},
+		{state: State(42), want: "unknown"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.want, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testCase.state.String(); got != testCase.want {
+				t.Errorf("got %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package circuit_breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	motmedelHttpErrors "github.com/Motmedel/utils_go/pkg/http/errors"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/circuit_breaker/circuit_breaker_config"
+)
+
+type clock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(duration time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(duration)
+}
+
+type transition struct {
+	host string
+	from circuit_breaker_config.State
+	to   circuit_breaker_config.State
+}
+
+func newBreaker(t *testing.T, options ...circuit_breaker_config.Option) (*Breaker, *clock, *[]transition) {
+	t.Helper()
+
+	c := &clock{now: time.Unix(1_700_000_000, 0)}
+	var mutex sync.Mutex
+	transitions := &[]transition{}
+
+	options = append(
+		[]circuit_breaker_config.Option{
+			circuit_breaker_config.WithNow(c.Now),
+			circuit_breaker_config.WithOnStateChange(
+				func(host string, from circuit_breaker_config.State, to circuit_breaker_config.State) {
+					mutex.Lock()
+					defer mutex.Unlock()
+					*transitions = append(*transitions, transition{host: host, from: from, to: to})
+				},
+			),
+		},
+		options...,
+	)
+
+	return New(options...), c, transitions
+}
+
+func attempt(t *testing.T, breaker *Breaker, host string, failed bool) error {
+	t.Helper()
+
+	done, err := breaker.Allow(context.Background(), host)
+	if err != nil {
+		return err
+	}
+	done(failed)
+
+	return nil
+}
+
+func TestBreakerOpensOnFailureRate(t *testing.T) {
+	t.Parallel()
+
+	breaker, _, transitions := newBreaker(
+		t,
+		circuit_breaker_config.WithMinimumRequests(4),
+		circuit_breaker_config.WithFailureRateThreshold(0.5),
+	)
+
+	// Below the minimum number of requests, failures do not open the circuit.
+	for _, failed := range []bool{true, true, false} {
+		if err := attempt(t, breaker, "a.example", failed); err != nil {
+			t.Fatalf("attempt: %v", err)
+		}
+	}
+	if state := breaker.State("a.example"); state != circuit_breaker_config.StateClosed {
+		t.Fatalf("state: got %s, want closed", state)
+	}
+
+	if err := attempt(t, breaker, "a.example", true); err != nil {
+		t.Fatalf("attempt: %v", err)
+	}
+	if state := breaker.State("a.example"); state != circuit_breaker_config.StateOpen {
+		t.Fatalf("state: got %s, want open", state)
+	}
+
+	err := attempt(t, breaker, "a.example", false)
+	if !errors.Is(err, motmedelHttpErrors.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	// The circuits of other hosts are independent.
+	if err := attempt(t, breaker, "b.example", false); err != nil {
+		t.Fatalf("attempt on another host: %v", err)
+	}
+
+	want := []transition{{host: "a.example", from: circuit_breaker_config.StateClosed, to: circuit_breaker_config.StateOpen}}
+	if len(*transitions) != 1 || (*transitions)[0] != want[0] {
+		t.Fatalf("transitions: got %v, want %v", *transitions, want)
+	}
+}
+
+func TestBreakerWindowExpires(t *testing.T) {
+	t.Parallel()
+
+	breaker, c, _ := newBreaker(
+		t,
+		circuit_breaker_config.WithWindow(10*time.Second),
+		circuit_breaker_config.WithBuckets(10),
+		circuit_breaker_config.WithMinimumRequests(2),
+	)
+
+	if err := attempt(t, breaker, "a.example", true); err != nil {
+		t.Fatalf("attempt: %v", err)
+	}
+
+	// The first failure has left the window by the time of the second.
+	c.Advance(11 * time.Second)
+
+	if err := attempt(t, breaker, "a.example", true); err != nil {
+		t.Fatalf("attempt: %v", err)
+	}
+	if state := breaker.State("a.example"); state != circuit_breaker_config.StateClosed {
+		t.Fatalf("state: got %s, want closed", state)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		probeFailed bool
+		wantState   circuit_breaker_config.State
+	}{
+		{name: "Probe succeeds", probeFailed: false, wantState: circuit_breaker_config.StateClosed},
+		{name: "Probe fails", probeFailed: true, wantState: circuit_breaker_config.StateOpen},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			breaker, c, transitions := newBreaker(
+				t,
+				circuit_breaker_config.WithMinimumRequests(1),
+				circuit_breaker_config.WithOpenDuration(time.Minute),
+			)
+
+			if err := attempt(t, breaker, "a.example", true); err != nil {
+				t.Fatalf("attempt: %v", err)
+			}
+
+			c.Advance(time.Minute)
+
+			done, err := breaker.Allow(context.Background(), "a.example")
+			if err != nil {
+				t.Fatalf("allow probe: %v", err)
+			}
+
+			// Only one probe is let through at a time.
+			if _, err := breaker.Allow(context.Background(), "a.example"); !errors.Is(err, motmedelHttpErrors.ErrCircuitOpen) {
+				t.Fatalf("expected a second probe to be refused, got %v", err)
+			}
+
+			done(testCase.probeFailed)
+			// Recording an outcome twice has no effect.
+			done(!testCase.probeFailed)
+
+			if state := breaker.State("a.example"); state != testCase.wantState {
+				t.Fatalf("state: got %s, want %s", state, testCase.wantState)
+			}
+
+			last := (*transitions)[len(*transitions)-1]
+			if last.from != circuit_breaker_config.StateHalfOpen || last.to != testCase.wantState {
+				t.Fatalf("last transition: got %+v", last)
+			}
+		})
+	}
+}
+
+func TestBreakerIgnoresOutcomesOfEarlierStates(t *testing.T) {
+	t.Parallel()
+
+	breaker, _, _ := newBreaker(t, circuit_breaker_config.WithMinimumRequests(1))
+
+	done, err := breaker.Allow(context.Background(), "a.example")
+	if err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+
+	if err := attempt(t, breaker, "a.example", true); err != nil {
+		t.Fatalf("attempt: %v", err)
+	}
+
+	// The request was let through while closed; its success does not count once the circuit opened.
+	done(false)
+
+	if state := breaker.State("a.example"); state != circuit_breaker_config.StateOpen {
+		t.Fatalf("state: got %s, want open", state)
+	}
+}
@@ -0,0 +1,78 @@
+package hedger
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/hedger/hedger_config"
+)
+
+// Hedger decides when an idempotent request that has not been answered yet is sent again, racing
+// the original, from a percentile of the latencies it has observed.
+type Hedger struct {
+	config  *hedger_config.Config
+	mutex   sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// Observe records the latency of a completed request.
+func (hedger *Hedger) Observe(latency time.Duration) {
+	hedger.mutex.Lock()
+	defer hedger.mutex.Unlock()
+
+	if len(hedger.samples) < hedger.config.WindowSize {
+		hedger.samples = append(hedger.samples, latency)
+		return
+	}
+
+	hedger.samples[hedger.next] = latency
+	hedger.next = (hedger.next + 1) % len(hedger.samples)
+}
+
+// Delay returns after how long a hedged request is to be sent, or false when too few latencies have
+// been observed to tell.
+func (hedger *Hedger) Delay() (time.Duration, bool) {
+	hedger.mutex.Lock()
+	samples := slices.Clone(hedger.samples)
+	hedger.mutex.Unlock()
+
+	if len(samples) == 0 || len(samples) < hedger.config.MinimumSamples {
+		return 0, false
+	}
+
+	slices.Sort(samples)
+
+	rank := int(math.Ceil(hedger.config.Percentile*float64(len(samples)))) - 1
+	rank = max(0, min(rank, len(samples)-1))
+
+	return max(samples[rank], hedger.config.MinimumDelay), true
+}
+
+// MaxHedges returns the largest number of hedged requests sent in addition to the original one.
+func (hedger *Hedger) MaxHedges() int {
+	return hedger.config.MaxHedges
+}
+
+// IsHedgeable returns whether a request may be hedged: it must be a GET or a HEAD without a body, so
+// that sending it more than once is safe.
+func IsHedgeable(request *http.Request) bool {
+	if request == nil {
+		return false
+	}
+
+	switch request.Method {
+	case "", http.MethodGet, http.MethodHead:
+	default:
+		return false
+	}
+
+	return request.Body == nil || request.Body == http.NoBody
+}
+
+func New(options ...hedger_config.Option) *Hedger {
+	return &Hedger{config: hedger_config.New(options...)}
+}
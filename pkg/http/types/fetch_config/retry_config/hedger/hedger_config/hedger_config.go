@@ -0,0 +1,78 @@
+package hedger_config
+
+import (
+	"time"
+)
+
+const (
+	DefaultPercentile     = 0.95
+	DefaultWindowSize     = 128
+	DefaultMinimumSamples = 16
+	DefaultMaxHedges      = 1
+)
+
+type Config struct {
+	// Percentile is the percentile of the latencies observed after which a hedged request is sent.
+	Percentile float64
+	// WindowSize is the number of the most recent latencies the percentile is computed over.
+	WindowSize int
+	// MinimumSamples is the number of latencies that must have been observed before requests are
+	// hedged.
+	MinimumSamples int
+	// MinimumDelay is the shortest time after which a hedged request is sent.
+	MinimumDelay time.Duration
+	// MaxHedges is the largest number of hedged requests sent in addition to the original one.
+	MaxHedges int
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		Percentile:     DefaultPercentile,
+		WindowSize:     DefaultWindowSize,
+		MinimumSamples: DefaultMinimumSamples,
+		MaxHedges:      DefaultMaxHedges,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(config)
+		}
+	}
+
+	if config.WindowSize < 1 {
+		config.WindowSize = 1
+	}
+
+	return config
+}
+
+func WithPercentile(percentile float64) Option {
+	return func(config *Config) {
+		config.Percentile = percentile
+	}
+}
+
+func WithWindowSize(windowSize int) Option {
+	return func(config *Config) {
+		config.WindowSize = windowSize
+	}
+}
+
+func WithMinimumSamples(minimumSamples int) Option {
+	return func(config *Config) {
+		config.MinimumSamples = minimumSamples
+	}
+}
+
+func WithMinimumDelay(minimumDelay time.Duration) Option {
+	return func(config *Config) {
+		config.MinimumDelay = minimumDelay
+	}
+}
+
+func WithMaxHedges(maxHedges int) Option {
+	return func(config *Config) {
+		config.MaxHedges = maxHedges
+	}
+}
@@ -0,0 +1,54 @@
+package hedger_config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.Percentile != DefaultPercentile {
+		t.Errorf("percentile: got %v", config.Percentile)
+	}
+	if config.WindowSize != DefaultWindowSize || config.MinimumSamples != DefaultMinimumSamples {
+		t.Errorf("samples: got %d, %d", config.WindowSize, config.MinimumSamples)
+	}
+	if config.MinimumDelay != 0 {
+		t.Errorf("minimum delay: got %v", config.MinimumDelay)
+	}
+	if config.MaxHedges != DefaultMaxHedges {
+		t.Errorf("max hedges: got %d", config.MaxHedges)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	config := New(
+		nil,
+		WithPercentile(0.99),
+		WithWindowSize(10),
+		WithMinimumSamples(5),
+		WithMinimumDelay(time.Millisecond),
+		WithMaxHedges(2),
+	)
+
+	if config.Percentile != 0.99 {
+		t.Errorf("percentile: got %v", config.Percentile)
+	}
+	if config.WindowSize != 10 || config.MinimumSamples != 5 {
+		t.Errorf("samples: got %d, %d", config.WindowSize, config.MinimumSamples)
+	}
+	if config.MinimumDelay != time.Millisecond {
+		t.Errorf("minimum delay: got %v", config.MinimumDelay)
+	}
+	if config.MaxHedges != 2 {
+		t.Errorf("max hedges: got %d", config.MaxHedges)
+	}
+
+	if config := New(WithWindowSize(0)); config.WindowSize != 1 {
+		t.Errorf("window size: got %d, want 1", config.WindowSize)
+	}
+}
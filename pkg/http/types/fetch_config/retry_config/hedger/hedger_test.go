@@ -0,0 +1,90 @@
+package hedger
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/hedger/hedger_config"
+)
+
+func TestHedgerDelay(t *testing.T) {
+	t.Parallel()
+
+	hedger := New(
+		hedger_config.WithPercentile(0.9),
+		hedger_config.WithWindowSize(10),
+		hedger_config.WithMinimumSamples(5),
+	)
+
+	for i := range 4 {
+		hedger.Observe(time.Duration(i+1) * time.Millisecond)
+	}
+	if _, ok := hedger.Delay(); ok {
+		t.Fatal("expected no delay below the minimum number of samples")
+	}
+
+	for i := 4; i < 10; i++ {
+		hedger.Observe(time.Duration(i+1) * time.Millisecond)
+	}
+	if delay, ok := hedger.Delay(); !ok || delay != 9*time.Millisecond {
+		t.Fatalf("delay: got %v, %v, want 9ms", delay, ok)
+	}
+
+	// The window keeps the most recent samples only.
+	for range 10 {
+		hedger.Observe(time.Second)
+	}
+	if delay, _ := hedger.Delay(); delay != time.Second {
+		t.Fatalf("delay: got %v, want 1s", delay)
+	}
+}
+
+func TestHedgerMinimumDelay(t *testing.T) {
+	t.Parallel()
+
+	hedger := New(
+		hedger_config.WithMinimumSamples(1),
+		hedger_config.WithMinimumDelay(50*time.Millisecond),
+	)
+	hedger.Observe(time.Millisecond)
+
+	if delay, ok := hedger.Delay(); !ok || delay != 50*time.Millisecond {
+		t.Fatalf("delay: got %v, %v, want 50ms", delay, ok)
+	}
+}
+
+func TestIsHedgeable(t *testing.T) {
+	t.Parallel()
+
+	newRequest := func(method string, body string) *http.Request {
+		request, err := http.NewRequest(method, "http://example.com", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		return request
+	}
+
+	testCases := []struct {
+		name    string
+		request *http.Request
+		want    bool
+	}{
+		{name: "GET", request: newRequest(http.MethodGet, ""), want: true},
+		{name: "HEAD", request: newRequest(http.MethodHead, ""), want: true},
+		{name: "GET with a body", request: newRequest(http.MethodGet, "a"), want: false},
+		{name: "POST", request: newRequest(http.MethodPost, ""), want: false},
+		{name: "Nil", request: nil, want: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsHedgeable(testCase.request); got != testCase.want {
+				t.Errorf("got %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
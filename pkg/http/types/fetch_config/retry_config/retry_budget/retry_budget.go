@@ -0,0 +1,65 @@
+package retry_budget
+
+import (
+	"sync"
+)
+
+const (
+	DefaultMaxTokens  = 10
+	DefaultTokenRatio = 0.1
+)
+
+// Budget limits the retries of the calls that share it, the way retry throttling does in gRPC: it
+// holds up to a maximum of tokens, each failed attempt takes one, each successful one gives back a
+// fraction of one, and retries are made only while more than half of the maximum is left. While a
+// dependency fails, retries thereby stop across all calls rather than multiplying the load on it.
+type Budget struct {
+	mutex      sync.Mutex
+	maxTokens  float64
+	tokenRatio float64
+	tokens     float64
+}
+
+// RecordSuccess records an attempt that did not need to be retried.
+func (budget *Budget) RecordSuccess() {
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	budget.tokens = min(budget.maxTokens, budget.tokens+budget.tokenRatio)
+}
+
+// RecordFailure records an attempt that needed to be retried.
+func (budget *Budget) RecordFailure() {
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	budget.tokens = max(0, budget.tokens-1)
+}
+
+// AllowRetry returns whether a retry may be made.
+func (budget *Budget) AllowRetry() bool {
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	return budget.tokens > budget.maxTokens/2
+}
+
+// Tokens returns the number of tokens left.
+func (budget *Budget) Tokens() float64 {
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	return budget.tokens
+}
+
+// New returns a full budget of maxTokens tokens, of which tokenRatio is given back per success.
+func New(maxTokens float64, tokenRatio float64) *Budget {
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+	if tokenRatio <= 0 {
+		tokenRatio = DefaultTokenRatio
+	}
+
+	return &Budget{maxTokens: maxTokens, tokenRatio: tokenRatio, tokens: maxTokens}
+}
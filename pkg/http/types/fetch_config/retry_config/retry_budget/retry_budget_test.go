@@ -0,0 +1,55 @@
+package retry_budget
+
+import (
+	"testing"
+)
+
+func TestBudget(t *testing.T) {
+	t.Parallel()
+
+	budget := New(4, 0.5)
+	if !budget.AllowRetry() {
+		t.Fatal("expected a full budget to allow retries")
+	}
+
+	budget.RecordFailure()
+	if !budget.AllowRetry() {
+		t.Fatal("expected 3 of 4 tokens to allow retries")
+	}
+
+	budget.RecordFailure()
+	if budget.AllowRetry() {
+		t.Fatal("expected 2 of 4 tokens to disallow retries")
+	}
+
+	budget.RecordSuccess()
+	if !budget.AllowRetry() {
+		t.Fatalf("expected %v of 4 tokens to allow retries", budget.Tokens())
+	}
+
+	for range 10 {
+		budget.RecordFailure()
+	}
+	if tokens := budget.Tokens(); tokens != 0 {
+		t.Fatalf("tokens: got %v, want 0", tokens)
+	}
+
+	for range 20 {
+		budget.RecordSuccess()
+	}
+	if tokens := budget.Tokens(); tokens != 4 {
+		t.Fatalf("tokens: got %v, want 4", tokens)
+	}
+}
+
+func TestNewDefaults(t *testing.T) {
+	t.Parallel()
+
+	budget := New(0, 0)
+	if budget.maxTokens != DefaultMaxTokens || budget.tokenRatio != DefaultTokenRatio {
+		t.Errorf("got %v, %v", budget.maxTokens, budget.tokenRatio)
+	}
+	if budget.Tokens() != DefaultMaxTokens {
+		t.Errorf("tokens: got %v", budget.Tokens())
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/circuit_breaker"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/hedger"
 	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/response_checker"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/retry_budget"
 )
 
 var DefaultResponseChecker = response_checker.New(
@@ -40,6 +43,13 @@ type Config struct {
 	MaximumWaitTime time.Duration
 	ResponseChecker response_checker.ResponseChecker
 	RetryAfterFunc  RetryAfterFunc
+	// CircuitBreaker, RetryBudget and Hedger are meant to be shared by the calls they govern. The
+	// breaker is consulted before every attempt and told its outcome, as judged by ResponseChecker;
+	// the budget is consulted before every retry; the hedger races idempotent requests that are slow
+	// to be answered.
+	CircuitBreaker *circuit_breaker.Breaker
+	RetryBudget    *retry_budget.Budget
+	Hedger         *hedger.Hedger
 }
 
 func New(options ...Option) *Config {
@@ -87,3 +97,21 @@ func WithRetryAfterFunc(retryAfterFunc RetryAfterFunc) Option {
 		configuration.RetryAfterFunc = retryAfterFunc
 	}
 }
+
+func WithCircuitBreaker(circuitBreaker *circuit_breaker.Breaker) Option {
+	return func(configuration *Config) {
+		configuration.CircuitBreaker = circuitBreaker
+	}
+}
+
+func WithRetryBudget(retryBudget *retry_budget.Budget) Option {
+	return func(configuration *Config) {
+		configuration.RetryBudget = retryBudget
+	}
+}
+
+func WithHedger(hedger *hedger.Hedger) Option {
+	return func(configuration *Config) {
+		configuration.Hedger = hedger
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/circuit_breaker"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/hedger"
 	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/response_checker"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/retry_budget"
 )
 
 func TestNewDefaults(t *testing.T) {
@@ -44,6 +47,9 @@ func TestNewOptions(t *testing.T) {
 
 	checker := response_checker.New(func(*http.Response, error) bool { return false })
 	retryAfter := func(*http.Response, []byte) *time.Duration { return nil }
+	circuitBreaker := circuit_breaker.New()
+	retryBudget := retry_budget.New(retry_budget.DefaultMaxTokens, retry_budget.DefaultTokenRatio)
+	requestHedger := hedger.New()
 
 	config := New(
 		WithCount(7),
@@ -51,6 +57,9 @@ func TestNewOptions(t *testing.T) {
 		WithMaximumWaitTime(10*time.Second),
 		WithResponseChecker(checker),
 		WithRetryAfterFunc(retryAfter),
+		WithCircuitBreaker(circuitBreaker),
+		WithRetryBudget(retryBudget),
+		WithHedger(requestHedger),
 	)
 
 	if config.Count != 7 {
@@ -68,6 +77,15 @@ func TestNewOptions(t *testing.T) {
 	if config.RetryAfterFunc == nil {
 		t.Error("RetryAfterFunc is nil, want the provided func")
 	}
+	if config.CircuitBreaker != circuitBreaker {
+		t.Error("CircuitBreaker is not the provided breaker")
+	}
+	if config.RetryBudget != retryBudget {
+		t.Error("RetryBudget is not the provided budget")
+	}
+	if config.Hedger != requestHedger {
+		t.Error("Hedger is not the provided hedger")
+	}
 }
 
 func TestDefaultResponseChecker(t *testing.T) {
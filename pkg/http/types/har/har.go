@@ -0,0 +1,159 @@
+package har
+
+import (
+	"time"
+)
+
+// Version is the version of the HTTP Archive format of the types.
+const Version = "1.2"
+
+// Har is an HTTP Archive, as specified by the HAR 1.2 specification.
+type Har struct {
+	Log *Log `json:"log"`
+}
+
+type Log struct {
+	Version string   `json:"version"`
+	Creator *Creator `json:"creator"`
+	Browser *Creator `json:"browser,omitzero"`
+	Pages   []*Page  `json:"pages,omitzero"`
+	Entries []*Entry `json:"entries"`
+	Comment string   `json:"comment,omitzero"`
+}
+
+// Creator describes the application that created the log, or, as the browser of a log, the browser
+// it was captured with.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Comment string `json:"comment,omitzero"`
+}
+
+type Page struct {
+	StartedDateTime time.Time    `json:"startedDateTime"`
+	Id              string       `json:"id"`
+	Title           string       `json:"title"`
+	PageTimings     *PageTimings `json:"pageTimings"`
+	Comment         string       `json:"comment,omitzero"`
+}
+
+// PageTimings are in milliseconds since the start of the page load; -1 means not applicable.
+type PageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad,omitzero"`
+	OnLoad        float64 `json:"onLoad,omitzero"`
+	Comment       string  `json:"comment,omitzero"`
+}
+
+type Entry struct {
+	Pageref         string    `json:"pageref,omitzero"`
+	StartedDateTime time.Time `json:"startedDateTime"`
+	// Time is the total time of the request in milliseconds.
+	Time            float64   `json:"time"`
+	Request         *Request  `json:"request"`
+	Response        *Response `json:"response"`
+	Cache           *Cache    `json:"cache"`
+	Timings         *Timings  `json:"timings"`
+	ServerIPAddress string    `json:"serverIPAddress,omitzero"`
+	Connection      string    `json:"connection,omitzero"`
+	Comment         string    `json:"comment,omitzero"`
+}
+
+type Request struct {
+	Method      string       `json:"method"`
+	Url         string       `json:"url"`
+	HttpVersion string       `json:"httpVersion"`
+	Cookies     []*Cookie    `json:"cookies"`
+	Headers     []*NameValue `json:"headers"`
+	QueryString []*NameValue `json:"queryString"`
+	PostData    *PostData    `json:"postData,omitzero"`
+	// HeadersSize and BodySize are in bytes; -1 means unknown.
+	HeadersSize int64  `json:"headersSize"`
+	BodySize    int64  `json:"bodySize"`
+	Comment     string `json:"comment,omitzero"`
+}
+
+type Response struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HttpVersion string       `json:"httpVersion"`
+	Cookies     []*Cookie    `json:"cookies"`
+	Headers     []*NameValue `json:"headers"`
+	Content     *Content     `json:"content"`
+	RedirectUrl string       `json:"redirectURL"`
+	// HeadersSize and BodySize are in bytes; -1 means unknown.
+	HeadersSize int64  `json:"headersSize"`
+	BodySize    int64  `json:"bodySize"`
+	Comment     string `json:"comment,omitzero"`
+}
+
+type Cookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Path     string     `json:"path,omitzero"`
+	Domain   string     `json:"domain,omitzero"`
+	Expires  *time.Time `json:"expires,omitzero"`
+	HttpOnly bool       `json:"httpOnly,omitzero"`
+	Secure   bool       `json:"secure,omitzero"`
+	Comment  string     `json:"comment,omitzero"`
+}
+
+// NameValue is a header or a query string parameter.
+type NameValue struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Comment string `json:"comment,omitzero"`
+}
+
+type PostData struct {
+	MimeType string   `json:"mimeType"`
+	Params   []*Param `json:"params,omitzero"`
+	Text     string   `json:"text"`
+	Comment  string   `json:"comment,omitzero"`
+}
+
+// Param is a parameter of a form that was posted.
+type Param struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitzero"`
+	FileName    string `json:"fileName,omitzero"`
+	ContentType string `json:"contentType,omitzero"`
+	Comment     string `json:"comment,omitzero"`
+}
+
+type Content struct {
+	// Size is that of the decoded body.
+	Size        int64  `json:"size"`
+	Compression int64  `json:"compression,omitzero"`
+	MimeType    string `json:"mimeType"`
+	Text        string `json:"text,omitzero"`
+	// Encoding is "base64" when Text is the base64 encoding of the body.
+	Encoding string `json:"encoding,omitzero"`
+	Comment  string `json:"comment,omitzero"`
+}
+
+type Cache struct {
+	BeforeRequest *CacheEntry `json:"beforeRequest,omitzero"`
+	AfterRequest  *CacheEntry `json:"afterRequest,omitzero"`
+	Comment       string      `json:"comment,omitzero"`
+}
+
+type CacheEntry struct {
+	Expires    *time.Time `json:"expires,omitzero"`
+	LastAccess time.Time  `json:"lastAccess"`
+	ETag       string     `json:"eTag"`
+	HitCount   int        `json:"hitCount"`
+	Comment    string     `json:"comment,omitzero"`
+}
+
+// Timings are in milliseconds; Blocked, Dns, Connect and Ssl are optional, and -1 means not
+// applicable.
+type Timings struct {
+	Blocked float64 `json:"blocked,omitzero"`
+	Dns     float64 `json:"dns,omitzero"`
+	Connect float64 `json:"connect,omitzero"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	Ssl     float64 `json:"ssl,omitzero"`
+	Comment string  `json:"comment,omitzero"`
+}
@@ -21,19 +21,21 @@ import (
 	"github.com/Motmedel/utils_go/pkg/json/jose/jws"
 	"github.com/Motmedel/utils_go/pkg/json/jose/jwt/types/claims/session_claims"
 	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	"github.com/Motmedel/utils_go/pkg/net/types/domain_parts"
 	"github.com/Motmedel/utils_go/pkg/schema"
 	schemaUtils "github.com/Motmedel/utils_go/pkg/schema/utils"
 	"github.com/Motmedel/utils_go/pkg/utils"
 )
 
-const maskedValue = "(MASKED)"
+// MaskedValue is what a masked secret is replaced with.
+const MaskedValue = "(MASKED)"
 
 func maskJws(serialization string) string {
 	if parts, err := jws.Split(serialization); err == nil {
-		parts[2] = maskedValue
+		parts[2] = MaskedValue
 		return strings.Join(parts[:], jws.Delimiter)
 	}
-	return maskedValue
+	return MaskedValue
 }
 
 func maskSetCookieHeader(setCookieHeader string) string {
@@ -71,15 +73,49 @@ func maskCookieHeader(cookieHeader string) string {
 func maskBasicAuth(value string) string {
 	decoded, err := base64.StdEncoding.DecodeString(value)
 	if err != nil {
-		return maskedValue
+		return MaskedValue
 	}
 
 	parts := strings.SplitN(string(decoded), ":", 2)
 	if len(parts) != 2 {
-		return maskedValue
+		return MaskedValue
 	}
 
-	return base64.StdEncoding.EncodeToString([]byte(parts[0]+":")) + maskedValue
+	return base64.StdEncoding.EncodeToString([]byte(parts[0]+":")) + MaskedValue
+}
+
+// MaskHeaderValue masks the secrets in a value of the header with the canonical name: the
+// credentials of Authorization, of which only the user of basic authentication is kept, and the
+// signatures of the JWSs in Cookie, Set-Cookie and X-Goog-Iap-Jwt-Assertion, other cookie values
+// being masked whole. Values of other headers are returned as they are.
+func MaskHeaderValue(name string, value string) string {
+	switch name {
+	case "Set-Cookie":
+		return maskSetCookieHeader(value)
+	case "Authorization":
+		parsedValue, err := authorization.Parse([]byte(value))
+		if err != nil || parsedValue == nil {
+			return MaskedValue
+		}
+
+		for k := range parsedValue.Params {
+			parsedValue.Params[k] = MaskedValue
+		}
+
+		if strings.ToLower(parsedValue.Scheme) == "basic" {
+			parsedValue.Token68 = maskBasicAuth(parsedValue.Token68)
+		} else {
+			parsedValue.Token68 = maskJws(parsedValue.Token68)
+		}
+
+		return parsedValue.String()
+	case "Cookie":
+		return maskCookieHeader(value)
+	case "X-Goog-Iap-Jwt-Assertion":
+		return maskJws(value)
+	default:
+		return value
+	}
 }
 
 func extractNormalizedHeaders(host string, header http.Header, maskedHeaders map[string]struct{}) string {
@@ -92,30 +128,9 @@ func extractNormalizedHeaders(host string, header http.Header, maskedHeaders map
 	for name, values := range header {
 		for _, value := range values {
 			if _, ok := maskedHeaders[name]; ok {
-				value = maskedValue
-			} else if name == "Set-Cookie" {
-				value = maskSetCookieHeader(value)
-			} else if name == "Authorization" {
-				parsedValue, err := authorization.Parse([]byte(value))
-				if err == nil && parsedValue != nil {
-					for k := range parsedValue.Params {
-						parsedValue.Params[k] = maskedValue
-					}
-
-					if strings.ToLower(parsedValue.Scheme) == "basic" {
-						parsedValue.Token68 = maskBasicAuth(parsedValue.Token68)
-					} else {
-						parsedValue.Token68 = maskJws(parsedValue.Token68)
-					}
-
-					value = parsedValue.String()
-				} else {
-					value = maskedValue
-				}
-			} else if name == "Cookie" {
-				value = maskCookieHeader(value)
-			} else if name == "X-Goog-Iap-Jwt-Assertion" {
-				value = maskJws(value)
+				value = MaskedValue
+			} else {
+				value = MaskHeaderValue(name, value)
 			}
 
 			headerStrings = append(headerStrings, fmt.Sprintf("%s: %s\r\n", name, value))
@@ -326,12 +341,16 @@ func urlMatchesPattern(pattern *schema.Url, incoming *schema.Url) (bool, []strin
 }
 
 func (e *Extractor) maskUrl(urlStruct *schema.Url) {
-	if urlStruct == nil || len(e.MaskedUrlParams) == 0 {
+	maskUrl(urlStruct, e.MaskedUrlParams)
+}
+
+func maskUrl(urlStruct *schema.Url, patterns []*schema.Url) {
+	if urlStruct == nil || len(patterns) == 0 {
 		return
 	}
 
 	paramsToMask := make(map[string]struct{})
-	for _, maskedUrlParam := range e.MaskedUrlParams {
+	for _, maskedUrlParam := range patterns {
 		if matches, params := urlMatchesPattern(maskedUrlParam, urlStruct); matches {
 			for _, param := range params {
 				paramsToMask[param] = struct{}{}
@@ -357,7 +376,7 @@ func (e *Extractor) maskUrl(urlStruct *schema.Url) {
 		modified := false
 		for param := range paramsToMask {
 			if queryValues.Has(param) {
-				queryValues.Set(param, maskedValue)
+				queryValues.Set(param, MaskedValue)
 				modified = true
 			}
 		}
@@ -379,7 +398,7 @@ func (e *Extractor) maskUrl(urlStruct *schema.Url) {
 			modified := false
 			for param := range paramsToMask {
 				if parsedQuery.Has(param) {
-					parsedQuery.Set(param, maskedValue)
+					parsedQuery.Set(param, MaskedValue)
 					modified = true
 				}
 			}
@@ -390,6 +409,58 @@ func (e *Extractor) maskUrl(urlStruct *schema.Url) {
 	}
 }
 
+func makeSchemaUrl(urlStruct *url.URL) *schema.Url {
+	schemaUrl := &schema.Url{
+		Domain: urlStruct.Hostname(),
+		Full:   urlStruct.String(),
+		Path:   urlStruct.Path,
+		Query:  urlStruct.RawQuery,
+	}
+	if domainParts := domain_parts.New(schemaUrl.Domain); domainParts != nil {
+		schemaUrl.RegisteredDomain = domainParts.RegisteredDomain
+		schemaUrl.Subdomain = domainParts.Subdomain
+		schemaUrl.TopLevelDomain = domainParts.TopLevelDomain
+	}
+
+	return schemaUrl
+}
+
+// MaskUrl returns a copy of the URL with the values of the query parameters that the patterns name
+// masked, as an extractor with the patterns as its MaskedUrlParams masks them.
+func MaskUrl(urlStruct *url.URL, patterns ...*schema.Url) *url.URL {
+	if urlStruct == nil {
+		return nil
+	}
+
+	schemaUrl := makeSchemaUrl(urlStruct)
+	maskUrl(schemaUrl, patterns)
+
+	maskedUrl, err := url.Parse(schemaUrl.Full)
+	if err != nil {
+		urlCopy := *urlStruct
+		return &urlCopy
+	}
+
+	return maskedUrl
+}
+
+// UrlMatches reports whether the URL matches any of the patterns, as the URL of a request is
+// matched with the MaskedRequestBodyUrls and MaskedResponseBodyUrls of an extractor.
+func UrlMatches(urlStruct *url.URL, patterns ...*schema.Url) bool {
+	if urlStruct == nil || len(patterns) == 0 {
+		return false
+	}
+
+	schemaUrl := makeSchemaUrl(urlStruct)
+	for _, pattern := range patterns {
+		if matches, _ := urlMatchesPattern(pattern, schemaUrl); matches {
+			return true
+		}
+	}
+
+	return false
+}
+
 type Extractor struct {
 	ReplaceableMessages    map[string]struct{}
 	MaskedUrlParams        []*schema.Url
@@ -466,7 +537,7 @@ func (e *Extractor) Handle(ctx context.Context, record *slog.Record) error {
 						if body := request.Body; body != nil && body.Content != "" {
 							for _, pattern := range e.MaskedRequestBodyUrls {
 								if matches, _ := urlMatchesPattern(pattern, baseUrl); matches {
-									body.Content = maskedValue
+									body.Content = MaskedValue
 									break
 								}
 							}
@@ -481,7 +552,7 @@ func (e *Extractor) Handle(ctx context.Context, record *slog.Record) error {
 						if body := response.Body; body != nil && body.Content != "" {
 							for _, pattern := range e.MaskedResponseBodyUrls {
 								if matches, _ := urlMatchesPattern(pattern, baseUrl); matches {
-									body.Content = maskedValue
+									body.Content = MaskedValue
 									break
 								}
 							}
@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -189,6 +190,31 @@ func TestMaskCookieHeader(t *testing.T) {
 	}
 }
 
+func TestMaskHeaderValue(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "Authorization", input: "Bearer a.b.c", want: "Bearer a.b.(MASKED)"},
+		{name: "Cookie", input: "sess=x.y.z; theme=dark", want: "sess=x.y.(MASKED); theme=(MASKED)"},
+		{name: "Set-Cookie", input: "token=a.b.c; Path=/", want: "token=a.b.(MASKED); Path=/"},
+		{name: "X-Goog-Iap-Jwt-Assertion", input: "a.b.c", want: "a.b.(MASKED)"},
+		{name: "Content-Type", input: "text/plain", want: "text/plain"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := MaskHeaderValue(tc.name, tc.input); got != tc.want {
+				t.Errorf("MaskHeaderValue(%q, %q) = %q, want %q", tc.name, tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
 func TestExtractNormalizedHeaders(t *testing.T) {
 	t.Parallel()
 
@@ -941,6 +967,71 @@ func TestExtractor_MaskUrl_NilUrl(t *testing.T) {
 	e.maskUrl(nil)
 }
 
+func TestMaskUrl(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		rawUrl   string
+		patterns []*motmedelSchemaTypes.Url
+		want     string
+	}{
+		{
+			name:     "matching param",
+			rawUrl:   "https://api.example.com/login?token=secret&next=/",
+			patterns: []*motmedelSchemaTypes.Url{{Path: "/login", Query: "token"}},
+			want:     "https://api.example.com/login?next=%2F&token=%28MASKED%29",
+		},
+		{
+			name:     "other path",
+			rawUrl:   "https://api.example.com/logout?token=secret",
+			patterns: []*motmedelSchemaTypes.Url{{Path: "/login", Query: "token"}},
+			want:     "https://api.example.com/logout?token=secret",
+		},
+		{
+			name:     "domain",
+			rawUrl:   "https://api.example.com/?token=secret",
+			patterns: []*motmedelSchemaTypes.Url{{Domain: "api.example.com", Query: "token"}},
+			want:     "https://api.example.com/?token=%28MASKED%29",
+		},
+		{name: "no patterns", rawUrl: "https://example.com/?token=secret", want: "https://example.com/?token=secret"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlStruct, err := url.Parse(testCase.rawUrl)
+			if err != nil {
+				t.Fatalf("url parse: %v", err)
+			}
+
+			if got := MaskUrl(urlStruct, testCase.patterns...).String(); got != testCase.want {
+				t.Errorf("got %q, want %q", got, testCase.want)
+			}
+			if got := urlStruct.String(); got != testCase.rawUrl {
+				t.Errorf("expected the url to be left as it was, got %q", got)
+			}
+		})
+	}
+}
+
+func TestUrlMatches(t *testing.T) {
+	t.Parallel()
+
+	urlStruct, err := url.Parse("https://example.com/api/users/1")
+	if err != nil {
+		t.Fatalf("url parse: %v", err)
+	}
+
+	if !UrlMatches(urlStruct, &motmedelSchemaTypes.Url{Path: "/other"}, &motmedelSchemaTypes.Url{Path: "/api/users/*"}) {
+		t.Error("expected a match")
+	}
+	if UrlMatches(urlStruct, &motmedelSchemaTypes.Url{Path: "/api/users"}) || UrlMatches(urlStruct) || UrlMatches(nil) {
+		t.Error("expected no match")
+	}
+}
+
 func TestPathMatches(t *testing.T) {
 	t.Parallel()
 
@@ -1095,12 +1186,12 @@ func TestExtractor_Handle_MaskedRequestBody(t *testing.T) {
 		{
 			name:     "masked when path matches",
 			patterns: []*motmedelSchemaTypes.Url{{Path: "/token"}},
-			want:     maskedValue,
+			want:     MaskedValue,
 		},
 		{
 			name:     "masked when domain and path match",
 			patterns: []*motmedelSchemaTypes.Url{{Domain: "oauth2.example.com", Path: "/token"}},
-			want:     maskedValue,
+			want:     MaskedValue,
 		},
 		{
 			name:     "unmasked when no patterns",
@@ -0,0 +1,221 @@
+// Package permissions_policy parses and serializes the Permissions-Policy header, a structured
+// field dictionary that says, per feature, which origins a document and its frames may use it from.
+package permissions_policy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/http/structured_field"
+)
+
+const (
+	HeaderName           = "Permissions-Policy"
+	ReportOnlyHeaderName = "Permissions-Policy-Report-Only"
+
+	reportToParameterKey = "report-to"
+
+	allToken  = "*"
+	selfToken = "self"
+	srcToken  = "src"
+)
+
+// Allowlist is the origins a feature is permitted for. The zero value permits it for none, which is
+// what "()" says.
+type Allowlist struct {
+	// All permits every origin, which is what "*" says.
+	All bool
+	// Self permits the origin of the document.
+	Self bool
+	// Src permits the origin of the src attribute of the frame the policy is given to, in an allow
+	// attribute. It says nothing in a header.
+	Src bool
+	// Origins are the other origins permitted, serialized.
+	Origins []string
+}
+
+// IsEmpty reports whether the allowlist permits no origin at all.
+func (allowlist *Allowlist) IsEmpty() bool {
+	return !allowlist.All && !allowlist.Self && !allowlist.Src && len(allowlist.Origins) == 0
+}
+
+// AddOrigins permits the origins as well, each once.
+func (allowlist *Allowlist) AddOrigins(origins ...string) {
+	for _, origin := range origins {
+		if origin != "" && !slices.Contains(allowlist.Origins, origin) {
+			allowlist.Origins = append(allowlist.Origins, origin)
+		}
+	}
+}
+
+type Directive struct {
+	Feature   string
+	Allowlist Allowlist
+	// ReportTo names the reporting endpoint the violations of the directive are reported to.
+	ReportTo string
+}
+
+func (directive *Directive) member() *structured_field.DictionaryMember {
+	allowlist := directive.Allowlist
+
+	var parameters structured_field.Parameters
+	if reportTo := directive.ReportTo; reportTo != "" {
+		parameters = structured_field.Parameters{
+			{Key: reportToParameterKey, Value: structured_field.Token(reportTo)},
+		}
+	}
+
+	// A feature permitted for every origin is said with "*" alone, as a bare item.
+	if allowlist.All && !allowlist.Self && !allowlist.Src && len(allowlist.Origins) == 0 {
+		return &structured_field.DictionaryMember{
+			Key:   directive.Feature,
+			Value: &structured_field.Item{Value: structured_field.Token(allToken), Parameters: parameters},
+		}
+	}
+
+	var items []*structured_field.Item
+	if allowlist.All {
+		items = append(items, &structured_field.Item{Value: structured_field.Token(allToken)})
+	}
+	if allowlist.Self {
+		items = append(items, &structured_field.Item{Value: structured_field.Token(selfToken)})
+	}
+	if allowlist.Src {
+		items = append(items, &structured_field.Item{Value: structured_field.Token(srcToken)})
+	}
+	for _, origin := range allowlist.Origins {
+		items = append(items, &structured_field.Item{Value: origin})
+	}
+
+	return &structured_field.DictionaryMember{
+		Key:   directive.Feature,
+		Value: &structured_field.InnerList{Items: items, Parameters: parameters},
+	}
+}
+
+type PermissionsPolicy struct {
+	Directives []*Directive
+}
+
+// Get returns the directive of the feature.
+func (policy *PermissionsPolicy) Get(feature string) *Directive {
+	for _, directive := range policy.Directives {
+		if directive != nil && directive.Feature == feature {
+			return directive
+		}
+	}
+
+	return nil
+}
+
+// Set replaces the directive of the directive's feature, or adds it where there is none.
+func (policy *PermissionsPolicy) Set(directive *Directive) {
+	if directive == nil {
+		return
+	}
+
+	for i, existingDirective := range policy.Directives {
+		if existingDirective != nil && existingDirective.Feature == directive.Feature {
+			policy.Directives[i] = directive
+			return
+		}
+	}
+
+	policy.Directives = append(policy.Directives, directive)
+}
+
+// Delete removes the directive of the feature, which leaves the feature to its default allowlist.
+func (policy *PermissionsPolicy) Delete(feature string) {
+	policy.Directives = slices.DeleteFunc(policy.Directives, func(directive *Directive) bool {
+		return directive != nil && directive.Feature == feature
+	})
+}
+
+// String serializes the policy as a header value. A directive that cannot be serialized -- its
+// feature not being a structured field key, or an origin not being printable -- is left out, as a
+// browser would have ignored it.
+func (policy *PermissionsPolicy) String() string {
+	var members []string
+	for _, directive := range policy.Directives {
+		if directive == nil {
+			continue
+		}
+
+		value, err := structured_field.SerializeDictionary(structured_field.Dictionary{directive.member()})
+		if err != nil {
+			continue
+		}
+
+		members = append(members, value)
+	}
+
+	return strings.Join(members, ", ")
+}
+
+func allowlistItem(allowlist *Allowlist, item *structured_field.Item) {
+	if item == nil {
+		return
+	}
+
+	switch value := item.Value.(type) {
+	case structured_field.Token:
+		switch string(value) {
+		case allToken:
+			allowlist.All = true
+		case selfToken:
+			allowlist.Self = true
+		case srcToken:
+			allowlist.Src = true
+		}
+	case string:
+		allowlist.AddOrigins(value)
+	}
+}
+
+// Parse parses a Permissions-Policy header value. What an allowlist holds beyond the tokens and
+// origins it is specified to is disregarded, as a browser disregards it.
+func Parse(data []byte) (*PermissionsPolicy, error) {
+	dictionary, err := structured_field.ParseDictionary(data)
+	if err != nil {
+		return nil, motmedelErrors.New(
+			fmt.Errorf("%w: parse dictionary: %w", motmedelErrors.ErrParseError, err),
+			data,
+		)
+	}
+
+	policy := &PermissionsPolicy{Directives: make([]*Directive, 0, len(dictionary))}
+	for _, member := range dictionary {
+		if member == nil {
+			continue
+		}
+
+		directive := &Directive{Feature: member.Key}
+
+		var parameters structured_field.Parameters
+		switch value := member.Value.(type) {
+		case *structured_field.Item:
+			allowlistItem(&directive.Allowlist, value)
+			parameters = value.Parameters
+		case *structured_field.InnerList:
+			for _, item := range value.Items {
+				allowlistItem(&directive.Allowlist, item)
+			}
+			parameters = value.Parameters
+		}
+
+		if reportTo, ok := parameters.Get(reportToParameterKey); ok {
+			switch typedReportTo := reportTo.(type) {
+			case structured_field.Token:
+				directive.ReportTo = string(typedReportTo)
+			case string:
+				directive.ReportTo = typedReportTo
+			}
+		}
+
+		policy.Directives = append(policy.Directives, directive)
+	}
+
+	return policy, nil
+}
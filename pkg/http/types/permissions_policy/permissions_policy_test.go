@@ -0,0 +1,219 @@
+package permissions_policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected *PermissionsPolicy
+	}{
+		{
+			name:  "denied",
+			input: "geolocation=(), camera=()",
+			expected: &PermissionsPolicy{Directives: []*Directive{
+				{Feature: "geolocation"},
+				{Feature: "camera"},
+			}},
+		},
+		{
+			name:  "all",
+			input: "fullscreen=*",
+			expected: &PermissionsPolicy{Directives: []*Directive{
+				{Feature: "fullscreen", Allowlist: Allowlist{All: true}},
+			}},
+		},
+		{
+			name:  "self and origins",
+			input: `identity-credentials-get=(self "https://accounts.example.com" "https://id.example.org")`,
+			expected: &PermissionsPolicy{Directives: []*Directive{
+				{
+					Feature: "identity-credentials-get",
+					Allowlist: Allowlist{
+						Self:    true,
+						Origins: []string{"https://accounts.example.com", "https://id.example.org"},
+					},
+				},
+			}},
+		},
+		{
+			name:  "self as an item",
+			input: "payment=self",
+			expected: &PermissionsPolicy{Directives: []*Directive{
+				{Feature: "payment", Allowlist: Allowlist{Self: true}},
+			}},
+		},
+		{
+			name:  "report-to",
+			input: "geolocation=();report-to=main",
+			expected: &PermissionsPolicy{Directives: []*Directive{
+				{Feature: "geolocation", ReportTo: "main"},
+			}},
+		},
+		{
+			// A browser disregards what an allowlist holds beyond tokens it knows and origins.
+			name:  "unknown members of an allowlist",
+			input: "usb=(none 1 self)",
+			expected: &PermissionsPolicy{Directives: []*Directive{
+				{Feature: "usb", Allowlist: Allowlist{Self: true}},
+			}},
+		},
+		{
+			name:  "the last of a feature given twice",
+			input: "usb=(), usb=self",
+			expected: &PermissionsPolicy{Directives: []*Directive{
+				{Feature: "usb", Allowlist: Allowlist{Self: true}},
+			}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := Parse([]byte(testCase.input))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !reflect.DeepEqual(policy, testCase.expected) {
+				t.Errorf("got %q, want %q", policy.String(), testCase.expected.String())
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{"Geolocation=()", "geolocation=(", "geolocation=(),"} {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := Parse([]byte(input)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		policy   *PermissionsPolicy
+		expected string
+	}{
+		{
+			name:     "empty",
+			policy:   &PermissionsPolicy{},
+			expected: "",
+		},
+		{
+			name: "allowlists",
+			policy: &PermissionsPolicy{Directives: []*Directive{
+				{Feature: "geolocation"},
+				{Feature: "fullscreen", Allowlist: Allowlist{All: true}},
+				{Feature: "payment", Allowlist: Allowlist{Self: true, Origins: []string{"https://pay.example.com"}}},
+				{Feature: "camera", ReportTo: "main"},
+			}},
+			expected: `geolocation=(), fullscreen=*, payment=(self "https://pay.example.com"), camera=();report-to=main`,
+		},
+		{
+			name: "a directive that cannot be serialized",
+			policy: &PermissionsPolicy{Directives: []*Directive{
+				{Feature: "Camera"},
+				{Feature: "usb"},
+			}},
+			expected: "usb=()",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testCase.policy.String(); got != testCase.expected {
+				t.Errorf("got %q, want %q", got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	input := `geolocation=(), microphone=(), identity-credentials-get=(self "https://accounts.example.com")`
+
+	policy, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := policy.String(); got != input {
+		t.Errorf("got %q, want %q", got, input)
+	}
+}
+
+func TestSetAndDelete(t *testing.T) {
+	t.Parallel()
+
+	policy := &PermissionsPolicy{Directives: []*Directive{{Feature: "geolocation"}, {Feature: "usb"}}}
+
+	policy.Set(&Directive{Feature: "usb", Allowlist: Allowlist{Self: true}})
+	policy.Set(&Directive{Feature: "camera"})
+	if got, expected := policy.String(), "geolocation=(), usb=(self), camera=()"; got != expected {
+		t.Errorf("set: got %q, want %q", got, expected)
+	}
+
+	policy.Delete("geolocation")
+	if policy.Get("geolocation") != nil {
+		t.Error("expected the deleted directive to be gone")
+	}
+	if directive := policy.Get("usb"); directive == nil || !directive.Allowlist.Self {
+		t.Errorf("expected the replaced directive, got %v", directive)
+	}
+}
+
+func TestPermissionsPolicyViolationReportBodyMessage(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		body     *PermissionsPolicyViolationReportBody
+		expected string
+	}{
+		{
+			name:     "enforced",
+			body:     &PermissionsPolicyViolationReportBody{FeatureId: "geolocation", Disposition: "enforce"},
+			expected: "The page's permissions policy blocked the use of geolocation.",
+		},
+		{
+			name:     "report only",
+			body:     &PermissionsPolicyViolationReportBody{FeatureId: "camera", Disposition: "report"},
+			expected: "The page's permissions policy would have blocked the use of camera.",
+		},
+		{
+			name: "console message",
+			body: &PermissionsPolicyViolationReportBody{
+				FeatureId:      "geolocation",
+				ConsoleMessage: "Geolocation access has been blocked because of a permissions policy applied to the current document.",
+			},
+			expected: "Geolocation access has been blocked because of a permissions policy applied to the current document.",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := testCase.body.Message(); got != testCase.expected {
+				t.Errorf("Message() = %q, want %q", got, testCase.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package permissions_policy
+
+import "fmt"
+
+// ViolationReportType is the type of the reports a browser sends about what a permissions policy
+// blocked.
+const ViolationReportType = "permissions-policy-violation"
+
+// PermissionsPolicyViolationReportBody is the report body for "permissions-policy-violation"
+// reports sent via the Reporting API. Defined in Permissions Policy section 9.
+type PermissionsPolicyViolationReportBody struct {
+	// The body is written by the browser, which is free to say more about what it blocked than
+	// what is specified today. What it adds is not worth refusing the report over.
+	//nolint:revive // The blank field is what carries what holds for the object; it is read from the type.
+	_ struct{} `jsonschema:",additionalProperties:true"`
+
+	FeatureId    string  `json:"featureId"`
+	SourceFile   *string `json:"sourceFile,omitempty" jsonschema:"sourceFile,optional,minlength:0"`
+	LineNumber   *int    `json:"lineNumber,omitempty"`
+	ColumnNumber *int    `json:"columnNumber,omitempty"`
+	Disposition  string  `json:"disposition,omitempty"`
+	// ConsoleMessage is what Chrome adds about the violation, as it logs it to the console.
+	ConsoleMessage string `json:"message,omitempty"`
+}
+
+func (body *PermissionsPolicyViolationReportBody) Message() string {
+	if message := body.ConsoleMessage; message != "" {
+		return message
+	}
+
+	if body.Disposition == "report" {
+		return fmt.Sprintf("The page's permissions policy would have blocked the use of %s.", body.FeatureId)
+	}
+
+	return fmt.Sprintf("The page's permissions policy blocked the use of %s.", body.FeatureId)
+}
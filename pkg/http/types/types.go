@@ -26,6 +26,10 @@ type HttpContext struct {
 	Extra        []*HttpContext
 	LocalAddr    net.Addr
 	RemoteAddr   net.Addr
+	// Rule is the rule that decided what became of the request, such as a firewall rule, and Reason
+	// is why.
+	Rule   *schema.Rule
+	Reason string
 }
 
 func getFullType(typeValue string, subtypeValue string, normalize bool) string {
@@ -653,3 +657,13 @@ func (cacheControl *CacheControl) Public() bool {
 func (cacheControl *CacheControl) SMaxAge() (int, error) {
 	return cacheControl.deltaSeconds("s-maxage")
 }
+
+// Extension directives (RFC 5861).
+
+func (cacheControl *CacheControl) StaleWhileRevalidate() (int, error) {
+	return cacheControl.deltaSeconds("stale-while-revalidate")
+}
+
+func (cacheControl *CacheControl) StaleIfError() (int, error) {
+	return cacheControl.deltaSeconds("stale-if-error")
+}
@@ -56,6 +56,8 @@ func TestCacheControlDeltaSeconds(t *testing.T) {
 		{name: "max-age", directive: "max-age", method: (*CacheControl).MaxAge},
 		{name: "min-fresh", directive: "min-fresh", method: (*CacheControl).MinFresh},
 		{name: "s-maxage", directive: "s-maxage", method: (*CacheControl).SMaxAge},
+		{name: "stale-while-revalidate", directive: "stale-while-revalidate", method: (*CacheControl).StaleWhileRevalidate},
+		{name: "stale-if-error", directive: "stale-if-error", method: (*CacheControl).StaleIfError},
 	}
 
 	for _, testCase := range testCases {
@@ -13,6 +13,11 @@ import (
 // to the keywords ('none', 'allow-duplicates') the directive also takes.
 const trustedTypePolicyNameKind = "policy-name"
 
+// StrictDynamicKeyword is the keyword with which the scripts a source list permits by nonce or hash
+// are trusted to load scripts of their own, and the host and 'self' sources alongside it are
+// disregarded.
+const StrictDynamicKeyword = "strict-dynamic"
+
 // ChromeXmlViewerStyleHashes are the styles Chrome's XML viewer applies to the document tree it
 // renders an XML response as. They are the bodies of style elements, which a hash source matches as
 // it is.
@@ -337,6 +342,74 @@ func PatchCspStyleSrcWithKeyword(contentSecurityPolicy *csp.ContentSecurityPolic
 	PatchCspSourceDirective[csp.StyleSrcDirective](contentSecurityPolicy, keywordSources...)
 }
 
+// PatchCspScriptSrcWithNonce merges the nonce sources into script-src, deduplicating by serialized
+// value.
+func PatchCspScriptSrcWithNonce(contentSecurityPolicy *csp.ContentSecurityPolicy, nonces ...string) {
+	if contentSecurityPolicy == nil {
+		return
+	}
+
+	var nonceSources []csp.SourceI
+	for _, nonce := range nonces {
+		if nonce == "" {
+			continue
+		}
+
+		nonceSources = append(nonceSources, &csp.NonceSource{Base64Value: nonce})
+	}
+
+	PatchCspSourceDirective[csp.ScriptSrcDirective](contentSecurityPolicy, nonceSources...)
+}
+
+// PatchCspScriptSrcWithKeyword merges the keyword sources into script-src, deduplicating by
+// serialized value.
+func PatchCspScriptSrcWithKeyword(contentSecurityPolicy *csp.ContentSecurityPolicy, keywords ...string) {
+	if contentSecurityPolicy == nil {
+		return
+	}
+
+	var keywordSources []csp.SourceI
+	for _, keyword := range keywords {
+		if keyword == "" {
+			continue
+		}
+
+		keywordSources = append(keywordSources, &csp.KeywordSource{Keyword: keyword})
+	}
+
+	PatchCspSourceDirective[csp.ScriptSrcDirective](contentSecurityPolicy, keywordSources...)
+}
+
+// PatchCspScriptSrcWithStrictDynamic merges the nonce sources and 'strict-dynamic' into script-src,
+// for the scripts the nonces permit to load the scripts they depend on. 'strict-dynamic' is merged
+// only where script-src permits scripts by nonce or hash: without either, it would permit none.
+func PatchCspScriptSrcWithStrictDynamic(contentSecurityPolicy *csp.ContentSecurityPolicy, nonces ...string) {
+	if contentSecurityPolicy == nil {
+		return
+	}
+
+	PatchCspScriptSrcWithNonce(contentSecurityPolicy, nonces...)
+
+	scriptSrc := contentSecurityPolicy.GetScriptSrc()
+	if scriptSrc == nil {
+		return
+	}
+
+	permitsByNonceOrHash := slices.ContainsFunc(scriptSrc.Sources, func(source csp.SourceI) bool {
+		switch source.(type) {
+		case *csp.NonceSource, *csp.HashSource:
+			return true
+		default:
+			return false
+		}
+	})
+	if !permitsByNonceOrHash {
+		return
+	}
+
+	PatchCspScriptSrcWithKeyword(contentSecurityPolicy, StrictDynamicKeyword)
+}
+
 // PatchCspTrustedTypes requires the named trusted types policies of the scripts the document runs:
 // the policies are merged into trusted-types, and require-trusted-types-for is ensured for the
 // script sink group, which is what makes the requirement take effect rather than merely be stated.
@@ -1024,3 +1024,62 @@ func TestPatchCspTrustedTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestPatchCspScriptSrcWithStrictDynamic(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		policy   string
+		nonces   []string
+		expected string
+	}{
+		{
+			name:     "no script-src",
+			policy:   "default-src 'self'",
+			nonces:   []string{"abc123"},
+			expected: "default-src 'self'; script-src 'nonce-abc123' 'strict-dynamic'",
+		},
+		{
+			name:     "an existing script-src is added to",
+			policy:   "script-src 'self'",
+			nonces:   []string{"abc123"},
+			expected: "script-src 'self' 'nonce-abc123' 'strict-dynamic'",
+		},
+		{
+			name:     "a hash permits scripts as well",
+			policy:   "script-src 'sha256-L2121qypPdYD4EOJ6AR1Amd2YKHYClryjHjORJFpR7U='",
+			expected: "script-src 'sha256-L2121qypPdYD4EOJ6AR1Amd2YKHYClryjHjORJFpR7U=' 'strict-dynamic'",
+		},
+		{
+			// 'strict-dynamic' without a nonce or hash would permit no script at all.
+			name:     "no nonce or hash",
+			policy:   "script-src 'self'",
+			nonces:   []string{""},
+			expected: "script-src 'self'",
+		},
+		{
+			name:     "what is already there is not repeated",
+			policy:   "script-src 'nonce-abc123' 'strict-dynamic'",
+			nonces:   []string{"abc123"},
+			expected: "script-src 'nonce-abc123' 'strict-dynamic'",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := csp.Parse([]byte(testCase.policy))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+
+			PatchCspScriptSrcWithStrictDynamic(policy, testCase.nonces...)
+
+			if got := policy.String(); got != testCase.expected {
+				t.Errorf("policy: got %q, want %q", got, testCase.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package permissions_policy
+
+import (
+	"net/url"
+
+	pp "github.com/Motmedel/utils_go/pkg/http/types/permissions_policy"
+)
+
+// Origin returns the serialized origin of the URL, which is what an allowlist names an origin by:
+// the scheme and the host, with the port where there is one, and nothing of the path.
+func Origin(originUrl *url.URL) string {
+	if originUrl == nil || originUrl.Scheme == "" || originUrl.Host == "" {
+		return ""
+	}
+
+	return (&url.URL{Scheme: originUrl.Scheme, Host: originUrl.Host}).String()
+}
+
+// PatchPermissionsPolicyWithOrigins permits the feature for the origins of the URLs as well. A
+// feature the policy says nothing of is permitted for them alongside 'self', so that the document
+// keeps the use of the feature it had by default; one denied to every origin is permitted for them
+// alone; and one already permitted for every origin is left as it is.
+func PatchPermissionsPolicyWithOrigins(permissionsPolicy *pp.PermissionsPolicy, feature string, originUrls ...*url.URL) {
+	if permissionsPolicy == nil || feature == "" {
+		return
+	}
+
+	var origins []string
+	for _, originUrl := range originUrls {
+		if origin := Origin(originUrl); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	if len(origins) == 0 {
+		return
+	}
+
+	directive := permissionsPolicy.Get(feature)
+	if directive == nil {
+		directive = &pp.Directive{Feature: feature, Allowlist: pp.Allowlist{Self: true}}
+		permissionsPolicy.Set(directive)
+	}
+
+	if directive.Allowlist.All {
+		return
+	}
+
+	directive.Allowlist.AddOrigins(origins...)
+}
+
+// PatchPermissionsPolicyWithSelf permits the features for the document's own origin as well.
+func PatchPermissionsPolicyWithSelf(permissionsPolicy *pp.PermissionsPolicy, features ...string) {
+	if permissionsPolicy == nil {
+		return
+	}
+
+	for _, feature := range features {
+		if feature == "" {
+			continue
+		}
+
+		directive := permissionsPolicy.Get(feature)
+		if directive == nil {
+			permissionsPolicy.Set(&pp.Directive{Feature: feature, Allowlist: pp.Allowlist{Self: true}})
+			continue
+		}
+
+		directive.Allowlist.Self = true
+	}
+}
+
+// PatchPermissionsPolicyDeny denies the features to every origin, the document's own included.
+// What the directive is reported to is kept.
+func PatchPermissionsPolicyDeny(permissionsPolicy *pp.PermissionsPolicy, features ...string) {
+	if permissionsPolicy == nil {
+		return
+	}
+
+	for _, feature := range features {
+		if feature == "" {
+			continue
+		}
+
+		if directive := permissionsPolicy.Get(feature); directive != nil {
+			directive.Allowlist = pp.Allowlist{}
+			continue
+		}
+
+		permissionsPolicy.Set(&pp.Directive{Feature: feature})
+	}
+}
@@ -0,0 +1,132 @@
+package permissions_policy
+
+import (
+	"net/url"
+	"testing"
+
+	pp "github.com/Motmedel/utils_go/pkg/http/types/permissions_policy"
+)
+
+func mustParseURL(rawURL string) *url.URL {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func mustParsePolicy(t *testing.T, policy string) *pp.PermissionsPolicy {
+	t.Helper()
+
+	permissionsPolicy, err := pp.Parse([]byte(policy))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	return permissionsPolicy
+}
+
+func TestOrigin(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input    *url.URL
+		expected string
+	}{
+		{input: mustParseURL("https://accounts.example.com"), expected: "https://accounts.example.com"},
+		{input: mustParseURL("https://accounts.example.com/fedcm.json?a=b"), expected: "https://accounts.example.com"},
+		{input: mustParseURL("https://accounts.example.com:8443/"), expected: "https://accounts.example.com:8443"},
+		{input: mustParseURL("/relative"), expected: ""},
+		{input: nil, expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		if got := Origin(testCase.input); got != testCase.expected {
+			t.Errorf("Origin(%v) = %q, want %q", testCase.input, got, testCase.expected)
+		}
+	}
+}
+
+func TestPatchPermissionsPolicyWithOrigins(t *testing.T) {
+	t.Parallel()
+
+	providers := []*url.URL{
+		mustParseURL("https://accounts.example.com/config.json"),
+		mustParseURL("https://id.example.org"),
+		nil,
+	}
+
+	testCases := []struct {
+		name     string
+		policy   string
+		expected string
+	}{
+		{
+			name:     "absent",
+			policy:   "geolocation=()",
+			expected: `geolocation=(), identity-credentials-get=(self "https://accounts.example.com" "https://id.example.org")`,
+		},
+		{
+			name:     "merged, each once",
+			policy:   `identity-credentials-get=(self "https://id.example.org")`,
+			expected: `identity-credentials-get=(self "https://id.example.org" "https://accounts.example.com")`,
+		},
+		{
+			name:     "denied",
+			policy:   "identity-credentials-get=()",
+			expected: `identity-credentials-get=("https://accounts.example.com" "https://id.example.org")`,
+		},
+		{
+			name:     "every origin",
+			policy:   "identity-credentials-get=*",
+			expected: "identity-credentials-get=*",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := mustParsePolicy(t, testCase.policy)
+			PatchPermissionsPolicyWithOrigins(policy, "identity-credentials-get", providers...)
+			if got := policy.String(); got != testCase.expected {
+				t.Errorf("got %q, want %q", got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestPatchPermissionsPolicyWithOrigins_NoOrigins(t *testing.T) {
+	t.Parallel()
+
+	policy := mustParsePolicy(t, "geolocation=()")
+	PatchPermissionsPolicyWithOrigins(policy, "identity-credentials-get", mustParseURL("/relative"))
+	if got := policy.String(); got != "geolocation=()" {
+		t.Errorf("expected the policy to be left as it was, got %q", got)
+	}
+
+	PatchPermissionsPolicyWithOrigins(nil, "identity-credentials-get", mustParseURL("https://example.com"))
+}
+
+func TestPatchPermissionsPolicyWithSelf(t *testing.T) {
+	t.Parallel()
+
+	policy := mustParsePolicy(t, `geolocation=(), payment=("https://pay.example.com");report-to=main`)
+	PatchPermissionsPolicyWithSelf(policy, "geolocation", "payment", "fullscreen", "")
+
+	expected := `geolocation=(self), payment=(self "https://pay.example.com");report-to=main, fullscreen=(self)`
+	if got := policy.String(); got != expected {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+}
+
+func TestPatchPermissionsPolicyDeny(t *testing.T) {
+	t.Parallel()
+
+	policy := mustParsePolicy(t, `camera=(self "https://a.example.com");report-to=main`)
+	PatchPermissionsPolicyDeny(policy, "camera", "usb")
+
+	if got, expected := policy.String(), "camera=();report-to=main, usb=()"; got != expected {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+}
@@ -1,11 +1,13 @@
 package utils
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json/v2"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -27,12 +29,18 @@ import (
 	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
 	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
 	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/circuit_breaker"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/hedger"
 	motmedelTlsTypes "github.com/Motmedel/utils_go/pkg/tls/types"
 	"github.com/Motmedel/utils_go/pkg/utils"
 )
 
 const AcceptContentIdentity = "identity"
 
+// streamErrorBodyLimit is the size of the largest body of a streamed response with an error status
+// that is read.
+const streamErrorBodyLimit = 1 << 20
+
 // FetchPerformedMessage is the message a fetch is logged under. What is worth saying about one is in
 // the HTTP context rather than here -- what was requested, and what came back -- so a log handler
 // that reads that context may replace it with what it says.
@@ -105,6 +113,40 @@ func retryWaitDuration(
 	return waitDuration, false
 }
 
+// limitedReadCloser is a response body that fails with a *ResponseTooLargeError once more than its
+// limit has been read from it.
+type limitedReadCloser struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+func (body *limitedReadCloser) Read(p []byte) (int, error) {
+	if int64(len(p)) > body.remaining+1 {
+		p = p[:body.remaining+1]
+	}
+
+	n, err := body.ReadCloser.Read(p)
+	if int64(n) > body.remaining {
+		n = int(body.remaining)
+		body.remaining = 0
+		return n, &motmedelHttpErrors.ResponseTooLargeError{Limit: body.limit, ContentLength: -1}
+	}
+	body.remaining -= int64(n)
+
+	return n, err
+}
+
+// streamBody is the body of a streamed response, read through the reader its first byte was peeked
+// with.
+type streamBody struct {
+	io.Reader
+	io.Closer
+}
+
+// fetchFunc makes one attempt of a fetch.
+type fetchFunc func(ctx context.Context, request *http.Request, fetchConfig *fetch_config.Config) (*http.Response, []byte, error)
+
 //nolint:contextcheck // The logging context is deliberately detached from the caller context (which may be cancelled) while still carrying the HTTP metadata.
 func fetch(ctx context.Context, request *http.Request, fetchConfig *fetch_config.Config) (*http.Response, []byte, error) {
 	if request == nil {
@@ -172,6 +214,26 @@ func fetch(ctx context.Context, request *http.Request, fetchConfig *fetch_config
 		return nil, nil, motmedelErrors.NewWithTraceCtx(ctxWithHttpContext, nil_error.New("http response body"))
 	}
 
+	if maxResponseBytes := fetchConfig.MaxResponseBytes; maxResponseBytes > 0 {
+		if response.ContentLength > maxResponseBytes {
+			_ = responseBody.Close()
+			return response, nil, motmedelErrors.NewWithTraceCtx(
+				ctxWithHttpContext,
+				&motmedelHttpErrors.ResponseTooLargeError{
+					Limit:         maxResponseBytes,
+					ContentLength: response.ContentLength,
+				},
+			)
+		}
+
+		responseBody = &limitedReadCloser{
+			ReadCloser: responseBody,
+			limit:      maxResponseBytes,
+			remaining:  maxResponseBytes,
+		}
+		response.Body = responseBody
+	}
+
 	var responseBodyData []byte
 	if !fetchConfig.SkipReadResponseBody {
 		responseBodyData, err = io.ReadAll(responseBody)
@@ -220,10 +282,112 @@ func fetch(ctx context.Context, request *http.Request, fetchConfig *fetch_config
 	return response, responseBodyData, nil
 }
 
+type hedgedResult struct {
+	response     *http.Response
+	responseBody []byte
+	err          error
+	httpContext  *motmedelHttpTypes.HttpContext
+}
+
+// fetchHedged sends the request, and sends it again each time the delay passes without an answer, up
+// to the hedger's maximum. The first answer that need not be retried is returned and the other
+// requests are canceled; if none is such, the last one is. Each request is given an HTTP context of
+// its own, and the one of the returned answer is copied into the caller's.
+func fetchHedged(
+	ctx context.Context,
+	request *http.Request,
+	fetchConfig *fetch_config.Config,
+	delay time.Duration,
+) (*http.Response, []byte, error) {
+	retryConfig := fetchConfig.RetryConfig
+	requestHedger := retryConfig.Hedger
+
+	hedgeCtx, cancel := context.WithCancel(request.Context())
+	defer cancel()
+
+	results := make(chan hedgedResult, 1+requestHedger.MaxHedges())
+	launch := func() {
+		httpContext := &motmedelHttpTypes.HttpContext{}
+		attemptCtx := motmedelHttpContext.WithHttpContextValue(ctx, httpContext)
+		attemptRequest := request.Clone(hedgeCtx)
+
+		go func() {
+			start := time.Now()
+			response, responseBody, err := fetch(attemptCtx, attemptRequest, fetchConfig)
+			if err == nil {
+				requestHedger.Observe(time.Since(start))
+			}
+			results <- hedgedResult{
+				response:     response,
+				responseBody: responseBody,
+				err:          err,
+				httpContext:  httpContext,
+			}
+		}()
+	}
+
+	launch()
+	launched := 1
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var result hedgedResult
+	for received := 0; received < launched; {
+		select {
+		case <-timer.C:
+			if launched < 1+requestHedger.MaxHedges() {
+				launch()
+				launched++
+				timer.Reset(delay)
+			}
+			continue
+		case result = <-results:
+			received++
+		}
+
+		if !retryConfig.ResponseChecker.Check(result.response, result.err) {
+			break
+		}
+	}
+
+	if httpContext, ok := ctx.Value(motmedelHttpContext.HttpContextContextKey).(*motmedelHttpTypes.HttpContext); ok && httpContext != nil {
+		*httpContext = *result.httpContext
+	}
+
+	return result.response, result.responseBody, result.err
+}
+
+// fetchAttempt makes one attempt of a fetch with a retry config, hedging it when the config has a
+// hedger that has observed enough latencies and the request is safe to send more than once.
+func fetchAttempt(
+	ctx context.Context,
+	request *http.Request,
+	fetchConfig *fetch_config.Config,
+) (*http.Response, []byte, error) {
+	requestHedger := fetchConfig.RetryConfig.Hedger
+	if requestHedger == nil || fetchConfig.SkipReadResponseBody || !hedger.IsHedgeable(request) {
+		return fetch(ctx, request, fetchConfig)
+	}
+
+	if delay, ok := requestHedger.Delay(); ok && requestHedger.MaxHedges() > 0 {
+		return fetchHedged(ctx, request, fetchConfig, delay)
+	}
+
+	start := time.Now()
+	response, responseBody, err := fetch(ctx, request, fetchConfig)
+	if err == nil {
+		requestHedger.Observe(time.Since(start))
+	}
+
+	return response, responseBody, err
+}
+
 func fetchWithRetryConfig(
 	ctx context.Context,
 	request *http.Request,
 	fetchConfig *fetch_config.Config,
+	attempt fetchFunc,
 ) (*http.Response, []byte, error) {
 	if request == nil {
 		return nil, nil, nil
@@ -246,6 +410,15 @@ func fetchWithRetryConfig(
 
 	for i := range 1 + retryConfig.Count {
 		if i != 0 {
+			// A body that was streamed and cannot be replayed cannot be sent again.
+			if request.GetBody == nil && request.Body != nil && request.Body != http.NoBody {
+				break
+			}
+
+			if retryBudget := retryConfig.RetryBudget; retryBudget != nil && !retryBudget.AllowRetry() {
+				break
+			}
+
 			// Wait before the next attempt, based on the previous response.
 			waitDuration, giveUp := retryWaitDuration(retryConfig, response, responseBody, i)
 			if giveUp {
@@ -271,11 +444,44 @@ func fetchWithRetryConfig(
 				}
 				request.Body = newBody
 			}
+
+			// The body of a response that was not read is left open by the attempt.
+			if fetchConfig.SkipReadResponseBody && response != nil && response.Body != nil {
+				_ = response.Body.Close()
+			}
 		}
 
-		response, responseBody, err = fetch(ctx, request, fetchConfig)
+		var done circuit_breaker.DoneFunc
+		if circuitBreaker := retryConfig.CircuitBreaker; circuitBreaker != nil {
+			var breakerErr error
+			var host string
+			if requestUrl := request.URL; requestUrl != nil {
+				host = requestUrl.Host
+			}
+
+			done, breakerErr = circuitBreaker.Allow(ctx, host)
+			if breakerErr != nil {
+				response, responseBody = nil, nil
+				err = motmedelErrors.NewWithTrace(fmt.Errorf("circuit breaker allow: %w", breakerErr))
+				break
+			}
+		}
 
-		if !retryConfig.ResponseChecker.Check(response, err) {
+		response, responseBody, err = attempt(ctx, request, fetchConfig)
+
+		shouldRetry := retryConfig.ResponseChecker.Check(response, err)
+		if done != nil {
+			done(shouldRetry)
+		}
+		if retryBudget := retryConfig.RetryBudget; retryBudget != nil {
+			if shouldRetry {
+				retryBudget.RecordFailure()
+			} else {
+				retryBudget.RecordSuccess()
+			}
+		}
+
+		if !shouldRetry {
 			break
 		}
 
@@ -290,6 +496,48 @@ func fetchWithRetryConfig(
 	return response, responseBody, nil
 }
 
+// newFetchConfig returns the configuration of the options, and sets its headers on the request.
+func newFetchConfig(request *http.Request, options ...fetch_config.Option) *fetch_config.Config {
+	fetchConfig := fetch_config.New(options...)
+	if request.Header != nil {
+		for key, value := range fetchConfig.Headers {
+			request.Header.Set(key, value)
+		}
+	}
+
+	return fetchConfig
+}
+
+// newRequest returns a request to the URL with the method and body of the configuration; a body
+// reader is streamed in place of the body.
+func newRequest(ctx context.Context, url string, fetchConfig *fetch_config.Config) (*http.Request, error) {
+	method := fetchConfig.Method
+
+	var body io.Reader = bytes.NewBuffer(fetchConfig.Body)
+	if fetchConfig.BodyReader != nil {
+		body = fetchConfig.BodyReader
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("http new request: %w", err), method)
+	}
+	if request == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("request"))
+	}
+
+	if fetchConfig.BodyReader != nil && fetchConfig.GetBody != nil {
+		request.GetBody = fetchConfig.GetBody
+	}
+
+	requestHeader := request.Header
+	if requestHeader == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("request header"))
+	}
+
+	return request, nil
+}
+
 func FetchWithRequest(ctx context.Context, request *http.Request, options ...fetch_config.Option) (*http.Response, []byte, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, nil, fmt.Errorf("context err: %w", err)
@@ -299,12 +547,7 @@ func FetchWithRequest(ctx context.Context, request *http.Request, options ...fet
 		return nil, nil, nil
 	}
 
-	fetchConfig := fetch_config.New(options...)
-	if request.Header != nil {
-		for key, value := range fetchConfig.Headers {
-			request.Header.Set(key, value)
-		}
-	}
+	fetchConfig := newFetchConfig(request, options...)
 
 	var response *http.Response
 	var responseBody []byte
@@ -312,7 +555,7 @@ func FetchWithRequest(ctx context.Context, request *http.Request, options ...fet
 	var errString string
 
 	if fetchConfig.RetryConfig != nil {
-		response, responseBody, err = fetchWithRetryConfig(ctx, request, fetchConfig)
+		response, responseBody, err = fetchWithRetryConfig(ctx, request, fetchConfig, fetchAttempt)
 		errString = " with retry config"
 	} else {
 		response, responseBody, err = fetch(ctx, request, fetchConfig)
@@ -333,23 +576,96 @@ func Fetch(ctx context.Context, url string, options ...fetch_config.Option) (*ht
 		return nil, nil, motmedelErrors.NewWithTrace(empty_error.New("url"))
 	}
 
-	fetchConfig := fetch_config.New(options...)
-	method := fetchConfig.Method
+	request, err := newRequest(ctx, url, fetch_config.New(options...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return FetchWithRequest(ctx, request, options...)
+}
+
+// fetchStreamAttempt makes one attempt of a streamed fetch. It peeks at the first byte of the body,
+// so that a failure before it is a failure of the attempt and can be retried; after it, failures
+// are the reader's. The body of a response with an error status is read, up to the limit, for the
+// retry logic to go by.
+func fetchStreamAttempt(ctx context.Context, request *http.Request, fetchConfig *fetch_config.Config) (*http.Response, []byte, error) {
+	response, _, err := fetch(ctx, request, fetchConfig)
+	if response == nil || response.Body == nil {
+		return response, nil, err
+	}
 
-	request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(fetchConfig.Body))
 	if err != nil {
-		return nil, nil, motmedelErrors.NewWithTrace(fmt.Errorf("http new request: %w", err), method)
+		var errorBody []byte
+		if !errors.Is(err, motmedelHttpErrors.ErrResponseTooLarge) {
+			errorBody, _ = io.ReadAll(io.LimitReader(response.Body, streamErrorBodyLimit))
+		}
+		_ = response.Body.Close()
+
+		return response, errorBody, err
+	}
+
+	reader := bufio.NewReader(response.Body)
+	if _, peekErr := reader.Peek(1); peekErr != nil && !errors.Is(peekErr, io.EOF) {
+		_ = response.Body.Close()
+		return nil, nil, motmedelErrors.NewWithTrace(fmt.Errorf("bufio reader peek (response body): %w", peekErr))
 	}
+	response.Body = &streamBody{Reader: reader, Closer: response.Body}
+
+	return response, nil, nil
+}
+
+// FetchStreamWithRequest is FetchWithRequest for bodies that are to be streamed rather than read
+// into memory: it returns the response body, which the caller must close, unread. With a retry
+// config, attempts are retried until the first byte of a body has been received.
+func FetchStreamWithRequest(ctx context.Context, request *http.Request, options ...fetch_config.Option) (*http.Response, io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, fmt.Errorf("context err: %w", err)
+	}
+
 	if request == nil {
-		return nil, nil, motmedelErrors.NewWithTrace(nil_error.New("request"))
+		return nil, nil, nil
 	}
 
-	requestHeader := request.Header
-	if requestHeader == nil {
-		return nil, nil, motmedelErrors.NewWithTrace(nil_error.New("request header"))
+	options = append(options, fetch_config.WithSkipReadResponseBody(true))
+	fetchConfig := newFetchConfig(request, options...)
+
+	var response *http.Response
+	var err error
+	var errString string
+
+	if fetchConfig.RetryConfig != nil {
+		response, _, err = fetchWithRetryConfig(ctx, request, fetchConfig, fetchStreamAttempt)
+		errString = " with retry config"
+	} else {
+		response, _, err = fetchStreamAttempt(ctx, request, fetchConfig)
+	}
+	if err != nil {
+		return response, nil, fmt.Errorf("fetch stream%s: %w", errString, err)
+	}
+	if response == nil {
+		return nil, nil, motmedelErrors.NewWithTrace(nil_error.New("http response"))
 	}
 
-	return FetchWithRequest(ctx, request, options...)
+	return response, response.Body, nil
+}
+
+// FetchStream is Fetch for bodies that are to be streamed rather than read into memory; see
+// FetchStreamWithRequest.
+func FetchStream(ctx context.Context, url string, options ...fetch_config.Option) (*http.Response, io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, fmt.Errorf("context err: %w", err)
+	}
+
+	if url == "" {
+		return nil, nil, motmedelErrors.NewWithTrace(empty_error.New("url"))
+	}
+
+	request, err := newRequest(ctx, url, fetch_config.New(options...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return FetchStreamWithRequest(ctx, request, options...)
 }
 
 func FetchJson[U any](ctx context.Context, url string, options ...fetch_config.Option) (*http.Response, U, error) {
@@ -575,12 +891,26 @@ func ParseLastModifiedTimestamp(timestamp string) (time.Time, error) {
 	return t, nil
 }
 
+// IfNoneMatchCacheHit reports whether an If-None-Match value matches the ETag. The comparison is
+// the weak one (RFC 9110, Section 13.1.2), so a tag weakened when a response was encoded still
+// matches.
 func IfNoneMatchCacheHit(ifNoneMatchValue string, etag string) bool {
 	if ifNoneMatchValue == "" || etag == "" {
 		return false
 	}
 
-	return ifNoneMatchValue == etag
+	if strings.TrimSpace(ifNoneMatchValue) == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for tag := range strings.SplitSeq(ifNoneMatchValue, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+
+	return false
 }
 
 func IfModifiedSinceCacheHit(ifModifiedSinceValue string, lastModifiedValue string) (bool, error) {
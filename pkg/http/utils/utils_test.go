@@ -7,14 +7,21 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	motmedelHttpContext "github.com/Motmedel/utils_go/pkg/http/context"
 	motmedelHttpErrors "github.com/Motmedel/utils_go/pkg/http/errors"
 	motmedelHttpTypes "github.com/Motmedel/utils_go/pkg/http/types"
 	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
 	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/circuit_breaker"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/circuit_breaker/circuit_breaker_config"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/hedger"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/hedger/hedger_config"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config/retry_config/retry_budget"
 )
 
 // serve starts an httptest server that is closed when the test ends.
@@ -374,6 +381,299 @@ func TestFetch_RetryExhaustedReturnsError(t *testing.T) {
 	}
 }
 
+func TestFetch_CircuitBreakerFailsFast(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	server := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	breaker := circuit_breaker.New(circuit_breaker_config.WithMinimumRequests(2))
+	retryConfig := retry_config.New(
+		retry_config.WithBaseDelay(time.Millisecond),
+		retry_config.WithCircuitBreaker(breaker),
+	)
+
+	_, _, err := Fetch(context.Background(), server.URL, fetch_config.WithRetryConfig(retryConfig))
+	if !errors.Is(err, motmedelHttpErrors.ErrCircuitOpen) {
+		t.Fatalf("err = %v, want it to wrap ErrCircuitOpen", err)
+	}
+	// The circuit opened after the second attempt, so the third was never sent.
+	if got := attempts.Load(); got != 2 {
+		t.Fatalf("attempts = %d, want 2", got)
+	}
+
+	_, _, err = Fetch(context.Background(), server.URL, fetch_config.WithRetryConfig(retryConfig))
+	if !errors.Is(err, motmedelHttpErrors.ErrCircuitOpen) {
+		t.Fatalf("err = %v, want it to wrap ErrCircuitOpen", err)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Fatalf("attempts = %d, want 2", got)
+	}
+}
+
+func TestFetch_RetryBudgetLimitsRetries(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	server := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	budget := retry_budget.New(4, 0.1)
+	retryConfig := retry_config.New(
+		retry_config.WithBaseDelay(time.Millisecond),
+		retry_config.WithRetryBudget(budget),
+	)
+
+	// The first call spends two tokens, leaving too few for the second call to retry.
+	for range 2 {
+		if _, _, err := Fetch(context.Background(), server.URL, fetch_config.WithRetryConfig(retryConfig)); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Fatalf("attempts = %d, want 3", got)
+	}
+}
+
+func TestFetch_HedgesSlowRequests(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	server := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 2 {
+			// The first request is answered quickly, to give the hedger a latency to go by; the
+			// second hangs until it is canceled, and the third, its hedge, is answered.
+			<-r.Context().Done()
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	})
+
+	requestHedger := hedger.New(
+		hedger_config.WithMinimumSamples(1),
+		hedger_config.WithMinimumDelay(10*time.Millisecond),
+	)
+	retryConfig := retry_config.New(retry_config.WithCount(0), retry_config.WithHedger(requestHedger))
+
+	if _, _, err := Fetch(context.Background(), server.URL, fetch_config.WithRetryConfig(retryConfig)); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	httpContext := &motmedelHttpTypes.HttpContext{}
+	ctx := motmedelHttpContext.WithHttpContextValue(context.Background(), httpContext)
+
+	start := time.Now()
+	_, body, err := Fetch(ctx, server.URL, fetch_config.WithRetryConfig(retryConfig))
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if string(body) != "ok" {
+		t.Fatalf("body = %q, want %q", body, "ok")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("elapsed = %v, want the hedge to answer", elapsed)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Fatalf("attempts = %d, want 3", got)
+	}
+	if string(httpContext.ResponseBody) != "ok" {
+		t.Fatalf("http context response body = %q, want %q", httpContext.ResponseBody, "ok")
+	}
+}
+
+func TestFetch_MaxResponseBytes(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("a", 100)
+	server := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/streamed" {
+			// Flushing before writing leaves the length of the body unknown.
+			w.(http.Flusher).Flush()
+		}
+		_, _ = io.WriteString(w, body)
+	})
+
+	testCases := []struct {
+		name              string
+		path              string
+		maxBytes          int64
+		wantContentLength int64
+		wantErr           bool
+	}{
+		{name: "Content-Length over the limit", path: "/", maxBytes: 10, wantContentLength: 100, wantErr: true},
+		{name: "Streamed over the limit", path: "/streamed", maxBytes: 10, wantContentLength: -1, wantErr: true},
+		{name: "At the limit", path: "/streamed", maxBytes: 100},
+		{name: "No limit", path: "/streamed"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, got, err := Fetch(
+				context.Background(),
+				server.URL+testCase.path,
+				fetch_config.WithMaxResponseBytes(testCase.maxBytes),
+			)
+			if !testCase.wantErr {
+				if err != nil || string(got) != body {
+					t.Fatalf("Fetch: got %d bytes, %v", len(got), err)
+				}
+				return
+			}
+
+			var responseTooLargeError *motmedelHttpErrors.ResponseTooLargeError
+			if !errors.As(err, &responseTooLargeError) {
+				t.Fatalf("err = %v, want a *ResponseTooLargeError", err)
+			}
+			if responseTooLargeError.Limit != testCase.maxBytes ||
+				responseTooLargeError.ContentLength != testCase.wantContentLength {
+				t.Errorf("error = %+v", responseTooLargeError)
+			}
+		})
+	}
+}
+
+func TestFetchStream_Success(t *testing.T) {
+	t.Parallel()
+
+	server := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "streamed")
+	})
+
+	response, body, err := FetchStream(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("FetchStream: %v", err)
+	}
+	defer body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", response.StatusCode)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil || string(data) != "streamed" {
+		t.Fatalf("body = %q, %v, want %q", data, err, "streamed")
+	}
+}
+
+func TestFetchStream_RetriesUntilFirstByte(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	server := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		switch attempts.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			// The connection is dropped after the headers, before the first byte of the body.
+			w.Header().Set("Content-Length", "8")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		default:
+			_, _ = io.WriteString(w, "streamed")
+		}
+	})
+
+	retryConfig := retry_config.New(retry_config.WithBaseDelay(time.Millisecond))
+	_, body, err := FetchStream(context.Background(), server.URL, fetch_config.WithRetryConfig(retryConfig))
+	if err != nil {
+		t.Fatalf("FetchStream: %v", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil || string(data) != "streamed" {
+		t.Fatalf("body = %q, %v, want %q", data, err, "streamed")
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Fatalf("attempts = %d, want 3", got)
+	}
+}
+
+func TestFetchStream_Non2xxStatusReturnsError(t *testing.T) {
+	t.Parallel()
+
+	server := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	response, body, err := FetchStream(context.Background(), server.URL)
+	var non2xx *motmedelHttpErrors.Non2xxStatusCodeError
+	if !errors.As(err, &non2xx) || non2xx.StatusCode != http.StatusNotFound {
+		t.Fatalf("err = %v, want a 404 *Non2xxStatusCodeError", err)
+	}
+	if body != nil {
+		t.Error("expected no body")
+	}
+	if response == nil || response.StatusCode != http.StatusNotFound {
+		t.Errorf("response = %v, want the 404 response", response)
+	}
+}
+
+func TestFetch_BodyReader(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		getBody      func() (io.ReadCloser, error)
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{
+			name: "Replayable",
+			getBody: func() (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader("payload")), nil
+			},
+			wantAttempts: 2,
+		},
+		// Without GetBody, a drained reader is not sent again.
+		{name: "Not replayable", wantAttempts: 1, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts atomic.Int32
+			server := serve(t, func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				if string(data) != "payload" {
+					t.Errorf("request body = %q, want %q", data, "payload")
+				}
+				if attempts.Add(1) == 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			})
+
+			// A reader of an unknown type, which http.NewRequest cannot replay by itself.
+			bodyReader := io.MultiReader(strings.NewReader("payload"))
+
+			retryConfig := retry_config.New(retry_config.WithBaseDelay(time.Millisecond))
+			_, _, err := Fetch(
+				context.Background(),
+				server.URL,
+				fetch_config.WithMethod(http.MethodPut),
+				fetch_config.WithBodyReader(bodyReader, testCase.getBody),
+				fetch_config.WithRetryConfig(retryConfig),
+			)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("err = %v, want error %v", err, testCase.wantErr)
+			}
+			if got := attempts.Load(); got != testCase.wantAttempts {
+				t.Fatalf("attempts = %d, want %d", got, testCase.wantAttempts)
+			}
+		})
+	}
+}
+
 func TestMakeStrongEtag(t *testing.T) {
 	t.Parallel()
 
@@ -797,6 +1097,9 @@ func TestIfNoneMatchCacheHit(t *testing.T) {
 		{name: "empty etag", ifNoneMatch: `"abc"`, etag: "", want: false},
 		{name: "match", ifNoneMatch: `"abc"`, etag: `"abc"`, want: true},
 		{name: "mismatch", ifNoneMatch: `"abc"`, etag: `"def"`, want: false},
+		{name: "weak match", ifNoneMatch: `W/"abc"`, etag: `"abc"`, want: true},
+		{name: "list", ifNoneMatch: `"def", W/"abc"`, etag: `"abc"`, want: true},
+		{name: "any", ifNoneMatch: `*`, etag: `"abc"`, want: true},
 	}
 
 	for _, tc := range testCases {
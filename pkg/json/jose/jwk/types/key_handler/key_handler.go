@@ -3,6 +3,7 @@ package key_handler
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -18,6 +19,17 @@ import (
 	"github.com/Motmedel/utils_go/pkg/utils"
 )
 
+// getAge returns the Age of a response, which a cache sets on a response it stored a while ago; the
+// keys are fresh for that much less than the max-age.
+func getAge(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(header.Get("Age")))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
 type Handler struct {
 	JwkUrl *url.URL
 	config *key_handler_config.Config
@@ -65,7 +77,7 @@ func (h *Handler) GetNamedVerifier(ctx context.Context, keyId string) (motmedelC
 					if strings.HasPrefix(d, "max-age=") {
 						v := strings.TrimSpace(strings.TrimPrefix(d, "max-age="))
 						if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
-							maxAgeExpiresAt := time.Now().Add(time.Duration(secs) * time.Second)
+							maxAgeExpiresAt := time.Now().Add(time.Duration(secs)*time.Second - getAge(responseHeader))
 							h.keysExpiresAt = &maxAgeExpiresAt
 							usedCacheControl = true
 						}
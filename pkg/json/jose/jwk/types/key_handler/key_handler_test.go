@@ -16,7 +16,10 @@ import (
 
 	motmedelCryptoEcdsa "github.com/Motmedel/utils_go/pkg/crypto/ecdsa"
 	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/client_cache"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
 	ecKey "github.com/Motmedel/utils_go/pkg/json/jose/jwk/types/key/ec"
+	"github.com/Motmedel/utils_go/pkg/json/jose/jwk/types/key_handler/key_handler_config"
 )
 
 // ecJwk generates a P-256 key pair and returns its JWK map (with the given kid)
@@ -252,3 +255,65 @@ func TestHandler_GetNamedVerifier_FetchError(t *testing.T) {
 		t.Fatal("expected fetch error but got nil")
 	}
 }
+
+func TestHandler_GetNamedVerifier_ClientCache(t *testing.T) {
+	t.Parallel()
+
+	keyMap, _ := ecJwk(t, "key-1")
+	server, hits := newTestServer(t, []map[string]any{keyMap})
+	transport := client_cache.New()
+
+	// Handlers that share a caching transport share the fetched keys.
+	for range 2 {
+		handler, err := New(
+			mustParseURL(t, server.URL),
+			key_handler_config.WithFetchOptions(fetch_config.WithTransport(transport)),
+		)
+		if err != nil {
+			t.Fatalf("new handler: %v", err)
+		}
+
+		if _, err := handler.GetNamedVerifier(context.Background(), "key-1"); err != nil {
+			t.Fatalf("get named verifier: %v", err)
+		}
+	}
+
+	if got := hits.Load(); got != 1 {
+		t.Errorf("expected 1 fetch, got %d", got)
+	}
+}
+
+func TestHandler_GetNamedVerifier_Age(t *testing.T) {
+	t.Parallel()
+
+	keyMap, _ := ecJwk(t, "key-1")
+	body, err := json.Marshal(map[string]any{"keys": []map[string]any{keyMap}})
+	if err != nil {
+		t.Fatalf("marshal jwks: %v", err)
+	}
+
+	// A response as old as its max-age is stale already, so the keys are fetched again.
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Header().Set("Age", "3600")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	handler, err := New(mustParseURL(t, server.URL))
+	if err != nil {
+		t.Fatalf("new handler: %v", err)
+	}
+
+	for range 2 {
+		if _, err := handler.GetNamedVerifier(context.Background(), "key-1"); err != nil {
+			t.Fatalf("get named verifier: %v", err)
+		}
+	}
+
+	if got := hits.Load(); got != 2 {
+		t.Errorf("expected 2 fetches, got %d", got)
+	}
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+)
+
+// atomicFloat is a float64 that is added to atomically.
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (value *atomicFloat) add(delta float64) {
+	for {
+		old := value.bits.Load()
+		if value.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+func (value *atomicFloat) set(newValue float64) {
+	value.bits.Store(math.Float64bits(newValue))
+}
+
+func (value *atomicFloat) load() float64 {
+	return math.Float64frombits(value.bits.Load())
+}
+
+// Counter is a metric that only goes up, such as the number of requests served.
+type Counter struct {
+	name   string
+	help   string
+	vector vector[*CounterSeries]
+}
+
+// CounterSeries is the counter of one combination of label values.
+type CounterSeries struct {
+	value atomicFloat
+}
+
+// Add adds a value, which is to be positive; a counter does not go down.
+func (series *CounterSeries) Add(value float64) {
+	if series == nil || !(value >= 0) {
+		return
+	}
+
+	series.value.add(value)
+}
+
+func (series *CounterSeries) Inc() {
+	series.Add(1)
+}
+
+func (series *CounterSeries) Value() float64 {
+	if series == nil {
+		return 0
+	}
+
+	return series.value.load()
+}
+
+// With returns the series of the label values, given in the order of the label names. It is nil,
+// which its methods ignore, where the values do not match the names in number.
+func (counter *Counter) With(labelValues ...string) *CounterSeries {
+	series, _ := counter.vector.with(labelValues)
+	return series
+}
+
+func (counter *Counter) familyName() string {
+	return counter.name
+}
+
+func (counter *Counter) write(buffer *bytes.Buffer) {
+	writeHeader(buffer, counter.name, typeCounter, counter.help)
+
+	labelNames := counter.vector.labelNames
+	entries := counter.vector.sorted()
+	// A counter without labels exists from the start, at zero.
+	if len(labelNames) == 0 && len(entries) == 0 {
+		writeSample(buffer, counter.name+"_total", nil, nil, [2]string{}, 0)
+	}
+	for _, entry := range entries {
+		writeSample(buffer, counter.name+"_total", labelNames, entry.labelValues, [2]string{}, entry.series.Value())
+	}
+}
+
+// NewCounter registers a counter. Its samples are written out with the "_total" suffix OpenMetrics
+// gives a counter, which the name is given without or with.
+func (registry *Registry) NewCounter(name string, help string, labelNames ...string) (*Counter, error) {
+	name = strings.TrimSuffix(name, "_total")
+	if err := validateNames(name, labelNames); err != nil {
+		return nil, fmt.Errorf("validate names: %w", err)
+	}
+
+	counter := &Counter{
+		name: name,
+		help: help,
+		vector: vector[*CounterSeries]{
+			labelNames: labelNames,
+			newSeries:  func() *CounterSeries { return &CounterSeries{} },
+		},
+	}
+	if err := registry.register(counter); err != nil {
+		return nil, fmt.Errorf("register: %w", err)
+	}
+
+	return counter, nil
+}
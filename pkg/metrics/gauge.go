@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Gauge is a metric that goes up and down, such as the number of requests being handled.
+type Gauge struct {
+	name   string
+	help   string
+	vector vector[*GaugeSeries]
+}
+
+// GaugeSeries is the gauge of one combination of label values.
+type GaugeSeries struct {
+	value atomicFloat
+}
+
+func (series *GaugeSeries) Set(value float64) {
+	if series == nil {
+		return
+	}
+
+	series.value.set(value)
+}
+
+func (series *GaugeSeries) Add(value float64) {
+	if series == nil {
+		return
+	}
+
+	series.value.add(value)
+}
+
+func (series *GaugeSeries) Inc() {
+	series.Add(1)
+}
+
+func (series *GaugeSeries) Dec() {
+	series.Add(-1)
+}
+
+func (series *GaugeSeries) Value() float64 {
+	if series == nil {
+		return 0
+	}
+
+	return series.value.load()
+}
+
+// With returns the series of the label values, given in the order of the label names. It is nil,
+// which its methods ignore, where the values do not match the names in number.
+func (gauge *Gauge) With(labelValues ...string) *GaugeSeries {
+	series, _ := gauge.vector.with(labelValues)
+	return series
+}
+
+func (gauge *Gauge) familyName() string {
+	return gauge.name
+}
+
+func (gauge *Gauge) write(buffer *bytes.Buffer) {
+	writeHeader(buffer, gauge.name, typeGauge, gauge.help)
+
+	labelNames := gauge.vector.labelNames
+	entries := gauge.vector.sorted()
+	if len(labelNames) == 0 && len(entries) == 0 {
+		writeSample(buffer, gauge.name, nil, nil, [2]string{}, 0)
+	}
+	for _, entry := range entries {
+		writeSample(buffer, gauge.name, labelNames, entry.labelValues, [2]string{}, entry.series.Value())
+	}
+}
+
+func (registry *Registry) NewGauge(name string, help string, labelNames ...string) (*Gauge, error) {
+	if err := validateNames(name, labelNames); err != nil {
+		return nil, fmt.Errorf("validate names: %w", err)
+	}
+
+	gauge := &Gauge{
+		name: name,
+		help: help,
+		vector: vector[*GaugeSeries]{
+			labelNames: labelNames,
+			newSeries:  func() *GaugeSeries { return &GaugeSeries{} },
+		},
+	}
+	if err := registry.register(gauge); err != nil {
+		return nil, fmt.Errorf("register: %w", err)
+	}
+
+	return gauge, nil
+}
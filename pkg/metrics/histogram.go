@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"slices"
+	"sync/atomic"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+)
+
+// DefaultBuckets are the upper bounds of a histogram of durations in seconds, from five
+// milliseconds to ten seconds; they are Prometheus's own defaults.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// ExponentialBuckets are count upper bounds, the first being start and each following one factor
+// times the one before.
+func ExponentialBuckets(start float64, factor float64, count int) []float64 {
+	if start <= 0 || factor <= 1 || count < 1 {
+		return nil
+	}
+
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+
+	return buckets
+}
+
+// Histogram is a metric that counts observations, such as durations, by the buckets they fall in.
+type Histogram struct {
+	name    string
+	help    string
+	buckets []float64
+	vector  vector[*HistogramSeries]
+}
+
+// HistogramSeries is the histogram of one combination of label values.
+type HistogramSeries struct {
+	buckets []float64
+	// counts are of the observations in each bucket and not the ones below it, and the last is of
+	// the observations above every bound.
+	counts []atomic.Uint64
+	sum    atomicFloat
+}
+
+// Observe counts a value in the bucket it falls in. NaN is not counted.
+func (series *HistogramSeries) Observe(value float64) {
+	if series == nil || math.IsNaN(value) {
+		return
+	}
+
+	index, _ := slices.BinarySearch(series.buckets, value)
+	series.counts[index].Add(1)
+	series.sum.add(value)
+}
+
+// Count is the number of values observed.
+func (series *HistogramSeries) Count() uint64 {
+	if series == nil {
+		return 0
+	}
+
+	var count uint64
+	for i := range series.counts {
+		count += series.counts[i].Load()
+	}
+
+	return count
+}
+
+func (series *HistogramSeries) Sum() float64 {
+	if series == nil {
+		return 0
+	}
+
+	return series.sum.load()
+}
+
+// With returns the series of the label values, given in the order of the label names. It is nil,
+// which its methods ignore, where the values do not match the names in number.
+func (histogram *Histogram) With(labelValues ...string) *HistogramSeries {
+	series, _ := histogram.vector.with(labelValues)
+	return series
+}
+
+func (histogram *Histogram) familyName() string {
+	return histogram.name
+}
+
+func (histogram *Histogram) writeSeries(buffer *bytes.Buffer, labelValues []string, series *HistogramSeries) {
+	labelNames := histogram.vector.labelNames
+
+	// The buckets are cumulative, and the count is the last of them, so that the two agree however
+	// the observations made meanwhile are interleaved with the loading.
+	var cumulative uint64
+	for i, bound := range histogram.buckets {
+		cumulative += series.counts[i].Load()
+		writeSample(
+			buffer, histogram.name+"_bucket", labelNames, labelValues,
+			[2]string{"le", formatFloat(bound)}, float64(cumulative),
+		)
+	}
+	cumulative += series.counts[len(histogram.buckets)].Load()
+	writeSample(
+		buffer, histogram.name+"_bucket", labelNames, labelValues,
+		[2]string{"le", "+Inf"}, float64(cumulative),
+	)
+	writeSample(buffer, histogram.name+"_count", labelNames, labelValues, [2]string{}, float64(cumulative))
+	writeSample(buffer, histogram.name+"_sum", labelNames, labelValues, [2]string{}, series.Sum())
+}
+
+func (histogram *Histogram) write(buffer *bytes.Buffer) {
+	writeHeader(buffer, histogram.name, typeHistogram, histogram.help)
+
+	entries := histogram.vector.sorted()
+	if len(histogram.vector.labelNames) == 0 && len(entries) == 0 {
+		histogram.writeSeries(buffer, nil, histogram.vector.newSeries())
+	}
+	for _, entry := range entries {
+		histogram.writeSeries(buffer, entry.labelValues, entry.series)
+	}
+}
+
+// NewHistogram registers a histogram with the upper bounds of its buckets, which are to be
+// increasing; DefaultBuckets are used where none are given. A bucket of everything above them is
+// implied.
+func (registry *Registry) NewHistogram(
+	name string,
+	help string,
+	buckets []float64,
+	labelNames ...string,
+) (*Histogram, error) {
+	// The bucket a sample belongs to is said by the "le" label.
+	if err := validateNames(name, labelNames, "le"); err != nil {
+		return nil, fmt.Errorf("validate names: %w", err)
+	}
+
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	if len(buckets) == 0 {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("buckets"), name)
+	}
+	for i, bound := range buckets {
+		if math.IsNaN(bound) || math.IsInf(bound, 0) || (i != 0 && bound <= buckets[i-1]) {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: buckets that are not finite and increasing", motmedelErrors.ErrValidationError),
+				name, buckets,
+			)
+		}
+	}
+	buckets = slices.Clone(buckets)
+
+	histogram := &Histogram{
+		name:    name,
+		help:    help,
+		buckets: buckets,
+		vector: vector[*HistogramSeries]{
+			labelNames: labelNames,
+			newSeries: func() *HistogramSeries {
+				return &HistogramSeries{buckets: buckets, counts: make([]atomic.Uint64, len(buckets)+1)}
+			},
+		},
+	}
+	if err := registry.register(histogram); err != nil {
+		return nil, fmt.Errorf("register: %w", err)
+	}
+
+	return histogram, nil
+}
@@ -0,0 +1,252 @@
+// Package metrics records counters, gauges and histograms, and writes them out in the OpenMetrics
+// text format (https://prometheus.io/docs/specs/om/open_metrics_spec/) that Prometheus scrapes.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+)
+
+// OpenMetricsContentType is the content type the text format is served as.
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+var (
+	metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegexp  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// labelValuesSeparator separates the label values of a series in the key it is looked up by; it
+// cannot occur in a valid UTF-8 label value.
+const labelValuesSeparator = "\xff"
+
+var escapeReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// family is a metric of a name, with one series per combination of label values.
+type family interface {
+	familyName() string
+	write(buffer *bytes.Buffer)
+}
+
+// Registry holds metrics and writes them out.
+type Registry struct {
+	mutex    sync.Mutex
+	families map[string]family
+}
+
+func (registry *Registry) register(f family) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	name := f.familyName()
+	if _, found := registry.families[name]; found {
+		return motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: a metric name registered more than once", motmedelErrors.ErrValidationError),
+			name,
+		)
+	}
+
+	if registry.families == nil {
+		registry.families = make(map[string]family)
+	}
+	registry.families[name] = f
+
+	return nil
+}
+
+// WriteOpenMetrics writes out the metrics in the OpenMetrics text format, ordered by name.
+func (registry *Registry) WriteOpenMetrics(writer io.Writer) error {
+	if writer == nil {
+		return motmedelErrors.NewWithTrace(nil_error.New("writer"))
+	}
+
+	registry.mutex.Lock()
+	families := make([]family, 0, len(registry.families))
+	for _, name := range slices.Sorted(maps.Keys(registry.families)) {
+		families = append(families, registry.families[name])
+	}
+	registry.mutex.Unlock()
+
+	var buffer bytes.Buffer
+	for _, f := range families {
+		f.write(&buffer)
+	}
+	buffer.WriteString("# EOF\n")
+
+	if _, err := writer.Write(buffer.Bytes()); err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("writer write: %w", err))
+	}
+
+	return nil
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func validateNames(name string, labelNames []string, reservedLabelNames ...string) error {
+	if name == "" {
+		return motmedelErrors.NewWithTrace(empty_error.New("metric name"))
+	}
+	if !metricNameRegexp.MatchString(name) {
+		return motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: metric name", motmedelErrors.ErrValidationError),
+			name,
+		)
+	}
+
+	seen := make(map[string]struct{}, len(labelNames))
+	for _, labelName := range labelNames {
+		// Names beginning with two underscores are reserved for Prometheus's own use.
+		if !labelNameRegexp.MatchString(labelName) || strings.HasPrefix(labelName, "__") ||
+			slices.Contains(reservedLabelNames, labelName) {
+			return motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: label name", motmedelErrors.ErrValidationError),
+				name, labelName,
+			)
+		}
+		if _, found := seen[labelName]; found {
+			return motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: a label name used more than once", motmedelErrors.ErrValidationError),
+				name, labelName,
+			)
+		}
+		seen[labelName] = struct{}{}
+	}
+
+	return nil
+}
+
+// vector holds the series of a family, by label values.
+type vector[T any] struct {
+	labelNames []string
+	newSeries  func() T
+	mutex      sync.RWMutex
+	series     map[string]*labeledSeries[T]
+}
+
+type labeledSeries[T any] struct {
+	labelValues []string
+	series      T
+}
+
+// with returns the series of the label values, making it where there is none. It reports false
+// where the label values do not match the label names in number.
+func (vector *vector[T]) with(labelValues []string) (T, bool) {
+	if len(labelValues) != len(vector.labelNames) {
+		var zero T
+		return zero, false
+	}
+
+	key := strings.Join(labelValues, labelValuesSeparator)
+
+	vector.mutex.RLock()
+	entry, found := vector.series[key]
+	vector.mutex.RUnlock()
+	if found {
+		return entry.series, true
+	}
+
+	vector.mutex.Lock()
+	defer vector.mutex.Unlock()
+
+	if entry, found := vector.series[key]; found {
+		return entry.series, true
+	}
+	if vector.series == nil {
+		vector.series = make(map[string]*labeledSeries[T])
+	}
+	entry = &labeledSeries[T]{labelValues: slices.Clone(labelValues), series: vector.newSeries()}
+	vector.series[key] = entry
+
+	return entry.series, true
+}
+
+// sorted returns the series ordered by their label values.
+func (vector *vector[T]) sorted() []*labeledSeries[T] {
+	vector.mutex.RLock()
+	defer vector.mutex.RUnlock()
+
+	entries := make([]*labeledSeries[T], 0, len(vector.series))
+	for _, key := range slices.Sorted(maps.Keys(vector.series)) {
+		entries = append(entries, vector.series[key])
+	}
+
+	return entries
+}
+
+func writeHeader(buffer *bytes.Buffer, name string, typeName string, help string) {
+	fmt.Fprintf(buffer, "# TYPE %s %s\n", name, typeName)
+	if help != "" {
+		fmt.Fprintf(buffer, "# HELP %s %s\n", name, escape(help))
+	}
+}
+
+// writeSample writes a sample of the name, labelled with the label names and values and then with
+// the extra label, where there is one.
+func writeSample(
+	buffer *bytes.Buffer,
+	name string,
+	labelNames []string,
+	labelValues []string,
+	extraLabel [2]string,
+	value float64,
+) {
+	buffer.WriteString(name)
+
+	if len(labelNames) != 0 || extraLabel[0] != "" {
+		buffer.WriteByte('{')
+		for i, labelName := range labelNames {
+			if i != 0 {
+				buffer.WriteByte(',')
+			}
+			fmt.Fprintf(buffer, `%s="%s"`, labelName, escape(labelValues[i]))
+		}
+		if extraLabel[0] != "" {
+			if len(labelNames) != 0 {
+				buffer.WriteByte(',')
+			}
+			fmt.Fprintf(buffer, `%s="%s"`, extraLabel[0], escape(extraLabel[1]))
+		}
+		buffer.WriteByte('}')
+	}
+
+	buffer.WriteByte(' ')
+	buffer.WriteString(formatFloat(value))
+	buffer.WriteByte('\n')
+}
+
+// escape escapes a label value or the text of a HELP line.
+func escape(value string) string {
+	return escapeReplacer.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,196 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestRegistry_WriteOpenMetrics(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+
+	requests, err := registry.NewCounter("requests_total", "The requests \"served\".", "method", "path")
+	if err != nil {
+		t.Fatalf("new counter: %v", err)
+	}
+	requests.With("GET", "/").Inc()
+	requests.With("GET", "/").Add(2)
+	requests.With("POST", "/a\"b\\c\n").Inc()
+	requests.With("GET", "/").Add(-1)
+	requests.With("too few").Inc()
+
+	if _, err := registry.NewCounter("errors", ""); err != nil {
+		t.Fatalf("new counter: %v", err)
+	}
+
+	active, err := registry.NewGauge("active", "Active.")
+	if err != nil {
+		t.Fatalf("new gauge: %v", err)
+	}
+	active.With().Inc()
+	active.With().Inc()
+	active.With().Dec()
+
+	duration, err := registry.NewHistogram("duration_seconds", "Durations.", []float64{0.1, 1}, "method")
+	if err != nil {
+		t.Fatalf("new histogram: %v", err)
+	}
+	duration.With("GET").Observe(0.05)
+	duration.With("GET").Observe(0.1)
+	duration.With("GET").Observe(0.5)
+	duration.With("GET").Observe(5)
+	duration.With("GET").Observe(math.NaN())
+
+	var buffer bytes.Buffer
+	if err := registry.WriteOpenMetrics(&buffer); err != nil {
+		t.Fatalf("write open metrics: %v", err)
+	}
+
+	want := `# TYPE active gauge
+# HELP active Active.
+active 1
+# TYPE duration_seconds histogram
+# HELP duration_seconds Durations.
+duration_seconds_bucket{method="GET",le="0.1"} 2
+duration_seconds_bucket{method="GET",le="1"} 3
+duration_seconds_bucket{method="GET",le="+Inf"} 4
+duration_seconds_count{method="GET"} 4
+duration_seconds_sum{method="GET"} 5.65
+# TYPE errors counter
+errors_total 0
+# TYPE requests counter
+# HELP requests The requests \"served\".
+requests_total{method="GET",path="/"} 3
+requests_total{method="POST",path="/a\"b\\c\n"} 1
+# EOF
+`
+	if got := buffer.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistry_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	counter, err := registry.NewCounter("counter", "", "label")
+	if err != nil {
+		t.Fatalf("new counter: %v", err)
+	}
+	histogram, err := registry.NewHistogram("histogram", "", nil)
+	if err != nil {
+		t.Fatalf("new histogram: %v", err)
+	}
+
+	var waitGroup sync.WaitGroup
+	for range 8 {
+		waitGroup.Go(func() {
+			for range 1000 {
+				counter.With("value").Inc()
+				histogram.With().Observe(0.2)
+			}
+			_ = registry.WriteOpenMetrics(&bytes.Buffer{})
+		})
+	}
+	waitGroup.Wait()
+
+	if value := counter.With("value").Value(); value != 8000 {
+		t.Errorf("counter: got %v", value)
+	}
+	if count := histogram.With().Count(); count != 8000 {
+		t.Errorf("histogram count: got %d", count)
+	}
+}
+
+func TestRegistry_Validation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		register func(*Registry) error
+	}{
+		{
+			name:     "empty name",
+			register: func(registry *Registry) error { _, err := registry.NewCounter("", ""); return err },
+		},
+		{
+			name:     "invalid name",
+			register: func(registry *Registry) error { _, err := registry.NewGauge("a-b", ""); return err },
+		},
+		{
+			name:     "invalid label name",
+			register: func(registry *Registry) error { _, err := registry.NewGauge("a", "", "b c"); return err },
+		},
+		{
+			name:     "reserved label name",
+			register: func(registry *Registry) error { _, err := registry.NewGauge("a", "", "__b"); return err },
+		},
+		{
+			name:     "duplicate label name",
+			register: func(registry *Registry) error { _, err := registry.NewGauge("a", "", "b", "b"); return err },
+		},
+		{
+			name: "le label",
+			register: func(registry *Registry) error {
+				_, err := registry.NewHistogram("a", "", nil, "le")
+				return err
+			},
+		},
+		{
+			name: "decreasing buckets",
+			register: func(registry *Registry) error {
+				_, err := registry.NewHistogram("a", "", []float64{1, 0.5})
+				return err
+			},
+		},
+		{
+			name: "infinite bucket",
+			register: func(registry *Registry) error {
+				_, err := registry.NewHistogram("a", "", []float64{1, math.Inf(1)})
+				return err
+			},
+		},
+		{
+			name: "empty buckets",
+			register: func(registry *Registry) error {
+				_, err := registry.NewHistogram("a", "", []float64{})
+				return err
+			},
+		},
+		{
+			name: "registered twice",
+			register: func(registry *Registry) error {
+				if _, err := registry.NewCounter("a_total", ""); err != nil {
+					return nil
+				}
+				_, err := registry.NewGauge("a", "")
+				return err
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := testCase.register(NewRegistry()); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	t.Parallel()
+
+	if got := ExponentialBuckets(100, 10, 3); !slices.Equal(got, []float64{100, 1000, 10000}) {
+		t.Errorf("got %v", got)
+	}
+	if got := ExponentialBuckets(1, 1, 3); got != nil {
+		t.Errorf("expected no buckets for a factor of one, got %v", got)
+	}
+}
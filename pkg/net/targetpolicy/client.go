@@ -0,0 +1,264 @@
+package targetpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrTooManyRedirects = errors.New("too many redirects")
+	ErrResponseTooLarge = errors.New("response too large")
+)
+
+const (
+	DefaultMaxRedirects     = 10
+	DefaultMaxResponseBytes = 10 << 20
+	DefaultClientTimeout    = 30 * time.Second
+)
+
+// DeniedAddressError records that the policy refused a connection or a redirect. It wraps
+// ErrNonRoutableIP.
+type DeniedAddressError struct {
+	// Stage is where the address was refused: "dial" or "redirect".
+	Stage  string
+	Target string
+	IP     netip.Addr
+}
+
+func (e *DeniedAddressError) Error() string {
+	return fmt.Sprintf("%s: stage=%s target=%q ip=%s", ErrNonRoutableIP, e.Stage, e.Target, e.IP)
+}
+
+func (e *DeniedAddressError) Unwrap() error {
+	return ErrNonRoutableIP
+}
+
+// ClientOption configures the dialers, transports and clients of the package.
+type ClientOption func(*clientConfig)
+
+type clientConfig struct {
+	maxRedirects     int
+	maxResponseBytes int64
+	timeout          time.Duration
+	allowedPrefixes  []netip.Prefix
+	lookupIP         LookupIPFunc
+}
+
+func newClientConfig(options ...ClientOption) *clientConfig {
+	config := &clientConfig{
+		maxRedirects:     DefaultMaxRedirects,
+		maxResponseBytes: DefaultMaxResponseBytes,
+		timeout:          DefaultClientTimeout,
+		lookupIP:         net.DefaultResolver.LookupIP,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(config)
+		}
+	}
+
+	return config
+}
+
+// WithMaxRedirects caps the number of redirects a client follows.
+func WithMaxRedirects(maxRedirects int) ClientOption {
+	return func(config *clientConfig) {
+		config.maxRedirects = maxRedirects
+	}
+}
+
+// WithMaxResponseBytes caps the size of the response bodies a transport reads; a cap that is not
+// positive does not apply.
+func WithMaxResponseBytes(maxResponseBytes int64) ClientOption {
+	return func(config *clientConfig) {
+		config.maxResponseBytes = maxResponseBytes
+	}
+}
+
+// WithTimeout sets the timeout of a client; zero means no timeout.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(config *clientConfig) {
+		config.timeout = timeout
+	}
+}
+
+// WithAllowedPrefixes allows connections to addresses in the prefixes even though they are denied
+// by the shared policy, e.g. to reach an internal service deliberately.
+func WithAllowedPrefixes(prefixes ...netip.Prefix) ClientOption {
+	return func(config *clientConfig) {
+		config.allowedPrefixes = append(config.allowedPrefixes, prefixes...)
+	}
+}
+
+// WithLookupIP sets the resolver redirect targets are validated with.
+func WithLookupIP(lookupIP LookupIPFunc) ClientOption {
+	return func(config *clientConfig) {
+		config.lookupIP = lookupIP
+	}
+}
+
+func (config *clientConfig) isAllowedAddr(addr netip.Addr) bool {
+	if IsRoutableAddr(addr) {
+		return true
+	}
+
+	addr = normalizeAddr(addr)
+	for _, allowed := range config.allowedPrefixes {
+		if allowed.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// control checks the address a dialer is about to connect to, which is the resolved one, so that a
+// host that resolves differently than when it was validated is checked again.
+func (config *clientConfig) control(_ string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: stage=dial target=%q: %w", ErrNonRoutableIP, address, err)
+	}
+
+	if addr := addrPort.Addr(); !config.isAllowedAddr(addr) {
+		return &DeniedAddressError{Stage: "dial", Target: address, IP: addr}
+	}
+
+	return nil
+}
+
+func (config *clientConfig) newDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   config.control,
+	}
+}
+
+// validateRedirect resolves the target of a redirect and checks every address it resolves to.
+func (config *clientConfig) validateRedirect(ctx context.Context, target string) error {
+	targetInfo, err := ResolveTarget(ctx, target, config.lookupIP)
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range targetInfo.IPs {
+		addr, ok := addrFromIP(ip)
+		if !ok || !config.isAllowedAddr(addr) {
+			return &DeniedAddressError{Stage: "redirect", Target: target, IP: addr}
+		}
+	}
+
+	return nil
+}
+
+// NewDialer returns a dialer that refuses to connect to addresses outside the shared policy. The
+// check is made as each resolved address is connected to, so DNS rebinding is rejected as well.
+func NewDialer(options ...ClientOption) *net.Dialer {
+	return newClientConfig(options...).newDialer()
+}
+
+// limitedBody is a response body that fails once more than its limit has been read from it.
+type limitedBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+	if int64(len(p)) > body.remaining+1 {
+		p = p[:body.remaining+1]
+	}
+
+	n, err := body.ReadCloser.Read(p)
+	if int64(n) > body.remaining {
+		n = int(body.remaining)
+		body.remaining = 0
+		return n, fmt.Errorf("%w: limit=%d", ErrResponseTooLarge, body.limit)
+	}
+	body.remaining -= int64(n)
+
+	return n, err
+}
+
+type transport struct {
+	next             http.RoundTripper
+	maxResponseBytes int64
+}
+
+func (t *transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := t.next.RoundTrip(request)
+	if err != nil || t.maxResponseBytes <= 0 {
+		return response, err
+	}
+
+	if response.ContentLength > t.maxResponseBytes {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf(
+			"%w: limit=%d content-length=%d",
+			ErrResponseTooLarge,
+			t.maxResponseBytes,
+			response.ContentLength,
+		)
+	}
+
+	response.Body = &limitedBody{
+		ReadCloser: response.Body,
+		limit:      t.maxResponseBytes,
+		remaining:  t.maxResponseBytes,
+	}
+
+	return response, nil
+}
+
+// NewTransport returns a transport that connects with a dialer of NewDialer, never through a proxy,
+// and that fails the reading of response bodies larger than the cap.
+func NewTransport(options ...ClientOption) http.RoundTripper {
+	config := newClientConfig(options...)
+
+	// http.DefaultTransport may have been replaced, e.g. by instrumentation; a transport with its
+	// defaults is then made instead.
+	var httpTransport *http.Transport
+	if defaultTransport, ok := http.DefaultTransport.(*http.Transport); ok && defaultTransport != nil {
+		httpTransport = defaultTransport.Clone()
+	} else {
+		httpTransport = &http.Transport{
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		}
+	}
+	// A proxy would connect on the transport's behalf, out of reach of the dialer.
+	httpTransport.Proxy = nil
+	httpTransport.DialContext = config.newDialer().DialContext
+
+	return &transport{next: httpTransport, maxResponseBytes: config.maxResponseBytes}
+}
+
+// NewClient returns a client for fetching user-supplied URLs, such as those of webhooks and
+// avatars: it connects with a transport of NewTransport, and validates the target of each redirect
+// before following it, following no more than the cap. It is meant for fetch_config.WithHttpClient.
+func NewClient(options ...ClientOption) *http.Client {
+	config := newClientConfig(options...)
+
+	return &http.Client{
+		Transport: NewTransport(options...),
+		Timeout:   config.timeout,
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if len(via) > config.maxRedirects {
+				return fmt.Errorf("%w: limit=%d", ErrTooManyRedirects, config.maxRedirects)
+			}
+
+			return config.validateRedirect(request.Context(), request.URL.String())
+		},
+	}
+}
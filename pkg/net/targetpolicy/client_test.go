@@ -0,0 +1,212 @@
+package targetpolicy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+var loopbackPrefix = netip.MustParsePrefix("127.0.0.0/8")
+
+func get(t *testing.T, client *http.Client, target string) (string, error) {
+	t.Helper()
+
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, target, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	return string(body), err
+}
+
+func TestNewClientRejectsNonRoutableTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	_, err := get(t, NewClient(), server.URL)
+	if !errors.Is(err, ErrNonRoutableIP) {
+		t.Fatalf("expected ErrNonRoutableIP, got %v", err)
+	}
+
+	var deniedAddressError *DeniedAddressError
+	if !errors.As(err, &deniedAddressError) {
+		t.Fatalf("expected a DeniedAddressError in %v", err)
+	}
+	if deniedAddressError.Stage != "dial" || !deniedAddressError.IP.IsLoopback() {
+		t.Fatalf("unexpected decision: %+v", deniedAddressError)
+	}
+
+	body, err := get(t, NewClient(WithAllowedPrefixes(loopbackPrefix)), server.URL)
+	if err != nil || body != "ok" {
+		t.Fatalf("expected the allowed prefix to be reachable, got %q, %v", body, err)
+	}
+}
+
+func TestNewDialerRejectsResolvedAddresses(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("split host port: %v", err)
+	}
+
+	// A hostname is checked by what it resolves to as it is connected to.
+	_, err = NewDialer().DialContext(context.Background(), "tcp", net.JoinHostPort("localhost", port))
+	if !errors.Is(err, ErrNonRoutableIP) {
+		t.Fatalf("expected ErrNonRoutableIP, got %v", err)
+	}
+
+	conn, err := NewDialer(WithAllowedPrefixes(loopbackPrefix)).DialContext(
+		context.Background(),
+		"tcp",
+		listener.Addr().String(),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	_ = conn.Close()
+}
+
+func TestNewClientValidatesRedirects(t *testing.T) {
+	lookup := func(ctx context.Context, network string, host string) ([]net.IP, error) {
+		switch host {
+		case "private.example":
+			return []net.IP{net.ParseIP("10.0.0.1")}, nil
+		case "loopback.example":
+			return []net.IP{net.ParseIP("127.0.0.1")}, nil
+		default:
+			return nil, errors.New("lookup failed")
+		}
+	}
+
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/private":
+			http.Redirect(w, r, "http://private.example/", http.StatusFound)
+		case "/metadata":
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, serverURL+"/loop", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	client := NewClient(WithAllowedPrefixes(loopbackPrefix), WithLookupIP(lookup), WithMaxRedirects(3))
+
+	testCases := []struct {
+		name      string
+		path      string
+		wantErr   error
+		wantStage string
+	}{
+		{name: "Private hostname", path: "/private", wantErr: ErrNonRoutableIP, wantStage: "redirect"},
+		{name: "Metadata IP", path: "/metadata", wantErr: ErrNonRoutableIP, wantStage: "redirect"},
+		{name: "Redirect loop", path: "/loop", wantErr: ErrTooManyRedirects},
+		{name: "No redirect", path: "/"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := get(t, client, server.URL+tc.path)
+			if tc.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+
+			var deniedAddressError *DeniedAddressError
+			if tc.wantStage != "" && (!errors.As(err, &deniedAddressError) || deniedAddressError.Stage != tc.wantStage) {
+				t.Fatalf("expected a %s decision in %v", tc.wantStage, err)
+			}
+		})
+	}
+}
+
+func TestNewTransportCapsResponseSize(t *testing.T) {
+	body := strings.Repeat("a", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/streamed" {
+			// Flushing before writing leaves the length of the body unknown.
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		path     string
+		maxBytes int64
+		wantErr  error
+	}{
+		{name: "Content-Length over the cap", path: "/", maxBytes: 10, wantErr: ErrResponseTooLarge},
+		{name: "Streamed over the cap", path: "/streamed", maxBytes: 10, wantErr: ErrResponseTooLarge},
+		{name: "At the cap", path: "/streamed", maxBytes: 100},
+		{name: "No cap", path: "/streamed", maxBytes: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewClient(WithAllowedPrefixes(loopbackPrefix), WithMaxResponseBytes(tc.maxBytes))
+
+			got, err := get(t, client, server.URL+tc.path)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr == nil && got != body {
+				t.Fatalf("unexpected body of %d bytes", len(got))
+			}
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func TestNewTransportWithReplacedDefaultTransport(t *testing.T) {
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("the default transport was used")
+	})
+	defer func() { http.DefaultTransport = defaultTransport }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	got, err := get(t, NewClient(WithAllowedPrefixes(loopbackPrefix)), server.URL)
+	if err != nil || got != "ok" {
+		t.Fatalf("expected ok, got %q, %v", got, err)
+	}
+}
@@ -12,4 +12,11 @@
 // Callers that use a scanner engine with its own connection policy can also
 // pass DeniedPrefixStrings to that engine so connect-time DNS rebinding attempts
 // are rejected by the same shared policy.
+//
+// NewDialer, NewTransport and NewClient enforce the policy on outbound HTTP:
+// every address is checked as it is connected to, every redirect target is
+// validated before it is followed, and redirects and response sizes are capped.
+// A refused address is recorded in the error chain as a DeniedAddressError. A
+// client of NewClient is meant for fetching user-supplied URLs, e.g. with
+// fetch_config.WithHttpClient.
 package targetpolicy
@@ -543,6 +543,17 @@ func ParseHttpContext(httpContext *motmedelHttpTypes.HttpContext) (*schema.Base,
 	base.User = httpContext.User
 	base.Message = MakeHttpMessage(base)
 
+	if rule := httpContext.Rule; rule != nil {
+		base.Rule = rule
+	}
+
+	if reason := httpContext.Reason; reason != "" {
+		if base.Event == nil {
+			base.Event = &schema.Event{}
+		}
+		base.Event.Reason = reason
+	}
+
 	if base.Http != nil && base.Http.Request != nil {
 		base.Http.Request.Reporting = httpContext.Reporting
 	}
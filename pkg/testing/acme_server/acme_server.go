@@ -0,0 +1,780 @@
+// Package acme_server is a stand-in ACME (RFC 8555) server for tests, in the spirit of Pebble: it
+// speaks enough of the protocol to see an order through, validates HTTP-01 and TLS-ALPN-01
+// challenges for real against the addresses it is given, issues certificates from a root of its
+// own and answers OCSP requests about them.
+package acme_server
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json/v2"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	motmedelCryptoInterfaces "github.com/Motmedel/utils_go/pkg/crypto/interfaces"
+	acmeTypes "github.com/Motmedel/utils_go/pkg/http/acme/types"
+	jwkKey "github.com/Motmedel/utils_go/pkg/json/jose/jwk/types/key"
+	"github.com/Motmedel/utils_go/pkg/tls/ocsp"
+)
+
+// DefaultValidity is for how long the certificates the server issues are valid.
+const DefaultValidity = 90 * 24 * time.Hour
+
+var oidAcmeIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+type account struct {
+	url        string
+	verifier   motmedelCryptoInterfaces.Verifier
+	thumbprint string
+}
+
+type order struct {
+	acmeTypes.Order
+	account     *account
+	names       []string
+	certificate []byte
+}
+
+type authorization struct {
+	acmeTypes.Authorization
+	order *order
+}
+
+type challenge struct {
+	acmeTypes.Challenge
+	authorization *authorization
+}
+
+// Server is a stand-in ACME server. Its directory is at DirectoryUrl; Client is an HTTP client that
+// trusts it.
+type Server struct {
+	DirectoryUrl string
+	// Validity is for how long the certificates issued from now on are valid.
+	Validity time.Duration
+
+	server   *httptest.Server
+	rootKey  *ecdsa.PrivateKey
+	root     *x509.Certificate
+	mutex    sync.Mutex
+	counter  int
+	nonces   map[string]bool
+	accounts map[string]*account
+
+	orders         map[string]*order
+	authorizations map[string]*authorization
+	challenges     map[string]*challenge
+	issued         map[string]*x509.Certificate
+	revoked        map[string]bool
+
+	httpChallengeAddress string
+	tlsChallengeAddress  string
+}
+
+// SetHttpChallengeAddress sets the address HTTP-01 challenges are validated at, as a host and port.
+func (server *Server) SetHttpChallengeAddress(address string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.httpChallengeAddress = address
+}
+
+// SetTlsChallengeAddress sets the address TLS-ALPN-01 challenges are validated at.
+func (server *Server) SetTlsChallengeAddress(address string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.tlsChallengeAddress = address
+}
+
+// Client is an HTTP client that trusts the server.
+func (server *Server) Client() *http.Client {
+	return server.server.Client()
+}
+
+// Roots is the pool of the root the server issues certificates from.
+func (server *Server) Roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(server.root)
+	return pool
+}
+
+// Issued is how many certificates the server has issued.
+func (server *Server) Issued() int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return len(server.issued)
+}
+
+// Revoke makes the server answer OCSP requests about the certificate with the serial number as
+// revoked.
+func (server *Server) Revoke(serialNumber *big.Int) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.revoked[serialNumber.String()] = true
+}
+
+func (server *Server) Close() {
+	server.server.Close()
+}
+
+func (server *Server) url(path string, id string) string {
+	if id == "" {
+		return server.server.URL + path
+	}
+	return server.server.URL + path + "/" + id
+}
+
+// nextId must be called with the mutex held.
+func (server *Server) nextId() string {
+	server.counter++
+	return strconv.Itoa(server.counter)
+}
+
+func (server *Server) newNonce() string {
+	data := make([]byte, 16)
+	_, _ = rand.Read(data)
+	nonce := base64.RawURLEncoding.EncodeToString(data)
+
+	server.mutex.Lock()
+	server.nonces[nonce] = true
+	server.mutex.Unlock()
+
+	return nonce
+}
+
+func (server *Server) writeProblem(responseWriter http.ResponseWriter, status int, problemType string, detail string) {
+	responseWriter.Header().Set("Content-Type", "application/problem+json")
+	responseWriter.WriteHeader(status)
+	_ = json.MarshalWrite(
+		responseWriter,
+		&acmeTypes.Problem{Type: "urn:ietf:params:acme:error:" + problemType, Detail: detail, Status: status},
+	)
+}
+
+func (server *Server) writeObject(responseWriter http.ResponseWriter, status int, location string, object any) {
+	if location != "" {
+		responseWriter.Header().Set("Location", location)
+	}
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(status)
+	_ = json.MarshalWrite(responseWriter, object)
+}
+
+type jwsRequest struct {
+	payload []byte
+	account *account
+	jwk     *jwkKey.Key
+	nonce   string
+}
+
+// readJws reads and verifies the signed request; a nil result means that the problem has been
+// written.
+func (server *Server) readJws(responseWriter http.ResponseWriter, request *http.Request) *jwsRequest {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		server.writeProblem(responseWriter, http.StatusBadRequest, "malformed", err.Error())
+		return nil
+	}
+
+	var flattened struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(body, &flattened); err != nil {
+		server.writeProblem(responseWriter, http.StatusBadRequest, "malformed", err.Error())
+		return nil
+	}
+
+	protectedData, err1 := base64.RawURLEncoding.DecodeString(flattened.Protected)
+	payload, err2 := base64.RawURLEncoding.DecodeString(flattened.Payload)
+	signature, err3 := base64.RawURLEncoding.DecodeString(flattened.Signature)
+	if err1 != nil || err2 != nil || err3 != nil {
+		server.writeProblem(responseWriter, http.StatusBadRequest, "malformed", "bad base64url")
+		return nil
+	}
+
+	var protected struct {
+		Alg   string         `json:"alg"`
+		Nonce string         `json:"nonce"`
+		Url   string         `json:"url"`
+		Jwk   map[string]any `json:"jwk"`
+		Kid   string         `json:"kid"`
+	}
+	if err := json.Unmarshal(protectedData, &protected); err != nil {
+		server.writeProblem(responseWriter, http.StatusBadRequest, "malformed", err.Error())
+		return nil
+	}
+
+	server.mutex.Lock()
+	validNonce := server.nonces[protected.Nonce]
+	delete(server.nonces, protected.Nonce)
+	server.mutex.Unlock()
+	if !validNonce {
+		server.writeProblem(responseWriter, http.StatusBadRequest, "badNonce", "unknown nonce")
+		return nil
+	}
+
+	if protected.Url != server.server.URL+request.URL.Path {
+		server.writeProblem(responseWriter, http.StatusUnauthorized, "unauthorized", "url mismatch")
+		return nil
+	}
+
+	jwsRequest := &jwsRequest{payload: payload, nonce: protected.Nonce}
+
+	var verifier motmedelCryptoInterfaces.Verifier
+	switch {
+	case protected.Jwk != nil && protected.Kid == "":
+		key, err := jwkKey.New(protected.Jwk)
+		if err != nil {
+			server.writeProblem(responseWriter, http.StatusBadRequest, "badPublicKey", err.Error())
+			return nil
+		}
+		namedVerifier, err := key.NamedVerifier()
+		if err != nil || namedVerifier.GetName() != protected.Alg {
+			server.writeProblem(responseWriter, http.StatusBadRequest, "badSignatureAlgorithm", protected.Alg)
+			return nil
+		}
+		jwsRequest.jwk = key
+		verifier = namedVerifier
+	case protected.Kid != "" && protected.Jwk == nil:
+		server.mutex.Lock()
+		account := server.accounts[protected.Kid]
+		server.mutex.Unlock()
+		if account == nil {
+			server.writeProblem(responseWriter, http.StatusBadRequest, "accountDoesNotExist", protected.Kid)
+			return nil
+		}
+		jwsRequest.account = account
+		verifier = account.verifier
+	default:
+		server.writeProblem(responseWriter, http.StatusBadRequest, "malformed", "exactly one of jwk and kid")
+		return nil
+	}
+
+	if err := verifier.Verify([]byte(flattened.Protected+"."+flattened.Payload), signature); err != nil {
+		server.writeProblem(responseWriter, http.StatusUnauthorized, "unauthorized", "bad signature")
+		return nil
+	}
+
+	return jwsRequest
+}
+
+func (server *Server) handleDirectory(responseWriter http.ResponseWriter, _ *http.Request) {
+	server.writeObject(
+		responseWriter,
+		http.StatusOK,
+		"",
+		&acmeTypes.Directory{
+			NewNonce:   server.url("/nonce", ""),
+			NewAccount: server.url("/account", ""),
+			NewOrder:   server.url("/order", ""),
+			Meta:       &acmeTypes.DirectoryMeta{TermsOfService: server.url("/terms", "")},
+		},
+	)
+}
+
+func (server *Server) handleAccount(responseWriter http.ResponseWriter, jwsRequest *jwsRequest) {
+	if jwsRequest.jwk == nil {
+		server.writeProblem(responseWriter, http.StatusBadRequest, "malformed", "a new account is identified by its jwk")
+		return
+	}
+
+	var payload acmeTypes.Account
+	if err := json.Unmarshal(jwsRequest.payload, &payload); err != nil {
+		server.writeProblem(responseWriter, http.StatusBadRequest, "malformed", err.Error())
+		return
+	}
+
+	thumbprint, err := jwsRequest.jwk.ThumbprintSHA256()
+	if err != nil {
+		server.writeProblem(responseWriter, http.StatusBadRequest, "badPublicKey", err.Error())
+		return
+	}
+
+	server.mutex.Lock()
+	var existing *account
+	for _, candidate := range server.accounts {
+		if candidate.thumbprint == thumbprint {
+			existing = candidate
+		}
+	}
+	if existing == nil {
+		verifier, _ := jwsRequest.jwk.NamedVerifier()
+		existing = &account{url: server.url("/account", server.nextId()), verifier: verifier, thumbprint: thumbprint}
+		server.accounts[existing.url] = existing
+		server.mutex.Unlock()
+
+		if !payload.TermsOfServiceAgreed {
+			server.writeProblem(responseWriter, http.StatusForbidden, "userActionRequired", "agree to the terms of service")
+			return
+		}
+
+		server.writeObject(responseWriter, http.StatusCreated, existing.url, &acmeTypes.Account{Status: acmeTypes.StatusValid, Contact: payload.Contact})
+		return
+	}
+	server.mutex.Unlock()
+
+	server.writeObject(responseWriter, http.StatusOK, existing.url, &acmeTypes.Account{Status: acmeTypes.StatusValid})
+}
+
+func (server *Server) handleNewOrder(responseWriter http.ResponseWriter, jwsRequest *jwsRequest) {
+	var payload struct {
+		Identifiers []*acmeTypes.Identifier `json:"identifiers"`
+	}
+	if err := json.Unmarshal(jwsRequest.payload, &payload); err != nil || len(payload.Identifiers) == 0 {
+		server.writeProblem(responseWriter, http.StatusBadRequest, "malformed", "identifiers")
+		return
+	}
+
+	server.mutex.Lock()
+	orderId := server.nextId()
+	newOrder := &order{account: jwsRequest.account}
+	newOrder.Status = acmeTypes.StatusPending
+	newOrder.Identifiers = payload.Identifiers
+	newOrder.Finalize = server.url("/finalize", orderId)
+	newOrder.Url = server.url("/order", orderId)
+	newOrder.Expires = time.Now().Add(time.Hour)
+
+	for _, identifier := range payload.Identifiers {
+		newOrder.names = append(newOrder.names, identifier.Value)
+
+		authorizationId := server.nextId()
+		newAuthorization := &authorization{order: newOrder}
+		newAuthorization.Identifier = identifier
+		newAuthorization.Status = acmeTypes.StatusPending
+		newAuthorization.Url = server.url("/authz", authorizationId)
+
+		for _, challengeType := range []string{acmeTypes.ChallengeTypeHttp01, acmeTypes.ChallengeTypeTlsAlpn01} {
+			challengeId := server.nextId()
+			token := make([]byte, 16)
+			_, _ = rand.Read(token)
+
+			newChallenge := &challenge{authorization: newAuthorization}
+			newChallenge.Type = challengeType
+			newChallenge.Status = acmeTypes.StatusPending
+			newChallenge.Url = server.url("/challenge", challengeId)
+			newChallenge.Token = base64.RawURLEncoding.EncodeToString(token)
+
+			server.challenges[challengeId] = newChallenge
+			newAuthorization.Challenges = append(newAuthorization.Challenges, &newChallenge.Challenge)
+		}
+
+		server.authorizations[authorizationId] = newAuthorization
+		newOrder.Authorizations = append(newOrder.Authorizations, newAuthorization.Url)
+	}
+
+	server.orders[orderId] = newOrder
+	object := newOrder.Order
+	server.mutex.Unlock()
+
+	server.writeObject(responseWriter, http.StatusCreated, object.Url, &object)
+}
+
+// refreshOrder must be called with the mutex held.
+func (server *Server) refreshOrder(order *order) {
+	if order.Status != acmeTypes.StatusPending {
+		return
+	}
+
+	for _, authorizationUrl := range order.Authorizations {
+		for _, candidate := range server.authorizations {
+			if candidate.Url != authorizationUrl {
+				continue
+			}
+			switch candidate.Status {
+			case acmeTypes.StatusValid:
+			case acmeTypes.StatusInvalid:
+				order.Status = acmeTypes.StatusInvalid
+				return
+			default:
+				return
+			}
+		}
+	}
+
+	order.Status = acmeTypes.StatusReady
+}
+
+func (server *Server) handleOrder(responseWriter http.ResponseWriter, request *http.Request, jwsRequest *jwsRequest) {
+	server.mutex.Lock()
+	found := server.orders[request.PathValue("id")]
+	if found == nil || found.account != jwsRequest.account {
+		server.mutex.Unlock()
+		server.writeProblem(responseWriter, http.StatusNotFound, "malformed", "no such order")
+		return
+	}
+	server.refreshOrder(found)
+	object := found.Order
+	server.mutex.Unlock()
+
+	server.writeObject(responseWriter, http.StatusOK, "", &object)
+}
+
+func (server *Server) handleAuthorization(responseWriter http.ResponseWriter, request *http.Request, jwsRequest *jwsRequest) {
+	server.mutex.Lock()
+	found := server.authorizations[request.PathValue("id")]
+	if found == nil || found.order.account != jwsRequest.account {
+		server.mutex.Unlock()
+		server.writeProblem(responseWriter, http.StatusNotFound, "malformed", "no such authorization")
+		return
+	}
+	object := found.Authorization
+	object.Challenges = nil
+	for _, candidate := range found.Challenges {
+		challengeCopy := *candidate
+		object.Challenges = append(object.Challenges, &challengeCopy)
+	}
+	server.mutex.Unlock()
+
+	if object.Status == acmeTypes.StatusPending {
+		responseWriter.Header().Set("Retry-After", "0")
+	}
+
+	server.writeObject(responseWriter, http.StatusOK, "", &object)
+}
+
+func keyAuthorizationDigest(keyAuthorization string) []byte {
+	digest := sha256.Sum256([]byte(keyAuthorization))
+	return digest[:]
+}
+
+func (server *Server) validate(found *challenge, thumbprint string) error {
+	server.mutex.Lock()
+	name := found.authorization.Identifier.Value
+	keyAuthorization := found.Token + "." + thumbprint
+	challengeType := found.Type
+	httpAddress := server.httpChallengeAddress
+	tlsAddress := server.tlsChallengeAddress
+	server.mutex.Unlock()
+
+	switch challengeType {
+	case acmeTypes.ChallengeTypeHttp01:
+		request, err := http.NewRequest(http.MethodGet, "http://"+httpAddress+"/.well-known/acme-challenge/"+found.Token, nil)
+		if err != nil {
+			return err
+		}
+		request.Host = name
+
+		response, err := (&http.Client{Timeout: 5 * time.Second}).Do(request)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+
+		body, _ := io.ReadAll(response.Body)
+		if response.StatusCode != http.StatusOK || string(bytes.TrimSpace(body)) != keyAuthorization {
+			return fmt.Errorf("http-01: got %d %q", response.StatusCode, body)
+		}
+		return nil
+	case acmeTypes.ChallengeTypeTlsAlpn01:
+		connection, err := tls.DialWithDialer(
+			&net.Dialer{Timeout: 5 * time.Second},
+			"tcp",
+			tlsAddress,
+			&tls.Config{ServerName: name, NextProtos: []string{"acme-tls/1"}, InsecureSkipVerify: true},
+		)
+		if err != nil {
+			return err
+		}
+		defer connection.Close()
+
+		state := connection.ConnectionState()
+		if state.NegotiatedProtocol != "acme-tls/1" || len(state.PeerCertificates) == 0 {
+			return fmt.Errorf("tls-alpn-01: negotiated %q", state.NegotiatedProtocol)
+		}
+
+		certificate := state.PeerCertificates[0]
+		if !slices.Equal(certificate.DNSNames, []string{name}) {
+			return fmt.Errorf("tls-alpn-01: names %v", certificate.DNSNames)
+		}
+		for _, extension := range certificate.Extensions {
+			if !extension.Id.Equal(oidAcmeIdentifier) {
+				continue
+			}
+			var digest []byte
+			if _, err := asn1.Unmarshal(extension.Value, &digest); err != nil {
+				return err
+			}
+			if extension.Critical && bytes.Equal(digest, keyAuthorizationDigest(keyAuthorization)) {
+				return nil
+			}
+		}
+		return fmt.Errorf("tls-alpn-01: no matching acme identifier")
+	default:
+		return fmt.Errorf("unsupported challenge type %q", challengeType)
+	}
+}
+
+func (server *Server) handleChallenge(responseWriter http.ResponseWriter, request *http.Request, jwsRequest *jwsRequest) {
+	server.mutex.Lock()
+	found := server.challenges[request.PathValue("id")]
+	if found == nil || found.authorization.order.account != jwsRequest.account {
+		server.mutex.Unlock()
+		server.writeProblem(responseWriter, http.StatusNotFound, "malformed", "no such challenge")
+		return
+	}
+
+	// A POST-as-GET fetches the challenge; anything else accepts it.
+	if len(jwsRequest.payload) != 0 && found.Status == acmeTypes.StatusPending {
+		found.Status = acmeTypes.StatusProcessing
+		thumbprint := jwsRequest.account.thumbprint
+
+		go func() {
+			err := server.validate(found, thumbprint)
+
+			server.mutex.Lock()
+			defer server.mutex.Unlock()
+
+			if err != nil {
+				found.Status = acmeTypes.StatusInvalid
+				found.Error = &acmeTypes.Problem{Type: acmeTypes.ProblemTypeUnauthorized, Detail: err.Error(), Status: http.StatusForbidden}
+				found.authorization.Status = acmeTypes.StatusInvalid
+				return
+			}
+
+			found.Status = acmeTypes.StatusValid
+			found.Validated = time.Now()
+			found.authorization.Status = acmeTypes.StatusValid
+		}()
+	}
+	object := found.Challenge
+	server.mutex.Unlock()
+
+	server.writeObject(responseWriter, http.StatusOK, "", &object)
+}
+
+func (server *Server) handleFinalize(responseWriter http.ResponseWriter, request *http.Request, jwsRequest *jwsRequest) {
+	var payload struct {
+		Csr string `json:"csr"`
+	}
+	if err := json.Unmarshal(jwsRequest.payload, &payload); err != nil {
+		server.writeProblem(responseWriter, http.StatusBadRequest, "malformed", err.Error())
+		return
+	}
+
+	csrData, err := base64.RawURLEncoding.DecodeString(payload.Csr)
+	if err != nil {
+		server.writeProblem(responseWriter, http.StatusBadRequest, "badCSR", err.Error())
+		return
+	}
+
+	csr, err := x509.ParseCertificateRequest(csrData)
+	if err == nil {
+		err = csr.CheckSignature()
+	}
+	if err != nil {
+		server.writeProblem(responseWriter, http.StatusBadRequest, "badCSR", err.Error())
+		return
+	}
+
+	id := request.PathValue("id")
+
+	server.mutex.Lock()
+	found := server.orders[id]
+	if found == nil || found.account != jwsRequest.account {
+		server.mutex.Unlock()
+		server.writeProblem(responseWriter, http.StatusNotFound, "malformed", "no such order")
+		return
+	}
+	server.refreshOrder(found)
+	if found.Status != acmeTypes.StatusReady {
+		server.mutex.Unlock()
+		server.writeProblem(responseWriter, http.StatusForbidden, "orderNotReady", found.Status)
+		return
+	}
+
+	names := slices.Sorted(slices.Values(found.names))
+	requested := slices.Sorted(slices.Values(csr.DNSNames))
+	if !slices.Equal(names, requested) {
+		server.mutex.Unlock()
+		server.writeProblem(responseWriter, http.StatusBadRequest, "badCSR", "names do not match the order")
+		return
+	}
+
+	serialNumber, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     found.names,
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(server.Validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		OCSPServer:   []string{server.url("/ocsp", "")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, server.root, csr.PublicKey, server.rootKey)
+	if err != nil {
+		server.mutex.Unlock()
+		server.writeProblem(responseWriter, http.StatusInternalServerError, "serverInternal", err.Error())
+		return
+	}
+	issued, _ := x509.ParseCertificate(der)
+	server.issued[serialNumber.String()] = issued
+
+	var chain bytes.Buffer
+	_ = pem.Encode(&chain, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	_ = pem.Encode(&chain, &pem.Block{Type: "CERTIFICATE", Bytes: server.root.Raw})
+	found.certificate = chain.Bytes()
+	found.Status = acmeTypes.StatusValid
+	found.Certificate = server.url("/certificate", id)
+	object := found.Order
+	server.mutex.Unlock()
+
+	server.writeObject(responseWriter, http.StatusOK, object.Url, &object)
+}
+
+func (server *Server) handleCertificate(responseWriter http.ResponseWriter, request *http.Request, jwsRequest *jwsRequest) {
+	server.mutex.Lock()
+	found := server.orders[request.PathValue("id")]
+	if found == nil || found.account != jwsRequest.account || found.certificate == nil {
+		server.mutex.Unlock()
+		server.writeProblem(responseWriter, http.StatusNotFound, "malformed", "no such certificate")
+		return
+	}
+	certificate := found.certificate
+	server.mutex.Unlock()
+
+	responseWriter.Header().Set("Content-Type", "application/pem-certificate-chain")
+	_, _ = responseWriter.Write(certificate)
+}
+
+func (server *Server) handleOcsp(responseWriter http.ResponseWriter, request *http.Request) {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		responseWriter.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	server.mutex.Lock()
+	var (
+		issued  *x509.Certificate
+		revoked bool
+	)
+	for serialNumber, candidate := range server.issued {
+		expected, err := ocsp.MakeRequest(candidate, server.root)
+		if err == nil && bytes.Equal(expected, body) {
+			issued = candidate
+			revoked = server.revoked[serialNumber]
+		}
+	}
+	server.mutex.Unlock()
+
+	if issued == nil {
+		responseWriter.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	status := ocsp.StatusGood
+	if revoked {
+		status = ocsp.StatusRevoked
+	}
+
+	now := time.Now()
+	data, err := ocsp.MakeResponse(issued, server.root, server.rootKey, status, now, now, now.Add(24*time.Hour))
+	if err != nil {
+		responseWriter.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	responseWriter.Header().Set("Content-Type", ocsp.ResponseContentType)
+	_, _ = responseWriter.Write(data)
+}
+
+func (server *Server) signed(handle func(http.ResponseWriter, *http.Request, *jwsRequest)) http.HandlerFunc {
+	return func(responseWriter http.ResponseWriter, request *http.Request) {
+		responseWriter.Header().Set("Replay-Nonce", server.newNonce())
+
+		jwsRequest := server.readJws(responseWriter, request)
+		if jwsRequest == nil {
+			return
+		}
+
+		if jwsRequest.account == nil && request.URL.Path != "/account" {
+			server.writeProblem(responseWriter, http.StatusBadRequest, "malformed", "a request is identified by its account")
+			return
+		}
+
+		handle(responseWriter, request, jwsRequest)
+	}
+}
+
+// New starts a server. It is closed with Close.
+func New() (*Server, error) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("ecdsa generate key: %w", err)
+	}
+
+	now := time.Now()
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ACME stand-in root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(10 * 365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	rootDer, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("x509 create certificate: %w", err)
+	}
+	root, err := x509.ParseCertificate(rootDer)
+	if err != nil {
+		return nil, fmt.Errorf("x509 parse certificate: %w", err)
+	}
+
+	server := &Server{
+		Validity:       DefaultValidity,
+		rootKey:        rootKey,
+		root:           root,
+		nonces:         make(map[string]bool),
+		accounts:       make(map[string]*account),
+		orders:         make(map[string]*order),
+		authorizations: make(map[string]*authorization),
+		challenges:     make(map[string]*challenge),
+		issued:         make(map[string]*x509.Certificate),
+		revoked:        make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /directory", server.handleDirectory)
+	mux.HandleFunc("/nonce", func(responseWriter http.ResponseWriter, _ *http.Request) {
+		responseWriter.Header().Set("Replay-Nonce", server.newNonce())
+		responseWriter.Header().Set("Cache-Control", "no-store")
+	})
+	mux.HandleFunc("POST /account", server.signed(func(responseWriter http.ResponseWriter, _ *http.Request, jwsRequest *jwsRequest) {
+		server.handleAccount(responseWriter, jwsRequest)
+	}))
+	mux.HandleFunc("POST /order", server.signed(func(responseWriter http.ResponseWriter, _ *http.Request, jwsRequest *jwsRequest) {
+		server.handleNewOrder(responseWriter, jwsRequest)
+	}))
+	mux.HandleFunc("POST /order/{id}", server.signed(server.handleOrder))
+	mux.HandleFunc("POST /authz/{id}", server.signed(server.handleAuthorization))
+	mux.HandleFunc("POST /challenge/{id}", server.signed(server.handleChallenge))
+	mux.HandleFunc("POST /finalize/{id}", server.signed(server.handleFinalize))
+	mux.HandleFunc("POST /certificate/{id}", server.signed(server.handleCertificate))
+	mux.HandleFunc("POST /ocsp", server.handleOcsp)
+
+	server.server = httptest.NewTLSServer(mux)
+	server.DirectoryUrl = server.url("/directory", "")
+
+	return server, nil
+}
@@ -0,0 +1,251 @@
+package certificate_reloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	motmedelContext "github.com/Motmedel/utils_go/pkg/context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+	"github.com/Motmedel/utils_go/pkg/tls/certificate_reloader/certificate_reloader_config"
+)
+
+var (
+	ErrNoCertificate           = errors.New("no certificate")
+	ErrNoClientCertificate     = errors.New("no client certificate")
+	ErrNoClientCaCertificate   = errors.New("no certificate in the client ca bundle")
+	ErrCertificateFilesPartial = errors.New("a certificate file without a key file, or the other way around")
+)
+
+// Reloader serves the certificate and the client certificate authorities in the configured files,
+// and replaces them as the files change. A change that cannot be loaded -- a certificate written
+// before its key, a truncated bundle -- leaves what was loaded last in place.
+type Reloader struct {
+	config *certificate_reloader_config.Config
+
+	certificate atomic.Pointer[tls.Certificate]
+	clientCas   atomic.Pointer[x509.CertPool]
+
+	// mutex serializes the reloads, and guards the digest of what was loaded last.
+	mutex  sync.Mutex
+	digest []byte
+}
+
+type loaded struct {
+	certificate *tls.Certificate
+	clientCas   *x509.CertPool
+	digest      []byte
+}
+
+func (reloader *Reloader) load() (*loaded, error) {
+	config := reloader.config
+	hash := sha256.New()
+
+	var result loaded
+
+	if config.CertificateFile != "" {
+		certificateData, err := os.ReadFile(config.CertificateFile)
+		if err != nil {
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("os read file: %w", err), config.CertificateFile)
+		}
+
+		keyData, err := os.ReadFile(config.KeyFile)
+		if err != nil {
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("os read file: %w", err), config.KeyFile)
+		}
+
+		hash.Write(certificateData)
+		hash.Write(keyData)
+
+		certificate, err := tls.X509KeyPair(certificateData, keyData)
+		if err != nil {
+			return nil, motmedelErrors.NewWithTrace(
+				fmt.Errorf("tls x509 key pair: %w", err),
+				config.CertificateFile,
+				config.KeyFile,
+			)
+		}
+		result.certificate = &certificate
+	}
+
+	if config.ClientCaFile != "" {
+		clientCaData, err := os.ReadFile(config.ClientCaFile)
+		if err != nil {
+			return nil, motmedelErrors.NewWithTrace(fmt.Errorf("os read file: %w", err), config.ClientCaFile)
+		}
+
+		hash.Write(clientCaData)
+
+		clientCas := x509.NewCertPool()
+		if !clientCas.AppendCertsFromPEM(clientCaData) {
+			return nil, motmedelErrors.NewWithTrace(ErrNoClientCaCertificate, config.ClientCaFile)
+		}
+		result.clientCas = clientCas
+	}
+
+	result.digest = hash.Sum(nil)
+
+	return &result, nil
+}
+
+func (reloader *Reloader) reload(force bool) (bool, error) {
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+
+	result, err := reloader.load()
+	if err != nil {
+		return false, err
+	}
+
+	if !force && bytes.Equal(result.digest, reloader.digest) {
+		return false, nil
+	}
+
+	if result.certificate != nil {
+		reloader.certificate.Store(result.certificate)
+	}
+	if result.clientCas != nil {
+		reloader.clientCas.Store(result.clientCas)
+	}
+	reloader.digest = result.digest
+
+	return true, nil
+}
+
+// Reload loads the files anew, whether they changed or not, and serves what they hold from then on.
+func (reloader *Reloader) Reload() error {
+	if _, err := reloader.reload(true); err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+
+	return nil
+}
+
+// Watch checks the files for changes at the configured interval until ctx is done, and serves what
+// they hold whenever they do. A change that cannot be loaded is logged, and tried again at the next
+// check.
+func (reloader *Reloader) Watch(ctx context.Context) {
+	interval := reloader.config.Interval
+	if interval <= 0 {
+		interval = certificate_reloader_config.DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reloaded, err := reloader.reload(false)
+		if err != nil {
+			slog.WarnContext(
+				motmedelContext.WithError(ctx, fmt.Errorf("reload: %w", err)),
+				"An error occurred when reloading the certificate files; the ones loaded last are kept.",
+			)
+			continue
+		}
+		if reloaded {
+			slog.InfoContext(ctx, "The certificate files were reloaded.")
+		}
+	}
+}
+
+// GetCertificate returns the certificate loaded last, as tls.Config.GetCertificate.
+func (reloader *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certificate := reloader.certificate.Load()
+	if certificate == nil {
+		return nil, motmedelErrors.NewWithTrace(ErrNoCertificate)
+	}
+
+	return certificate, nil
+}
+
+// ClientCas returns the client certificate authorities loaded last, or nil if none are configured.
+func (reloader *Reloader) ClientCas() *x509.CertPool {
+	return reloader.clientCas.Load()
+}
+
+// VerifyConnection verifies the certificate the client presented, as tls.Config.VerifyConnection,
+// against the client certificate authorities loaded last. The handshake asks for a certificate
+// without verifying it itself, tls.Config.ClientCAs being fixed for the life of the configuration.
+func (reloader *Reloader) VerifyConnection(connectionState tls.ConnectionState) error {
+	peerCertificates := connectionState.PeerCertificates
+	if len(peerCertificates) == 0 {
+		if reloader.config.RequireClientCertificate {
+			return motmedelErrors.NewWithTrace(ErrNoClientCertificate)
+		}
+		return nil
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range peerCertificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	_, err := peerCertificates[0].Verify(
+		x509.VerifyOptions{
+			Roots:         reloader.ClientCas(),
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		},
+	)
+	if err != nil {
+		return motmedelErrors.NewWithTrace(fmt.Errorf("x509 certificate verify: %w", err))
+	}
+
+	return nil
+}
+
+// TlsConfig is a TLS configuration that serves the certificate loaded last and, where client
+// certificate authorities are configured, asks for a client certificate and verifies the one
+// presented against them.
+func (reloader *Reloader) TlsConfig() *tls.Config {
+	tlsConfig := &tls.Config{}
+	if reloader.config.CertificateFile != "" {
+		tlsConfig.GetCertificate = reloader.GetCertificate
+	}
+	if reloader.config.ClientCaFile != "" {
+		tlsConfig.ClientAuth = tls.RequestClientCert
+		tlsConfig.VerifyConnection = reloader.VerifyConnection
+	}
+
+	return tlsConfig
+}
+
+// New makes a reloader of the configured files and loads them, which is required to succeed.
+func New(options ...certificate_reloader_config.Option) (*Reloader, error) {
+	config := certificate_reloader_config.New(options...)
+
+	if (config.CertificateFile == "") != (config.KeyFile == "") {
+		return nil, motmedelErrors.NewWithTrace(
+			ErrCertificateFilesPartial,
+			config.CertificateFile,
+			config.KeyFile,
+		)
+	}
+
+	if config.CertificateFile == "" && config.ClientCaFile == "" {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("certificate files or client ca file"))
+	}
+
+	reloader := &Reloader{config: config}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
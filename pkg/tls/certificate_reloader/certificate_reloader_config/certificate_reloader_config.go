@@ -0,0 +1,57 @@
+package certificate_reloader_config
+
+import "time"
+
+// DefaultInterval is how often the files are checked for changes.
+const DefaultInterval = 30 * time.Second
+
+type Config struct {
+	CertificateFile string
+	KeyFile         string
+	// ClientCaFile is a PEM bundle of the certificate authorities client certificates are verified
+	// against. Without one, no client certificate is asked for.
+	ClientCaFile string
+	// RequireClientCertificate refuses a handshake in which the client presents no certificate. A
+	// certificate that is presented is verified either way; without this, requiring one is left to
+	// the endpoints that do.
+	RequireClientCertificate bool
+	Interval                 time.Duration
+}
+
+type Option func(*Config)
+
+func New(options ...Option) *Config {
+	config := &Config{
+		Interval: DefaultInterval,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	return config
+}
+
+func WithCertificateFiles(certificateFile string, keyFile string) Option {
+	return func(config *Config) {
+		config.CertificateFile = certificateFile
+		config.KeyFile = keyFile
+	}
+}
+
+func WithClientCaFile(clientCaFile string) Option {
+	return func(config *Config) {
+		config.ClientCaFile = clientCaFile
+	}
+}
+
+func WithRequireClientCertificate(requireClientCertificate bool) Option {
+	return func(config *Config) {
+		config.RequireClientCertificate = requireClientCertificate
+	}
+}
+
+func WithInterval(interval time.Duration) Option {
+	return func(config *Config) {
+		config.Interval = interval
+	}
+}
@@ -0,0 +1,45 @@
+package certificate_reloader_config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	config := New()
+	if config.Interval != DefaultInterval {
+		t.Errorf("interval: got %v", config.Interval)
+	}
+	if config.CertificateFile != "" || config.KeyFile != "" || config.ClientCaFile != "" {
+		t.Errorf("expected no files, got %#v", config)
+	}
+	if config.RequireClientCertificate {
+		t.Error("expected a client certificate not to be required by default")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	config := New(
+		WithCertificateFiles("certificate.pem", "key.pem"),
+		WithClientCaFile("ca.pem"),
+		WithRequireClientCertificate(true),
+		WithInterval(time.Second),
+	)
+
+	if config.CertificateFile != "certificate.pem" || config.KeyFile != "key.pem" {
+		t.Errorf("certificate files: got %q %q", config.CertificateFile, config.KeyFile)
+	}
+	if config.ClientCaFile != "ca.pem" {
+		t.Errorf("client ca file: got %q", config.ClientCaFile)
+	}
+	if !config.RequireClientCertificate {
+		t.Error("expected a client certificate to be required")
+	}
+	if config.Interval != time.Second {
+		t.Errorf("interval: got %v", config.Interval)
+	}
+}
@@ -0,0 +1,316 @@
+package certificate_reloader
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Motmedel/utils_go/pkg/tls/certificate_reloader/certificate_reloader_config"
+)
+
+type authority struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+// issue makes a certificate of the template, signed by the authority or self-signed if it is nil.
+func issue(t *testing.T, signer *authority, template *x509.Certificate) *authority {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa generate key: %v", err)
+	}
+
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	parent, parentKey := template, key
+	if signer != nil {
+		parent, parentKey = signer.certificate, signer.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("x509 create certificate: %v", err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509 parse certificate: %v", err)
+	}
+
+	return &authority{certificate: certificate, key: key}
+}
+
+func newAuthority(t *testing.T, name string) *authority {
+	t.Helper()
+
+	return issue(
+		t,
+		nil,
+		&x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: name},
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		},
+	)
+}
+
+func newClient(t *testing.T, signer *authority) *authority {
+	t.Helper()
+
+	return issue(
+		t,
+		signer,
+		&x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: "client"},
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		},
+	)
+}
+
+func writePem(t *testing.T, path string, blockType string, der []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("os write file: %v", err)
+	}
+}
+
+// writeServer writes a server certificate named name and its key to the files.
+func writeServer(t *testing.T, certificateFile string, keyFile string, name string) {
+	t.Helper()
+
+	server := issue(t, nil, &x509.Certificate{SerialNumber: big.NewInt(3), Subject: pkix.Name{CommonName: name}})
+
+	keyDer, err := x509.MarshalECPrivateKey(server.key)
+	if err != nil {
+		t.Fatalf("x509 marshal ec private key: %v", err)
+	}
+
+	writePem(t, certificateFile, "CERTIFICATE", server.certificate.Raw)
+	writePem(t, keyFile, "EC PRIVATE KEY", keyDer)
+}
+
+func servedName(t *testing.T, reloader *Reloader) string {
+	t.Helper()
+
+	certificate, err := reloader.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("get certificate: %v", err)
+	}
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		t.Fatalf("x509 parse certificate: %v", err)
+	}
+
+	return leaf.Subject.CommonName
+}
+
+func TestReloader_Reload(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+	certificateFile := filepath.Join(directory, "certificate.pem")
+	keyFile := filepath.Join(directory, "key.pem")
+	writeServer(t, certificateFile, keyFile, "first")
+
+	reloader, err := New(certificate_reloader_config.WithCertificateFiles(certificateFile, keyFile))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if name := servedName(t, reloader); name != "first" {
+		t.Fatalf("served: got %q", name)
+	}
+
+	if reloaded, err := reloader.reload(false); err != nil || reloaded {
+		t.Fatalf("expected unchanged files not to be reloaded, got %v %v", reloaded, err)
+	}
+
+	writeServer(t, certificateFile, keyFile, "second")
+	if reloaded, err := reloader.reload(false); err != nil || !reloaded {
+		t.Fatalf("expected changed files to be reloaded, got %v %v", reloaded, err)
+	}
+	if name := servedName(t, reloader); name != "second" {
+		t.Fatalf("served: got %q", name)
+	}
+
+	// A key that does not match the certificate leaves the last loaded in place.
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0600); err != nil {
+		t.Fatalf("os write file: %v", err)
+	}
+	if err := reloader.Reload(); err == nil {
+		t.Fatal("expected an error for an invalid key")
+	}
+	if name := servedName(t, reloader); name != "second" {
+		t.Fatalf("served: got %q", name)
+	}
+}
+
+func TestReloader_Watch(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+	certificateFile := filepath.Join(directory, "certificate.pem")
+	keyFile := filepath.Join(directory, "key.pem")
+	writeServer(t, certificateFile, keyFile, "first")
+
+	reloader, err := New(
+		certificate_reloader_config.WithCertificateFiles(certificateFile, keyFile),
+		certificate_reloader_config.WithInterval(10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	go reloader.Watch(t.Context())
+
+	writeServer(t, certificateFile, keyFile, "second")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for servedName(t, reloader) != "second" {
+		if time.Now().After(deadline) {
+			t.Fatal("the change was never picked up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloader_VerifyConnection(t *testing.T) {
+	t.Parallel()
+
+	trusted := newAuthority(t, "trusted")
+	untrusted := newAuthority(t, "untrusted")
+
+	clientCaFile := filepath.Join(t.TempDir(), "ca.pem")
+	writePem(t, clientCaFile, "CERTIFICATE", trusted.certificate.Raw)
+
+	testCases := []struct {
+		name     string
+		require  bool
+		presents *authority
+		wantErr  error
+		wantOk   bool
+	}{
+		{
+			name:     "trusted",
+			presents: newClient(t, trusted),
+			wantOk:   true,
+		},
+		{
+			name:     "untrusted",
+			presents: newClient(t, untrusted),
+		},
+		{
+			name:   "none, optional",
+			wantOk: true,
+		},
+		{
+			name:    "none, required",
+			require: true,
+			wantErr: ErrNoClientCertificate,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			reloader, err := New(
+				certificate_reloader_config.WithClientCaFile(clientCaFile),
+				certificate_reloader_config.WithRequireClientCertificate(testCase.require),
+			)
+			if err != nil {
+				t.Fatalf("new: %v", err)
+			}
+
+			tlsConfig := reloader.TlsConfig()
+			if tlsConfig.ClientAuth != tls.RequestClientCert || tlsConfig.GetCertificate != nil {
+				t.Errorf("unexpected tls config: %v", tlsConfig.ClientAuth)
+			}
+
+			var connectionState tls.ConnectionState
+			if testCase.presents != nil {
+				connectionState.PeerCertificates = []*x509.Certificate{testCase.presents.certificate}
+			}
+
+			err = reloader.VerifyConnection(connectionState)
+			if testCase.wantOk {
+				if err != nil {
+					t.Fatalf("verify connection: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if testCase.wantErr != nil && !errors.Is(err, testCase.wantErr) {
+				t.Errorf("expected %v, got %v", testCase.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+	emptyFile := filepath.Join(directory, "empty.pem")
+	if err := os.WriteFile(emptyFile, nil, 0600); err != nil {
+		t.Fatalf("os write file: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		options []certificate_reloader_config.Option
+		wantErr error
+	}{
+		{
+			name: "nothing",
+		},
+		{
+			name:    "certificate without key",
+			options: []certificate_reloader_config.Option{certificate_reloader_config.WithCertificateFiles("certificate.pem", "")},
+			wantErr: ErrCertificateFilesPartial,
+		},
+		{
+			name:    "missing files",
+			options: []certificate_reloader_config.Option{certificate_reloader_config.WithCertificateFiles(filepath.Join(directory, "a"), filepath.Join(directory, "b"))},
+			wantErr: os.ErrNotExist,
+		},
+		{
+			name:    "empty client ca bundle",
+			options: []certificate_reloader_config.Option{certificate_reloader_config.WithClientCaFile(emptyFile)},
+			wantErr: ErrNoClientCaCertificate,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := New(testCase.options...)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if testCase.wantErr != nil && !errors.Is(err, testCase.wantErr) {
+				t.Errorf("expected %v, got %v", testCase.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,497 @@
+// Package ocsp makes Online Certificate Status Protocol (RFC 6960) requests and checks their
+// responses, for a server that staples the status of its certificate to the handshake.
+package ocsp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/Motmedel/utils_go/pkg/errors/types/empty_error"
+	"github.com/Motmedel/utils_go/pkg/errors/types/nil_error"
+	"github.com/Motmedel/utils_go/pkg/http/types/fetch_config"
+	motmedelHttpUtils "github.com/Motmedel/utils_go/pkg/http/utils"
+)
+
+const (
+	RequestContentType  = "application/ocsp-request"
+	ResponseContentType = "application/ocsp-response"
+)
+
+var (
+	ErrNoServer             = errors.New("no ocsp server")
+	ErrUnsuccessfulResponse = errors.New("unsuccessful ocsp response")
+	ErrCertificateMismatch  = errors.New("ocsp response for another certificate")
+	ErrUnsupportedAlgorithm = errors.New("unsupported ocsp signature algorithm")
+)
+
+// Status is the status a response gives a certificate.
+type Status int
+
+const (
+	StatusGood Status = iota
+	StatusRevoked
+	StatusUnknown
+)
+
+func (status Status) String() string {
+	switch status {
+	case StatusGood:
+		return "good"
+	case StatusRevoked:
+		return "revoked"
+	default:
+		return "unknown"
+	}
+}
+
+// Response is a checked OCSP response. Raw is what is stapled.
+type Response struct {
+	Status       Status
+	SerialNumber *big.Int
+	ProducedAt   time.Time
+	ThisUpdate   time.Time
+	NextUpdate   time.Time
+	RevokedAt    time.Time
+	Raw          []byte
+}
+
+var (
+	oidSha1            = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidBasicResponse   = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	oidToSignatureAlgo = map[string]x509.SignatureAlgorithm{
+		"1.2.840.113549.1.1.11": x509.SHA256WithRSA,
+		"1.2.840.113549.1.1.12": x509.SHA384WithRSA,
+		"1.2.840.113549.1.1.13": x509.SHA512WithRSA,
+		"1.2.840.10045.4.3.2":   x509.ECDSAWithSHA256,
+		"1.2.840.10045.4.3.3":   x509.ECDSAWithSHA384,
+		"1.2.840.10045.4.3.4":   x509.ECDSAWithSHA512,
+		"1.3.101.112":           x509.PureEd25519,
+	}
+)
+
+type certId struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+type singleRequest struct {
+	Cert certId
+}
+
+type tbsRequest struct {
+	Version     int `asn1:"explicit,tag:0,default:0,optional"`
+	RequestList []singleRequest
+}
+
+type ocspRequest struct {
+	TbsRequest tbsRequest
+}
+
+type responseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type ocspResponse struct {
+	Status        asn1.Enumerated
+	ResponseBytes responseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type revokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+type singleResponse struct {
+	CertId           certId
+	Good             asn1.Flag        `asn1:"tag:0,optional"`
+	Revoked          revokedInfo      `asn1:"tag:1,optional"`
+	Unknown          asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate       time.Time        `asn1:"generalized"`
+	NextUpdate       time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	SingleExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type responseData struct {
+	Raw                asn1.RawContent
+	Version            int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderId     asn1.RawValue
+	ProducedAt         time.Time `asn1:"generalized"`
+	Responses          []singleResponse
+	ResponseExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type basicResponse struct {
+	TbsResponseData    responseData
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+func makeCertId(certificate *x509.Certificate, issuer *x509.Certificate) (*certId, error) {
+	var publicKeyInfo subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("asn1 unmarshal (issuer public key info): %w", err))
+	}
+
+	// RFC 6960 identifies the issuer by SHA-1 hashes, which is what responders are asked with.
+	issuerNameHash := sha1.Sum(issuer.RawSubject)
+	issuerKeyHash := sha1.Sum(publicKeyInfo.PublicKey.RightAlign())
+
+	return &certId{
+		HashAlgorithm:  pkix.AlgorithmIdentifier{Algorithm: oidSha1, Parameters: asn1.NullRawValue},
+		IssuerNameHash: issuerNameHash[:],
+		IssuerKeyHash:  issuerKeyHash[:],
+		SerialNumber:   certificate.SerialNumber,
+	}, nil
+}
+
+// MakeRequest makes the DER-encoded request for the status of certificate, issued by issuer.
+func MakeRequest(certificate *x509.Certificate, issuer *x509.Certificate) ([]byte, error) {
+	if certificate == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("certificate"))
+	}
+
+	if issuer == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("issuer"))
+	}
+
+	id, err := makeCertId(certificate, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("make cert id: %w", err)
+	}
+
+	data, err := asn1.Marshal(ocspRequest{TbsRequest: tbsRequest{RequestList: []singleRequest{{Cert: *id}}}})
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("asn1 marshal (ocsp request): %w", err))
+	}
+
+	return data, nil
+}
+
+// verifySignature checks that the response is signed by the issuer, or by a responder the issuer
+// delegated to.
+func verifySignature(basic *basicResponse, issuer *x509.Certificate) error {
+	algorithm, found := oidToSignatureAlgo[basic.SignatureAlgorithm.Algorithm.String()]
+	if !found {
+		return motmedelErrors.NewWithTrace(ErrUnsupportedAlgorithm, basic.SignatureAlgorithm.Algorithm.String())
+	}
+
+	signer := issuer
+	if len(basic.Certificates) != 0 {
+		responder, err := x509.ParseCertificate(basic.Certificates[0].FullBytes)
+		if err != nil {
+			return motmedelErrors.NewWithTrace(
+				fmt.Errorf("%w: x509 parse certificate (responder): %w", motmedelErrors.ErrParseError, err),
+			)
+		}
+
+		if !bytes.Equal(responder.Raw, issuer.Raw) {
+			if err := responder.CheckSignatureFrom(issuer); err != nil {
+				return motmedelErrors.NewWithTrace(
+					fmt.Errorf("%w: responder check signature from: %w", motmedelErrors.ErrVerificationError, err),
+				)
+			}
+
+			var delegated bool
+			for _, extKeyUsage := range responder.ExtKeyUsage {
+				if extKeyUsage == x509.ExtKeyUsageOCSPSigning {
+					delegated = true
+				}
+			}
+			if !delegated {
+				return motmedelErrors.NewWithTrace(
+					fmt.Errorf("%w: the responder is not delegated ocsp signing", motmedelErrors.ErrVerificationError),
+				)
+			}
+		}
+
+		signer = responder
+	}
+
+	if err := signer.CheckSignature(algorithm, basic.TbsResponseData.Raw, basic.Signature.RightAlign()); err != nil {
+		return motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: check signature: %w", motmedelErrors.ErrVerificationError, err),
+		)
+	}
+
+	return nil
+}
+
+// ParseResponse parses a DER-encoded response and checks that it is a signed, current statement
+// about certificate.
+func ParseResponse(data []byte, certificate *x509.Certificate, issuer *x509.Certificate, now time.Time) (*Response, error) {
+	if certificate == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("certificate"))
+	}
+
+	if issuer == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("issuer"))
+	}
+
+	var response ocspResponse
+	if rest, err := asn1.Unmarshal(data, &response); err != nil || len(rest) != 0 {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: asn1 unmarshal (ocsp response): %w", motmedelErrors.ErrParseError, err),
+		)
+	}
+
+	if response.Status != 0 {
+		return nil, motmedelErrors.NewWithTrace(ErrUnsuccessfulResponse, int(response.Status))
+	}
+
+	if !response.ResponseBytes.ResponseType.Equal(oidBasicResponse) {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: response type", motmedelErrors.ErrUnexpectedType),
+			response.ResponseBytes.ResponseType.String(),
+		)
+	}
+
+	var basic basicResponse
+	if rest, err := asn1.Unmarshal(response.ResponseBytes.Response, &basic); err != nil || len(rest) != 0 {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: asn1 unmarshal (basic response): %w", motmedelErrors.ErrParseError, err),
+		)
+	}
+
+	if err := verifySignature(&basic, issuer); err != nil {
+		return nil, fmt.Errorf("verify signature: %w", err)
+	}
+
+	id, err := makeCertId(certificate, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("make cert id: %w", err)
+	}
+
+	var single *singleResponse
+	for i := range basic.TbsResponseData.Responses {
+		candidate := &basic.TbsResponseData.Responses[i]
+		if candidate.CertId.SerialNumber != nil &&
+			candidate.CertId.SerialNumber.Cmp(id.SerialNumber) == 0 &&
+			bytes.Equal(candidate.CertId.IssuerNameHash, id.IssuerNameHash) &&
+			bytes.Equal(candidate.CertId.IssuerKeyHash, id.IssuerKeyHash) {
+			single = candidate
+			break
+		}
+	}
+	if single == nil {
+		return nil, motmedelErrors.NewWithTrace(ErrCertificateMismatch, certificate.SerialNumber)
+	}
+
+	if !single.NextUpdate.IsZero() && !now.Before(single.NextUpdate) {
+		return nil, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: the response is out of date", motmedelErrors.ErrValidationError),
+			single.NextUpdate,
+		)
+	}
+
+	parsed := &Response{
+		SerialNumber: single.CertId.SerialNumber,
+		ProducedAt:   basic.TbsResponseData.ProducedAt,
+		ThisUpdate:   single.ThisUpdate,
+		NextUpdate:   single.NextUpdate,
+		Raw:          data,
+	}
+
+	switch {
+	case bool(single.Good):
+		parsed.Status = StatusGood
+	case !single.Revoked.RevocationTime.IsZero():
+		parsed.Status = StatusRevoked
+		parsed.RevokedAt = single.Revoked.RevocationTime
+	default:
+		parsed.Status = StatusUnknown
+	}
+
+	return parsed, nil
+}
+
+func signatureAlgorithmFor(publicKey crypto.PublicKey) (asn1.ObjectIdentifier, crypto.Hash, error) {
+	switch typedPublicKey := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		switch typedPublicKey.Curve {
+		case elliptic.P384():
+			return asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}, crypto.SHA384, nil
+		case elliptic.P521():
+			return asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}, crypto.SHA512, nil
+		default:
+			return asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}, crypto.SHA256, nil
+		}
+	case *rsa.PublicKey:
+		return asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}, crypto.SHA256, nil
+	case ed25519.PublicKey:
+		return asn1.ObjectIdentifier{1, 3, 101, 112}, 0, nil
+	default:
+		return nil, 0, motmedelErrors.NewWithTrace(
+			fmt.Errorf("%w: %T", ErrUnsupportedAlgorithm, publicKey),
+		)
+	}
+}
+
+// MakeResponse makes a DER-encoded response about certificate, signed by its issuer with signer. It
+// is what a responder answers with; a revoked status is given revokedAt.
+func MakeResponse(
+	certificate *x509.Certificate,
+	issuer *x509.Certificate,
+	signer crypto.Signer,
+	status Status,
+	revokedAt time.Time,
+	thisUpdate time.Time,
+	nextUpdate time.Time,
+) ([]byte, error) {
+	if certificate == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("certificate"))
+	}
+
+	if issuer == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("issuer"))
+	}
+
+	if signer == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("signer"))
+	}
+
+	id, err := makeCertId(certificate, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("make cert id: %w", err)
+	}
+
+	single := singleResponse{CertId: *id, ThisUpdate: thisUpdate.UTC(), NextUpdate: nextUpdate.UTC()}
+	switch status {
+	case StatusGood:
+		single.Good = true
+	case StatusRevoked:
+		single.Revoked = revokedInfo{RevocationTime: revokedAt.UTC()}
+	default:
+		single.Unknown = true
+	}
+
+	responderId, err := asn1.Marshal(id.IssuerKeyHash)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("asn1 marshal (responder id): %w", err))
+	}
+
+	tbs, err := asn1.Marshal(
+		responseData{
+			// The responder is identified by the hash of its key, which is the issuer's.
+			RawResponderId: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: responderId},
+			ProducedAt:     time.Now().UTC().Truncate(time.Second),
+			Responses:      []singleResponse{single},
+		},
+	)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("asn1 marshal (response data): %w", err))
+	}
+
+	algorithm, hash, err := signatureAlgorithmFor(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("signature algorithm for: %w", err)
+	}
+
+	signed := tbs
+	if hash != 0 {
+		hasher := hash.New()
+		hasher.Write(tbs)
+		signed = hasher.Sum(nil)
+	}
+
+	signature, err := signer.Sign(rand.Reader, signed, hash)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("signer sign: %w", err))
+	}
+
+	basicData, err := asn1.Marshal(
+		basicResponse{
+			TbsResponseData:    responseData{Raw: tbs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: algorithm},
+			Signature:          asn1.BitString{Bytes: signature, BitLength: 8 * len(signature)},
+		},
+	)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("asn1 marshal (basic response): %w", err))
+	}
+
+	data, err := asn1.Marshal(
+		ocspResponse{ResponseBytes: responseBytes{ResponseType: oidBasicResponse, Response: basicData}},
+	)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("asn1 marshal (ocsp response): %w", err))
+	}
+
+	return data, nil
+}
+
+// Fetch asks the certificate's OCSP server for its status.
+func Fetch(
+	ctx context.Context,
+	certificate *x509.Certificate,
+	issuer *x509.Certificate,
+	options ...fetch_config.Option,
+) (*Response, error) {
+	if certificate == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("certificate"))
+	}
+
+	if len(certificate.OCSPServer) == 0 {
+		return nil, motmedelErrors.NewWithTrace(ErrNoServer)
+	}
+
+	server := certificate.OCSPServer[0]
+	if server == "" {
+		return nil, motmedelErrors.NewWithTrace(empty_error.New("ocsp server"))
+	}
+
+	request, err := MakeRequest(certificate, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("make request: %w", err)
+	}
+
+	response, responseBody, err := motmedelHttpUtils.Fetch(
+		ctx,
+		server,
+		append(
+			[]fetch_config.Option{
+				fetch_config.WithMethod(http.MethodPost),
+				fetch_config.WithHeaders(map[string]string{"Content-Type": RequestContentType}),
+				fetch_config.WithBody(request),
+			},
+			options...,
+		)...,
+	)
+	if err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("fetch: %w", err), server)
+	}
+	if response == nil {
+		return nil, motmedelErrors.NewWithTrace(nil_error.New("http response"), server)
+	}
+
+	parsed, err := ParseResponse(responseBody, certificate, issuer, time.Now())
+	if err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("parse response: %w", err), server)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,384 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+)
+
+type testPki struct {
+	issuer         *x509.Certificate
+	issuerKey      *ecdsa.PrivateKey
+	certificate    *x509.Certificate
+	responder      *x509.Certificate
+	responderKey   *ecdsa.PrivateKey
+	undelegated    *x509.Certificate
+	undelegatedKey *ecdsa.PrivateKey
+	otherIssuer    *x509.Certificate
+	otherIssuerKey *ecdsa.PrivateKey
+}
+
+func makeCertificate(
+	t *testing.T,
+	template *x509.Certificate,
+	parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa generate key: %v", err)
+	}
+
+	if parent == nil {
+		parent = template
+		parentKey = key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("x509 create certificate: %v", err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509 parse certificate: %v", err)
+	}
+
+	return certificate, key
+}
+
+func newTestPki(t *testing.T, ocspServer string) *testPki {
+	t.Helper()
+
+	now := time.Now()
+	caTemplate := func(name string) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             now.Add(-time.Hour),
+			NotAfter:              now.Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+	}
+
+	var pki testPki
+	pki.issuer, pki.issuerKey = makeCertificate(t, caTemplate("Issuer"), nil, nil)
+	pki.otherIssuer, pki.otherIssuerKey = makeCertificate(t, caTemplate("Other issuer"), nil, nil)
+
+	pki.certificate, _ = makeCertificate(
+		t,
+		&x509.Certificate{
+			SerialNumber: big.NewInt(4711),
+			Subject:      pkix.Name{CommonName: "example.com"},
+			DNSNames:     []string{"example.com"},
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     now.Add(time.Hour),
+			OCSPServer:   []string{ocspServer},
+		},
+		pki.issuer,
+		pki.issuerKey,
+	)
+
+	pki.responder, pki.responderKey = makeCertificate(
+		t,
+		&x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: "Responder"},
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     now.Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		},
+		pki.issuer,
+		pki.issuerKey,
+	)
+
+	pki.undelegated, pki.undelegatedKey = makeCertificate(
+		t,
+		&x509.Certificate{
+			SerialNumber: big.NewInt(3),
+			Subject:      pkix.Name{CommonName: "Undelegated"},
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     now.Add(time.Hour),
+		},
+		pki.issuer,
+		pki.issuerKey,
+	)
+
+	return &pki
+}
+
+type responseSpecification struct {
+	status     asn1.Enumerated
+	serial     *big.Int
+	good       bool
+	revokedAt  time.Time
+	nextUpdate time.Time
+	signer     *ecdsa.PrivateKey
+	included   *x509.Certificate
+	tamper     bool
+}
+
+func makeResponse(t *testing.T, pki *testPki, specification *responseSpecification) []byte {
+	t.Helper()
+
+	if specification.status != 0 {
+		data, err := asn1.Marshal(ocspResponse{Status: specification.status})
+		if err != nil {
+			t.Fatalf("asn1 marshal: %v", err)
+		}
+		return data
+	}
+
+	id, err := makeCertId(pki.certificate, pki.issuer)
+	if err != nil {
+		t.Fatalf("make cert id: %v", err)
+	}
+	if specification.serial != nil {
+		id.SerialNumber = specification.serial
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	single := singleResponse{CertId: *id, ThisUpdate: now, NextUpdate: specification.nextUpdate}
+	switch {
+	case specification.good:
+		single.Good = true
+	case !specification.revokedAt.IsZero():
+		single.Revoked = revokedInfo{RevocationTime: specification.revokedAt}
+	default:
+		single.Unknown = true
+	}
+
+	tbs, err := asn1.Marshal(
+		responseData{
+			RawResponderId: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: []byte{4, 0}},
+			ProducedAt:     now,
+			Responses:      []singleResponse{single},
+		},
+	)
+	if err != nil {
+		t.Fatalf("asn1 marshal (tbs): %v", err)
+	}
+
+	digest := sha256.Sum256(tbs)
+	signature, err := specification.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if specification.tamper {
+		signature[len(signature)-1] ^= 1
+	}
+
+	basic := basicResponse{
+		TbsResponseData:    responseData{Raw: tbs},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+		Signature:          asn1.BitString{Bytes: signature, BitLength: 8 * len(signature)},
+	}
+	if specification.included != nil {
+		basic.Certificates = []asn1.RawValue{{FullBytes: specification.included.Raw}}
+	}
+
+	basicData, err := asn1.Marshal(basic)
+	if err != nil {
+		t.Fatalf("asn1 marshal (basic): %v", err)
+	}
+
+	data, err := asn1.Marshal(
+		ocspResponse{ResponseBytes: responseBytes{ResponseType: oidBasicResponse, Response: basicData}},
+	)
+	if err != nil {
+		t.Fatalf("asn1 marshal (response): %v", err)
+	}
+
+	return data
+}
+
+func TestParseResponse(t *testing.T) {
+	t.Parallel()
+
+	pki := newTestPki(t, "http://ocsp.example.com")
+	revokedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name           string
+		specification  *responseSpecification
+		expectedStatus Status
+		expectedErr    error
+	}{
+		{
+			name:           "good, signed by the issuer",
+			specification:  &responseSpecification{good: true, signer: pki.issuerKey, nextUpdate: time.Now().Add(time.Hour).UTC()},
+			expectedStatus: StatusGood,
+		},
+		{
+			name:           "good, signed by a delegated responder",
+			specification:  &responseSpecification{good: true, signer: pki.responderKey, included: pki.responder},
+			expectedStatus: StatusGood,
+		},
+		{
+			name:           "revoked",
+			specification:  &responseSpecification{revokedAt: revokedAt, signer: pki.issuerKey},
+			expectedStatus: StatusRevoked,
+		},
+		{
+			name:           "unknown",
+			specification:  &responseSpecification{signer: pki.issuerKey},
+			expectedStatus: StatusUnknown,
+		},
+		{
+			name:          "unsuccessful",
+			specification: &responseSpecification{status: 6},
+			expectedErr:   ErrUnsuccessfulResponse,
+		},
+		{
+			name:          "another certificate",
+			specification: &responseSpecification{good: true, serial: big.NewInt(1), signer: pki.issuerKey},
+			expectedErr:   ErrCertificateMismatch,
+		},
+		{
+			name:          "out of date",
+			specification: &responseSpecification{good: true, signer: pki.issuerKey, nextUpdate: time.Now().Add(-time.Minute).UTC()},
+			expectedErr:   motmedelErrors.ErrValidationError,
+		},
+		{
+			name:          "tampered",
+			specification: &responseSpecification{good: true, signer: pki.issuerKey, tamper: true},
+			expectedErr:   motmedelErrors.ErrVerificationError,
+		},
+		{
+			name:          "signed by another issuer",
+			specification: &responseSpecification{good: true, signer: pki.otherIssuerKey, included: pki.otherIssuer},
+			expectedErr:   motmedelErrors.ErrVerificationError,
+		},
+		{
+			name:          "signed by an undelegated responder",
+			specification: &responseSpecification{good: true, signer: pki.undelegatedKey, included: pki.undelegated},
+			expectedErr:   motmedelErrors.ErrVerificationError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := makeResponse(t, pki, testCase.specification)
+
+			response, err := ParseResponse(data, pki.certificate, pki.issuer, time.Now())
+			if testCase.expectedErr != nil {
+				if !errors.Is(err, testCase.expectedErr) {
+					t.Fatalf("error: got %v, want %v", err, testCase.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse response: %v", err)
+			}
+
+			if response.Status != testCase.expectedStatus {
+				t.Errorf("status: got %s, want %s", response.Status, testCase.expectedStatus)
+			}
+			if response.SerialNumber.Cmp(pki.certificate.SerialNumber) != 0 {
+				t.Errorf("serial number: got %v", response.SerialNumber)
+			}
+			if testCase.expectedStatus == StatusRevoked && !response.RevokedAt.Equal(revokedAt) {
+				t.Errorf("revoked at: got %v, want %v", response.RevokedAt, revokedAt)
+			}
+			if string(response.Raw) != string(data) {
+				t.Error("the raw response is not what was parsed")
+			}
+		})
+	}
+}
+
+func TestParseResponse_Malformed(t *testing.T) {
+	t.Parallel()
+
+	pki := newTestPki(t, "http://ocsp.example.com")
+
+	if _, err := ParseResponse([]byte{0x30, 0x01}, pki.certificate, pki.issuer, time.Now()); !errors.Is(err, motmedelErrors.ErrParseError) {
+		t.Errorf("error: got %v, want a parse error", err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+
+	var pki *testPki
+	server := httptest.NewServer(
+		http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+			body, _ := io.ReadAll(request.Body)
+
+			expected, err := MakeRequest(pki.certificate, pki.issuer)
+			if err != nil || request.Header.Get("Content-Type") != RequestContentType || string(body) != string(expected) {
+				responseWriter.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			responseWriter.Header().Set("Content-Type", ResponseContentType)
+			_, _ = responseWriter.Write(makeResponse(t, pki, &responseSpecification{good: true, signer: pki.issuerKey}))
+		}),
+	)
+	t.Cleanup(server.Close)
+
+	pki = newTestPki(t, server.URL)
+
+	response, err := Fetch(t.Context(), pki.certificate, pki.issuer)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if response.Status != StatusGood {
+		t.Errorf("status: got %s", response.Status)
+	}
+
+	withoutServer := *pki.certificate
+	withoutServer.OCSPServer = nil
+	if _, err := Fetch(t.Context(), &withoutServer, pki.issuer); !errors.Is(err, ErrNoServer) {
+		t.Errorf("error: got %v, want %v", err, ErrNoServer)
+	}
+}
+
+func TestMakeResponse(t *testing.T) {
+	t.Parallel()
+
+	pki := newTestPki(t, "http://ocsp.example.com")
+	now := time.Now().Truncate(time.Second)
+
+	for _, status := range []Status{StatusGood, StatusRevoked, StatusUnknown} {
+		t.Run(status.String(), func(t *testing.T) {
+			t.Parallel()
+
+			data, err := MakeResponse(pki.certificate, pki.issuer, pki.issuerKey, status, now.Add(-time.Minute), now, now.Add(time.Hour))
+			if err != nil {
+				t.Fatalf("make response: %v", err)
+			}
+
+			response, err := ParseResponse(data, pki.certificate, pki.issuer, now)
+			if err != nil {
+				t.Fatalf("parse response: %v", err)
+			}
+			if response.Status != status {
+				t.Errorf("status: got %s, want %s", response.Status, status)
+			}
+			if !response.NextUpdate.Equal(now.Add(time.Hour)) {
+				t.Errorf("next update: got %v", response.NextUpdate)
+			}
+		})
+	}
+}
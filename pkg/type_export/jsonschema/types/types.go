@@ -17,8 +17,22 @@ import (
 	"github.com/Motmedel/utils_go/pkg/utils"
 )
 
+// DefaultDefinitionsRefPrefix is where a reference to a declared type points by default: the local
+// $defs of the rendered schema document.
+const DefaultDefinitionsRefPrefix = "#/$defs/"
+
 type Context struct {
 	*typeExportContext.Context
+	// DefinitionsRefPrefix is prepended to a declared type's identifier to reference it; a document
+	// embedding the definitions elsewhere, such as under an OpenAPI document's components, sets it.
+	DefinitionsRefPrefix string
+}
+
+func (c *Context) definitionsRefPrefix() string {
+	if c.DefinitionsRefPrefix == "" {
+		return DefaultDefinitionsRefPrefix
+	}
+	return c.DefinitionsRefPrefix
 }
 
 // JSON Schema type names.
@@ -51,7 +65,7 @@ func (c *Context) GetJSONSchemaType(reflectType reflect.Type) (map[string]any, e
 		typeDeclaration, ok := c.TypeDeclarations[reflectType]
 		if ok {
 			if iface, ok2 := typeDeclaration.(*type_declaration.InterfaceDeclaration); ok2 {
-				return map[string]any{"$ref": "#/$defs/" + iface.QualifiedName()}, nil
+				return map[string]any{"$ref": c.definitionsRefPrefix() + iface.QualifiedName()}, nil
 			}
 		}
 		return nil, motmedelErrors.NewWithTrace(typeExportErrors.ErrUnsupportedKind, kind)
@@ -305,6 +319,26 @@ func (c *Context) buildInterfaceSchema(interfaceDeclaration *type_declaration.In
 	return schemaMap, nil
 }
 
+// BuildDefinitions builds the object schema of every discovered interface, keyed by its identifier.
+func (c *Context) BuildDefinitions() (map[string]any, error) {
+	defs := map[string]any{}
+	for _, typeDeclaration := range c.TypeDeclarationsInOrder {
+		interfaceDeclaration, ok := typeDeclaration.(*type_declaration.InterfaceDeclaration)
+		if !ok || interfaceDeclaration == nil {
+			continue
+		}
+
+		schema, err := c.buildInterfaceSchema(interfaceDeclaration)
+		if err != nil {
+			return nil, motmedelErrors.New(fmt.Errorf("build interface schema: %w", err), interfaceDeclaration)
+		}
+
+		defs[interfaceDeclaration.Identifier] = schema
+	}
+
+	return defs, nil
+}
+
 // RenderRoot builds a single JSON Schema document with the provided root type as the top-level schema
 // and all discovered interfaces included under $defs. References use local $refs to $defs.
 // If root is a slice or array of structs, the top-level schema describes an array whose items
@@ -346,20 +380,9 @@ func (c *Context) RenderRoot(root reflect.Type) (string, error) {
 		)
 	}
 
-	// Build $defs for all interfaces
-	defs := map[string]any{}
-	for _, typeDeclaration := range c.TypeDeclarationsInOrder {
-		interfaceDeclaration, ok := typeDeclaration.(*type_declaration.InterfaceDeclaration)
-		if !ok || interfaceDeclaration == nil {
-			continue
-		}
-
-		schema, err := c.buildInterfaceSchema(interfaceDeclaration)
-		if err != nil {
-			return "", motmedelErrors.New(fmt.Errorf("build interface schema: %w", err), interfaceDeclaration)
-		}
-
-		defs[interfaceDeclaration.Identifier] = schema
+	defs, err := c.BuildDefinitions()
+	if err != nil {
+		return "", fmt.Errorf("build definitions: %w", err)
 	}
 
 	rootInterfaceDeclarationIdentifier := rootInterfaceDeclaration.Identifier
@@ -372,11 +395,11 @@ func (c *Context) RenderRoot(root reflect.Type) (string, error) {
 	if isArray {
 		schemaMap["title"] = rootInterfaceDeclarationIdentifier + "Array"
 		schemaMap["type"] = schemaTypeArray
-		schemaMap["items"] = map[string]any{"$ref": "#/$defs/" + rootInterfaceDeclarationIdentifier}
+		schemaMap["items"] = map[string]any{"$ref": c.definitionsRefPrefix() + rootInterfaceDeclarationIdentifier}
 	} else {
 		schemaMap["title"] = rootInterfaceDeclarationIdentifier
 		// Reference the root schema via $defs to avoid duplicating the object at the top level
-		schemaMap["$ref"] = "#/$defs/" + rootInterfaceDeclarationIdentifier
+		schemaMap["$ref"] = c.definitionsRefPrefix() + rootInterfaceDeclarationIdentifier
 	}
 
 	data, err := json.Marshal(schemaMap)
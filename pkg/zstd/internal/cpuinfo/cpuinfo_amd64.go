@@ -0,0 +1,12 @@
+// Code generated by vendoring github.com/klauspost/compress v1.20.1 (BSD-3-Clause, see LICENSE); DO NOT EDIT.
+
+//go:build amd64 && !appengine && !noasm && gc
+
+package cpuinfo
+
+// go:noescape
+func x86extensions() (bmi1, bmi2 bool)
+
+func init() {
+	hasBMI1, hasBMI2 = x86extensions()
+}
@@ -0,0 +1,52 @@
+package zstd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// The vendored implementation is covered thoroughly upstream; this exercises
+// the round trip used by the facade.
+func TestVendoredRoundTrip(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: []byte{}},
+		{name: "text", data: []byte(strings.Repeat("vendored round trip ", 100))},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			var buffer bytes.Buffer
+			writer, err := NewWriter(&buffer, WithEncoderConcurrency(1))
+			if err != nil {
+				t.Fatalf("new writer: %v", err)
+			}
+			if _, err := writer.Write(testCase.data); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+
+			reader, err := NewReader(&buffer, WithDecoderConcurrency(1))
+			if err != nil {
+				t.Fatalf("new reader: %v", err)
+			}
+			defer reader.Close()
+
+			decompressed, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("read all: %v", err)
+			}
+			if !bytes.Equal(decompressed, testCase.data) {
+				t.Error("expected the decompressed data to equal the input")
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Package zstd wraps the Zstandard implementation used for compression, keeping the rest of the
+// module independent of the underlying library.
+//
+// The implementation is a vendored copy of github.com/klauspost/compress/zstd (the established
+// pure-Go implementation), together with the packages of that module it depends on, under
+// internal/, kept verbatim with its license so it stays diffable against upstream. The upstream
+// zip.go (a zip compressor pulling the module's zip package) and snappy.go (a Snappy converter
+// pulling its Snappy package) are omitted. A compression codec is correctness critical, so it is
+// wrapped rather than rewritten; only this facade is part of the module's API.
+package zstd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/Motmedel/utils_go/pkg/zstd/internal/zstd"
+
+	motmedelContext "github.com/Motmedel/utils_go/pkg/context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+)
+
+const (
+	BestSpeed          = 1
+	DefaultCompression = 3
+	BestCompression    = 22
+)
+
+// DefaultMaxWindowSize is the largest window a reader accepts, bounding the memory a stream can make
+// it allocate.
+const DefaultMaxWindowSize = 8 << 20
+
+func MakeZstdData(ctx context.Context, data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	level := BestCompression
+	zstdWriter, err := NewWriter(&buffer, level)
+	if err != nil {
+		return nil, motmedelErrors.New(fmt.Errorf("new writer: %w", err), level)
+	}
+
+	closed := false
+	defer func() {
+		if closed {
+			return
+		}
+		if err := zstdWriter.Close(); err != nil {
+			slog.WarnContext(
+				motmedelContext.WithError(
+					ctx,
+					motmedelErrors.NewWithTrace(fmt.Errorf("zstd writer close: %w", err)),
+				),
+				"An error occurred when closing a zstd writer.",
+			)
+		}
+	}()
+
+	if _, err := zstdWriter.Write(data); err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("zstd writer write: %w", err), level)
+	}
+
+	closed = true
+	if err := zstdWriter.Close(); err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("zstd writer close: %w", err))
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Writer compresses what is written to it into an underlying writer.
+type Writer interface {
+	io.WriteCloser
+	// Flush compresses and writes out what has been written so far, so that a reader can decompress
+	// it without waiting for more.
+	Flush() error
+}
+
+// NewWriter returns a writer that compresses data into the provided writer at a level from 1
+// (fastest) to 22 (smallest), which is mapped to the nearest level the implementation has. The
+// writer compresses on the calling goroutine and must be closed to complete the data.
+func NewWriter(writer io.Writer, level int) (Writer, error) {
+	encoder, err := zstd.NewWriter(
+		writer,
+		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+		zstd.WithEncoderConcurrency(1),
+	)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("zstd new writer: %w", err), level)
+	}
+
+	return encoder, nil
+}
+
+// NewReader returns a reader that decompresses Zstandard data from the provided reader. The reader
+// decompresses on the calling goroutine and should be closed to release its resources.
+func NewReader(reader io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(
+		reader,
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxWindow(DefaultMaxWindowSize),
+	)
+	if err != nil {
+		return nil, motmedelErrors.NewWithTrace(fmt.Errorf("zstd new reader: %w", err))
+	}
+
+	return decoder.IOReadCloser(), nil
+}
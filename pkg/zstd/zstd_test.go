@@ -0,0 +1,110 @@
+package zstd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func decompress(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	reader, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("new reader: %v", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read all: %v", err)
+	}
+
+	return decompressed
+}
+
+func TestMakeZstdData(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: []byte{}},
+		{name: "short text", data: []byte("hello")},
+		{name: "compressible text", data: []byte(strings.Repeat("The quick brown fox jumps over the lazy dog. ", 100))},
+		{name: "binary", data: bytes.Repeat([]byte{0x00, 0xff, 0x10, 0x20}, 256)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			compressed, err := MakeZstdData(context.Background(), testCase.data)
+			if err != nil {
+				t.Fatalf("make zstd data: %v", err)
+			}
+
+			if decompressed := decompress(t, compressed); !bytes.Equal(decompressed, testCase.data) {
+				t.Error("expected the decompressed data to equal the input")
+			}
+		})
+	}
+}
+
+func TestMakeZstdDataBeatsSize(t *testing.T) {
+	t.Parallel()
+	data := []byte(strings.Repeat(`{"id":1,"name":"repetitive json"},`, 200))
+
+	compressed, err := MakeZstdData(context.Background(), data)
+	if err != nil {
+		t.Fatalf("make zstd data: %v", err)
+	}
+	if len(compressed) >= len(data) {
+		t.Errorf("expected the compressed data (%d) to be smaller than the input (%d)", len(compressed), len(data))
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	t.Parallel()
+	data := []byte(strings.Repeat("streamed ", 100))
+
+	var buffer bytes.Buffer
+	writer, err := NewWriter(&buffer, DefaultCompression)
+	if err != nil {
+		t.Fatalf("new writer: %v", err)
+	}
+	if _, err := writer.Write(data[:400]); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if buffer.Len() == 0 {
+		t.Fatal("expected a flush to write out data")
+	}
+	if _, err := writer.Write(data[400:]); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if decompressed := decompress(t, buffer.Bytes()); !bytes.Equal(decompressed, data) {
+		t.Error("expected the decompressed data to equal the input")
+	}
+}
+
+func TestNewReader_Malformed(t *testing.T) {
+	t.Parallel()
+
+	reader, err := NewReader(strings.NewReader("not zstd"))
+	if err != nil {
+		t.Fatalf("new reader: %v", err)
+	}
+	defer reader.Close()
+
+	if _, err := io.ReadAll(reader); err == nil {
+		t.Error("expected an error for malformed data")
+	}
+}